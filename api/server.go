@@ -19,13 +19,14 @@ import (
 	"go.k6.io/k6/metrics/engine"
 )
 
-func newHandler(cs *v1.ControlSurface, profilingEnabled bool) http.Handler {
+func newHandler(cs *v1.ControlSurface, profilingEnabled, metricsEndpointEnabled bool) http.Handler {
 	mux := http.NewServeMux()
 	mux.Handle("/v1/", v1.NewHandler(cs))
 	mux.Handle("/ping", handlePing(cs.RunState.Logger))
 	mux.Handle("/", handlePing(cs.RunState.Logger))
 
 	injectProfilerHandler(mux, profilingEnabled)
+	injectMetricsHandler(mux, cs, metricsEndpointEnabled)
 
 	return mux
 }
@@ -46,11 +47,30 @@ func injectProfilerHandler(mux *http.ServeMux, profilingEnabled bool) {
 	mux.Handle("/debug/vars/", expvar.Handler())
 }
 
+func injectMetricsHandler(mux *http.ServeMux, cs *v1.ControlSurface, metricsEndpointEnabled bool) {
+	var handler http.Handler
+
+	// The endpoint responds with an error when it's disabled, so scrapers don't
+	// consider the ping response as an empty set of metrics
+	handler = http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.Header().Add("Content-Type", "text/plain; charset=utf-8")
+		rw.WriteHeader(http.StatusNotFound)
+		_, _ = rw.Write([]byte("To enable the metrics endpoint, please run k6 with the --metrics-endpoint-enabled flag"))
+	})
+
+	if metricsEndpointEnabled {
+		handler = v1.NewPrometheusHandler(cs)
+	}
+
+	mux.Handle("/metrics", handler)
+}
+
 // GetServer returns a http.Server instance that can serve k6's REST API.
 func GetServer(
 	runCtx context.Context,
 	addr string,
 	profilingEnabled bool,
+	metricsEndpointEnabled bool,
 	runState *lib.TestRunState,
 	samples chan metrics.SampleContainer,
 	me *engine.MetricsEngine,
@@ -66,7 +86,7 @@ func GetServer(
 		RunState:      runState,
 	}
 
-	mux := withLoggingHandler(runState.Logger, newHandler(cs, profilingEnabled))
+	mux := withLoggingHandler(runState.Logger, newHandler(cs, profilingEnabled, metricsEndpointEnabled))
 	return &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
 }
 
@@ -80,6 +100,12 @@ func (w *wrappedResponseWriter) WriteHeader(status int) {
 	w.ResponseWriter.WriteHeader(w.status)
 }
 
+// Unwrap returns the original response writer, so the handlers can use its
+// optional features, like flushing the streamed responses.
+func (w *wrappedResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 // withLoggingHandler returns the middleware which logs response status for request.
 func withLoggingHandler(l logrus.FieldLogger, next http.Handler) http.HandlerFunc {
 	return func(rw http.ResponseWriter, r *http.Request) {
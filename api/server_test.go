@@ -71,3 +71,17 @@ func TestPing(t *testing.T) {
 	assert.Equal(t, []byte{'o', 'k'}, rw.Body.Bytes())
 	assert.NoError(t, res.Body.Close())
 }
+
+func TestMetricsEndpointDisabled(t *testing.T) {
+	t.Parallel()
+	mux := http.NewServeMux()
+	injectMetricsHandler(mux, nil, false)
+
+	rw := httptest.NewRecorder()
+	mux.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	res := rw.Result()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	assert.Contains(t, rw.Body.String(), "--metrics-endpoint-enabled")
+	assert.NoError(t, res.Body.Close())
+}
@@ -2,11 +2,14 @@ package v1
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	"go.k6.io/k6/execution"
 	"go.k6.io/k6/lib"
 	"go.k6.io/k6/metrics"
 	"go.k6.io/k6/metrics/engine"
+	"go.k6.io/k6/output"
 )
 
 // ControlSurface includes the methods the REST API can use to control and
@@ -17,4 +20,14 @@ type ControlSurface struct {
 	MetricsEngine *engine.MetricsEngine
 	Scheduler     *execution.Scheduler
 	RunState      *lib.TestRunState
+
+	// runTagsMx guards the run tags, after they were changed from the REST API
+	runTagsMx sync.Mutex
+	runTags   map[string]string
+}
+
+// annotate sends an annotation about a change from the REST API to the
+// outputs, unless the test run is already done.
+func (cs *ControlSurface) annotate(text string, data any) {
+	metrics.PushIfNotDone(cs.RunCtx, cs.Samples, &output.Annotation{Time: time.Now(), Text: text, Data: data})
 }
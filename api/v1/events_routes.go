@@ -0,0 +1,210 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"go.k6.io/k6/event"
+)
+
+// The names of the Server-Sent Events sent on the /v1/events stream.
+const (
+	sseEventStatus    = "status"
+	sseEventMetrics   = "metrics"
+	sseEventThreshold = "threshold"
+	sseEventTestStart = "test-start"
+	sseEventTestEnd   = "test-end"
+	sseEventExit      = "exit"
+)
+
+// defaultEventsInterval is how often the status and the metrics are checked for
+// changes, unless a different interval is requested with the query string.
+const defaultEventsInterval = time.Second
+
+// ThresholdEvent is the data of the threshold event, which is sent when the
+// thresholds of a metric are breached.
+type ThresholdEvent struct {
+	Metric     string   `json:"metric"`
+	Thresholds []string `json:"thresholds"`
+}
+
+// LifecycleEvent is the data of the events that are sent when the test
+// starts, ends and when k6 is about to exit.
+type LifecycleEvent struct {
+	Error string `json:"error,omitempty"`
+}
+
+// eventStream writes the Server-Sent Events of a single client and keeps track
+// of what was already sent to it, so only the changes are sent.
+type eventStream struct {
+	cs *ControlSurface
+	rw http.ResponseWriter
+	rc *http.ResponseController
+
+	lastStatus *Status
+	breached   map[string]bool
+}
+
+func handleGetEvents(cs *ControlSurface, rw http.ResponseWriter, r *http.Request) {
+	interval := defaultEventsInterval
+	if v := r.URL.Query().Get("interval"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			apiError(rw, "Invalid interval", fmt.Sprintf("%q isn't a valid positive duration", v), http.StatusBadRequest)
+			return
+		}
+		interval = d
+	}
+
+	// A nil channel is never ready, so the stream works without the events
+	// system as well, only the lifecycle events aren't sent
+	var lifecycle <-chan *event.Event
+	if cs.RunState.Events != nil {
+		var subID uint64
+		subID, lifecycle = cs.RunState.Events.Subscribe(event.TestStart, event.TestEnd, event.Exit)
+		defer func() {
+			// Emit() blocks while the channel is full, so it's drained until
+			// Unsubscribe() closes it, otherwise they could deadlock
+			go func(ch <-chan *event.Event) {
+				for evt := range ch {
+					evt.Done()
+				}
+			}(lifecycle)
+			cs.RunState.Events.Unsubscribe(subID)
+		}()
+	}
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.WriteHeader(http.StatusOK)
+
+	stream := &eventStream{
+		cs:       cs,
+		rw:       rw,
+		rc:       http.NewResponseController(rw),
+		breached: make(map[string]bool),
+	}
+	if err := stream.update(); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			if err := stream.update(); err != nil {
+				return
+			}
+		case evt, ok := <-lifecycle:
+			if !ok {
+				return
+			}
+			evt.Done()
+			// The latest values are sent first, so the clients see the
+			// final ones before the test-end and the exit events
+			if err := stream.update(); err != nil {
+				return
+			}
+			if err := stream.lifecycle(evt); err != nil || evt.Type == event.Exit {
+				return
+			}
+		}
+	}
+}
+
+// update sends the status if it changed since it was last sent, a snapshot of
+// the metrics and the thresholds that were breached since the last update.
+func (s *eventStream) update() error {
+	if s.cs.Scheduler != nil {
+		status := newStatus(s.cs)
+		if s.lastStatus == nil || *s.lastStatus != status {
+			if err := s.send(sseEventStatus, NewStatusJSONAPI(status)); err != nil {
+				return err
+			}
+			s.lastStatus = &status
+		}
+	}
+
+	var t time.Duration
+	if s.cs.Scheduler != nil {
+		t = s.cs.Scheduler.GetState().GetCurrentTestRunDuration()
+	}
+	s.cs.MetricsEngine.MetricsLock.Lock()
+	metrics := newMetricsJSONAPI(s.cs.MetricsEngine.ObservedMetrics, t)
+	breached := s.newlyBreachedThresholds()
+	s.cs.MetricsEngine.MetricsLock.Unlock()
+
+	if err := s.send(sseEventMetrics, metrics); err != nil {
+		return err
+	}
+	for _, data := range breached {
+		if err := s.send(sseEventThreshold, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newlyBreachedThresholds returns the metrics that have breached thresholds,
+// which weren't breached on the previous update. It must be called with the
+// metrics lock held.
+func (s *eventStream) newlyBreachedThresholds() []ThresholdEvent {
+	var result []ThresholdEvent
+	for name, m := range s.cs.MetricsEngine.ObservedMetrics {
+		if len(m.Thresholds.Thresholds) == 0 {
+			continue
+		}
+		tainted := m.Tainted.Bool
+		if tainted && !s.breached[name] {
+			data := ThresholdEvent{Metric: name, Thresholds: []string{}}
+			for _, threshold := range m.Thresholds.Thresholds {
+				if threshold.LastFailed {
+					data.Thresholds = append(data.Thresholds, threshold.Source)
+				}
+			}
+			result = append(result, data)
+		}
+		s.breached[name] = tainted
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Metric < result[j].Metric })
+	return result
+}
+
+func (s *eventStream) lifecycle(evt *event.Event) error {
+	var name string
+	var data LifecycleEvent
+	switch evt.Type {
+	case event.TestStart:
+		name = sseEventTestStart
+	case event.TestEnd:
+		name = sseEventTestEnd
+	case event.Exit:
+		name = sseEventExit
+		if exitData, ok := evt.Data.(*event.ExitData); ok && exitData.Error != nil {
+			data.Error = exitData.Error.Error()
+		}
+	default:
+		return nil
+	}
+	return s.send(name, data)
+}
+
+// send writes a single event to the stream and flushes it to the client. An
+// event that can't be encoded is skipped, only the write errors end the stream.
+func (s *eventStream) send(name string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		s.cs.RunState.Logger.WithError(err).Debugf("Couldn't encode the %s event", name)
+		return nil
+	}
+	if _, err = fmt.Fprintf(s.rw, "event: %s\ndata: %s\n\n", name, data); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
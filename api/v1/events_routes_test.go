@@ -0,0 +1,139 @@
+package v1
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/event"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/testutils/minirunner"
+	"go.k6.io/k6/metrics"
+)
+
+type sseEvent struct {
+	name string
+	data string
+}
+
+// readSSEEvents parses the Server-Sent Events from the body and sends them
+// on the returned channel, which is closed when the stream ends.
+func readSSEEvents(t *testing.T, res *http.Response) <-chan sseEvent {
+	t.Helper()
+	ch := make(chan sseEvent, 100)
+	go func() {
+		defer close(ch)
+		var evt sseEvent
+		scanner := bufio.NewScanner(res.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				ch <- evt
+				evt = sseEvent{}
+			case strings.HasPrefix(line, "event: "):
+				evt.name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				evt.data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}()
+	return ch
+}
+
+// waitSSEEvent returns the first event with the given name, skipping the rest.
+func waitSSEEvent(t *testing.T, events <-chan sseEvent, name string) sseEvent {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case evt, ok := <-events:
+			require.True(t, ok, "the stream ended before the %s event", name)
+			if evt.name == name {
+				return evt
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for the %s event", name)
+		}
+	}
+}
+
+func TestGetEvents(t *testing.T) {
+	t.Parallel()
+
+	testState := getTestRunState(t, lib.Options{}, &minirunner.MiniRunner{})
+	testState.Events = event.NewEventSystem(10, testState.Logger)
+	cs := getControlSurface(t, testState)
+
+	m, err := testState.Registry.NewMetric("my_metric", metrics.Gauge)
+	require.NoError(t, err)
+	m.Sink.Add(metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: m}, Time: time.Now(), Value: 2})
+	m.Thresholds = metrics.NewThresholds([]string{"value<1"})
+	m.Thresholds.Thresholds[0].LastFailed = true
+	m.Tainted = null.BoolFrom(true)
+	cs.MetricsEngine.ObservedMetrics[m.Name] = m
+
+	srv := httptest.NewServer(NewHandler(cs))
+	t.Cleanup(srv.Close)
+
+	res, err := http.Get(srv.URL + "/v1/events?interval=50ms") //nolint:noctx
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, res.Body.Close()) })
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+	events := readSSEEvents(t, res)
+
+	var status StatusJSONAPI
+	require.NoError(t, json.Unmarshal([]byte(waitSSEEvent(t, events, sseEventStatus).data), &status))
+	assert.Equal(t, lib.ExecutionStatusCreated, status.Status().Status)
+
+	var snapshot MetricsJSONAPI
+	require.NoError(t, json.Unmarshal([]byte(waitSSEEvent(t, events, sseEventMetrics).data), &snapshot))
+	require.Len(t, snapshot.Data, 1)
+	assert.Equal(t, "my_metric", snapshot.Data[0].ID)
+
+	var breach ThresholdEvent
+	require.NoError(t, json.Unmarshal([]byte(waitSSEEvent(t, events, sseEventThreshold).data), &breach))
+	assert.Equal(t, ThresholdEvent{Metric: "my_metric", Thresholds: []string{"value<1"}}, breach)
+
+	waitDone := testState.Events.Emit(&event.Event{Type: event.TestStart})
+	waitSSEEvent(t, events, sseEventTestStart)
+	require.NoError(t, waitDone(context.Background()))
+
+	waitDone = testState.Events.Emit(&event.Event{
+		Type: event.Exit,
+		Data: &event.ExitData{Error: errors.New("test aborted")},
+	})
+	var exit LifecycleEvent
+	require.NoError(t, json.Unmarshal([]byte(waitSSEEvent(t, events, sseEventExit).data), &exit))
+	assert.Equal(t, "test aborted", exit.Error)
+	require.NoError(t, waitDone(context.Background()))
+
+	// The stream ends after the exit event, and the breach isn't sent again
+	for evt := range events {
+		assert.NotEqual(t, sseEventThreshold, evt.name)
+	}
+}
+
+func TestGetEventsInvalidInterval(t *testing.T) {
+	t.Parallel()
+
+	testState := getTestRunState(t, lib.Options{}, &minirunner.MiniRunner{})
+	cs := getControlSurface(t, testState)
+
+	rw := httptest.NewRecorder()
+	NewHandler(cs).ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v1/events?interval=-1s", nil))
+	res := rw.Result()
+	t.Cleanup(func() { assert.NoError(t, res.Body.Close()) })
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
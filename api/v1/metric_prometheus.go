@@ -0,0 +1,200 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"google.golang.org/protobuf/proto"
+
+	"go.k6.io/k6/metrics"
+)
+
+const (
+	// prometheusNamespace is the prefix of the names of all exposed metrics.
+	prometheusNamespace = "k6_"
+
+	// prometheusMaxSeriesPerMetric is the cardinality guard for the exposed
+	// metrics, at most that many tag dimensions are rendered for each metric.
+	prometheusMaxSeriesPerMetric = 1000
+)
+
+// prometheusQuantiles are the quantiles of the trend metrics, which are
+// exposed as summaries.
+var prometheusQuantiles = []float64{0.5, 0.9, 0.95, 0.99} //nolint:gochecknoglobals
+
+// prometheusHandler renders the live values of the observed metrics in the
+// Prometheus text exposition or in the OpenMetrics format, so a running test
+// can be scraped by any Prometheus-compatible agent.
+type prometheusHandler struct {
+	cs *ControlSurface
+
+	// truncated keeps the names of the metrics that had too many tag
+	// dimensions, so the warning is only logged once for each of them
+	truncated sync.Map
+}
+
+// NewPrometheusHandler returns the handler for the Prometheus /metrics
+// endpoint.
+func NewPrometheusHandler(cs *ControlSurface) http.Handler {
+	return &prometheusHandler{cs: cs}
+}
+
+func (h *prometheusHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	families := h.metricFamilies()
+
+	format := expfmt.NegotiateIncludingOpenMetrics(r.Header)
+	rw.Header().Set("Content-Type", string(format))
+	encoder := expfmt.NewEncoder(rw, format)
+	for _, family := range families {
+		if err := encoder.Encode(family); err != nil {
+			h.cs.RunState.Logger.WithError(err).Error("Error while encoding the Prometheus metrics")
+			return
+		}
+	}
+	if closer, ok := encoder.(expfmt.Closer); ok {
+		if err := closer.Close(); err != nil {
+			h.cs.RunState.Logger.WithError(err).Error("Error while encoding the Prometheus metrics")
+		}
+	}
+}
+
+// metricFamilies returns a metric family for each observed metric, with the
+// values of the whole metric and of each of its observed sub-metrics, which
+// are labeled with their tags.
+func (h *prometheusHandler) metricFamilies() []*dto.MetricFamily {
+	h.cs.MetricsEngine.MetricsLock.Lock()
+	defer h.cs.MetricsEngine.MetricsLock.Unlock()
+
+	series := make(map[*metrics.Metric][]*metrics.Metric)
+	for _, m := range h.cs.MetricsEngine.ObservedMetrics {
+		parent := m
+		if m.Sub != nil {
+			parent = m.Sub.Parent
+		}
+		series[parent] = append(series[parent], m)
+	}
+
+	families := make([]*dto.MetricFamily, 0, len(series))
+	for parent, observed := range series {
+		sort.Slice(observed, func(i, j int) bool { return observed[i].Name < observed[j].Name })
+		if len(observed) > prometheusMaxSeriesPerMetric {
+			if _, warned := h.truncated.LoadOrStore(parent.Name, true); !warned {
+				h.cs.RunState.Logger.Warnf(
+					"The metric '%s' has %d tag dimensions, only the first %d of them are exposed on the /metrics endpoint",
+					parent.Name, len(observed), prometheusMaxSeriesPerMetric,
+				)
+			}
+			observed = observed[:prometheusMaxSeriesPerMetric]
+		}
+
+		family := newPrometheusFamily(parent)
+		for _, m := range observed {
+			if m.Sink.IsEmpty() {
+				continue
+			}
+			family.Metric = append(family.Metric, newPrometheusMetric(m))
+		}
+		if len(family.Metric) > 0 {
+			families = append(families, family)
+		}
+	}
+	sort.Slice(families, func(i, j int) bool { return families[i].GetName() < families[j].GetName() })
+
+	return families
+}
+
+func newPrometheusFamily(m *metrics.Metric) *dto.MetricFamily {
+	name := prometheusNamespace + sanitizePrometheusName(m.Name)
+	var typ dto.MetricType
+	switch m.Type {
+	case metrics.Counter:
+		name += "_total"
+		typ = dto.MetricType_COUNTER
+	case metrics.Gauge:
+		typ = dto.MetricType_GAUGE
+	case metrics.Rate:
+		name += "_rate"
+		typ = dto.MetricType_GAUGE
+	case metrics.Trend:
+		typ = dto.MetricType_SUMMARY
+	}
+	if m.Contains == metrics.Time && m.Type != metrics.Counter {
+		name += "_seconds"
+	}
+
+	return &dto.MetricFamily{
+		Name: proto.String(name),
+		Help: proto.String(fmt.Sprintf("k6 %s metric %s", m.Type, m.Name)),
+		Type: typ.Enum(),
+	}
+}
+
+func newPrometheusMetric(m *metrics.Metric) *dto.Metric {
+	result := &dto.Metric{}
+	if m.Sub != nil {
+		tags := m.Sub.Tags.Map()
+		keys := make([]string, 0, len(tags))
+		for key := range tags {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			result.Label = append(result.Label, &dto.LabelPair{
+				Name:  proto.String(sanitizePrometheusName(key)),
+				Value: proto.String(tags[key]),
+			})
+		}
+	}
+
+	// The time values are in milliseconds, while seconds are the Prometheus
+	// convention
+	scale := func(v float64) float64 {
+		if m.Contains == metrics.Time {
+			return v / 1000
+		}
+		return v
+	}
+
+	switch sink := m.Sink.(type) {
+	case *metrics.CounterSink:
+		result.Counter = &dto.Counter{Value: proto.Float64(sink.Value)}
+	case *metrics.GaugeSink:
+		result.Gauge = &dto.Gauge{Value: proto.Float64(scale(sink.Value))}
+	case *metrics.RateSink:
+		result.Gauge = &dto.Gauge{Value: proto.Float64(sink.Format(0)["rate"])}
+	case *metrics.TrendSink:
+		summary := &dto.Summary{
+			SampleCount: proto.Uint64(sink.Count()),
+			SampleSum:   proto.Float64(scale(sink.Total())),
+		}
+		for _, q := range prometheusQuantiles {
+			summary.Quantile = append(summary.Quantile, &dto.Quantile{
+				Quantile: proto.Float64(q),
+				Value:    proto.Float64(scale(sink.P(q))),
+			})
+		}
+		result.Summary = summary
+	}
+	return result
+}
+
+// sanitizePrometheusName replaces the characters that aren't valid in the
+// Prometheus metric and label names.
+func sanitizePrometheusName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+}
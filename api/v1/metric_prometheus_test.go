@@ -0,0 +1,138 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/testutils/minirunner"
+	"go.k6.io/k6/metrics"
+)
+
+func TestPrometheusHandler(t *testing.T) {
+	t.Parallel()
+
+	testState := getTestRunState(t, lib.Options{}, &minirunner.MiniRunner{})
+	cs := getControlSurface(t, testState)
+
+	reqs, err := testState.Registry.NewMetric("http_reqs", metrics.Counter)
+	require.NoError(t, err)
+	vus, err := testState.Registry.NewMetric("vus", metrics.Gauge)
+	require.NoError(t, err)
+	checks, err := testState.Registry.NewMetric("checks", metrics.Rate)
+	require.NoError(t, err)
+	duration, err := testState.Registry.NewMetric("http_req_duration", metrics.Trend, metrics.Time)
+	require.NoError(t, err)
+	okDuration, err := duration.AddSubmetric("status:200,expected_response:true")
+	require.NoError(t, err)
+	_, err = testState.Registry.NewMetric("unobserved", metrics.Counter)
+	require.NoError(t, err)
+
+	add := func(m *metrics.Metric, values ...float64) {
+		for _, v := range values {
+			m.Sink.Add(metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: m}, Time: time.Now(), Value: v})
+		}
+		cs.MetricsEngine.ObservedMetrics[m.Name] = m
+	}
+	add(reqs, 1, 1, 1)
+	add(vus, 5)
+	add(checks, 1, 0, 1, 1)
+	add(duration, 100, 100, 300)
+	add(okDuration.Metric, 100, 100)
+
+	t.Run("text", func(t *testing.T) {
+		t.Parallel()
+
+		rw := httptest.NewRecorder()
+		NewPrometheusHandler(cs).ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		res := rw.Result()
+		t.Cleanup(func() { assert.NoError(t, res.Body.Close()) })
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8; escaping=values", res.Header.Get("Content-Type"))
+
+		body := rw.Body.String()
+		for _, line := range []string{
+			"# TYPE k6_checks_rate gauge",
+			"k6_checks_rate 0.75",
+			"# TYPE k6_http_req_duration_seconds summary",
+			`k6_http_req_duration_seconds{quantile="0.5"} 0.1`,
+			`k6_http_req_duration_seconds{quantile="0.99"} 0.296`,
+			"k6_http_req_duration_seconds_sum 0.5",
+			"k6_http_req_duration_seconds_count 3",
+			`k6_http_req_duration_seconds{expected_response="true",status="200",quantile="0.95"} 0.1`,
+			`k6_http_req_duration_seconds_count{expected_response="true",status="200"} 2`,
+			"# TYPE k6_http_reqs_total counter",
+			"k6_http_reqs_total 3",
+			"# TYPE k6_vus gauge",
+			"k6_vus 5",
+		} {
+			assert.Contains(t, body, line+"\n")
+		}
+		assert.NotContains(t, body, "unobserved")
+	})
+
+	t.Run("openmetrics", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Accept", "application/openmetrics-text;version=1.0.0,"+
+			"application/openmetrics-text;version=0.0.1;q=0.75,text/plain;version=0.0.4;q=0.5,*/*;q=0.1")
+		rw := httptest.NewRecorder()
+		NewPrometheusHandler(cs).ServeHTTP(rw, req)
+		res := rw.Result()
+		t.Cleanup(func() { assert.NoError(t, res.Body.Close()) })
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.True(t, strings.HasPrefix(res.Header.Get("Content-Type"), "application/openmetrics-text"))
+
+		body := rw.Body.String()
+		assert.Contains(t, body, "# TYPE k6_http_reqs counter\nk6_http_reqs_total 3.0\n")
+		assert.True(t, strings.HasSuffix(body, "# EOF\n"))
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		t.Parallel()
+
+		rw := httptest.NewRecorder()
+		NewPrometheusHandler(cs).ServeHTTP(rw, httptest.NewRequest(http.MethodPost, "/metrics", nil))
+		res := rw.Result()
+		t.Cleanup(func() { assert.NoError(t, res.Body.Close()) })
+		assert.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+	})
+}
+
+func TestPrometheusHandlerCardinalityGuard(t *testing.T) {
+	t.Parallel()
+
+	testState := getTestRunState(t, lib.Options{}, &minirunner.MiniRunner{})
+	cs := getControlSurface(t, testState)
+
+	reqs, err := testState.Registry.NewMetric("http_reqs", metrics.Counter)
+	require.NoError(t, err)
+	reqs.Sink.Add(metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: reqs}, Time: time.Now(), Value: 1})
+	cs.MetricsEngine.ObservedMetrics[reqs.Name] = reqs
+	for i := 0; i < prometheusMaxSeriesPerMetric+10; i++ {
+		sub, err := reqs.AddSubmetric(fmt.Sprintf("url:%05d", i))
+		require.NoError(t, err)
+		sub.Metric.Sink.Add(metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: sub.Metric}, Time: time.Now(), Value: 1})
+		cs.MetricsEngine.ObservedMetrics[sub.Metric.Name] = sub.Metric
+	}
+
+	rw := httptest.NewRecorder()
+	NewPrometheusHandler(cs).ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	res := rw.Result()
+	t.Cleanup(func() { assert.NoError(t, res.Body.Close()) })
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	body := rw.Body.String()
+	assert.Equal(t, prometheusMaxSeriesPerMetric, strings.Count(body, "\nk6_http_reqs_total"))
+	assert.Contains(t, body, "\nk6_http_reqs_total 1\n")
+	assert.Contains(t, body, `k6_http_reqs_total{url="00998"} 1`)
+	assert.NotContains(t, body, `k6_http_reqs_total{url="00999"} 1`)
+}
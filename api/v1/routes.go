@@ -38,6 +38,42 @@ func NewHandler(cs *ControlSurface) http.Handler {
 		handleGetMetric(cs, rw, r, id)
 	})
 
+	mux.HandleFunc("/v1/events", func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		handleGetEvents(cs, rw, r)
+	})
+
+	mux.HandleFunc("/v1/thresholds", func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		handlePatchThresholds(cs, rw, r)
+	})
+
+	mux.HandleFunc("/v1/tags", func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		handlePatchTags(cs, rw, r)
+	})
+
+	mux.HandleFunc("/v1/scenarios/", func(rw http.ResponseWriter, r *http.Request) {
+		name := r.URL.Path[len("/v1/scenarios/"):]
+		switch r.Method {
+		case http.MethodGet:
+			handleGetScenario(cs, rw, r, name)
+		case http.MethodPatch:
+			handlePatchScenario(cs, rw, r, name)
+		default:
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
 	mux.HandleFunc("/v1/groups", func(rw http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			rw.WriteHeader(http.StatusMethodNotAllowed)
@@ -79,5 +115,29 @@ func NewHandler(cs *ControlSurface) http.Handler {
 		handleRunTeardown(cs, rw, r)
 	})
 
+	mux.HandleFunc("/v1/setup/", func(rw http.ResponseWriter, r *http.Request) {
+		scenario := r.URL.Path[len("/v1/setup/"):]
+		switch r.Method {
+		case http.MethodPost:
+			handleRunScenarioSetup(cs, rw, r, scenario)
+		case http.MethodPut:
+			handleSetScenarioSetupData(cs, rw, r, scenario)
+		case http.MethodGet:
+			handleGetScenarioSetupData(cs, rw, r, scenario)
+		default:
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/v1/teardown/", func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		scenario := r.URL.Path[len("/v1/teardown/"):]
+		handleRunScenarioTeardown(cs, rw, r, scenario)
+	})
+
 	return mux
 }
@@ -0,0 +1,144 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/executor"
+	"go.k6.io/k6/ui/pb"
+)
+
+// ScenarioJSON is the response of the scenario endpoint, with the progress of
+// the scenario and its config, including the changes that were made to it
+// while the test is running.
+type ScenarioJSON struct {
+	Name     string `json:"name"`
+	Executor string `json:"executor"`
+	// Status is one of pending, waiting, running, stopping, done or interrupted
+	Status   string  `json:"status"`
+	Progress float64 `json:"progress"`
+	// Details are the same as the ones next to the progress bar of the scenario
+	Details []string           `json:"details"`
+	Config  lib.ExecutorConfig `json:"config"`
+}
+
+func getScenarioStatus(status pb.Status) string {
+	switch status {
+	case pb.Waiting:
+		return "waiting"
+	case pb.Running:
+		return "running"
+	case pb.Stopping:
+		return "stopping"
+	case pb.Done:
+		return "done"
+	case pb.Interrupted:
+		return "interrupted"
+	default:
+		return "pending"
+	}
+}
+
+func newScenarioJSON(exec lib.Executor) ScenarioJSON {
+	config := exec.GetConfig()
+	if liveExec, ok := exec.(executor.LiveArrivalRate); ok {
+		config = liveExec.GetCurrentConfig()
+	}
+	progress, details := exec.GetProgress().Progress()
+	if details == nil {
+		details = []string{}
+	}
+	return ScenarioJSON{
+		Name:     config.GetName(),
+		Executor: config.GetType(),
+		Status:   getScenarioStatus(exec.GetProgress().Status()),
+		Progress: progress,
+		Details:  details,
+		Config:   config,
+	}
+}
+
+// getScenarioExecutor returns the executor of the scenario with the given name,
+// or writes an error response if it isn't found.
+func getScenarioExecutor(cs *ControlSurface, rw http.ResponseWriter, name string) (lib.Executor, bool) {
+	for _, exec := range cs.Scheduler.GetExecutors() {
+		if exec.GetConfig().GetName() == name {
+			return exec, true
+		}
+	}
+	// The executors without any work in this execution segment aren't created
+	for _, config := range cs.Scheduler.GetExecutorConfigs() {
+		if config.GetName() == name {
+			apiError(rw, "Not Found",
+				fmt.Sprintf("scenario %q doesn't have any work in this execution segment", name), http.StatusNotFound)
+			return nil, false
+		}
+	}
+	apiError(rw, "Not Found", fmt.Sprintf("no scenario with the name %q was found", name), http.StatusNotFound)
+	return nil, false
+}
+
+func writeScenario(rw http.ResponseWriter, exec lib.Executor) {
+	data, err := json.Marshal(newScenarioJSON(exec))
+	if err != nil {
+		apiError(rw, "Encoding error", err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, _ = rw.Write(data)
+}
+
+func handleGetScenario(cs *ControlSurface, rw http.ResponseWriter, _ *http.Request, name string) {
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	exec, ok := getScenarioExecutor(cs, rw, name)
+	if !ok {
+		return
+	}
+	writeScenario(rw, exec)
+}
+
+func handlePatchScenario(cs *ControlSurface, rw http.ResponseWriter, r *http.Request, name string) {
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	exec, ok := getScenarioExecutor(cs, rw, name)
+	if !ok {
+		return
+	}
+	liveExec, ok := exec.(executor.LiveArrivalRate)
+	if !ok {
+		apiError(rw, "Scenario update error", fmt.Sprintf(
+			"only the arrival-rate scenarios can be changed, but %q uses the %s executor",
+			name, exec.GetConfig().GetType(),
+		), http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		apiError(rw, "Couldn't read request", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The values are for the whole test, so every instance of a distributed
+	// test applies its own part of them, according to its execution segment
+	var update executor.ArrivalRateUpdate
+	if err = json.Unmarshal(body, &update); err != nil {
+		apiError(rw, "Invalid data", err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !update.Rate.Valid && !update.Duration.Valid && update.Stages == nil && !update.MaxVUs.Valid && !update.Stop {
+		apiError(rw, "Invalid data", "no changes were specified", http.StatusBadRequest)
+		return
+	}
+
+	if err = liveExec.UpdateConfig(r.Context(), update); err != nil {
+		apiError(rw, "Scenario update error", err.Error(), http.StatusBadRequest)
+		return
+	}
+	cs.annotate(fmt.Sprintf("Scenario %s changed from the REST API", name), update)
+
+	writeScenario(rw, exec)
+}
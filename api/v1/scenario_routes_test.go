@@ -0,0 +1,87 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/executor"
+	"go.k6.io/k6/lib/testutils/minirunner"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/output"
+)
+
+func TestScenarioRoutes(t *testing.T) {
+	t.Parallel()
+
+	arrivalRate := executor.NewConstantArrivalRateConfig("arrival")
+	arrivalRate.Rate = null.IntFrom(10)
+	arrivalRate.Duration = types.NullDurationFrom(time.Minute)
+	arrivalRate.PreAllocatedVUs = null.IntFrom(2)
+	arrivalRate.MaxVUs = null.IntFrom(5)
+	iterations := executor.NewSharedIterationsConfig("iterations")
+	options := lib.Options{Scenarios: lib.ScenarioConfigs{
+		arrivalRate.Name: arrivalRate,
+		iterations.Name:  iterations,
+	}}
+	cs := getControlSurface(t, getTestRunState(t, options, &minirunner.MiniRunner{}))
+
+	request := func(method, name, payload string) *httptest.ResponseRecorder {
+		rw := httptest.NewRecorder()
+		NewHandler(cs).ServeHTTP(rw, httptest.NewRequest(method, "/v1/scenarios/"+name, bytes.NewBufferString(payload)))
+		t.Cleanup(func() { assert.NoError(t, rw.Result().Body.Close()) })
+		return rw
+	}
+	type scenario struct {
+		ScenarioJSON
+		Config map[string]any `json:"config"`
+	}
+
+	rw := request(http.MethodGet, "arrival", "")
+	require.Equal(t, http.StatusOK, rw.Code, rw.Body.String())
+	var result scenario
+	require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &result))
+	assert.Equal(t, "arrival", result.Name)
+	assert.Equal(t, "constant-arrival-rate", result.Executor)
+	assert.Equal(t, "pending", result.Status)
+	assert.Equal(t, 0.0, result.Progress)
+	assert.Equal(t, 10.0, result.Config["rate"])
+
+	rw = request(http.MethodPatch, "arrival", `{"rate":50,"maxVUs":10}`)
+	require.Equal(t, http.StatusOK, rw.Code, rw.Body.String())
+	require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &result))
+	assert.Equal(t, 50.0, result.Config["rate"])
+	assert.Equal(t, 10.0, result.Config["maxVUs"])
+
+	require.Len(t, cs.Samples, 1)
+	annotation, ok := (<-cs.Samples).(*output.Annotation)
+	require.True(t, ok)
+	assert.Equal(t, "Scenario arrival changed from the REST API", annotation.Text)
+	assert.Equal(t, executor.ArrivalRateUpdate{Rate: null.IntFrom(50), MaxVUs: null.IntFrom(10)}, annotation.Data)
+
+	testCases := []struct {
+		method, name, payload string
+		expected              int
+	}{
+		{method: http.MethodGet, name: "missing", expected: http.StatusNotFound},
+		{method: http.MethodPatch, name: "iterations", payload: `{"stop":true}`, expected: http.StatusBadRequest},
+		{method: http.MethodPatch, name: "arrival", payload: `{}`, expected: http.StatusBadRequest},
+		{method: http.MethodPatch, name: "arrival", payload: `{"rate":`, expected: http.StatusBadRequest},
+		{method: http.MethodPatch, name: "arrival", payload: `{"rate":-1}`, expected: http.StatusBadRequest},
+		{method: http.MethodPatch, name: "arrival", payload: `{"stop":true}`, expected: http.StatusBadRequest},
+		{method: http.MethodPost, name: "arrival", expected: http.StatusMethodNotAllowed},
+	}
+	for _, tc := range testCases {
+		rw = request(tc.method, tc.name, tc.payload)
+		assert.Equal(t, tc.expected, rw.Code, tc.method, tc.name, tc.payload)
+	}
+	assert.Empty(t, cs.Samples)
+}
@@ -10,11 +10,13 @@ type setUpData struct {
 	Attributes interface{} `json:"attributes"`
 }
 
-func newSetUpJSONAPI(setup interface{}) setUpJSONAPI {
+// newSetUpJSONAPI returns the setup data of the given scenario, where default
+// is the one of the global setup().
+func newSetUpJSONAPI(scenario string, setup interface{}) setUpJSONAPI {
 	return setUpJSONAPI{
 		Data: setUpData{
 			Type:       "setupData",
-			ID:         "default",
+			ID:         scenario,
 			Attributes: setup,
 		},
 	}
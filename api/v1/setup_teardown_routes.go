@@ -2,6 +2,7 @@ package v1
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 )
@@ -17,15 +18,19 @@ type SetupData struct {
 	Data interface{} `json:"data" yaml:"data"`
 }
 
-func handleSetupDataOutput(rw http.ResponseWriter, setupData json.RawMessage) {
+// defaultSetupDataID is the ID of the data of the global setup(), the data of
+// the setup functions of the scenarios have the names of the scenarios as IDs.
+const defaultSetupDataID = "default"
+
+func handleSetupDataOutput(rw http.ResponseWriter, id string, setupData json.RawMessage) {
 	rw.Header().Set("Content-Type", "application/json")
 	var err error
 	var data []byte
 
 	if setupData == nil {
-		data, err = json.Marshal(newSetUpJSONAPI(NullSetupData{Data: nil}))
+		data, err = json.Marshal(newSetUpJSONAPI(id, NullSetupData{Data: nil}))
 	} else {
-		data, err = json.Marshal(newSetUpJSONAPI(SetupData{setupData}))
+		data, err = json.Marshal(newSetUpJSONAPI(id, SetupData{setupData}))
 	}
 	if err != nil {
 		apiError(rw, "Encoding error", err.Error(), http.StatusInternalServerError)
@@ -37,33 +42,39 @@ func handleSetupDataOutput(rw http.ResponseWriter, setupData json.RawMessage) {
 
 // handleGetSetupData just returns the current JSON-encoded setup data
 func handleGetSetupData(cs *ControlSurface, rw http.ResponseWriter, _ *http.Request) {
-	handleSetupDataOutput(rw, cs.RunState.Runner.GetSetupData())
+	handleSetupDataOutput(rw, defaultSetupDataID, cs.RunState.Runner.GetSetupData())
 }
 
-// handleSetSetupData just parses the JSON request body and sets the result as setup data for the runner
-func handleSetSetupData(cs *ControlSurface, rw http.ResponseWriter, r *http.Request) {
+// readSetupData reads the JSON request body, which is nil if it's empty, or
+// writes an error response if it's invalid.
+func readSetupData(rw http.ResponseWriter, r *http.Request) ([]byte, bool) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		apiError(rw, "Error reading request body", err.Error(), http.StatusBadRequest)
-		return
+		return nil, false
+	}
+	if len(body) == 0 {
+		return nil, true
 	}
 
 	var data interface{}
-	if len(body) > 0 {
-		if err := json.Unmarshal(body, &data); err != nil {
-			apiError(rw, "Error parsing request body", err.Error(), http.StatusBadRequest)
-			return
-		}
+	if err := json.Unmarshal(body, &data); err != nil {
+		apiError(rw, "Error parsing request body", err.Error(), http.StatusBadRequest)
+		return nil, false
 	}
+	return body, true
+}
 
-	runner := cs.RunState.Runner
-	if len(body) == 0 {
-		runner.SetSetupData(nil)
-	} else {
-		runner.SetSetupData(body)
+// handleSetSetupData just parses the JSON request body and sets the result as setup data for the runner
+func handleSetSetupData(cs *ControlSurface, rw http.ResponseWriter, r *http.Request) {
+	data, ok := readSetupData(rw, r)
+	if !ok {
+		return
 	}
 
-	handleSetupDataOutput(rw, runner.GetSetupData())
+	runner := cs.RunState.Runner
+	runner.SetSetupData(data)
+	handleSetupDataOutput(rw, defaultSetupDataID, runner.GetSetupData())
 }
 
 // handleRunSetup executes the runner's Setup() method and returns the result
@@ -76,7 +87,7 @@ func handleRunSetup(cs *ControlSurface, rw http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	handleSetupDataOutput(rw, runner.GetSetupData())
+	handleSetupDataOutput(rw, defaultSetupDataID, runner.GetSetupData())
 }
 
 // handleRunTeardown executes the runner's Teardown() method
@@ -86,3 +97,67 @@ func handleRunTeardown(cs *ControlSurface, rw http.ResponseWriter, r *http.Reque
 		apiError(rw, "Error executing teardown", err.Error(), http.StatusInternalServerError)
 	}
 }
+
+// hasScenario checks that there's a scenario with the given name, or
+// writes an error response if there isn't one.
+func hasScenario(cs *ControlSurface, rw http.ResponseWriter, scenario string) bool {
+	if _, ok := cs.RunState.Options.Scenarios[scenario]; !ok {
+		apiError(rw, "Not Found", fmt.Sprintf("no scenario with the name %q was found", scenario), http.StatusNotFound)
+		return false
+	}
+	return true
+}
+
+// handleGetScenarioSetupData returns the current JSON-encoded setup data of the
+// given scenario
+func handleGetScenarioSetupData(cs *ControlSurface, rw http.ResponseWriter, _ *http.Request, scenario string) {
+	if !hasScenario(cs, rw, scenario) {
+		return
+	}
+	handleSetupDataOutput(rw, scenario, cs.RunState.Runner.GetScenarioSetupData(scenario))
+}
+
+// handleSetScenarioSetupData parses the JSON request body and sets the result
+// as the setup data of the given scenario
+func handleSetScenarioSetupData(cs *ControlSurface, rw http.ResponseWriter, r *http.Request, scenario string) {
+	if !hasScenario(cs, rw, scenario) {
+		return
+	}
+	data, ok := readSetupData(rw, r)
+	if !ok {
+		return
+	}
+
+	runner := cs.RunState.Runner
+	runner.SetScenarioSetupData(scenario, data)
+	handleSetupDataOutput(rw, scenario, runner.GetScenarioSetupData(scenario))
+}
+
+// handleRunScenarioSetup executes the setup function of the given scenario and
+// returns the result
+func handleRunScenarioSetup(cs *ControlSurface, rw http.ResponseWriter, r *http.Request, scenario string) {
+	if !hasScenario(cs, rw, scenario) {
+		return
+	}
+
+	runner := cs.RunState.Runner
+	if err := runner.ScenarioSetup(r.Context(), scenario, cs.Samples); err != nil {
+		cs.RunState.Logger.WithError(err).Errorf("Error executing the setup of scenario %s", scenario)
+		apiError(rw, "Error executing setup", err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	handleSetupDataOutput(rw, scenario, runner.GetScenarioSetupData(scenario))
+}
+
+// handleRunScenarioTeardown executes the teardown function of the given scenario
+func handleRunScenarioTeardown(cs *ControlSurface, rw http.ResponseWriter, r *http.Request, scenario string) {
+	if !hasScenario(cs, rw, scenario) {
+		return
+	}
+
+	if err := cs.RunState.Runner.ScenarioTeardown(r.Context(), scenario, cs.Samples); err != nil {
+		cs.RunState.Logger.WithError(err).Errorf("Error executing the teardown of scenario %s", scenario)
+		apiError(rw, "Error executing teardown", err.Error(), http.StatusInternalServerError)
+	}
+}
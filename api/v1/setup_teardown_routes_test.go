@@ -19,6 +19,8 @@ import (
 	"go.k6.io/k6/execution/local"
 	"go.k6.io/k6/js"
 	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/executor"
+	"go.k6.io/k6/lib/testutils/minirunner"
 	"go.k6.io/k6/lib/types"
 	"go.k6.io/k6/loader"
 	"go.k6.io/k6/metrics"
@@ -213,3 +215,59 @@ func TestSetupData(t *testing.T) {
 		})
 	}
 }
+
+func TestScenarioSetupData(t *testing.T) {
+	t.Parallel()
+
+	login := executor.NewSharedIterationsConfig("login")
+	login.Setup = null.StringFrom("loginSetup")
+	login.Teardown = null.StringFrom("loginTeardown")
+	var tornDown []string
+	runner := &minirunner.MiniRunner{
+		ScenarioSetupFn: func(_ context.Context, scenario string, _ chan<- metrics.SampleContainer) ([]byte, error) {
+			return []byte(`{"scenario":"` + scenario + `"}`), nil
+		},
+		ScenarioTeardownFn: func(_ context.Context, scenario string, _ chan<- metrics.SampleContainer) error {
+			tornDown = append(tornDown, scenario)
+			return nil
+		},
+	}
+	cs := getControlSurface(t, getTestRunState(t, lib.Options{Scenarios: lib.ScenarioConfigs{"login": login}}, runner))
+
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		rw := httptest.NewRecorder()
+		NewHandler(cs).ServeHTTP(rw, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+		t.Cleanup(func() { assert.NoError(t, rw.Result().Body.Close()) })
+		return rw
+	}
+	checkSetup := func(method, body, expResult string) {
+		rw := request(method, "/v1/setup/login", body)
+		require.Equal(t, http.StatusOK, rw.Code, rw.Body.String())
+		var doc setUpJSONAPI
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &doc))
+		assert.Equal(t, "login", doc.Data.ID)
+		encoded, err := json.Marshal(doc.Data.Attributes)
+		require.NoError(t, err)
+		assert.JSONEq(t, expResult, string(encoded))
+	}
+
+	checkSetup(http.MethodGet, "", `{}`)
+	checkSetup(http.MethodPost, "", `{"data": {"scenario": "login"}}`)
+	checkSetup(http.MethodGet, "", `{"data": {"scenario": "login"}}`)
+	checkSetup(http.MethodPut, `{"v": 2}`, `{"data": {"v": 2}}`)
+	checkSetup(http.MethodPut, "", `{}`)
+
+	// The global setup data isn't affected
+	assert.Nil(t, runner.GetSetupData())
+
+	rw := request(http.MethodPost, "/v1/teardown/login", "")
+	assert.Equal(t, http.StatusOK, rw.Code, rw.Body.String())
+	assert.Equal(t, []string{"login"}, tornDown)
+
+	rw = request(http.MethodGet, "/v1/setup/missing", "")
+	assert.Equal(t, http.StatusNotFound, rw.Code)
+	rw = request(http.MethodPut, "/v1/setup/login", "{")
+	assert.Equal(t, http.StatusBadRequest, rw.Code)
+	rw = request(http.MethodDelete, "/v1/setup/login", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, rw.Code)
+}
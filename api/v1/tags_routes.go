@@ -0,0 +1,71 @@
+package v1
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+// TagsJSON is the body of the requests and responses of the tags endpoint.
+type TagsJSON struct {
+	Tags map[string]string `json:"tags"`
+}
+
+func handlePatchTags(cs *ControlSurface, rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		apiError(rw, "Couldn't read request", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var payload TagsJSON
+	if err = json.Unmarshal(body, &payload); err != nil {
+		apiError(rw, "Invalid data", err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(payload.Tags) == 0 {
+		apiError(rw, "Invalid data", "no tags were specified", http.StatusBadRequest)
+		return
+	}
+	for key := range payload.Tags {
+		if key == "" {
+			apiError(rw, "Invalid data", "the tag names can't be empty", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// The lock is held while the update is sent, so the concurrent updates
+	// reach the outputs in the same order as they are applied here
+	cs.runTagsMx.Lock()
+	defer cs.runTagsMx.Unlock()
+
+	original := cs.RunState.RunTags.Map()
+	if cs.runTags == nil {
+		cs.runTags = original
+	}
+	current := make(map[string]string, len(cs.runTags)+len(payload.Tags))
+	for key, value := range cs.runTags {
+		current[key] = value
+	}
+	for key, value := range payload.Tags {
+		current[key] = value
+	}
+	cs.runTags = current
+
+	// The samples are changed by the output manager, so the new tags are only
+	// applied to the samples that are emitted after the update
+	metrics.PushIfNotDone(cs.RunCtx, cs.Samples, &output.RunTagsUpdate{Original: original, Current: current})
+	cs.annotate("Run tags changed from the REST API", current)
+
+	data, err := json.Marshal(TagsJSON{Tags: current})
+	if err != nil {
+		apiError(rw, "Encoding error", err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, _ = rw.Write(data)
+}
@@ -0,0 +1,59 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/testutils/minirunner"
+	"go.k6.io/k6/output"
+)
+
+func TestPatchTags(t *testing.T) {
+	t.Parallel()
+
+	testState := getTestRunState(t, lib.Options{RunTags: map[string]string{"phase": "warmup"}}, &minirunner.MiniRunner{})
+	cs := getControlSurface(t, testState)
+
+	patch := func(payload string) *httptest.ResponseRecorder {
+		rw := httptest.NewRecorder()
+		NewHandler(cs).ServeHTTP(rw, httptest.NewRequest(http.MethodPatch, "/v1/tags", bytes.NewBufferString(payload)))
+		t.Cleanup(func() { assert.NoError(t, rw.Result().Body.Close()) })
+		return rw
+	}
+
+	rw := patch(`{"tags":{"phase":"load"}}`)
+	require.Equal(t, http.StatusOK, rw.Code, rw.Body.String())
+	rw = patch(`{"tags":{"env":"staging"}}`)
+	require.Equal(t, http.StatusOK, rw.Code, rw.Body.String())
+
+	var result TagsJSON
+	require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &result))
+	expected := map[string]string{"phase": "load", "env": "staging"}
+	assert.Equal(t, expected, result.Tags)
+
+	// Each update is followed by its annotation
+	require.Len(t, cs.Samples, 4)
+	<-cs.Samples
+	<-cs.Samples
+	update, ok := (<-cs.Samples).(*output.RunTagsUpdate)
+	require.True(t, ok)
+	assert.Equal(t, map[string]string{"phase": "warmup"}, update.Original)
+	assert.Equal(t, expected, update.Current)
+	annotation, ok := (<-cs.Samples).(*output.Annotation)
+	require.True(t, ok)
+	assert.Equal(t, "Run tags changed from the REST API", annotation.Text)
+	assert.Equal(t, expected, annotation.Data)
+
+	for _, payload := range []string{`{"tags":{}}`, `{"tags":{"":"value"}}`, `{"tags":`} {
+		rw = patch(payload)
+		assert.Equal(t, http.StatusBadRequest, rw.Code, payload)
+	}
+	assert.Empty(t, cs.Samples)
+}
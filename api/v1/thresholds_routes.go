@@ -0,0 +1,68 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"go.k6.io/k6/metrics"
+)
+
+// ThresholdsJSON is the body of the requests and responses of the thresholds
+// endpoint, with the thresholds of each metric in the same format as the
+// thresholds option.
+type ThresholdsJSON struct {
+	Thresholds map[string]metrics.Thresholds `json:"thresholds"`
+}
+
+func handlePatchThresholds(cs *ControlSurface, rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	if !cs.MetricsEngine.IsEvaluatingThresholds() {
+		apiError(rw, "Thresholds aren't evaluated",
+			"the thresholds can't be changed when k6 is run with --no-thresholds",
+			http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		apiError(rw, "Couldn't read request", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var payload ThresholdsJSON
+	if err = json.Unmarshal(body, &payload); err != nil {
+		apiError(rw, "Invalid data", err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(payload.Thresholds) == 0 {
+		apiError(rw, "Invalid data", "no thresholds were specified", http.StatusBadRequest)
+		return
+	}
+
+	if err = cs.MetricsEngine.UpdateThresholds(payload.Thresholds); err != nil {
+		apiError(rw, "Invalid thresholds", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	metricNames := make([]string, 0, len(payload.Thresholds))
+	for metricName := range payload.Thresholds {
+		metricNames = append(metricNames, metricName)
+	}
+	sort.Strings(metricNames)
+	cs.annotate(
+		fmt.Sprintf("Thresholds of %s changed from the REST API", strings.Join(metricNames, ", ")),
+		payload.Thresholds,
+	)
+
+	data, err := metrics.MarshalJSONWithoutHTMLEscape(payload)
+	if err != nil {
+		apiError(rw, "Encoding error", err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, _ = rw.Write(data)
+}
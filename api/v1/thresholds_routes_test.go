@@ -0,0 +1,108 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/testutils/minirunner"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+func TestPatchThresholds(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		payload            string
+		notEvaluated       bool
+		noThresholds       bool
+		expectedStatusCode int
+	}{
+		"valid": {
+			payload:            `{"thresholds":{"http_req_duration":["p(95)<500"],"checks":[{"threshold":"rate>0.9"}]}}`,
+			expectedStatusCode: http.StatusOK,
+		},
+		"invalid expression": {
+			payload:            `{"thresholds":{"http_req_duration":["p(95)<<500"]}}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		"unsupported aggregation": {
+			payload:            `{"thresholds":{"http_req_duration":["rate>0.9"]}}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		"unknown metric": {
+			payload:            `{"thresholds":{"unknown":["count>0"]}}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		"empty": {
+			payload:            `{"thresholds":{}}`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		"no thresholds before": {
+			payload:            `{"thresholds":{"http_req_duration":["p(95)<500"],"checks":[{"threshold":"rate>0.9"}]}}`,
+			noThresholds:       true,
+			expectedStatusCode: http.StatusOK,
+		},
+		"not evaluated": {
+			payload:            `{"thresholds":{"http_req_duration":["p(95)<500"]}}`,
+			notEvaluated:       true,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			testState := getTestRunState(t, lib.Options{}, &minirunner.MiniRunner{})
+			cs := getControlSurface(t, testState)
+			if !tc.notEvaluated {
+				if !tc.noThresholds {
+					ths := metrics.NewThresholds([]string{"count>=0"})
+					require.NoError(t, ths.Parse())
+					require.NoError(t, cs.MetricsEngine.InitSubMetricsAndThresholds(lib.Options{
+						Thresholds: map[string]metrics.Thresholds{"http_reqs": ths},
+					}, false))
+				}
+				finalize := cs.MetricsEngine.StartThresholdCalculations(nil, func(error) {}, func() time.Duration { return 0 })
+				t.Cleanup(func() { finalize() })
+			}
+
+			rw := httptest.NewRecorder()
+			NewHandler(cs).ServeHTTP(rw, httptest.NewRequest(http.MethodPatch, "/v1/thresholds", bytes.NewBufferString(tc.payload)))
+			res := rw.Result()
+			t.Cleanup(func() { assert.NoError(t, res.Body.Close()) })
+			require.Equal(t, tc.expectedStatusCode, res.StatusCode, rw.Body.String())
+
+			if tc.expectedStatusCode != http.StatusOK {
+				assert.Empty(t, cs.Samples)
+				assert.Empty(t, testState.BuiltinMetrics.HTTPReqDuration.Thresholds.Thresholds)
+				return
+			}
+
+			var result ThresholdsJSON
+			require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &result))
+			assert.Len(t, result.Thresholds, 2)
+
+			duration := testState.BuiltinMetrics.HTTPReqDuration
+			require.Len(t, duration.Thresholds.Thresholds, 1)
+			assert.Equal(t, "p(95)<500", duration.Thresholds.Thresholds[0].Source)
+			assert.Contains(t, cs.MetricsEngine.ObservedMetrics, "http_req_duration")
+
+			require.Len(t, cs.Samples, 1)
+			annotation, ok := (<-cs.Samples).(*output.Annotation)
+			require.True(t, ok)
+			assert.Equal(t, "Thresholds of checks, http_req_duration changed from the REST API", annotation.Text)
+			assert.IsType(t, map[string]metrics.Thresholds{}, annotation.Data)
+		})
+	}
+}
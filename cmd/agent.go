@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/execution"
+	"go.k6.io/k6/execution/distributed"
+)
+
+// cmdAgent handles the `k6 agent` sub-command
+type cmdAgent struct {
+	gs *state.GlobalState
+}
+
+func (c *cmdAgent) run(cmd *cobra.Command, args []string) (err error) {
+	c.gs.Logger.Infof("Connecting to the coordinator at %s...", args[0])
+	conn, err := grpc.NewClient(
+		args[0],
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(maxDistributedMsgSize)),
+	)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := conn.Close(); cerr != nil {
+			c.gs.Logger.WithError(cerr).Debug("Error while closing the connection to the coordinator")
+		}
+	}()
+
+	client := distributed.NewDistributedTestClient(conn)
+	// The agents may be started before the coordinator, so we wait for it
+	resp, err := client.Register(c.gs.Ctx, &distributed.RegisterRequest{}, grpc.WaitForReady(true))
+	if err != nil {
+		return fmt.Errorf("could not register with the coordinator: %w", err)
+	}
+	c.gs.Logger.Infof("Registered as instance %d", resp.InstanceID)
+
+	controller, err := distributed.NewAgentController(c.gs.Ctx, resp.InstanceID, client, c.gs.Logger)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := controller.Close(); cerr != nil {
+			c.gs.Logger.WithError(cerr).Debug("Error while closing the agent controller")
+		}
+	}()
+
+	// The archive the coordinator sent is the script of this instance
+	name := fmt.Sprintf("instance-%d.tar", resp.InstanceID)
+	runCmd := &cmdRun{
+		gs: c.gs,
+		loadConfiguredTest: func(cmd *cobra.Command, _ []string) (*loadedAndConfiguredTest, execution.Controller, error) {
+			test, err := loadArchiveData(c.gs, cmd, name, resp.Archive)
+			if err != nil {
+				return nil, nil, err
+			}
+			// All of the options come from the archive the coordinator sent,
+			// so we don't need to parse any CLI flags.
+			configuredTest, err := test.consolidateDeriveAndValidateConfig(c.gs, cmd, nil)
+			if err != nil {
+				return nil, nil, err
+			}
+			// The coordinator evaluates the thresholds and generates the
+			// end-of-test summary from the metrics of all instances.
+			test.preInitState.RuntimeOptions.NoThresholds = null.BoolFrom(true)
+			test.preInitState.RuntimeOptions.NoSummary = null.BoolFrom(true)
+			return configuredTest, controller, nil
+		},
+		metricsEngineHook: controller.PushMetrics,
+	}
+
+	return runCmd.run(cmd, []string{name})
+}
+
+func (c *cmdAgent) flagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
+	flags.SortFlags = false
+	flags.AddFlagSet(runtimeOptionFlagSet(true))
+	return flags
+}
+
+func getCmdAgent(gs *state.GlobalState) *cobra.Command {
+	c := &cmdAgent{gs: gs}
+
+	exampleText := getExampleText(gs, `
+  # Connect to a coordinator and run the part of the test it assigns.
+  {{.}} agent localhost:6566`[1:])
+
+	agentCmd := &cobra.Command{
+		Use:   "agent",
+		Short: "Join a distributed test as an agent",
+		Long: `Join a distributed test as an agent.
+
+The agent connects to a k6 coordinator, receives the test archive and the
+execution segment it should run, and then executes it in sync with all of the
+other agents that are a part of the same test.`,
+		Example: exampleText,
+		Args:    exactArgsWithMsg(1, "arg should be the address of the coordinator's gRPC server"),
+		RunE:    c.run,
+	}
+
+	agentCmd.Flags().SortFlags = false
+	agentCmd.Flags().AddFlagSet(c.flagSet())
+
+	return agentCmd
+}
@@ -7,11 +7,11 @@ import (
 	"strings"
 )
 
-const _builtinOutputName = "cloudcsvdatadogexperimental-prometheus-rwinfluxdbjsonkafkastatsdexperimental-opentelemetry"
+const _builtinOutputName = "cloudcsvdatadogexperimental-prometheus-rwinfluxdbjsonkafkastatsdexperimental-opentelemetryparquet"
 
-var _builtinOutputIndex = [...]uint8{0, 5, 8, 15, 41, 49, 53, 58, 64, 90}
+var _builtinOutputIndex = [...]uint8{0, 5, 8, 15, 41, 49, 53, 58, 64, 90, 97}
 
-const _builtinOutputLowerName = "cloudcsvdatadogexperimental-prometheus-rwinfluxdbjsonkafkastatsdexperimental-opentelemetry"
+const _builtinOutputLowerName = "cloudcsvdatadogexperimental-prometheus-rwinfluxdbjsonkafkastatsdexperimental-opentelemetryparquet"
 
 func (i builtinOutput) String() string {
 	if i >= builtinOutput(len(_builtinOutputIndex)-1) {
@@ -33,9 +33,10 @@ func _builtinOutputNoOp() {
 	_ = x[builtinOutputKafka-(6)]
 	_ = x[builtinOutputStatsd-(7)]
 	_ = x[builtinOutputExperimentalOpentelemetry-(8)]
+	_ = x[builtinOutputParquet-(9)]
 }
 
-var _builtinOutputValues = []builtinOutput{builtinOutputCloud, builtinOutputCSV, builtinOutputDatadog, builtinOutputExperimentalPrometheusRW, builtinOutputInfluxdb, builtinOutputJSON, builtinOutputKafka, builtinOutputStatsd, builtinOutputExperimentalOpentelemetry}
+var _builtinOutputValues = []builtinOutput{builtinOutputCloud, builtinOutputCSV, builtinOutputDatadog, builtinOutputExperimentalPrometheusRW, builtinOutputInfluxdb, builtinOutputJSON, builtinOutputKafka, builtinOutputStatsd, builtinOutputExperimentalOpentelemetry, builtinOutputParquet}
 
 var _builtinOutputNameToValueMap = map[string]builtinOutput{
 	_builtinOutputName[0:5]:        builtinOutputCloud,
@@ -56,6 +57,8 @@ var _builtinOutputNameToValueMap = map[string]builtinOutput{
 	_builtinOutputLowerName[58:64]: builtinOutputStatsd,
 	_builtinOutputName[64:90]:      builtinOutputExperimentalOpentelemetry,
 	_builtinOutputLowerName[64:90]: builtinOutputExperimentalOpentelemetry,
+	_builtinOutputName[90:97]:      builtinOutputParquet,
+	_builtinOutputLowerName[90:97]: builtinOutputParquet,
 }
 
 var _builtinOutputNames = []string{
@@ -68,6 +71,7 @@ var _builtinOutputNames = []string{
 	_builtinOutputName[53:58],
 	_builtinOutputName[58:64],
 	_builtinOutputName[64:90],
+	_builtinOutputName[90:97],
 }
 
 // builtinOutputString retrieves an enum value from the enum constants string name.
@@ -282,9 +282,19 @@ func consolidateErrorMessage(errList []error, title string) error {
 }
 
 func validateScenarioConfig(conf lib.ExecutorConfig, isExecutable func(string) bool) error {
-	execFn := conf.GetExec()
-	if !isExecutable(execFn) {
+	if weights := conf.GetExecWeights(); len(weights) > 0 {
+		for _, w := range weights {
+			if !isExecutable(w.Fn) {
+				return fmt.Errorf("executor %s: function '%s' not found in exports", conf.GetName(), w.Fn)
+			}
+		}
+	} else if execFn := conf.GetExec(); !isExecutable(execFn) {
 		return fmt.Errorf("executor %s: function '%s' not found in exports", conf.GetName(), execFn)
 	}
+	for _, getFn := range []func() (string, types.NullDuration){conf.GetSetup, conf.GetTeardown} {
+		if fn, _ := getFn(); fn != "" && !isExecutable(fn) {
+			return fmt.Errorf("executor %s: function '%s' not found in exports", conf.GetName(), fn)
+		}
+	}
 	return nil
 }
@@ -160,7 +160,7 @@ func TestDeriveAndValidateConfig(t *testing.T) {
 			"nonDefaultOK", Config{Options: lib.Options{Scenarios: lib.ScenarioConfigs{
 				"per_vu_iters": executor.PerVUIterationsConfig{
 					BaseConfig: executor.BaseConfig{
-						Name: "per_vu_iters", Type: "per-vu-iterations", Exec: null.StringFrom("nonDefault"),
+						Name: "per_vu_iters", Type: "per-vu-iterations", Exec: executor.ScenarioExecFrom("nonDefault"),
 					},
 					VUs:         null.IntFrom(1),
 					Iterations:  null.IntFrom(1),
@@ -173,7 +173,7 @@ func TestDeriveAndValidateConfig(t *testing.T) {
 			Config{Options: lib.Options{Scenarios: lib.ScenarioConfigs{
 				"per_vu_iters": executor.PerVUIterationsConfig{
 					BaseConfig: executor.BaseConfig{
-						Name: "per_vu_iters", Type: "per-vu-iterations", Exec: null.StringFrom("nonDefaultErr"),
+						Name: "per_vu_iters", Type: "per-vu-iterations", Exec: executor.ScenarioExecFrom("nonDefaultErr"),
 					},
 					VUs:         null.IntFrom(1),
 					Iterations:  null.IntFrom(1),
@@ -183,6 +183,22 @@ func TestDeriveAndValidateConfig(t *testing.T) {
 			false,
 			"executor per_vu_iters: function 'nonDefaultErr' not found in exports",
 		},
+		{
+			"trafficMixErr",
+			Config{Options: lib.Options{Scenarios: lib.ScenarioConfigs{
+				"per_vu_iters": executor.PerVUIterationsConfig{
+					BaseConfig: executor.BaseConfig{
+						Name: "per_vu_iters", Type: "per-vu-iterations",
+						Exec: executor.ScenarioExec{Weights: map[string]float64{"search": 20, "browse": 80}},
+					},
+					VUs:         null.IntFrom(1),
+					Iterations:  null.IntFrom(1),
+					MaxDuration: types.NullDurationFrom(time.Second),
+				},
+			}}},
+			false,
+			"executor per_vu_iters: function 'browse' not found in exports",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"net"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"google.golang.org/grpc"
+
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/execution/distributed"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics/engine"
+)
+
+// maxDistributedMsgSize is the maximum size of the gRPC messages between the
+// coordinator and the agents. It needs to be big enough for the test archive.
+const maxDistributedMsgSize = 128 * 1024 * 1024
+
+// cmdCoordinator handles the `k6 coordinator` sub-command
+type cmdCoordinator struct {
+	gs            *state.GlobalState
+	gRPCAddress   string
+	instanceCount int
+}
+
+func (c *cmdCoordinator) run(cmd *cobra.Command, args []string) (err error) {
+	test, err := loadAndConfigureLocalTest(c.gs, cmd, args, getPartialConfig)
+	if err != nil {
+		return err
+	}
+
+	// Similar to `k6 archive`, we don't set the derived options back to the
+	// runner, only the consolidated ones. The agents will derive them again.
+	testRunState, err := test.buildTestRunState(test.consolidatedConfig.Options)
+	if err != nil {
+		return err
+	}
+
+	// The agents send their metrics to the coordinator, so it can evaluate the
+	// thresholds and generate the end-of-test summary for the whole test.
+	metricsEngine, err := engine.NewMetricsEngine(testRunState.Registry, c.gs.Logger)
+	if err != nil {
+		return err
+	}
+	err = metricsEngine.InitSubMetricsAndThresholds(testRunState.Options, testRunState.RuntimeOptions.NoThresholds.Bool)
+	if err != nil {
+		return err
+	}
+
+	coordinator, err := distributed.NewCoordinatorServer(
+		c.instanceCount, testRunState.Runner.MakeArchive(), metricsEngine, c.gs.Logger,
+	)
+	if err != nil {
+		return err
+	}
+
+	if !testRunState.RuntimeOptions.NoSummary.Bool {
+		defer c.handleSummary(test, metricsEngine, coordinator)
+	}
+	if !testRunState.RuntimeOptions.NoThresholds.Bool {
+		finalizeThresholds := metricsEngine.StartThresholdCalculations(
+			nil, coordinator.AbortTest, coordinator.GetCurrentTestRunDuration,
+		)
+		defer func() {
+			if tErr := finalizeThresholdCalculation(finalizeThresholds); tErr != nil && err == nil {
+				err = tErr
+			}
+		}()
+	}
+
+	return c.serve(coordinator)
+}
+
+func (c *cmdCoordinator) serve(coordinator *distributed.CoordinatorServer) error {
+	c.gs.Logger.Infof("Starting gRPC server on %s", c.gRPCAddress)
+	listener, err := net.Listen("tcp", c.gRPCAddress)
+	if err != nil {
+		return err
+	}
+
+	grpcServer := grpc.NewServer(grpc.MaxSendMsgSize(maxDistributedMsgSize), grpc.MaxRecvMsgSize(maxDistributedMsgSize))
+	distributed.RegisterDistributedTestServer(grpcServer, coordinator)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- grpcServer.Serve(listener)
+	}()
+
+	c.gs.Logger.Infof("Waiting for %d instances to connect...", c.instanceCount)
+	select {
+	case err = <-serveErr:
+		return err
+	case <-c.gs.Ctx.Done():
+		c.gs.Logger.Debug("Stopping the coordinator because k6 was stopped...")
+		grpcServer.Stop()
+		return c.gs.Ctx.Err()
+	case <-coordinator.Done():
+		c.gs.Logger.Info("All instances ended!")
+	}
+
+	grpcServer.GracefulStop()
+	return coordinator.Err()
+}
+
+func (c *cmdCoordinator) handleSummary(
+	test *loadedAndConfiguredTest, metricsEngine *engine.MetricsEngine, coordinator *distributed.CoordinatorServer,
+) {
+	c.gs.Logger.Debug("Generating the end-of-test summary...")
+	summaryResult, err := test.initRunner.HandleSummary(c.gs.Ctx, &lib.Summary{
+		Metrics:         metricsEngine.ObservedMetrics,
+		RootGroup:       coordinator.RootGroup(),
+		TestRunDuration: coordinator.GetCurrentTestRunDuration(),
+		NoColor:         c.gs.Flags.NoColor,
+		UIState: lib.UIState{
+			IsStdOutTTY: c.gs.Stdout.IsTTY,
+			IsStdErrTTY: c.gs.Stderr.IsTTY,
+		},
+	})
+	if err == nil {
+		err = handleSummaryResult(c.gs.FS, c.gs.Stdout, c.gs.Stderr, summaryResult)
+	}
+	if err != nil {
+		c.gs.Logger.WithError(err).Error("failed to handle the end-of-test summary")
+	}
+}
+
+func (c *cmdCoordinator) flagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
+	flags.SortFlags = false
+	flags.AddFlagSet(optionFlagSet())
+	flags.AddFlagSet(runtimeOptionFlagSet(false))
+	flags.StringVar(&c.gRPCAddress, "grpc-address", c.gRPCAddress, "address on which to bind the gRPC server")
+	flags.IntVar(&c.instanceCount, "instance-count", c.instanceCount, "number of agent instances that will run the test")
+	return flags
+}
+
+func getCmdCoordinator(gs *state.GlobalState) *cobra.Command {
+	c := &cmdCoordinator{
+		gs:            gs,
+		gRPCAddress:   "localhost:6566",
+		instanceCount: 1,
+	}
+
+	exampleText := getExampleText(gs, `
+  # Split a test between 3 agents, waiting for them on the default address.
+  {{.}} coordinator --instance-count 3 script.js
+
+  # Start each of the agents, possibly on different machines.
+  {{.}} agent localhost:6566`[1:])
+
+	coordinatorCmd := &cobra.Command{
+		Use:   "coordinator",
+		Short: "Start a distributed test",
+		Long: `Start a distributed test.
+
+The coordinator splits the test between the given number of k6 agent instances
+by assigning a different execution segment to each of them. It then keeps them
+in sync during the test execution: it gates the start, setup() and teardown()
+of the test and shares the setup() data between the instances.
+
+The agents send their metrics to the coordinator, which aggregates them to
+evaluate the thresholds and to generate the end-of-test summary for the whole
+test. Thresholds with abortOnFail stop the test on all instances.`,
+		Example: exampleText,
+		Args:    exactArgsWithMsg(1, "arg should either be \"-\", if reading script from stdin, or a path to a script file"),
+		RunE:    c.run,
+	}
+
+	coordinatorCmd.Flags().SortFlags = false
+	coordinatorCmd.Flags().AddFlagSet(c.flagSet())
+
+	return coordinatorCmd
+}
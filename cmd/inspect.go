@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 
 	"github.com/spf13/cobra"
 
@@ -12,7 +14,10 @@ import (
 
 // TODO: split apart like `k6 run` and `k6 archive`
 func getCmdInspect(gs *state.GlobalState) *cobra.Command {
-	var addExecReqs bool
+	var (
+		addExecReqs bool
+		planFormat  string
+	)
 
 	// inspectCmd represents the inspect command
 	inspectCmd := &cobra.Command{
@@ -21,11 +26,24 @@ func getCmdInspect(gs *state.GlobalState) *cobra.Command {
 		Long:  `Inspect a script or archive.`,
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if planFormat != "" {
+				if addExecReqs {
+					return errors.New("the --plan and --execution-requirements flags can't be used together")
+				}
+				if planFormat != "text" && planFormat != "json" && planFormat != "csv" {
+					return fmt.Errorf("invalid --plan format '%s', it must be text, json or csv", planFormat)
+				}
+			}
+
 			test, err := loadLocalTest(gs, cmd, args)
 			if err != nil {
 				return err
 			}
 
+			if planFormat != "" {
+				return inspectPlan(gs, cmd, test, planFormat)
+			}
+
 			// At the moment, `k6 inspect` output can take 2 forms: standard
 			// (equal to the lib.Options struct) and extended, with additional
 			// fields with execution requirements.
@@ -55,12 +73,19 @@ func getCmdInspect(gs *state.GlobalState) *cobra.Command {
 		"execution-requirements",
 		false,
 		"include calculations of execution requirements for the test")
+	inspectCmd.Flags().StringVar(&planFormat,
+		"plan",
+		"",
+		"simulate the execution plan of the scenarios, without running any VUs, and print it as a `format` of "+
+			"text charts (default), json or csv")
+	inspectCmd.Flags().Lookup("plan").NoOptDefVal = "text"
 
 	return inspectCmd
 }
 
 // If --execution-requirements is enabled, this will consolidate the config,
-// derive the value of `scenarios` and calculate the max test duration and VUs.
+// derive the value of `scenarios` and calculate the max test duration and VUs,
+// as well as when each scenario starts, according to its dependencies.
 func inspectOutputWithExecRequirements(
 	gs *state.GlobalState, cmd *cobra.Command, test *loadedTest,
 ) (interface{}, error) {
@@ -83,11 +108,13 @@ func inspectOutputWithExecRequirements(
 
 	return struct {
 		lib.Options
-		TotalDuration types.NullDuration `json:"totalDuration"`
-		MaxVUs        uint64             `json:"maxVUs"`
+		TotalDuration  types.NullDuration              `json:"totalDuration"`
+		MaxVUs         uint64                          `json:"maxVUs"`
+		ExecutionGraph map[string]lib.ScenarioSchedule `json:"executionGraph"`
 	}{
 		configuredTest.derivedConfig.Options,
 		types.NewNullDuration(duration, true),
 		lib.GetMaxPossibleVUs(executionPlan),
+		configuredTest.derivedConfig.Scenarios.GetSchedules(et),
 	}, nil
 }
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/lib"
+)
+
+const (
+	// planChartWidth is the number of points the plan aims for, so the charts
+	// fit in a terminal, and planChartHeight the number of rows of the charts.
+	planChartWidth  = 60
+	planChartHeight = 4
+)
+
+// inspectPlan consolidates the config, like --execution-requirements, and
+// prints the simulated execution plan of the scenarios in the given format.
+func inspectPlan(gs *state.GlobalState, cmd *cobra.Command, test *loadedTest, format string) error {
+	// we don't actually support CLI flags here, so we pass nil as the getter
+	configuredTest, err := test.consolidateDeriveAndValidateConfig(gs, cmd, nil)
+	if err != nil {
+		return err
+	}
+
+	et, err := lib.NewExecutionTuple(
+		configuredTest.derivedConfig.ExecutionSegment,
+		configuredTest.derivedConfig.ExecutionSegmentSequence,
+	)
+	if err != nil {
+		return err
+	}
+
+	scenarios := configuredTest.derivedConfig.Scenarios
+	duration, _ := lib.GetEndOffset(scenarios.GetFullExecutionRequirements(et))
+	plan := scenarios.GetExecutionPlan(et, getPlanResolution(duration))
+
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return err
+		}
+		printToStdout(gs, string(data))
+		return nil
+	case "csv":
+		return writePlanCSV(gs.Stdout, plan)
+	default:
+		printToStdout(gs, renderPlan(plan))
+		return nil
+	}
+}
+
+// getPlanResolution returns the whole number of seconds between the points of
+// the plan, so there are at most planChartWidth of them.
+func getPlanResolution(duration time.Duration) time.Duration {
+	resolution := (duration + planChartWidth*time.Second - 1) / (planChartWidth * time.Second) * time.Second
+	if resolution < time.Second {
+		return time.Second
+	}
+	return resolution
+}
+
+// getSortedPlanScenarios returns the names of the scenarios of the plan,
+// sorted by their start times and by their names if there are ties.
+func getSortedPlanScenarios(plan lib.ExecutionPlan) []string {
+	names := make([]string, 0, len(plan.Scenarios))
+	for name := range plan.Scenarios {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(a, b int) bool {
+		startA, startB := plan.Scenarios[names[a]].Start.Duration, plan.Scenarios[names[b]].Start.Duration
+		if startA == startB {
+			return names[a] < names[b]
+		}
+		return startA < startB
+	})
+	return names
+}
+
+// writePlanCSV writes a row for every point of every scenario of the plan, the
+// times are in seconds and the unknown iterations per second are empty.
+func writePlanCSV(w io.Writer, plan lib.ExecutionPlan) error {
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.Write([]string{"scenario", "time", "vus", "maxVUs", "iterationsPerSecond"}); err != nil {
+		return err
+	}
+	for _, name := range getSortedPlanScenarios(plan) {
+		for _, point := range plan.Scenarios[name].Points {
+			var iterationsPerSecond string
+			if point.IterationsPerSecond.Valid {
+				iterationsPerSecond = strconv.FormatFloat(point.IterationsPerSecond.Float64, 'f', 2, 64)
+			}
+			err := csvWriter.Write([]string{
+				name,
+				strconv.FormatFloat(point.Time.TimeDuration().Seconds(), 'f', -1, 64),
+				strconv.FormatUint(point.VUs, 10),
+				strconv.FormatUint(point.MaxVUs, 10),
+				iterationsPerSecond,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// renderPlan returns the ASCII charts of the VUs and of the iterations per
+// second of every scenario of the plan.
+func renderPlan(plan lib.ExecutionPlan) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "execution plan: %s, max VUs: %d, resolution: %s\n",
+		plan.Duration, plan.MaxVUs, plan.Resolution)
+
+	for _, name := range getSortedPlanScenarios(plan) {
+		scenarioPlan := plan.Scenarios[name]
+		timing := fmt.Sprintf("%s-%s", scenarioPlan.Start, scenarioPlan.End)
+		if scenarioPlan.Dynamic {
+			timing = "from " + timing + ", waiting for metric conditions"
+		}
+		fmt.Fprintf(&sb, "\nscenario %s (%s, %s)\n", name, scenarioPlan.Executor, timing)
+
+		vus := make([]float64, len(scenarioPlan.Points))
+		var iterationsPerSecond []float64
+		for i, point := range scenarioPlan.Points {
+			vus[i] = float64(point.VUs)
+			if point.IterationsPerSecond.Valid {
+				iterationsPerSecond = append(iterationsPerSecond, point.IterationsPerSecond.Float64)
+			}
+		}
+		renderPlanChart(&sb, "VUs", vus, "%.0f")
+		if len(iterationsPerSecond) == len(vus) {
+			renderPlanChart(&sb, "iterations/s", iterationsPerSecond, "%.2f")
+		} else {
+			sb.WriteString("  iterations/s: not known in advance for this executor\n")
+		}
+		fmt.Fprintf(&sb, "  %8s  %-*s%s\n", "", len(vus)-len(plan.Duration.String()), "0s", plan.Duration)
+	}
+	return sb.String()
+}
+
+// renderPlanChart writes a bar chart of the values, with a bar for every point
+// of the plan.
+func renderPlanChart(sb *strings.Builder, title string, values []float64, valueFormat string) {
+	var maxValue float64
+	for _, value := range values {
+		maxValue = math.Max(maxValue, value)
+	}
+	fmt.Fprintf(sb, "  %s, max "+valueFormat+"\n", title, maxValue)
+
+	levels := make([]int, len(values))
+	if maxValue > 0 {
+		for i, value := range values {
+			levels[i] = int(math.Ceil(value / maxValue * planChartHeight))
+		}
+	}
+	for row := planChartHeight; row > 0; row-- {
+		var label string
+		if row == planChartHeight {
+			label = fmt.Sprintf(valueFormat, maxValue)
+		}
+		bars := make([]byte, len(levels))
+		for i, level := range levels {
+			bars[i] = ' '
+			if level >= row {
+				bars[i] = '#'
+			}
+		}
+		fmt.Fprintf(sb, "  %8s |%s\n", label, strings.TrimRight(string(bars), " "))
+	}
+	fmt.Fprintf(sb, "  %8s +%s\n", "0", strings.Repeat("-", len(values)))
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+)
+
+func TestGetPlanResolution(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, time.Second, getPlanResolution(0))
+	assert.Equal(t, time.Second, getPlanResolution(30*time.Second))
+	assert.Equal(t, time.Second, getPlanResolution(time.Minute))
+	assert.Equal(t, 2*time.Second, getPlanResolution(time.Minute+time.Millisecond))
+	assert.Equal(t, 30*time.Second, getPlanResolution(30*time.Minute))
+}
+
+func TestInspectPlanOutput(t *testing.T) {
+	t.Parallel()
+
+	point := func(secs int, vus uint64, iterationsPerSecond null.Float) lib.PlanPoint {
+		return lib.PlanPoint{
+			Time: types.NullDurationFrom(time.Duration(secs) * time.Second),
+			VUs:  vus, MaxVUs: vus * 2, IterationsPerSecond: iterationsPerSecond,
+		}
+	}
+	plan := lib.ExecutionPlan{
+		Duration:   types.NullDurationFrom(4 * time.Second),
+		Resolution: types.NullDurationFrom(time.Second),
+		MaxVUs:     20,
+		Scenarios: map[string]lib.ScenarioPlan{
+			"load": {
+				Executor: "ramping-arrival-rate",
+				ScenarioSchedule: lib.ScenarioSchedule{
+					Start: types.NullDurationFrom(time.Second),
+					End:   types.NullDurationFrom(4 * time.Second),
+				},
+				Points: []lib.PlanPoint{
+					point(0, 0, null.FloatFrom(0)), point(1, 10, null.FloatFrom(10)), point(2, 10, null.FloatFrom(20)),
+					point(3, 10, null.FloatFrom(40)), point(4, 0, null.FloatFrom(0)),
+				},
+			},
+			"warmup": {
+				Executor: "constant-vus",
+				ScenarioSchedule: lib.ScenarioSchedule{
+					Start: types.NullDurationFrom(0),
+					End:   types.NullDurationFrom(2 * time.Second),
+				},
+				Points: []lib.PlanPoint{
+					point(0, 5, null.Float{}), point(1, 5, null.Float{}), point(2, 0, null.Float{}),
+					point(3, 0, null.Float{}), point(4, 0, null.Float{}),
+				},
+			},
+		},
+	}
+
+	t.Run("text", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, ""+
+			"execution plan: 4s, max VUs: 20, resolution: 1s\n"+
+			"\n"+
+			"scenario warmup (constant-vus, 0s-2s)\n"+
+			"  VUs, max 5\n"+
+			"         5 |##\n"+
+			"           |##\n"+
+			"           |##\n"+
+			"           |##\n"+
+			"         0 +-----\n"+
+			"  iterations/s: not known in advance for this executor\n"+
+			"            0s 4s\n"+
+			"\n"+
+			"scenario load (ramping-arrival-rate, 1s-4s)\n"+
+			"  VUs, max 10\n"+
+			"        10 | ###\n"+
+			"           | ###\n"+
+			"           | ###\n"+
+			"           | ###\n"+
+			"         0 +-----\n"+
+			"  iterations/s, max 40.00\n"+
+			"     40.00 |   #\n"+
+			"           |   #\n"+
+			"           |  ##\n"+
+			"           | ###\n"+
+			"         0 +-----\n"+
+			"            0s 4s\n",
+			renderPlan(plan))
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		require.NoError(t, writePlanCSV(&buf, plan))
+		assert.Equal(t, ""+
+			"scenario,time,vus,maxVUs,iterationsPerSecond\n"+
+			"warmup,0,5,10,\n"+
+			"warmup,1,5,10,\n"+
+			"warmup,2,0,0,\n"+
+			"warmup,3,0,0,\n"+
+			"warmup,4,0,0,\n"+
+			"load,0,0,0,0.00\n"+
+			"load,1,10,20,10.00\n"+
+			"load,2,10,20,20.00\n"+
+			"load,3,10,20,40.00\n"+
+			"load,4,0,0,0.00\n",
+			buf.String())
+	})
+}
@@ -43,6 +43,7 @@ func optionFlagSet() *pflag.FlagSet {
 	flags.Bool("insecure-skip-tls-verify", false, "skip verification of TLS certificates")
 	flags.Bool("no-connection-reuse", false, "disable keep-alive connections")
 	flags.Bool("no-vu-connection-reuse", false, "don't reuse connections between iterations")
+	flags.Bool("http3", false, "make the requests to https:// URLs with HTTP/3")
 	flags.Duration("min-iteration-duration", 0, "minimum amount of time k6 will take executing a single iteration")
 	flags.BoolP("throw", "w", false, "throw warnings (like failed http requests) as errors")
 	flags.StringSlice("blacklist-ip", nil, "blacklist an `ip range` from being called")
@@ -57,6 +58,9 @@ func optionFlagSet() *pflag.FlagSet {
 	)
 	flags.StringSlice("summary-trend-stats", nil, sumTrendStatsHelp)
 	flags.String("summary-time-unit", "", "define the time unit used to display the trend stats. Possible units are: 's', 'ms' and 'us'") //nolint:lll
+	flags.String("trend-sink", "", "how to aggregate trend metrics, either 'exact' or 'histogram' (default 'exact')")
+	flags.Float64("trend-sink-max-error", lib.DefaultTrendSinkMaxError,
+		"maximum relative error of the percentiles calculated by the 'histogram' trend sink")
 	// system-tags must have a default value, but we can't specify it here, otherwiese, it will always override others.
 	// set it to nil here, and add the default in applyDefault() instead.
 	systemTagsCliHelpText := fmt.Sprintf(
@@ -95,9 +99,12 @@ func getOptions(flags *pflag.FlagSet) (lib.Options, error) {
 		InsecureSkipTLSVerify:   getNullBool(flags, "insecure-skip-tls-verify"),
 		NoConnectionReuse:       getNullBool(flags, "no-connection-reuse"),
 		NoVUConnectionReuse:     getNullBool(flags, "no-vu-connection-reuse"),
+		HTTP3:                   getNullBool(flags, "http3"),
 		MinIterationDuration:    getNullDuration(flags, "min-iteration-duration"),
 		Throw:                   getNullBool(flags, "throw"),
 		DiscardResponseBodies:   getNullBool(flags, "discard-response-bodies"),
+		TrendSink:               getNullString(flags, "trend-sink"),
+		TrendSinkMaxError:       getNullFloat64(flags, "trend-sink-max-error"),
 		MetricSamplesBufferSize: null.NewInt(1000, false),
 	}
 
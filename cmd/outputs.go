@@ -14,6 +14,7 @@ import (
 	"go.k6.io/k6/output/csv"
 	"go.k6.io/k6/output/influxdb"
 	"go.k6.io/k6/output/json"
+	"go.k6.io/k6/output/parquet"
 	"go.k6.io/k6/output/statsd"
 
 	"github.com/grafana/xk6-dashboard/dashboard"
@@ -38,6 +39,7 @@ const (
 	builtinOutputKafka
 	builtinOutputStatsd
 	builtinOutputExperimentalOpentelemetry
+	builtinOutputParquet
 )
 
 // TODO: move this to an output sub-module after we get rid of the old collectors?
@@ -48,6 +50,7 @@ func getAllOutputConstructors() (map[string]output.Constructor, error) {
 		builtinOutputCloud.String():    cloud.New,
 		builtinOutputCSV.String():      csv.New,
 		builtinOutputInfluxdb.String(): influxdb.New,
+		builtinOutputParquet.String():  parquet.New,
 		builtinOutputKafka.String(): func(_ output.Params) (output.Output, error) {
 			return nil, errors.New("the kafka output was deprecated in k6 v0.32.0 and removed in k6 v0.34.0, " +
 				"please use the new xk6 kafka output extension instead - https://github.com/k6io/xk6-output-kafka")
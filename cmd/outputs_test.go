@@ -10,7 +10,7 @@ func TestBuiltinOutputString(t *testing.T) {
 	t.Parallel()
 	exp := []string{
 		"cloud", "csv", "datadog", "experimental-prometheus-rw",
-		"influxdb", "json", "kafka", "statsd", "experimental-opentelemetry",
+		"influxdb", "json", "kafka", "statsd", "experimental-opentelemetry", "parquet",
 	}
 	assert.Equal(t, exp, builtinOutputStrings())
 }
@@ -61,8 +61,8 @@ func newRootCommand(gs *state.GlobalState) *rootCommand {
 	rootCmd.SetIn(gs.Stdin)
 
 	subCommands := []func(*state.GlobalState) *cobra.Command{
-		getCmdArchive, getCmdCloud, getCmdNewScript, getCmdInspect,
-		getCmdLogin, getCmdPause, getCmdResume, getCmdScale, getCmdRun,
+		getCmdAgent, getCmdArchive, getCmdCloud, getCmdCoordinator, getCmdNewScript,
+		getCmdInspect, getCmdLogin, getCmdPause, getCmdResume, getCmdScale, getCmdRun,
 		getCmdStats, getCmdStatus, getCmdVersion,
 	}
 
@@ -190,6 +190,12 @@ func rootCmdPersistentFlagSet(gs *state.GlobalState) *pflag.FlagSet {
 		gs.DefaultFlags.ProfilingEnabled,
 		"enable profiling (pprof) endpoints, k6's REST API should be enabled as well",
 	)
+	flags.BoolVar(
+		&gs.Flags.MetricsEndpointEnabled,
+		"metrics-endpoint-enabled",
+		gs.DefaultFlags.MetricsEndpointEnabled,
+		"enable the Prometheus /metrics endpoint, k6's REST API should be enabled as well",
+	)
 
 	return flags
 }
@@ -39,6 +39,20 @@ type cmdRun struct {
 
 	// TODO: figure out something more elegant?
 	loadConfiguredTest func(cmd *cobra.Command, args []string) (*loadedAndConfiguredTest, execution.Controller, error)
+
+	// metricsEngineHook is called, if set, once the metrics engine is ready,
+	// e.g. so that a k6 agent can send its metrics to the coordinator. The
+	// returned callback is called after all of the metrics have been
+	// processed by the engine and all outputs have been stopped.
+	metricsEngineHook func(
+		me *engine.MetricsEngine, getCurrentTestRunDuration func() time.Duration, abortRun func(error),
+	) (finalize func(rootGroup *lib.Group))
+
+	// checkpointDir, checkpointInterval and resumeDir store the state of the
+	// --checkpoint, --checkpoint-interval and --resume flags.
+	checkpointDir      string
+	checkpointInterval time.Duration
+	resumeDir          string
 }
 
 const (
@@ -123,6 +137,23 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 		}
 	}
 
+	if c.checkpointDir != "" && c.checkpointInterval <= 0 {
+		return errors.New("the --checkpoint-interval must be positive")
+	}
+	var resumedFrom *checkpointFile
+	if c.resumeDir != "" {
+		if resumedFrom, err = loadCheckpoint(c.gs.FS, c.resumeDir); err != nil {
+			return err
+		}
+		// Only the rest of the work of the scenarios is left to run.
+		test.derivedConfig.Scenarios, err = execution.GetResumedScenarios(
+			test.derivedConfig.Scenarios, &resumedFrom.Checkpoint,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Write the full consolidated *and derived* options back to the Runner.
 	conf := test.derivedConfig
 	testRunState, err := test.buildTestRunState(conf.Options)
@@ -136,6 +167,9 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 	if err != nil {
 		return err
 	}
+	if resumedFrom != nil {
+		execScheduler.ResumeFrom(&resumedFrom.Checkpoint)
+	}
 
 	backgroundProcesses := &sync.WaitGroup{}
 	defer backgroundProcesses.Wait()
@@ -173,22 +207,40 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 	}
 
 	// We'll need to pipe metrics to the MetricsEngine and process them if any
-	// of these are enabled: thresholds, end-of-test summary
+	// of these are enabled: thresholds, end-of-test summary, metrics engine hook
 	shouldProcessMetrics := (!testRunState.RuntimeOptions.NoSummary.Bool ||
-		!testRunState.RuntimeOptions.NoThresholds.Bool)
+		!testRunState.RuntimeOptions.NoThresholds.Bool || c.metricsEngineHook != nil)
 	var metricsIngester *engine.OutputIngester
 	if shouldProcessMetrics {
-		err = metricsEngine.InitSubMetricsAndThresholds(conf.Options, testRunState.RuntimeOptions.NoThresholds.Bool)
+		// The hook still needs the sub-metrics that the thresholds reference,
+		// even if the thresholds themselves are evaluated somewhere else.
+		onlyLogErrors := testRunState.RuntimeOptions.NoThresholds.Bool && c.metricsEngineHook == nil
+		err = metricsEngine.InitSubMetricsAndThresholds(conf.Options, onlyLogErrors)
 		if err != nil {
 			return err
 		}
+		// The summary and the thresholds continue with the metrics of the
+		// previous runs of the test.
+		if resumedFrom != nil {
+			if err = metricsEngine.RestoreSnapshots(resumedFrom.Metrics); err != nil {
+				return err
+			}
+		}
 		// We'll need to pipe metrics to the MetricsEngine if either the
 		// thresholds or the end-of-test summary are enabled.
 		metricsIngester = metricsEngine.CreateIngester()
 		outputs = append(outputs, metricsIngester)
+		testRunState.MetricsWatcher = metricsEngine
 	}
 
 	executionState := execScheduler.GetState()
+	if c.metricsEngineHook != nil {
+		finalizeHook := c.metricsEngineHook(metricsEngine, executionState.GetCurrentTestRunDuration, runAbort)
+		defer func() {
+			logger.Debug("Finalizing the metrics engine hook...")
+			finalizeHook(testRunState.GroupSummary.Group())
+		}()
+	}
 	if !testRunState.RuntimeOptions.NoSummary.Bool {
 		defer func() {
 			logger.Debug("Generating the end-of-test summary...")
@@ -247,26 +299,42 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 			// the OutputManager has flushed all of the cached samples to
 			// outputs (including MetricsEngine's ingester). So we are sure
 			// there won't be any more metrics being sent.
-			logger.Debug("Finalizing thresholds...")
-			breachedThresholds := finalizeThresholds()
-			if len(breachedThresholds) == 0 {
+			tErr := finalizeThresholdCalculation(finalizeThresholds)
+			if tErr == nil {
 				return
 			}
-			tErr := errext.WithAbortReasonIfNone(
-				errext.WithExitCodeIfNone(
-					fmt.Errorf("thresholds on metrics '%s' have been crossed", strings.Join(breachedThresholds, ", ")),
-					exitcodes.ThresholdsHaveFailed,
-				), errext.AbortedByThresholdsAfterTestEnd)
-
 			if err == nil {
 				err = tErr
 			} else {
 				logger.WithError(tErr).Debug("Crossed thresholds, but test already exited with another error")
 			}
 		}
-		if finalizeThresholds != nil {
-			defer handleFinalThresholdCalculation()
+		defer handleFinalThresholdCalculation()
+	}
+
+	var checkpoints *checkpointer
+	testRunStarted := false
+	if c.checkpointDir != "" {
+		checkpoints = &checkpointer{
+			fs: c.gs.FS, dir: c.checkpointDir, logger: logger, scheduler: execScheduler,
+			metricsIngester: metricsIngester,
 		}
+		// The final checkpoint is saved after all of the metrics have been
+		// processed, which is waited for in the deferred function below.
+		defer func() {
+			if !testRunStarted {
+				return
+			}
+			if metricsIngester != nil {
+				// Stop the ingester, so all of its buffered metrics are in
+				// the engine, it's safe to stop it more than once.
+				if stopErr := metricsIngester.Stop(); stopErr != nil {
+					logger.WithError(stopErr).Warn("There was a problem stopping the output ingester")
+				}
+			}
+			logger.Debug("Saving the final checkpoint...")
+			checkpoints.save(err == nil)
+		}()
 	}
 
 	defer func() {
@@ -307,7 +375,7 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 
 		srv := api.GetServer(
 			runCtx,
-			c.gs.Flags.Address, c.gs.Flags.ProfilingEnabled,
+			c.gs.Flags.Address, c.gs.Flags.ProfilingEnabled, c.gs.Flags.MetricsEndpointEnabled,
 			testRunState,
 			samples,
 			metricsEngine,
@@ -319,6 +387,9 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 			if c.gs.Flags.ProfilingEnabled {
 				logger.Debugf("Profiling exposed on http://%s/debug/pprof/", c.gs.Flags.Address)
 			}
+			if c.gs.Flags.MetricsEndpointEnabled {
+				logger.Debugf("Prometheus metrics exposed on http://%s/metrics", c.gs.Flags.Address)
+			}
 			if aerr := srv.ListenAndServe(); aerr != nil && !errors.Is(aerr, http.ErrServerClosed) {
 				// Only exit k6 if the user has explicitly set the REST API address
 				if cmd.Flags().Lookup("address").Changed {
@@ -392,9 +463,19 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 	waitTestStartDone := emitEvent(&event.Event{Type: event.TestStart})
 	waitTestStartDone()
 
+	if resumedFrom != nil {
+		annotateCheckpoint(runCtx, samples, "resumed from checkpoint", resumedFrom.Checkpoint)
+	}
+	stopCheckpoints := func() {}
+	if checkpoints != nil {
+		stopCheckpoints = checkpoints.start(runCtx, c.checkpointInterval, samples)
+	}
+
 	// Start the test! However, we won't immediately return if there was an
 	// error, we still have things to do.
+	testRunStarted = true
 	err = execScheduler.Run(globalCtx, runCtx, samples)
+	stopCheckpoints()
 
 	waitTestEndDone := emitEvent(&event.Event{Type: event.TestEnd})
 	defer waitTestEndDone()
@@ -440,6 +521,7 @@ func (c *cmdRun) flagSet() *pflag.FlagSet {
 	flags.AddFlagSet(optionFlagSet())
 	flags.AddFlagSet(runtimeOptionFlagSet(true))
 	flags.AddFlagSet(configFlagSet())
+	flags.AddFlagSet(c.checkpointFlagSet())
 	return flags
 }
 
@@ -505,6 +587,20 @@ a commandline interface for interacting with it.`,
 	return runCmd
 }
 
+// finalizeThresholdCalculation runs the final threshold calculations and
+// returns an error with the appropriate exit code if any were crossed.
+func finalizeThresholdCalculation(finalizeThresholds func() []string) error {
+	breachedThresholds := finalizeThresholds()
+	if len(breachedThresholds) == 0 {
+		return nil
+	}
+	return errext.WithAbortReasonIfNone(
+		errext.WithExitCodeIfNone(
+			fmt.Errorf("thresholds on metrics '%s' have been crossed", strings.Join(breachedThresholds, ", ")),
+			exitcodes.ThresholdsHaveFailed,
+		), errext.AbortedByThresholdsAfterTestEnd)
+}
+
 func handleSummaryResult(fs fsext.Fs, stdOut, stdErr io.Writer, result map[string]io.Reader) error {
 	var errs []error
 
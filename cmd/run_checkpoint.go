@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+
+	"go.k6.io/k6/execution"
+	"go.k6.io/k6/lib/fsext"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/metrics/engine"
+	"go.k6.io/k6/output"
+)
+
+const (
+	checkpointFileName = "checkpoint.json"
+
+	defaultCheckpointInterval = 30 * time.Second
+)
+
+// checkpointFlagSet returns the flags for saving the progress of the test
+// run, and for resuming it from a previous run.
+func (c *cmdRun) checkpointFlagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
+	flags.SortFlags = false
+	flags.StringVar(&c.checkpointDir, "checkpoint", c.checkpointDir,
+		"periodically save the progress of the test run in this directory, so it can be resumed with --resume")
+	flags.DurationVar(&c.checkpointInterval, "checkpoint-interval", defaultCheckpointInterval,
+		"how often to save the progress of the test run with --checkpoint")
+	flags.StringVar(&c.resumeDir, "resume", c.resumeDir,
+		"continue the test run from the checkpoint saved in this directory by a previous run")
+	return flags
+}
+
+// checkpointFile is what is saved at a checkpoint, the progress of the test run
+// and the snapshots of its aggregated metrics, which the resumed test keeps
+// adding to, so the summary and the thresholds cover all of its runs.
+type checkpointFile struct {
+	execution.Checkpoint
+	Metrics []engine.MetricSnapshot `json:"metrics,omitempty"`
+}
+
+// loadCheckpoint reads the checkpoint that was saved in the directory.
+func loadCheckpoint(fs fsext.Fs, dir string) (*checkpointFile, error) {
+	data, err := fsext.ReadFile(fs, filepath.Join(dir, checkpointFileName))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read the checkpoint to resume from: %w", err)
+	}
+	checkpoint := &checkpointFile{}
+	if err = json.Unmarshal(data, checkpoint); err != nil {
+		return nil, fmt.Errorf("couldn't parse the checkpoint in '%s': %w", dir, err)
+	}
+	return checkpoint, nil
+}
+
+// saveCheckpoint writes the checkpoint to the directory. It's first written to
+// a temporary file, so an interrupted write doesn't corrupt the last checkpoint.
+func saveCheckpoint(fs fsext.Fs, dir string, checkpoint *checkpointFile) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	if err = fs.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	fileName := filepath.Join(dir, checkpointFileName)
+	if err = fsext.WriteFile(fs, fileName+".tmp", data, 0o644); err != nil {
+		return err
+	}
+	return fs.Rename(fileName+".tmp", fileName)
+}
+
+// checkpointer takes the checkpoints of the test run, with the snapshots of the
+// aggregated metrics, if the metrics engine processes them.
+type checkpointer struct {
+	fs              fsext.Fs
+	dir             string
+	logger          logrus.FieldLogger
+	scheduler       *execution.Scheduler
+	metricsIngester *engine.OutputIngester
+}
+
+// save takes a checkpoint and writes it, it returns nil if it couldn't. The
+// test run is finished only at the final checkpoint, if it ran all of the plan.
+func (cp *checkpointer) save(finished bool) *checkpointFile {
+	checkpoint := &checkpointFile{}
+	takeCheckpoint := func() { checkpoint.Checkpoint = *cp.scheduler.GetCheckpoint() }
+	if cp.metricsIngester == nil {
+		takeCheckpoint()
+	} else {
+		// The progress is taken while the metrics are paused, after all of
+		// the buffered samples were added to them, so both match.
+		snapshots, err := cp.metricsIngester.GetSnapshots(takeCheckpoint)
+		if err != nil {
+			cp.logger.WithError(err).Error("Couldn't get the metrics for the checkpoint")
+			return nil
+		}
+		checkpoint.Metrics = snapshots
+	}
+	checkpoint.Finished = finished
+	if err := saveCheckpoint(cp.fs, cp.dir, checkpoint); err != nil {
+		cp.logger.WithError(err).Error("Couldn't save the checkpoint")
+		return nil
+	}
+	cp.logger.WithField("elapsed", checkpoint.Elapsed).Debug("Saved a checkpoint")
+	return checkpoint
+}
+
+// start saves a checkpoint on every interval, until the returned function is
+// called.
+func (cp *checkpointer) start(
+	ctx context.Context, interval time.Duration, samples chan<- metrics.SampleContainer,
+) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if checkpoint := cp.save(false); checkpoint != nil {
+					annotateCheckpoint(ctx, samples, "checkpoint", checkpoint.Checkpoint)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+// annotateCheckpoint sends the outputs an annotation about the checkpoint,
+// without the metrics, e.g. so they can mark the gap until the test run was
+// resumed from it.
+func annotateCheckpoint(
+	ctx context.Context, samples chan<- metrics.SampleContainer, text string, checkpoint execution.Checkpoint,
+) {
+	metrics.PushIfNotDone(ctx, samples, &output.Annotation{Time: time.Now(), Text: text, Data: checkpoint})
+}
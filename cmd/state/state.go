@@ -134,14 +134,15 @@ func NewGlobalState(ctx context.Context) *GlobalState {
 
 // GlobalFlags contains global config values that apply for all k6 sub-commands.
 type GlobalFlags struct {
-	ConfigFilePath   string
-	Quiet            bool
-	NoColor          bool
-	Address          string
-	ProfilingEnabled bool
-	LogOutput        string
-	LogFormat        string
-	Verbose          bool
+	ConfigFilePath         string
+	Quiet                  bool
+	NoColor                bool
+	Address                string
+	ProfilingEnabled       bool
+	MetricsEndpointEnabled bool
+	LogOutput              string
+	LogFormat              string
+	Verbose                bool
 }
 
 // GetDefaultFlags returns the default global flags.
@@ -180,5 +181,8 @@ func getFlags(defaultFlags GlobalFlags, env map[string]string) GlobalFlags {
 	if _, ok := env["K6_PROFILING_ENABLED"]; ok {
 		result.ProfilingEnabled = true
 	}
+	if _, ok := env["K6_METRICS_ENDPOINT_ENABLED"]; ok {
+		result.MetricsEndpointEnabled = true
+	}
 	return result
 }
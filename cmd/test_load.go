@@ -6,6 +6,7 @@ import (
 	"crypto/x509"
 	"fmt"
 	"io"
+	"net/url"
 	"path/filepath"
 	"sync"
 	"syscall"
@@ -61,6 +62,28 @@ func loadLocalTest(gs *state.GlobalState, cmd *cobra.Command, args []string) (*l
 		sourceRootPath, resolvedPath, len(src.Data),
 	)
 
+	return loadTest(gs, cmd, sourceRootPath, src, fileSystems, pwd)
+}
+
+// loadArchiveData loads a test from the given in-memory archive data, e.g.
+// one that a k6 agent received from the coordinator of a distributed test.
+func loadArchiveData(gs *state.GlobalState, cmd *cobra.Command, name string, data []byte) (*loadedTest, error) {
+	pwd, err := gs.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	src := &loader.SourceData{
+		URL:  &url.URL{Path: name, Scheme: "file"},
+		Data: data,
+	}
+	return loadTest(gs, cmd, name, src, loader.CreateFilesystems(gs.FS), pwd)
+}
+
+func loadTest(
+	gs *state.GlobalState, cmd *cobra.Command, sourceRootPath string,
+	src *loader.SourceData, fileSystems map[string]fsext.Fs, pwd string,
+) (*loadedTest, error) {
+	resolvedPath := src.URL.String()
 	gs.Logger.Debugf("Gathering k6 runtime options...")
 	runtimeOptions, err := getRuntimeOptions(cmd.Flags(), gs.Env)
 	if err != nil {
@@ -270,6 +293,16 @@ func (lct *loadedAndConfiguredTest) buildTestRunState(
 		return nil, err
 	}
 
+	if options := lct.derivedConfig.Options; options.TrendSink.String == lib.TrendSinkHistogram {
+		maxError := options.TrendSinkMaxError.Float64
+		if !options.TrendSinkMaxError.Valid {
+			maxError = lib.DefaultTrendSinkMaxError
+		}
+		lct.preInitState.Registry.SetTrendSinkFactory(func() *metrics.TrendSink {
+			return metrics.NewHistogramTrendSink(maxError)
+		})
+	}
+
 	// it pre-loads system certificates to avoid doing it on the first TLS request.
 	// This is done async to avoid blocking the rest of the loading process as it will not stop if it fails.
 	go loadSystemCertPool(lct.preInitState.Logger)
@@ -0,0 +1,150 @@
+package tests
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/cmd"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/lib/fsext"
+	"go.k6.io/k6/lib/testutils"
+)
+
+// runDistributedTest runs the given script with a k6 coordinator and the
+// given number of k6 agents and waits for all of them to finish.
+func runDistributedTest(
+	t *testing.T, script string, instanceCount int, expectedExitCode exitcodes.ExitCode,
+) (*GlobalTestState, []*GlobalTestState) {
+	t.Helper()
+
+	coordinator := NewGlobalTestState(t)
+	coordinator.ExpectedExitCode = int(expectedExitCode)
+	grpcAddress := getFreeBindAddr(t)
+	require.NoError(t, fsext.WriteFile(coordinator.FS, filepath.Join(coordinator.Cwd, "test.js"), []byte(script), 0o644))
+	coordinator.CmdArgs = []string{
+		"k6", "coordinator", "--grpc-address", grpcAddress, "--instance-count", strconv.Itoa(instanceCount), "test.js",
+	}
+
+	agents := make([]*GlobalTestState, instanceCount)
+	for i := range agents {
+		agents[i] = NewGlobalTestState(t)
+		agents[i].ExpectedExitCode = int(expectedExitCode)
+		agents[i].CmdArgs = []string{"k6", "agent", "-v", "--log-output=stdout", grpcAddress}
+	}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		cmd.ExecuteWithGlobalState(coordinator.GlobalState)
+	}()
+	for _, agent := range agents {
+		wg.Add(1)
+		go func(agent *GlobalTestState) {
+			defer wg.Done()
+			cmd.ExecuteWithGlobalState(agent.GlobalState)
+		}(agent)
+	}
+	wg.Wait()
+
+	return coordinator, agents
+}
+
+func TestDistributedCoordinatorAndAgents(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		import { check } from 'k6';
+
+		export const options = {
+			scenarios: {
+				example: {
+					executor: 'shared-iterations',
+					vus: 4,
+					iterations: 20,
+				},
+			},
+			thresholds: {
+				'iterations': ['count == 20'],
+				'checks': ['rate == 1'],
+				'checks{check:data}': ['rate == 1'],
+			},
+		};
+
+		export function setup() {
+			console.log('setup() ran');
+			return { foo: 'bar' };
+		}
+
+		export default function (data) {
+			check(data, { 'data': (d) => d.foo === 'bar' });
+		}
+
+		export function teardown() {
+			console.log('teardown() ran');
+		}
+	`
+
+	coordinator, agents := runDistributedTest(t, script, 2, 0)
+
+	assert.True(t, testutils.LogContains(coordinator.LoggerHook.Drain(), logrus.InfoLevel, "All instances ended!"))
+	var setupRuns, teardownRuns int
+	for _, agent := range agents {
+		stdout := agent.Stdout.String()
+		assert.Contains(t, stdout, "10 complete and 0 interrupted iterations")
+		assert.Contains(t, stdout, "(50.00%) 1 scenario")
+		assert.Regexp(t, `script: instance-\d+\.tar`, stdout)
+		assert.NotContains(t, stdout, "checks...")
+		setupRuns += strings.Count(stdout, "setup() ran")
+		teardownRuns += strings.Count(stdout, "teardown() ran")
+	}
+	assert.Equal(t, 1, setupRuns)
+	assert.Equal(t, 1, teardownRuns)
+
+	// The coordinator prints a single end-of-test summary for all instances
+	stdout := coordinator.Stdout.String()
+	assert.Contains(t, stdout, "✓ data")
+	assert.Contains(t, stdout, "✓ checks...............: 100.00% 20 out of 20")
+	assert.Contains(t, stdout, "✓ { check:data }.....: 100.00% 20 out of 20")
+	assert.Contains(t, stdout, "✓ iterations...........: 20")
+}
+
+func TestDistributedAbortOnFail(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		import { check, sleep } from 'k6';
+
+		export const options = {
+			scenarios: {
+				example: {
+					executor: 'constant-vus',
+					vus: 2,
+					duration: '30s',
+				},
+			},
+			thresholds: {
+				'checks': [{ threshold: 'rate == 1', abortOnFail: true }],
+			},
+		};
+
+		export default function () {
+			check(null, { 'fails': () => false });
+			sleep(0.1);
+		}
+	`
+
+	coordinator, agents := runDistributedTest(t, script, 2, exitcodes.ThresholdsHaveFailed)
+
+	assert.Contains(t, coordinator.Stdout.String(), "✗ checks...............: 0.00%")
+	for _, agent := range agents {
+		assert.Contains(t, agent.Stdout.String(), "thresholds on metrics 'checks' were crossed")
+	}
+}
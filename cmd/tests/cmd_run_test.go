@@ -441,10 +441,37 @@ func TestExecutionTestOptionsDefaultValues(t *testing.T) {
 	loglines := ts.LoggerHook.Drain()
 	require.Len(t, loglines, 1)
 
-	expected := `{"paused":null,"executionSegment":null,"executionSegmentSequence":null,"noSetup":null,"setupTimeout":null,"noTeardown":null,"teardownTimeout":null,"rps":null,"dns":{"ttl":null,"select":null,"policy":null},"maxRedirects":null,"userAgent":null,"batch":null,"batchPerHost":null,"httpDebug":null,"insecureSkipTLSVerify":null,"tlsCipherSuites":null,"tlsVersion":null,"tlsAuth":null,"throw":null,"thresholds":null,"blacklistIPs":null,"blockHostnames":null,"hosts":null,"noConnectionReuse":null,"noVUConnectionReuse":null,"minIterationDuration":null,"ext":null,"summaryTrendStats":["avg", "min", "med", "max", "p(90)", "p(95)"],"summaryTimeUnit":null,"systemTags":["check","error","error_code","expected_response","group","method","name","proto","scenario","service","status","subproto","tls_version","url"],"tags":null,"metricSamplesBufferSize":null,"noCookiesReset":null,"discardResponseBodies":null,"consoleOutput":null,"scenarios":{"default":{"vus":null,"iterations":1,"executor":"shared-iterations","maxDuration":null,"startTime":null,"env":null,"tags":null,"gracefulStop":null,"exec":null}},"localIPs":null}`
+	expected := `{"paused":null,"executionSegment":null,"executionSegmentSequence":null,"randomSeed":null,"noSetup":null,"setupTimeout":null,"noTeardown":null,"teardownTimeout":null,"rps":null,"httpLimits":null,"dns":{"ttl":null,"select":null,"policy":null},"maxRedirects":null,"userAgent":null,"batch":null,"batchPerHost":null,"httpDebug":null,"insecureSkipTLSVerify":null,"tlsCipherSuites":null,"tlsVersion":null,"tlsAuth":null,"throw":null,"thresholds":null,"blacklistIPs":null,"blockHostnames":null,"hosts":null,"noConnectionReuse":null,"http3":null,"noVUConnectionReuse":null,"minIterationDuration":null,"ext":null,"summaryTrendStats":["avg", "min", "med", "max", "p(90)", "p(95)"],"summaryTimeUnit":null,"trendSink":null,"trendSinkMaxError":null,"systemTags":["check","error","error_code","exec","expected_response","group","method","name","proto","scenario","service","status","subproto","tls_version","url"],"tags":null,"metricSamplesBufferSize":null,"noCookiesReset":null,"discardResponseBodies":null,"consoleOutput":null,"scenarios":{"default":{"vus":null,"iterations":1,"executor":"shared-iterations","maxDuration":null,"startTime":null,"env":null,"tags":null,"gracefulStop":null,"exec":null,"setup":null,"setupTimeout":null,"teardown":null,"teardownTimeout":null,"pacing":null}},"localIPs":null}`
 	assert.JSONEq(t, expected, loglines[0].Message)
 }
 
+func TestPacingLongerThanRemainingDuration(t *testing.T) {
+	t.Parallel()
+	script := `
+		export const options = {
+			scenarios: {
+				paced: {
+					executor: 'constant-vus',
+					vus: 1,
+					duration: '1s',
+					gracefulStop: '30s',
+					pacing: '10s',
+				},
+			},
+		};
+
+		export default function () {}
+	`
+
+	// The VU stops at the end of the duration instead of waiting for the next
+	// slot until the graceful stop interrupts it
+	ts := getSingleFileTestState(t, script, nil, 0)
+	startTime := time.Now()
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+	assert.Less(t, time.Since(startTime), 10*time.Second)
+	assert.Contains(t, ts.Stdout.String(), "1 complete and 0 interrupted iterations")
+}
+
 func TestSubMetricThresholdNoData(t *testing.T) {
 	t.Parallel()
 	script := `
@@ -718,6 +745,113 @@ func TestAbortedByThreshold(t *testing.T) {
 	assert.Contains(t, stdOut, `level=debug msg="Sending test finished" output=cloud ref=111 run_status=8 tainted=true`)
 }
 
+func TestThresholdsTimeWindow(t *testing.T) {
+	t.Parallel()
+	script := `
+		import { Trend } from 'k6/metrics';
+		import exec from 'k6/execution';
+
+		export const options = {
+			scenarios: {
+				sc1: {
+					executor: 'constant-arrival-rate',
+					duration: '5s',
+					rate: 2,
+					preAllocatedVUs: 2,
+				},
+			},
+			thresholds: {
+				// The spike at the start of the test is out of the window at its end
+				'my_trend': ['max<500 over 2s'],
+				'my_trend{spike:true}': [{ threshold: 'max<500', window: '2s' }],
+			},
+		};
+
+		const myTrend = new Trend('my_trend');
+
+		export default function () {
+			if (exec.scenario.iterationInTest == 0) {
+				myTrend.add(1000, { spike: 'true' });
+			} else {
+				myTrend.add(10);
+			}
+		};
+	`
+
+	ts := NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "test.js"), []byte(script), 0o644))
+	ts.CmdArgs = []string{"k6", "run", "test.js"}
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Contains(t, stdout, `✓ my_trend`)
+	assert.Contains(t, stdout, `max=1000`)
+	assert.Contains(t, stdout, `✓ { spike:true }`)
+}
+
+func TestThresholdsReferencingOtherMetrics(t *testing.T) {
+	t.Parallel()
+	script := `
+		import { Counter, Trend } from 'k6/metrics';
+
+		export const options = {
+			scenarios: {
+				browse: { executor: 'shared-iterations', vus: 1, iterations: 4 },
+				checkout: { executor: 'shared-iterations', vus: 1, iterations: 4 },
+			},
+			thresholds: {
+				'errs': ['count / iterations.count < 0.25'],
+				'my_trend{scenario:checkout}': ['max < 1.5 * my_trend{scenario:browse}.max'],
+			},
+		};
+
+		const errs = new Counter('errs');
+		const myTrend = new Trend('my_trend');
+
+		export default function () {
+			errs.add(1);
+			myTrend.add(100);
+		};
+	`
+
+	ts := NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "test.js"), []byte(script), 0o644))
+	ts.CmdArgs = []string{"k6", "run", "test.js"}
+	ts.ExpectedExitCode = int(exitcodes.ThresholdsHaveFailed)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	expErr := "thresholds on metrics 'errs' have been crossed"
+	assert.True(t, testutils.LogContains(ts.LoggerHook.Drain(), logrus.ErrorLevel, expErr))
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Contains(t, stdout, `✗ errs`)
+	assert.Contains(t, stdout, `✓ { scenario:checkout }`)
+}
+
+func TestThresholdsReferencingMissingMetric(t *testing.T) {
+	t.Parallel()
+	script := `
+		export const options = {
+			thresholds: {
+				'iterations': ['count < 2 * missing.count'],
+			},
+		};
+
+		export default function () {};
+	`
+
+	ts := NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "test.js"), []byte(script), 0o644))
+	ts.CmdArgs = []string{"k6", "run", "test.js"}
+	ts.ExpectedExitCode = int(exitcodes.InvalidConfig)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	assert.True(t, testutils.LogContains(
+		ts.LoggerHook.Drain(), logrus.ErrorLevel, `the referenced metric "missing" doesn't exist`,
+	))
+}
+
 func TestAbortedByUserWithGoodThresholds(t *testing.T) {
 	t.Parallel()
 	script := `
@@ -2403,3 +2537,115 @@ func TestTypeScriptSupport(t *testing.T) {
 	t.Log(stderr)
 	assert.Contains(t, stderr, `something 42`)
 }
+
+func TestParquetOutput(t *testing.T) {
+	t.Parallel()
+	script := `
+		import { Counter } from 'k6/metrics';
+
+		const myCounter = new Counter('my_counter');
+
+		export const options = {
+			iterations: 3,
+			thresholds: {
+				my_counter: ['count>1'],
+			},
+		};
+
+		export default function () {
+			myCounter.add(1, { custom: 'tag' });
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, []string{"--out", "parquet=results.parquet"}, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Contains(t, stdout, "output: parquet (results.parquet)")
+
+	results, err := fsext.ReadFile(ts.FS, "results.parquet")
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(results, []byte("PAR1")))
+	assert.True(t, bytes.HasSuffix(results, []byte("PAR1")))
+	assert.Contains(t, string(results), `{"my_counter":["count>1"]}`)
+	assert.Contains(t, string(results), `"iterations":3`)
+}
+
+func TestRunCheckpointAndResume(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		import exec from "k6/execution";
+		import { Counter } from "k6/metrics";
+
+		const setups = new Counter("setups");
+		const done = new Counter("done");
+
+		export const options = {
+			scenarios: {
+				first: { executor: "shared-iterations", iterations: 3, exec: "first" },
+				second: { executor: "shared-iterations", iterations: 4, exec: "second", startAfter: ["first"] },
+			},
+			thresholds: {
+				setups: ["count == 1"],
+				done: ["count == 6"],
+			},
+		};
+
+		export function setup() {
+			setups.add(1);
+			return { token: "abc" };
+		}
+
+		export function first() {
+			done.add(1);
+		}
+
+		export function second(data) {
+			if (data.token !== "abc") {
+				throw new Error("unexpected setup data " + JSON.stringify(data));
+			}
+			if (__ENV.INTERRUPT && exec.scenario.iterationInTest == 1) {
+				exec.test.abort("interrupted");
+			}
+			done.add(1);
+		}
+	`
+
+	ts := NewGlobalTestState(t)
+	dir := filepath.Join(ts.Cwd, "checkpoints")
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "test.js"), []byte(script), 0o644))
+	ts.CmdArgs = []string{"k6", "run", "--checkpoint", dir, "-e", "INTERRUPT=1", "--no-thresholds", "test.js"}
+	ts.ExpectedExitCode = int(exitcodes.ScriptAborted)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	data, err := fsext.ReadFile(ts.FS, filepath.Join(dir, "checkpoint.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"finished":false`)
+
+	// The second scenario continues with the 2 iterations it didn't start,
+	// and the thresholds include the metrics of the interrupted run.
+	resumed := NewGlobalTestState(t)
+	resumed.FS = ts.FS
+	resumed.CmdArgs = []string{"k6", "run", "--resume", dir, "--checkpoint", dir, "test.js"}
+	cmd.ExecuteWithGlobalState(resumed.GlobalState)
+
+	stdout := resumed.Stdout.String()
+	t.Log(stdout)
+	assert.Regexp(t, `✓ setups\.+: 1 `, stdout)
+	assert.Regexp(t, `✓ done\.+: 6 `, stdout)
+	assert.Contains(t, stdout, "* second: 2 iterations shared among 1 VUs")
+
+	data, err = fsext.ReadFile(ts.FS, filepath.Join(dir, "checkpoint.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"finished":true`)
+
+	// There is nothing left to resume
+	again := NewGlobalTestState(t)
+	again.FS = ts.FS
+	again.CmdArgs = []string{"k6", "run", "--resume", dir, "test.js"}
+	again.ExpectedExitCode = -1
+	cmd.ExecuteWithGlobalState(again.GlobalState)
+	assert.Contains(t, again.Stderr.String(), "the test had already finished at the checkpoint")
+}
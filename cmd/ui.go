@@ -134,6 +134,9 @@ func printExecutionDescription(
 	if gs.Flags.ProfilingEnabled && gs.Flags.Address != "" {
 		fmt.Fprintf(buf, "     profiling: %s\n", valueColor.Sprintf("http://%s/debug/pprof/", gs.Flags.Address))
 	}
+	if gs.Flags.MetricsEndpointEnabled && gs.Flags.Address != "" {
+		fmt.Fprintf(buf, "       metrics: %s\n", valueColor.Sprintf("http://%s/metrics", gs.Flags.Address))
+	}
 
 	fmt.Fprintf(buf, "\n")
 
@@ -0,0 +1,196 @@
+package execution
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+)
+
+// Checkpoint is the saved progress of a test run, from which a later run of
+// the same test can continue with the rest of its execution plan.
+type Checkpoint struct {
+	// Time is when the checkpoint was taken, and Elapsed is the duration of
+	// the test run until then, including any previous runs it resumed.
+	Time    time.Time      `json:"time"`
+	Elapsed types.Duration `json:"elapsed"`
+	// Finished is whether all of the execution plan had run, so there's
+	// nothing to resume.
+	Finished bool `json:"finished"`
+
+	FullIterations        uint64 `json:"fullIterations"`
+	InterruptedIterations uint64 `json:"interruptedIterations"`
+
+	// SetupDone is whether setup() had run, and SetupData is what it returned.
+	SetupDone bool            `json:"setupDone"`
+	SetupData json.RawMessage `json:"setupData,omitempty"`
+
+	Scenarios map[string]ScenarioCheckpoint `json:"scenarios"`
+}
+
+// ScenarioCheckpoint is the progress of a scenario at a checkpoint, and the
+// data returned by its setup function, if it had started.
+type ScenarioCheckpoint struct {
+	lib.ExecutorCheckpoint
+	SetupData json.RawMessage `json:"setupData,omitempty"`
+}
+
+// GetResumedScenarios returns the configs of the rest of the work of the
+// scenarios after the checkpoint. The scenarios that were done, or have no
+// work left, are left out. The ones that had started have to support resuming.
+func GetResumedScenarios(scenarios lib.ScenarioConfigs, checkpoint *Checkpoint) (lib.ScenarioConfigs, error) {
+	if checkpoint.Finished {
+		return nil, errors.New("the test had already finished at the checkpoint, there is nothing to resume")
+	}
+	for name := range checkpoint.Scenarios {
+		if _, ok := scenarios[name]; !ok {
+			return nil, fmt.Errorf("the checkpoint has scenario %s, which the test doesn't have", name)
+		}
+	}
+
+	result := make(lib.ScenarioConfigs, len(scenarios))
+	for name, config := range scenarios {
+		scenarioCheckpoint, ok := checkpoint.Scenarios[name]
+		if !ok {
+			result[name] = config
+			continue
+		}
+		if scenarioCheckpoint.Done {
+			continue
+		}
+		resumableConfig, ok := config.(lib.ResumableExecutorConfig)
+		if !ok {
+			if scenarioCheckpoint.Started {
+				return nil, fmt.Errorf("scenario %s can't be resumed, since the %s executor doesn't support it",
+					name, config.GetType())
+			}
+			result[name] = config
+			continue
+		}
+		if resumedConfig := resumableConfig.GetResumedConfig(scenarioCheckpoint.ExecutorCheckpoint); resumedConfig != nil {
+			result[name] = resumedConfig
+		}
+	}
+	return result, nil
+}
+
+// executorProgress tracks when an executor started to wait for its startTime,
+// when it started running, and when it ended or was stopped by the end of the
+// test run, for the checkpoints.
+type executorProgress struct {
+	mx                          sync.Mutex
+	waitStart, start, end, stop time.Time
+}
+
+func (p *executorProgress) mark(moment *time.Time) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	*moment = time.Now()
+}
+
+// getCheckpoint returns the progress of the executor at the given time, added
+// to its progress in the previous runs of the test, if it was resumed.
+func (p *executorProgress) getCheckpoint(
+	now time.Time, startTime time.Duration, previous lib.ExecutorCheckpoint,
+) lib.ExecutorCheckpoint {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	var checkpoint lib.ExecutorCheckpoint
+	var elapsed time.Duration
+	switch {
+	case !p.end.IsZero():
+		checkpoint.Started, checkpoint.Done = true, true
+		elapsed = p.end.Sub(p.start)
+	case !p.stop.IsZero():
+		checkpoint.Started = true
+		elapsed = p.stop.Sub(p.start)
+	case !p.start.IsZero():
+		checkpoint.Started = true
+		elapsed = now.Sub(p.start)
+	case !p.waitStart.IsZero():
+		elapsed = min(now.Sub(p.waitStart), startTime)
+	}
+
+	// The executors which had started continue right away in the resumed
+	// test, and the other ones wait for the rest of their startTime.
+	if previous.Started {
+		checkpoint.Started = true
+		checkpoint.Iterations = previous.Iterations
+		elapsed += time.Duration(previous.Elapsed)
+	} else if !checkpoint.Started {
+		elapsed += time.Duration(previous.Elapsed)
+	}
+	// it's precise enough to resume from, and the resumed durations are shorter
+	checkpoint.Elapsed = types.Duration(elapsed.Round(time.Millisecond))
+	return checkpoint
+}
+
+// ResumeFrom makes the scheduler continue the test run from the checkpoint of
+// a previous run. The scenarios of the test should already be the resumed
+// ones, from GetResumedScenarios(). It has to be called before Init().
+func (e *Scheduler) ResumeFrom(checkpoint *Checkpoint) {
+	e.resumedFrom = checkpoint
+	e.state.SetResumedDuration(time.Duration(checkpoint.Elapsed))
+	e.state.AddFullIterations(checkpoint.FullIterations)
+	e.state.AddInterruptedIterations(checkpoint.InterruptedIterations)
+}
+
+// GetCheckpoint returns the current progress of the test run, from which a
+// later run of the test can resume. It doesn't include the metrics, since the
+// scheduler doesn't aggregate them.
+func (e *Scheduler) GetCheckpoint() *Checkpoint {
+	now := time.Now()
+	runner := e.state.Test.Runner
+	checkpoint := &Checkpoint{
+		Time:                  now,
+		Elapsed:               types.Duration(e.state.GetCurrentTestRunDuration().Round(time.Millisecond)),
+		FullIterations:        e.state.GetFullIterationCount(),
+		InterruptedIterations: e.state.GetPartialIterationCount(),
+		SetupDone:             e.setupDone.Load(),
+		Scenarios:             make(map[string]ScenarioCheckpoint, len(e.executorConfigs)),
+	}
+	if checkpoint.SetupDone {
+		checkpoint.SetupData = runner.GetSetupData()
+	}
+
+	// The scenarios which were left out of the resumed test, and the ones
+	// which have no work in this instance, are done.
+	var previous map[string]ScenarioCheckpoint
+	if e.resumedFrom != nil {
+		previous = e.resumedFrom.Scenarios
+	}
+	for name, scenarioCheckpoint := range previous {
+		scenarioCheckpoint.Done = true
+		checkpoint.Scenarios[name] = scenarioCheckpoint
+	}
+	for _, config := range e.executorConfigs {
+		if _, hasExecutor := e.progress[config.GetName()]; !hasExecutor {
+			scenarioCheckpoint := previous[config.GetName()]
+			scenarioCheckpoint.Done = true
+			checkpoint.Scenarios[config.GetName()] = scenarioCheckpoint
+		}
+	}
+
+	for _, executor := range e.executors {
+		config := executor.GetConfig()
+		name := config.GetName()
+		scenarioCheckpoint := ScenarioCheckpoint{
+			ExecutorCheckpoint: e.progress[name].getCheckpoint(
+				now, config.GetStartTime(), previous[name].ExecutorCheckpoint,
+			),
+		}
+		if resumableExecutor, ok := executor.(lib.ResumableExecutor); ok {
+			scenarioCheckpoint.Iterations += resumableExecutor.GetStartedIterations()
+		}
+		if scenarioCheckpoint.Started {
+			scenarioCheckpoint.SetupData = runner.GetScenarioSetupData(name)
+		}
+		checkpoint.Scenarios[name] = scenarioCheckpoint
+	}
+	return checkpoint
+}
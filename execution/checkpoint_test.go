@@ -0,0 +1,156 @@
+package execution_test
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/execution"
+	"go.k6.io/k6/execution/local"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/executor"
+	"go.k6.io/k6/lib/testutils/minirunner"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+func TestGetResumedScenarios(t *testing.T) {
+	t.Parallel()
+
+	adaptive := executor.NewAdaptiveArrivalRateConfig("adaptive")
+	scenarios := lib.ScenarioConfigs{
+		"done":     getTestIterationsConfig("done", 3),
+		"started":  getTestIterationsConfig("started", 3),
+		"waiting":  getTestIterationsConfig("waiting", 3),
+		"adaptive": adaptive,
+	}
+
+	t.Run("resumed", func(t *testing.T) {
+		t.Parallel()
+		resumed, err := execution.GetResumedScenarios(scenarios, &execution.Checkpoint{
+			Scenarios: map[string]execution.ScenarioCheckpoint{
+				"done":     {ExecutorCheckpoint: lib.ExecutorCheckpoint{Started: true, Done: true, Iterations: 3}},
+				"started":  {ExecutorCheckpoint: lib.ExecutorCheckpoint{Started: true, Iterations: 1}},
+				"waiting":  {},
+				"adaptive": {},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, resumed, 3)
+		assert.Equal(t, null.IntFrom(2), resumed["started"].(executor.SharedIterationsConfig).Iterations) //nolint:forcetypeassert
+		assert.Equal(t, null.IntFrom(3), resumed["waiting"].(executor.SharedIterationsConfig).Iterations) //nolint:forcetypeassert
+		assert.Equal(t, scenarios["adaptive"], resumed["adaptive"])
+	})
+
+	t.Run("finished", func(t *testing.T) {
+		t.Parallel()
+		_, err := execution.GetResumedScenarios(scenarios, &execution.Checkpoint{Finished: true})
+		assert.ErrorContains(t, err, "there is nothing to resume")
+	})
+
+	t.Run("unknown scenario", func(t *testing.T) {
+		t.Parallel()
+		_, err := execution.GetResumedScenarios(scenarios, &execution.Checkpoint{
+			Scenarios: map[string]execution.ScenarioCheckpoint{"other": {}},
+		})
+		assert.ErrorContains(t, err, "the checkpoint has scenario other, which the test doesn't have")
+	})
+
+	t.Run("not resumable", func(t *testing.T) {
+		t.Parallel()
+		_, err := execution.GetResumedScenarios(scenarios, &execution.Checkpoint{
+			Scenarios: map[string]execution.ScenarioCheckpoint{
+				"adaptive": {ExecutorCheckpoint: lib.ExecutorCheckpoint{Started: true}},
+			},
+		})
+		assert.ErrorContains(t, err, "scenario adaptive can't be resumed")
+	})
+}
+
+func runCheckpointedScheduler(
+	t *testing.T, scenarios lib.ScenarioConfigs, runner *minirunner.MiniRunner, resumeFrom *execution.Checkpoint,
+) *execution.Checkpoint {
+	t.Helper()
+	testRunState := getTestRunState(t, getTestPreInitState(t), lib.Options{Scenarios: scenarios}, runner)
+	execScheduler, err := execution.NewScheduler(testRunState, local.NewController())
+	require.NoError(t, err)
+	if resumeFrom != nil {
+		execScheduler.ResumeFrom(resumeFrom)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	samples := make(chan metrics.SampleContainer, 1000)
+	go func() {
+		for range samples { //nolint:revive
+		}
+	}()
+	defer close(samples)
+
+	stopEmission, err := execScheduler.Init(ctx, samples)
+	require.NoError(t, err)
+	defer stopEmission()
+	require.NoError(t, execScheduler.Run(ctx, ctx, samples))
+	return execScheduler.GetCheckpoint()
+}
+
+func TestSchedulerCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	var setups, iterations atomic.Int64
+	runner := &minirunner.MiniRunner{
+		SetupFn: func(_ context.Context, _ chan<- metrics.SampleContainer) ([]byte, error) {
+			setups.Add(1)
+			return []byte(`{"token":"abc"}`), nil
+		},
+		Fn: func(_ context.Context, _ *lib.State, _ chan<- metrics.SampleContainer) error {
+			iterations.Add(1)
+			return nil
+		},
+	}
+	later := getTestIterationsConfig("later", 2)
+	later.StartTime = types.NullDurationFrom(time.Hour)
+	scenarios := lib.ScenarioConfigs{"first": getTestIterationsConfig("first", 3), "later": later}
+
+	// The first run only runs the first scenario, like it was interrupted
+	// while the later one was waiting to start.
+	checkpoint := runCheckpointedScheduler(t, lib.ScenarioConfigs{"first": scenarios["first"]}, runner, nil)
+	assert.True(t, checkpoint.SetupDone)
+	assert.JSONEq(t, `{"token":"abc"}`, string(checkpoint.SetupData))
+	assert.Equal(t, uint64(3), checkpoint.FullIterations)
+	first := checkpoint.Scenarios["first"]
+	assert.True(t, first.Started)
+	assert.True(t, first.Done)
+	assert.Equal(t, uint64(3), first.Iterations)
+
+	// The checkpoint is saved as JSON
+	data, err := json.Marshal(checkpoint)
+	require.NoError(t, err)
+	checkpoint = &execution.Checkpoint{}
+	require.NoError(t, json.Unmarshal(data, checkpoint))
+	checkpoint.Scenarios["later"] = execution.ScenarioCheckpoint{
+		ExecutorCheckpoint: lib.ExecutorCheckpoint{Elapsed: types.Duration(time.Hour)},
+	}
+
+	resumed, err := execution.GetResumedScenarios(scenarios, checkpoint)
+	require.NoError(t, err)
+	require.Len(t, resumed, 1)
+	assert.Equal(t, time.Duration(0), resumed["later"].GetStartTime())
+
+	runner.SetupData = nil
+	checkpoint = runCheckpointedScheduler(t, resumed, runner, checkpoint)
+	assert.Equal(t, int64(1), setups.Load(), "setup() shouldn't run again")
+	assert.JSONEq(t, `{"token":"abc"}`, string(runner.GetSetupData()))
+	assert.Equal(t, int64(5), iterations.Load())
+	assert.Equal(t, uint64(5), checkpoint.FullIterations)
+	assert.True(t, checkpoint.Scenarios["first"].Done)
+	assert.True(t, checkpoint.Scenarios["later"].Done)
+	assert.Equal(t, uint64(2), checkpoint.Scenarios["later"].Iterations)
+	assert.GreaterOrEqual(t, time.Duration(checkpoint.Elapsed), time.Duration(first.Elapsed))
+}
@@ -0,0 +1,257 @@
+package distributed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// AgentController implements the execution.Controller interface for a single
+// k6 instance that is a part of a distributed test. It sends the signals and
+// data requests to the coordinator and waits for its responses.
+type AgentController struct {
+	ctx        context.Context
+	instanceID uint32
+	client     DistributedTestClient
+	cnc        DistributedTest_CommandAndControlClient
+	logger     logrus.FieldLogger
+
+	sendMx sync.Mutex
+
+	mx          sync.Mutex
+	barriers    map[string]*agentBarrier
+	dataWaiters map[string]chan *ControllerMessage
+	streamErr   error // set when the connection to the coordinator is lost
+
+	recvDone chan struct{}
+}
+
+type agentBarrier struct {
+	done chan struct{}
+	err  error
+}
+
+// NewAgentController opens the command and control stream to the coordinator
+// and starts listening for its messages in a background goroutine.
+func NewAgentController(
+	ctx context.Context, instanceID uint32, client DistributedTestClient, logger logrus.FieldLogger,
+) (*AgentController, error) {
+	cnc, err := client.CommandAndControl(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	logger = logger.WithField("instance", instanceID)
+	logger.Debug("Sending instance ID to the coordinator...")
+	err = cnc.Send(&AgentMessage{Message: &AgentMessage_InitInstanceID{InitInstanceID: instanceID}})
+	if err != nil {
+		return nil, err
+	}
+
+	ac := &AgentController{
+		ctx:         ctx,
+		instanceID:  instanceID,
+		client:      client,
+		cnc:         cnc,
+		logger:      logger,
+		barriers:    make(map[string]*agentBarrier),
+		dataWaiters: make(map[string]chan *ControllerMessage),
+		recvDone:    make(chan struct{}),
+	}
+	go ac.receiveMessages()
+
+	return ac, nil
+}
+
+func (ac *AgentController) receiveMessages() {
+	defer close(ac.recvDone)
+	for {
+		msgContainer, err := ac.cnc.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = errors.New("the coordinator closed the connection")
+			} else {
+				err = fmt.Errorf("lost the connection to the coordinator: %w", err)
+			}
+			ac.logger.WithError(err).Debug("Stopped receiving messages from the coordinator")
+			ac.failAll(err)
+			return
+		}
+
+		switch msg := msgContainer.Message.(type) {
+		case *ControllerMessage_DoneWaitWithID:
+			ac.doneWait(msg.DoneWaitWithID)
+		case *ControllerMessage_CreateDataWithID:
+			ac.notifyDataWaiter(msg.CreateDataWithID, msgContainer)
+		case *ControllerMessage_DataWithID:
+			ac.notifyDataWaiter(msg.DataWithID.ID, msgContainer)
+		default:
+			ac.logger.Warnf("Received an unknown message type %T from the coordinator", msg)
+		}
+	}
+}
+
+func (ac *AgentController) failAll(err error) {
+	ac.mx.Lock()
+	defer ac.mx.Unlock()
+	ac.streamErr = err
+	for _, b := range ac.barriers {
+		select {
+		case <-b.done:
+		default:
+			b.err = err
+			close(b.done)
+		}
+	}
+	for id, ch := range ac.dataWaiters {
+		close(ch)
+		delete(ac.dataWaiters, id)
+	}
+}
+
+// getBarrier returns the barrier for the given event ID, creating it if it
+// doesn't exist. It has to be called with the mutex locked.
+func (ac *AgentController) getBarrier(eventID string) *agentBarrier {
+	b, ok := ac.barriers[eventID]
+	if !ok {
+		b = &agentBarrier{done: make(chan struct{})}
+		ac.barriers[eventID] = b
+		if ac.streamErr != nil {
+			b.err = ac.streamErr
+			close(b.done)
+		}
+	}
+	return b
+}
+
+func (ac *AgentController) doneWait(signal *SignalPacket) {
+	ac.mx.Lock()
+	defer ac.mx.Unlock()
+	b := ac.getBarrier(signal.ID)
+	select {
+	case <-b.done:
+		return // already done, nothing to do
+	default:
+	}
+	if signal.Error != "" {
+		b.err = errors.New(signal.Error)
+	}
+	close(b.done)
+}
+
+func (ac *AgentController) notifyDataWaiter(dataID string, msg *ControllerMessage) {
+	ac.mx.Lock()
+	defer ac.mx.Unlock()
+	ch, ok := ac.dataWaiters[dataID]
+	if !ok {
+		ac.logger.Warnf("Received unexpected data message for '%s'", dataID)
+		return
+	}
+	delete(ac.dataWaiters, dataID)
+	ch <- msg
+}
+
+func (ac *AgentController) send(msg *AgentMessage) error {
+	ac.sendMx.Lock()
+	defer ac.sendMx.Unlock()
+	return ac.cnc.Send(msg)
+}
+
+// GetOrCreateData asks the coordinator for the data with the given ID. If this
+// is the first instance that has requested it, the coordinator will ask it to
+// execute the callback and the result will be shared with all other instances.
+func (ac *AgentController) GetOrCreateData(dataID string, callback func() ([]byte, error)) ([]byte, error) {
+	ac.logger.Debugf("GetOrCreateData(%s)", dataID)
+
+	ac.mx.Lock()
+	if ac.streamErr != nil {
+		ac.mx.Unlock()
+		return nil, ac.streamErr
+	}
+	if _, ok := ac.dataWaiters[dataID]; ok {
+		ac.mx.Unlock()
+		return nil, fmt.Errorf("there is already a pending request for the data with ID '%s'", dataID)
+	}
+	resp := make(chan *ControllerMessage, 1)
+	ac.dataWaiters[dataID] = resp
+	ac.mx.Unlock()
+
+	err := ac.send(&AgentMessage{Message: &AgentMessage_GetOrCreateDataWithID{GetOrCreateDataWithID: dataID}})
+	if err != nil {
+		return nil, err
+	}
+
+	msgContainer, ok := <-resp
+	if !ok {
+		return nil, ac.getStreamErr()
+	}
+
+	switch msg := msgContainer.Message.(type) {
+	case *ControllerMessage_CreateDataWithID:
+		ac.logger.Debugf("Creating the data for '%s'...", dataID)
+		data, cerr := callback()
+		packet := &DataPacket{ID: dataID, Data: data}
+		if cerr != nil {
+			packet.Error = cerr.Error()
+		}
+		if err := ac.send(&AgentMessage{Message: &AgentMessage_CreatedData{CreatedData: packet}}); err != nil {
+			ac.logger.WithError(err).Errorf("Could not send the data for '%s' to the coordinator", dataID)
+		}
+		return data, cerr
+	case *ControllerMessage_DataWithID:
+		ac.logger.Debugf("Received the data for '%s'", dataID)
+		if msg.DataWithID.Error != "" {
+			return nil, errors.New(msg.DataWithID.Error)
+		}
+		return msg.DataWithID.Data, nil
+	default:
+		return nil, fmt.Errorf("received an unexpected message type %T for the data '%s'", msg, dataID)
+	}
+}
+
+func (ac *AgentController) getStreamErr() error {
+	ac.mx.Lock()
+	defer ac.mx.Unlock()
+	return ac.streamErr
+}
+
+// Signal notifies the coordinator that this instance has reached the given
+// event ID, or that it has had an error.
+func (ac *AgentController) Signal(eventID string, sigErr error) error {
+	ac.logger.Debugf("Signal(%s, %v)", eventID, sigErr)
+	packet := &SignalPacket{ID: eventID}
+	if sigErr != nil {
+		packet.Error = sigErr.Error()
+	}
+	return ac.send(&AgentMessage{Message: &AgentMessage_Signal{Signal: packet}})
+}
+
+// Subscribe creates a listener for the specified event ID and returns a
+// callback that waits until the coordinator reports that all instances have
+// reached it, or that one of them has had an error.
+func (ac *AgentController) Subscribe(eventID string) func() error {
+	ac.logger.Debugf("Subscribe(%s)", eventID)
+	ac.mx.Lock()
+	b := ac.getBarrier(eventID)
+	ac.mx.Unlock()
+
+	return func() error {
+		<-b.done
+		ac.logger.Debugf("Done waiting for '%s'", eventID)
+		return b.err
+	}
+}
+
+// Close notifies the coordinator that this instance is done and waits for
+// it to close the stream.
+func (ac *AgentController) Close() error {
+	ac.sendMx.Lock()
+	err := ac.cnc.CloseSend()
+	ac.sendMx.Unlock()
+	<-ac.recvDone
+	return err
+}
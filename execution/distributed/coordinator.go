@@ -0,0 +1,470 @@
+package distributed
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics/engine"
+)
+
+// CoordinatorServer coordinates multiple k6 agents. It hands out the test
+// archive with a different execution segment to each of them and it
+// implements the server side of the execution.Controller primitives, so the
+// agents can synchronize their execution and share data between themselves.
+type CoordinatorServer struct {
+	UnimplementedDistributedTestServer
+	instanceCount int
+	archives      [][]byte // one per instance, with their execution segment set
+	logger        logrus.FieldLogger
+
+	currentInstance int32 // atomic
+	cc              *coordinatorController
+	metrics         *coordinatorMetrics
+}
+
+// NewCoordinatorServer initializes and returns a new CoordinatorServer. It
+// splits the test into instanceCount execution segments, using the execution
+// segment sequence from the test options, if there is one. Otherwise the
+// test is evenly divided between all instances.
+//
+// The metrics that the agents send are merged into the given metrics engine,
+// so that it can evaluate the thresholds for the whole test.
+func NewCoordinatorServer(
+	instanceCount int, test *lib.Archive, metricsEngine *engine.MetricsEngine, logger logrus.FieldLogger,
+) (*CoordinatorServer, error) {
+	segments, err := getInstanceSegments(instanceCount, test.Options)
+	if err != nil {
+		return nil, err
+	}
+	ess, err := lib.NewExecutionSegmentSequence(segments...)
+	if err != nil {
+		return nil, err
+	}
+
+	// All of the instances draw the random parts of the execution, like the
+	// arrivals of the scenarios, with the same seed, so their segments split
+	// the same random sequence.
+	randomSeed := test.Options.RandomSeed
+	if !randomSeed.Valid {
+		randomSeed = null.IntFrom(rand.Int63()) //nolint:gosec
+	}
+
+	// Archive.Write() is not safe for concurrent use, so we pre-generate all
+	// of the instance archives here, instead of on every Register() call.
+	archives := make([][]byte, instanceCount)
+	for i, segment := range segments {
+		instanceArc := *test
+		instanceArc.Options.ExecutionSegment = segment
+		instanceArc.Options.ExecutionSegmentSequence = &ess
+		instanceArc.Options.RandomSeed = randomSeed
+		buf := &bytes.Buffer{}
+		if err := instanceArc.Write(buf); err != nil {
+			return nil, err
+		}
+		archives[i] = buf.Bytes()
+	}
+
+	cs := &CoordinatorServer{
+		instanceCount: instanceCount,
+		archives:      archives,
+		logger:        logger,
+		cc:            newCoordinatorController(instanceCount, logger),
+		metrics:       newCoordinatorMetrics(metricsEngine),
+	}
+
+	return cs, nil
+}
+
+func getInstanceSegments(instanceCount int, options lib.Options) ([]*lib.ExecutionSegment, error) {
+	if instanceCount < 1 {
+		return nil, fmt.Errorf("the number of instances must be at least 1, %d received", instanceCount)
+	}
+	if options.ExecutionSegmentSequence == nil {
+		return options.ExecutionSegment.Split(int64(instanceCount))
+	}
+
+	ess := lib.GetFilledExecutionSegmentSequence(options.ExecutionSegmentSequence, options.ExecutionSegment)
+	if len(ess) != instanceCount {
+		return nil, fmt.Errorf(
+			"the execution segment sequence '%s' has %d segments, but the test needs exactly %d, one per instance",
+			ess, len(ess), instanceCount,
+		)
+	}
+	return ess, nil
+}
+
+// Register assigns a unique instance ID to the agent that calls it and returns
+// the test archive with the execution segment for that particular instance.
+func (cs *CoordinatorServer) Register(context.Context, *RegisterRequest) (*RegisterResponse, error) {
+	instanceID := atomic.AddInt32(&cs.currentInstance, 1)
+	if instanceID > int32(cs.instanceCount) { //nolint:gosec
+		return nil, fmt.Errorf("we don't need any more instances, all %d have already registered", cs.instanceCount)
+	}
+	cs.logger.Infof("Instance %d of %d connected!", instanceID, cs.instanceCount)
+
+	return &RegisterResponse{
+		InstanceID: uint32(instanceID),
+		Archive:    cs.archives[instanceID-1],
+	}, nil
+}
+
+// CommandAndControl handles the bidirectional stream with a single agent,
+// through which it synchronizes its execution with all other agents.
+func (cs *CoordinatorServer) CommandAndControl(stream DistributedTest_CommandAndControlServer) error {
+	msgContainer, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+
+	initInstMsg, ok := msgContainer.Message.(*AgentMessage_InitInstanceID)
+	if !ok {
+		return errors.New("received wrong message type, expected the instance ID")
+	}
+	instanceID := initInstMsg.InitInstanceID
+	if instanceID < 1 || instanceID > uint32(cs.instanceCount) { //nolint:gosec
+		return fmt.Errorf("invalid instance ID %d", instanceID)
+	}
+	is := newInstanceStream(instanceID, stream, cs.logger)
+	if err := cs.cc.connectInstance(is); err != nil {
+		return err
+	}
+	defer func() {
+		is.stop()
+		cs.cc.finishInstance()
+	}()
+
+	for {
+		msgContainer, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			cs.logger.Debugf("Instance %d disconnected", instanceID)
+			cs.cc.disconnectInstance(instanceID, nil)
+			return nil
+		}
+		if err != nil {
+			cs.logger.WithError(err).Errorf("Instance %d disconnected unexpectedly", instanceID)
+			cs.cc.disconnectInstance(instanceID, err)
+			return err
+		}
+
+		switch msg := msgContainer.Message.(type) {
+		case *AgentMessage_Signal:
+			cs.cc.signal(instanceID, msg.Signal)
+		case *AgentMessage_GetOrCreateDataWithID:
+			cs.cc.getOrCreateData(instanceID, msg.GetOrCreateDataWithID)
+		case *AgentMessage_CreatedData:
+			cs.cc.setData(instanceID, msg.CreatedData)
+		default:
+			cs.logger.Warnf("Received an unknown message type %T from instance %d", msg, instanceID)
+		}
+	}
+}
+
+// Done returns a channel that is closed once all of the instances have
+// connected and then disconnected, i.e. when the distributed test is over.
+func (cs *CoordinatorServer) Done() <-chan struct{} {
+	return cs.cc.done
+}
+
+// Err returns the error that the test was aborted with, if AbortTest() was
+// called. Otherwise, it returns the first error that any of the instances has
+// signalled, or the first failure caused by an instance that disconnected
+// unexpectedly.
+func (cs *CoordinatorServer) Err() error {
+	if err := cs.metrics.getAbortErr(); err != nil {
+		return err
+	}
+	return cs.cc.getErr()
+}
+
+// instanceStream wraps the stream to a single agent. The messages to it are
+// queued and sent by its own goroutine, since the gRPC streams are not safe
+// for concurrent sends, and so a slow agent doesn't hold up the others.
+type instanceStream struct {
+	id     uint32
+	stream DistributedTest_CommandAndControlServer
+	logger logrus.FieldLogger
+
+	mx      sync.Mutex
+	queue   []*ControllerMessage
+	wake    chan struct{}
+	stopped chan struct{}
+	done    chan struct{}
+}
+
+func newInstanceStream(
+	id uint32, stream DistributedTest_CommandAndControlServer, logger logrus.FieldLogger,
+) *instanceStream {
+	is := &instanceStream{
+		id:      id,
+		stream:  stream,
+		logger:  logger,
+		wake:    make(chan struct{}, 1),
+		stopped: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go is.sendQueued()
+	return is
+}
+
+// send queues the message to the agent, it never blocks.
+func (is *instanceStream) send(msg *ControllerMessage) {
+	is.mx.Lock()
+	is.queue = append(is.queue, msg)
+	is.mx.Unlock()
+	select {
+	case is.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (is *instanceStream) sendQueued() {
+	defer close(is.done)
+	for {
+		var stopped bool
+		select {
+		case <-is.wake:
+		case <-is.stopped:
+			stopped = true
+		}
+		is.mx.Lock()
+		queue := is.queue
+		is.queue = nil
+		is.mx.Unlock()
+
+		for _, msg := range queue {
+			if err := is.stream.Send(msg); err != nil {
+				is.logger.WithError(err).Warnf("Could not send a message to instance %d", is.id)
+			}
+		}
+		if stopped {
+			return
+		}
+	}
+}
+
+// stop waits for the queued messages to be sent and stops the sending
+// goroutine. It has to be called before the stream handler returns, since the
+// stream can't be used after that.
+func (is *instanceStream) stop() {
+	close(is.stopped)
+	<-is.done
+}
+
+type coordinatorBarrier struct {
+	signalled map[uint32]bool
+	done      bool
+	err       string
+}
+
+type coordinatorData struct {
+	creator uint32
+	waiting []uint32
+	result  *DataPacket // nil until the data is created
+}
+
+// coordinatorController implements the logic behind the barriers and the
+// shared data for all of the connected agents.
+type coordinatorController struct {
+	instanceCount int
+	logger        logrus.FieldLogger
+
+	mx           sync.Mutex
+	instances    map[uint32]*instanceStream
+	disconnected map[uint32]bool
+	barriers     map[string]*coordinatorBarrier
+	data         map[string]*coordinatorData
+	firstErr     error
+
+	// The number of instances that were connected and have finished, done is
+	// closed once all of them have.
+	finished int
+	done     chan struct{}
+}
+
+func newCoordinatorController(instanceCount int, logger logrus.FieldLogger) *coordinatorController {
+	return &coordinatorController{
+		instanceCount: instanceCount,
+		logger:        logger,
+		instances:     make(map[uint32]*instanceStream),
+		disconnected:  make(map[uint32]bool),
+		barriers:      make(map[string]*coordinatorBarrier),
+		data:          make(map[string]*coordinatorData),
+		done:          make(chan struct{}),
+	}
+}
+
+func (cc *coordinatorController) connectInstance(is *instanceStream) error {
+	cc.mx.Lock()
+	defer cc.mx.Unlock()
+	if _, ok := cc.instances[is.id]; ok || cc.disconnected[is.id] {
+		is.stop()
+		return fmt.Errorf("instance %d is already connected", is.id)
+	}
+	cc.instances[is.id] = is
+	return nil
+}
+
+// disconnectInstance removes the given instance and fails any barriers it
+// has not reached yet and any data it was supposed to create, since they can
+// no longer be completed.
+func (cc *coordinatorController) disconnectInstance(id uint32, err error) {
+	cc.mx.Lock()
+	defer cc.mx.Unlock()
+	delete(cc.instances, id)
+	cc.disconnected[id] = true
+
+	reason := fmt.Sprintf("instance %d disconnected", id)
+	if err != nil {
+		reason = fmt.Sprintf("instance %d disconnected unexpectedly: %s", id, err)
+	}
+	for eventID, b := range cc.barriers {
+		if !b.done && !b.signalled[id] {
+			cc.failBarrier(eventID, b, reason)
+		}
+	}
+	for dataID, d := range cc.data {
+		if d.result == nil && d.creator == id {
+			cc.completeData(&DataPacket{ID: dataID, Error: reason})
+		}
+	}
+}
+
+// finishInstance is called when the stream of an instance that was accepted
+// by connectInstance() is closed, after all messages to it have been sent.
+func (cc *coordinatorController) finishInstance() {
+	cc.mx.Lock()
+	defer cc.mx.Unlock()
+	cc.finished++
+	if cc.finished == cc.instanceCount {
+		close(cc.done)
+	}
+}
+
+func (cc *coordinatorController) getErr() error {
+	cc.mx.Lock()
+	defer cc.mx.Unlock()
+	return cc.firstErr
+}
+
+func (cc *coordinatorController) sendTo(ids []uint32, msg *ControllerMessage) {
+	for _, id := range ids {
+		if is, ok := cc.instances[id]; ok {
+			is.send(msg)
+		}
+	}
+}
+
+func (cc *coordinatorController) connectedIDs() []uint32 {
+	ids := make([]uint32, 0, len(cc.instances))
+	for id := range cc.instances {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (cc *coordinatorController) failBarrier(eventID string, b *coordinatorBarrier, reason string) {
+	b.done = true
+	b.err = reason
+	if cc.firstErr == nil {
+		cc.firstErr = errors.New(reason)
+	}
+	cc.logger.Debugf("Barrier '%s' failed: %s", eventID, reason)
+	cc.sendTo(cc.connectedIDs(), &ControllerMessage{Message: &ControllerMessage_DoneWaitWithID{
+		DoneWaitWithID: &SignalPacket{ID: eventID, Error: reason},
+	}})
+}
+
+func (cc *coordinatorController) signal(id uint32, signal *SignalPacket) {
+	cc.mx.Lock()
+	defer cc.mx.Unlock()
+
+	b, ok := cc.barriers[signal.ID]
+	if !ok {
+		b = &coordinatorBarrier{signalled: make(map[uint32]bool)}
+		cc.barriers[signal.ID] = b
+	}
+	if b.done {
+		// The barrier was already completed or failed, so we just tell the
+		// late instance what happened with it.
+		cc.sendTo([]uint32{id}, &ControllerMessage{Message: &ControllerMessage_DoneWaitWithID{
+			DoneWaitWithID: &SignalPacket{ID: signal.ID, Error: b.err},
+		}})
+		return
+	}
+
+	if signal.Error != "" {
+		cc.failBarrier(signal.ID, b, fmt.Sprintf("instance %d: %s", id, signal.Error))
+		return
+	}
+	b.signalled[id] = true
+
+	for disconnectedID := range cc.disconnected {
+		if !b.signalled[disconnectedID] {
+			cc.failBarrier(signal.ID, b, fmt.Sprintf("instance %d disconnected", disconnectedID))
+			return
+		}
+	}
+
+	if len(b.signalled) < cc.instanceCount {
+		return
+	}
+	b.done = true
+	cc.logger.Debugf("All instances reached '%s'", signal.ID)
+	cc.sendTo(cc.connectedIDs(), &ControllerMessage{Message: &ControllerMessage_DoneWaitWithID{
+		DoneWaitWithID: &SignalPacket{ID: signal.ID},
+	}})
+}
+
+func (cc *coordinatorController) getOrCreateData(id uint32, dataID string) {
+	cc.mx.Lock()
+	defer cc.mx.Unlock()
+
+	d, ok := cc.data[dataID]
+	switch {
+	case !ok:
+		cc.data[dataID] = &coordinatorData{creator: id}
+		cc.logger.Debugf("Instance %d will create the data for '%s'", id, dataID)
+		cc.sendTo([]uint32{id}, &ControllerMessage{Message: &ControllerMessage_CreateDataWithID{
+			CreateDataWithID: dataID,
+		}})
+	case d.result != nil:
+		cc.sendTo([]uint32{id}, &ControllerMessage{Message: &ControllerMessage_DataWithID{DataWithID: d.result}})
+	default:
+		d.waiting = append(d.waiting, id)
+	}
+}
+
+func (cc *coordinatorController) setData(id uint32, packet *DataPacket) {
+	cc.mx.Lock()
+	defer cc.mx.Unlock()
+
+	d, ok := cc.data[packet.ID]
+	if !ok || d.creator != id || d.result != nil {
+		cc.logger.Warnf("Instance %d sent unexpected data for '%s'", id, packet.ID)
+		return
+	}
+	if packet.Error != "" {
+		packet.Error = fmt.Sprintf("instance %d: %s", id, packet.Error)
+		if cc.firstErr == nil {
+			cc.firstErr = errors.New(packet.Error)
+		}
+	}
+	cc.completeData(packet)
+}
+
+func (cc *coordinatorController) completeData(packet *DataPacket) {
+	d := cc.data[packet.ID]
+	d.result = packet
+	cc.sendTo(d.waiting, &ControllerMessage{Message: &ControllerMessage_DataWithID{DataWithID: packet}})
+	d.waiting = nil
+}
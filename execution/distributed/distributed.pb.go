@@ -0,0 +1,1065 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        v3.21.12
+// source: distributed.proto
+
+package distributed
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type RegisterRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *RegisterRequest) Reset() {
+	*x = RegisterRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_distributed_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RegisterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterRequest) ProtoMessage() {}
+
+func (x *RegisterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_distributed_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterRequest.ProtoReflect.Descriptor instead.
+func (*RegisterRequest) Descriptor() ([]byte, []int) {
+	return file_distributed_proto_rawDescGZIP(), []int{0}
+}
+
+type RegisterResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	InstanceID uint32 `protobuf:"varint,1,opt,name=InstanceID,proto3" json:"InstanceID,omitempty"`
+	// TODO: send this with a `stream` of smaller chunks
+	Archive []byte `protobuf:"bytes,2,opt,name=Archive,proto3" json:"Archive,omitempty"`
+}
+
+func (x *RegisterResponse) Reset() {
+	*x = RegisterResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_distributed_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RegisterResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterResponse) ProtoMessage() {}
+
+func (x *RegisterResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_distributed_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterResponse.ProtoReflect.Descriptor instead.
+func (*RegisterResponse) Descriptor() ([]byte, []int) {
+	return file_distributed_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RegisterResponse) GetInstanceID() uint32 {
+	if x != nil {
+		return x.InstanceID
+	}
+	return 0
+}
+
+func (x *RegisterResponse) GetArchive() []byte {
+	if x != nil {
+		return x.Archive
+	}
+	return nil
+}
+
+type AgentMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Message:
+	//	*AgentMessage_InitInstanceID
+	//	*AgentMessage_Signal
+	//	*AgentMessage_GetOrCreateDataWithID
+	//	*AgentMessage_CreatedData
+	Message isAgentMessage_Message `protobuf_oneof:"Message"`
+}
+
+func (x *AgentMessage) Reset() {
+	*x = AgentMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_distributed_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AgentMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentMessage) ProtoMessage() {}
+
+func (x *AgentMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_distributed_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentMessage.ProtoReflect.Descriptor instead.
+func (*AgentMessage) Descriptor() ([]byte, []int) {
+	return file_distributed_proto_rawDescGZIP(), []int{2}
+}
+
+func (m *AgentMessage) GetMessage() isAgentMessage_Message {
+	if m != nil {
+		return m.Message
+	}
+	return nil
+}
+
+func (x *AgentMessage) GetInitInstanceID() uint32 {
+	if x, ok := x.GetMessage().(*AgentMessage_InitInstanceID); ok {
+		return x.InitInstanceID
+	}
+	return 0
+}
+
+func (x *AgentMessage) GetSignal() *SignalPacket {
+	if x, ok := x.GetMessage().(*AgentMessage_Signal); ok {
+		return x.Signal
+	}
+	return nil
+}
+
+func (x *AgentMessage) GetGetOrCreateDataWithID() string {
+	if x, ok := x.GetMessage().(*AgentMessage_GetOrCreateDataWithID); ok {
+		return x.GetOrCreateDataWithID
+	}
+	return ""
+}
+
+func (x *AgentMessage) GetCreatedData() *DataPacket {
+	if x, ok := x.GetMessage().(*AgentMessage_CreatedData); ok {
+		return x.CreatedData
+	}
+	return nil
+}
+
+type isAgentMessage_Message interface {
+	isAgentMessage_Message()
+}
+
+type AgentMessage_InitInstanceID struct {
+	// Has to be the first message an agent sends after the stream is opened.
+	InitInstanceID uint32 `protobuf:"varint,1,opt,name=InitInstanceID,proto3,oneof"`
+}
+
+type AgentMessage_Signal struct {
+	Signal *SignalPacket `protobuf:"bytes,2,opt,name=Signal,proto3,oneof"`
+}
+
+type AgentMessage_GetOrCreateDataWithID struct {
+	GetOrCreateDataWithID string `protobuf:"bytes,3,opt,name=GetOrCreateDataWithID,proto3,oneof"`
+}
+
+type AgentMessage_CreatedData struct {
+	CreatedData *DataPacket `protobuf:"bytes,4,opt,name=CreatedData,proto3,oneof"`
+}
+
+func (*AgentMessage_InitInstanceID) isAgentMessage_Message() {}
+
+func (*AgentMessage_Signal) isAgentMessage_Message() {}
+
+func (*AgentMessage_GetOrCreateDataWithID) isAgentMessage_Message() {}
+
+func (*AgentMessage_CreatedData) isAgentMessage_Message() {}
+
+type ControllerMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Message:
+	//	*ControllerMessage_DoneWaitWithID
+	//	*ControllerMessage_DataWithID
+	//	*ControllerMessage_CreateDataWithID
+	Message isControllerMessage_Message `protobuf_oneof:"Message"`
+}
+
+func (x *ControllerMessage) Reset() {
+	*x = ControllerMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_distributed_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ControllerMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ControllerMessage) ProtoMessage() {}
+
+func (x *ControllerMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_distributed_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ControllerMessage.ProtoReflect.Descriptor instead.
+func (*ControllerMessage) Descriptor() ([]byte, []int) {
+	return file_distributed_proto_rawDescGZIP(), []int{3}
+}
+
+func (m *ControllerMessage) GetMessage() isControllerMessage_Message {
+	if m != nil {
+		return m.Message
+	}
+	return nil
+}
+
+func (x *ControllerMessage) GetDoneWaitWithID() *SignalPacket {
+	if x, ok := x.GetMessage().(*ControllerMessage_DoneWaitWithID); ok {
+		return x.DoneWaitWithID
+	}
+	return nil
+}
+
+func (x *ControllerMessage) GetDataWithID() *DataPacket {
+	if x, ok := x.GetMessage().(*ControllerMessage_DataWithID); ok {
+		return x.DataWithID
+	}
+	return nil
+}
+
+func (x *ControllerMessage) GetCreateDataWithID() string {
+	if x, ok := x.GetMessage().(*ControllerMessage_CreateDataWithID); ok {
+		return x.CreateDataWithID
+	}
+	return ""
+}
+
+type isControllerMessage_Message interface {
+	isControllerMessage_Message()
+}
+
+type ControllerMessage_DoneWaitWithID struct {
+	DoneWaitWithID *SignalPacket `protobuf:"bytes,1,opt,name=DoneWaitWithID,proto3,oneof"`
+}
+
+type ControllerMessage_DataWithID struct {
+	DataWithID *DataPacket `protobuf:"bytes,2,opt,name=DataWithID,proto3,oneof"`
+}
+
+type ControllerMessage_CreateDataWithID struct {
+	CreateDataWithID string `protobuf:"bytes,3,opt,name=CreateDataWithID,proto3,oneof"`
+}
+
+func (*ControllerMessage_DoneWaitWithID) isControllerMessage_Message() {}
+
+func (*ControllerMessage_DataWithID) isControllerMessage_Message() {}
+
+func (*ControllerMessage_CreateDataWithID) isControllerMessage_Message() {}
+
+type SignalPacket struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ID    string `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	Error string `protobuf:"bytes,2,opt,name=Error,proto3" json:"Error,omitempty"`
+}
+
+func (x *SignalPacket) Reset() {
+	*x = SignalPacket{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_distributed_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SignalPacket) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignalPacket) ProtoMessage() {}
+
+func (x *SignalPacket) ProtoReflect() protoreflect.Message {
+	mi := &file_distributed_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignalPacket.ProtoReflect.Descriptor instead.
+func (*SignalPacket) Descriptor() ([]byte, []int) {
+	return file_distributed_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SignalPacket) GetID() string {
+	if x != nil {
+		return x.ID
+	}
+	return ""
+}
+
+func (x *SignalPacket) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type DataPacket struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ID    string `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	Data  []byte `protobuf:"bytes,2,opt,name=Data,proto3" json:"Data,omitempty"`
+	Error string `protobuf:"bytes,3,opt,name=Error,proto3" json:"Error,omitempty"`
+}
+
+func (x *DataPacket) Reset() {
+	*x = DataPacket{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_distributed_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DataPacket) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DataPacket) ProtoMessage() {}
+
+func (x *DataPacket) ProtoReflect() protoreflect.Message {
+	mi := &file_distributed_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DataPacket.ProtoReflect.Descriptor instead.
+func (*DataPacket) Descriptor() ([]byte, []int) {
+	return file_distributed_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DataPacket) GetID() string {
+	if x != nil {
+		return x.ID
+	}
+	return ""
+}
+
+func (x *DataPacket) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *DataPacket) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type MetricsDump struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	InstanceID uint32 `protobuf:"varint,1,opt,name=InstanceID,proto3" json:"InstanceID,omitempty"`
+	// The current test run duration of the instance, in nanoseconds.
+	TestRunDuration int64         `protobuf:"varint,2,opt,name=TestRunDuration,proto3" json:"TestRunDuration,omitempty"`
+	Metrics         []*MetricDump `protobuf:"bytes,3,rep,name=Metrics,proto3" json:"Metrics,omitempty"`
+	// The groups and checks are cumulative, so they are only sent with the
+	// final dump, after the instance has finished executing the test.
+	Groups []*GroupDump `protobuf:"bytes,4,rep,name=Groups,proto3" json:"Groups,omitempty"`
+}
+
+func (x *MetricsDump) Reset() {
+	*x = MetricsDump{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_distributed_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MetricsDump) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetricsDump) ProtoMessage() {}
+
+func (x *MetricsDump) ProtoReflect() protoreflect.Message {
+	mi := &file_distributed_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MetricsDump.ProtoReflect.Descriptor instead.
+func (*MetricsDump) Descriptor() ([]byte, []int) {
+	return file_distributed_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *MetricsDump) GetInstanceID() uint32 {
+	if x != nil {
+		return x.InstanceID
+	}
+	return 0
+}
+
+func (x *MetricsDump) GetTestRunDuration() int64 {
+	if x != nil {
+		return x.TestRunDuration
+	}
+	return 0
+}
+
+func (x *MetricsDump) GetMetrics() []*MetricDump {
+	if x != nil {
+		return x.Metrics
+	}
+	return nil
+}
+
+func (x *MetricsDump) GetGroups() []*GroupDump {
+	if x != nil {
+		return x.Groups
+	}
+	return nil
+}
+
+type MetricDump struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name     string `protobuf:"bytes,1,opt,name=Name,proto3" json:"Name,omitempty"`
+	Type     string `protobuf:"bytes,2,opt,name=Type,proto3" json:"Type,omitempty"`
+	Contains string `protobuf:"bytes,3,opt,name=Contains,proto3" json:"Contains,omitempty"`
+	Data     []byte `protobuf:"bytes,4,opt,name=Data,proto3" json:"Data,omitempty"`
+}
+
+func (x *MetricDump) Reset() {
+	*x = MetricDump{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_distributed_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MetricDump) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetricDump) ProtoMessage() {}
+
+func (x *MetricDump) ProtoReflect() protoreflect.Message {
+	mi := &file_distributed_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MetricDump.ProtoReflect.Descriptor instead.
+func (*MetricDump) Descriptor() ([]byte, []int) {
+	return file_distributed_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *MetricDump) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *MetricDump) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *MetricDump) GetContains() string {
+	if x != nil {
+		return x.Contains
+	}
+	return ""
+}
+
+func (x *MetricDump) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type GroupDump struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Path   string       `protobuf:"bytes,1,opt,name=Path,proto3" json:"Path,omitempty"`
+	Checks []*CheckDump `protobuf:"bytes,2,rep,name=Checks,proto3" json:"Checks,omitempty"`
+}
+
+func (x *GroupDump) Reset() {
+	*x = GroupDump{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_distributed_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GroupDump) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GroupDump) ProtoMessage() {}
+
+func (x *GroupDump) ProtoReflect() protoreflect.Message {
+	mi := &file_distributed_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GroupDump.ProtoReflect.Descriptor instead.
+func (*GroupDump) Descriptor() ([]byte, []int) {
+	return file_distributed_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GroupDump) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *GroupDump) GetChecks() []*CheckDump {
+	if x != nil {
+		return x.Checks
+	}
+	return nil
+}
+
+type CheckDump struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name   string `protobuf:"bytes,1,opt,name=Name,proto3" json:"Name,omitempty"`
+	Passes int64  `protobuf:"varint,2,opt,name=Passes,proto3" json:"Passes,omitempty"`
+	Fails  int64  `protobuf:"varint,3,opt,name=Fails,proto3" json:"Fails,omitempty"`
+}
+
+func (x *CheckDump) Reset() {
+	*x = CheckDump{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_distributed_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CheckDump) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckDump) ProtoMessage() {}
+
+func (x *CheckDump) ProtoReflect() protoreflect.Message {
+	mi := &file_distributed_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckDump.ProtoReflect.Descriptor instead.
+func (*CheckDump) Descriptor() ([]byte, []int) {
+	return file_distributed_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *CheckDump) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CheckDump) GetPasses() int64 {
+	if x != nil {
+		return x.Passes
+	}
+	return 0
+}
+
+func (x *CheckDump) GetFails() int64 {
+	if x != nil {
+		return x.Fails
+	}
+	return 0
+}
+
+type MetricsDumpResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Set when thresholds with abortOnFail were crossed, so the agent should
+	// stop its test run.
+	AbortError string `protobuf:"bytes,1,opt,name=AbortError,proto3" json:"AbortError,omitempty"`
+}
+
+func (x *MetricsDumpResponse) Reset() {
+	*x = MetricsDumpResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_distributed_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MetricsDumpResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetricsDumpResponse) ProtoMessage() {}
+
+func (x *MetricsDumpResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_distributed_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MetricsDumpResponse.ProtoReflect.Descriptor instead.
+func (*MetricsDumpResponse) Descriptor() ([]byte, []int) {
+	return file_distributed_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *MetricsDumpResponse) GetAbortError() string {
+	if x != nil {
+		return x.AbortError
+	}
+	return ""
+}
+
+var File_distributed_proto protoreflect.FileDescriptor
+
+var file_distributed_proto_rawDesc = []byte{
+	0x0a, 0x11, 0x64, 0x69, 0x73, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x64, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x12, 0x0b, 0x64, 0x69, 0x73, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x64,
+	0x22, 0x11, 0x0a, 0x0f, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x22, 0x4c, 0x0a, 0x10, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x49, 0x6e, 0x73, 0x74, 0x61,
+	0x6e, 0x63, 0x65, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x49, 0x6e, 0x73,
+	0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x44, 0x12, 0x18, 0x0a, 0x07, 0x41, 0x72, 0x63, 0x68, 0x69,
+	0x76, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x41, 0x72, 0x63, 0x68, 0x69, 0x76,
+	0x65, 0x22, 0xed, 0x01, 0x0a, 0x0c, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x12, 0x28, 0x0a, 0x0e, 0x49, 0x6e, 0x69, 0x74, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e,
+	0x63, 0x65, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x48, 0x00, 0x52, 0x0e, 0x49, 0x6e,
+	0x69, 0x74, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x44, 0x12, 0x33, 0x0a, 0x06,
+	0x53, 0x69, 0x67, 0x6e, 0x61, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x64,
+	0x69, 0x73, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x64, 0x2e, 0x53, 0x69, 0x67, 0x6e, 0x61,
+	0x6c, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x48, 0x00, 0x52, 0x06, 0x53, 0x69, 0x67, 0x6e, 0x61,
+	0x6c, 0x12, 0x36, 0x0a, 0x15, 0x47, 0x65, 0x74, 0x4f, 0x72, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65,
+	0x44, 0x61, 0x74, 0x61, 0x57, 0x69, 0x74, 0x68, 0x49, 0x44, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
+	0x48, 0x00, 0x52, 0x15, 0x47, 0x65, 0x74, 0x4f, 0x72, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x44,
+	0x61, 0x74, 0x61, 0x57, 0x69, 0x74, 0x68, 0x49, 0x44, 0x12, 0x3b, 0x0a, 0x0b, 0x43, 0x72, 0x65,
+	0x61, 0x74, 0x65, 0x64, 0x44, 0x61, 0x74, 0x61, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17,
+	0x2e, 0x64, 0x69, 0x73, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x64, 0x2e, 0x44, 0x61, 0x74,
+	0x61, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x48, 0x00, 0x52, 0x0b, 0x43, 0x72, 0x65, 0x61, 0x74,
+	0x65, 0x64, 0x44, 0x61, 0x74, 0x61, 0x42, 0x09, 0x0a, 0x07, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x22, 0xcc, 0x01, 0x0a, 0x11, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x6c, 0x65, 0x72,
+	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x43, 0x0a, 0x0e, 0x44, 0x6f, 0x6e, 0x65, 0x57,
+	0x61, 0x69, 0x74, 0x57, 0x69, 0x74, 0x68, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x19, 0x2e, 0x64, 0x69, 0x73, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x64, 0x2e, 0x53, 0x69,
+	0x67, 0x6e, 0x61, 0x6c, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x48, 0x00, 0x52, 0x0e, 0x44, 0x6f,
+	0x6e, 0x65, 0x57, 0x61, 0x69, 0x74, 0x57, 0x69, 0x74, 0x68, 0x49, 0x44, 0x12, 0x39, 0x0a, 0x0a,
+	0x44, 0x61, 0x74, 0x61, 0x57, 0x69, 0x74, 0x68, 0x49, 0x44, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x17, 0x2e, 0x64, 0x69, 0x73, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x64, 0x2e, 0x44,
+	0x61, 0x74, 0x61, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x48, 0x00, 0x52, 0x0a, 0x44, 0x61, 0x74,
+	0x61, 0x57, 0x69, 0x74, 0x68, 0x49, 0x44, 0x12, 0x2c, 0x0a, 0x10, 0x43, 0x72, 0x65, 0x61, 0x74,
+	0x65, 0x44, 0x61, 0x74, 0x61, 0x57, 0x69, 0x74, 0x68, 0x49, 0x44, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x48, 0x00, 0x52, 0x10, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x44, 0x61, 0x74, 0x61, 0x57,
+	0x69, 0x74, 0x68, 0x49, 0x44, 0x42, 0x09, 0x0a, 0x07, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x22, 0x34, 0x0a, 0x0c, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x6c, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74,
+	0x12, 0x0e, 0x0a, 0x02, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x49, 0x44,
+	0x12, 0x14, 0x0a, 0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x46, 0x0a, 0x0a, 0x44, 0x61, 0x74, 0x61, 0x50, 0x61,
+	0x63, 0x6b, 0x65, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x02, 0x49, 0x44, 0x12, 0x12, 0x0a, 0x04, 0x44, 0x61, 0x74, 0x61, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x04, 0x44, 0x61, 0x74, 0x61, 0x12, 0x14, 0x0a, 0x05, 0x45, 0x72, 0x72, 0x6f,
+	0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x22, 0xba,
+	0x01, 0x0a, 0x0b, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x44, 0x75, 0x6d, 0x70, 0x12, 0x1e,
+	0x0a, 0x0a, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x0a, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x44, 0x12, 0x28,
+	0x0a, 0x0f, 0x54, 0x65, 0x73, 0x74, 0x52, 0x75, 0x6e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0f, 0x54, 0x65, 0x73, 0x74, 0x52, 0x75, 0x6e,
+	0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x31, 0x0a, 0x07, 0x4d, 0x65, 0x74, 0x72,
+	0x69, 0x63, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x64, 0x69, 0x73, 0x74,
+	0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x64, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x44, 0x75,
+	0x6d, 0x70, 0x52, 0x07, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x12, 0x2e, 0x0a, 0x06, 0x47,
+	0x72, 0x6f, 0x75, 0x70, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x64, 0x69,
+	0x73, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x64, 0x2e, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x44,
+	0x75, 0x6d, 0x70, 0x52, 0x06, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x73, 0x22, 0x64, 0x0a, 0x0a, 0x4d,
+	0x65, 0x74, 0x72, 0x69, 0x63, 0x44, 0x75, 0x6d, 0x70, 0x12, 0x12, 0x0a, 0x04, 0x4e, 0x61, 0x6d,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a,
+	0x04, 0x54, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x54, 0x79, 0x70,
+	0x65, 0x12, 0x1a, 0x0a, 0x08, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x73, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x08, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x73, 0x12, 0x12, 0x0a,
+	0x04, 0x44, 0x61, 0x74, 0x61, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x44, 0x61, 0x74,
+	0x61, 0x22, 0x4f, 0x0a, 0x09, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x44, 0x75, 0x6d, 0x70, 0x12, 0x12,
+	0x0a, 0x04, 0x50, 0x61, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x50, 0x61,
+	0x74, 0x68, 0x12, 0x2e, 0x0a, 0x06, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x73, 0x18, 0x02, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x16, 0x2e, 0x64, 0x69, 0x73, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x64,
+	0x2e, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x44, 0x75, 0x6d, 0x70, 0x52, 0x06, 0x43, 0x68, 0x65, 0x63,
+	0x6b, 0x73, 0x22, 0x4d, 0x0a, 0x09, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x44, 0x75, 0x6d, 0x70, 0x12,
+	0x12, 0x0a, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x4e,
+	0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x50, 0x61, 0x73, 0x73, 0x65, 0x73, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x06, 0x50, 0x61, 0x73, 0x73, 0x65, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x46,
+	0x61, 0x69, 0x6c, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x46, 0x61, 0x69, 0x6c,
+	0x73, 0x22, 0x35, 0x0a, 0x13, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x44, 0x75, 0x6d, 0x70,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x41, 0x62, 0x6f, 0x72,
+	0x74, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x41, 0x62,
+	0x6f, 0x72, 0x74, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x32, 0xff, 0x01, 0x0a, 0x0f, 0x44, 0x69, 0x73,
+	0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x64, 0x54, 0x65, 0x73, 0x74, 0x12, 0x49, 0x0a, 0x08,
+	0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x12, 0x1c, 0x2e, 0x64, 0x69, 0x73, 0x74, 0x72,
+	0x69, 0x62, 0x75, 0x74, 0x65, 0x64, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x64, 0x69, 0x73, 0x74, 0x72, 0x69, 0x62,
+	0x75, 0x74, 0x65, 0x64, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x54, 0x0a, 0x11, 0x43, 0x6f, 0x6d, 0x6d, 0x61,
+	0x6e, 0x64, 0x41, 0x6e, 0x64, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x12, 0x19, 0x2e, 0x64,
+	0x69, 0x73, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x64, 0x2e, 0x41, 0x67, 0x65, 0x6e, 0x74,
+	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x1e, 0x2e, 0x64, 0x69, 0x73, 0x74, 0x72, 0x69,
+	0x62, 0x75, 0x74, 0x65, 0x64, 0x2e, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x6c, 0x65, 0x72,
+	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x00, 0x28, 0x01, 0x30, 0x01, 0x12, 0x4b, 0x0a,
+	0x0b, 0x53, 0x65, 0x6e, 0x64, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x12, 0x18, 0x2e, 0x64,
+	0x69, 0x73, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x64, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69,
+	0x63, 0x73, 0x44, 0x75, 0x6d, 0x70, 0x1a, 0x20, 0x2e, 0x64, 0x69, 0x73, 0x74, 0x72, 0x69, 0x62,
+	0x75, 0x74, 0x65, 0x64, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x44, 0x75, 0x6d, 0x70,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0x23, 0x5a, 0x21, 0x67, 0x6f,
+	0x2e, 0x6b, 0x36, 0x2e, 0x69, 0x6f, 0x2f, 0x6b, 0x36, 0x2f, 0x65, 0x78, 0x65, 0x63, 0x75, 0x74,
+	0x69, 0x6f, 0x6e, 0x2f, 0x64, 0x69, 0x73, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x64, 0x62,
+	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_distributed_proto_rawDescOnce sync.Once
+	file_distributed_proto_rawDescData = file_distributed_proto_rawDesc
+)
+
+func file_distributed_proto_rawDescGZIP() []byte {
+	file_distributed_proto_rawDescOnce.Do(func() {
+		file_distributed_proto_rawDescData = protoimpl.X.CompressGZIP(file_distributed_proto_rawDescData)
+	})
+	return file_distributed_proto_rawDescData
+}
+
+var file_distributed_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_distributed_proto_goTypes = []any{
+	(*RegisterRequest)(nil),     // 0: distributed.RegisterRequest
+	(*RegisterResponse)(nil),    // 1: distributed.RegisterResponse
+	(*AgentMessage)(nil),        // 2: distributed.AgentMessage
+	(*ControllerMessage)(nil),   // 3: distributed.ControllerMessage
+	(*SignalPacket)(nil),        // 4: distributed.SignalPacket
+	(*DataPacket)(nil),          // 5: distributed.DataPacket
+	(*MetricsDump)(nil),         // 6: distributed.MetricsDump
+	(*MetricDump)(nil),          // 7: distributed.MetricDump
+	(*GroupDump)(nil),           // 8: distributed.GroupDump
+	(*CheckDump)(nil),           // 9: distributed.CheckDump
+	(*MetricsDumpResponse)(nil), // 10: distributed.MetricsDumpResponse
+}
+var file_distributed_proto_depIdxs = []int32{
+	4,  // 0: distributed.AgentMessage.Signal:type_name -> distributed.SignalPacket
+	5,  // 1: distributed.AgentMessage.CreatedData:type_name -> distributed.DataPacket
+	4,  // 2: distributed.ControllerMessage.DoneWaitWithID:type_name -> distributed.SignalPacket
+	5,  // 3: distributed.ControllerMessage.DataWithID:type_name -> distributed.DataPacket
+	7,  // 4: distributed.MetricsDump.Metrics:type_name -> distributed.MetricDump
+	8,  // 5: distributed.MetricsDump.Groups:type_name -> distributed.GroupDump
+	9,  // 6: distributed.GroupDump.Checks:type_name -> distributed.CheckDump
+	0,  // 7: distributed.DistributedTest.Register:input_type -> distributed.RegisterRequest
+	2,  // 8: distributed.DistributedTest.CommandAndControl:input_type -> distributed.AgentMessage
+	6,  // 9: distributed.DistributedTest.SendMetrics:input_type -> distributed.MetricsDump
+	1,  // 10: distributed.DistributedTest.Register:output_type -> distributed.RegisterResponse
+	3,  // 11: distributed.DistributedTest.CommandAndControl:output_type -> distributed.ControllerMessage
+	10, // 12: distributed.DistributedTest.SendMetrics:output_type -> distributed.MetricsDumpResponse
+	10, // [10:13] is the sub-list for method output_type
+	7,  // [7:10] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_distributed_proto_init() }
+func file_distributed_proto_init() {
+	if File_distributed_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_distributed_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*RegisterRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_distributed_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*RegisterResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_distributed_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*AgentMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_distributed_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*ControllerMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_distributed_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*SignalPacket); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_distributed_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*DataPacket); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_distributed_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*MetricsDump); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_distributed_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*MetricDump); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_distributed_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*GroupDump); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_distributed_proto_msgTypes[9].Exporter = func(v any, i int) any {
+			switch v := v.(*CheckDump); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_distributed_proto_msgTypes[10].Exporter = func(v any, i int) any {
+			switch v := v.(*MetricsDumpResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_distributed_proto_msgTypes[2].OneofWrappers = []any{
+		(*AgentMessage_InitInstanceID)(nil),
+		(*AgentMessage_Signal)(nil),
+		(*AgentMessage_GetOrCreateDataWithID)(nil),
+		(*AgentMessage_CreatedData)(nil),
+	}
+	file_distributed_proto_msgTypes[3].OneofWrappers = []any{
+		(*ControllerMessage_DoneWaitWithID)(nil),
+		(*ControllerMessage_DataWithID)(nil),
+		(*ControllerMessage_CreateDataWithID)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_distributed_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_distributed_proto_goTypes,
+		DependencyIndexes: file_distributed_proto_depIdxs,
+		MessageInfos:      file_distributed_proto_msgTypes,
+	}.Build()
+	File_distributed_proto = out.File
+	file_distributed_proto_rawDesc = nil
+	file_distributed_proto_goTypes = nil
+	file_distributed_proto_depIdxs = nil
+}
@@ -0,0 +1,379 @@
+package distributed
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/execution"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/consts"
+	"go.k6.io/k6/lib/fsext"
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/metrics/engine"
+)
+
+func getTestArchive(t *testing.T) *lib.Archive {
+	t.Helper()
+	return &lib.Archive{
+		Type:        "js",
+		K6Version:   consts.Version,
+		Options:     lib.Options{SystemTags: &metrics.DefaultSystemTagSet},
+		FilenameURL: &url.URL{Scheme: "file", Path: "/path/to/a.js"},
+		Data:        []byte(`export default function() {}`),
+		PwdURL:      &url.URL{Scheme: "file", Path: "/path/to"},
+		Filesystems: map[string]fsext.Fs{
+			"file": testutils.MakeMemMapFs(t, map[string][]byte{
+				"/path/to/a.js": []byte(`export default function() {}`),
+			}),
+		},
+	}
+}
+
+func startCoordinator(t *testing.T, instanceCount int) (*CoordinatorServer, DistributedTestClient) {
+	t.Helper()
+	metricsEngine, err := engine.NewMetricsEngine(metrics.NewRegistry(), testutils.NewLogger(t))
+	require.NoError(t, err)
+	coordinator, err := NewCoordinatorServer(instanceCount, getTestArchive(t), metricsEngine, testutils.NewLogger(t))
+	require.NoError(t, err)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	grpcServer := grpc.NewServer()
+	RegisterDistributedTestServer(grpcServer, coordinator)
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return coordinator, NewDistributedTestClient(conn)
+}
+
+func registerAgents(t *testing.T, client DistributedTestClient, count int) []*AgentController {
+	t.Helper()
+	agents := make([]*AgentController, count)
+	var randomSeed null.Int
+	for i := range agents {
+		resp, err := client.Register(context.Background(), &RegisterRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, uint32(i+1), resp.InstanceID) //nolint:gosec
+
+		arc, err := lib.ReadArchive(bytes.NewReader(resp.Archive))
+		require.NoError(t, err)
+		require.NotNil(t, arc.Options.ExecutionSegmentSequence)
+		assert.Len(t, *arc.Options.ExecutionSegmentSequence, count)
+		assert.Equal(t, (*arc.Options.ExecutionSegmentSequence)[i], arc.Options.ExecutionSegment)
+		// All of the instances get the same seed, even if the test doesn't set one
+		require.True(t, arc.Options.RandomSeed.Valid)
+		if i == 0 {
+			randomSeed = arc.Options.RandomSeed
+		}
+		assert.Equal(t, randomSeed, arc.Options.RandomSeed)
+
+		agents[i], err = NewAgentController(context.Background(), resp.InstanceID, client, testutils.NewLogger(t))
+		require.NoError(t, err)
+	}
+	return agents
+}
+
+func TestDistributedExecutionSuccess(t *testing.T) {
+	t.Parallel()
+
+	const instanceCount = 3
+	coordinator, client := startCoordinator(t, instanceCount)
+	agents := registerAgents(t, client, instanceCount)
+
+	_, err := client.Register(context.Background(), &RegisterRequest{})
+	require.ErrorContains(t, err, "we don't need any more instances")
+
+	var setupCalls int64
+	wg := &sync.WaitGroup{}
+	for _, agent := range agents {
+		wg.Add(1)
+		go func(agent *AgentController) {
+			defer wg.Done()
+			assert.NoError(t, execution.SignalAndWait(agent, "start"))
+			data, err := agent.GetOrCreateData("setup", func() ([]byte, error) {
+				atomic.AddInt64(&setupCalls, 1)
+				return []byte("setup data"), nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("setup data"), data)
+			assert.NoError(t, execution.SignalErrorOrWait(agent, "end", nil))
+			assert.NoError(t, agent.Close())
+		}(agent)
+	}
+	wg.Wait()
+
+	<-coordinator.Done()
+	assert.NoError(t, coordinator.Err())
+	assert.Equal(t, int64(1), setupCalls)
+}
+
+func TestDistributedExecutionErrors(t *testing.T) {
+	t.Parallel()
+
+	const instanceCount = 2
+	coordinator, client := startCoordinator(t, instanceCount)
+	agents := registerAgents(t, client, instanceCount)
+
+	// The first agent fails its setup(), so the other one should get the error
+	data, err := agents[0].GetOrCreateData("setup", func() ([]byte, error) {
+		return nil, errors.New("setup failed")
+	})
+	require.ErrorContains(t, err, "setup failed")
+	assert.Nil(t, data)
+
+	_, err = agents[1].GetOrCreateData("setup", func() ([]byte, error) {
+		t.Error("the second agent should not run setup()")
+		return nil, nil
+	})
+	require.ErrorContains(t, err, "instance 1: setup failed")
+
+	// The second agent waits on a barrier that the first never reaches,
+	// because it disconnects beforehand.
+	wait := agents[1].Subscribe("teardown-done")
+	require.NoError(t, agents[1].Signal("teardown-done", nil))
+	require.NoError(t, agents[0].Close())
+	require.ErrorContains(t, wait(), "instance 1 disconnected")
+	require.NoError(t, agents[1].Close())
+
+	<-coordinator.Done()
+	assert.ErrorContains(t, coordinator.Err(), "instance 1: setup failed")
+}
+
+func TestDistributedExecutionRejectedStreams(t *testing.T) {
+	t.Parallel()
+
+	const instanceCount = 2
+	coordinator, client := startCoordinator(t, instanceCount)
+	agents := registerAgents(t, client, instanceCount)
+
+	rejected := []*AgentMessage{
+		{Message: &AgentMessage_Signal{Signal: &SignalPacket{ID: "start"}}},
+		{Message: &AgentMessage_InitInstanceID{InitInstanceID: instanceCount + 1}},
+		{Message: &AgentMessage_InitInstanceID{InitInstanceID: 1}}, // already connected
+	}
+	for _, msg := range rejected {
+		stream, err := client.CommandAndControl(context.Background())
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(msg))
+		_, err = stream.Recv()
+		require.Error(t, err)
+	}
+
+	// The rejected streams don't count as instances that finished the test
+	select {
+	case <-coordinator.Done():
+		t.Fatal("the coordinator is done before any instance disconnected")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.NoError(t, agents[0].Close())
+	select {
+	case <-coordinator.Done():
+		t.Fatal("the coordinator is done before all instances disconnected")
+	case <-time.After(50 * time.Millisecond):
+	}
+	require.NoError(t, agents[1].Close())
+	<-coordinator.Done()
+	assert.NoError(t, coordinator.Err())
+}
+
+// blockedStream is a stream to an agent that doesn't read the messages.
+type blockedStream struct {
+	DistributedTest_CommandAndControlServer
+	unblock chan struct{}
+}
+
+func (s *blockedStream) Send(*ControllerMessage) error {
+	<-s.unblock
+	return nil
+}
+
+type recordedStream struct {
+	DistributedTest_CommandAndControlServer
+	sent chan *ControllerMessage
+}
+
+func (s *recordedStream) Send(msg *ControllerMessage) error {
+	s.sent <- msg
+	return nil
+}
+
+func TestCoordinatorSlowInstance(t *testing.T) {
+	t.Parallel()
+
+	logger := testutils.NewLogger(t)
+	cc := newCoordinatorController(2, logger)
+	blocked := &blockedStream{unblock: make(chan struct{})}
+	recorded := &recordedStream{sent: make(chan *ControllerMessage, 10)}
+	slowInstance := newInstanceStream(1, blocked, logger)
+	fastInstance := newInstanceStream(2, recorded, logger)
+	require.NoError(t, cc.connectInstance(slowInstance))
+	require.NoError(t, cc.connectInstance(fastInstance))
+
+	for _, eventID := range []string{"first", "second"} {
+		cc.signal(1, &SignalPacket{ID: eventID})
+		cc.signal(2, &SignalPacket{ID: eventID})
+		select {
+		case msg := <-recorded.sent:
+			assert.Equal(t, eventID, msg.GetDoneWaitWithID().ID)
+		case <-time.After(time.Second):
+			t.Fatalf("the fast instance didn't get '%s' while the slow one is blocked", eventID)
+		}
+	}
+
+	close(blocked.unblock)
+	slowInstance.stop()
+	fastInstance.stop()
+}
+
+func TestGetInstanceSegments(t *testing.T) {
+	t.Parallel()
+
+	segments, err := getInstanceSegments(4, lib.Options{})
+	require.NoError(t, err)
+	require.Len(t, segments, 4)
+	assert.Equal(t, "0:1/4", segments[0].String())
+	assert.Equal(t, "3/4:1", segments[3].String())
+
+	ess, err := lib.NewExecutionSegmentSequenceFromString("0,1/3,1")
+	require.NoError(t, err)
+	segments, err = getInstanceSegments(2, lib.Options{ExecutionSegmentSequence: &ess})
+	require.NoError(t, err)
+	assert.Equal(t, "0:1/3", segments[0].String())
+	assert.Equal(t, "1/3:1", segments[1].String())
+
+	_, err = getInstanceSegments(3, lib.Options{ExecutionSegmentSequence: &ess})
+	require.ErrorContains(t, err, "needs exactly 3")
+
+	_, err = getInstanceSegments(0, lib.Options{})
+	require.ErrorContains(t, err, "at least 1")
+}
+
+func TestDistributedMetrics(t *testing.T) {
+	t.Parallel()
+
+	const instanceCount = 2
+	coordinator, client := startCoordinator(t, instanceCount)
+	agents := registerAgents(t, client, instanceCount)
+
+	// The coordinator will ask all of the agents to abort the test on their
+	// next push, e.g. because a threshold with abortOnFail was crossed.
+	coordinator.AbortTest(errors.New("thresholds were crossed"))
+
+	for i, agent := range agents {
+		registry := metrics.NewRegistry()
+		metricsEngine, err := engine.NewMetricsEngine(registry, testutils.NewLogger(t))
+		require.NoError(t, err)
+		counter := registry.MustNewMetric("my_counter", metrics.Counter)
+		counter.Sink.Add(metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: counter}, Time: time.Now(), Value: float64(i + 1),
+		})
+		metricsEngine.ObservedMetrics[counter.Name] = counter
+
+		rootGroup, err := lib.NewGroup(lib.RootGroupPath, nil)
+		require.NoError(t, err)
+		group, err := rootGroup.Group("my group")
+		require.NoError(t, err)
+		check, err := group.Check("my check")
+		require.NoError(t, err)
+		check.Passes, check.Fails = 2, int64(i)
+
+		var abortErr error
+		finalize := agent.PushMetrics(
+			metricsEngine,
+			func() time.Duration { return time.Duration(i+1) * time.Second },
+			func(err error) { abortErr = err },
+		)
+		finalize(rootGroup)
+
+		require.ErrorContains(t, abortErr, "thresholds were crossed")
+		var errWithExitCode errext.HasExitCode
+		require.ErrorAs(t, abortErr, &errWithExitCode)
+		assert.Equal(t, exitcodes.ThresholdsHaveFailed, errWithExitCode.ExitCode())
+		assert.True(t, counter.Sink.IsEmpty())
+		require.NoError(t, agent.Close())
+	}
+	<-coordinator.Done()
+
+	assert.Equal(t, 2*time.Second, coordinator.GetCurrentTestRunDuration())
+	merged := coordinator.metrics.metricsEngine.ObservedMetrics["my_counter"]
+	require.NotNil(t, merged)
+	assert.Equal(t, 3.0, merged.Sink.(*metrics.CounterSink).Value) //nolint:forcetypeassert
+
+	group := coordinator.RootGroup().Groups["my group"]
+	require.NotNil(t, group)
+	require.Len(t, group.OrderedChecks, 1)
+	assert.Equal(t, int64(4), group.OrderedChecks[0].Passes)
+	assert.Equal(t, int64(1), group.OrderedChecks[0].Fails)
+}
+
+// failingMetricsClient fails to send the metrics the first time.
+type failingMetricsClient struct {
+	DistributedTestClient
+	dumps []*MetricsDump
+}
+
+func (c *failingMetricsClient) SendMetrics(
+	_ context.Context, dump *MetricsDump, _ ...grpc.CallOption,
+) (*MetricsDumpResponse, error) {
+	c.dumps = append(c.dumps, dump)
+	if len(c.dumps) == 1 {
+		return nil, errors.New("connection lost")
+	}
+	return &MetricsDumpResponse{}, nil
+}
+
+func TestDistributedMetricsSendFailure(t *testing.T) {
+	t.Parallel()
+
+	client := &failingMetricsClient{}
+	agent := &AgentController{ctx: context.Background(), instanceID: 1, client: client, logger: testutils.NewLogger(t)}
+
+	registry := metrics.NewRegistry()
+	metricsEngine, err := engine.NewMetricsEngine(registry, testutils.NewLogger(t))
+	require.NoError(t, err)
+	counter := registry.MustNewMetric("my_counter", metrics.Counter)
+	addSample := func(value float64) {
+		counter.Sink.Add(metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: counter}, Time: time.Now(), Value: value})
+	}
+	addSample(1)
+	metricsEngine.ObservedMetrics[counter.Name] = counter
+	getTestRunDuration := func() time.Duration { return time.Second }
+	rootGroup, err := lib.NewGroup(lib.RootGroupPath, nil)
+	require.NoError(t, err)
+
+	// The metrics that couldn't be sent are sent with the next push
+	agent.PushMetrics(metricsEngine, getTestRunDuration, func(error) {})(rootGroup)
+	assert.Equal(t, 1.0, counter.Sink.(*metrics.CounterSink).Value) //nolint:forcetypeassert
+	addSample(2)
+	agent.PushMetrics(metricsEngine, getTestRunDuration, func(error) {})(rootGroup)
+	assert.True(t, counter.Sink.IsEmpty())
+
+	require.Len(t, client.dumps, 2)
+	require.Len(t, client.dumps[1].Metrics, 1)
+	coordinatorMetrics := newCoordinatorMetrics(metricsEngine)
+	require.NoError(t, coordinatorMetrics.merge(client.dumps[1]))
+	assert.Equal(t, 3.0, counter.Sink.(*metrics.CounterSink).Value) //nolint:forcetypeassert
+}
@@ -0,0 +1,6 @@
+// Package distributed implements the execution.Controller interface for
+// distributed k6 execution, where a single test is split across multiple k6
+// instances (agents) that are synchronized by a central coordinator over gRPC.
+package distributed
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative ./distributed.proto
@@ -0,0 +1,234 @@
+package distributed
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics/engine"
+)
+
+// metricsPushInterval is how often the agents send their metrics to the
+// coordinator. It's lower than the rate with which the metrics engine
+// evaluates the thresholds, so they are always evaluated with fresh data.
+const metricsPushInterval = 1 * time.Second
+
+// coordinatorMetrics aggregates the metrics, groups and checks that all of
+// the agents send to the coordinator.
+type coordinatorMetrics struct {
+	metricsEngine *engine.MetricsEngine
+	rootGroup     *lib.Group
+
+	mx              sync.Mutex
+	testRunDuration time.Duration
+	abortErr        error
+}
+
+func newCoordinatorMetrics(metricsEngine *engine.MetricsEngine) *coordinatorMetrics {
+	rootGroup, _ := lib.NewGroup(lib.RootGroupPath, nil)
+	return &coordinatorMetrics{metricsEngine: metricsEngine, rootGroup: rootGroup}
+}
+
+func (cm *coordinatorMetrics) merge(dump *MetricsDump) error {
+	snapshots := make([]engine.MetricSnapshot, len(dump.Metrics))
+	for i, m := range dump.Metrics {
+		snapshots[i] = engine.MetricSnapshot{Name: m.Name, Data: m.Data}
+		if err := snapshots[i].Type.UnmarshalText([]byte(m.Type)); err != nil {
+			return err
+		}
+		if err := snapshots[i].Contains.UnmarshalText([]byte(m.Contains)); err != nil {
+			return err
+		}
+	}
+	if err := cm.metricsEngine.MergeSnapshots(snapshots); err != nil {
+		return err
+	}
+	for _, g := range dump.Groups {
+		if err := cm.mergeGroup(g); err != nil {
+			return err
+		}
+	}
+
+	cm.mx.Lock()
+	defer cm.mx.Unlock()
+	if d := time.Duration(dump.TestRunDuration); d > cm.testRunDuration {
+		cm.testRunDuration = d
+	}
+	return nil
+}
+
+func (cm *coordinatorMetrics) mergeGroup(dump *GroupDump) error {
+	group := cm.rootGroup
+	if dump.Path != lib.RootGroupPath {
+		for _, name := range strings.Split(dump.Path, lib.GroupSeparator)[1:] {
+			var err error
+			if group, err = group.Group(name); err != nil {
+				return err
+			}
+		}
+	}
+	for _, c := range dump.Checks {
+		check, err := group.Check(c.Name)
+		if err != nil {
+			return err
+		}
+		atomic.AddInt64(&check.Passes, c.Passes)
+		atomic.AddInt64(&check.Fails, c.Fails)
+	}
+	return nil
+}
+
+func (cm *coordinatorMetrics) abort(err error) {
+	cm.mx.Lock()
+	defer cm.mx.Unlock()
+	if cm.abortErr == nil {
+		cm.abortErr = err
+	}
+}
+
+func (cm *coordinatorMetrics) getAbortErr() error {
+	cm.mx.Lock()
+	defer cm.mx.Unlock()
+	return cm.abortErr
+}
+
+func (cm *coordinatorMetrics) getTestRunDuration() time.Duration {
+	cm.mx.Lock()
+	defer cm.mx.Unlock()
+	return cm.testRunDuration
+}
+
+// PushMetrics starts periodically draining the metrics from the given
+// metrics engine of the agent and sending them to the coordinator. If the
+// coordinator responds that the thresholds with abortOnFail were crossed,
+// abortRun is called. If they can't be sent, they are restored in the metrics
+// engine, so they are sent with the next push.
+//
+// The returned callback stops the periodic pushes and sends everything that
+// remains, together with the groups and checks from the given root group.
+// It should be called only after all of the metrics have been processed.
+func (ac *AgentController) PushMetrics(
+	metricsEngine *engine.MetricsEngine, getCurrentTestRunDuration func() time.Duration, abortRun func(error),
+) (finalize func(rootGroup *lib.Group)) {
+	var abortOnce sync.Once
+	push := func(groups []*GroupDump) {
+		snapshots, err := metricsEngine.DrainSnapshots()
+		if err != nil {
+			ac.logger.WithError(err).Error("Couldn't serialize the metrics for the coordinator")
+			return
+		}
+		dump := newMetricsDump(ac.instanceID, snapshots, getCurrentTestRunDuration(), groups)
+		resp, err := ac.client.SendMetrics(ac.ctx, dump)
+		if err != nil {
+			ac.logger.WithError(err).Error("Couldn't send the metrics to the coordinator")
+			// They were drained from the engine, so they are restored in it
+			// to be sent with the next push instead of getting lost
+			if rerr := metricsEngine.RestoreSnapshots(snapshots); rerr != nil {
+				ac.logger.WithError(rerr).Error("Couldn't restore the metrics that weren't sent")
+			}
+			return
+		}
+		if resp.AbortError == "" {
+			return
+		}
+		abortOnce.Do(func() {
+			ac.logger.Debugf("The coordinator aborted the test: %s", resp.AbortError)
+			abortRun(errext.WithAbortReasonIfNone(
+				errext.WithExitCodeIfNone(errors.New(resp.AbortError), exitcodes.ThresholdsHaveFailed),
+				errext.AbortedByThreshold,
+			))
+		})
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(metricsPushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				push(nil)
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return func(rootGroup *lib.Group) {
+		close(stop)
+		<-done
+		ac.logger.Debug("Sending the final metrics to the coordinator...")
+		push(dumpGroups(rootGroup, nil))
+	}
+}
+
+func newMetricsDump(
+	instanceID uint32, snapshots []engine.MetricSnapshot, testRunDuration time.Duration, groups []*GroupDump,
+) *MetricsDump {
+	dump := &MetricsDump{
+		InstanceID:      instanceID,
+		TestRunDuration: int64(testRunDuration),
+		Metrics:         make([]*MetricDump, len(snapshots)),
+		Groups:          groups,
+	}
+	for i, s := range snapshots {
+		dump.Metrics[i] = &MetricDump{Name: s.Name, Type: s.Type.String(), Contains: s.Contains.String(), Data: s.Data}
+	}
+	return dump
+}
+
+// dumpGroups flattens the group tree in depth-first order, so that the
+// coordinator can recreate the groups and checks in the same order.
+func dumpGroups(group *lib.Group, result []*GroupDump) []*GroupDump {
+	dump := &GroupDump{Path: group.Path, Checks: make([]*CheckDump, len(group.OrderedChecks))}
+	for i, check := range group.OrderedChecks {
+		dump.Checks[i] = &CheckDump{Name: check.Name, Passes: check.Passes, Fails: check.Fails}
+	}
+	result = append(result, dump)
+	for _, subGroup := range group.OrderedGroups {
+		result = dumpGroups(subGroup, result)
+	}
+	return result
+}
+
+// SendMetrics merges the metrics from an agent with the ones from all other
+// agents. Its response tells the agent if it should abort the test.
+func (cs *CoordinatorServer) SendMetrics(_ context.Context, dump *MetricsDump) (*MetricsDumpResponse, error) {
+	if err := cs.metrics.merge(dump); err != nil {
+		cs.logger.WithError(err).Errorf("Couldn't merge the metrics from instance %d", dump.InstanceID)
+		return nil, err
+	}
+	resp := &MetricsDumpResponse{}
+	if abortErr := cs.metrics.getAbortErr(); abortErr != nil {
+		resp.AbortError = abortErr.Error()
+	}
+	return resp, nil
+}
+
+// AbortTest makes the coordinator tell all of the agents to abort the test
+// with the given error, the next time they send their metrics. It is meant
+// to be used for thresholds with abortOnFail.
+func (cs *CoordinatorServer) AbortTest(err error) {
+	cs.logger.WithError(err).Debug("Aborting the test on all instances...")
+	cs.metrics.abort(err)
+}
+
+// GetCurrentTestRunDuration returns the longest test run duration that any
+// of the agents has reported.
+func (cs *CoordinatorServer) GetCurrentTestRunDuration() time.Duration {
+	return cs.metrics.getTestRunDuration()
+}
+
+// RootGroup returns the root group, with the groups and checks from all of
+// the agents merged into it.
+func (cs *CoordinatorServer) RootGroup() *lib.Group {
+	return cs.metrics.rootGroup
+}
@@ -0,0 +1,320 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/ui/pb"
+)
+
+// startConditionsCheckInterval is how often the startWhen conditions of the
+// waiting scenarios are checked.
+const startConditionsCheckInterval = time.Second
+
+// scenarioEnds keeps track of the ends of the scenarios that other scenarios
+// start after, and of the ones with teardown functions. A scenario has ended when its executor has finished in all of
+// the instances of the test, which is synchronized with the controller.
+type scenarioEnds struct {
+	local map[string]chan struct{} // closed when the local executor finishes
+	all   map[string]chan struct{} // closed when the scenario ended everywhere
+
+	// The number of local executors which are running, or which are going to
+	// run without waiting for anything.
+	active atomic.Int64
+
+	// Closed when all of the instances agreed that none of their executors
+	// is active anymore, so the startWhen conditions can't be met anymore.
+	inactive chan struct{}
+}
+
+// errScenariosActive is signaled for the activity checks by the instances
+// which still have active executors.
+var errScenariosActive = errors.New("some scenarios are still active")
+
+func getScenarioEndEventID(name string) string {
+	return "scenario-end-" + name
+}
+
+func getScenariosInactiveEventID(check int) string {
+	return fmt.Sprintf("scenarios-inactive-%d", check)
+}
+
+// newScenarioEnds starts tracking the ends of the scenarios that the other
+// scenarios start after, and of the ones with teardown functions. The scenarios without an executor, since they don't
+// have any work in this instance, have already ended in it. If any scenario
+// has startWhen conditions, it also checks the activity of the executors with
+// the other instances, until the context is done.
+func newScenarioEnds(
+	ctx context.Context, controller Controller, configs []lib.ExecutorConfig, executors []lib.Executor,
+	logger logrus.FieldLogger,
+) *scenarioEnds {
+	se := &scenarioEnds{
+		local:    make(map[string]chan struct{}),
+		all:      make(map[string]chan struct{}),
+		inactive: make(chan struct{}),
+	}
+	se.active.Store(int64(len(executors)))
+	hasStartConditions := false
+	for _, config := range configs {
+		hasStartConditions = hasStartConditions || len(config.GetStartWhen()) > 0
+		names := config.GetStartAfter()
+		if teardown, _ := config.GetTeardown(); teardown != "" {
+			names = append(names[:len(names):len(names)], config.GetName())
+		}
+		for _, name := range names {
+			if _, ok := se.all[name]; !ok {
+				se.local[name] = make(chan struct{})
+				se.all[name] = make(chan struct{})
+			}
+		}
+	}
+	hasExecutor := make(map[string]bool, len(executors))
+	for _, executor := range executors {
+		hasExecutor[executor.GetConfig().GetName()] = true
+	}
+
+	for name := range se.all {
+		if !hasExecutor[name] {
+			close(se.local[name])
+		}
+		eventID := getScenarioEndEventID(name)
+		wait := controller.Subscribe(eventID)
+		go func(name string) {
+			<-se.local[name]
+			err := controller.Signal(eventID, nil)
+			if err == nil {
+				err = wait()
+			}
+			if err != nil {
+				logger.WithError(err).Debugf("Error while waiting for the end of scenario %s", name)
+			}
+			close(se.all[name])
+		}(name)
+	}
+	if hasStartConditions {
+		go se.checkActivity(ctx, controller, logger)
+	}
+	return se
+}
+
+// checkActivity checks whether any local executor is active at every
+// startConditionsCheckInterval, and all of the instances signal the results of
+// their checks with the controller. So they all see the same combined results
+// and close inactive after the same check, the first one at which none of
+// them had an active executor, at it and at the previous check. That has to be
+// the case in two consecutive checks, since the executors that wait for the
+// end of other scenarios aren't active until they end.
+func (se *scenarioEnds) checkActivity(ctx context.Context, controller Controller, logger logrus.FieldLogger) {
+	ticker := time.NewTicker(startConditionsCheckInterval)
+	defer ticker.Stop()
+	wasInactive := false
+	for check := 0; ; check++ {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		isInactive := se.active.Load() == 0
+		var sigErr error
+		if !isInactive || !wasInactive {
+			sigErr = errScenariosActive
+		}
+		wasInactive = isInactive
+
+		eventID := getScenariosInactiveEventID(check)
+		wait := controller.Subscribe(eventID)
+		err := controller.Signal(eventID, sigErr)
+		if err == nil {
+			err = wait()
+		}
+		switch {
+		case err == nil && sigErr == nil:
+			close(se.inactive)
+			return
+		case err == nil || strings.HasSuffix(err.Error(), errScenariosActive.Error()):
+			continue
+		default:
+			logger.WithError(err).Debugf("Error while checking the activity of the scenarios")
+			close(se.inactive)
+			return
+		}
+	}
+}
+
+// finished marks the local executor of the scenario as finished.
+func (se *scenarioEnds) finished(name string) {
+	se.active.Add(-1)
+	if ch, ok := se.local[name]; ok {
+		close(ch)
+	}
+}
+
+// waitFor waits until all of the given scenarios have ended, it returns false
+// if the context was done before that.
+func (se *scenarioEnds) waitFor(ctx context.Context, names []string) bool {
+	for _, name := range names {
+		select {
+		case <-se.all[name]:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return ctx.Err() == nil
+}
+
+// startCondition is one of the startWhen conditions of a scenario, which is
+// evaluated with all of the samples of its metric since the test started.
+type startCondition struct {
+	metric     string
+	thresholds metrics.Thresholds
+	watch      func() (metrics.Sink, time.Duration)
+	sink       metrics.Sink
+	elapsed    time.Duration
+}
+
+// getStartConditions parses and validates the startWhen conditions of all of
+// the scenarios, by scenario name.
+func getStartConditions(trs *lib.TestRunState, configs []lib.ExecutorConfig) (map[string][]*startCondition, error) {
+	result := make(map[string][]*startCondition)
+	for _, config := range configs {
+		startWhen := config.GetStartWhen()
+		if len(startWhen) == 0 {
+			continue
+		}
+		if trs.MetricsWatcher == nil {
+			return nil, fmt.Errorf("the startWhen conditions of scenario %s need the metrics of the test, "+
+				"which aren't processed when both the end-of-test summary and the thresholds are disabled",
+				config.GetName())
+		}
+
+		metricNames := make([]string, 0, len(startWhen))
+		for metric := range startWhen {
+			metricNames = append(metricNames, metric)
+		}
+		sort.Strings(metricNames)
+		for _, metric := range metricNames {
+			thresholds := metrics.NewThresholds(startWhen[metric])
+			if err := thresholds.Validate(metric, trs.Registry); err != nil {
+				return nil, fmt.Errorf("invalid startWhen condition of scenario %s: %w", config.GetName(), err)
+			}
+			result[config.GetName()] = append(result[config.GetName()], &startCondition{
+				metric:     metric,
+				thresholds: thresholds,
+			})
+		}
+	}
+	return result, nil
+}
+
+// holds checks whether the condition holds, with the new samples of its
+// metric. It doesn't hold before there are any samples.
+func (sc *startCondition) holds() (bool, error) {
+	sink, elapsed := sc.watch()
+	sc.elapsed += elapsed
+	if sc.sink == nil {
+		sc.sink = sink
+	} else if !sink.IsEmpty() {
+		from, isMergeable := sink.(metrics.MergeableSink)
+		to, isMergeableTo := sc.sink.(metrics.MergeableSink)
+		if !isMergeable || !isMergeableTo {
+			return false, fmt.Errorf("the %T sink of metric %s can't be merged", sink, sc.metric)
+		}
+		data, err := from.Drain()
+		if err != nil {
+			return false, err
+		}
+		if err = to.Merge(data); err != nil {
+			return false, err
+		}
+	}
+	if sc.sink.IsEmpty() {
+		return false, nil
+	}
+	return sc.thresholds.Run(sc.sink, sc.elapsed)
+}
+
+// waitForStart waits until the scenarios that the executor starts after have
+// ended and its startWhen conditions hold. It returns false if the context
+// was done before that.
+func (e *Scheduler) waitForStart(
+	ctx context.Context, ends *scenarioEnds, executor lib.Executor, logger logrus.FieldLogger,
+) (bool, error) {
+	config := executor.GetConfig()
+	startAfter := config.GetStartAfter()
+	conditions := e.startConditions[config.GetName()]
+	if len(startAfter) == 0 && len(conditions) == 0 {
+		return true, nil
+	}
+	for _, condition := range conditions {
+		watch, err := e.state.Test.MetricsWatcher.WatchMetric(condition.metric)
+		if err != nil {
+			return false, err
+		}
+		condition.watch = watch
+	}
+
+	startTime := time.Now()
+	waitingFor := func(reason string) {
+		executor.GetProgress().Modify(
+			pb.WithStatus(pb.Waiting),
+			pb.WithProgress(func() (float64, []string) {
+				return 0, []string{reason, pb.GetFixedLengthDuration(time.Since(startTime), 0)}
+			}),
+		)
+	}
+
+	if len(startAfter) > 0 {
+		logger.Debugf("Waiting for the scenarios it starts after...")
+		waitingFor("waiting for " + strings.Join(startAfter, ", "))
+		ends.active.Add(-1)
+		ended := ends.waitFor(ctx, startAfter)
+		ends.active.Add(1)
+		if !ended {
+			return false, nil
+		}
+	}
+	if len(conditions) == 0 {
+		return true, nil
+	}
+
+	// While the conditions are checked, the executor isn't active, and if no
+	// other executor is active in any of the instances either, the conditions
+	// can't be met anymore.
+	logger.Debugf("Waiting for the startWhen conditions...")
+	waitingFor("waiting for the start conditions")
+	ends.active.Add(-1)
+	defer ends.active.Add(1)
+	ticker := time.NewTicker(startConditionsCheckInterval)
+	defer ticker.Stop()
+	for {
+		allHold := true
+		for _, condition := range conditions {
+			holds, err := condition.holds()
+			if err != nil {
+				return false, err
+			}
+			allHold = allHold && holds
+		}
+		if allHold {
+			return true, nil
+		}
+		select {
+		case <-ends.inactive:
+			logger.Warn("The scenario didn't start, since all of the other scenarios have finished " +
+				"before its startWhen conditions were met")
+			return false, nil
+		case <-ticker.C:
+		case <-ctx.Done():
+			return false, nil
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package execution_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/execution"
+	"go.k6.io/k6/execution/local"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/executor"
+	"go.k6.io/k6/lib/testutils/minirunner"
+	"go.k6.io/k6/metrics"
+)
+
+type testMetricsWatcher func(name string) metrics.Sink
+
+func (w testMetricsWatcher) WatchMetric(name string) (func() (metrics.Sink, time.Duration), error) {
+	return func() (metrics.Sink, time.Duration) { return w(name), 100 * time.Millisecond }, nil
+}
+
+func runSchedulerWithScenarios(
+	t *testing.T, scenarios lib.ScenarioConfigs, runner *minirunner.MiniRunner, watcher lib.MetricsWatcher,
+) error {
+	t.Helper()
+	return runSchedulerWithController(t, scenarios, runner, watcher, local.NewController())
+}
+
+func runSchedulerWithController(
+	t *testing.T, scenarios lib.ScenarioConfigs, runner *minirunner.MiniRunner, watcher lib.MetricsWatcher,
+	controller execution.Controller,
+) error {
+	t.Helper()
+	testRunState := getTestRunState(t, getTestPreInitState(t), lib.Options{Scenarios: scenarios}, runner)
+	testRunState.MetricsWatcher = watcher
+	execScheduler, err := execution.NewScheduler(testRunState, controller)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	samples := make(chan metrics.SampleContainer, 1000)
+	go func() {
+		for range samples { //nolint:revive
+		}
+	}()
+	defer close(samples)
+
+	stopEmission, err := execScheduler.Init(ctx, samples)
+	if err != nil {
+		return err
+	}
+	defer stopEmission()
+	return execScheduler.Run(ctx, ctx, samples)
+}
+
+func getTestIterationsConfig(name string, iterations int64, startAfter ...string) executor.SharedIterationsConfig {
+	config := executor.NewSharedIterationsConfig(name)
+	config.VUs = null.IntFrom(1)
+	config.Iterations = null.IntFrom(iterations)
+	config.StartAfter = startAfter
+	return config
+}
+
+func TestSchedulerStartAfter(t *testing.T) {
+	t.Parallel()
+
+	var (
+		lock      sync.Mutex
+		scenarios []string
+	)
+	runner := &minirunner.MiniRunner{
+		Fn: func(ctx context.Context, _ *lib.State, _ chan<- metrics.SampleContainer) error {
+			time.Sleep(10 * time.Millisecond)
+			lock.Lock()
+			scenarios = append(scenarios, lib.GetScenarioState(ctx).Name)
+			lock.Unlock()
+			return nil
+		},
+	}
+	err := runSchedulerWithScenarios(t, lib.ScenarioConfigs{
+		"first":  getTestIterationsConfig("first", 3),
+		"second": getTestIterationsConfig("second", 2, "first"),
+		"third":  getTestIterationsConfig("third", 1, "first", "second"),
+	}, runner, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first", "first", "first", "second", "second", "third"}, scenarios)
+}
+
+func TestSchedulerStartWhen(t *testing.T) {
+	t.Parallel()
+
+	var (
+		newWarmupIterations atomic.Int64
+		warmupIterations    atomic.Int64
+		loadIterations      atomic.Int64
+		warmupAtLoadStart   atomic.Int64
+	)
+	runner := &minirunner.MiniRunner{
+		Fn: func(ctx context.Context, _ *lib.State, _ chan<- metrics.SampleContainer) error {
+			if lib.GetScenarioState(ctx).Name == "warmup" {
+				newWarmupIterations.Add(1)
+				warmupIterations.Add(1)
+				time.Sleep(5 * time.Millisecond)
+				return nil
+			}
+			if loadIterations.Add(1) == 1 {
+				warmupAtLoadStart.Store(warmupIterations.Load())
+			}
+			return nil
+		},
+	}
+	watcher := testMetricsWatcher(func(name string) metrics.Sink {
+		assert.Equal(t, "iterations", name)
+		sink := metrics.NewSink(metrics.Counter)
+		if n := newWarmupIterations.Swap(0); n > 0 {
+			sink.Add(metrics.Sample{Time: time.Now(), Value: float64(n)})
+		}
+		return sink
+	})
+
+	// The load scenario starts when the warmup one has done at least 20
+	// iterations, which is before it ends
+	load := getTestIterationsConfig("load", 5)
+	load.StartWhen = map[string][]string{"iterations": {"count>=20"}}
+	err := runSchedulerWithScenarios(t, lib.ScenarioConfigs{
+		"warmup": getTestIterationsConfig("warmup", 300),
+		"load":   load,
+	}, runner, watcher)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), loadIterations.Load())
+	assert.GreaterOrEqual(t, warmupAtLoadStart.Load(), int64(20))
+	assert.Less(t, warmupAtLoadStart.Load(), int64(300))
+}
+
+func TestSchedulerStartWhenNeverMet(t *testing.T) {
+	t.Parallel()
+
+	var loadIterations atomic.Int64
+	runner := &minirunner.MiniRunner{
+		Fn: func(ctx context.Context, _ *lib.State, _ chan<- metrics.SampleContainer) error {
+			if lib.GetScenarioState(ctx).Name != "warmup" {
+				loadIterations.Add(1)
+			}
+			return nil
+		},
+	}
+	watcher := testMetricsWatcher(func(string) metrics.Sink { return metrics.NewSink(metrics.Counter) })
+
+	// The scenario that starts after the one that never starts still runs
+	load := getTestIterationsConfig("load", 5)
+	load.StartWhen = map[string][]string{"iterations": {"count>=20"}}
+	err := runSchedulerWithScenarios(t, lib.ScenarioConfigs{
+		"warmup":   getTestIterationsConfig("warmup", 1),
+		"load":     load,
+		"cooldown": getTestIterationsConfig("cooldown", 1, "load"),
+	}, runner, watcher)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), loadIterations.Load())
+}
+
+// activeElsewhereController is a local controller for which another instance
+// still has active scenarios at the first activity checks.
+type activeElsewhereController struct {
+	*local.Controller
+	activeChecks int
+
+	mx      sync.Mutex
+	signals []string
+}
+
+func (c *activeElsewhereController) Signal(eventID string, err error) error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if strings.HasPrefix(eventID, "scenarios-inactive-") {
+		c.signals = append(c.signals, fmt.Sprintf("%s %v", eventID, err))
+	}
+	return nil
+}
+
+func (c *activeElsewhereController) Subscribe(eventID string) func() error {
+	var check int
+	if _, err := fmt.Sscanf(eventID, "scenarios-inactive-%d", &check); err != nil || check >= c.activeChecks {
+		return c.Controller.Subscribe(eventID)
+	}
+	return func() error { return errors.New("instance 2: some scenarios are still active") }
+}
+
+func TestSchedulerStartWhenNeverMetActiveElsewhere(t *testing.T) {
+	t.Parallel()
+
+	var loadIterations atomic.Int64
+	runner := &minirunner.MiniRunner{
+		Fn: func(ctx context.Context, _ *lib.State, _ chan<- metrics.SampleContainer) error {
+			if lib.GetScenarioState(ctx).Name == "load" {
+				loadIterations.Add(1)
+			}
+			return nil
+		},
+	}
+	watcher := testMetricsWatcher(func(string) metrics.Sink { return metrics.NewSink(metrics.Counter) })
+
+	// The scenario waits while another instance has active scenarios, even
+	// though none is active in this one
+	load := getTestIterationsConfig("load", 5)
+	load.StartWhen = map[string][]string{"iterations": {"count>=20"}}
+	controller := &activeElsewhereController{Controller: local.NewController(), activeChecks: 3}
+	err := runSchedulerWithController(t, lib.ScenarioConfigs{
+		"warmup": getTestIterationsConfig("warmup", 1),
+		"load":   load,
+	}, runner, watcher, controller)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), loadIterations.Load())
+	assert.Equal(t, []string{
+		"scenarios-inactive-0 some scenarios are still active",
+		"scenarios-inactive-1 <nil>",
+		"scenarios-inactive-2 <nil>",
+		"scenarios-inactive-3 <nil>",
+	}, controller.signals)
+}
+
+func TestSchedulerStartWhenWithoutMetrics(t *testing.T) {
+	t.Parallel()
+
+	load := getTestIterationsConfig("load", 1)
+	load.StartWhen = map[string][]string{"iterations": {"count>=20"}}
+	err := runSchedulerWithScenarios(t, lib.ScenarioConfigs{"load": load}, &minirunner.MiniRunner{}, nil)
+	assert.ErrorContains(t, err, "the startWhen conditions of scenario load need the metrics of the test")
+}
@@ -29,6 +29,15 @@ type Scheduler struct {
 	maxDuration     time.Duration // cached value derived from the execution plan
 	maxPossibleVUs  uint64        // cached value derived from the execution plan
 	state           *lib.ExecutionState
+
+	// The startWhen conditions of the scenarios, by scenario name
+	startConditions map[string][]*startCondition
+
+	// The progress of the executors and of setup() for the checkpoints, and
+	// the checkpoint of the previous run of the test, if it was resumed
+	progress    map[string]*executorProgress
+	setupDone   atomic.Bool
+	resumedFrom *Checkpoint
 }
 
 // NewScheduler creates and returns a new Scheduler instance, without
@@ -50,6 +59,7 @@ func NewScheduler(trs *lib.TestRunState, controller Controller) (*Scheduler, err
 
 	executorConfigs := options.Scenarios.GetSortedConfigs()
 	executors := make([]lib.Executor, 0, len(executorConfigs))
+	progress := make(map[string]*executorProgress, len(executorConfigs))
 	// Only take executors which have work.
 	for _, sc := range executorConfigs {
 		if !sc.HasWork(et) {
@@ -67,6 +77,7 @@ func NewScheduler(trs *lib.TestRunState, controller Controller) (*Scheduler, err
 			return nil, err
 		}
 		executors = append(executors, s)
+		progress[sc.GetName()] = &executorProgress{}
 	}
 
 	if options.Paused.Bool {
@@ -84,6 +95,7 @@ func NewScheduler(trs *lib.TestRunState, controller Controller) (*Scheduler, err
 		maxPossibleVUs:  maxPossibleVUs,
 		state:           executionState,
 		controller:      controller,
+		progress:        progress,
 	}, nil
 }
 
@@ -325,11 +337,13 @@ func (e *Scheduler) initVUsAndExecutors(ctx context.Context, samplesOut chan<- m
 }
 
 // runExecutor gets called by the public Run() method once per configured
-// executor, each time in a new goroutine. It is responsible for waiting out the
-// configured startTime for the specific executor and then running its Run()
-// method.
+// executor, each time in a new goroutine. It is responsible for waiting for the
+// scenarios the executor starts after and for its startWhen conditions, then
+// waiting out its configured startTime, and then running the setup function of
+// its scenario, its Run() method and the teardown function of its scenario.
 func (e *Scheduler) runExecutor(
-	runCtx context.Context, runResults chan<- error, engineOut chan<- metrics.SampleContainer, executor lib.Executor,
+	runCtx, teardownCtx context.Context, runResults chan<- error, engineOut chan<- metrics.SampleContainer,
+	ends *scenarioEnds, executor lib.Executor,
 ) {
 	executorConfig := executor.GetConfig()
 	executorStartTime := executorConfig.GetStartTime()
@@ -339,9 +353,20 @@ func (e *Scheduler) runExecutor(
 		"startTime": executorStartTime,
 	})
 	executorProgress := executor.GetProgress()
+	checkpointProgress := e.progress[executorConfig.GetName()]
+	var finishOnce sync.Once
+	finished := func() { finishOnce.Do(func() { ends.finished(executorConfig.GetName()) }) }
+	defer finished()
+
+	// Wait for the scenarios it starts after and for its start conditions
+	if start, err := e.waitForStart(runCtx, ends, executor, executorLogger); !start {
+		runResults <- err // no error if the executor hasn't started because the test ended
+		return
+	}
 
 	// Check if we have to wait before starting the actual executor execution
 	if executorStartTime > 0 {
+		checkpointProgress.mark(&checkpointProgress.waitStart)
 		startTime := time.Now()
 		executorProgress.Modify(
 			pb.WithStatus(pb.Waiting),
@@ -361,20 +386,103 @@ func (e *Scheduler) runExecutor(
 		}
 	}
 
+	if err := e.runScenarioSetup(runCtx, engineOut, executor, executorLogger); err != nil {
+		runResults <- err
+		return
+	}
+
 	executorProgress.Modify(
 		pb.WithStatus(pb.Running),
 		pb.WithConstProgress(0, "started"),
 	)
 	executorLogger.Debugf("Starting executor")
+	checkpointProgress.mark(&checkpointProgress.start)
 	err := executor.Run(runCtx, engineOut) // executor should handle context cancel itself
+	if runCtx.Err() == nil {
+		checkpointProgress.mark(&checkpointProgress.end)
+	} else {
+		// the test run was interrupted, so it may not have done all of its work
+		checkpointProgress.mark(&checkpointProgress.stop)
+	}
 	if err == nil {
 		executorLogger.Debugf("Executor finished successfully")
 	} else {
 		executorLogger.WithField("error", err).Errorf("Executor error")
 	}
+	finished()
+
+	if teardownErr := e.runScenarioTeardown(teardownCtx, engineOut, ends, executor, executorLogger); err == nil {
+		err = teardownErr
+	}
 	runResults <- err
 }
 
+// runScenarioSetup runs the setup function of the scenario of the executor, if
+// it has one and setup isn't disabled. Like setup(), it runs only once in all
+// of the instances of the test, and the other ones get the data it returned.
+func (e *Scheduler) runScenarioSetup(
+	ctx context.Context, samplesOut chan<- metrics.SampleContainer, executor lib.Executor, logger logrus.FieldLogger,
+) error {
+	scenario := executor.GetConfig().GetName()
+	setupFn, _ := executor.GetConfig().GetSetup()
+	if setupFn == "" || e.state.Test.Options.NoSetup.Bool {
+		return nil
+	}
+
+	runner := e.state.Test.Runner
+	if e.resumedFrom != nil {
+		if previous := e.resumedFrom.Scenarios[scenario]; previous.Started {
+			runner.SetScenarioSetupData(scenario, previous.SetupData)
+			return nil
+		}
+	}
+
+	executor.GetProgress().Modify(pb.WithConstProgress(0, setupFn+"()"))
+	actuallyRanSetup := false
+	data, err := e.controller.GetOrCreateData("scenario-setup-"+scenario, func() ([]byte, error) {
+		actuallyRanSetup = true
+		if err := runner.ScenarioSetup(ctx, scenario, samplesOut); err != nil {
+			logger.WithField("error", err).Debugf("%s() aborted by error", setupFn)
+			return nil, err
+		}
+		return runner.GetScenarioSetupData(scenario), nil
+	})
+	if err != nil {
+		return err
+	}
+	if !actuallyRanSetup {
+		runner.SetScenarioSetupData(scenario, data)
+	}
+	return nil
+}
+
+// runScenarioTeardown runs the teardown function of the scenario of the
+// executor, if it has one and teardown isn't disabled, once the scenario has
+// ended in all of the instances of the test. Like teardown(), it runs only once.
+func (e *Scheduler) runScenarioTeardown(
+	ctx context.Context, samplesOut chan<- metrics.SampleContainer, ends *scenarioEnds, executor lib.Executor,
+	logger logrus.FieldLogger,
+) error {
+	scenario := executor.GetConfig().GetName()
+	teardownFn, _ := executor.GetConfig().GetTeardown()
+	if teardownFn == "" || e.state.Test.Options.NoTeardown.Bool {
+		return nil
+	}
+
+	logger.Debugf("Waiting for the end of the scenario in all instances before %s()...", teardownFn)
+	if !ends.waitFor(ctx, []string{scenario}) {
+		return nil
+	}
+	_, err := e.controller.GetOrCreateData("scenario-teardown-"+scenario, func() ([]byte, error) {
+		if err := e.state.Test.Runner.ScenarioTeardown(ctx, scenario, samplesOut); err != nil {
+			logger.WithField("error", err).Debugf("%s() aborted by error", teardownFn)
+			return nil, err
+		}
+		return nil, nil
+	})
+	return err
+}
+
 // Init concurrently initializes all of the planned VUs and then sequentially
 // initializes all of the configured executors. It also starts the measurement
 // and emission of the `vus` and `vus_max` metrics.
@@ -386,6 +494,12 @@ func (e *Scheduler) Init(
 	if err := SignalAndWait(e.controller, "scheduler-init-start"); err != nil {
 		return nil, err
 	}
+
+	startConditions, err := getStartConditions(e.state.Test, e.executorConfigs)
+	if err != nil {
+		return nil, SignalErrorOrWait(e.controller, "scheduler-init-done", err)
+	}
+	e.startConditions = startConditions
 	defer func() {
 		initErr = SignalErrorOrWait(e.controller, "scheduler-init-done", initErr)
 	}()
@@ -463,8 +577,11 @@ func (e *Scheduler) Run(globalCtx, runCtx context.Context, samplesOut chan<- met
 	// its properties in their init context executions.
 	withExecStateCtx := lib.WithExecutionState(runCtx, e.state)
 
-	// Run setup() before any executors, if it's not disabled
-	if !e.state.Test.Options.NoSetup.Bool {
+	// Run setup() before any executors, if it's not disabled and it didn't
+	// run before the checkpoint the test was resumed from
+	if e.resumedFrom != nil && e.resumedFrom.SetupDone {
+		e.state.Test.Runner.SetSetupData(e.resumedFrom.SetupData)
+	} else if !e.state.Test.Options.NoSetup.Bool {
 		e.state.SetExecutionStatus(lib.ExecutionStatusSetup)
 		e.initProgress.Modify(pb.WithConstProgress(1, "setup()"))
 		actuallyRanSetup := false
@@ -483,6 +600,7 @@ func (e *Scheduler) Run(globalCtx, runCtx context.Context, samplesOut chan<- met
 			e.state.Test.Runner.SetSetupData(data)
 		}
 	}
+	e.setupDone.Store(true)
 
 	if err := SignalAndWait(e.controller, "setup-done"); err != nil {
 		return err
@@ -496,8 +614,11 @@ func (e *Scheduler) Run(globalCtx, runCtx context.Context, samplesOut chan<- met
 
 	executorsRunCtx, executorsRunCancel := context.WithCancel(withExecStateCtx)
 	defer executorsRunCancel()
+	ends := newScenarioEnds(executorsRunCtx, e.controller, e.executorConfigs, e.executors, logger)
+	// Like teardown(), the teardown functions of the scenarios run with the
+	// global context, so they aren't interrupted when the test is stopped.
 	for _, exec := range e.executors {
-		go e.runExecutor(executorsRunCtx, runResults, samplesOut, exec)
+		go e.runExecutor(executorsRunCtx, globalCtx, runResults, samplesOut, ends, exec)
 	}
 
 	// Wait for all executors to finish
@@ -8,6 +8,7 @@ import (
 	"net"
 	"net/url"
 	"reflect"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -816,6 +817,93 @@ func TestSchedulerSetupTeardownRun(t *testing.T) {
 	})
 }
 
+func TestSchedulerScenarioSetupTeardownRun(t *testing.T) {
+	t.Parallel()
+
+	getScenarios := func() lib.ScenarioConfigs {
+		login := executor.NewSharedIterationsConfig("login")
+		login.Iterations = null.IntFrom(2)
+		login.Setup = null.StringFrom("loginSetup")
+		login.Teardown = null.StringFrom("loginTeardown")
+		browse := executor.NewSharedIterationsConfig("browse")
+		browse.StartAfter = []string{"login"}
+		return lib.ScenarioConfigs{"login": login, "browse": browse}
+	}
+	newRunner := func(setupErr error) (*minirunner.MiniRunner, func() []string) {
+		var (
+			lock   sync.Mutex
+			events []string
+		)
+		addEvent := func(event string) {
+			lock.Lock()
+			defer lock.Unlock()
+			events = append(events, event)
+		}
+		runner := &minirunner.MiniRunner{
+			Fn: func(ctx context.Context, _ *lib.State, _ chan<- metrics.SampleContainer) error {
+				addEvent(lib.GetScenarioState(ctx).Name)
+				return nil
+			},
+			SetupFn: func(_ context.Context, _ chan<- metrics.SampleContainer) ([]byte, error) {
+				addEvent("setup")
+				return nil, nil
+			},
+			TeardownFn: func(_ context.Context, _ chan<- metrics.SampleContainer) error {
+				addEvent("teardown")
+				return nil
+			},
+			ScenarioSetupFn: func(_ context.Context, scenario string, _ chan<- metrics.SampleContainer) ([]byte, error) {
+				addEvent(scenario + " setup")
+				return []byte(`"token"`), setupErr
+			},
+			ScenarioTeardownFn: func(_ context.Context, scenario string, _ chan<- metrics.SampleContainer) error {
+				addEvent(scenario + " teardown")
+				return nil
+			},
+		}
+		return runner, func() []string {
+			lock.Lock()
+			defer lock.Unlock()
+			return events
+		}
+	}
+
+	t.Run("Normal", func(t *testing.T) {
+		t.Parallel()
+		runner, getEvents := newRunner(nil)
+		ctx, cancel, execScheduler, samples := newTestScheduler(t, runner, nil, lib.Options{Scenarios: getScenarios()})
+		defer cancel()
+		require.NoError(t, execScheduler.Run(ctx, ctx, samples))
+		// The scenarios that start after login don't wait for its teardown
+		events := getEvents()
+		require.Len(t, events, 7)
+		assert.Equal(t, []string{"setup", "login setup", "login", "login"}, events[:4])
+		assert.ElementsMatch(t, []string{"login teardown", "browse"}, events[4:6])
+		assert.Equal(t, "teardown", events[6])
+		assert.Equal(t, []byte(`"token"`), runner.GetScenarioSetupData("login"))
+	})
+	t.Run("Setup Error", func(t *testing.T) {
+		t.Parallel()
+		runner, getEvents := newRunner(errors.New("login setup error"))
+		ctx, cancel, execScheduler, samples := newTestScheduler(t, runner, nil, lib.Options{Scenarios: getScenarios()})
+		defer cancel()
+		assert.EqualError(t, execScheduler.Run(ctx, ctx, samples), "login setup error")
+		assert.Equal(t, []string{"setup", "login setup", "teardown"}, getEvents())
+	})
+	t.Run("Don't Run Setup And Teardown", func(t *testing.T) {
+		t.Parallel()
+		runner, getEvents := newRunner(nil)
+		ctx, cancel, execScheduler, samples := newTestScheduler(t, runner, nil, lib.Options{
+			Scenarios:  getScenarios(),
+			NoSetup:    null.BoolFrom(true),
+			NoTeardown: null.BoolFrom(true),
+		})
+		defer cancel()
+		require.NoError(t, execScheduler.Run(ctx, ctx, samples))
+		assert.Equal(t, []string{"login", "login", "browse"}, getEvents())
+	})
+}
+
 func TestSchedulerStages(t *testing.T) {
 	t.Parallel()
 	testdata := map[string]struct {
@@ -1253,7 +1341,7 @@ func TestRealTimeAndSetupTeardownMetrics(t *testing.T) {
 				if assert.Len(t, gotSamples, len(expSamples)) {
 					for i, s := range gotSamples {
 						expS := expSamples[i]
-						if s.Metric.Name != metrics.IterationDurationName {
+						if s.Metric.Name != metrics.IterationDurationName && s.Metric.Name != metrics.ThinkTimeName {
 							assert.Equal(t, expS.Value, s.Value)
 						}
 						assert.Equal(t, expS.Metric.Name, s.Metric.Name)
@@ -1338,11 +1426,13 @@ func TestRealTimeAndSetupTeardownMetrics(t *testing.T) {
 	expectIn(900, 1100, getSample(6, testCounter, "group", "", "place", "defaultAfterSleep", "scenario", "default"))
 	expectIn(0, 100, getNetworkSamples("", "scenario", "default"))
 	expectIn(0, 100, getIterationsSamples("", "scenario", "default"))
+	expectIn(0, 100, getSample(1000, piState.BuiltinMetrics.ThinkTime, "group", "", "scenario", "default"))
 
 	expectIn(0, 100, getSample(5, testCounter, "group", "", "place", "defaultBeforeSleep", "scenario", "default"))
 	expectIn(900, 1100, getSample(6, testCounter, "group", "", "place", "defaultAfterSleep", "scenario", "default"))
 	expectIn(0, 100, getNetworkSamples("", "scenario", "default"))
 	expectIn(0, 100, getIterationsSamples("", "scenario", "default"))
+	expectIn(0, 100, getSample(1000, piState.BuiltinMetrics.ThinkTime, "group", "", "scenario", "default"))
 
 	expectIn(0, 1000, getSample(3, testCounter, "group", "::teardown", "place", "teardownBeforeSleep"))
 	expectIn(900, 1100, getSample(4, testCounter, "group", "::teardown", "place", "teardownAfterSleep"))
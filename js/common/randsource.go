@@ -3,6 +3,7 @@ package common
 import (
 	crand "crypto/rand"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"math/rand"
 
@@ -14,9 +15,29 @@ import (
 // The returned RandSource is NOT safe for concurrent use:
 // https://golang.org/pkg/math/rand/#NewSource
 func NewRandSource() sobek.RandSource {
+	return NewRand().Float64
+}
+
+// NewRand returns a new pseudo-random generator with a random seed. It's NOT
+// safe for concurrent use.
+func NewRand() *rand.Rand {
 	var seed int64
 	if err := binary.Read(crand.Reader, binary.LittleEndian, &seed); err != nil {
 		panic(fmt.Errorf("could not read random bytes: %w", err))
 	}
-	return rand.New(rand.NewSource(seed)).Float64 //nolint:gosec
+	return rand.New(rand.NewSource(seed)) //nolint:gosec
+}
+
+// MathRandom returns a random number in [0, 1) from the Math.random() of the
+// runtime, so it's the same in every test run if the script calls randomSeed().
+func MathRandom(rt *sobek.Runtime) (float64, error) {
+	random, ok := sobek.AssertFunction(rt.Get("Math").ToObject(rt).Get("random"))
+	if !ok {
+		return 0, errors.New("Math.random isn't a function")
+	}
+	value, err := random(sobek.Undefined())
+	if err != nil {
+		return 0, err
+	}
+	return value.ToFloat(), nil
 }
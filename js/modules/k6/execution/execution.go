@@ -122,6 +122,13 @@ func (mi *ModuleInstance) newScenarioInfo() (*sobek.Object, error) {
 
 			return vuState.GetScenarioGlobalVUIter()
 		},
+		"record": func() interface{} {
+			// only the replay-arrival executor supplies the replayed record
+			if vuState.GetScenarioIterationData == nil {
+				return nil
+			}
+			return vuState.GetScenarioIterationData()
+		},
 	}
 
 	return newInfoObj(rt, si)
@@ -223,7 +223,7 @@ func TestAbortTest(t *testing.T) { //nolint:tparallel
 func TestOptionsTestFull(t *testing.T) {
 	t.Parallel()
 
-	expected := `{"paused":true,"scenarios":{"const-vus":{"executor":"constant-vus","options":{"browser":{"someOption":true}},"startTime":"10s","gracefulStop":"30s","env":{"FOO":"bar"},"exec":"default","tags":{"tagkey":"tagvalue"},"vus":50,"duration":"10m0s"}},"executionSegment":"0:1/4","executionSegmentSequence":"0,1/4,1/2,1","noSetup":true,"setupTimeout":"1m0s","noTeardown":true,"teardownTimeout":"5m0s","rps":100,"dns":{"ttl":"1m","select":"roundRobin","policy":"any"},"maxRedirects":3,"userAgent":"k6-user-agent","batch":15,"batchPerHost":5,"httpDebug":"full","insecureSkipTLSVerify":true,"tlsCipherSuites":["TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"],"tlsVersion":{"min":"tls1.2","max":"tls1.3"},"tlsAuth":[{"domains":["example.com"],"cert":"mycert.pem","key":"mycert-key.pem","password":"mypwd"}],"throw":true,"thresholds":{"http_req_duration":[{"threshold":"rate>0.01","abortOnFail":true,"delayAbortEval":"10s"}]},"blacklistIPs":["192.0.2.0/24"],"blockHostnames":["test.k6.io","*.example.com"],"hosts":{"test.k6.io":"1.2.3.4:8443"},"noConnectionReuse":true,"noVUConnectionReuse":true,"minIterationDuration":"10s","ext":{"ext-one":{"rawkey":"rawvalue"}},"summaryTrendStats":["avg","min","max"],"summaryTimeUnit":"ms","systemTags":["iter","vu"],"tags":null,"metricSamplesBufferSize":8,"noCookiesReset":true,"discardResponseBodies":true,"consoleOutput":"loadtest.log","tags":{"runtag-key":"runtag-value"},"localIPs":"192.168.20.12-192.168.20.15,192.168.10.0/27"}`
+	expected := `{"paused":true,"scenarios":{"const-vus":{"executor":"constant-vus","options":{"browser":{"someOption":true}},"startTime":"10s","gracefulStop":"30s","env":{"FOO":"bar"},"exec":"default","setup":null,"setupTimeout":null,"teardown":null,"teardownTimeout":null,"pacing":null,"tags":{"tagkey":"tagvalue"},"vus":50,"duration":"10m0s"}},"executionSegment":"0:1/4","executionSegmentSequence":"0,1/4,1/2,1","randomSeed":42,"noSetup":true,"setupTimeout":"1m0s","noTeardown":true,"teardownTimeout":"5m0s","rps":100,"httpLimits":{"*":{"rps":50.5,"concurrency":null},"test.k6.io":{"rps":null,"concurrency":10}},"dns":{"ttl":"1m","select":"roundRobin","policy":"any"},"maxRedirects":3,"userAgent":"k6-user-agent","batch":15,"batchPerHost":5,"httpDebug":"full","insecureSkipTLSVerify":true,"tlsCipherSuites":["TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"],"tlsVersion":{"min":"tls1.2","max":"tls1.3"},"tlsAuth":[{"domains":["example.com"],"cert":"mycert.pem","key":"mycert-key.pem","password":"mypwd"}],"throw":true,"thresholds":{"http_req_duration":[{"threshold":"rate>0.01","abortOnFail":true,"delayAbortEval":"10s"}]},"blacklistIPs":["192.0.2.0/24"],"blockHostnames":["test.k6.io","*.example.com"],"hosts":{"test.k6.io":"1.2.3.4:8443"},"noConnectionReuse":true,"http3":true,"noVUConnectionReuse":true,"minIterationDuration":"10s","ext":{"ext-one":{"rawkey":"rawvalue"}},"summaryTrendStats":["avg","min","max"],"summaryTimeUnit":"ms","trendSink":"histogram","trendSinkMaxError":0.05,"systemTags":["iter","vu"],"tags":null,"metricSamplesBufferSize":8,"noCookiesReset":true,"discardResponseBodies":true,"consoleOutput":"loadtest.log","tags":{"runtag-key":"runtag-value"},"localIPs":"192.168.20.12-192.168.20.15,192.168.10.0/27"}`
 
 	var (
 		rt    = sobek.New()
@@ -240,7 +240,7 @@ func TestOptionsTestFull(t *testing.T) {
 							Env: map[string]string{
 								"FOO": "bar",
 							},
-							Exec: null.StringFrom("default"),
+							Exec: executor.ScenarioExecFrom("default"),
 							Tags: map[string]string{
 								"tagkey": "tagvalue",
 							},
@@ -267,12 +267,14 @@ func TestOptionsTestFull(t *testing.T) {
 				NoSetup:               null.BoolFrom(true),
 				NoTeardown:            null.BoolFrom(true),
 				NoConnectionReuse:     null.BoolFrom(true),
+				HTTP3:                 null.BoolFrom(true),
 				NoVUConnectionReuse:   null.BoolFrom(true),
 				InsecureSkipTLSVerify: null.BoolFrom(true),
 				Throw:                 null.BoolFrom(true),
 				NoCookiesReset:        null.BoolFrom(true),
 				DiscardResponseBodies: null.BoolFrom(true),
 				RPS:                   null.IntFrom(100),
+				RandomSeed:            null.IntFrom(42),
 				MaxRedirects:          null.IntFrom(3),
 				UserAgent:             null.StringFrom("k6-user-agent"),
 				Batch:                 null.IntFrom(15),
@@ -281,6 +283,10 @@ func TestOptionsTestFull(t *testing.T) {
 				TeardownTimeout:       types.NullDurationFrom(5 * time.Minute),
 				MinIterationDuration:  types.NullDurationFrom(10 * time.Second),
 				HTTPDebug:             null.StringFrom("full"),
+				HTTPLimits: lib.HTTPLimits{
+					lib.HTTPLimitsAllHosts: {RPS: null.FloatFrom(50.5)},
+					"test.k6.io":           {Concurrency: null.IntFrom(10)},
+				},
 				DNS: types.DNSConfig{
 					TTL:    null.StringFrom("1m"),
 					Select: types.NullDNSSelect{DNSSelect: types.DNSroundRobin, Valid: true},
@@ -345,6 +351,8 @@ func TestOptionsTestFull(t *testing.T) {
 				},
 				SummaryTrendStats: []string{"avg", "min", "max"},
 				SummaryTimeUnit:   null.StringFrom("ms"),
+				TrendSink:         null.StringFrom("histogram"),
+				TrendSinkMaxError: null.FloatFrom(0.05),
 				SystemTags: func() *metrics.SystemTagSet {
 					sysm := metrics.SystemTagSet(metrics.TagIter | metrics.TagVU)
 					return &sysm
@@ -0,0 +1,171 @@
+package http
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/netext/httpext"
+)
+
+// Client represents a stand-alone HTTP client.
+type Client struct {
+	moduleInstance   *ModuleInstance
+	responseCallback func(int) bool
+
+	// The options of the clients created with new http.Client(), the default
+	// client doesn't have any.
+	baseURL *url.URL
+	params  sobek.Value // the defaults of the request params
+	tlsAuth []*lib.TLSAuth
+
+	tlsConfigOnce sync.Once
+	tlsConfig     *tls.Config
+	tlsConfigErr  error
+}
+
+// newClient is the http.Client constructor, it returns a client with the
+// method helpers of the module, that makes requests with the params of the
+// client as defaults.
+func (mi *ModuleInstance) newClient(call sobek.ConstructorCall) *sobek.Object {
+	rt := mi.vu.Runtime()
+	c := &Client{
+		moduleInstance:   mi,
+		responseCallback: defaultExpectedStatuses.match,
+	}
+	if err := c.parseOptions(call.Argument(0)); err != nil {
+		common.Throw(rt, fmt.Errorf("invalid http.Client options: %w", err))
+	}
+
+	obj := rt.NewObject()
+	mustSet := func(name string, value interface{}) {
+		if err := obj.Set(name, value); err != nil {
+			common.Throw(rt, err)
+		}
+	}
+	mustSet("get", c.getNoBodyMethodClosure(http.MethodGet))
+	mustSet("head", c.getNoBodyMethodClosure(http.MethodHead))
+	mustSet("post", c.getMethodClosure(http.MethodPost))
+	mustSet("put", c.getMethodClosure(http.MethodPut))
+	mustSet("patch", c.getMethodClosure(http.MethodPatch))
+	mustSet("del", c.getMethodClosure(http.MethodDelete))
+	mustSet("options", c.getMethodClosure(http.MethodOptions))
+	mustSet("request", c.Request)
+	mustSet("asyncRequest", c.asyncRequest)
+	mustSet("batch", c.Batch)
+	mustSet("setResponseCallback", c.SetResponseCallback)
+	return obj
+}
+
+// parseOptions sets the options of the client. The baseURL, tlsAuth and
+// responseCallback options are specific to the client, all of the others are
+// the defaults of the request params.
+func (c *Client) parseOptions(options sobek.Value) error {
+	if common.IsNullish(options) {
+		return nil
+	}
+	rt := c.moduleInstance.vu.Runtime()
+	opts := options.ToObject(rt)
+	params := rt.NewObject()
+	for _, k := range opts.Keys() {
+		v := opts.Get(k)
+		switch k {
+		case "baseURL":
+			u, err := url.Parse(v.String())
+			if err != nil {
+				return fmt.Errorf("invalid baseURL: %w", err)
+			}
+			if !u.IsAbs() {
+				return fmt.Errorf("baseURL should be an absolute URL, but is '%s'", v.String())
+			}
+			c.baseURL = u
+		case "tlsAuth":
+			data, err := json.Marshal(v.Export())
+			if err != nil {
+				return fmt.Errorf("invalid tlsAuth: %w", err)
+			}
+			var tlsAuth []*lib.TLSAuth
+			if err = json.Unmarshal(data, &tlsAuth); err != nil {
+				return fmt.Errorf("invalid tlsAuth: %w", err)
+			}
+			c.tlsAuth = tlsAuth
+		case "responseCallback":
+			c.SetResponseCallback(v)
+		default:
+			if err := params.Set(k, v); err != nil {
+				return err
+			}
+		}
+	}
+	c.params = params
+	return nil
+}
+
+// getNoBodyMethodClosure is getMethodClosure for the methods without a body
+// argument, like http.get(url, params).
+func (c *Client) getNoBodyMethodClosure(method string) func(url sobek.Value, args ...sobek.Value) (*Response, error) {
+	return func(url sobek.Value, args ...sobek.Value) (*Response, error) {
+		// add undefined as the body argument of Request(method, url, body, params)
+		args = append([]sobek.Value{sobek.Undefined()}, args...)
+		return c.Request(method, url, args...)
+	}
+}
+
+// resolveURL resolves the relative URL of a request against the baseURL of the
+// client, and the name of the URL too if it was created with http.url.
+func (c *Client) resolveURL(u httpext.URL) (httpext.URL, error) {
+	resolved := c.baseURL.ResolveReference(u.GetURL()).String()
+	if u.Name == u.Clean() {
+		return httpext.NewURL(resolved, resolved)
+	}
+
+	nameURL, err := url.Parse(u.Name)
+	if err != nil {
+		return httpext.URL{}, err
+	}
+	resolvedName := c.baseURL.ResolveReference(nameURL)
+	resolvedName.User = nil // the name is used as a tag, so it shouldn't have credentials
+	name, err := url.PathUnescape(resolvedName.String())
+	if err != nil {
+		return httpext.URL{}, err
+	}
+	return httpext.NewURL(resolved, name)
+}
+
+// getTLSConfig returns the TLS config of the VU, with the tlsAuth certificates
+// of the client instead of the ones of the tlsAuth option.
+func (c *Client) getTLSConfig(state *lib.State) (*tls.Config, error) {
+	c.tlsConfigOnce.Do(func() {
+		certs := make([]tls.Certificate, len(c.tlsAuth))
+		nameToCert := make(map[string]*tls.Certificate)
+		for i, auth := range c.tlsAuth {
+			cert, err := auth.Certificate()
+			if err != nil {
+				c.tlsConfigErr = err
+				return
+			}
+			certs[i] = *cert
+			for _, name := range auth.Domains {
+				nameToCert[name] = cert
+			}
+		}
+
+		c.tlsConfig = state.TLSConfig.Clone()
+		if c.tlsConfig == nil {
+			c.tlsConfig = &tls.Config{} //nolint:gosec // the VU doesn't have a TLS config only in tests
+		}
+		c.tlsConfig.Certificates = certs
+		c.tlsConfig.NameToCertificate = nil //nolint:staticcheck // the runner sets it for the tlsAuth option
+		if len(nameToCert) > 0 {
+			c.tlsConfig.NameToCertificate = nameToCert //nolint:staticcheck // like the runner does
+		}
+	})
+	return c.tlsConfig, c.tlsConfigErr
+}
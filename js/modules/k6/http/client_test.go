@@ -0,0 +1,174 @@
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/metrics"
+)
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+	ts := newTestCase(t)
+	tb := ts.tb
+	rt := ts.runtime.VU.Runtime()
+	sr := tb.Replacer.Replace
+
+	_, err := rt.RunString(sr(`
+	var client = new http.Client({
+		baseURL: "HTTPBIN_URL/",
+		headers: { "X-Client": "client", "X-Request": "client" },
+		tags: { client: "yes", tag: "client" },
+		timeout: "5s",
+	});
+	`))
+	require.NoError(t, err)
+
+	t.Run("defaults", func(t *testing.T) {
+		metrics.GetBufferedSamples(ts.samples)
+		_, err := rt.RunString(`
+		var res = client.post("post?a=1", "body", { headers: { "X-Request": "request" }, tags: { tag: "request" } });
+		if (res.status != 200) { throw new Error("wrong status: " + res.status) }
+		var json = res.json();
+		if (json.url.indexOf("/post?a=1") < 0) { throw new Error("wrong url: " + json.url) }
+		if (json.data != "body") { throw new Error("wrong body: " + json.data) }
+		if (json.headers["X-Client"] != "client") { throw new Error("wrong X-Client: " + json.headers["X-Client"]) }
+		if (json.headers["X-Request"] != "request") { throw new Error("wrong X-Request: " + json.headers["X-Request"]) }
+		`)
+		require.NoError(t, err)
+
+		for _, container := range metrics.GetBufferedSamples(ts.samples) {
+			for _, sample := range container.GetSamples() {
+				tags := sample.Tags.Map()
+				assert.Equal(t, "yes", tags["client"])
+				assert.Equal(t, "request", tags["tag"])
+				assert.Equal(t, sr("HTTPBIN_URL/post?a=1"), tags["url"])
+			}
+		}
+	})
+
+	t.Run("url template", func(t *testing.T) {
+		metrics.GetBufferedSamples(ts.samples)
+		_, err := rt.RunString("client.get(http.url`/status/${200}`);")
+		require.NoError(t, err)
+
+		for _, container := range metrics.GetBufferedSamples(ts.samples) {
+			for _, sample := range container.GetSamples() {
+				assert.Equal(t, sr("HTTPBIN_URL/status/${}"), sample.Tags.Map()["name"])
+			}
+		}
+	})
+
+	t.Run("absolute url", func(t *testing.T) {
+		_, err := rt.RunString(sr(`
+		var res = client.get("HTTPSBIN_URL/get");
+		if (res.status != 200) { throw new Error("wrong status: " + res.status) }
+		if (res.url != "HTTPSBIN_URL/get") { throw new Error("wrong url: " + res.url) }
+		`))
+		require.NoError(t, err)
+	})
+
+	t.Run("batch and asyncRequest", func(t *testing.T) {
+		_, err := rt.RunString(`
+		var responses = client.batch([["GET", "get"], ["GET", "headers"]]);
+		responses.forEach(function(res) {
+			if (res.json().headers["X-Client"] != "client") { throw new Error("no client headers") }
+		});
+		if (!(client.asyncRequest("GET", "get") instanceof Promise)) { throw new Error("not a promise") }
+		`)
+		require.NoError(t, err)
+	})
+
+	t.Run("responseCallback", func(t *testing.T) {
+		metrics.GetBufferedSamples(ts.samples)
+		_, err := rt.RunString(sr(`
+		var client404 = new http.Client({ responseCallback: http.expectedStatuses(404) });
+		var res = client404.get("HTTPBIN_URL/status/404");
+		if (res.status != 404) { throw new Error("wrong status: " + res.status) }
+		`))
+		require.NoError(t, err)
+
+		bufSamples := metrics.GetBufferedSamples(ts.samples)
+		for _, container := range bufSamples {
+			for _, sample := range container.GetSamples() {
+				if sample.Metric.Name == metrics.HTTPReqFailedName {
+					assert.Equal(t, 0.0, sample.Value)
+				}
+			}
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := rt.RunString(`new http.Client({ baseURL: "/relative" });`)
+		require.ErrorContains(t, err, "invalid http.Client options: baseURL should be an absolute URL, but is '/relative'")
+
+		_, err = rt.RunString(`new http.Client({ tlsAuth: [{ cert: "cert", key: "key" }] });`)
+		require.ErrorContains(t, err, "invalid http.Client options: invalid tlsAuth")
+
+		_, err = rt.RunString(`new http.Client({ responseCallback: 200 });`)
+		require.ErrorContains(t, err, "unsupported argument, expected http.expectedStatuses")
+	})
+}
+
+func TestClientTLSAuth(t *testing.T) {
+	t.Parallel()
+	ts := newTestCase(t)
+	rt := ts.runtime.VU.Runtime()
+	state := ts.runtime.VU.State()
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, r.TLS.PeerCertificates[0].Subject.Organization[0])
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert} //nolint:gosec
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	state.TLSConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec
+
+	cert, key := generateClientCertificate(t)
+	_, err := rt.RunString(fmt.Sprintf(`
+	var client = new http.Client({ tlsAuth: [{ cert: %q, key: %q }] });
+	var res = client.get(%q);
+	if (res.status != 200) { throw new Error("wrong status: " + res.status) }
+	if (res.body != "Acme Co") { throw new Error("wrong client certificate: " + res.body) }
+	`, cert, key, srv.URL))
+	require.NoError(t, err)
+
+	_, err = rt.RunString(fmt.Sprintf(`http.get(%q);`, srv.URL))
+	require.ErrorContains(t, err, "tls: certificate required")
+}
+
+// generateClientCertificate returns a PEM-encoded self-signed client
+// certificate and its key.
+func generateClientCertificate(t *testing.T) (string, string) {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{Organization: []string{"Acme Co"}},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	require.NoError(t, err)
+	privBytes, err := x509.MarshalPKCS8PrivateKey(priv)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: derBytes})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privBytes}))
+}
@@ -16,7 +16,10 @@ import (
 //
 // TODO: add sync.Once for all of the deprecation warnings we might want to do
 // for the old k6/http APIs here, so they are shown only once in a test run.
-type RootModule struct{}
+type RootModule struct {
+	// The OAuth2 tokens that are shared by all VUs.
+	sharedOAuth2Tokens *httpext.OAuth2Tokens
+}
 
 // ModuleInstance represents an instance of the HTTP module for every VU.
 type ModuleInstance struct {
@@ -24,6 +27,11 @@ type ModuleInstance struct {
 	rootModule    *RootModule
 	defaultClient *Client
 	exports       *sobek.Object
+
+	// The connection pools of the requests with connection params.
+	connectionPools *httpext.ConnectionPools
+	// The OAuth2 tokens of the VU.
+	oauth2Tokens *httpext.OAuth2Tokens
 }
 
 var (
@@ -33,16 +41,18 @@ var (
 
 // New returns a pointer to a new HTTP RootModule.
 func New() *RootModule {
-	return &RootModule{}
+	return &RootModule{sharedOAuth2Tokens: httpext.NewOAuth2Tokens()}
 }
 
 // NewModuleInstance returns an HTTP module instance for each VU.
 func (r *RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
 	rt := vu.Runtime()
 	mi := &ModuleInstance{
-		vu:         vu,
-		rootModule: r,
-		exports:    rt.NewObject(),
+		vu:              vu,
+		rootModule:      r,
+		exports:         rt.NewObject(),
+		connectionPools: httpext.NewConnectionPools(),
+		oauth2Tokens:    httpext.NewOAuth2Tokens(),
 	}
 	mi.defineConstants()
 
@@ -68,18 +78,8 @@ func (r *RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
 	// TODO: refactor so the Client actually has better APIs and these are
 	// wrappers (facades) that convert the old k6 idiosyncratic APIs to the new
 	// proper Client ones that accept Request objects and don't suck
-	mustExport("get", func(url sobek.Value, args ...sobek.Value) (*Response, error) {
-		// http.get(url, params) doesn't have a body argument, so we add undefined
-		// as the third argument to http.request(method, url, body, params)
-		args = append([]sobek.Value{sobek.Undefined()}, args...)
-		return mi.defaultClient.Request(http.MethodGet, url, args...)
-	})
-	mustExport("head", func(url sobek.Value, args ...sobek.Value) (*Response, error) {
-		// http.head(url, params) doesn't have a body argument, so we add undefined
-		// as the third argument to http.request(method, url, body, params)
-		args = append([]sobek.Value{sobek.Undefined()}, args...)
-		return mi.defaultClient.Request(http.MethodHead, url, args...)
-	})
+	mustExport("get", mi.defaultClient.getNoBodyMethodClosure(http.MethodGet))
+	mustExport("head", mi.defaultClient.getNoBodyMethodClosure(http.MethodHead))
 	mustExport("post", mi.defaultClient.getMethodClosure(http.MethodPost))
 	mustExport("put", mi.defaultClient.getMethodClosure(http.MethodPut))
 	mustExport("patch", mi.defaultClient.getMethodClosure(http.MethodPatch))
@@ -91,10 +91,11 @@ func (r *RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
 	mustExport("setResponseCallback", mi.defaultClient.SetResponseCallback)
 
 	mustExport("expectedStatuses", mi.expectedStatuses) // TODO: refactor?
+	mustExport("Client", mi.newClient)
 
 	// TODO: actually expose the default client as k6/http.defaultClient when we
-	// have a better HTTP API (e.g. proper Client constructor, an actual Request
-	// object, custom Transport implementations you can pass the Client, etc.).
+	// have a better HTTP API (e.g. an actual Request object, custom Transport
+	// implementations you can pass the Client, etc.).
 	// This will allow us to find solutions to many of the issues with the
 	// current HTTP API that plague us:
 	// https://github.com/grafana/k6/issues?q=is%3Aopen+is%3Aissue+label%3Anew-http
@@ -173,11 +174,3 @@ func (mi *ModuleInstance) URL(parts []string, pieces ...string) (httpext.URL, er
 	}
 	return httpext.NewURL(urlstr, name)
 }
-
-// Client represents a stand-alone HTTP client.
-//
-// TODO: move to its own file
-type Client struct {
-	moduleInstance   *ModuleInstance
-	responseCallback func(int) bool
-}
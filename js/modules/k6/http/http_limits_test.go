@@ -0,0 +1,135 @@
+package http
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+)
+
+func TestRequestHTTPLimits(t *testing.T) {
+	t.Parallel()
+	ts := newTestCase(t)
+	tb := ts.tb
+	rt := ts.runtime.VU.Runtime()
+	state := ts.runtime.VU.State()
+	sr := tb.Replacer.Replace
+
+	var (
+		mx                      sync.Mutex
+		inFlight, maxInFlight   int
+		requestTimes            []time.Time
+		resetInFlightStatistics = func() {
+			mx.Lock()
+			defer mx.Unlock()
+			maxInFlight = 0
+			requestTimes = nil
+		}
+	)
+	tb.Mux.HandleFunc("/limited", func(w http.ResponseWriter, _ *http.Request) {
+		mx.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		requestTimes = append(requestTimes, time.Now())
+		mx.Unlock()
+
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+
+		mx.Lock()
+		inFlight--
+		mx.Unlock()
+	})
+
+	getQueuedSamples := func() []float64 {
+		var queued []float64
+		for _, container := range metrics.GetBufferedSamples(ts.samples) {
+			for _, sample := range container.GetSamples() {
+				if sample.Metric.Name == metrics.HTTPReqQueuedName {
+					queued = append(queued, sample.Value)
+				}
+			}
+		}
+		return queued
+	}
+
+	t.Run("concurrency", func(t *testing.T) {
+		state.HTTPLimiter = lib.NewHTTPLimiter(lib.HTTPLimits{
+			sr("HTTPBIN_DOMAIN"): {Concurrency: null.IntFrom(1)},
+		}, nil, state.Logger)
+		resetInFlightStatistics()
+		metrics.GetBufferedSamples(ts.samples)
+
+		_, err := rt.RunString(sr(`
+		var responses = http.batch([
+			"HTTPBIN_URL/limited", "HTTPBIN_URL/limited", "HTTPBIN_URL/limited",
+		]);
+		responses.forEach(function(res) {
+			if (res.status != 200) { throw new Error("wrong status: " + res.status) }
+		});
+		`))
+		require.NoError(t, err)
+
+		mx.Lock()
+		assert.Equal(t, 1, maxInFlight)
+		mx.Unlock()
+		queued := getQueuedSamples()
+		require.Len(t, queued, 3)
+		var total float64
+		for _, q := range queued {
+			total += q
+		}
+		// The second request waited for the first one, the third one for both
+		assert.GreaterOrEqual(t, total, 150.0)
+	})
+
+	t.Run("unlimited host", func(t *testing.T) {
+		resetInFlightStatistics()
+		metrics.GetBufferedSamples(ts.samples)
+
+		_, err := rt.RunString(sr(`
+		http.batch(["HTTPBIN_IP_URL/limited", "HTTPBIN_IP_URL/limited", "HTTPBIN_IP_URL/limited"]);
+		`))
+		require.NoError(t, err)
+
+		mx.Lock()
+		assert.Equal(t, 3, maxInFlight)
+		mx.Unlock()
+		assert.Empty(t, getQueuedSamples())
+	})
+
+	t.Run("rps", func(t *testing.T) {
+		state.HTTPLimiter = lib.NewHTTPLimiter(lib.HTTPLimits{
+			lib.HTTPLimitsAllHosts: {RPS: null.FloatFrom(10)},
+		}, nil, state.Logger)
+		resetInFlightStatistics()
+		metrics.GetBufferedSamples(ts.samples)
+
+		_, err := rt.RunString(sr(`
+		http.batch(["HTTPBIN_URL/limited", "HTTPBIN_IP_URL/limited", "HTTPBIN_URL/limited"]);
+		`))
+		require.NoError(t, err)
+
+		mx.Lock()
+		require.Len(t, requestTimes, 3)
+		first, last := requestTimes[0], requestTimes[0]
+		for _, reqTime := range requestTimes {
+			if reqTime.Before(first) {
+				first = reqTime
+			}
+			if reqTime.After(last) {
+				last = reqTime
+			}
+		}
+		mx.Unlock()
+		assert.GreaterOrEqual(t, last.Sub(first), 190*time.Millisecond)
+		assert.Len(t, getQueuedSamples(), 3)
+	})
+}
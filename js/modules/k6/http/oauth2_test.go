@@ -0,0 +1,194 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/metrics"
+)
+
+func TestRequestOAuth2(t *testing.T) {
+	t.Parallel()
+	ts := newTestCase(t)
+	tb := ts.tb
+	rt := ts.runtime.VU.Runtime()
+	sr := tb.Replacer.Replace
+
+	var (
+		mx     sync.Mutex
+		grants []string
+	)
+	tb.Mux.HandleFunc("/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if id != "k6" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = fmt.Fprint(w, `{"error":"invalid_client"}`)
+			return
+		}
+		mx.Lock()
+		grants = append(grants, r.PostFormValue("grant_type")+" "+r.PostFormValue("refresh_token"))
+		count := len(grants)
+		mx.Unlock()
+		if delay, err := time.ParseDuration(r.URL.Query().Get("delay")); err == nil {
+			time.Sleep(delay)
+		}
+		expiresIn := r.URL.Query().Get("expires_in")
+		_, _ = fmt.Fprintf(w,
+			`{"access_token":"token-%d","token_type":"Bearer","expires_in":%s,"refresh_token":"refresh-%d"}`,
+			count, expiresIn, count)
+	})
+	tb.Mux.HandleFunc("/oauth2/protected", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, r.Header.Get("Authorization"))
+	})
+	getGrants := func() []string {
+		mx.Lock()
+		defer mx.Unlock()
+		result := grants
+		grants = nil
+		return result
+	}
+
+	t.Run("cached", func(t *testing.T) {
+		metrics.GetBufferedSamples(ts.samples)
+		_, err := rt.RunString(sr(`
+		var params = {
+			auth: "oauth2",
+			oauth2: { tokenURL: "HTTPBIN_URL/oauth2/token?expires_in=3600", clientID: "k6", clientSecret: "secret" },
+		};
+		for (var i = 0; i < 2; i++) {
+			var res = http.get("HTTPBIN_URL/oauth2/protected", params);
+			if (res.body != "Bearer token-1") { throw new Error("wrong authorization: " + res.body) }
+		}
+		`))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"client_credentials "}, getGrants())
+
+		requests := make(map[string][]string)
+		for _, container := range metrics.GetBufferedSamples(ts.samples) {
+			for _, sample := range container.GetSamples() {
+				if sample.Metric.Name == metrics.HTTPReqsName || sample.Metric.Name == metrics.OAuth2TokenReqsName {
+					url, _ := sample.Tags.Get("url")
+					requests[sample.Metric.Name] = append(requests[sample.Metric.Name], url)
+				}
+			}
+		}
+		// The token request isn't in the http_req_* metrics
+		assert.Equal(t, map[string][]string{
+			metrics.HTTPReqsName:        {sr("HTTPBIN_URL/oauth2/protected"), sr("HTTPBIN_URL/oauth2/protected")},
+			metrics.OAuth2TokenReqsName: {sr("HTTPBIN_URL/oauth2/token?expires_in=3600")},
+		}, requests)
+	})
+
+	t.Run("thresholds", func(t *testing.T) {
+		metrics.GetBufferedSamples(ts.samples)
+		_, err := rt.RunString(sr(`
+		http.get("HTTPBIN_URL/oauth2/protected", {
+			auth: "oauth2",
+			oauth2: {
+				tokenURL: "HTTPBIN_URL/oauth2/token?expires_in=3600&delay=300ms", clientID: "k6", clientSecret: "secret",
+			},
+		});
+		`))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"client_credentials "}, getGrants())
+
+		sinks := map[string]metrics.Sink{
+			metrics.HTTPReqDurationName:     metrics.NewTrendSink(),
+			metrics.OAuth2TokenDurationName: metrics.NewTrendSink(),
+		}
+		for _, container := range metrics.GetBufferedSamples(ts.samples) {
+			for _, sample := range container.GetSamples() {
+				if sink, ok := sinks[sample.Metric.Name]; ok {
+					sink.Add(sample)
+				}
+			}
+		}
+
+		// The slow token request doesn't fail the threshold of the requests
+		// that use the token
+		for metricName, passes := range map[string]bool{
+			metrics.HTTPReqDurationName:     true,
+			metrics.OAuth2TokenDurationName: false,
+		} {
+			thresholds := metrics.NewThresholds([]string{"max<300"})
+			require.NoError(t, thresholds.Parse())
+			passed, err := thresholds.Run(sinks[metricName], time.Second)
+			require.NoError(t, err)
+			assert.Equal(t, passes, passed, metricName)
+		}
+	})
+
+	t.Run("refreshed", func(t *testing.T) {
+		_, err := rt.RunString(sr(`
+		var params = {
+			auth: "oauth2",
+			oauth2: { tokenURL: "HTTPBIN_URL/oauth2/token?expires_in=0.2", clientID: "k6", clientSecret: "secret" },
+		};
+		var res = http.get("HTTPBIN_URL/oauth2/protected", params);
+		if (res.body != "Bearer token-1") { throw new Error("wrong authorization: " + res.body) }
+		`))
+		require.NoError(t, err)
+
+		time.Sleep(200 * time.Millisecond)
+		_, err = rt.RunString(sr(`
+		var res = http.get("HTTPBIN_URL/oauth2/protected", params);
+		if (res.body != "Bearer token-2") { throw new Error("wrong authorization: " + res.body) }
+		`))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"client_credentials ", "refresh_token refresh-1"}, getGrants())
+	})
+
+	t.Run("shared", func(t *testing.T) {
+		_, err := rt.RunString(sr(`
+		var oauth2 = {
+			tokenURL: "HTTPBIN_URL/oauth2/token?expires_in=3600",
+			grantType: "password",
+			clientID: "k6",
+			clientSecret: "secret",
+			username: "user",
+			password: "pass",
+			shared: true,
+		};
+		var client = new http.Client({ auth: "oauth2", oauth2: oauth2 });
+		var res = client.get("HTTPBIN_URL/oauth2/protected");
+		if (res.body != "Bearer token-1") { throw new Error("wrong authorization: " + res.body) }
+		`))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"password "}, getGrants())
+
+		// The token of the VU isn't the shared one
+		_, err = rt.RunString(sr(`
+		oauth2.shared = false;
+		var res = http.get("HTTPBIN_URL/oauth2/protected", { auth: "oauth2", oauth2: oauth2 });
+		if (res.body != "Bearer token-1") { throw new Error("wrong authorization: " + res.body) }
+		res = client.get("HTTPBIN_URL/oauth2/protected");
+		if (res.body != "Bearer token-1") { throw new Error("wrong authorization: " + res.body) }
+		`))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"password "}, getGrants())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := rt.RunString(sr(`http.get("HTTPBIN_URL/oauth2/protected", { auth: "oauth2" });`))
+		require.ErrorContains(t, err, "the oauth2 auth needs the oauth2 param")
+
+		_, err = rt.RunString(sr(`
+		http.get("HTTPBIN_URL/oauth2/protected", {
+			auth: "oauth2", oauth2: { tokenURL: "HTTPBIN_URL/oauth2/token", clientID: "k6", grantType: "implicit" },
+		});`))
+		require.ErrorContains(t, err, "invalid oauth2 value: unsupported grantType 'implicit'")
+
+		_, err = rt.RunString(sr(`
+		http.get("HTTPBIN_URL/oauth2/protected", {
+			auth: "oauth2", oauth2: { tokenURL: "HTTPBIN_URL/oauth2/token", clientID: "k6", clientSecret: "wrong" },
+		});`))
+		require.ErrorContains(t, err,
+			`couldn't get the OAuth2 token, the token endpoint responded with status 401: {"error":"invalid_client"}`)
+	})
+}
@@ -2,6 +2,7 @@ package http
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"mime/multipart"
@@ -16,6 +17,7 @@ import (
 	"gopkg.in/guregu/null.v3"
 
 	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/lib"
 	"go.k6.io/k6/lib/netext/httpext"
 	"go.k6.io/k6/lib/types"
 )
@@ -145,7 +147,6 @@ func (c *Client) responseFromHTTPext(resp *httpext.Response) *Response {
 func (c *Client) parseRequest(
 	method string, reqURL, body interface{}, params sobek.Value,
 ) (*httpext.ParsedHTTPRequest, error) {
-	rt := c.moduleInstance.vu.Runtime()
 	state := c.moduleInstance.vu.State()
 	if state == nil {
 		return nil, ErrHTTPForbiddenInInitContext
@@ -158,6 +159,11 @@ func (c *Client) parseRequest(
 	if err != nil {
 		return nil, err
 	}
+	if c.baseURL != nil && !u.GetURL().IsAbs() {
+		if u, err = c.resolveURL(u); err != nil {
+			return nil, err
+		}
+	}
 
 	result := &httpext.ParsedHTTPRequest{
 		URL: &u,
@@ -283,6 +289,58 @@ func (c *Client) parseRequest(
 		result.ActiveJar = state.CookieJar
 	}
 
+	// The http3 param of the client or the request overrides the option
+	connParams := httpext.ConnectionParams{HTTP3: state.Options.HTTP3.Bool}
+
+	// The params of the client are the defaults of the ones of the request
+	for _, params := range []sobek.Value{c.params, params} {
+		if err = c.parseParams(params, result, &connParams); err != nil {
+			return nil, err
+		}
+	}
+	// HTTP/3 is only used for https:// URLs, there's no QUIC without TLS
+	if result.URL.GetURL().Scheme != "https" {
+		connParams.HTTP3 = false
+	}
+
+	if result.Auth == "oauth2" {
+		if result.OAuth2 == nil {
+			return nil, errors.New("the oauth2 auth needs the oauth2 param, with the config of the token endpoint")
+		}
+		result.OAuth2Tokens = c.moduleInstance.oauth2Tokens
+		if result.OAuth2.Shared {
+			result.OAuth2Tokens = c.moduleInstance.rootModule.sharedOAuth2Tokens
+		}
+	}
+
+	if c.tlsAuth != nil {
+		if connParams.TLSConfig, err = c.getTLSConfig(state); err != nil {
+			return nil, err
+		}
+	}
+	if !connParams.IsZero() {
+		result.Transport, err = c.moduleInstance.connectionPools.Get(state, connParams)
+		if err != nil {
+			return nil, fmt.Errorf("invalid connection params: %w", err)
+		}
+	}
+
+	if result.ActiveJar != nil {
+		httpext.SetRequestCookies(result.Req, result.ActiveJar, result.Cookies)
+	}
+
+	return result, nil
+}
+
+// parseParams applies the request params to the parsed request, and collects
+// the connection params of the request.
+//
+//nolint:gocyclo, cyclop, funlen, gocognit
+func (c *Client) parseParams(
+	params sobek.Value, result *httpext.ParsedHTTPRequest, connParams *httpext.ConnectionParams,
+) error {
+	rt := c.moduleInstance.vu.Runtime()
+
 	// TODO: ditch sobek.Value, reflections and Object and use a simple go map and type assertions?
 	//nolint: nestif
 	if params != nil && !sobek.IsUndefined(params) && !sobek.IsNull(params) {
@@ -355,7 +413,7 @@ func (c *Client) parseRequest(
 					algo = strings.TrimSpace(algo)
 					result.Compressions[index], err = httpext.CompressionTypeString(algo)
 					if err != nil {
-						return nil, fmt.Errorf("unknown compression algorithm %s, supported algorithms are %s",
+						return fmt.Errorf("unknown compression algorithm %s, supported algorithms are %s",
 							algo, httpext.CompressionTypeValues())
 					}
 				}
@@ -363,14 +421,20 @@ func (c *Client) parseRequest(
 				result.Redirects = null.IntFrom(params.Get(k).ToInteger())
 			case "tags":
 				if err := common.ApplyCustomUserTags(rt, &result.TagsAndMeta, params.Get(k)); err != nil {
-					return nil, fmt.Errorf("invalid HTTP request metric tags: %w", err)
+					return fmt.Errorf("invalid HTTP request metric tags: %w", err)
 				}
 			case "auth":
 				result.Auth = params.Get(k).String()
+			case "oauth2":
+				config, err := parseOAuth2Config(params.Get(k))
+				if err != nil {
+					return fmt.Errorf("invalid oauth2 value: %w", err)
+				}
+				result.OAuth2 = config
 			case "timeout":
 				t, err := types.GetDurationValue(params.Get(k).Export())
 				if err != nil {
-					return nil, fmt.Errorf("invalid timeout value: %w", err)
+					return fmt.Errorf("invalid timeout value: %w", err)
 				}
 				result.Timeout = t
 			case "throw":
@@ -378,7 +442,7 @@ func (c *Client) parseRequest(
 			case "responseType":
 				responseType, err := httpext.ResponseTypeString(params.Get(k).String())
 				if err != nil {
-					return nil, err
+					return err
 				}
 				result.ResponseType = responseType
 			case "responseCallback":
@@ -388,17 +452,45 @@ func (c *Client) parseRequest(
 				} else if c, ok := v.(*expectedStatuses); ok {
 					result.ResponseCallback = c.match
 				} else {
-					return nil, fmt.Errorf("unsupported responseCallback")
+					return fmt.Errorf("unsupported responseCallback")
+				}
+			case "connectionPool":
+				connParams.Pool = params.Get(k).String()
+			case "maxConnsPerHost":
+				connParams.MaxConnsPerHost = int(params.Get(k).ToInteger())
+			case "forceHTTP1":
+				connParams.ForceHTTP1 = params.Get(k).ToBoolean()
+			case "forceHTTP2":
+				connParams.ForceHTTP2 = params.Get(k).ToBoolean()
+			case "http3":
+				connParams.HTTP3 = params.Get(k).ToBoolean()
+			case "idleTimeout":
+				t, err := types.GetDurationValue(params.Get(k).Export())
+				if err != nil {
+					return fmt.Errorf("invalid idleTimeout value: %w", err)
 				}
+				connParams.IdleTimeout = t
 			}
 		}
 	}
 
-	if result.ActiveJar != nil {
-		httpext.SetRequestCookies(result.Req, result.ActiveJar, result.Cookies)
-	}
+	return nil
+}
 
-	return result, nil
+// parseOAuth2Config parses the config of the token endpoint of the oauth2 auth.
+func parseOAuth2Config(v sobek.Value) (*httpext.OAuth2Config, error) {
+	data, err := json.Marshal(v.Export())
+	if err != nil {
+		return nil, err
+	}
+	config := &httpext.OAuth2Config{}
+	if err = lib.StrictJSONUnmarshal(data, config); err != nil {
+		return nil, err
+	}
+	if err = config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
 }
 
 func (c *Client) prepareBatchArray(requests []interface{}) (
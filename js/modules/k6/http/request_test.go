@@ -23,6 +23,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -34,6 +35,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
 	"gopkg.in/guregu/null.v3"
 
 	"go.k6.io/k6/js/modulestest"
@@ -1282,6 +1284,287 @@ func TestRequest(t *testing.T) {
 	})
 }
 
+func TestRequestConnectionParams(t *testing.T) {
+	t.Parallel()
+	ts := newTestCase(t)
+	tb := ts.tb
+	rt := ts.runtime.VU.Runtime()
+	sr := tb.Replacer.Replace
+
+	t.Run("forceHTTP1", func(t *testing.T) {
+		_, err := rt.RunString(sr(`
+		var res = http.get("HTTP2BIN_URL/get", { forceHTTP1: true });
+		if (res.status != 200) { throw new Error("wrong status: " + res.status) }
+		if (res.proto != "HTTP/1.1") { throw new Error("wrong proto: " + res.proto) }
+		`))
+		require.NoError(t, err)
+	})
+
+	// The new connections of the requests are reported in http_req_connecting
+	getConnecting := func() []float64 {
+		var connecting []float64
+		for _, container := range metrics.GetBufferedSamples(ts.samples) {
+			for _, sample := range container.GetSamples() {
+				if sample.Metric.Name == metrics.HTTPReqConnectingName {
+					connecting = append(connecting, sample.Value)
+				}
+			}
+		}
+		return connecting
+	}
+
+	t.Run("forceHTTP2", func(t *testing.T) {
+		metrics.GetBufferedSamples(ts.samples)
+		_, err := rt.RunString(sr(`
+		var res = http.get("HTTP2BIN_URL/get", { forceHTTP2: true });
+		if (res.status != 200) { throw new Error("wrong status: " + res.status) }
+		if (res.proto != "HTTP/2.0") { throw new Error("wrong proto: " + res.proto) }
+		if (res.timings.tls_handshaking <= 0) { throw new Error("no TLS handshake: " + res.timings.tls_handshaking) }
+		`))
+		require.NoError(t, err)
+		connecting := getConnecting()
+		require.Len(t, connecting, 1)
+		assert.Greater(t, connecting[0], 0.0)
+
+		_, err = rt.RunString(sr(`http.get("HTTPSBIN_URL/get", { forceHTTP2: true });`))
+		require.ErrorContains(t, err, "tls: no application protocol")
+	})
+
+	t.Run("h2c", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = listener.Close() })
+		h2cSrv := &http2.Server{}
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				go h2cSrv.ServeConn(conn, &http2.ServeConnOpts{Handler: tb.Mux})
+			}
+		}()
+
+		metrics.GetBufferedSamples(ts.samples)
+		_, err = rt.RunString(fmt.Sprintf(`
+		var res = http.get("http://%s/get", { forceHTTP2: true });
+		if (res.status != 200) { throw new Error("wrong status: " + res.status) }
+		if (res.proto != "HTTP/2.0") { throw new Error("wrong proto: " + res.proto) }
+		`, listener.Addr()))
+		require.NoError(t, err)
+		connecting := getConnecting()
+		require.Len(t, connecting, 1)
+		assert.Greater(t, connecting[0], 0.0)
+	})
+
+	t.Run("maxConnsPerHost", func(t *testing.T) {
+		var newConns atomic.Int64
+		srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			time.Sleep(50 * time.Millisecond)
+			w.WriteHeader(http.StatusOK)
+		}))
+		srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+			if state == http.StateNew {
+				newConns.Add(1)
+			}
+		}
+		srv.Start()
+		t.Cleanup(srv.Close)
+
+		_, err := rt.RunString(fmt.Sprintf(`
+		var params = { connectionPool: "single", maxConnsPerHost: 1 };
+		var responses = http.batch([
+			["GET", "%[1]s", null, params],
+			["GET", "%[1]s", null, params],
+			["GET", "%[1]s", null, params],
+			["GET", "%[1]s", null, params],
+		]);
+		responses.forEach(function(res) {
+			if (res.status != 200) { throw new Error("wrong status: " + res.status) }
+		});
+		`, srv.URL))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), newConns.Load())
+	})
+
+	t.Run("named pool", func(t *testing.T) {
+		_, err := rt.RunString(sr(`
+		http.get("HTTPBIN_URL/get", { connectionPool: "browser", idleTimeout: "10s" });
+		var res = http.get("HTTPBIN_URL/get", { connectionPool: "browser", idleTimeout: "10s" });
+		if (res.timings.connecting != 0) { throw new Error("the connection wasn't reused") }
+		`))
+		require.NoError(t, err)
+
+		_, err = rt.RunString(sr(`http.get("HTTPBIN_URL/get", { connectionPool: "browser", forceHTTP1: true });`))
+		require.ErrorContains(t, err, `the connection pool "browser" was already created with different params`)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := rt.RunString(sr(`http.get("HTTPBIN_URL/get", { forceHTTP1: true, forceHTTP2: true });`))
+		require.ErrorContains(t, err, "invalid connection params: forceHTTP1 and forceHTTP2 can't be used together")
+
+		_, err = rt.RunString(sr(`http.get("HTTPBIN_URL/get", { idleTimeout: "-1s" });`))
+		require.ErrorContains(t, err, "invalid connection params: idleTimeout should be positive")
+	})
+}
+
+func TestRequestHTTP3(t *testing.T) {
+	t.Parallel()
+
+	newHTTP3TestCase := func(t *testing.T) *httpTestCase {
+		ts := newTestCase(t)
+		ts.runtime.VU.State().Dialer = ts.tb.Dialer
+		return ts
+	}
+	getProtos := func(samples chan metrics.SampleContainer) []string {
+		var protos []string
+		for _, container := range metrics.GetBufferedSamples(samples) {
+			for _, sample := range container.GetSamples() {
+				if sample.Metric.Name == metrics.HTTPReqsName {
+					proto, _ := sample.Tags.Get("proto")
+					protos = append(protos, proto)
+				}
+			}
+		}
+		return protos
+	}
+
+	t.Run("param", func(t *testing.T) {
+		t.Parallel()
+		ts := newHTTP3TestCase(t)
+		sr := ts.tb.Replacer.Replace
+
+		_, err := ts.runtime.VU.Runtime().RunString(sr(`
+		var res = http.get("HTTP3BIN_URL/get", { http3: true });
+		if (res.status != 200) { throw new Error("wrong status: " + res.status) }
+		if (res.proto != "HTTP/3.0") { throw new Error("wrong proto: " + res.proto) }
+		if (res.timings.tls_handshaking <= 0) { throw new Error("no handshake: " + res.timings.tls_handshaking) }
+		if (res.timings.connecting != 0) { throw new Error("wrong connecting: " + res.timings.connecting) }
+
+		res = http.post("HTTP3BIN_URL/post", "body", { http3: true });
+		if (res.status != 200) { throw new Error("wrong status: " + res.status) }
+		if (res.json().data != "body") { throw new Error("wrong body: " + res.json().data) }
+		if (res.timings.tls_handshaking != 0) { throw new Error("the connection wasn't reused") }
+		if (res.timings.connecting != 0) { throw new Error("wrong connecting: " + res.timings.connecting) }
+		if (res.timings.waiting <= 0) { throw new Error("wrong waiting: " + res.timings.waiting) }
+		`))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"HTTP/3.0", "HTTP/3.0"}, getProtos(ts.samples))
+		assert.Greater(t, atomic.LoadInt64(&ts.tb.Dialer.BytesWritten), int64(0))
+		assert.Greater(t, atomic.LoadInt64(&ts.tb.Dialer.BytesRead), int64(0))
+	})
+
+	t.Run("option", func(t *testing.T) {
+		t.Parallel()
+		ts := newHTTP3TestCase(t)
+		ts.runtime.VU.State().Options.HTTP3 = null.BoolFrom(true)
+		sr := ts.tb.Replacer.Replace
+
+		_, err := ts.runtime.VU.Runtime().RunString(sr(`
+		var res = http.get("HTTP3BIN_URL/get");
+		if (res.proto != "HTTP/3.0") { throw new Error("wrong proto: " + res.proto) }
+		res = http.get("HTTPSBIN_URL/get", { http3: false });
+		if (res.proto != "HTTP/1.1") { throw new Error("wrong proto: " + res.proto) }
+		res = http.get("HTTPBIN_URL/get");
+		if (res.proto != "HTTP/1.1") { throw new Error("wrong proto: " + res.proto) }
+		`))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"HTTP/3.0", "HTTP/1.1", "HTTP/1.1"}, getProtos(ts.samples))
+	})
+
+	t.Run("noConnectionReuse", func(t *testing.T) {
+		t.Parallel()
+		ts := newHTTP3TestCase(t)
+		transport := ts.tb.HTTPTransport.Clone()
+		transport.DisableKeepAlives = true
+		ts.runtime.VU.State().Transport = transport
+		sr := ts.tb.Replacer.Replace
+
+		_, err := ts.runtime.VU.Runtime().RunString(sr(`
+		for (var i = 0; i < 2; i++) {
+			var res = http.get("HTTP3BIN_URL/get", { http3: true });
+			if (res.proto != "HTTP/3.0") { throw new Error("wrong proto: " + res.proto) }
+			if (res.timings.tls_handshaking <= 0) { throw new Error("the connection was reused") }
+		}
+		`))
+		require.NoError(t, err)
+	})
+
+	t.Run("noVUConnectionReuse", func(t *testing.T) {
+		t.Parallel()
+		ts := newHTTP3TestCase(t)
+		state := ts.runtime.VU.State()
+		state.Options.NoVUConnectionReuse = null.BoolFrom(true)
+		sr := ts.tb.Replacer.Replace
+
+		// The connection is reused only in the same iteration
+		var newConns []bool
+		for _, iteration := range []int64{0, 0, 1} {
+			state.Iteration = iteration
+			v, err := ts.runtime.VU.Runtime().RunString(sr(`
+			http.get("HTTP3BIN_URL/get", { http3: true }).timings.tls_handshaking > 0;
+			`))
+			require.NoError(t, err)
+			newConns = append(newConns, v.ToBoolean())
+		}
+		assert.Equal(t, []bool{true, false, true}, newConns)
+	})
+
+	t.Run("no fallback", func(t *testing.T) {
+		t.Parallel()
+		ts := newHTTP3TestCase(t)
+		sr := ts.tb.Replacer.Replace
+
+		// The https server doesn't listen on the UDP port with the same number
+		_, err := ts.runtime.VU.Runtime().RunString(sr(`
+		http.get("HTTPSBIN_URL/get", { http3: true, timeout: "500ms" });
+		`))
+		require.ErrorContains(t, err, "request timeout")
+	})
+
+	t.Run("tlsVersion", func(t *testing.T) {
+		t.Parallel()
+		ts := newHTTP3TestCase(t)
+		transport := ts.tb.HTTPTransport.Clone()
+		transport.TLSClientConfig.MaxVersion = tls.VersionTLS12
+		ts.runtime.VU.State().Transport = transport
+		sr := ts.tb.Replacer.Replace
+
+		_, err := ts.runtime.VU.Runtime().RunString(sr(`http.get("HTTP3BIN_URL/get", { http3: true });`))
+		require.ErrorContains(t, err, "http3 needs TLS 1.3, but the tlsVersion option allows at most tls1.2")
+	})
+
+	t.Run("hosts and blacklistIPs", func(t *testing.T) {
+		t.Parallel()
+		ts := newHTTP3TestCase(t)
+		sr := ts.tb.Replacer.Replace
+
+		// The domain of the server is only resolved with the hosts of the dialer
+		_, err := ts.runtime.VU.Runtime().RunString(sr(`
+		var res = http.get("https://HTTP3BIN_DOMAIN:HTTP3BIN_PORT/get", { http3: true });
+		if (res.proto != "HTTP/3.0") { throw new Error("wrong proto: " + res.proto) }
+		`))
+		require.NoError(t, err)
+
+		ipNet, err := lib.ParseCIDR(ts.tb.Replacer.Replace("HTTP3BIN_IP/32"))
+		require.NoError(t, err)
+		ts.tb.Dialer.Blacklist = []*lib.IPNet{ipNet}
+		_, err = ts.runtime.VU.Runtime().RunString(sr(`
+		http.get("HTTP3BIN_URL/get", { http3: true, connectionPool: "new" });
+		`))
+		require.ErrorContains(t, err, sr("IP (HTTP3BIN_IP) is in a blacklisted range"))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		ts := newHTTP3TestCase(t)
+		sr := ts.tb.Replacer.Replace
+
+		_, err := ts.runtime.VU.Runtime().RunString(sr(`http.get("HTTP3BIN_URL/get", { http3: true, forceHTTP2: true });`))
+		require.ErrorContains(t, err, "invalid connection params: http3 can't be used with forceHTTP1 or forceHTTP2")
+	})
+}
+
 func TestRequestCancellation(t *testing.T) {
 	t.Parallel()
 	ts := newTestCase(t)
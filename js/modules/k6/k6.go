@@ -2,6 +2,7 @@
 package k6
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/rand"
@@ -13,6 +14,7 @@ import (
 	"go.k6.io/k6/js/common"
 	"go.k6.io/k6/js/modules"
 	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
 	"go.k6.io/k6/metrics"
 )
 
@@ -72,15 +74,52 @@ func (*K6) Fail(msg string) (sobek.Value, error) {
 	return sobek.Undefined(), errors.New(msg)
 }
 
-// Sleep waits the provided seconds before continuing the execution.
-func (mi *K6) Sleep(secs float64) {
+// Sleep waits the provided seconds before continuing the execution. They can
+// also be a distribution of think times, like {normal: {mean: 3, stddev: 1}},
+// drawn with Math.random(), so they can be seeded with randomSeed().
+func (mi *K6) Sleep(val sobek.Value) error {
+	var d time.Duration
+	if obj, ok := val.(*sobek.Object); ok {
+		thinkTime, err := parseThinkTime(obj)
+		if err != nil {
+			return err
+		}
+		rt := mi.vu.Runtime()
+		if d, err = thinkTime.Draw(func() (float64, error) { return common.MathRandom(rt) }); err != nil {
+			return fmt.Errorf("couldn't draw the sleep() time: %w", err)
+		}
+	} else {
+		d = time.Duration(val.ToFloat() * float64(time.Second))
+	}
+
 	ctx := mi.vu.Context()
-	timer := time.NewTimer(time.Duration(secs * float64(time.Second)))
+	startTime := time.Now()
+	timer := time.NewTimer(d)
 	select {
 	case <-timer.C:
 	case <-ctx.Done():
 		timer.Stop()
 	}
+	if state := mi.vu.State(); state != nil {
+		state.IterationSleep += time.Since(startTime)
+	}
+	return nil
+}
+
+// parseThinkTime parses the distribution of the think times of sleep().
+func parseThinkTime(obj *sobek.Object) (types.ThinkTime, error) {
+	var thinkTime types.ThinkTime
+	data, err := json.Marshal(obj.Export())
+	if err != nil {
+		return thinkTime, fmt.Errorf("invalid sleep() distribution: %w", err)
+	}
+	if err = json.Unmarshal(data, &thinkTime); err == nil {
+		err = thinkTime.Validate()
+	}
+	if err != nil {
+		return thinkTime, fmt.Errorf("invalid sleep() distribution %s: %w", data, err)
+	}
+	return thinkTime, nil
 }
 
 // RandomSeed sets the seed to the random generator used for this VU.
@@ -199,6 +238,7 @@ func (mi *K6) Check(arg0, checks sobek.Value, extras ...sobek.Value) (bool, erro
 		if !booleanVal {
 			// A single failure makes the return value false.
 			succ = false
+			state.IterationFailedCheck = true
 		}
 
 		sample := metrics.Sample{
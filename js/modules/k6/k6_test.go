@@ -63,6 +63,48 @@ func TestSleep(t *testing.T) {
 		assert.True(t, d > 500*time.Millisecond, "did not sleep long enough")
 		assert.True(t, d < 2*time.Second, "slept for too long!!")
 	})
+
+	t.Run("Distribution", func(t *testing.T) {
+		t.Parallel()
+
+		tc := testCaseRuntime(t)
+		_, err := tc.testRuntime.RunOnEventLoop(`
+			k6.sleep({uniform: [0.1, 0.2]});
+			k6.sleep({normal: {mean: 0.1, stddev: 0.01}});
+			k6.sleep({exponential: 0.01});
+		`)
+		require.NoError(t, err)
+		slept := tc.testRuntime.VU.State().IterationSleep
+		assert.GreaterOrEqual(t, slept, 150*time.Millisecond)
+		assert.Less(t, slept, time.Second)
+	})
+
+	t.Run("Seeded", func(t *testing.T) {
+		t.Parallel()
+
+		tc := testCaseRuntime(t)
+		_, err := tc.testRuntime.RunOnEventLoop(`
+			k6.randomSeed(12345);
+			k6.sleep({uniform: [0, 0.1]});
+			var next = Math.random();
+			k6.randomSeed(12345);
+			Math.random();
+			if (Math.random() != next) { throw new Error("sleep() didn't use Math.random()"); }
+		`)
+		require.NoError(t, err)
+		// The first random number of the seed is 0.8487305991992138
+		assert.InDelta(t, 84873*time.Microsecond, tc.testRuntime.VU.State().IterationSleep, float64(20*time.Millisecond))
+	})
+
+	t.Run("InvalidDistribution", func(t *testing.T) {
+		t.Parallel()
+
+		tc := testCaseRuntime(t)
+		_, err := tc.testRuntime.RunOnEventLoop(`k6.sleep({uniform: [2, 1]})`)
+		assert.ErrorContains(t, err, `invalid sleep() distribution {"uniform":[2,1]}: the uniform think time must be a range`)
+		_, err = tc.testRuntime.RunOnEventLoop(`k6.sleep({poisson: 1})`)
+		assert.ErrorContains(t, err, `invalid sleep() distribution {"poisson":1}`)
+	})
 }
 
 func TestRandSeed(t *testing.T) {
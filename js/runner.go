@@ -3,10 +3,13 @@ package js
 import (
 	"context"
 	"crypto/tls"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"io"
+	"math/rand"
 	"net"
 	"net/http"
 	"net/http/cookiejar"
@@ -35,8 +38,11 @@ import (
 	"go.k6.io/k6/metrics"
 )
 
-// Ensure Runner implements the lib.Runner interface
-var _ lib.Runner = &Runner{}
+// Ensure Runner implements the lib.Runner and lib.FileLoader interfaces
+var (
+	_ lib.Runner     = &Runner{}
+	_ lib.FileLoader = &Runner{}
+)
 
 // TODO: https://github.com/grafana/k6/issues/2186
 // An advanced TLS support should cover the rid of the warning
@@ -54,11 +60,15 @@ type Runner struct {
 	// TODO: Remove ActualResolver, it's a hack to simplify mocking in tests.
 	ActualResolver netext.MultiResolver
 	RPSLimit       *rate.Limiter
+	HTTPLimiter    *lib.HTTPLimiter
 	RunTags        *metrics.TagSet
 
 	console    *console
 	setupData  []byte
 	BufferPool *lib.BufferPool
+
+	scenarioSetupData   map[string][]byte
+	scenarioSetupDataMx sync.RWMutex
 }
 
 // New returns a new Runner for the provided source
@@ -94,8 +104,9 @@ func NewFromBundle(piState *lib.TestPreInitState, b *Bundle) (*Runner, error) {
 		console: newConsole(piState.Logger),
 		Resolver: netext.NewResolver(
 			net.LookupIP, 0, defDNS.Select.DNSSelect, defDNS.Policy.DNSPolicy),
-		ActualResolver: net.LookupIP,
-		BufferPool:     lib.NewBufferPool(),
+		ActualResolver:    net.LookupIP,
+		BufferPool:        lib.NewBufferPool(),
+		scenarioSetupData: make(map[string][]byte),
 	}
 
 	err := r.SetOptions(r.Bundle.Options)
@@ -225,6 +236,7 @@ func (r *Runner) newVU(
 		BufferPool:     r.BufferPool,
 		Samples:        samplesOut,
 		scenarioIter:   make(map[string]uint64),
+		execRands:      make(map[string]*rand.Rand),
 	}
 
 	vu.state = &lib.State{
@@ -235,6 +247,7 @@ func (r *Runner) newVU(
 		TLSConfig:      vu.TLSConfig,
 		CookieJar:      cookieJar,
 		RPSLimit:       vu.Runner.RPSLimit,
+		HTTPLimiter:    vu.Runner.HTTPLimiter,
 		BufferPool:     vu.BufferPool,
 		VUID:           vu.ID,
 		VUIDGlobal:     vu.IDGlobal,
@@ -280,25 +293,38 @@ func (r *Runner) Setup(ctx context.Context, out chan<- metrics.SampleContainer)
 	}
 	r.preInitState.Logger.Debugf("Running %s()...", consts.SetupFn)
 
-	setupCtx, setupCancel := context.WithTimeout(ctx, r.getTimeoutFor(consts.SetupFn))
-	defer setupCancel()
-
-	v, err := r.runPart(setupCtx, out, consts.SetupFn, nil)
+	timeout := r.getTimeoutFor(consts.SetupFn)
+	data, err := r.runSetup(ctx, out, consts.SetupFn, timeout, newTimeoutError(consts.SetupFn, timeout))
 	if err != nil {
 		return err
 	}
 	// r.setupData = nil is special it means undefined from this moment forward
+	r.setupData = data
+	return nil
+}
+
+// runSetup runs the given setup function and returns the JSON representation
+// of the value it returns, which is nil if it's undefined.
+func (r *Runner) runSetup(
+	ctx context.Context, out chan<- metrics.SampleContainer, name string, timeout time.Duration, timeoutErr error,
+) ([]byte, error) {
+	setupCtx, setupCancel := context.WithTimeout(ctx, timeout)
+	defer setupCancel()
+
+	v, err := r.runPart(setupCtx, out, name, nil, timeoutErr)
+	if err != nil {
+		return nil, err
+	}
 	if sobek.IsUndefined(v) {
-		r.setupData = nil
-		return nil
+		return nil, nil //nolint:nilnil // nil is special, it means undefined
 	}
 
-	r.setupData, err = json.Marshal(v.Export())
+	data, err := json.Marshal(v.Export())
 	if err != nil {
-		return fmt.Errorf("error marshaling setup() data to JSON: %w", err)
+		return nil, fmt.Errorf("error marshaling %s() data to JSON: %w", name, err)
 	}
 	var tmp interface{}
-	return json.Unmarshal(r.setupData, &tmp)
+	return data, json.Unmarshal(data, &tmp)
 }
 
 // GetSetupData returns the setup data as json if Setup() was specified and executed, nil otherwise
@@ -321,21 +347,109 @@ func (r *Runner) Teardown(ctx context.Context, out chan<- metrics.SampleContaine
 	}
 	r.preInitState.Logger.Debugf("Running %s()...", consts.TeardownFn)
 
-	teardownCtx, teardownCancel := context.WithTimeout(ctx, r.getTimeoutFor(consts.TeardownFn))
+	timeout := r.getTimeoutFor(consts.TeardownFn)
+	return r.runTeardown(ctx, out, consts.TeardownFn, timeout, r.setupData, newTimeoutError(consts.TeardownFn, timeout))
+}
+
+// runTeardown runs the given teardown function with the given setup data.
+func (r *Runner) runTeardown(
+	ctx context.Context, out chan<- metrics.SampleContainer, name string, timeout time.Duration,
+	setupData []byte, timeoutErr error,
+) error {
+	teardownCtx, teardownCancel := context.WithTimeout(ctx, timeout)
 	defer teardownCancel()
 
 	var data interface{}
-	if r.setupData != nil {
-		if err := json.Unmarshal(r.setupData, &data); err != nil {
-			return fmt.Errorf("error unmarshaling setup data for teardown() from JSON: %w", err)
+	if setupData != nil {
+		if err := json.Unmarshal(setupData, &data); err != nil {
+			return fmt.Errorf("error unmarshaling setup data for %s() from JSON: %w", name, err)
 		}
 	} else {
 		data = sobek.Undefined()
 	}
-	_, err := r.runPart(teardownCtx, out, consts.TeardownFn, data)
+	_, err := r.runPart(teardownCtx, out, name, data, timeoutErr)
 	return err
 }
 
+// getScenarioFn returns the setup or the teardown function of the given
+// scenario, if it has one, and its timeout.
+func (r *Runner) getScenarioFn(scenario, stage string) (string, time.Duration, error) {
+	config, ok := r.Bundle.Options.Scenarios[scenario]
+	if !ok {
+		return "", 0, fmt.Errorf("there's no scenario with the name %q", scenario)
+	}
+	getFn := config.GetSetup
+	if stage == consts.TeardownFn {
+		getFn = config.GetTeardown
+	}
+	name, timeout := getFn()
+	if !timeout.Valid {
+		return name, r.getTimeoutFor(stage), nil
+	}
+	return name, timeout.TimeDuration(), nil
+}
+
+// ScenarioSetup runs the setup function of the given scenario, if it has one,
+// and saves the value it returns as the setup data of the scenario.
+func (r *Runner) ScenarioSetup(ctx context.Context, scenario string, out chan<- metrics.SampleContainer) error {
+	name, timeout, err := r.getScenarioFn(scenario, consts.SetupFn)
+	if err != nil || name == "" {
+		return err
+	}
+	r.preInitState.Logger.Debugf("Running %s() of scenario %s...", name, scenario)
+
+	data, err := r.runSetup(ctx, out, name, timeout, newScenarioTimeoutError(consts.SetupFn, name, scenario, timeout))
+	if err != nil {
+		return err
+	}
+	r.SetScenarioSetupData(scenario, data)
+	return nil
+}
+
+// GetScenarioSetupData returns the setup data of the given scenario as json,
+// if its setup function was specified and executed, nil otherwise.
+func (r *Runner) GetScenarioSetupData(scenario string) []byte {
+	r.scenarioSetupDataMx.RLock()
+	defer r.scenarioSetupDataMx.RUnlock()
+	return r.scenarioSetupData[scenario]
+}
+
+// SetScenarioSetupData saves the externally supplied setup data of the given
+// scenario as json in the runner, so it can be used in the VUs of the scenario.
+func (r *Runner) SetScenarioSetupData(scenario string, data []byte) {
+	r.scenarioSetupDataMx.Lock()
+	defer r.scenarioSetupDataMx.Unlock()
+	r.scenarioSetupData[scenario] = data
+}
+
+// hasScenarioSetup returns whether the given scenario has its own setup
+// function, whose data is used instead of the one of the global setup().
+func (r *Runner) hasScenarioSetup(scenario string) bool {
+	config, ok := r.Bundle.Options.Scenarios[scenario]
+	if !ok {
+		return false
+	}
+	name, _ := config.GetSetup()
+	return name != ""
+}
+
+// ScenarioTeardown runs the teardown function of the given scenario, if it has
+// one, with the setup data of the scenario.
+func (r *Runner) ScenarioTeardown(ctx context.Context, scenario string, out chan<- metrics.SampleContainer) error {
+	name, timeout, err := r.getScenarioFn(scenario, consts.TeardownFn)
+	if err != nil || name == "" {
+		return err
+	}
+	r.preInitState.Logger.Debugf("Running %s() of scenario %s...", name, scenario)
+
+	setupData := r.setupData
+	if r.hasScenarioSetup(scenario) {
+		setupData = r.GetScenarioSetupData(scenario)
+	}
+	return r.runTeardown(ctx, out, name, timeout, setupData,
+		newScenarioTimeoutError(consts.TeardownFn, name, scenario, timeout))
+}
+
 // GetOptions returns the currently calculated [lib.Options] for the given Runner.
 func (r *Runner) GetOptions() lib.Options {
 	return r.Bundle.Options
@@ -406,7 +520,8 @@ func (r *Runner) HandleSummary(ctx context.Context, summary *lib.Summary) (map[s
 	}
 	rawResult, _, _, err := vu.runFn(summaryCtx, false, handleSummaryWrapper, nil, wrapperArgs...)
 
-	if deadlineError := r.checkDeadline(summaryCtx, consts.HandleSummaryFn, rawResult, err); deadlineError != nil {
+	timeoutErr := newTimeoutError(consts.HandleSummaryFn, r.getTimeoutFor(consts.HandleSummaryFn))
+	if deadlineError := r.checkDeadline(summaryCtx, rawResult, err, timeoutErr); deadlineError != nil {
 		return nil, deadlineError
 	}
 
@@ -416,7 +531,7 @@ func (r *Runner) HandleSummary(ctx context.Context, summary *lib.Summary) (map[s
 	return getSummaryResult(rawResult)
 }
 
-func (r *Runner) checkDeadline(ctx context.Context, name string, result sobek.Value, err error) error {
+func (r *Runner) checkDeadline(ctx context.Context, result sobek.Value, err, timeoutErr error) error {
 	if deadline, ok := ctx.Deadline(); !(ok && time.Now().After(deadline)) {
 		return nil
 	}
@@ -429,7 +544,7 @@ func (r *Runner) checkDeadline(ctx context.Context, name string, result sobek.Va
 		return err
 	}
 	// otherwise we have timeouted
-	return newTimeoutError(name, r.getTimeoutFor(name))
+	return timeoutErr
 }
 
 // SetOptions sets the test Options to the provided data and makes necessary changes to the Runner.
@@ -439,6 +554,14 @@ func (r *Runner) SetOptions(opts lib.Options) error {
 	if rps := opts.RPS; rps.Valid && rps.Int64 > 0 {
 		r.RPSLimit = rate.NewLimiter(rate.Limit(rps.Int64), 1)
 	}
+	r.HTTPLimiter = nil
+	if len(opts.HTTPLimits) > 0 {
+		et, err := lib.NewExecutionTuple(opts.ExecutionSegment, opts.ExecutionSegmentSequence)
+		if err != nil {
+			return err
+		}
+		r.HTTPLimiter = lib.NewHTTPLimiter(opts.HTTPLimits, et, r.preInitState.Logger)
+	}
 
 	// TODO: validate that all exec values are either nil or valid exported methods (or HTTP requests in the future)
 
@@ -476,9 +599,39 @@ func (r *Runner) SetOptions(opts lib.Options) error {
 	// FIXME: add tests
 	r.RunTags = r.preInitState.Registry.RootTagSet().WithTagsFromMap(r.Bundle.Options.RunTags)
 
+	// Load the data files of the scenarios now, so they are in the archive
+	for _, config := range opts.Scenarios {
+		withFiles, ok := config.(lib.ExecutorConfigWithFiles)
+		if !ok {
+			continue
+		}
+		for _, name := range withFiles.GetFiles() {
+			if _, err := r.LoadFile(name); err != nil {
+				return fmt.Errorf("scenario %s: %w", config.GetName(), err)
+			}
+		}
+	}
+
 	return nil
 }
 
+// LoadFile implements lib.FileLoader. The files are read from the same
+// filesystem as the ones opened with open(), so they are part of the archive
+// too, but unlike them, they can be loaded after the init context.
+func (r *Runner) LoadFile(name string) ([]byte, error) {
+	fs := r.Bundle.filesystems["file"]
+	if cachedFs, ok := fs.(*fsext.CacheOnReadFs); ok {
+		// bypass the check for the files opened during the initialization
+		fs = cachedFs.Fs
+	}
+	filename := fsext.Abs(r.Bundle.pwd.Path, name)
+	data, err := fsext.ReadFile(fs, filename)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't load the file %q: %w", filename, err)
+	}
+	return data, nil
+}
+
 func (r *Runner) setResolver(dns types.DNSConfig) error {
 	ttl, err := parseTTL(dns.TTL.String)
 	if err != nil {
@@ -521,12 +674,14 @@ func parseTTL(ttlS string) (time.Duration, error) {
 }
 
 // Runs an exported function in its own temporary VU, optionally with an argument. Execution is
-// interrupted if the context expires. No error is returned if the part does not exist.
+// interrupted if the context expires, in which case timeoutErr is returned. No error is returned
+// if the part does not exist.
 func (r *Runner) runPart(
 	parentCtx context.Context,
 	out chan<- metrics.SampleContainer,
 	name string,
 	arg interface{},
+	timeoutErr error,
 ) (sobek.Value, error) {
 	ctx, cancel := context.WithCancel(parentCtx)
 	defer cancel()
@@ -558,7 +713,7 @@ func (r *Runner) runPart(
 	}
 	v, _, _, err := vu.runFn(ctx, false, fn, nil, vu.Runtime.ToValue(arg))
 
-	if deadlineError := r.checkDeadline(ctx, name, v, err); deadlineError != nil {
+	if deadlineError := r.checkDeadline(ctx, v, err, timeoutErr); deadlineError != nil {
 		return nil, deadlineError
 	}
 
@@ -616,18 +771,31 @@ type VU struct {
 	Samples chan<- metrics.SampleContainer
 
 	setupData sobek.Value
+	// the setup data of the scenarios with their own setup functions
+	scenarioSetupData map[string]sobek.Value
 
 	state *lib.State
 	// count of iterations executed by this VU in each scenario
 	scenarioIter map[string]uint64
+	// the attempt of the current iteration, more than 1 for the retries of a
+	// failed one, which are the same iteration as its first attempt
+	attempt int64
+	// pick the functions of the traffic mixes, by scenario, they aren't
+	// Math.random() so the script can't change the mix by overriding or
+	// seeding it
+	execRands map[string]*rand.Rand
 }
 
 // Verify that interfaces are implemented
 var (
 	_ lib.ActiveVU      = &ActiveVU{}
+	_ lib.RetryableVU   = &ActiveVU{}
 	_ lib.InitializedVU = &VU{}
 )
 
+// attemptTagName is the tag of the metrics of the retries of an iteration.
+const attemptTagName = "attempt"
+
 // ActiveVU holds a VU and its activation parameters
 type ActiveVU struct {
 	*VU
@@ -637,6 +805,10 @@ type ActiveVU struct {
 	scenarioName              string
 	getNextIterationCounters  func() (uint64, uint64)
 	scIterLocal, scIterGlobal uint64
+
+	// The start of the first iteration, the ones after it start at the
+	// multiples of the pacing since then
+	pacingStart time.Time
 }
 
 // GetID returns the unique VU ID.
@@ -648,7 +820,7 @@ func (u *VU) GetID() uint64 {
 func (u *VU) Activate(params *lib.VUActivationParams) lib.ActiveVU {
 	u.Runtime.ClearInterrupt()
 
-	if params.Exec == "" {
+	if params.Exec == "" && len(params.ExecWeights) == 0 {
 		params.Exec = consts.DefaultFn
 	}
 
@@ -705,6 +877,7 @@ func (u *VU) Activate(params *lib.VUActivationParams) lib.ActiveVU {
 	u.state.GetScenarioGlobalVUIter = func() uint64 {
 		return avu.scIterGlobal
 	}
+	u.state.GetScenarioIterationData = params.GetIterationData
 
 	go func() {
 		// Wait for the run context to be over
@@ -723,6 +896,47 @@ func (u *VU) Activate(params *lib.VUActivationParams) lib.ActiveVU {
 	return avu
 }
 
+// getSetupData returns the setup data for the iterations of the scenario, which
+// is the one of its own setup function, if it has one. It's unmarshalled only the
+// first time for each VU so that VUs are isolated but we still don't use too much
+// CPU in the middle test.
+func (u *ActiveVU) getSetupData() (sobek.Value, error) {
+	if !u.Runner.hasScenarioSetup(u.scenarioName) {
+		if u.setupData == nil {
+			data, err := u.unmarshalSetupData(u.Runner.setupData)
+			if err != nil {
+				return nil, err
+			}
+			u.setupData = data
+		}
+		return u.setupData, nil
+	}
+
+	if data, ok := u.scenarioSetupData[u.scenarioName]; ok {
+		return data, nil
+	}
+	data, err := u.unmarshalSetupData(u.Runner.GetScenarioSetupData(u.scenarioName))
+	if err != nil {
+		return nil, err
+	}
+	if u.scenarioSetupData == nil {
+		u.scenarioSetupData = make(map[string]sobek.Value)
+	}
+	u.scenarioSetupData[u.scenarioName] = data
+	return data, nil
+}
+
+func (u *ActiveVU) unmarshalSetupData(setupData []byte) (sobek.Value, error) {
+	if setupData == nil {
+		return sobek.Undefined(), nil
+	}
+	var data interface{}
+	if err := json.Unmarshal(setupData, &data); err != nil {
+		return nil, fmt.Errorf("error unmarshaling setup data for the iteration from JSON: %w", err)
+	}
+	return u.Runtime.ToValue(data), nil
+}
+
 // RunOnce runs the configured Exec function once.
 func (u *ActiveVU) RunOnce() error {
 	select {
@@ -735,27 +949,27 @@ func (u *ActiveVU) RunOnce() error {
 		<-u.busy // unlock deactivation again
 	}()
 
-	// Unmarshall the setupData only the first time for each VU so that VUs are isolated but we
-	// still don't use too much CPU in the middle test
-	if u.setupData == nil {
-		if u.Runner.setupData != nil {
-			var data interface{}
-			if err := json.Unmarshal(u.Runner.setupData, &data); err != nil {
-				return fmt.Errorf("error unmarshaling setup data for the iteration from JSON: %w", err)
-			}
-			u.setupData = u.Runtime.ToValue(data)
-		} else {
-			u.setupData = sobek.Undefined()
-		}
+	setupData, err := u.getSetupData()
+	if err != nil {
+		return err
 	}
 
-	fn := u.getCallableExport(u.Exec)
+	execFn := u.Exec
+	if len(u.ExecWeights) > 0 {
+		execFn = u.pickExec()
+		u.state.Tags.Modify(func(tagsAndMeta *metrics.TagsAndMeta) {
+			tagsAndMeta.SetSystemTagOrMetaIfEnabled(u.Runner.Bundle.Options.SystemTags, metrics.TagExec, execFn)
+		})
+	}
+	fn := u.getCallableExport(execFn)
 	if fn == nil {
 		// Shouldn't happen; this is validated in cmd.validateScenarioConfig()
-		panic(fmt.Sprintf("function '%s' not found in exports", u.Exec))
+		panic(fmt.Sprintf("function '%s' not found in exports", execFn))
 	}
 
-	u.incrIteration()
+	if u.attempt <= 1 {
+		u.incrIteration()
+	}
 	if err := u.Runtime.Set("__ITER", u.iteration); err != nil {
 		panic(fmt.Errorf("error setting __ITER in Sobek runtime: %w", err))
 	}
@@ -772,8 +986,12 @@ func (u *ActiveVU) RunOnce() error {
 
 	u.emitAndWaitEvent(&event.Event{Type: event.IterStart, Data: eventIterData})
 
+	if u.Pacing > 0 && u.pacingStart.IsZero() {
+		u.pacingStart = time.Now()
+	}
+
 	// Call the exported function.
-	_, isFullIteration, totalTime, err := u.runFn(ctx, true, fn, cancel, u.setupData)
+	_, isFullIteration, totalTime, err := u.runFn(ctx, true, fn, cancel, setupData)
 	if err != nil {
 		var x *sobek.InterruptedError
 		if errors.As(err, &x) {
@@ -799,9 +1017,133 @@ func (u *ActiveVU) RunOnce() error {
 		}
 	}
 
+	if isFullIteration {
+		if thinkErr := u.think(); thinkErr != nil && err == nil {
+			err = thinkErr
+		}
+		u.pace()
+	}
+
 	return err
 }
 
+// RunAttempt runs the iteration once, as the given attempt of it. The retries
+// don't start a new iteration, they keep its number and aren't counted in the
+// iterations metric, and their metrics are tagged with their attempt number.
+func (u *ActiveVU) RunAttempt(attempt int64) (bool, error) {
+	u.attempt = attempt
+	defer func() { u.attempt = 0 }()
+	if attempt > 1 {
+		u.state.Tags.Modify(func(tagsAndMeta *metrics.TagsAndMeta) {
+			tagsAndMeta.SetTag(attemptTagName, strconv.FormatInt(attempt, 10))
+		})
+		defer u.state.Tags.Modify(func(tagsAndMeta *metrics.TagsAndMeta) {
+			tagsAndMeta.DeleteTag(attemptTagName)
+		})
+	}
+	err := u.RunOnce()
+	return u.state.IterationFailedCheck, err
+}
+
+// think waits for the think time of the scenario after the iteration, if it
+// has one, and emits the think_time of the iteration, which also includes the
+// time it spent in sleep().
+func (u *ActiveVU) think() error {
+	thinkTime := u.state.IterationSleep
+	if u.ThinkTime != nil {
+		d, err := u.ThinkTime.Draw(func() (float64, error) { return common.MathRandom(u.Runtime) })
+		if err != nil {
+			return fmt.Errorf("couldn't draw the think time of the iteration: %w", err)
+		}
+		startTime := time.Now()
+		select {
+		case <-time.After(d):
+		case <-u.getRegularDurationContext().Done():
+		}
+		thinkTime += time.Since(startTime)
+	}
+	if thinkTime <= 0 {
+		return nil
+	}
+
+	ctm := u.state.Tags.GetCurrentValues()
+	metrics.PushIfNotDone(u.RunContext, u.state.Samples, metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: u.Runner.preInitState.BuiltinMetrics.ThinkTime,
+			Tags:   ctm.Tags,
+		},
+		Time:     time.Now(),
+		Metadata: ctm.Metadata,
+		Value:    metrics.D(thinkTime),
+	})
+	return nil
+}
+
+// pace waits until the start of the next iteration of the VU, if the scenario
+// has a pacing. The iterations start at multiples of the pacing since the
+// first one, so the slots of the iterations that would start late are skipped.
+// Like the think time, it isn't waited for after the regular duration is over.
+func (u *ActiveVU) pace() {
+	if u.Pacing <= 0 {
+		return
+	}
+	elapsed := time.Since(u.pacingStart)
+	wait := u.Pacing - elapsed%u.Pacing
+	select {
+	case <-time.After(wait):
+	case <-u.getRegularDurationContext().Done():
+	}
+}
+
+// getRegularDurationContext returns the context that is done when the executor
+// doesn't start new iterations anymore, so the VU doesn't keep waiting for the
+// next one during the graceful stop.
+func (u *ActiveVU) getRegularDurationContext() context.Context {
+	if u.RegularDurationContext != nil {
+		return u.RegularDurationContext
+	}
+	return u.RunContext
+}
+
+// getExecRand returns the generator of the traffic mix picks of the scenario.
+// With the randomSeed option, it's seeded with it mixed with the global VU ID
+// and the scenario name, so every VU and scenario has different picks, which
+// are the same in every test run.
+func (u *ActiveVU) getExecRand() *rand.Rand {
+	if execRand, ok := u.execRands[u.scenarioName]; ok {
+		return execRand
+	}
+	var execRand *rand.Rand
+	if randomSeed := u.Runner.Bundle.Options.RandomSeed; randomSeed.Valid {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(u.scenarioName))
+		_ = binary.Write(h, binary.LittleEndian, u.IDGlobal)
+		execRand = rand.New(rand.NewSource(randomSeed.Int64 ^ int64(h.Sum64()))) //nolint:gosec
+	} else {
+		execRand = common.NewRand()
+	}
+	u.execRands[u.scenarioName] = execRand
+	return execRand
+}
+
+// pickExec picks the function of the traffic mix of the scenario that the
+// iteration runs, at random by the weights of the functions.
+func (u *ActiveVU) pickExec() string {
+	var total float64
+	for _, w := range u.ExecWeights {
+		total += w.Weight
+	}
+	target := u.getExecRand().Float64() * total
+	for _, w := range u.ExecWeights {
+		if target < w.Weight {
+			return w.Fn
+		}
+		target -= w.Weight
+	}
+	// Only because of the rounding errors
+	return u.ExecWeights[len(u.ExecWeights)-1].Fn
+}
+
 func (u *ActiveVU) emitAndWaitEvent(evt *event.Event) {
 	waitDone := u.moduleVUImpl.events.local.Emit(evt)
 	waitCtx, waitCancel := context.WithTimeout(u.RunContext, 30*time.Minute)
@@ -828,6 +1170,8 @@ func (u *VU) runFn(
 	}
 
 	opts := &u.Runner.Bundle.Options
+	u.state.IterationSleep = 0
+	u.state.IterationFailedCheck = false
 
 	if opts.SystemTags.Has(metrics.TagIter) {
 		u.state.Tags.Modify(func(tagsAndMeta *metrics.TagsAndMeta) {
@@ -871,7 +1215,15 @@ func (u *VU) runFn(
 	u.state.Samples <- u.Dialer.IOSamples(endTime, ctm, builtinMetrics)
 
 	if isFullIteration && isDefault {
-		u.state.Samples <- iterationSamples(startTime, endTime, ctm, builtinMetrics)
+		// The time the iteration spent in sleep() is emitted as its think_time
+		duration := endTime.Sub(startTime) - u.state.IterationSleep
+		samples := iterationSamples(duration, endTime, ctm, builtinMetrics)
+		if u.attempt > 1 {
+			// The iteration was counted with its first attempt, so a retry
+			// only has its iteration_duration
+			samples = samples[:1]
+		}
+		u.state.Samples <- samples
 	}
 
 	v = unPromisify(v)
@@ -880,7 +1232,7 @@ func (u *VU) runFn(
 }
 
 func iterationSamples(
-	startTime, endTime time.Time, ctm metrics.TagsAndMeta, builtinMetrics *metrics.BuiltinMetrics,
+	duration time.Duration, endTime time.Time, ctm metrics.TagsAndMeta, builtinMetrics *metrics.BuiltinMetrics,
 ) metrics.Samples {
 	return metrics.Samples([]metrics.Sample{
 		{
@@ -890,7 +1242,7 @@ func iterationSamples(
 			},
 			Time:     endTime,
 			Metadata: ctm.Metadata,
-			Value:    metrics.D(endTime.Sub(startTime)),
+			Value:    metrics.D(duration),
 		},
 		{
 			TimeSeries: metrics.TimeSeries{
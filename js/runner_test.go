@@ -8,6 +8,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/json"
 	"encoding/pem"
 	"fmt"
 	"go/build"
@@ -1906,6 +1907,64 @@ func TestArchiveNotPanicking(t *testing.T) {
 	require.Nil(t, r2)
 }
 
+func TestArchiveScenarioFiles(t *testing.T) {
+	t.Parallel()
+
+	baseFS := fsext.NewMemMapFs()
+	data := `
+			var exec = require("k6/execution");
+			exports.options = {
+				scenarios: {
+					replay: { executor: "replay-arrival", file: "./logs/access.csv", preAllocatedVUs: 1 },
+				},
+			};
+			exports.default = function() {
+				if (exec.scenario.record.path !== "/a") {
+					throw new Error("unexpected record " + JSON.stringify(exec.scenario.record));
+				}
+			}
+		`
+	require.NoError(t, fsext.WriteFile(baseFS, "/home/somebody/logs/access.csv", []byte("timestamp,path\n1,/a\n"), fs.ModePerm))
+	require.NoError(t, fsext.WriteFile(baseFS, "/home/somebody/script.js", []byte(data), fs.ModePerm))
+	fileSystem := fsext.NewCacheOnReadFs(baseFS, fsext.NewMemMapFs(), 0)
+	_, err := fsext.ReadFile(fileSystem, "/home/somebody/script.js") // like when the test is loaded
+	require.NoError(t, err)
+
+	checkRunner := func(r *Runner) {
+		loaded, err := r.LoadFile("logs/access.csv")
+		require.NoError(t, err)
+		assert.Equal(t, "timestamp,path\n1,/a\n", string(loaded))
+		_, err = r.LoadFile("logs/missing.csv")
+		require.Error(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		initVU, err := r.NewVU(ctx, 1, 1, make(chan metrics.SampleContainer, 100))
+		require.NoError(t, err)
+		vu := initVU.Activate(&lib.VUActivationParams{
+			RunContext:       ctx,
+			Scenario:         "replay",
+			GetIterationData: func() interface{} { return map[string]interface{}{"path": "/a"} },
+		})
+		require.NoError(t, vu.RunOnce())
+	}
+
+	r1, err := getSimpleRunner(t, "/home/somebody/script.js", data, fileSystem)
+	require.NoError(t, err)
+	// the file is loaded when the options are set, even though the script
+	// has been initialized already
+	require.NoError(t, r1.SetOptions(r1.GetOptions()))
+	checkRunner(r1)
+
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, r1.MakeArchive().Write(buf))
+	arc, err := lib.ReadArchive(buf)
+	require.NoError(t, err)
+	r2, err := getSimpleArchiveRunner(t, arc)
+	require.NoError(t, err)
+	checkRunner(r2)
+}
+
 func TestStuffNotPanicking(t *testing.T) {
 	t.Parallel()
 	tb := httpmultibin.NewHTTPMultiBin(t)
@@ -2547,3 +2606,306 @@ func TestArchivingAnArchiveWorks(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, r3)
 }
+
+func TestScenarioSetupData(t *testing.T) {
+	t.Parallel()
+	r, err := getSimpleRunner(t, "/script.js", `
+	exports.options = { setupTimeout: "1s", teardownTimeout: "1s" };
+	exports.setup = function() {
+		return "global";
+	}
+	exports.loginSetup = function() {
+		return {"token": "secret"};
+	}
+	exports.login = function(data) {
+		if (data.token !== "secret") {
+			throw new Error("login: wrong data: " + JSON.stringify(data))
+		}
+	};
+	exports.loginTeardown = function(data) {
+		if (data.token !== "secret") {
+			throw new Error("loginTeardown: wrong data: " + JSON.stringify(data))
+		}
+	};
+	exports.browse = function(data) {
+		if (data !== "global") {
+			throw new Error("browse: wrong data: " + JSON.stringify(data))
+		}
+	};
+	exports.slowSetup = function() {
+		while (true) {}
+	};`)
+	require.NoError(t, err)
+
+	var scenarios lib.ScenarioConfigs
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"login": {"executor": "shared-iterations", "exec": "login", "setup": "loginSetup", "teardown": "loginTeardown"},
+		"browse": {"executor": "shared-iterations", "exec": "browse"},
+		"slow": {"executor": "shared-iterations", "exec": "browse", "setup": "slowSetup", "setupTimeout": "1s"}
+	}`), &scenarios))
+	require.NoError(t, r.SetOptions(r.GetOptions().Apply(lib.Options{Scenarios: scenarios})))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	samples := make(chan metrics.SampleContainer, 100)
+	require.NoError(t, r.Setup(ctx, samples))
+	require.NoError(t, r.ScenarioSetup(ctx, "login", samples))
+	require.NoError(t, r.ScenarioSetup(ctx, "browse", samples)) // it doesn't have a setup function
+	assert.JSONEq(t, `{"token": "secret"}`, string(r.GetScenarioSetupData("login")))
+	assert.Nil(t, r.GetScenarioSetupData("browse"))
+
+	// The same VU runs the iterations of both scenarios, with their own data
+	initVU, err := r.NewVU(ctx, 1, 1, samples)
+	require.NoError(t, err)
+	for _, scenario := range []string{"login", "browse", "login"} {
+		vuCtx, vuCancel := context.WithCancel(ctx)
+		deactivated := make(chan struct{})
+		vu := initVU.Activate(&lib.VUActivationParams{
+			RunContext:         vuCtx,
+			Exec:               scenario,
+			Scenario:           scenario,
+			DeactivateCallback: func(lib.InitializedVU) { close(deactivated) },
+		})
+		require.NoError(t, vu.RunOnce())
+		vuCancel()
+		<-deactivated
+	}
+	require.NoError(t, r.ScenarioTeardown(ctx, "login", samples))
+
+	err = r.ScenarioSetup(ctx, "slow", samples)
+	require.ErrorContains(t, err, "slowSetup() execution of scenario slow timed out after 1 seconds")
+	var hinter errext.HasHint
+	require.ErrorAs(t, err, &hinter)
+	assert.Equal(t, "You can increase the time limit via the setupTimeout option of the scenario slow", hinter.Hint())
+
+	assert.ErrorContains(t, r.ScenarioSetup(ctx, "missing", samples), `there's no scenario with the name "missing"`)
+}
+
+func TestExecTrafficMix(t *testing.T) {
+	t.Parallel()
+	r, err := getSimpleRunner(t, "/script.js", `
+	exports.browse = function() {
+		// The picks don't depend on Math.random(), which the script can change
+		Math.random = function() { return 0; };
+	};
+	exports.search = function() {};
+	exports.checkout = function() {};`)
+	require.NoError(t, err)
+	require.NoError(t, r.SetOptions(r.GetOptions().Apply(lib.Options{
+		SystemTags: metrics.ToSystemTagSet([]string{"exec"}),
+	})))
+
+	weights := []lib.ExecWeight{{Fn: "browse", Weight: 70}, {Fn: "checkout", Weight: 10}, {Fn: "search", Weight: 20}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	samples := make(chan metrics.SampleContainer, 1000)
+	initVU, err := r.NewVU(ctx, 1, 1, samples)
+	require.NoError(t, err)
+	vu := initVU.Activate(&lib.VUActivationParams{RunContext: ctx, Scenario: "mix", ExecWeights: weights})
+
+	for i := 0; i < 200; i++ {
+		require.NoError(t, vu.RunOnce())
+	}
+	close(samples)
+	counts := make(map[string]int)
+	for sampleContainer := range samples {
+		for _, sample := range sampleContainer.GetSamples() {
+			if sample.Metric.Name != metrics.IterationsName {
+				continue
+			}
+			fn, ok := sample.Tags.Get("exec")
+			require.True(t, ok)
+			counts[fn]++
+		}
+	}
+	assert.InDelta(t, 140, counts["browse"], 30)
+	assert.InDelta(t, 40, counts["search"], 20)
+	assert.InDelta(t, 20, counts["checkout"], 15)
+}
+
+func TestExecTrafficMixRandomSeed(t *testing.T) {
+	t.Parallel()
+
+	weights := []lib.ExecWeight{{Fn: "browse", Weight: 50}, {Fn: "search", Weight: 50}}
+	getPicks := func(vuID uint64) []string {
+		r, err := getSimpleRunner(t, "/script.js", `
+		exports.browse = function() {};
+		exports.search = function() {};`)
+		require.NoError(t, err)
+		require.NoError(t, r.SetOptions(r.GetOptions().Apply(lib.Options{RandomSeed: null.IntFrom(42)})))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		initVU, err := r.NewVU(ctx, vuID, vuID, make(chan metrics.SampleContainer, 100))
+		require.NoError(t, err)
+		vu, ok := initVU.Activate(&lib.VUActivationParams{
+			RunContext: ctx, Scenario: "mix", ExecWeights: weights,
+		}).(*ActiveVU)
+		require.True(t, ok)
+
+		picks := make([]string, 50)
+		for i := range picks {
+			picks[i] = vu.pickExec()
+		}
+		return picks
+	}
+
+	// The runners with the same seed pick the same functions for the same VU
+	picks := getPicks(1)
+	assert.Equal(t, picks, getPicks(1))
+	assert.NotEqual(t, picks, getPicks(2))
+}
+
+func TestRandomSeedInInitContext(t *testing.T) {
+	t.Parallel()
+	r, err := getSimpleRunner(t, "/script.js", `
+	var k6 = require("k6");
+	k6.randomSeed(42);
+	exports.default = function() {};`)
+	require.NoError(t, err)
+
+	// The seed of the init context doesn't carry over to the iterations, so
+	// the VUs don't all get the same Math.random() sequence
+	randoms := make([]float64, 2)
+	for i := range randoms {
+		initVU, err := r.NewVU(context.Background(), uint64(i+1), uint64(i+1), make(chan metrics.SampleContainer, 100))
+		require.NoError(t, err)
+		vu, ok := initVU.(*VU)
+		require.True(t, ok)
+		value, err := vu.Runtime.RunString("Math.random()")
+		require.NoError(t, err)
+		randoms[i] = value.ToFloat()
+	}
+	assert.NotEqual(t, randoms[0], randoms[1])
+}
+
+func TestThinkTimeAndPacing(t *testing.T) {
+	t.Parallel()
+	r, err := getSimpleRunner(t, "/script.js", `
+	var k6 = require("k6");
+	exports.default = function() {
+		k6.sleep({uniform: [0.1, 0.1]});
+	};
+	exports.fast = function() {};`)
+	require.NoError(t, err)
+
+	runIterations := func(params *lib.VUActivationParams, iterations int) (map[string][]float64, time.Duration) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		samples := make(chan metrics.SampleContainer, 1000)
+		initVU, err := r.NewVU(ctx, 1, 1, samples)
+		require.NoError(t, err)
+		params.RunContext = ctx
+		vu := initVU.Activate(params)
+
+		startTime := time.Now()
+		for i := 0; i < iterations; i++ {
+			require.NoError(t, vu.RunOnce())
+		}
+		elapsed := time.Since(startTime)
+		close(samples)
+		values := make(map[string][]float64)
+		for sampleContainer := range samples {
+			for _, sample := range sampleContainer.GetSamples() {
+				values[sample.Metric.Name] = append(values[sample.Metric.Name], sample.Value)
+			}
+		}
+		return values, elapsed
+	}
+
+	t.Run("sleep", func(t *testing.T) {
+		t.Parallel()
+		values, _ := runIterations(&lib.VUActivationParams{}, 1)
+		require.Len(t, values[metrics.IterationDurationName], 1)
+		assert.Less(t, values[metrics.IterationDurationName][0], 50.0)
+		require.Len(t, values[metrics.ThinkTimeName], 1)
+		assert.InDelta(t, 100, values[metrics.ThinkTimeName][0], 50)
+	})
+
+	t.Run("thinkTime", func(t *testing.T) {
+		t.Parallel()
+		thinkTime := types.NewThinkTime(0.1)
+		values, elapsed := runIterations(&lib.VUActivationParams{Exec: "fast", ThinkTime: &thinkTime}, 2)
+		assert.GreaterOrEqual(t, elapsed, 200*time.Millisecond)
+		require.Len(t, values[metrics.ThinkTimeName], 2)
+		assert.InDelta(t, 100, values[metrics.ThinkTimeName][0], 50)
+	})
+
+	t.Run("pacing", func(t *testing.T) {
+		t.Parallel()
+		// The iterations take 100ms, so the second one starts at 160ms, since
+		// the slot at 80ms is skipped, and the VU waits until 320ms after it
+		values, elapsed := runIterations(&lib.VUActivationParams{Pacing: 80 * time.Millisecond}, 2)
+		assert.GreaterOrEqual(t, elapsed, 320*time.Millisecond)
+		assert.Less(t, elapsed, 400*time.Millisecond)
+		assert.Len(t, values[metrics.IterationsName], 2)
+	})
+
+	t.Run("pacing after the regular duration", func(t *testing.T) {
+		t.Parallel()
+		// The VU doesn't wait for the next slot once the regular duration of
+		// the executor is over, since it won't start another iteration
+		regDurationCtx, regDurationCancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer regDurationCancel()
+		values, elapsed := runIterations(&lib.VUActivationParams{
+			Pacing: 10 * time.Second, RegularDurationContext: regDurationCtx,
+		}, 1)
+		assert.Less(t, elapsed, time.Second)
+		assert.Len(t, values[metrics.IterationsName], 1)
+	})
+}
+
+func TestRunAttempt(t *testing.T) {
+	t.Parallel()
+	r, err := getSimpleRunner(t, "/script.js", `
+	var k6 = require("k6");
+	var calls = 0;
+	exports.default = function() {
+		calls++;
+		k6.check(calls, { "is even": (c) => c % 2 === 0 });
+		if (__ITER !== (calls > 2 ? 1 : 0)) { throw new Error("wrong __ITER " + __ITER + " in call " + calls); }
+	};`)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	samples := make(chan metrics.SampleContainer, 1000)
+	initVU, err := r.NewVU(ctx, 1, 1, samples)
+	require.NoError(t, err)
+	var iterationCounters int
+	vu, ok := initVU.Activate(&lib.VUActivationParams{
+		RunContext: ctx,
+		GetNextIterationCounters: func() (uint64, uint64) {
+			iterationCounters++
+			return uint64(iterationCounters - 1), uint64(iterationCounters - 1) //nolint:gosec
+		},
+	}).(lib.RetryableVU)
+	require.True(t, ok)
+
+	failedCheck, err := vu.RunAttempt(1)
+	require.NoError(t, err)
+	assert.True(t, failedCheck)
+	// The retry is the same iteration, with the same __ITER
+	failedCheck, err = vu.RunAttempt(2)
+	require.NoError(t, err)
+	assert.False(t, failedCheck)
+	require.NoError(t, vu.RunOnce())
+	assert.Equal(t, 2, iterationCounters)
+
+	// Only the metrics of the retry are tagged with its attempt number, and it
+	// isn't counted as another iteration
+	var iterations, durations []string
+	for _, sampleContainer := range metrics.GetBufferedSamples(samples) {
+		for _, sample := range sampleContainer.GetSamples() {
+			attempt, _ := sample.Tags.Get("attempt")
+			switch sample.Metric.Name {
+			case metrics.IterationsName:
+				iterations = append(iterations, attempt)
+			case metrics.IterationDurationName:
+				durations = append(durations, attempt)
+			}
+		}
+	}
+	assert.Equal(t, []string{"", ""}, iterations)
+	assert.Equal(t, []string{"", "2", ""}, durations)
+}
@@ -13,6 +13,10 @@ import (
 type timeoutError struct {
 	place string
 	d     time.Duration
+
+	// fn and scenario are set when the place is the setup or the teardown
+	// function of a scenario
+	fn, scenario string
 }
 
 var _ interface {
@@ -27,8 +31,17 @@ func newTimeoutError(place string, d time.Duration) timeoutError {
 	return timeoutError{place: place, d: d}
 }
 
+// newScenarioTimeoutError returns a new timeout error, reporting that the given
+// setup or teardown function of a scenario has timed out.
+func newScenarioTimeoutError(place, fn, scenario string, d time.Duration) timeoutError {
+	return timeoutError{place: place, d: d, fn: fn, scenario: scenario}
+}
+
 // String returns the timeout error in human readable format.
 func (t timeoutError) Error() string {
+	if t.scenario != "" {
+		return fmt.Sprintf("%s() execution of scenario %s timed out after %.f seconds", t.fn, t.scenario, t.d.Seconds())
+	}
 	return fmt.Sprintf("%s() execution timed out after %.f seconds", t.place, t.d.Seconds())
 }
 
@@ -42,6 +55,9 @@ func (t timeoutError) Hint() string {
 	case consts.TeardownFn:
 		hint = "You can increase the time limit via the teardownTimeout option"
 	}
+	if hint != "" && t.scenario != "" {
+		hint += " of the scenario " + t.scenario
+	}
 	return hint
 }
 
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"go.k6.io/k6/errext/exitcodes"
 	"go.k6.io/k6/lib/consts"
 )
 
@@ -48,3 +49,17 @@ func TestTimeoutErrorHint(t *testing.T) {
 		}
 	}
 }
+
+func TestScenarioTimeoutError(t *testing.T) {
+	t.Parallel()
+	te := newScenarioTimeoutError(consts.TeardownFn, "logout", "login", 2*time.Second)
+	if expected := "logout() execution of scenario login timed out after 2 seconds"; te.Error() != expected {
+		t.Errorf("Expected error %s, but got: %s", expected, te.Error())
+	}
+	if !strings.HasSuffix(te.Hint(), "teardownTimeout option of the scenario login") {
+		t.Errorf("Expected the hint to mention the scenario, got: %s", te.Hint())
+	}
+	if te.ExitCode() != exitcodes.TeardownTimeout {
+		t.Errorf("Expected the teardown timeout exit code, got: %d", te.ExitCode())
+	}
+}
@@ -196,6 +196,10 @@ type ExecutionState struct {
 	pauseStateLock      sync.RWMutex
 	totalPausedDuration time.Duration // only modified behind the lock
 	resumeNotify        chan struct{}
+
+	// The duration of the previous runs of a test that was resumed from a
+	// checkpoint, which is counted in GetCurrentTestRunDuration() as well.
+	resumedDuration time.Duration // only modified behind the pauseStateLock
 }
 
 // NewExecutionState initializes all of the pointers in the ExecutionState
@@ -380,7 +384,7 @@ func (es *ExecutionState) GetCurrentTestRunDuration() time.Duration {
 
 	es.pauseStateLock.RLock()
 	endTime := atomic.LoadInt64(es.endTime)
-	pausedDuration := es.totalPausedDuration
+	pausedDuration := es.totalPausedDuration - es.resumedDuration
 	es.pauseStateLock.RUnlock()
 
 	if endTime == 0 {
@@ -396,6 +400,14 @@ func (es *ExecutionState) GetCurrentTestRunDuration() time.Duration {
 	return time.Duration(endTime-startTime) - pausedDuration
 }
 
+// SetResumedDuration sets the duration of the previous runs of a test that is
+// resumed from a checkpoint, so it's counted in the test run duration.
+func (es *ExecutionState) SetResumedDuration(duration time.Duration) {
+	es.pauseStateLock.Lock()
+	defer es.pauseStateLock.Unlock()
+	es.resumedDuration = duration
+}
+
 // Pause pauses the current execution. It acquires the lock, writes
 // the current timestamp in currentPauseTime, and makes a new
 // channel for resumeNotify.
@@ -0,0 +1,96 @@
+package lib
+
+import (
+	"time"
+
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+// ArrivalRateConfig is implemented by the configs of the executors which start
+// their iterations at an arrival rate that's known before the test runs.
+type ArrivalRateConfig interface {
+	// GetArrivalRate returns the expected iterations per second of the
+	// execution segment at the given time from the start of the executor.
+	GetArrivalRate(et *ExecutionTuple, t time.Duration) float64
+}
+
+// PlanPoint is the expected state of a scenario at a point of the execution
+// plan. The iterations per second are null for the executors that don't
+// start their iterations at a known arrival rate.
+type PlanPoint struct {
+	Time                types.NullDuration `json:"time"`
+	VUs                 uint64             `json:"vus"`
+	MaxVUs              uint64             `json:"maxVUs"`
+	IterationsPerSecond null.Float         `json:"iterationsPerSecond"`
+}
+
+// ScenarioPlan is the timeline of a scenario in the execution plan.
+type ScenarioPlan struct {
+	Executor string `json:"executor"`
+	ScenarioSchedule
+	Points []PlanPoint `json:"points"`
+}
+
+// ExecutionPlan is the simulated timeline of all of the scenarios of a test,
+// with points every resolution from the start until the end of the test.
+type ExecutionPlan struct {
+	Duration   types.NullDuration      `json:"duration"`
+	Resolution types.NullDuration      `json:"resolution"`
+	MaxVUs     uint64                  `json:"maxVUs"`
+	Scenarios  map[string]ScenarioPlan `json:"scenarios"`
+}
+
+// GetExecutionPlan simulates the execution of the scenarios, without running
+// any VUs, from their execution requirements and their arrival rates. The
+// scenarios which wait for conditions on the metrics are placed at the
+// earliest time they can start.
+func (scs ScenarioConfigs) GetExecutionPlan(et *ExecutionTuple, resolution time.Duration) ExecutionPlan {
+	fullRequirements := scs.GetFullExecutionRequirements(et)
+	duration, _ := GetEndOffset(fullRequirements)
+	plan := ExecutionPlan{
+		Duration:   types.NewNullDuration(duration, true),
+		Resolution: types.NewNullDuration(resolution, true),
+		MaxVUs:     GetMaxPossibleVUs(fullRequirements),
+		Scenarios:  make(map[string]ScenarioPlan, len(scs)),
+	}
+
+	schedules := scs.GetSchedules(et)
+	for name, config := range scs {
+		schedule := schedules[name]
+		start, end := schedule.Start.TimeDuration(), schedule.End.TimeDuration()
+		steps := config.GetExecutionRequirements(et)
+		arrivalRateConfig, hasArrivalRate := config.(ArrivalRateConfig)
+
+		scenarioPlan := ScenarioPlan{Executor: config.GetType(), ScenarioSchedule: schedule}
+		for t := time.Duration(0); ; t += resolution {
+			if t > duration {
+				t = duration
+			}
+			point := PlanPoint{Time: types.NewNullDuration(t, true)}
+			isActive := t >= start && t < end
+			if isActive {
+				for _, step := range steps {
+					if step.TimeOffset > t-start {
+						break
+					}
+					point.VUs, point.MaxVUs = step.PlannedVUs, step.PlannedVUs+step.MaxUnplannedVUs
+				}
+			}
+			if hasArrivalRate {
+				if isActive {
+					point.IterationsPerSecond = null.FloatFrom(arrivalRateConfig.GetArrivalRate(et, t-start))
+				} else {
+					point.IterationsPerSecond = null.FloatFrom(0)
+				}
+			}
+			scenarioPlan.Points = append(scenarioPlan.Points, point)
+			if t == duration || resolution <= 0 {
+				break
+			}
+		}
+		plan.Scenarios[name] = scenarioPlan
+	}
+	return plan
+}
@@ -0,0 +1,585 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+const adaptiveArrivalRateType = "adaptive-arrival-rate"
+
+// sustainedIterationRateName is the name of the gauge with the highest rate,
+// in iterations per second, at which an adaptive scenario met its target.
+const sustainedIterationRateName = "sustained_iteration_rate"
+
+// The supported controllers of the adaptive-arrival-rate executor.
+const (
+	adaptiveControllerAIMD = "aimd"
+	adaptiveControllerPID  = "pid"
+)
+
+const (
+	defaultAdaptiveInterval   = 10 * time.Second
+	defaultAIMDDecreaseFactor = 0.5
+	defaultPIDKp              = 0.5
+	defaultPIDKi              = 0.1
+	defaultPIDKd              = 0.0
+)
+
+func init() {
+	lib.RegisterExecutorConfigType(
+		adaptiveArrivalRateType,
+		func(name string, rawJSON []byte) (lib.ExecutorConfig, error) {
+			config := NewAdaptiveArrivalRateConfig(name)
+			err := lib.StrictJSONUnmarshal(rawJSON, &config)
+			return config, err
+		},
+	)
+}
+
+// AdaptiveController changes the rate of the adaptive-arrival-rate executor
+// after every interval, depending on how far the metric was from the target.
+//
+// It can be specified only with its type, like "pid", or as an object with
+// its parameters.
+type AdaptiveController struct {
+	// Type is either aimd (additive increase, multiplicative decrease) or pid.
+	Type string `json:"type"`
+
+	// Increase is the number of iterations per timeUnit that the AIMD
+	// controller adds to the rate after every interval that met the target,
+	// a tenth of the maxRate by default.
+	Increase null.Int `json:"increase"`
+	// DecreaseFactor multiplies the rate after every interval that missed
+	// the target.
+	DecreaseFactor null.Float `json:"decreaseFactor"`
+
+	// Kp, Ki and Kd are the proportional, integral and derivative gains of the
+	// PID controller. Its error is the distance of the metric from the target,
+	// relative to the target, and its output is a relative change of the rate.
+	Kp null.Float `json:"kp"`
+	Ki null.Float `json:"ki"`
+	Kd null.Float `json:"kd"`
+}
+
+// UnmarshalJSON accepts both the type of the controller and the full object.
+func (c *AdaptiveController) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		*c = AdaptiveController{}
+		return json.Unmarshal(data, &c.Type)
+	}
+	type rawAdaptiveController AdaptiveController
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode((*rawAdaptiveController)(c))
+}
+
+// Validate makes sure the controller and its parameters are valid.
+func (c *AdaptiveController) Validate() []error {
+	var errors []error
+	switch c.Type {
+	case adaptiveControllerAIMD:
+		if c.Increase.Valid && c.Increase.Int64 <= 0 {
+			errors = append(errors, fmt.Errorf("the rate increase must be more than 0"))
+		}
+		if c.DecreaseFactor.Valid && (c.DecreaseFactor.Float64 <= 0 || c.DecreaseFactor.Float64 >= 1) {
+			errors = append(errors, fmt.Errorf("the rate decreaseFactor must be between 0 and 1"))
+		}
+		if c.Kp.Valid || c.Ki.Valid || c.Kd.Valid {
+			errors = append(errors, fmt.Errorf("the kp, ki and kd gains can only be set for the %s controller",
+				adaptiveControllerPID))
+		}
+	case adaptiveControllerPID:
+		for _, gain := range []null.Float{c.Kp, c.Ki, c.Kd} {
+			if gain.Valid && gain.Float64 < 0 {
+				errors = append(errors, fmt.Errorf("the gains of the %s controller can't be negative",
+					adaptiveControllerPID))
+				break
+			}
+		}
+		if c.Increase.Valid || c.DecreaseFactor.Valid {
+			errors = append(errors, fmt.Errorf("the increase and decreaseFactor can only be set for the %s controller",
+				adaptiveControllerAIMD))
+		}
+	default:
+		errors = append(errors, fmt.Errorf("the controller must be either %s or %s, but is '%s'",
+			adaptiveControllerAIMD, adaptiveControllerPID, c.Type))
+	}
+	return errors
+}
+
+// AdaptiveArrivalRateConfig stores config for the adaptive arrival-rate executor
+type AdaptiveArrivalRateConfig struct {
+	BaseConfig
+	StartRate null.Int           `json:"startRate"`
+	MinRate   null.Int           `json:"minRate"`
+	MaxRate   null.Int           `json:"maxRate"`
+	TimeUnit  types.NullDuration `json:"timeUnit"`
+	Duration  types.NullDuration `json:"duration"`
+
+	// Metric is the metric or sub-metric, like http_req_duration{scenario:api},
+	// whose samples in every interval are compared with the Target, which is
+	// a threshold expression, like p(95)<500.
+	Metric     null.String         `json:"metric"`
+	Target     null.String         `json:"target"`
+	Interval   types.NullDuration  `json:"interval"`
+	Controller *AdaptiveController `json:"controller,omitempty"`
+
+	// Initialize `PreAllocatedVUs` number of VUs, and if more than that are needed,
+	// they will be dynamically allocated, until `MaxVUs` is reached, which is an
+	// absolutely hard limit on the number of VUs the executor will use
+	PreAllocatedVUs null.Int `json:"preAllocatedVUs"`
+	MaxVUs          null.Int `json:"maxVUs"`
+
+	// ArrivalDistribution randomizes the times between the iterations, they
+	// are evenly spaced if it isn't specified
+	ArrivalDistribution *ArrivalDistribution `json:"arrivalDistribution,omitempty"`
+}
+
+// NewAdaptiveArrivalRateConfig returns an AdaptiveArrivalRateConfig with default values
+func NewAdaptiveArrivalRateConfig(name string) *AdaptiveArrivalRateConfig {
+	return &AdaptiveArrivalRateConfig{
+		BaseConfig: NewBaseConfig(name, adaptiveArrivalRateType),
+		MinRate:    null.NewInt(1, false),
+		TimeUnit:   types.NewNullDuration(1*time.Second, false),
+		Interval:   types.NewNullDuration(defaultAdaptiveInterval, false),
+	}
+}
+
+// Make sure we implement the lib.ExecutorConfig interface
+var _ lib.ExecutorConfig = &AdaptiveArrivalRateConfig{}
+
+// GetPreAllocatedVUs is just a helper method that returns the scaled pre-allocated VUs.
+func (aarc AdaptiveArrivalRateConfig) GetPreAllocatedVUs(et *lib.ExecutionTuple) int64 {
+	return et.ScaleInt64(aarc.PreAllocatedVUs.Int64)
+}
+
+// GetMaxVUs is just a helper method that returns the scaled max VUs.
+func (aarc AdaptiveArrivalRateConfig) GetMaxVUs(et *lib.ExecutionTuple) int64 {
+	return et.ScaleInt64(aarc.MaxVUs.Int64)
+}
+
+// GetDescription returns a human-readable description of the executor options
+func (aarc AdaptiveArrivalRateConfig) GetDescription(et *lib.ExecutionTuple) string {
+	preAllocatedVUs, maxVUs := aarc.GetPreAllocatedVUs(et), aarc.GetMaxVUs(et)
+	maxVUsRange := fmt.Sprintf("maxVUs: %d", preAllocatedVUs)
+	if maxVUs > preAllocatedVUs {
+		maxVUsRange += fmt.Sprintf("-%d", maxVUs)
+	}
+
+	getRatePerSec := func(rate int64) float64 {
+		if maxVUs == 0 {
+			return 0
+		}
+		arrRate := big.NewRat(rate, int64(aarc.TimeUnit.TimeDuration()))
+		arrRate.Mul(arrRate, big.NewRat(maxVUs, aarc.MaxVUs.Int64))
+		arrRatePerSec, _ := getArrivalRatePerSec(arrRate).Float64()
+		return arrRatePerSec
+	}
+
+	return fmt.Sprintf("Up to %.2f iterations/s%s for %s, starting at %.2f, to keep %s %s%s",
+		getRatePerSec(aarc.MaxRate.Int64), getArrivalsInfo(aarc.ArrivalDistribution), aarc.Duration.Duration,
+		getRatePerSec(aarc.StartRate.Int64), aarc.Metric.String, aarc.Target.String,
+		aarc.getBaseInfo(maxVUsRange, "controller: "+aarc.getController().Type))
+}
+
+// getController returns the controller of the executor, the AIMD one by default.
+func (aarc AdaptiveArrivalRateConfig) getController() AdaptiveController {
+	if aarc.Controller == nil {
+		return AdaptiveController{Type: adaptiveControllerAIMD}
+	}
+	return *aarc.Controller
+}
+
+// getTarget returns the parsed target threshold of the executor.
+func (aarc AdaptiveArrivalRateConfig) getTarget() (*metrics.Threshold, error) {
+	target, err := parseMetricCondition(aarc.Target.String)
+	if err != nil {
+		return nil, err
+	}
+	switch operator := target.Operator(); operator {
+	case "<", "<=", ">", ">=":
+	default:
+		return nil, fmt.Errorf("the operator of the target %q must be one of <, <=, > or >=, but is %s",
+			aarc.Target.String, operator)
+	}
+	return target, nil
+}
+
+// Validate makes sure all options are configured and valid
+//
+//nolint:funlen,cyclop
+func (aarc *AdaptiveArrivalRateConfig) Validate() []error {
+	errors := aarc.BaseConfig.Validate()
+	if !aarc.StartRate.Valid {
+		errors = append(errors, fmt.Errorf("the startRate isn't specified"))
+	} else if aarc.StartRate.Int64 <= 0 {
+		errors = append(errors, fmt.Errorf("the startRate must be more than 0"))
+	}
+
+	if !aarc.MaxRate.Valid {
+		errors = append(errors, fmt.Errorf("the maxRate isn't specified"))
+	} else if aarc.MaxRate.Int64 < aarc.StartRate.Int64 {
+		errors = append(errors, fmt.Errorf("the maxRate can't be less than the startRate"))
+	}
+
+	if aarc.MinRate.Int64 <= 0 {
+		errors = append(errors, fmt.Errorf("the minRate must be more than 0"))
+	} else if aarc.StartRate.Valid && aarc.MinRate.Int64 > aarc.StartRate.Int64 {
+		errors = append(errors, fmt.Errorf("the minRate can't be more than the startRate"))
+	}
+
+	if aarc.TimeUnit.TimeDuration() <= 0 {
+		errors = append(errors, fmt.Errorf("the timeUnit must be more than 0"))
+	}
+
+	if !aarc.Duration.Valid {
+		errors = append(errors, fmt.Errorf("the duration is unspecified"))
+	} else if aarc.Duration.TimeDuration() < minDuration {
+		errors = append(errors, fmt.Errorf(
+			"the duration must be at least %s, but is %s", minDuration, aarc.Duration,
+		))
+	}
+
+	if aarc.Interval.TimeDuration() <= 0 {
+		errors = append(errors, fmt.Errorf("the interval must be more than 0"))
+	} else if aarc.Duration.Valid && aarc.Interval.TimeDuration() > aarc.Duration.TimeDuration() {
+		errors = append(errors, fmt.Errorf("the interval can't be longer than the duration"))
+	}
+
+	if !aarc.Metric.Valid || aarc.Metric.String == "" {
+		errors = append(errors, fmt.Errorf("the metric isn't specified"))
+	} else if _, _, err := metrics.ParseMetricName(aarc.Metric.String); err != nil {
+		errors = append(errors, fmt.Errorf("invalid metric %q: %w", aarc.Metric.String, err))
+	}
+
+	if !aarc.Target.Valid || aarc.Target.String == "" {
+		errors = append(errors, fmt.Errorf("the target isn't specified"))
+	} else if _, err := aarc.getTarget(); err != nil {
+		errors = append(errors, err)
+	}
+
+	if aarc.Controller != nil {
+		errors = append(errors, aarc.Controller.Validate()...)
+	}
+
+	if !aarc.PreAllocatedVUs.Valid {
+		errors = append(errors, fmt.Errorf("the number of preAllocatedVUs isn't specified"))
+	} else if aarc.PreAllocatedVUs.Int64 < 0 {
+		errors = append(errors, fmt.Errorf("the number of preAllocatedVUs can't be negative"))
+	}
+
+	if !aarc.MaxVUs.Valid {
+		// TODO: don't change the config while validating
+		aarc.MaxVUs.Int64 = aarc.PreAllocatedVUs.Int64
+	} else if aarc.MaxVUs.Int64 < aarc.PreAllocatedVUs.Int64 {
+		errors = append(errors, fmt.Errorf("maxVUs can't be less than preAllocatedVUs"))
+	}
+
+	if aarc.ArrivalDistribution != nil {
+		errors = append(errors, aarc.ArrivalDistribution.Validate()...)
+	}
+
+	return errors
+}
+
+// getConstantArrivalRateConfig returns the config of the constant-arrival-rate
+// executor that runs the iterations, starting with the startRate.
+func (aarc AdaptiveArrivalRateConfig) getConstantArrivalRateConfig() ConstantArrivalRateConfig {
+	return ConstantArrivalRateConfig{
+		BaseConfig:          aarc.BaseConfig,
+		Rate:                aarc.StartRate,
+		TimeUnit:            aarc.TimeUnit,
+		Duration:            aarc.Duration,
+		PreAllocatedVUs:     aarc.PreAllocatedVUs,
+		MaxVUs:              aarc.MaxVUs,
+		ArrivalDistribution: aarc.ArrivalDistribution,
+	}
+}
+
+// GetExecutionRequirements returns the number of required VUs to run the
+// executor for its whole duration (disregarding any startTime), including the
+// maximum waiting time for any iterations to gracefully stop. This is used by
+// the execution scheduler in its VU reservation calculations, so it knows how
+// many VUs to pre-initialize.
+func (aarc AdaptiveArrivalRateConfig) GetExecutionRequirements(et *lib.ExecutionTuple) []lib.ExecutionStep {
+	return aarc.getConstantArrivalRateConfig().GetExecutionRequirements(et)
+}
+
+// NewExecutor creates a new AdaptiveArrivalRate executor
+func (aarc AdaptiveArrivalRateConfig) NewExecutor(
+	es *lib.ExecutionState, logger *logrus.Entry,
+) (lib.Executor, error) {
+	target, err := aarc.getTarget()
+	if err != nil {
+		return nil, err
+	}
+	carc := aarc.getConstantArrivalRateConfig()
+	base := NewBaseExecutor(&aarc, es, logger)
+	return &AdaptiveArrivalRate{
+		BaseExecutor: base,
+		config:       aarc,
+		target:       target,
+		car: &ConstantArrivalRate{
+			BaseExecutor: base,
+			config:       carc,
+			control:      newArrivalRateControl(&carc),
+		},
+	}, nil
+}
+
+// HasWork reports whether there is any work to be done for the given execution segment.
+func (aarc AdaptiveArrivalRateConfig) HasWork(et *lib.ExecutionTuple) bool {
+	return aarc.GetMaxVUs(et) > 0
+}
+
+// AdaptiveArrivalRate changes its iteration rate while it's running, to find
+// the highest one at which a metric still meets a target, like the 95th
+// percentile of the request durations staying below 500ms. The iterations are
+// run by a constant-arrival-rate executor, whose rate is set by a controller
+// after every interval.
+type AdaptiveArrivalRate struct {
+	*BaseExecutor
+	config AdaptiveArrivalRateConfig
+	target *metrics.Threshold
+	car    *ConstantArrivalRate
+
+	watchMetric     func() (metrics.Sink, time.Duration)
+	sustainedMetric *metrics.Metric
+}
+
+// Make sure we implement the lib.Executor interface.
+var _ lib.Executor = &AdaptiveArrivalRate{}
+
+// Init values needed for the execution
+func (aar *AdaptiveArrivalRate) Init(ctx context.Context) error {
+	test := aar.executionState.Test
+	if test.MetricsWatcher == nil {
+		return fmt.Errorf("the %s executor needs the metrics of the test, "+
+			"which aren't processed when both the end-of-test summary and the thresholds are disabled",
+			adaptiveArrivalRateType)
+	}
+	target := metrics.Thresholds{Thresholds: []*metrics.Threshold{aar.target}}
+	if err := target.Validate(aar.config.Metric.String, test.Registry); err != nil {
+		return fmt.Errorf("invalid target of scenario %s: %w", aar.config.Name, err)
+	}
+
+	var err error
+	aar.sustainedMetric, err = test.Registry.NewMetric(sustainedIterationRateName, metrics.Gauge)
+	if err != nil {
+		return err
+	}
+	aar.watchMetric, err = test.MetricsWatcher.WatchMetric(aar.config.Metric.String)
+	if err != nil {
+		return fmt.Errorf("invalid metric of scenario %s: %w", aar.config.Name, err)
+	}
+
+	return aar.car.Init(ctx)
+}
+
+// Run executes the iterations with the constant-arrival-rate executor, while
+// it adapts their rate to the metric.
+func (aar AdaptiveArrivalRate) Run(parentCtx context.Context, out chan<- metrics.SampleContainer) error {
+	// The dropped iterations are counted, to know when the VUs are exhausted
+	var dropped int64
+	carOut := make(chan metrics.SampleContainer)
+	forwarded := make(chan struct{})
+	go func() {
+		defer close(forwarded)
+		for sc := range carOut {
+			for _, s := range sc.GetSamples() {
+				if s.Metric.Name == metrics.DroppedIterationsName {
+					atomic.AddInt64(&dropped, int64(s.Value))
+				}
+			}
+			metrics.PushIfNotDone(parentCtx, out, sc)
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	controlled := &sync.WaitGroup{}
+	controlled.Add(1)
+	go func() {
+		defer controlled.Done()
+		aar.control(ctx, out, func() bool { return atomic.SwapInt64(&dropped, 0) > 0 })
+	}()
+
+	err := aar.car.Run(parentCtx, carOut)
+	cancel()
+	controlled.Wait()
+	close(carOut)
+	<-forwarded
+	return err
+}
+
+// control adjusts the rate of the iterations after every interval, until the
+// regular duration of the executor is over.
+func (aar AdaptiveArrivalRate) control(
+	ctx context.Context, out chan<- metrics.SampleContainer, wereDropped func() bool,
+) {
+	select {
+	case <-aar.car.control.hasStarted:
+	case <-ctx.Done():
+		return
+	}
+	// The samples from before the start don't count
+	aar.watchMetric()
+	wereDropped()
+
+	rate := newAdaptiveRate(aar.config)
+	ticker := time.NewTicker(aar.config.Interval.TimeDuration())
+	defer ticker.Stop()
+	tags := aar.getMetricTags(nil)
+	timeUnit := aar.config.TimeUnit.TimeDuration()
+	defer func() {
+		if rate.sustained == 0 {
+			aar.logger.Warnf("The target %s of %s wasn't met in any interval", aar.config.Target.String,
+				aar.config.Metric.String)
+			return
+		}
+		aar.logger.Infof("The highest rate that kept %s %s was %d iterations per %s", aar.config.Metric.String,
+			aar.config.Target.String, rate.sustained, timeUnit)
+	}()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-aar.car.control.hasEnded:
+			return
+		case <-ctx.Done():
+			return
+		}
+
+		sink, elapsed := aar.watchMetric()
+		value, target, ok := aar.target.Operands(sink, elapsed)
+		if !ok {
+			aar.logger.Debugf("There were no samples of %s in the last interval", aar.config.Metric.String)
+			continue
+		}
+		previousRate, previousSustained := rate.rate, rate.sustained
+		newRate := rate.adjust(value, target, wereDropped())
+		if rate.sustained > previousSustained {
+			metrics.PushIfNotDone(ctx, out, metrics.Sample{
+				TimeSeries: metrics.TimeSeries{Metric: aar.sustainedMetric, Tags: tags},
+				Time:       time.Now(),
+				Value:      float64(rate.sustained) * float64(time.Second) / float64(timeUnit),
+			})
+		}
+		aar.logger.WithFields(logrus.Fields{
+			"value": value, "target": target, "rate": newRate,
+		}).Debug("Adjusted the rate of the executor")
+		if newRate == previousRate {
+			continue
+		}
+
+		err := aar.car.UpdateConfig(ctx, ArrivalRateUpdate{Rate: null.IntFrom(newRate)})
+		if err != nil {
+			if !errors.Is(err, errScenarioEnded) && !errors.Is(err, context.Canceled) {
+				aar.logger.WithError(err).Error("Couldn't change the rate of the executor")
+			}
+			return
+		}
+	}
+}
+
+// adaptiveRate is the state of the controller of the executor's rate.
+type adaptiveRate struct {
+	config     AdaptiveArrivalRateConfig
+	controller AdaptiveController
+	operator   string
+
+	// The current rate and the highest one that met the target, in
+	// iterations per timeUnit, for the whole test.
+	rate, sustained int64
+
+	// The state of the PID controller.
+	integral, lastError float64
+}
+
+func newAdaptiveRate(config AdaptiveArrivalRateConfig) *adaptiveRate {
+	target, _ := config.getTarget() // it was already validated
+	return &adaptiveRate{
+		config:     config,
+		controller: config.getController(),
+		operator:   target.Operator(),
+		rate:       config.StartRate.Int64,
+	}
+}
+
+// adjust returns the rate for the next interval, given the value of the metric
+// and the target in the last one, and whether any iterations were dropped,
+// since there weren't enough VUs. The rate isn't increased above the maxRate
+// or while there aren't enough VUs.
+func (ar *adaptiveRate) adjust(value, target float64, saturated bool) int64 {
+	// The error is how far the metric is from the target, relative to it,
+	// positive when the target is met
+	diff := target - value
+	if ar.operator == ">" || ar.operator == ">=" {
+		diff = -diff
+	}
+	met := diff > 0 || (diff == 0 && (ar.operator == "<=" || ar.operator == ">="))
+	if target != 0 {
+		diff /= math.Abs(target)
+	}
+	if met && !saturated && ar.rate > ar.sustained {
+		ar.sustained = ar.rate
+	}
+
+	newRate := ar.rate
+	switch ar.controller.Type {
+	case adaptiveControllerPID:
+		e := math.Max(-1, math.Min(1, diff))
+		if saturated && e > 0 {
+			e = 0 // don't ask for more than the VUs can do
+		}
+		integral := ar.integral + e
+		output := getGain(ar.controller.Kp, defaultPIDKp)*e + getGain(ar.controller.Ki, defaultPIDKi)*integral +
+			getGain(ar.controller.Kd, defaultPIDKd)*(e-ar.lastError)
+		newRate = int64(math.Round(float64(ar.rate) * (1 + output)))
+		ar.lastError = e
+		// the integral doesn't grow while the rate is at its limits
+		if newRate > ar.config.MinRate.Int64 && newRate < ar.config.MaxRate.Int64 {
+			ar.integral = integral
+		}
+	default:
+		switch {
+		case !met:
+			decreaseFactor := defaultAIMDDecreaseFactor
+			if ar.controller.DecreaseFactor.Valid {
+				decreaseFactor = ar.controller.DecreaseFactor.Float64
+			}
+			newRate = int64(float64(ar.rate) * decreaseFactor)
+		case !saturated:
+			increase := ar.config.MaxRate.Int64 / 10
+			if ar.controller.Increase.Valid {
+				increase = ar.controller.Increase.Int64
+			}
+			newRate += max(increase, 1)
+		}
+	}
+
+	ar.rate = min(max(newRate, ar.config.MinRate.Int64), ar.config.MaxRate.Int64)
+	return ar.rate
+}
+
+// getGain returns the gain of the PID controller, or its default value.
+func getGain(gain null.Float, defaultGain float64) float64 {
+	if gain.Valid {
+		return gain.Float64
+	}
+	return defaultGain
+}
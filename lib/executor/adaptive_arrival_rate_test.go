@@ -0,0 +1,179 @@
+package executor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/testutils/minirunner"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+func getTestAdaptiveArrivalRateConfig() *AdaptiveArrivalRateConfig {
+	config := NewAdaptiveArrivalRateConfig("adaptive")
+	config.StartRate = null.IntFrom(10)
+	config.MaxRate = null.IntFrom(100)
+	config.Duration = types.NullDurationFrom(2 * time.Second)
+	config.Interval = types.NullDurationFrom(100 * time.Millisecond)
+	config.Metric = null.StringFrom("http_req_duration")
+	config.Target = null.StringFrom("p(95)<500")
+	config.Controller = &AdaptiveController{Type: adaptiveControllerAIMD, Increase: null.IntFrom(10)}
+	config.PreAllocatedVUs = null.IntFrom(20)
+	config.MaxVUs = null.IntFrom(50)
+	config.GracefulStop = types.NullDurationFrom(0)
+	return config
+}
+
+func TestAdaptiveArrivalRateConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	config := getTestAdaptiveArrivalRateConfig()
+	require.Empty(t, config.Validate())
+	et, err := lib.NewExecutionTuple(nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t,
+		"Up to 100.00 iterations/s for 2s, starting at 10.00, to keep http_req_duration p(95)<500 "+
+			"(maxVUs: 20-50, controller: aimd)",
+		config.GetDescription(et))
+
+	testCases := []struct {
+		change func(*AdaptiveArrivalRateConfig)
+		err    string
+	}{
+		{change: func(c *AdaptiveArrivalRateConfig) { c.StartRate = null.Int{} }, err: "startRate isn't specified"},
+		{change: func(c *AdaptiveArrivalRateConfig) { c.MaxRate = null.IntFrom(5) }, err: "less than the startRate"},
+		{change: func(c *AdaptiveArrivalRateConfig) { c.MinRate = null.IntFrom(20) }, err: "more than the startRate"},
+		{change: func(c *AdaptiveArrivalRateConfig) { c.Interval = types.NullDurationFrom(time.Hour) }, err: "longer than"},
+		{change: func(c *AdaptiveArrivalRateConfig) { c.Metric = null.String{} }, err: "metric isn't specified"},
+		{change: func(c *AdaptiveArrivalRateConfig) { c.Target = null.StringFrom("p(95)") }, err: "failed parsing"},
+		{change: func(c *AdaptiveArrivalRateConfig) { c.Target = null.StringFrom("p(95)!=500") }, err: "must be one of"},
+		{
+			change: func(c *AdaptiveArrivalRateConfig) { c.Target = null.StringFrom("p(95)<500 over 1m") },
+			err:    "can't have a time window",
+		},
+		{
+			change: func(c *AdaptiveArrivalRateConfig) { c.Target = null.StringFrom("p(95)<2*iteration_duration.avg") },
+			err:    "can't reference other metrics",
+		},
+		{
+			change: func(c *AdaptiveArrivalRateConfig) { c.Controller = &AdaptiveController{Type: "bang-bang"} },
+			err:    "must be either aimd or pid",
+		},
+		{
+			change: func(c *AdaptiveArrivalRateConfig) {
+				c.Controller = &AdaptiveController{Type: adaptiveControllerPID, DecreaseFactor: null.FloatFrom(0.5)}
+			},
+			err: "can only be set for the aimd controller",
+		},
+	}
+	for _, tc := range testCases {
+		config := getTestAdaptiveArrivalRateConfig()
+		tc.change(config)
+		errs := config.Validate()
+		require.Len(t, errs, 1, tc.err)
+		assert.ErrorContains(t, errs[0], tc.err)
+	}
+}
+
+func TestAdaptiveRateAdjust(t *testing.T) {
+	t.Parallel()
+
+	t.Run("aimd", func(t *testing.T) {
+		t.Parallel()
+		rate := newAdaptiveRate(*getTestAdaptiveArrivalRateConfig())
+		assert.Equal(t, int64(20), rate.adjust(100, 500, false))
+		assert.Equal(t, int64(30), rate.adjust(499, 500, false))
+		assert.Equal(t, int64(15), rate.adjust(500, 500, false))
+		assert.Equal(t, int64(15), rate.adjust(100, 500, true)) // no more VUs
+		assert.Equal(t, int64(7), rate.adjust(800, 500, false))
+		assert.Equal(t, int64(3), rate.adjust(800, 500, false))
+		assert.Equal(t, int64(1), rate.adjust(800, 500, false))
+		assert.Equal(t, int64(1), rate.adjust(800, 500, false)) // the minRate
+		assert.Equal(t, int64(20), rate.sustained)
+
+		rate.rate = 95
+		assert.Equal(t, int64(100), rate.adjust(100, 500, false)) // the maxRate
+		assert.Equal(t, int64(100), rate.adjust(100, 500, false))
+		assert.Equal(t, int64(100), rate.sustained)
+	})
+
+	t.Run("pid", func(t *testing.T) {
+		t.Parallel()
+		config := getTestAdaptiveArrivalRateConfig()
+		config.Target = null.StringFrom("rate>=0.99")
+		config.Controller = &AdaptiveController{Type: adaptiveControllerPID, Ki: null.FloatFrom(0)}
+		rate := newAdaptiveRate(*config)
+		// the error is clamped to 1, so the rate is at most 1.5 times higher
+		assert.Equal(t, int64(15), rate.adjust(1, 0.5, false))
+		assert.Equal(t, int64(15), rate.adjust(1, 0.5, true))
+		// 20% below the target
+		assert.Equal(t, int64(14), rate.adjust(0.792, 0.99, false))
+		assert.Equal(t, int64(10), rate.sustained)
+	})
+}
+
+type testMetricsWatcher func() (metrics.Sink, time.Duration)
+
+func (w testMetricsWatcher) WatchMetric(string) (func() (metrics.Sink, time.Duration), error) {
+	return w, nil
+}
+
+func TestAdaptiveArrivalRateRun(t *testing.T) {
+	t.Parallel()
+
+	config := getTestAdaptiveArrivalRateConfig()
+	et, err := lib.NewExecutionTuple(nil, nil)
+	require.NoError(t, err)
+	testRunState := getTestRunState(t, lib.Options{}, &minirunner.MiniRunner{})
+
+	// The requests are slow when there are more than 30 iterations per second
+	var aar *AdaptiveArrivalRate
+	testRunState.MetricsWatcher = testMetricsWatcher(func() (metrics.Sink, time.Duration) {
+		sink := metrics.NewSink(metrics.Trend)
+		duration := 100.0
+		if aar != nil && aar.car.GetCurrentConfig().(*ConstantArrivalRateConfig).Rate.Int64 > 30 { //nolint:forcetypeassert
+			duration = 900
+		}
+		sink.Add(metrics.Sample{Value: duration})
+		return sink, config.Interval.TimeDuration()
+	})
+
+	execReqs := config.GetExecutionRequirements(et)
+	es := lib.NewExecutionState(testRunState, et, lib.GetMaxPlannedVUs(execReqs), lib.GetMaxPossibleVUs(execReqs))
+	ctx, cancel, executor, _ := setupExecutor(t, config, es)
+	defer cancel()
+	var ok bool
+	aar, ok = executor.(*AdaptiveArrivalRate)
+	require.True(t, ok)
+
+	engineOut := make(chan metrics.SampleContainer, 1000)
+	require.NoError(t, executor.Run(ctx, engineOut))
+	assert.NotZero(t, es.GetFullIterationCount())
+
+	var sustained []float64
+	for _, sample := range metrics.GetBufferedSamples(engineOut) {
+		for _, s := range sample.GetSamples() {
+			if s.Metric.Name == sustainedIterationRateName {
+				sustained = append(sustained, s.Value)
+			}
+		}
+	}
+	assert.Equal(t, []float64{10, 20, 30}, sustained)
+}
+
+func TestAdaptiveArrivalRateWithoutMetrics(t *testing.T) {
+	t.Parallel()
+
+	et, err := lib.NewExecutionTuple(nil, nil)
+	require.NoError(t, err)
+	es := lib.NewExecutionState(getTestRunState(t, lib.Options{}, &minirunner.MiniRunner{}), et, 20, 50)
+	executor, err := getTestAdaptiveArrivalRateConfig().NewExecutor(es, nil)
+	require.NoError(t, err)
+	assert.ErrorContains(t, executor.Init(context.Background()), "needs the metrics of the test")
+}
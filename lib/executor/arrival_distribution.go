@@ -0,0 +1,227 @@
+package executor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"time"
+
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+)
+
+// The supported distributions of the times between the arrivals of the
+// iterations of the arrival-rate executors.
+const (
+	arrivalDistributionEven    = "even"
+	arrivalDistributionPoisson = "poisson"
+	arrivalDistributionUniform = "uniform"
+	arrivalDistributionPareto  = "pareto"
+)
+
+const (
+	defaultArrivalJitter = 1.0
+	defaultParetoShape   = 2.0
+)
+
+// ArrivalDistribution randomizes the times between the arrivals of the
+// iterations of the arrival-rate executors, while keeping their configured
+// average rate. By default, the iterations are evenly spaced.
+//
+// It can be specified only with its type, like "poisson", or as an object
+// with its parameters.
+type ArrivalDistribution struct {
+	// Type is one of even, poisson (exponential times between the arrivals),
+	// uniform or pareto.
+	Type string `json:"type"`
+	// Jitter is the maximum deviation of the uniform times between the
+	// arrivals, as a fraction of their average.
+	Jitter null.Float `json:"jitter"`
+	// Shape is the shape parameter of the pareto distribution, the lower it
+	// is, the more bursty the arrivals are.
+	Shape null.Float `json:"shape"`
+}
+
+// UnmarshalJSON accepts both the type of the distribution and the full object.
+func (d *ArrivalDistribution) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		*d = ArrivalDistribution{}
+		return json.Unmarshal(data, &d.Type)
+	}
+	type rawArrivalDistribution ArrivalDistribution
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode((*rawArrivalDistribution)(d))
+}
+
+// Validate makes sure the distribution and its parameters are valid.
+func (d *ArrivalDistribution) Validate() []error {
+	var errors []error
+	switch d.Type {
+	case arrivalDistributionEven, arrivalDistributionPoisson:
+	case arrivalDistributionUniform:
+		if d.Jitter.Valid && (d.Jitter.Float64 < 0 || d.Jitter.Float64 > 1) {
+			errors = append(errors, fmt.Errorf("the arrival jitter must be between 0 and 1"))
+		}
+	case arrivalDistributionPareto:
+		if d.Shape.Valid && d.Shape.Float64 <= 1 {
+			errors = append(errors, fmt.Errorf("the shape of the pareto arrival distribution must be more than 1"))
+		}
+	default:
+		return append(errors, fmt.Errorf(
+			"the arrival distribution must be one of %s, %s, %s or %s, but is '%s'",
+			arrivalDistributionEven, arrivalDistributionPoisson, arrivalDistributionUniform,
+			arrivalDistributionPareto, d.Type,
+		))
+	}
+	if d.Jitter.Valid && d.Type != arrivalDistributionUniform {
+		errors = append(errors, fmt.Errorf("the arrival jitter can only be set for the %s distribution",
+			arrivalDistributionUniform))
+	}
+	if d.Shape.Valid && d.Type != arrivalDistributionPareto {
+		errors = append(errors, fmt.Errorf("the arrival shape can only be set for the %s distribution",
+			arrivalDistributionPareto))
+	}
+	return errors
+}
+
+// getArrivalsInfo returns a description of the distribution for the
+// executors' descriptions, it's empty for the evenly spaced arrivals.
+func getArrivalsInfo(d *ArrivalDistribution) string {
+	if d == nil || d.Type == arrivalDistributionEven {
+		return ""
+	}
+	return fmt.Sprintf(" with %s arrivals", d.Type)
+}
+
+// arrivalGaps draws the times between the arrivals, in units of their average.
+type arrivalGaps struct {
+	distribution *ArrivalDistribution
+	seed         int64
+}
+
+// validateArrivalsSeed makes sure that all instances of a test with execution
+// segments draw the same random arrivals, so their segments split a single
+// sequence of arrivals. They can do that only with the same seed, so the
+// randomSeed option is required then. The coordinator of a distributed test
+// sets it for all of its instances if it isn't set.
+func validateArrivalsSeed(d *ArrivalDistribution, scenario string, options lib.Options) error {
+	if d == nil || d.Type == arrivalDistributionEven || options.RandomSeed.Valid {
+		return nil
+	}
+	if options.ExecutionSegment.FloatLength() < 1 {
+		return fmt.Errorf("the %s arrivals of scenario %s need the randomSeed option with an execution segment, "+
+			"so all of the instances of the test draw the same arrivals", d.Type, scenario)
+	}
+	return nil
+}
+
+// newArrivalGaps returns the gaps of the distribution for the given scenario,
+// drawn with the randomSeed option if it's set. The seed is mixed with the
+// scenario name, so every scenario has different arrivals even with the same
+// seed.
+func newArrivalGaps(d *ArrivalDistribution, scenario string, randomSeed null.Int) arrivalGaps {
+	if d == nil || d.Type == arrivalDistributionEven {
+		return arrivalGaps{}
+	}
+	seed := time.Now().UnixNano()
+	if randomSeed.Valid {
+		seed = randomSeed.Int64
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(scenario))
+	return arrivalGaps{distribution: d, seed: seed ^ int64(h.Sum64())} //nolint:gosec
+}
+
+// newGapFunc returns a function that returns the next gap, or nil if the
+// arrivals are evenly spaced.
+func (g arrivalGaps) newGapFunc() func() float64 {
+	if g.distribution == nil {
+		return nil
+	}
+	rng := rand.New(rand.NewSource(g.seed)) //nolint:gosec
+	switch g.distribution.Type {
+	case arrivalDistributionPoisson:
+		return rng.ExpFloat64
+	case arrivalDistributionUniform:
+		jitter := g.distribution.Jitter.ValueOrZero()
+		if !g.distribution.Jitter.Valid {
+			jitter = defaultArrivalJitter
+		}
+		return func() float64 {
+			return 1 + jitter*(2*rng.Float64()-1)
+		}
+	case arrivalDistributionPareto:
+		shape := g.distribution.Shape.ValueOrZero()
+		if !g.distribution.Shape.Valid {
+			shape = defaultParetoShape
+		}
+		scale := (shape - 1) / shape // so the average is 1
+		return func() float64 {
+			return scale / math.Pow(1-rng.Float64(), 1/shape)
+		}
+	default:
+		return nil
+	}
+}
+
+// arrivalSequence iterates over the positions of the iterations of an
+// execution segment in the global sequence of arrivals, with the number of
+// iterations that should have arrived by each of them. That's the position
+// itself for the evenly spaced arrivals, or the sum of the random gaps before
+// it otherwise. All of the gaps are drawn in the same order by every segment,
+// so the segments combined have the same arrivals as the whole test.
+type arrivalSequence struct {
+	offsets  []int64
+	li       int
+	position int64
+	area     float64
+	gap      func() float64
+}
+
+// newSequence returns the sequence of the execution segment, starting from
+// the position of its first iteration plus the given base position.
+func (g arrivalGaps) newSequence(et *lib.ExecutionTuple, base int64) *arrivalSequence {
+	start, offsets, _ := et.GetStripedOffsets()
+	s := &arrivalSequence{offsets: offsets, gap: g.newGapFunc()}
+	s.advance(start + base)
+	return s
+}
+
+func (s *arrivalSequence) advance(n int64) {
+	s.position += n
+	if s.gap == nil {
+		s.area = float64(s.position)
+		return
+	}
+	for ; n > 0; n-- {
+		s.area += s.gap()
+	}
+}
+
+// next moves to the next iteration of the execution segment.
+func (s *arrivalSequence) next() {
+	offset := s.offsets[s.li%len(s.offsets)]
+	s.li++
+	s.advance(offset)
+}
+
+// skipPast moves to the first iteration of the execution segment after the
+// given number of arrived iterations.
+func (s *arrivalSequence) skipPast(done float64) {
+	if s.gap == nil && s.area <= done {
+		// skip the whole cycles of the offsets at once
+		var cycle int64
+		for _, offset := range s.offsets {
+			cycle += offset
+		}
+		s.advance(int64((done-s.area)/float64(cycle)) * cycle)
+	}
+	for s.area <= done {
+		s.next()
+	}
+}
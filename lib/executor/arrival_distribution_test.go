@@ -0,0 +1,187 @@
+package executor
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+)
+
+func TestArrivalDistributionUnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	var config ConstantArrivalRateConfig
+	require.NoError(t, json.Unmarshal([]byte(`{"arrivalDistribution":"poisson"}`), &config))
+	assert.Equal(t, &ArrivalDistribution{Type: arrivalDistributionPoisson}, config.ArrivalDistribution)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"arrivalDistribution":{"type":"uniform","jitter":0.5}}`), &config))
+	assert.Equal(t, &ArrivalDistribution{
+		Type: arrivalDistributionUniform, Jitter: null.FloatFrom(0.5),
+	}, config.ArrivalDistribution)
+
+	// The seed is the randomSeed option of the test
+	assert.Error(t, json.Unmarshal([]byte(`{"arrivalDistribution":{"type":"poisson","randomSeed":7}}`), &config))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"arrivalDistribution":{"type":"pareto","alpha":2}}`), &config))
+}
+
+func TestArrivalDistributionValidate(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		distribution ArrivalDistribution
+		err          string
+	}{
+		{distribution: ArrivalDistribution{Type: arrivalDistributionEven}},
+		{distribution: ArrivalDistribution{Type: arrivalDistributionPoisson}},
+		{distribution: ArrivalDistribution{Type: arrivalDistributionUniform, Jitter: null.FloatFrom(0.2)}},
+		{distribution: ArrivalDistribution{Type: arrivalDistributionPareto, Shape: null.FloatFrom(1.5)}},
+		{distribution: ArrivalDistribution{Type: "gaussian"}, err: "must be one of"},
+		{distribution: ArrivalDistribution{}, err: "must be one of"},
+		{
+			distribution: ArrivalDistribution{Type: arrivalDistributionUniform, Jitter: null.FloatFrom(1.5)},
+			err:          "must be between 0 and 1",
+		},
+		{
+			distribution: ArrivalDistribution{Type: arrivalDistributionPareto, Shape: null.FloatFrom(1)},
+			err:          "must be more than 1",
+		},
+		{
+			distribution: ArrivalDistribution{Type: arrivalDistributionPoisson, Jitter: null.FloatFrom(0.5)},
+			err:          "jitter can only be set",
+		},
+		{
+			distribution: ArrivalDistribution{Type: arrivalDistributionUniform, Shape: null.FloatFrom(2)},
+			err:          "shape can only be set",
+		},
+	}
+	for _, tc := range testCases {
+		errs := tc.distribution.Validate()
+		if tc.err == "" {
+			assert.Empty(t, errs, tc.distribution.Type)
+			continue
+		}
+		require.Len(t, errs, 1, tc.distribution.Type)
+		assert.ErrorContains(t, errs[0], tc.err)
+	}
+}
+
+func TestValidateArrivalsSeed(t *testing.T) {
+	t.Parallel()
+
+	segment, err := lib.NewExecutionSegmentFromString("0:1/2")
+	require.NoError(t, err)
+	poisson := &ArrivalDistribution{Type: arrivalDistributionPoisson}
+
+	assert.NoError(t, validateArrivalsSeed(nil, "test", lib.Options{ExecutionSegment: segment}))
+	assert.NoError(t, validateArrivalsSeed(
+		&ArrivalDistribution{Type: arrivalDistributionEven}, "test", lib.Options{ExecutionSegment: segment}))
+	assert.NoError(t, validateArrivalsSeed(poisson, "test", lib.Options{}))
+	assert.NoError(t, validateArrivalsSeed(
+		poisson, "test", lib.Options{ExecutionSegment: segment, RandomSeed: null.IntFrom(1)}))
+
+	err = validateArrivalsSeed(poisson, "test", lib.Options{ExecutionSegment: segment})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "randomSeed")
+}
+
+func TestArrivalGapsAverage(t *testing.T) {
+	t.Parallel()
+
+	full, err := lib.NewExecutionTuple(nil, nil)
+	require.NoError(t, err)
+	for _, distribution := range []string{
+		arrivalDistributionPoisson, arrivalDistributionUniform, arrivalDistributionPareto,
+	} {
+		gaps := newArrivalGaps(&ArrivalDistribution{Type: distribution}, "test", null.IntFrom(42))
+		arrivals := gaps.newSequence(full, 0)
+		arrivals.advance(100000)
+		assert.InEpsilon(t, 100000, arrivals.area, 0.05, distribution)
+
+		// the same seed always gives the same arrivals
+		same := gaps.newSequence(full, 0)
+		same.advance(100000)
+		assert.Equal(t, arrivals.area, same.area, distribution)
+	}
+}
+
+func TestArrivalSequenceSkipPast(t *testing.T) {
+	t.Parallel()
+
+	sequence := newExecutionSegmentSequenceFromString("0,1/4,1/3,1/2,1")
+	et, err := lib.NewExecutionTuple(newExecutionSegmentFromString("1/3:1/2"), sequence)
+	require.NoError(t, err)
+
+	gaps := newArrivalGaps(&ArrivalDistribution{Type: arrivalDistributionPoisson}, "test", null.IntFrom(1))
+	for _, g := range []arrivalGaps{{}, gaps} {
+		for _, done := range []float64{0, 0.5, 3, 17.2, 1000, 12345.6} {
+			expected := g.newSequence(et, 1)
+			for expected.area <= done {
+				expected.next()
+			}
+			skipped := g.newSequence(et, 1)
+			skipped.skipPast(done)
+			assert.Equal(t, expected.position, skipped.position, done)
+			assert.Equal(t, expected.area, skipped.area, done)
+		}
+	}
+}
+
+func TestRampingArrivalRatePoissonSegments(t *testing.T) {
+	t.Parallel()
+
+	config := RampingArrivalRateConfig{
+		BaseConfig: BaseConfig{Name: "poisson"},
+		TimeUnit:   types.NullDurationFrom(time.Second),
+		StartRate:  null.IntFrom(5),
+		Stages: []Stage{
+			{Duration: types.NullDurationFrom(10 * time.Second), Target: null.IntFrom(50)},
+			{Duration: types.NullDurationFrom(10 * time.Second), Target: null.IntFrom(20)},
+		},
+		ArrivalDistribution: &ArrivalDistribution{Type: arrivalDistributionPoisson},
+	}
+	getTimes := func(et *lib.ExecutionTuple) []time.Duration {
+		ch := make(chan time.Duration)
+		go config.getSchedule(null.IntFrom(3)).cal(et, ch, nil)
+		var times []time.Duration
+		for t := range ch {
+			times = append(times, t)
+		}
+		return times
+	}
+
+	full, err := lib.NewExecutionTuple(nil, nil)
+	require.NoError(t, err)
+	expected := getTimes(full)
+	// 5 to 50 and then to 20 iterations/s for 10s each
+	assert.InEpsilon(t, 625, len(expected), 0.15)
+
+	// The random arrivals of all of the segments combined are the same as the
+	// ones without segments
+	sequence := newExecutionSegmentSequenceFromString("0,1/4,1/3,1/2,1")
+	var combined []time.Duration
+	for _, segment := range []string{"0:1/4", "1/4:1/3", "1/3:1/2", "1/2:1"} {
+		et, err := lib.NewExecutionTuple(newExecutionSegmentFromString(segment), sequence)
+		require.NoError(t, err)
+		combined = append(combined, getTimes(et)...)
+	}
+	sort.Slice(combined, func(i, j int) bool { return combined[i] < combined[j] })
+	assert.Equal(t, expected, combined)
+}
+
+func TestConstantArrivalRateDescriptionWithDistribution(t *testing.T) {
+	t.Parallel()
+
+	config := getTestConstantArrivalRateConfig()
+	config.ArrivalDistribution = &ArrivalDistribution{Type: arrivalDistributionPoisson}
+	et, err := lib.NewExecutionTuple(nil, nil)
+	require.NoError(t, err)
+	assert.Contains(t, config.GetDescription(et), "with poisson arrivals")
+}
@@ -0,0 +1,207 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+)
+
+// ArrivalRateUpdate is a change of an arrival-rate scenario while it's
+// running. The values are for the whole test, the same as the ones in the
+// config, so every instance of a distributed test applies its own part of
+// them, according to its execution segment. Only the specified values are
+// changed.
+type ArrivalRateUpdate struct {
+	// Rate and Duration can only be changed on the constant-arrival-rate
+	// executor. The duration is from the start of the scenario.
+	Rate     null.Int           `json:"rate"`
+	Duration types.NullDuration `json:"duration"`
+
+	// Stages replace the remaining stages of the ramping-arrival-rate
+	// executor, they start from its current rate.
+	Stages []Stage `json:"stages,omitempty"`
+
+	MaxVUs null.Int `json:"maxVUs"`
+
+	// Stop ends the scenario now, its iterations can still finish during
+	// the graceful stop.
+	Stop bool `json:"stop,omitempty"`
+}
+
+// LiveArrivalRate is implemented by the arrival-rate executors, which can be
+// changed while they are running, by passing an ArrivalRateUpdate to their
+// UpdateConfig() method.
+type LiveArrivalRate interface {
+	lib.LiveUpdatableExecutor
+	// GetCurrentConfig returns the config with all of the updates so far.
+	GetCurrentConfig() lib.ExecutorConfig
+}
+
+var errScenarioEnded = errors.New("the scenario has already ended")
+
+type arrivalRateUpdateEvent struct {
+	update ArrivalRateUpdate
+	err    chan error
+}
+
+// arrivalRateControl keeps the current config of an arrival-rate executor and
+// passes the updates to its Run() loop, once it has started.
+type arrivalRateControl struct {
+	updateLock sync.Mutex // only one update is applied at a time
+	configLock sync.RWMutex
+	config     lib.ExecutorConfig
+
+	updates    chan arrivalRateUpdateEvent
+	hasStarted chan struct{}
+	hasEnded   chan struct{}
+	endOnce    sync.Once
+
+	// beyondPlanVUs is the number of the VUs over the execution plan, that
+	// were initialized after maxVUs was raised and are still in use.
+	beyondPlanVUs int64
+}
+
+func newArrivalRateControl(config lib.ExecutorConfig) *arrivalRateControl {
+	return &arrivalRateControl{
+		config:     config,
+		updates:    make(chan arrivalRateUpdateEvent),
+		hasStarted: make(chan struct{}),
+		hasEnded:   make(chan struct{}),
+	}
+}
+
+func (c *arrivalRateControl) getConfig() lib.ExecutorConfig {
+	c.configLock.RLock()
+	defer c.configLock.RUnlock()
+	return c.config
+}
+
+func (c *arrivalRateControl) setConfig(config lib.ExecutorConfig) {
+	c.configLock.Lock()
+	defer c.configLock.Unlock()
+	c.config = config
+}
+
+// start is called by Run() and returns the config it should use, which
+// includes the updates that were made before the executor started.
+func (c *arrivalRateControl) start() lib.ExecutorConfig {
+	c.configLock.Lock()
+	defer c.configLock.Unlock()
+	close(c.hasStarted)
+	return c.config
+}
+
+// end is called when the regular duration of Run() is over or when it returns
+// early, the updates after that are rejected.
+func (c *arrivalRateControl) end() {
+	c.endOnce.Do(func() { close(c.hasEnded) })
+}
+
+// update applies the update with the given function directly to the config, if
+// the executor hasn't started yet, otherwise it's applied by its Run() loop.
+func (c *arrivalRateControl) update(
+	ctx context.Context, update ArrivalRateUpdate,
+	apply func(lib.ExecutorConfig) (lib.ExecutorConfig, error),
+) error {
+	c.updateLock.Lock()
+	defer c.updateLock.Unlock()
+
+	c.configLock.Lock() // guard against a simultaneous start of the executor
+	select {
+	case <-c.hasStarted:
+		c.configLock.Unlock()
+	default:
+		defer c.configLock.Unlock()
+		if update.Stop {
+			return errors.New("the scenario can't be stopped before it has started")
+		}
+		config, err := apply(c.config)
+		if err != nil {
+			return err
+		}
+		c.config = config
+		return nil
+	}
+
+	event := arrivalRateUpdateEvent{update: update, err: make(chan error, 1)}
+	select {
+	case c.updates <- event:
+	case <-c.hasEnded:
+		return errScenarioEnded
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-event.err:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// getUnplannedVU returns a new VU for the executor. The VUs beyond the
+// execution plan aren't taken into account by the execution state, so they
+// are always initialized anew.
+func (c *arrivalRateControl) getUnplannedVU(
+	ctx context.Context, es *lib.ExecutionState, logger *logrus.Entry, beyondPlan bool,
+) (lib.InitializedVU, error) {
+	if !beyondPlan {
+		return es.GetUnplannedVU(ctx, logger)
+	}
+	logger.Debug("Initializing a VU beyond the execution plan, this may affect test results")
+	initVU, err := es.InitializeNewVU(ctx, logger)
+	if err == nil {
+		atomic.AddInt64(&c.beyondPlanVUs, 1)
+	}
+	return initVU, err
+}
+
+// returnVU returns the VU to the buffer of the execution state, unless it's
+// one of the VUs beyond the execution plan, which don't fit in there.
+func (c *arrivalRateControl) returnVU(es *lib.ExecutionState, initVU lib.InitializedVU) {
+	if atomic.AddInt64(&c.beyondPlanVUs, -1) >= 0 {
+		es.ModInitializedVUsCount(-1)
+		return
+	}
+	atomic.AddInt64(&c.beyondPlanVUs, 1)
+	es.ReturnVU(initVU, false)
+}
+
+// getLiveDurationContexts is like getDurationContexts, but the end of the
+// regular duration can be moved while the executor is running, with the
+// returned setDuration function. It returns false if the regular duration is
+// already over. The graceful stop always starts at the end of the regular
+// duration, whenever that is.
+func getLiveDurationContexts(parentCtx context.Context, regularDuration, gracefulStop time.Duration) (
+	startTime time.Time, maxDurationCtx, regDurationCtx context.Context, maxDurationCancel func(),
+	setDuration func(time.Duration) bool,
+) {
+	startTime = time.Now()
+	maxDurationCtx, cancelMax := context.WithCancel(parentCtx)
+	regDurationCtx, cancelReg := context.WithCancel(maxDurationCtx)
+
+	regularEnd := time.AfterFunc(regularDuration, func() {
+		cancelReg()
+		time.AfterFunc(gracefulStop, cancelMax)
+	})
+	maxDurationCancel = func() {
+		regularEnd.Stop()
+		cancelMax()
+	}
+	setDuration = func(d time.Duration) bool {
+		if !regularEnd.Stop() {
+			return false
+		}
+		regularEnd.Reset(time.Until(startTime.Add(d)))
+		return true
+	}
+	return startTime, maxDurationCtx, regDurationCtx, maxDurationCancel, setDuration
+}
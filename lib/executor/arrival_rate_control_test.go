@@ -0,0 +1,233 @@
+package executor
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+func TestArrivalRateUpdateBeforeStart(t *testing.T) {
+	t.Parallel()
+
+	runner := simpleRunner(func(_ context.Context, _ *lib.State) error { return nil })
+	ctx := context.Background()
+
+	constantConfig := getTestConstantArrivalRateConfig()
+	constantConfig.Name, constantConfig.Type = "constant", constantArrivalRateType
+	car, ok := setupExecutorTest(t, "", "", lib.Options{}, runner, constantConfig).executor.(*ConstantArrivalRate)
+	require.True(t, ok)
+	require.NoError(t, car.UpdateConfig(ctx, ArrivalRateUpdate{
+		Rate: null.IntFrom(100), Duration: types.NullDurationFrom(time.Minute), MaxVUs: null.IntFrom(30),
+	}))
+	constantConfig, ok = car.GetCurrentConfig().(*ConstantArrivalRateConfig)
+	require.True(t, ok)
+	assert.Equal(t, null.IntFrom(100), constantConfig.Rate)
+	assert.Equal(t, types.NullDurationFrom(time.Minute), constantConfig.Duration)
+	assert.Equal(t, null.IntFrom(30), constantConfig.MaxVUs)
+	assert.Equal(t, null.IntFrom(50), car.config.Rate, "the original config is kept")
+
+	assert.ErrorContains(t, car.UpdateConfig(ctx, ArrivalRateUpdate{MaxVUs: null.IntFrom(5)}),
+		"maxVUs can't be less than preAllocatedVUs")
+	assert.ErrorContains(t, car.UpdateConfig(ctx, ArrivalRateUpdate{Stages: []Stage{}}),
+		"doesn't have stages")
+	assert.ErrorContains(t, car.UpdateConfig(ctx, ArrivalRateUpdate{Stop: true}),
+		"can't be stopped before it has started")
+	assert.ErrorContains(t, car.UpdateConfig(ctx, ExternallyControlledConfigParams{}), "invalid config type")
+
+	rampingConfig := getTestRampingArrivalRateConfig()
+	rampingConfig.Name, rampingConfig.Type = "ramping", rampingArrivalRateType
+	varr, ok := setupExecutorTest(t, "", "", lib.Options{}, runner, rampingConfig).executor.(*RampingArrivalRate)
+	require.True(t, ok)
+	stages := []Stage{{Duration: types.NullDurationFrom(time.Second), Target: null.IntFrom(10)}}
+	require.NoError(t, varr.UpdateConfig(ctx, ArrivalRateUpdate{Stages: stages}))
+	rampingConfig, ok = varr.GetCurrentConfig().(*RampingArrivalRateConfig)
+	require.True(t, ok)
+	assert.Equal(t, stages, rampingConfig.Stages)
+
+	assert.ErrorContains(t, varr.UpdateConfig(ctx, ArrivalRateUpdate{Rate: null.IntFrom(10)}),
+		"the remaining stages can be changed instead")
+	assert.ErrorContains(t, varr.UpdateConfig(ctx, ArrivalRateUpdate{Stages: []Stage{{}}}),
+		"invalid stages supplied")
+}
+
+func TestConstantArrivalRateUpdate(t *testing.T) {
+	t.Parallel()
+
+	var count int64
+	runner := simpleRunner(func(_ context.Context, _ *lib.State) error {
+		atomic.AddInt64(&count, 1)
+		return nil
+	})
+
+	config := getTestConstantArrivalRateConfig()
+	config.Name, config.Type = "constant", constantArrivalRateType
+	config.Rate = null.IntFrom(10)
+	config.Duration = types.NullDurationFrom(time.Minute)
+	test := setupExecutorTest(t, "", "", lib.Options{}, runner, config)
+	defer test.cancel()
+	car, ok := test.executor.(*ConstantArrivalRate)
+	require.True(t, ok)
+
+	errCh := make(chan error)
+	startTime := time.Now()
+	go func() { errCh <- test.executor.Run(test.ctx, make(chan metrics.SampleContainer, 1000)) }()
+
+	time.Sleep(time.Second)
+	require.NoError(t, car.UpdateConfig(test.ctx, ArrivalRateUpdate{Rate: null.IntFrom(50)}))
+	before := atomic.LoadInt64(&count)
+	assert.InDelta(t, 10, before, 2)
+	time.Sleep(time.Second)
+	assert.InDelta(t, 50, atomic.LoadInt64(&count)-before, 5)
+
+	err := car.UpdateConfig(test.ctx, ArrivalRateUpdate{Duration: types.NullDurationFrom(time.Second)})
+	assert.ErrorContains(t, err, "the scenario has already run for")
+	err = car.UpdateConfig(test.ctx, ArrivalRateUpdate{MaxVUs: null.IntFrom(5)})
+	assert.ErrorContains(t, err, "maxVUs can't be less than preAllocatedVUs")
+
+	require.NoError(t, car.UpdateConfig(test.ctx, ArrivalRateUpdate{Stop: true}))
+	require.NoError(t, <-errCh)
+	assert.Less(t, time.Since(startTime), 5*time.Second)
+	progress, _ := car.GetProgress().Progress()
+	assert.Equal(t, 1.0, progress)
+
+	currentConfig, ok := car.GetCurrentConfig().(*ConstantArrivalRateConfig)
+	require.True(t, ok)
+	assert.Equal(t, null.IntFrom(50), currentConfig.Rate)
+	assert.ErrorIs(t, car.UpdateConfig(test.ctx, ArrivalRateUpdate{Rate: null.IntFrom(10)}), errScenarioEnded)
+}
+
+func TestConstantArrivalRateUpdateMaxVUsBeyondPlan(t *testing.T) {
+	t.Parallel()
+
+	runner := simpleRunner(func(_ context.Context, _ *lib.State) error {
+		time.Sleep(200 * time.Millisecond)
+		return nil
+	})
+
+	config := getTestConstantArrivalRateConfig()
+	config.Name, config.Type = "constant", constantArrivalRateType
+	config.Rate = null.IntFrom(20)
+	config.Duration = types.NullDurationFrom(2 * time.Second)
+	config.GracefulStop = types.NullDurationFrom(time.Second)
+	config.PreAllocatedVUs = null.IntFrom(1)
+	config.MaxVUs = null.IntFrom(1)
+	test := setupExecutorTest(t, "", "", lib.Options{}, runner, config)
+	defer test.cancel()
+	car, ok := test.executor.(*ConstantArrivalRate)
+	require.True(t, ok)
+
+	errCh := make(chan error)
+	go func() { errCh <- test.executor.Run(test.ctx, make(chan metrics.SampleContainer, 1000)) }()
+
+	time.Sleep(500 * time.Millisecond)
+	require.NoError(t, car.UpdateConfig(test.ctx, ArrivalRateUpdate{MaxVUs: null.IntFrom(6)}))
+	time.Sleep(time.Second)
+	assert.Greater(t, test.state.GetInitializedVUsCount(), int64(1))
+
+	// the VUs beyond the plan don't fit in the buffer, so they are discarded
+	require.NoError(t, <-errCh)
+	assert.Equal(t, int64(1), test.state.GetInitializedVUsCount())
+	assert.Equal(t, int64(0), car.control.beyondPlanVUs)
+}
+
+func TestRampingArrivalRateUpdateStages(t *testing.T) {
+	t.Parallel()
+
+	var count int64
+	runner := simpleRunner(func(_ context.Context, _ *lib.State) error {
+		atomic.AddInt64(&count, 1)
+		return nil
+	})
+
+	config := &RampingArrivalRateConfig{
+		BaseConfig: BaseConfig{
+			Name: "ramping", Type: rampingArrivalRateType, GracefulStop: types.NullDurationFrom(0),
+		},
+		TimeUnit:        types.NullDurationFrom(time.Second),
+		StartRate:       null.IntFrom(10),
+		Stages:          []Stage{{Duration: types.NullDurationFrom(time.Minute), Target: null.IntFrom(10)}},
+		PreAllocatedVUs: null.IntFrom(10),
+		MaxVUs:          null.IntFrom(20),
+	}
+	test := setupExecutorTest(t, "", "", lib.Options{}, runner, config)
+	defer test.cancel()
+	varr, ok := test.executor.(*RampingArrivalRate)
+	require.True(t, ok)
+
+	errCh := make(chan error)
+	startTime := time.Now()
+	go func() { errCh <- test.executor.Run(test.ctx, make(chan metrics.SampleContainer, 1000)) }()
+
+	time.Sleep(time.Second)
+	require.NoError(t, varr.UpdateConfig(test.ctx, ArrivalRateUpdate{
+		Stages: []Stage{{Duration: types.NullDurationFrom(time.Second), Target: null.IntFrom(50)}},
+	}))
+	before := atomic.LoadInt64(&count)
+	assert.InDelta(t, 10, before, 2)
+
+	require.NoError(t, <-errCh)
+	assert.InDelta(t, 2*time.Second, time.Since(startTime), float64(500*time.Millisecond))
+	// ramping up from 10 to 50 iterations/s for 1s
+	assert.InDelta(t, 30, atomic.LoadInt64(&count)-before, 3)
+
+	currentConfig, ok := varr.GetCurrentConfig().(*RampingArrivalRateConfig)
+	require.True(t, ok)
+	require.Len(t, currentConfig.Stages, 2)
+	assert.InDelta(t, time.Second, currentConfig.Stages[0].Duration.TimeDuration(), float64(200*time.Millisecond))
+	assert.Equal(t, null.IntFrom(10), currentConfig.Stages[0].Target)
+	assert.Equal(t, null.IntFrom(50), currentConfig.Stages[1].Target)
+}
+
+func TestRampingArrivalRateScheduleUpdateSegments(t *testing.T) {
+	t.Parallel()
+
+	config := RampingArrivalRateConfig{
+		TimeUnit:  types.NullDurationFrom(time.Second),
+		StartRate: null.IntFrom(5),
+		Stages: []Stage{
+			{Duration: types.NullDurationFrom(10 * time.Second), Target: null.IntFrom(50)},
+			{Duration: types.NullDurationFrom(10 * time.Second), Target: null.IntFrom(50)},
+		},
+	}
+	schedule := config.getSchedule(null.Int{}).update(7*time.Second+300*time.Millisecond, []Stage{
+		{Duration: types.NullDurationFrom(5 * time.Second), Target: null.IntFrom(100)},
+		{Duration: types.NullDurationFrom(5 * time.Second), Target: null.IntFrom(0)},
+	})
+	getTimes := func(et *lib.ExecutionTuple) []time.Duration {
+		ch := make(chan time.Duration)
+		go schedule.cal(et, ch, nil)
+		var times []time.Duration
+		for t := range ch {
+			times = append(times, t)
+		}
+		return times
+	}
+
+	full, err := lib.NewExecutionTuple(nil, nil)
+	require.NoError(t, err)
+	expected := getTimes(full)
+	require.NotEmpty(t, expected)
+	assert.GreaterOrEqual(t, expected[0], 7*time.Second+300*time.Millisecond)
+
+	// The updated schedules of all of the segments combined are the same as
+	// the one without segments, even though the update is in the middle
+	sequence := newExecutionSegmentSequenceFromString("0,1/4,1/3,1/2,1")
+	var combined []time.Duration
+	for _, segment := range []string{"0:1/4", "1/4:1/3", "1/3:1/2", "1/2:1"} {
+		et, err := lib.NewExecutionTuple(newExecutionSegmentFromString(segment), sequence)
+		require.NoError(t, err)
+		combined = append(combined, getTimes(et)...)
+	}
+	sort.Slice(combined, func(i, j int) bool { return combined[i] < combined[j] })
+	assert.Equal(t, expected, combined)
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 	"go.k6.io/k6/lib"
 	"go.k6.io/k6/lib/consts"
 	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
 )
 
 // DefaultGracefulStopValue is the graceful top value for all executors, unless
@@ -30,10 +32,37 @@ type BaseConfig struct {
 	StartTime    types.NullDuration   `json:"startTime"`
 	GracefulStop types.NullDuration   `json:"gracefulStop"`
 	Env          map[string]string    `json:"env"`
-	Exec         null.String          `json:"exec"` // function name, externally validated
+	Exec         ScenarioExec         `json:"exec"` // function name or traffic mix
 	Tags         map[string]string    `json:"tags"`
 	Options      *lib.ScenarioOptions `json:"options,omitempty"`
 
+	// StartAfter and StartWhen delay the start of the scenario until the
+	// given scenarios have ended, and until the conditions on the metrics,
+	// which are written like the thresholds, hold. The StartTime is counted
+	// from then.
+	StartAfter []string            `json:"startAfter,omitempty"`
+	StartWhen  map[string][]string `json:"startWhen,omitempty"`
+
+	// Setup and Teardown are exported functions, externally validated, which
+	// run once before and after the scenario. The value that Setup returns is
+	// passed to the Exec function and to Teardown, instead of the one of the
+	// global setup(). Their timeouts are the global ones, unless specified.
+	// The scenarios that start after this one don't wait for its Teardown.
+	Setup           null.String        `json:"setup"`
+	SetupTimeout    types.NullDuration `json:"setupTimeout"`
+	Teardown        null.String        `json:"teardown"`
+	TeardownTimeout types.NullDuration `json:"teardownTimeout"`
+
+	// ThinkTime is the possibly random time that every VU waits after each
+	// of its iterations. Pacing is the cadence at which every VU starts its
+	// iterations instead, the slots of the iterations that start late are
+	// skipped. Only one of them can be used.
+	ThinkTime *types.ThinkTime   `json:"thinkTime,omitempty"`
+	Pacing    types.NullDuration `json:"pacing"`
+
+	// Retry is the policy by which the failed iterations are retried, if any.
+	Retry *lib.RetryPolicy `json:"retry,omitempty"`
+
 	// TODO: future extensions like distribution, others?
 }
 
@@ -56,12 +85,16 @@ func (bc BaseConfig) Validate() (result []error) {
 	if !scenarioNameWhitelist.MatchString(bc.Name) {
 		result = append(result, errors.New(scenarioNameErr))
 	}
-	if bc.Exec.Valid && bc.Exec.String == "" {
-		result = append(result, errors.New("exec value cannot be empty"))
-	}
+	result = append(result, bc.Exec.Validate()...)
 	if bc.Type == "" {
 		result = append(result, errors.New("missing or empty type field"))
 	}
+	if bc.Setup.Valid && bc.Setup.String == "" {
+		result = append(result, errors.New("setup value cannot be empty"))
+	}
+	if bc.Teardown.Valid && bc.Teardown.String == "" {
+		result = append(result, errors.New("teardown value cannot be empty"))
+	}
 	// The actually reasonable checks:
 	if bc.StartTime.Duration < 0 {
 		result = append(result, errors.New("the startTime can't be negative"))
@@ -69,6 +102,47 @@ func (bc BaseConfig) Validate() (result []error) {
 	if bc.GracefulStop.Duration < 0 {
 		result = append(result, errors.New("the gracefulStop timeout can't be negative"))
 	}
+	if bc.SetupTimeout.Valid && bc.SetupTimeout.Duration <= 0 {
+		result = append(result, errors.New("the setupTimeout should be more than 0"))
+	}
+	if bc.TeardownTimeout.Valid && bc.TeardownTimeout.Duration <= 0 {
+		result = append(result, errors.New("the teardownTimeout should be more than 0"))
+	}
+	if bc.ThinkTime != nil {
+		if err := bc.ThinkTime.Validate(); err != nil {
+			result = append(result, fmt.Errorf("invalid thinkTime: %w", err))
+		}
+	}
+	if bc.Pacing.Valid && bc.Pacing.Duration <= 0 {
+		result = append(result, errors.New("the pacing should be more than 0"))
+	}
+	if bc.ThinkTime != nil && bc.Pacing.Valid {
+		result = append(result, errors.New("the thinkTime and the pacing can't be used together"))
+	}
+	if bc.Retry != nil {
+		if err := bc.Retry.Validate(); err != nil {
+			result = append(result, fmt.Errorf("invalid retry: %w", err))
+		}
+	}
+	for _, dependency := range bc.StartAfter {
+		if dependency == bc.Name {
+			result = append(result, errors.New("the scenario can't start after itself"))
+		}
+	}
+	for metric, conditions := range bc.StartWhen {
+		if _, _, err := metrics.ParseMetricName(metric); err != nil {
+			result = append(result, fmt.Errorf("invalid metric %q in startWhen: %w", metric, err))
+			continue
+		}
+		if len(conditions) == 0 {
+			result = append(result, fmt.Errorf("the startWhen conditions on the metric %s are empty", metric))
+		}
+		for _, condition := range conditions {
+			if _, err := parseMetricCondition(condition); err != nil {
+				result = append(result, fmt.Errorf("invalid startWhen condition on the metric %s: %w", metric, err))
+			}
+		}
+	}
 	return result
 }
 
@@ -88,6 +162,18 @@ func (bc BaseConfig) GetStartTime() time.Duration {
 	return bc.StartTime.TimeDuration()
 }
 
+// GetStartAfter returns the names of the scenarios that have to end before
+// the scenario starts.
+func (bc BaseConfig) GetStartAfter() []string {
+	return bc.StartAfter
+}
+
+// GetStartWhen returns the conditions on the metrics that have to hold
+// before the scenario starts.
+func (bc BaseConfig) GetStartWhen() map[string][]string {
+	return bc.StartWhen
+}
+
 // GetGracefulStop returns how long k6 is supposed to wait for any still
 // running iterations to finish executing at the end of the normal executor
 // duration, before it actually kills them.
@@ -104,15 +190,54 @@ func (bc BaseConfig) GetEnv() map[string]string {
 	return bc.Env
 }
 
-// GetExec returns the configured custom exec value, if any.
+// GetExec returns the configured custom exec value, if any. It's empty if the
+// scenario has a traffic mix instead.
 func (bc BaseConfig) GetExec() string {
-	exec := bc.Exec.ValueOrZero()
+	if bc.Exec.Weights != nil {
+		return ""
+	}
+	exec := bc.Exec.Fn.ValueOrZero()
 	if exec == "" {
 		exec = consts.DefaultFn
 	}
 	return exec
 }
 
+// GetExecWeights returns the functions of the traffic mix, if it's configured,
+// sorted by their names.
+func (bc BaseConfig) GetExecWeights() []lib.ExecWeight {
+	return bc.Exec.getWeights()
+}
+
+// GetSetup returns the function that runs once before the scenario, if any,
+// and its timeout, if it's specified.
+func (bc BaseConfig) GetSetup() (string, types.NullDuration) {
+	return bc.Setup.ValueOrZero(), bc.SetupTimeout
+}
+
+// GetTeardown returns the function that runs once after the scenario, if any,
+// and its timeout, if it's specified.
+func (bc BaseConfig) GetTeardown() (string, types.NullDuration) {
+	return bc.Teardown.ValueOrZero(), bc.TeardownTimeout
+}
+
+// GetThinkTime returns the think time after every iteration, if any.
+func (bc BaseConfig) GetThinkTime() *types.ThinkTime {
+	return bc.ThinkTime
+}
+
+// GetPacing returns the cadence at which every VU starts its iterations, if
+// it's specified.
+func (bc BaseConfig) GetPacing() time.Duration {
+	return bc.Pacing.TimeDuration()
+}
+
+// GetRetry returns the policy by which the failed iterations are retried, if
+// any.
+func (bc BaseConfig) GetRetry() *lib.RetryPolicy {
+	return bc.Retry
+}
+
 // GetScenarioOptions returns the options specific to a scenario.
 func (bc BaseConfig) GetScenarioOptions() *lib.ScenarioOptions {
 	return bc.Options
@@ -129,10 +254,46 @@ func (bc BaseConfig) IsDistributable() bool {
 	return true
 }
 
+// getResumedBaseConfig returns the base config of the rest of the work of the
+// executor after the checkpoint. The executors which had started continue
+// right away, and the other ones wait for the rest of their startTime.
+func (bc BaseConfig) getResumedBaseConfig(checkpoint lib.ExecutorCheckpoint) BaseConfig {
+	elapsed := time.Duration(checkpoint.Elapsed)
+	if !checkpoint.Started {
+		bc.StartTime = types.NullDurationFrom(max(0, bc.StartTime.TimeDuration()-elapsed))
+		return bc
+	}
+	bc.StartTime = types.NullDurationFrom(0)
+	bc.StartAfter = nil
+	bc.StartWhen = nil
+	return bc
+}
+
 // getBaseInfo is a helper method for the "parent" String methods.
 func (bc BaseConfig) getBaseInfo(facts ...string) string {
-	if bc.Exec.Valid {
-		facts = append(facts, fmt.Sprintf("exec: %s", bc.Exec.String))
+	if execInfo := bc.Exec.getInfo(); execInfo != "" {
+		facts = append(facts, fmt.Sprintf("exec: %s", execInfo))
+	}
+	if bc.Setup.Valid {
+		facts = append(facts, fmt.Sprintf("setup: %s", bc.Setup.String))
+	}
+	if bc.Teardown.Valid {
+		facts = append(facts, fmt.Sprintf("teardown: %s", bc.Teardown.String))
+	}
+	if bc.ThinkTime != nil {
+		facts = append(facts, fmt.Sprintf("thinkTime: %s", bc.ThinkTime))
+	}
+	if bc.Pacing.Valid {
+		facts = append(facts, fmt.Sprintf("pacing: %s", bc.Pacing.Duration))
+	}
+	if bc.Retry != nil {
+		facts = append(facts, fmt.Sprintf("retry: %s", bc.Retry))
+	}
+	if len(bc.StartAfter) > 0 {
+		facts = append(facts, fmt.Sprintf("startAfter: %s", strings.Join(bc.StartAfter, ", ")))
+	}
+	if len(bc.StartWhen) > 0 {
+		facts = append(facts, "startWhen: "+bc.getStartWhenInfo())
 	}
 	if bc.StartTime.Duration > 0 {
 		facts = append(facts, fmt.Sprintf("startTime: %s", bc.StartTime.Duration))
@@ -145,3 +306,17 @@ func (bc BaseConfig) getBaseInfo(facts ...string) string {
 	}
 	return " (" + strings.Join(facts, ", ") + ")"
 }
+
+// getStartWhenInfo returns the startWhen conditions, sorted by the metrics.
+func (bc BaseConfig) getStartWhenInfo() string {
+	metricNames := make([]string, 0, len(bc.StartWhen))
+	for metric := range bc.StartWhen {
+		metricNames = append(metricNames, metric)
+	}
+	sort.Strings(metricNames)
+	conditions := make([]string, len(metricNames))
+	for i, metric := range metricNames {
+		conditions[i] = fmt.Sprintf("%s %s", metric, strings.Join(bc.StartWhen[metric], " && "))
+	}
+	return strings.Join(conditions, " && ")
+}
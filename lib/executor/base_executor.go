@@ -22,6 +22,7 @@ type BaseExecutor struct {
 	executionState *lib.ExecutionState
 	iterSegIndexMx *sync.Mutex
 	iterSegIndex   *lib.SegmentedIndex
+	iterStarted    uint64 // the unscaled counter of the last started iteration
 	logger         *logrus.Entry
 	progress       *pb.ProgressBar
 }
@@ -47,9 +48,19 @@ func (bs *BaseExecutor) nextIterationCounters() (uint64, uint64) {
 	bs.iterSegIndexMx.Lock()
 	defer bs.iterSegIndexMx.Unlock()
 	scaled, unscaled := bs.iterSegIndex.Next()
+	bs.iterStarted = uint64(unscaled)
 	return uint64(scaled - 1), uint64(unscaled - 1)
 }
 
+// GetStartedIterations returns the number of iterations the executor has
+// started in all of the instances of the test, as far as its execution segment
+// is concerned.
+func (bs *BaseExecutor) GetStartedIterations() uint64 {
+	bs.iterSegIndexMx.Lock()
+	defer bs.iterSegIndexMx.Unlock()
+	return bs.iterStarted
+}
+
 // Init doesn't do anything for most executors, since initialization of all
 // planned VUs is handled by the executor.
 func (bs *BaseExecutor) Init(_ context.Context) error {
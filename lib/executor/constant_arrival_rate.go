@@ -2,6 +2,7 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"math/big"
@@ -43,6 +44,10 @@ type ConstantArrivalRateConfig struct {
 	// absolutely hard limit on the number of VUs the executor will use
 	PreAllocatedVUs null.Int `json:"preAllocatedVUs"`
 	MaxVUs          null.Int `json:"maxVUs"`
+
+	// ArrivalDistribution randomizes the times between the iterations, they
+	// are evenly spaced if it isn't specified
+	ArrivalDistribution *ArrivalDistribution `json:"arrivalDistribution,omitempty"`
 }
 
 // NewConstantArrivalRateConfig returns a ConstantArrivalRateConfig with default values
@@ -53,8 +58,13 @@ func NewConstantArrivalRateConfig(name string) *ConstantArrivalRateConfig {
 	}
 }
 
-// Make sure we implement the lib.ExecutorConfig interface
-var _ lib.ExecutorConfig = &ConstantArrivalRateConfig{}
+// Make sure we implement the lib.ExecutorConfig, lib.ArrivalRateConfig and
+// lib.ResumableExecutorConfig interfaces
+var (
+	_ lib.ExecutorConfig          = &ConstantArrivalRateConfig{}
+	_ lib.ArrivalRateConfig       = &ConstantArrivalRateConfig{}
+	_ lib.ResumableExecutorConfig = &ConstantArrivalRateConfig{}
+)
 
 // GetPreAllocatedVUs is just a helper method that returns the scaled pre-allocated VUs.
 func (carc ConstantArrivalRateConfig) GetPreAllocatedVUs(et *lib.ExecutionTuple) int64 {
@@ -83,8 +93,8 @@ func (carc ConstantArrivalRateConfig) GetDescription(et *lib.ExecutionTuple) str
 		arrRatePerSec, _ = getArrivalRatePerSec(arrRate).Float64()
 	}
 
-	return fmt.Sprintf("%.2f iterations/s for %s%s", arrRatePerSec, carc.Duration.Duration,
-		carc.getBaseInfo(maxVUsRange))
+	return fmt.Sprintf("%.2f iterations/s%s for %s%s", arrRatePerSec, getArrivalsInfo(carc.ArrivalDistribution),
+		carc.Duration.Duration, carc.getBaseInfo(maxVUsRange))
 }
 
 // Validate makes sure all options are configured and valid
@@ -121,6 +131,10 @@ func (carc *ConstantArrivalRateConfig) Validate() []error {
 		errors = append(errors, fmt.Errorf("maxVUs can't be less than preAllocatedVUs"))
 	}
 
+	if carc.ArrivalDistribution != nil {
+		errors = append(errors, carc.ArrivalDistribution.Validate()...)
+	}
+
 	return errors
 }
 
@@ -143,6 +157,29 @@ func (carc ConstantArrivalRateConfig) GetExecutionRequirements(et *lib.Execution
 	}
 }
 
+// GetArrivalRate returns the expected iterations per second of the execution
+// segment at the given time from the start of the executor.
+func (carc ConstantArrivalRateConfig) GetArrivalRate(et *lib.ExecutionTuple, t time.Duration) float64 {
+	if t < 0 || t >= carc.Duration.TimeDuration() {
+		return 0
+	}
+	arrRatePerSec, _ := getArrivalRatePerSec(
+		getScaledArrivalRate(et.Segment, carc.Rate.Int64, carc.TimeUnit.TimeDuration()),
+	).Float64()
+	return arrRatePerSec
+}
+
+// GetResumedConfig returns the config of the rest of the duration after the
+// checkpoint, or nil if the executor has run for all of it.
+func (carc ConstantArrivalRateConfig) GetResumedConfig(checkpoint lib.ExecutorCheckpoint) lib.ExecutorConfig {
+	carc.BaseConfig = carc.getResumedBaseConfig(checkpoint)
+	carc.Duration = getResumedDuration(carc.Duration, checkpoint)
+	if carc.Duration.TimeDuration() <= 0 {
+		return nil
+	}
+	return &carc
+}
+
 // NewExecutor creates a new ConstantArrivalRate executor
 func (carc ConstantArrivalRateConfig) NewExecutor(
 	es *lib.ExecutionState, logger *logrus.Entry,
@@ -150,6 +187,7 @@ func (carc ConstantArrivalRateConfig) NewExecutor(
 	return &ConstantArrivalRate{
 		BaseExecutor: NewBaseExecutor(&carc, es, logger),
 		config:       carc,
+		control:      newArrivalRateControl(&carc),
 	}, nil
 }
 
@@ -158,19 +196,66 @@ func (carc ConstantArrivalRateConfig) HasWork(et *lib.ExecutionTuple) bool {
 	return carc.GetMaxVUs(et) > 0
 }
 
+// withUpdate returns the config with the update applied.
+func (carc ConstantArrivalRateConfig) withUpdate(update ArrivalRateUpdate) (*ConstantArrivalRateConfig, error) {
+	if update.Stages != nil {
+		return nil, fmt.Errorf("the %s executor doesn't have stages", constantArrivalRateType)
+	}
+	if update.Rate.Valid {
+		carc.Rate = update.Rate
+	}
+	if update.Duration.Valid {
+		carc.Duration = update.Duration
+	}
+	if update.MaxVUs.Valid {
+		carc.MaxVUs = update.MaxVUs
+	}
+	if errs := carc.Validate(); len(errs) != 0 {
+		return nil, fmt.Errorf("invalid configuration supplied: %s", lib.ConcatErrors(errs, ", "))
+	}
+	return &carc, nil
+}
+
 // ConstantArrivalRate tries to execute a specific number of iterations for a
-// specific period.
+// specific period. Its rate, duration and maxVUs can be changed while it's
+// running, it implements the LiveArrivalRate interface.
 type ConstantArrivalRate struct {
 	*BaseExecutor
-	config ConstantArrivalRateConfig
-	et     *lib.ExecutionTuple
+	config  ConstantArrivalRateConfig
+	et      *lib.ExecutionTuple
+	control *arrivalRateControl
+}
+
+// Make sure we implement the lib.Executor and LiveArrivalRate interfaces.
+var (
+	_ lib.Executor    = &ConstantArrivalRate{}
+	_ LiveArrivalRate = &ConstantArrivalRate{}
+)
+
+// GetCurrentConfig returns the config of the executor with all of the updates
+// so far.
+func (car *ConstantArrivalRate) GetCurrentConfig() lib.ExecutorConfig {
+	return car.control.getConfig()
 }
 
-// Make sure we implement the lib.Executor interface.
-var _ lib.Executor = &ConstantArrivalRate{}
+// UpdateConfig changes the rate, duration or maxVUs of the executor, or stops
+// it, with the supplied ArrivalRateUpdate.
+func (car *ConstantArrivalRate) UpdateConfig(ctx context.Context, newConf interface{}) error {
+	update, ok := newConf.(ArrivalRateUpdate)
+	if !ok {
+		return errors.New("invalid config type")
+	}
+	return car.control.update(ctx, update, func(config lib.ExecutorConfig) (lib.ExecutorConfig, error) {
+		return config.(*ConstantArrivalRateConfig).withUpdate(update) //nolint:forcetypeassert
+	})
+}
 
 // Init values needed for the execution
 func (car *ConstantArrivalRate) Init(_ context.Context) error {
+	err := validateArrivalsSeed(car.config.ArrivalDistribution, car.config.Name, car.executionState.Test.Options)
+	if err != nil {
+		return err
+	}
 	// err should always be nil, because Init() won't be called for executors
 	// with no work, as determined by their config's HasWork() method.
 	et, err := car.BaseExecutor.executionState.ExecutionTuple.GetNewExecutionTupleFromValue(car.config.MaxVUs.Int64)
@@ -193,26 +278,29 @@ func (car *ConstantArrivalRate) Init(_ context.Context) error {
 //
 //nolint:funlen
 func (car ConstantArrivalRate) Run(parentCtx context.Context, out chan<- metrics.SampleContainer) (err error) {
-	gracefulStop := car.config.GetGracefulStop()
-	duration := car.config.Duration.TimeDuration()
-	preAllocatedVUs := car.config.GetPreAllocatedVUs(car.executionState.ExecutionTuple)
-	maxVUs := car.config.GetMaxVUs(car.executionState.ExecutionTuple)
+	config := *car.control.start().(*ConstantArrivalRateConfig) //nolint:forcetypeassert
+	gracefulStop := config.GetGracefulStop()
+	duration := config.Duration.TimeDuration()
+	preAllocatedVUs := config.GetPreAllocatedVUs(car.executionState.ExecutionTuple)
+	maxVUs := config.GetMaxVUs(car.executionState.ExecutionTuple)
+	plannedMaxVUs := car.config.GetMaxVUs(car.executionState.ExecutionTuple)
 	// TODO: refactor and simplify
-	arrivalRate := getScaledArrivalRate(car.et.Segment, car.config.Rate.Int64, car.config.TimeUnit.TimeDuration())
+	arrivalRate := getScaledArrivalRate(car.et.Segment, config.Rate.Int64, config.TimeUnit.TimeDuration())
 	tickerPeriod := getTickerPeriod(arrivalRate).TimeDuration()
 	arrivalRatePerSec, _ := getArrivalRatePerSec(arrivalRate).Float64()
 
 	// Make sure the log and the progress bar have accurate information
 	car.logger.WithFields(logrus.Fields{
 		"maxVUs": maxVUs, "preAllocatedVUs": preAllocatedVUs, "duration": duration,
-		"tickerPeriod": tickerPeriod, "type": car.config.GetType(),
+		"tickerPeriod": tickerPeriod, "type": config.GetType(),
 	}).Debug("Starting executor run...")
 
 	activeVUsWg := &sync.WaitGroup{}
 
 	returnedVUs := make(chan struct{})
 	waitOnProgressChannel := make(chan struct{})
-	startTime, maxDurationCtx, regDurationCtx, cancel := getDurationContexts(parentCtx, duration, gracefulStop)
+	startTime, maxDurationCtx, regDurationCtx, cancel, setDuration := getLiveDurationContexts(
+		parentCtx, duration, gracefulStop)
 	defer func() {
 		cancel()
 		<-waitOnProgressChannel
@@ -228,18 +316,27 @@ func (car ConstantArrivalRate) Run(parentCtx context.Context, out chan<- metrics
 		cancel()
 		activeVUsWg.Wait()
 	}()
+	// The live updates are rejected once the executor has ended, which has
+	// to happen before the deferred wait for the graceful stop above, or the
+	// updates sent in the meantime would block until it's over.
+	defer car.control.end()
+
 	activeVUsCount := uint64(0)
 
 	vusFmt := pb.GetFixedLengthIntFormat(maxVUs)
-	progIters := fmt.Sprintf(
-		pb.GetFixedLengthFloatFormat(arrivalRatePerSec, 2)+" iters/s", arrivalRatePerSec)
+	itersFmt := pb.GetFixedLengthFloatFormat(arrivalRatePerSec, 2) + " iters/s"
+	// the rate and the duration are changed by the updates
+	var progIters atomic.Value
+	progIters.Store(fmt.Sprintf(itersFmt, arrivalRatePerSec))
+	currentDuration := int64(duration)
 	progressFn := func() (float64, []string) {
 		spent := time.Since(startTime)
+		duration := time.Duration(atomic.LoadInt64(&currentDuration))
 		currActiveVUs := atomic.LoadUint64(&activeVUsCount)
 		progVUs := fmt.Sprintf(vusFmt+"/"+vusFmt+" VUs",
 			vusPool.Running(), currActiveVUs)
 
-		right := []string{progVUs, duration.String(), progIters}
+		right := []string{progVUs, duration.String(), progIters.Load().(string)} //nolint:forcetypeassert
 
 		if spent > duration {
 			return 1, right
@@ -253,8 +350,8 @@ func (car ConstantArrivalRate) Run(parentCtx context.Context, out chan<- metrics
 	}
 	car.progress.Modify(pb.WithProgress(progressFn))
 	maxDurationCtx = lib.WithScenarioState(maxDurationCtx, &lib.ScenarioState{
-		Name:       car.config.Name,
-		Executor:   car.config.Type,
+		Name:       config.Name,
+		Executor:   config.Type,
 		StartTime:  startTime,
 		ProgressFn: progressFn,
 	})
@@ -269,15 +366,15 @@ func (car ConstantArrivalRate) Run(parentCtx context.Context, out chan<- metrics
 		// is done in the goroutine started by activeVUPool.AddVU, whenever the
 		// VU finishes running an iteration. This results in a more accurate
 		// report of VUs that are _actually_ active.
-		car.executionState.ReturnVU(u, false)
+		car.control.returnVU(car.executionState, u)
 		activeVUsWg.Done()
 	}
 
-	runIterationBasic := getIterationRunner(car.executionState, car.logger)
+	runIterationBasic := car.getIterationRunner(out)
 	activateVU := func(initVU lib.InitializedVU) lib.ActiveVU {
 		activeVUsWg.Add(1)
 		activeVU := initVU.Activate(getVUActivationParams(
-			maxDurationCtx, car.config.BaseConfig, returnVU,
+			maxDurationCtx, regDurationCtx, config.BaseConfig, returnVU,
 			car.nextIterationCounters,
 		))
 		atomic.AddUint64(&activeVUsCount, 1)
@@ -286,13 +383,13 @@ func (car ConstantArrivalRate) Run(parentCtx context.Context, out chan<- metrics
 	}
 
 	remainingUnplannedVUs := maxVUs - preAllocatedVUs
-	makeUnplannedVUCh := make(chan struct{})
+	makeUnplannedVUCh := make(chan bool) // true for the VUs beyond the execution plan
 	defer close(makeUnplannedVUCh)
 	go func() {
 		defer close(returnedVUs)
-		for range makeUnplannedVUCh {
+		for beyondPlan := range makeUnplannedVUCh {
 			car.logger.Debug("Starting initialization of an unplanned VU...")
-			initVU, err := car.executionState.GetUnplannedVU(maxDurationCtx, car.logger)
+			initVU, err := car.control.getUnplannedVU(maxDurationCtx, car.executionState, car.logger, beyondPlan)
 			if err != nil {
 				// TODO figure out how to return it to the Run goroutine
 				car.logger.WithError(err).Error("Error while allocating unplanned VU")
@@ -312,57 +409,132 @@ func (car ConstantArrivalRate) Run(parentCtx context.Context, out chan<- metrics
 		activateVU(initVU)
 	}
 
-	start, offsets, _ := car.et.GetStripedOffsets()
+	arrivals := newArrivalGaps(
+		config.ArrivalDistribution, config.Name, car.executionState.Test.Options.RandomSeed,
+	).newSequence(car.et, 0)
 	timer := time.NewTimer(time.Hour * 24)
 	// here the we need the not scaled one
-	notScaledTickerPeriod := getTickerPeriod(
-		big.NewRat(
-			car.config.Rate.Int64,
-			int64(car.config.TimeUnit.TimeDuration()),
-		)).TimeDuration()
+	getNotScaledTickerPeriod := func(config ConstantArrivalRateConfig) time.Duration {
+		return getTickerPeriod(
+			big.NewRat(
+				config.Rate.Int64,
+				int64(config.TimeUnit.TimeDuration()),
+			)).TimeDuration()
+	}
+	notScaledTickerPeriod := getNotScaledTickerPeriod(config)
+	// The iterations after a rate change are scheduled with the new period from
+	// the time of the change, continuing from the (fractional) global iteration
+	// that was reached by then, so all of the segments remain in sync.
+	var (
+		anchorTime time.Duration
+		anchorIter float64
+	)
 
 	droppedIterationMetric := car.executionState.Test.BuiltinMetrics.DroppedIterations
 	shownWarning := false
 	metricTags := car.getMetricTags(nil)
-	for li, gi := 0, start; ; li, gi = li+1, gi+offsets[li%len(offsets)] {
-		t := notScaledTickerPeriod*time.Duration(gi) - time.Since(startTime)
-		timer.Reset(t)
-		select {
-		case <-timer.C:
-			if vusPool.TryRunIteration() {
-				continue
-			}
 
-			// Since there aren't any free VUs available, consider this iteration
-			// dropped - we aren't going to try to recover it, but
-
-			metrics.PushIfNotDone(parentCtx, out, metrics.Sample{
-				TimeSeries: metrics.TimeSeries{
-					Metric: droppedIterationMetric,
-					Tags:   metricTags,
-				},
-				Time:  time.Now(),
-				Value: 1,
-			})
-
-			// We'll try to start allocating another VU in the background,
-			// non-blockingly, if we have remainingUnplannedVUs...
-			if remainingUnplannedVUs == 0 {
-				if !shownWarning {
-					car.logger.Warningf("Insufficient VUs, reached %d active VUs and cannot initialize more", maxVUs)
-					shownWarning = true
-				}
-				continue
-			}
+	applyUpdate := func(update ArrivalRateUpdate) error {
+		newConfig, err := config.withUpdate(update)
+		if err != nil {
+			return err
+		}
+		newMaxVUs := newConfig.GetMaxVUs(car.executionState.ExecutionTuple)
+		initializedVUs := maxVUs - remainingUnplannedVUs
+		if newMaxVUs < initializedVUs {
+			return fmt.Errorf("maxVUs can't be lower than the %d VUs that were already initialized", initializedVUs)
+		}
+		t := time.Since(startTime)
+		newDuration := newConfig.Duration.TimeDuration()
+		if update.Stop {
+			newDuration = t
+		} else if newDuration <= t {
+			return fmt.Errorf("the duration can't be changed to %s, the scenario has already run for %s",
+				newDuration, t.Round(time.Millisecond))
+		}
+		if !setDuration(newDuration) {
+			return errScenarioEnded
+		}
+		atomic.StoreInt64(&currentDuration, int64(newDuration))
+
+		if newConfig.Rate != config.Rate || newConfig.TimeUnit != config.TimeUnit {
+			anchorIter += float64(t-anchorTime) / float64(notScaledTickerPeriod)
+			anchorTime = t
+			notScaledTickerPeriod = getNotScaledTickerPeriod(*newConfig)
+			newRatePerSec, _ := getArrivalRatePerSec(getScaledArrivalRate(
+				car.et.Segment, newConfig.Rate.Int64, newConfig.TimeUnit.TimeDuration(),
+			)).Float64()
+			progIters.Store(fmt.Sprintf(itersFmt, newRatePerSec))
+		}
+		remainingUnplannedVUs = newMaxVUs - initializedVUs
+		maxVUs = newMaxVUs
+		shownWarning = false
+
+		config = *newConfig
+		car.control.setConfig(newConfig)
+		car.logger.WithFields(logrus.Fields{
+			"maxVUs": maxVUs, "duration": newDuration, "rate": config.Rate.Int64, "stop": update.Stop,
+		}).Debug("Updated the executor config")
+		return nil
+	}
 
+	// waitIteration waits until it's time to start the iteration, which is
+	// after the given number of global iterations have arrived, while it
+	// applies the updates. It returns false when the regular duration
+	// is over.
+	waitIteration := func(arrived float64) bool {
+		for {
+			elapsed := time.Since(startTime)
+			timer.Reset(anchorTime + time.Duration((arrived-anchorIter)*float64(notScaledTickerPeriod)) - elapsed)
 			select {
-			case makeUnplannedVUCh <- struct{}{}: // great!
-				remainingUnplannedVUs--
-			default: // we're already allocating a new VU
+			case <-timer.C:
+				return true
+			case event := <-car.control.updates:
+				if !timer.Stop() {
+					<-timer.C
+				}
+				// the iteration is rescheduled, in case the rate was changed
+				event.err <- applyUpdate(event.update)
+			case <-regDurationCtx.Done():
+				return false
 			}
+		}
+	}
 
-		case <-regDurationCtx.Done():
+	for ; ; arrivals.next() {
+		if !waitIteration(arrivals.area) {
 			return nil
 		}
+		if vusPool.TryRunIteration() {
+			continue
+		}
+
+		// Since there aren't any free VUs available, consider this iteration
+		// dropped - we aren't going to try to recover it, but
+
+		metrics.PushIfNotDone(parentCtx, out, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: droppedIterationMetric,
+				Tags:   metricTags,
+			},
+			Time:  time.Now(),
+			Value: 1,
+		})
+
+		// We'll try to start allocating another VU in the background,
+		// non-blockingly, if we have remainingUnplannedVUs...
+		if remainingUnplannedVUs == 0 {
+			if !shownWarning {
+				car.logger.Warningf("Insufficient VUs, reached %d active VUs and cannot initialize more", maxVUs)
+				shownWarning = true
+			}
+			continue
+		}
+
+		select {
+		case makeUnplannedVUCh <- maxVUs-remainingUnplannedVUs >= plannedMaxVUs: // great!
+			remainingUnplannedVUs--
+		default: // we're already allocating a new VU
+		}
 	}
 }
@@ -47,8 +47,12 @@ func NewConstantVUsConfig(name string) ConstantVUsConfig {
 	}
 }
 
-// Make sure we implement the lib.ExecutorConfig interface
-var _ lib.ExecutorConfig = &ConstantVUsConfig{}
+// Make sure we implement the lib.ExecutorConfig and lib.ResumableExecutorConfig
+// interfaces
+var (
+	_ lib.ExecutorConfig          = &ConstantVUsConfig{}
+	_ lib.ResumableExecutorConfig = &ConstantVUsConfig{}
+)
 
 // GetVUs returns the scaled VUs for the executor.
 func (clvc ConstantVUsConfig) GetVUs(et *lib.ExecutionTuple) int64 {
@@ -102,6 +106,17 @@ func (clvc ConstantVUsConfig) HasWork(et *lib.ExecutionTuple) bool {
 	return clvc.GetVUs(et) > 0
 }
 
+// GetResumedConfig returns the config of the rest of the duration after the
+// checkpoint, or nil if the executor has run for all of it.
+func (clvc ConstantVUsConfig) GetResumedConfig(checkpoint lib.ExecutorCheckpoint) lib.ExecutorConfig {
+	clvc.BaseConfig = clvc.getResumedBaseConfig(checkpoint)
+	clvc.Duration = getResumedDuration(clvc.Duration, checkpoint)
+	if clvc.Duration.TimeDuration() <= 0 {
+		return nil
+	}
+	return clvc
+}
+
 // NewExecutor creates a new ConstantVUs executor
 func (clvc ConstantVUsConfig) NewExecutor(es *lib.ExecutionState, logger *logrus.Entry) (lib.Executor, error) {
 	return ConstantVUs{
@@ -122,7 +137,7 @@ var _ lib.Executor = &ConstantVUs{}
 
 // Run constantly loops through as many iterations as possible on a fixed number
 // of VUs for the specified duration.
-func (clv ConstantVUs) Run(parentCtx context.Context, _ chan<- metrics.SampleContainer) (err error) {
+func (clv ConstantVUs) Run(parentCtx context.Context, out chan<- metrics.SampleContainer) (err error) {
 	numVUs := clv.config.GetVUs(clv.executionState.ExecutionTuple)
 	duration := clv.config.Duration.TimeDuration()
 	gracefulStop := clv.config.GetGracefulStop()
@@ -168,7 +183,7 @@ func (clv ConstantVUs) Run(parentCtx context.Context, _ chan<- metrics.SampleCon
 	defer activeVUs.Wait()
 
 	regDurationDone := regDurationCtx.Done()
-	runIteration := getIterationRunner(clv.executionState, clv.logger)
+	runIteration := clv.getIterationRunner(out)
 
 	returnVU := func(u lib.InitializedVU) {
 		clv.executionState.ReturnVU(u, true)
@@ -180,7 +195,7 @@ func (clv ConstantVUs) Run(parentCtx context.Context, _ chan<- metrics.SampleCon
 		defer cancel()
 
 		activeVU := initVU.Activate(
-			getVUActivationParams(ctx, clv.config.BaseConfig, returnVU, clv.nextIterationCounters))
+			getVUActivationParams(ctx, regDurationCtx, clv.config.BaseConfig, returnVU, clv.nextIterationCounters))
 
 		for {
 			select {
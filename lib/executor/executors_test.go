@@ -60,7 +60,7 @@ var configMapTestCases = []configMapTestCase{
 			sched.Duration = types.NullDurationFrom(1 * time.Minute)
 			sched.GracefulStop = types.NullDurationFrom(10 * time.Second)
 			sched.StartTime = types.NullDurationFrom(70 * time.Second)
-			sched.Exec = null.StringFrom("someFunc")
+			sched.Exec = ScenarioExecFrom("someFunc")
 			sched.Env = map[string]string{"test": "mest"}
 			require.Equal(t, cm, lib.ScenarioConfigs{"someKey": sched})
 			require.Equal(t, sched.BaseConfig.Name, cm["someKey"].GetName())
@@ -408,6 +408,272 @@ var configMapTestCases = []configMapTestCase{
 	{`{"varrival": {"executor": "ramping-arrival-rate", "preAllocatedVUs": 20, "maxVUs": 50, "stages": [{"duration": "5m", "target": 10}], "timeUnit": "-1s"}}`, exp{validationError: true}},
 	{`{"varrival": {"executor": "ramping-arrival-rate", "preAllocatedVUs": 20, "maxVUs": 50, "stages": [{"duration": "5m", "target": 10}], "timeUnit": "0s"}}`, exp{validationError: true}},
 	{`{"varrival": {"executor": "ramping-arrival-rate", "preAllocatedVUs": 30, "maxVUs": 20, "stages": [{"duration": "5m", "target": 10}]}}`, exp{validationError: true}},
+	// replay-arrival
+	{
+		`{"replay": {"executor": "replay-arrival", "file": "access.csv", "speed": 2, "preAllocatedVUs": 20, "maxVUs": 50, "maxDuration": "1h"}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			sched := NewReplayArrivalConfig("replay")
+			sched.File = null.StringFrom("access.csv")
+			sched.Speed = null.FloatFrom(2)
+			sched.PreAllocatedVUs = null.IntFrom(20)
+			sched.MaxVUs = null.IntFrom(50)
+			sched.MaxDuration = types.NullDurationFrom(time.Hour)
+			require.Equal(t, cm, lib.ScenarioConfigs{"replay": sched})
+
+			assert.Empty(t, cm["replay"].Validate())
+			assert.Empty(t, cm.Validate())
+
+			et, err := lib.NewExecutionTuple(nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, "Replay of the arrivals in access.csv at 2x speed (maxVUs: 20-50, maxDuration: 1h0m0s, gracefulStop: 30s)", cm["replay"].GetDescription(et))
+
+			schedReqs := cm["replay"].GetExecutionRequirements(et)
+			endOffset, isFinal := lib.GetEndOffset(schedReqs)
+			assert.Equal(t, 3630*time.Second, endOffset)
+			assert.Equal(t, true, isFinal)
+			assert.Equal(t, uint64(20), lib.GetMaxPlannedVUs(schedReqs))
+			assert.Equal(t, uint64(50), lib.GetMaxPossibleVUs(schedReqs))
+		}},
+	},
+	{
+		`{"replay": {"executor": "replay-arrival", "file": "access.jsonl", "preAllocatedVUs": 20}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			assert.Empty(t, cm["replay"].Validate())
+			require.EqualValues(t, 20, cm["replay"].(*ReplayArrivalConfig).MaxVUs.Int64)
+		}},
+	},
+	{`{"replay": {"executor": "replay-arrival", "file": "access.log", "format": "csv", "timestampColumn": "time", "preAllocatedVUs": 20}}`, exp{}},
+	{`{"replay": {"executor": "replay-arrival", "file": "access.log", "preAllocatedVUs": 20}}`, exp{validationError: true}},
+	{`{"replay": {"executor": "replay-arrival", "file": "access.csv", "format": "xml", "preAllocatedVUs": 20}}`, exp{validationError: true}},
+	{`{"replay": {"executor": "replay-arrival", "preAllocatedVUs": 20}}`, exp{validationError: true}},
+	{`{"replay": {"executor": "replay-arrival", "file": "access.csv"}}`, exp{validationError: true}},
+	{`{"replay": {"executor": "replay-arrival", "file": "access.csv", "preAllocatedVUs": 20, "speed": -1}}`, exp{validationError: true}},
+	{`{"replay": {"executor": "replay-arrival", "file": "access.csv", "preAllocatedVUs": 20, "maxVUs": 10}}`, exp{validationError: true}},
+	{`{"replay": {"executor": "replay-arrival", "file": "access.csv", "preAllocatedVUs": 20, "maxDuration": "0s"}}`, exp{validationError: true}},
+	// adaptive-arrival-rate
+	{
+		`{"knee": {"executor": "adaptive-arrival-rate", "startRate": 10, "maxRate": 200, "duration": "10m", "metric": "http_req_duration{scenario:knee}", "target": "p(95)<500", "controller": {"type": "aimd", "increase": 20}, "preAllocatedVUs": 20, "maxVUs": 100}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			sched := NewAdaptiveArrivalRateConfig("knee")
+			sched.StartRate = null.IntFrom(10)
+			sched.MaxRate = null.IntFrom(200)
+			sched.Duration = types.NullDurationFrom(10 * time.Minute)
+			sched.Metric = null.StringFrom("http_req_duration{scenario:knee}")
+			sched.Target = null.StringFrom("p(95)<500")
+			sched.Controller = &AdaptiveController{Type: "aimd", Increase: null.IntFrom(20)}
+			sched.PreAllocatedVUs = null.IntFrom(20)
+			sched.MaxVUs = null.IntFrom(100)
+			require.Equal(t, cm, lib.ScenarioConfigs{"knee": sched})
+
+			assert.Empty(t, cm["knee"].Validate())
+			assert.Empty(t, cm.Validate())
+
+			et, err := lib.NewExecutionTuple(nil, nil)
+			require.NoError(t, err)
+			schedReqs := cm["knee"].GetExecutionRequirements(et)
+			endOffset, isFinal := lib.GetEndOffset(schedReqs)
+			assert.Equal(t, 630*time.Second, endOffset)
+			assert.Equal(t, true, isFinal)
+			assert.Equal(t, uint64(20), lib.GetMaxPlannedVUs(schedReqs))
+			assert.Equal(t, uint64(100), lib.GetMaxPossibleVUs(schedReqs))
+		}},
+	},
+	{`{"knee": {"executor": "adaptive-arrival-rate", "startRate": 10, "maxRate": 200, "duration": "10m", "metric": "http_req_failed", "target": "rate<0.01", "controller": "pid", "preAllocatedVUs": 20}}`, exp{}},
+	{`{"knee": {"executor": "adaptive-arrival-rate", "startRate": 10, "maxRate": 200, "duration": "10m", "interval": "30s", "metric": "http_req_failed", "target": "rate<0.01", "controller": {"type": "pid", "kp": 0.2}, "preAllocatedVUs": 20}}`, exp{}},
+	{`{"knee": {"executor": "adaptive-arrival-rate", "startRate": 10, "maxRate": 200, "duration": "10m", "metric": "http_req_failed", "target": "rate<0.01", "controller": {"type": "pid", "gain": 1}, "preAllocatedVUs": 20}}`, exp{parseError: true}},
+	{`{"knee": {"executor": "adaptive-arrival-rate", "startRate": 10, "duration": "10m", "metric": "http_req_failed", "target": "rate<0.01", "preAllocatedVUs": 20}}`, exp{validationError: true}},
+	{`{"knee": {"executor": "adaptive-arrival-rate", "startRate": 10, "maxRate": 200, "duration": "10m", "metric": "http_req_failed", "preAllocatedVUs": 20}}`, exp{validationError: true}},
+	{`{"knee": {"executor": "adaptive-arrival-rate", "startRate": 10, "maxRate": 200, "duration": "10m", "metric": "http_req_failed", "target": "rate<0.01", "controller": "bang-bang", "preAllocatedVUs": 20}}`, exp{validationError: true}},
+	// scenario dependencies
+	{
+		`{"warmup": {"executor": "constant-vus", "vus": 5, "duration": "1m", "gracefulStop": "10s"},
+		"load": {"executor": "constant-vus", "vus": 20, "duration": "5m", "gracefulStop": 0, "startAfter": ["warmup"], "startTime": "30s"},
+		"spike": {"executor": "constant-vus", "vus": 50, "duration": "1m", "gracefulStop": 0, "startAfter": ["warmup"], "startWhen": {"http_req_duration": ["p(95)<500"]}}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			et, err := lib.NewExecutionTuple(nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, "20 looping VUs for 5m0s (startAfter: warmup, startTime: 30s)", cm["load"].GetDescription(et))
+			assert.Equal(t,
+				"50 looping VUs for 1m0s (startAfter: warmup, startWhen: http_req_duration p(95)<500)",
+				cm["spike"].GetDescription(et))
+
+			schedules := cm.GetSchedules(et)
+			assert.Equal(t, lib.ScenarioSchedule{
+				Start: types.NullDurationFrom(0),
+				End:   types.NullDurationFrom(70 * time.Second),
+			}, schedules["warmup"])
+			assert.Equal(t, lib.ScenarioSchedule{
+				StartAfter: []string{"warmup"},
+				Start:      types.NullDurationFrom(100 * time.Second),
+				End:        types.NullDurationFrom(400 * time.Second),
+			}, schedules["load"])
+			assert.Equal(t, lib.ScenarioSchedule{
+				StartAfter: []string{"warmup"},
+				StartWhen:  map[string][]string{"http_req_duration": {"p(95)<500"}},
+				Start:      types.NullDurationFrom(70 * time.Second),
+				End:        types.NullDurationFrom(130 * time.Second),
+				Dynamic:    true,
+			}, schedules["spike"])
+
+			// The VUs of spike are reserved from its earliest start until the end
+			assert.Equal(t, []lib.ExecutionStep{
+				{TimeOffset: 0, PlannedVUs: 5},
+				{TimeOffset: 70 * time.Second, PlannedVUs: 55},
+				{TimeOffset: 70 * time.Second, PlannedVUs: 50},
+				{TimeOffset: 100 * time.Second, PlannedVUs: 70},
+				{TimeOffset: 400 * time.Second, PlannedVUs: 50},
+			}, cm.GetFullExecutionRequirements(et))
+		}},
+	},
+	{`{"a": {"executor": "shared-iterations", "startAfter": ["b"]}}`, exp{validationError: true}},
+	{`{"a": {"executor": "shared-iterations", "startAfter": ["a"]}}`, exp{validationError: true}},
+	{
+		`{"a": {"executor": "shared-iterations", "startAfter": ["c"]}, "b": {"executor": "shared-iterations", "startAfter": ["a"]},
+		"c": {"executor": "shared-iterations", "startAfter": ["b"]}}`,
+		exp{validationError: true, custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			errs := cm.Validate()
+			require.Len(t, errs, 1)
+			assert.EqualError(t, errs[0], "the scenarios have a cycle in their startAfter dependencies: a -> c -> b -> a")
+		}},
+	},
+	{`{"a": {"executor": "shared-iterations", "startWhen": {"http_req_failed": ["rate<0.01"], "checks": ["rate>0.99"]}}}`, exp{}},
+	{`{"a": {"executor": "shared-iterations", "startWhen": {"http_req_failed": []}}}`, exp{validationError: true}},
+	{`{"a": {"executor": "shared-iterations", "startWhen": {"http_req_failed": ["rate"]}}}`, exp{validationError: true}},
+	{`{"a": {"executor": "shared-iterations", "startWhen": {"http_req_failed": ["rate<0.01 over 1m"]}}}`, exp{validationError: true}},
+	{`{"a": {"executor": "shared-iterations", "startWhen": {"http_req_failed{": ["rate<0.01"]}}}`, exp{validationError: true}},
+	{`{"a": {"executor": "shared-iterations", "startAfter": "b"}}`, exp{parseError: true}},
+	// execution plan
+	{
+		`{"warmup": {"executor": "constant-vus", "vus": 5, "duration": "20s", "gracefulStop": 0},
+		"load": {"executor": "ramping-arrival-rate", "preAllocatedVUs": 10, "maxVUs": 20, "gracefulStop": 0, "startAfter": ["warmup"],
+		"stages": [{"target": 100, "duration": "20s"}, {"target": 100, "duration": "10s"}]},
+		"spike": {"executor": "constant-arrival-rate", "rate": 10, "timeUnit": "100ms", "duration": "20s", "preAllocatedVUs": 30, "gracefulStop": 0, "startTime": "10s"}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			et, err := lib.NewExecutionTuple(nil, nil)
+			require.NoError(t, err)
+			plan := cm.GetExecutionPlan(et, 10*time.Second)
+			assert.Equal(t, types.NullDurationFrom(50*time.Second), plan.Duration)
+			assert.Equal(t, uint64(55), plan.MaxVUs) // warmup ends when load starts
+
+			point := func(vus, maxVUs uint64, iterationsPerSecond null.Float) lib.PlanPoint {
+				return lib.PlanPoint{VUs: vus, MaxVUs: maxVUs, IterationsPerSecond: iterationsPerSecond}
+			}
+			for name, expPoints := range map[string][]lib.PlanPoint{
+				"warmup": {
+					point(5, 5, null.Float{}), point(5, 5, null.Float{}), point(0, 0, null.Float{}),
+					point(0, 0, null.Float{}), point(0, 0, null.Float{}), point(0, 0, null.Float{}),
+				},
+				"load": {
+					point(0, 0, null.FloatFrom(0)), point(0, 0, null.FloatFrom(0)), point(10, 20, null.FloatFrom(0)),
+					point(10, 20, null.FloatFrom(50)), point(10, 20, null.FloatFrom(100)), point(0, 0, null.FloatFrom(0)),
+				},
+				"spike": {
+					point(0, 0, null.FloatFrom(0)), point(30, 30, null.FloatFrom(100)), point(30, 30, null.FloatFrom(100)),
+					point(0, 0, null.FloatFrom(0)), point(0, 0, null.FloatFrom(0)), point(0, 0, null.FloatFrom(0)),
+				},
+			} {
+				scenarioPlan := plan.Scenarios[name]
+				require.Len(t, scenarioPlan.Points, len(expPoints), name)
+				for i, expPoint := range expPoints {
+					expPoint.Time = types.NullDurationFrom(time.Duration(i) * 10 * time.Second)
+					assert.Equal(t, expPoint, scenarioPlan.Points[i], "%s at %d", name, i)
+				}
+			}
+			assert.Equal(t, cm["load"].GetType(), plan.Scenarios["load"].Executor)
+			assert.Equal(t, []string{"warmup"}, plan.Scenarios["load"].StartAfter)
+		}},
+	},
+	// scenario setup and teardown
+	{
+		`{"login": {"executor": "shared-iterations", "iterations": 10, "vus": 2, "setup": "loginSetup", "setupTimeout": "10s", "teardown": "logout"}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			et, err := lib.NewExecutionTuple(nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t,
+				"10 iterations shared among 2 VUs (maxDuration: 10m0s, setup: loginSetup, teardown: logout, gracefulStop: 30s)",
+				cm["login"].GetDescription(et))
+
+			setup, setupTimeout := cm["login"].GetSetup()
+			assert.Equal(t, "loginSetup", setup)
+			assert.Equal(t, types.NullDurationFrom(10*time.Second), setupTimeout)
+			teardown, teardownTimeout := cm["login"].GetTeardown()
+			assert.Equal(t, "logout", teardown)
+			assert.False(t, teardownTimeout.Valid)
+		}},
+	},
+	// traffic mix
+	{
+		`{"mix": {"executor": "constant-vus", "vus": 10, "duration": "1m", "exec": {"browse": 70, "search": 20, "checkout": 10}}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			et, err := lib.NewExecutionTuple(nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t,
+				"10 looping VUs for 1m0s (exec: browse 70, checkout 10, search 20, gracefulStop: 30s)",
+				cm["mix"].GetDescription(et))
+			assert.Equal(t, "", cm["mix"].GetExec())
+			assert.Equal(t, []lib.ExecWeight{
+				{Fn: "browse", Weight: 70},
+				{Fn: "checkout", Weight: 10},
+				{Fn: "search", Weight: 20},
+			}, cm["mix"].GetExecWeights())
+
+			data, err := json.Marshal(cm["mix"])
+			require.NoError(t, err)
+			assert.Contains(t, string(data), `"exec":{"browse":70,"checkout":10,"search":20}`)
+		}},
+	},
+	{`{"mix": {"executor": "constant-vus", "vus": 10, "duration": "1m", "exec": {}}}`, exp{validationError: true}},
+	{`{"mix": {"executor": "constant-vus", "vus": 10, "duration": "1m", "exec": {"browse": 0}}}`, exp{validationError: true}},
+	{`{"mix": {"executor": "constant-vus", "vus": 10, "duration": "1m", "exec": {"": 10}}}`, exp{validationError: true}},
+	{`{"mix": {"executor": "constant-vus", "vus": 10, "duration": "1m", "exec": {"browse": "70"}}}`, exp{parseError: true}},
+	// think time and pacing
+	{
+		`{"think": {"executor": "constant-vus", "vus": 10, "duration": "1m", "thinkTime": {"normal": {"mean": 3, "stddev": 1}}},
+		"paced": {"executor": "constant-vus", "vus": 10, "duration": "1m", "pacing": "5s"}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			et, err := lib.NewExecutionTuple(nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t,
+				"10 looping VUs for 1m0s (thinkTime: normal(mean: 3s, stddev: 1s), gracefulStop: 30s)",
+				cm["think"].GetDescription(et))
+			assert.Equal(t, "10 looping VUs for 1m0s (pacing: 5s, gracefulStop: 30s)", cm["paced"].GetDescription(et))
+			assert.Equal(t, &types.ThinkTime{Normal: &types.NormalThinkTime{Mean: 3, StdDev: 1}}, cm["think"].GetThinkTime())
+			assert.Equal(t, 5*time.Second, cm["paced"].GetPacing())
+			assert.Nil(t, cm["paced"].GetThinkTime())
+		}},
+	},
+	{`{"a": {"executor": "shared-iterations", "thinkTime": 2}}`, exp{}},
+	{`{"a": {"executor": "shared-iterations", "thinkTime": {"uniform": [3, 1]}}}`, exp{validationError: true}},
+	{`{"a": {"executor": "shared-iterations", "thinkTime": {"gamma": 1}}}`, exp{parseError: true}},
+	{`{"a": {"executor": "shared-iterations", "pacing": "0s"}}`, exp{validationError: true}},
+	{`{"a": {"executor": "shared-iterations", "thinkTime": 2, "pacing": "5s"}}`, exp{validationError: true}},
+	// retries of the failed iterations
+	{
+		`{"a": {"executor": "constant-vus", "vus": 10, "duration": "1m",
+		"retry": {"attempts": 3, "backoff": "1s", "on": ["exception", "failedCheck"]}}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			et, err := lib.NewExecutionTuple(nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t,
+				"10 looping VUs for 1m0s (retry: 3 attempts on exception, failedCheck, backoff 1s, gracefulStop: 30s)",
+				cm["a"].GetDescription(et))
+			assert.Equal(t, &lib.RetryPolicy{
+				Attempts: null.IntFrom(3),
+				Backoff:  types.NullDurationFrom(time.Second),
+				On:       []string{lib.RetryOnException, lib.RetryOnFailedCheck},
+			}, cm["a"].GetRetry())
+		}},
+	},
+	{`{"a": {"executor": "shared-iterations", "retry": {"attempts": 2}}}`, exp{}},
+	{`{"a": {"executor": "shared-iterations", "retry": {}}}`, exp{validationError: true}},
+	{`{"a": {"executor": "shared-iterations", "retry": {"attempts": 0}}}`, exp{validationError: true}},
+	{`{"a": {"executor": "shared-iterations", "retry": {"attempts": 2, "backoff": "-1s"}}}`, exp{validationError: true}},
+	{`{"a": {"executor": "shared-iterations", "retry": {"attempts": 2, "on": ["timeout"]}}}`, exp{validationError: true}},
+	{`{"a": {"executor": "shared-iterations", "setup": ""}}`, exp{validationError: true}},
+	{`{"a": {"executor": "shared-iterations", "teardown": ""}}`, exp{validationError: true}},
+	{`{"a": {"executor": "shared-iterations", "setup": "s", "setupTimeout": "0s"}}`, exp{validationError: true}},
+	{`{"a": {"executor": "shared-iterations", "teardown": "t", "teardownTimeout": "-1s"}}`, exp{validationError: true}},
+
 	// TODO: more tests of mixed executors and execution plans
 
 	// scenario options
@@ -469,7 +735,7 @@ func TestArchiveRoundTripExecutorConfig(t *testing.T) {
 			Env: map[string]string{
 				"FOO": "bar",
 			},
-			Exec: null.StringFrom("default"),
+			Exec: ScenarioExecFrom("default"),
 			Tags: map[string]string{
 				"tagkey": "tagvalue",
 			},
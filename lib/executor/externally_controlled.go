@@ -341,7 +341,7 @@ func (rs *externallyControlledRunState) newManualVUHandle(
 	}
 	ctx, cancel := context.WithCancel(rs.ctx)
 	return &manualVUHandle{
-		vuHandle: newStoppedVUHandle(ctx, getVU, returnVU,
+		vuHandle: newStoppedVUHandle(ctx, rs.ctx, getVU, returnVU,
 			rs.executor.nextIterationCounters,
 			&rs.executor.config.BaseConfig, logger),
 		initVU:   initVU,
@@ -480,7 +480,7 @@ func (rs *externallyControlledRunState) handleConfigChange(oldCfg, newCfg Extern
 // until the test is manually stopped.
 //
 //nolint:funlen,gocognit
-func (mex *ExternallyControlled) Run(parentCtx context.Context, _ chan<- metrics.SampleContainer) (err error) {
+func (mex *ExternallyControlled) Run(parentCtx context.Context, out chan<- metrics.SampleContainer) (err error) {
 	mex.configLock.RLock()
 	// Safely get the current config - it's important that the close of the
 	// hasStarted channel is inside of the lock, so that there are no data races
@@ -523,7 +523,7 @@ func (mex *ExternallyControlled) Run(parentCtx context.Context, _ chan<- metrics
 		currentlyPaused: false,
 		activeVUsCount:  new(int64),
 		maxVUs:          new(int64),
-		runIteration:    getIterationRunner(mex.executionState, mex.logger),
+		runIteration:    mex.getIterationRunner(out),
 	}
 	ss.ProgressFn = runState.progressFn
 
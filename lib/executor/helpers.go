@@ -4,15 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"math/big"
+	"slices"
 	"time"
 
-	"github.com/sirupsen/logrus"
-
 	"go.k6.io/k6/errext"
 	"go.k6.io/k6/execution"
 	"go.k6.io/k6/lib"
 	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
 	"go.k6.io/k6/ui/pb"
 )
 
@@ -39,6 +40,37 @@ func getStagesUnscaledMaxTarget(unscaledStartValue int64, stages []Stage) int64
 	return result
 }
 
+// skipStages returns the target at the given time and the rest of the stages
+// after it, the first of which is cut at it. There are no stages left after
+// their end.
+func skipStages(unscaledStartValue int64, stages []Stage, t time.Duration) (int64, []Stage) {
+	var stageStart time.Duration
+	from := float64(unscaledStartValue)
+	for i, stage := range stages {
+		dur := stage.Duration.TimeDuration()
+		if t < stageStart+dur {
+			x := t - stageStart
+			to := float64(stage.Target.Int64)
+			rest := make([]Stage, 0, len(stages)-i)
+			rest = append(rest, Stage{Duration: types.NullDurationFrom(dur - x), Target: stage.Target})
+			rest = append(rest, stages[i+1:]...)
+			return int64(math.Round(from + (to-from)*float64(x)/float64(dur))), rest
+		}
+		from = float64(stage.Target.Int64)
+		stageStart += dur
+	}
+	return int64(from), nil
+}
+
+// getResumedDuration returns the rest of the duration after the checkpoint,
+// which is 0 if the executor has run for all of it.
+func getResumedDuration(duration types.NullDuration, checkpoint lib.ExecutorCheckpoint) types.NullDuration {
+	if !checkpoint.Started {
+		return duration
+	}
+	return types.NullDurationFrom(max(0, duration.TimeDuration()-time.Duration(checkpoint.Elapsed)))
+}
+
 // validateTargetShifts validates the VU Target shifts.
 // It will append an error for any VU target that is larger than the maximum value allowed.
 // Each Stage needs a Target value. The stages array can be empty. The Targes could be negative.
@@ -98,25 +130,41 @@ func handleInterrupt(ctx context.Context, err error) bool {
 
 // getIterationRunner is a helper function that returns an iteration executor
 // closure. It takes care of updating the execution state statistics and
-// warning messages. And returns whether a full iteration was finished or not
+// warning messages, and of retrying the failed iterations by the retry policy
+// of the scenario, if it has one. And returns whether a full iteration was
+// finished or not
 //
 // TODO: emit the end-of-test iteration metrics here (https://github.com/k6io/k6/issues/1250)
-func getIterationRunner(
-	executionState *lib.ExecutionState, logger *logrus.Entry,
+func (bs *BaseExecutor) getIterationRunner(
+	out chan<- metrics.SampleContainer,
 ) func(context.Context, lib.ActiveVU) bool {
+	executionState, logger := bs.executionState, bs.logger
+	retry := bs.config.GetRetry()
+	metricTags := bs.getMetricTags(nil)
+	count := func(ctx context.Context, metric *metrics.Metric) {
+		metrics.PushIfNotDone(ctx, out, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: metric, Tags: metricTags},
+			Time:       time.Now(),
+			Value:      1,
+		})
+	}
+
 	return func(ctx context.Context, vu lib.ActiveVU) bool {
-		err := vu.RunOnce()
-
-		// TODO: track (non-ramp-down) errors from script iterations as a metric,
-		// and have a default threshold that will abort the script when the error
-		// rate exceeds a certain percentage
-
-		select {
-		case <-ctx.Done():
-			// Don't log errors or emit iterations metrics from cancelled iterations
-			executionState.AddInterruptedIterations(1)
-			return false
-		default:
+		for attempt := int64(1); ; attempt++ {
+			failedCheck, err := runIterationAttempt(vu, attempt)
+
+			// TODO: track (non-ramp-down) errors from script iterations as a metric,
+			// and have a default threshold that will abort the script when the error
+			// rate exceeds a certain percentage
+
+			select {
+			case <-ctx.Done():
+				// Don't log errors or emit iterations metrics from cancelled iterations
+				executionState.AddInterruptedIterations(1)
+				return false
+			default:
+			}
+
 			if err != nil {
 				if handleInterrupt(ctx, err) {
 					executionState.AddInterruptedIterations(1)
@@ -133,13 +181,39 @@ func getIterationRunner(
 				// TODO: investigate context cancelled errors
 			}
 
-			// TODO: move emission of end-of-iteration metrics here?
-			executionState.AddFullIterations(1)
-			return true
+			// The failed checks only fail the iterations that are retried on them
+			failed := err != nil || (failedCheck && retry != nil && slices.Contains(retry.On, lib.RetryOnFailedCheck))
+			// All of the attempts are a single iteration, so it's counted once,
+			// when the last one is done or interrupted.
+			if !failed || retry == nil || !retry.ShouldRetry(attempt, err, failedCheck) {
+				if failed {
+					count(ctx, executionState.Test.BuiltinMetrics.IterationsFailed)
+				}
+				// TODO: move emission of end-of-iteration metrics here?
+				executionState.AddFullIterations(1)
+				return true
+			}
+
+			count(ctx, executionState.Test.BuiltinMetrics.IterationsRetried)
+			select {
+			case <-time.After(retry.GetBackoff(attempt + 1)):
+			case <-ctx.Done():
+				executionState.AddInterruptedIterations(1)
+				return false
+			}
 		}
 	}
 }
 
+// runIterationAttempt runs the given attempt of an iteration on the VU, and
+// returns whether any of its checks failed, if the VU keeps track of them.
+func runIterationAttempt(vu lib.ActiveVU, attempt int64) (bool, error) {
+	if retryableVU, ok := vu.(lib.RetryableVU); ok {
+		return retryableVU.RunAttempt(attempt)
+	}
+	return false, vu.RunOnce()
+}
+
 // getDurationContexts is used to create sub-contexts that can restrict an
 // executor to only run for its allotted time.
 //
@@ -249,16 +323,37 @@ func getArrivalRatePerSec(scaledArrivalRate *big.Rat) *big.Rat {
 
 // TODO: Refactor this, maybe move all scenario things to an embedded struct?
 func getVUActivationParams(
-	ctx context.Context, conf BaseConfig, deactivateCallback func(lib.InitializedVU),
+	ctx, regDurationCtx context.Context, conf BaseConfig, deactivateCallback func(lib.InitializedVU),
 	nextIterationCounters func() (uint64, uint64),
 ) *lib.VUActivationParams {
 	return &lib.VUActivationParams{
 		RunContext:               ctx,
+		RegularDurationContext:   regDurationCtx,
 		Scenario:                 conf.Name,
 		Exec:                     conf.GetExec(),
+		ExecWeights:              conf.GetExecWeights(),
+		ThinkTime:                conf.GetThinkTime(),
+		Pacing:                   conf.GetPacing(),
 		Env:                      conf.GetEnv(),
 		Tags:                     conf.GetTags(),
 		DeactivateCallback:       deactivateCallback,
 		GetNextIterationCounters: nextIterationCounters,
 	}
 }
+
+// parseMetricCondition parses a condition on the samples of a metric, which is
+// written like a threshold, for example p(95)<500. It can't reference other
+// metrics or have a time window.
+func parseMetricCondition(expression string) (*metrics.Threshold, error) {
+	condition := metrics.NewThresholds([]string{expression})
+	if err := condition.Parse(); err != nil {
+		return nil, err
+	}
+	if len(condition.References()) != 0 {
+		return nil, fmt.Errorf("the condition %q can't reference other metrics", expression)
+	}
+	if len(condition.Windows()) != 0 {
+		return nil, fmt.Errorf("the condition %q can't have a time window", expression)
+	}
+	return condition.Thresholds[0], nil
+}
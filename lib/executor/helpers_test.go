@@ -1,6 +1,17 @@
 package executor
 
-import "go.k6.io/k6/metrics"
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
 
 func sumMetricValues(samples chan metrics.SampleContainer, metricName string) (sum float64) { //nolint:unparam
 	for _, sc := range metrics.GetBufferedSamples(samples) {
@@ -13,3 +24,96 @@ func sumMetricValues(samples chan metrics.SampleContainer, metricName string) (s
 	}
 	return sum
 }
+
+func TestSkipStages(t *testing.T) {
+	t.Parallel()
+
+	stages := []Stage{
+		{Duration: types.NullDurationFrom(10 * time.Second), Target: null.IntFrom(10)},
+		{Duration: types.NullDurationFrom(20 * time.Second), Target: null.IntFrom(10)},
+		{Duration: types.NullDurationFrom(10 * time.Second), Target: null.IntFrom(0)},
+	}
+
+	start, rest := skipStages(0, stages, 0)
+	assert.Equal(t, int64(0), start)
+	assert.Equal(t, stages, rest)
+
+	start, rest = skipStages(0, stages, 5*time.Second)
+	assert.Equal(t, int64(5), start)
+	assert.Equal(t, []Stage{
+		{Duration: types.NullDurationFrom(5 * time.Second), Target: null.IntFrom(10)},
+		stages[1], stages[2],
+	}, rest)
+
+	start, rest = skipStages(0, stages, 37*time.Second)
+	assert.Equal(t, int64(3), start)
+	assert.Equal(t, []Stage{{Duration: types.NullDurationFrom(3 * time.Second), Target: null.IntFrom(0)}}, rest)
+
+	start, rest = skipStages(0, stages, time.Minute)
+	assert.Equal(t, int64(0), start)
+	assert.Empty(t, rest)
+}
+
+func TestGetResumedConfig(t *testing.T) {
+	t.Parallel()
+
+	started := func(elapsed time.Duration, iterations uint64) lib.ExecutorCheckpoint {
+		return lib.ExecutorCheckpoint{Started: true, Elapsed: types.Duration(elapsed), Iterations: iterations}
+	}
+
+	t.Run("not started", func(t *testing.T) {
+		t.Parallel()
+		config := NewConstantVUsConfig("test")
+		config.StartTime = types.NullDurationFrom(time.Minute)
+		config.Duration = types.NullDurationFrom(time.Minute)
+		resumed, ok := config.GetResumedConfig(lib.ExecutorCheckpoint{Elapsed: types.Duration(20 * time.Second)}).(ConstantVUsConfig)
+		require.True(t, ok)
+		assert.Equal(t, types.NullDurationFrom(40*time.Second), resumed.StartTime)
+		assert.Equal(t, config.Duration, resumed.Duration)
+	})
+
+	t.Run("constant-vus", func(t *testing.T) {
+		t.Parallel()
+		config := NewConstantVUsConfig("test")
+		config.StartTime = types.NullDurationFrom(time.Minute)
+		config.StartAfter = []string{"other"}
+		config.Duration = types.NullDurationFrom(time.Minute)
+		resumed, ok := config.GetResumedConfig(started(20*time.Second, 10)).(ConstantVUsConfig)
+		require.True(t, ok)
+		assert.Equal(t, types.NullDurationFrom(0), resumed.StartTime)
+		assert.Nil(t, resumed.StartAfter)
+		assert.Equal(t, types.NullDurationFrom(40*time.Second), resumed.Duration)
+
+		assert.Nil(t, config.GetResumedConfig(started(time.Minute, 10)))
+	})
+
+	t.Run("shared-iterations", func(t *testing.T) {
+		t.Parallel()
+		config := NewSharedIterationsConfig("test")
+		config.VUs = null.IntFrom(10)
+		config.Iterations = null.IntFrom(100)
+		config.MaxDuration = types.NullDurationFrom(time.Minute)
+		resumed, ok := config.GetResumedConfig(started(20*time.Second, 95)).(SharedIterationsConfig)
+		require.True(t, ok)
+		assert.Equal(t, null.IntFrom(5), resumed.Iterations)
+		assert.Equal(t, null.IntFrom(5), resumed.VUs)
+		assert.Equal(t, types.NullDurationFrom(40*time.Second), resumed.MaxDuration)
+
+		assert.Nil(t, config.GetResumedConfig(started(20*time.Second, 100)))
+		assert.Nil(t, config.GetResumedConfig(started(time.Minute, 10)))
+	})
+
+	t.Run("ramping-arrival-rate", func(t *testing.T) {
+		t.Parallel()
+		config := NewRampingArrivalRateConfig("test")
+		config.StartRate = null.IntFrom(0)
+		config.Stages = []Stage{{Duration: types.NullDurationFrom(time.Minute), Target: null.IntFrom(60)}}
+		resumed, ok := config.GetResumedConfig(started(30*time.Second, 10)).(*RampingArrivalRateConfig)
+		require.True(t, ok)
+		assert.Equal(t, null.IntFrom(30), resumed.StartRate)
+		assert.Equal(t, []Stage{{Duration: types.NullDurationFrom(30 * time.Second), Target: null.IntFrom(60)}},
+			resumed.Stages)
+
+		assert.Nil(t, config.GetResumedConfig(started(time.Minute, 10)))
+	})
+}
@@ -44,8 +44,12 @@ func NewPerVUIterationsConfig(name string) PerVUIterationsConfig {
 	}
 }
 
-// Make sure we implement the lib.ExecutorConfig interface
-var _ lib.ExecutorConfig = &PerVUIterationsConfig{}
+// Make sure we implement the lib.ExecutorConfig and lib.ResumableExecutorConfig
+// interfaces
+var (
+	_ lib.ExecutorConfig          = &PerVUIterationsConfig{}
+	_ lib.ResumableExecutorConfig = &PerVUIterationsConfig{}
+)
 
 // GetVUs returns the scaled VUs for the executor.
 func (pvic PerVUIterationsConfig) GetVUs(et *lib.ExecutionTuple) int64 {
@@ -105,6 +109,22 @@ func (pvic PerVUIterationsConfig) GetExecutionRequirements(et *lib.ExecutionTupl
 	}
 }
 
+// GetResumedConfig returns the config of the rest of the iterations after the
+// checkpoint, or nil if there are none left. The VUs continue from the
+// average number of iterations they had started, since they aren't tracked
+// separately.
+func (pvic PerVUIterationsConfig) GetResumedConfig(checkpoint lib.ExecutorCheckpoint) lib.ExecutorConfig {
+	pvic.BaseConfig = pvic.getResumedBaseConfig(checkpoint)
+	pvic.MaxDuration = getResumedDuration(pvic.MaxDuration, checkpoint)
+	if pvic.VUs.Int64 > 0 {
+		pvic.Iterations.Int64 -= int64(checkpoint.Iterations) / pvic.VUs.Int64 //nolint:gosec
+	}
+	if pvic.Iterations.Int64 <= 0 || pvic.MaxDuration.TimeDuration() <= 0 {
+		return nil
+	}
+	return pvic
+}
+
 // NewExecutor creates a new PerVUIterations executor
 func (pvic PerVUIterationsConfig) NewExecutor(
 	es *lib.ExecutionState, logger *logrus.Entry,
@@ -192,7 +212,7 @@ func (pvi PerVUIterations) Run(parentCtx context.Context, out chan<- metrics.Sam
 	defer activeVUs.Wait()
 
 	regDurationDone := regDurationCtx.Done()
-	runIteration := getIterationRunner(pvi.executionState, pvi.logger)
+	runIteration := pvi.getIterationRunner(out)
 
 	returnVU := func(u lib.InitializedVU) {
 		pvi.executionState.ReturnVU(u, true)
@@ -207,7 +227,7 @@ func (pvi PerVUIterations) Run(parentCtx context.Context, out chan<- metrics.Sam
 
 		vuID := initVU.GetID()
 		activeVU := initVU.Activate(
-			getVUActivationParams(ctx, pvi.config.BaseConfig, returnVU,
+			getVUActivationParams(ctx, regDurationCtx, pvi.config.BaseConfig, returnVU,
 				pvi.nextIterationCounters))
 
 		for i := int64(0); i < iterations; i++ {
@@ -2,6 +2,7 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"sync"
@@ -43,6 +44,10 @@ type RampingArrivalRateConfig struct {
 	// absolutely hard limit on the number of VUs the executor will use
 	PreAllocatedVUs null.Int `json:"preAllocatedVUs"`
 	MaxVUs          null.Int `json:"maxVUs"`
+
+	// ArrivalDistribution randomizes the times between the iterations, they
+	// are evenly spaced if it isn't specified
+	ArrivalDistribution *ArrivalDistribution `json:"arrivalDistribution,omitempty"`
 }
 
 // NewRampingArrivalRateConfig returns a RampingArrivalRateConfig with default values
@@ -53,8 +58,13 @@ func NewRampingArrivalRateConfig(name string) *RampingArrivalRateConfig {
 	}
 }
 
-// Make sure we implement the lib.ExecutorConfig interface
-var _ lib.ExecutorConfig = &RampingArrivalRateConfig{}
+// Make sure we implement the lib.ExecutorConfig, lib.ArrivalRateConfig and
+// lib.ResumableExecutorConfig interfaces
+var (
+	_ lib.ExecutorConfig          = &RampingArrivalRateConfig{}
+	_ lib.ArrivalRateConfig       = &RampingArrivalRateConfig{}
+	_ lib.ResumableExecutorConfig = &RampingArrivalRateConfig{}
+)
 
 // GetPreAllocatedVUs is just a helper method that returns the scaled pre-allocated VUs.
 func (varc RampingArrivalRateConfig) GetPreAllocatedVUs(et *lib.ExecutionTuple) int64 {
@@ -78,8 +88,8 @@ func (varc RampingArrivalRateConfig) GetDescription(et *lib.ExecutionTuple) stri
 		getScaledArrivalRate(et.Segment, maxUnscaledRate, varc.TimeUnit.TimeDuration()),
 	).Float64()
 
-	return fmt.Sprintf("Up to %.2f iterations/s for %s over %d stages%s",
-		maxArrRatePerSec, sumStagesDuration(varc.Stages),
+	return fmt.Sprintf("Up to %.2f iterations/s%s for %s over %d stages%s",
+		maxArrRatePerSec, getArrivalsInfo(varc.ArrivalDistribution), sumStagesDuration(varc.Stages),
 		len(varc.Stages), varc.getBaseInfo(maxVUsRange))
 }
 
@@ -110,6 +120,10 @@ func (varc *RampingArrivalRateConfig) Validate() []error {
 		errors = append(errors, fmt.Errorf("maxVUs can't be less than preAllocatedVUs"))
 	}
 
+	if varc.ArrivalDistribution != nil {
+		errors = append(errors, varc.ArrivalDistribution.Validate()...)
+	}
+
 	return errors
 }
 
@@ -133,6 +147,32 @@ func (varc RampingArrivalRateConfig) GetExecutionRequirements(et *lib.ExecutionT
 	}
 }
 
+// GetArrivalRate returns the expected iterations per second of the execution
+// segment at the given time from the start of the executor.
+func (varc RampingArrivalRateConfig) GetArrivalRate(et *lib.ExecutionTuple, t time.Duration) float64 {
+	if t < 0 || t >= sumStagesDuration(varc.Stages) {
+		return 0
+	}
+	rate, _ := varc.getSchedule(null.Int{}).at(t)
+	return rate * float64(time.Second) * et.Segment.FloatLength()
+}
+
+// GetResumedConfig returns the config of the rest of the stages after the
+// checkpoint, starting from the rate at it, or nil if there are no stages
+// left.
+func (varc RampingArrivalRateConfig) GetResumedConfig(checkpoint lib.ExecutorCheckpoint) lib.ExecutorConfig {
+	varc.BaseConfig = varc.getResumedBaseConfig(checkpoint)
+	if !checkpoint.Started {
+		return &varc
+	}
+	startRate, stages := skipStages(varc.StartRate.Int64, varc.Stages, time.Duration(checkpoint.Elapsed))
+	if len(stages) == 0 {
+		return nil
+	}
+	varc.StartRate, varc.Stages = null.IntFrom(startRate), stages
+	return &varc
+}
+
 // NewExecutor creates a new RampingArrivalRate executor
 func (varc RampingArrivalRateConfig) NewExecutor(
 	es *lib.ExecutionState, logger *logrus.Entry,
@@ -140,6 +180,7 @@ func (varc RampingArrivalRateConfig) NewExecutor(
 	return &RampingArrivalRate{
 		BaseExecutor: NewBaseExecutor(&varc, es, logger),
 		config:       varc,
+		control:      newArrivalRateControl(&varc),
 	}, nil
 }
 
@@ -148,20 +189,77 @@ func (varc RampingArrivalRateConfig) HasWork(et *lib.ExecutionTuple) bool {
 	return varc.GetMaxVUs(et) > 0
 }
 
+// withUpdate returns the config with the update applied at the given time
+// since the start of the executor, the remaining stages are replaced with the
+// new ones.
+func (varc RampingArrivalRateConfig) withUpdate(update ArrivalRateUpdate, t time.Duration) (
+	*RampingArrivalRateConfig, error,
+) {
+	if update.Rate.Valid || update.Duration.Valid {
+		return nil, fmt.Errorf("the rate and the duration of the %s executor can't be changed, "+
+			"the remaining stages can be changed instead", rampingArrivalRateType)
+	}
+	if update.Stages != nil {
+		if errs := validateStages(update.Stages); len(errs) != 0 {
+			return nil, fmt.Errorf("invalid stages supplied: %s", lib.ConcatErrors(errs, ", "))
+		}
+		varc.Stages = append(cutStages(varc.StartRate.Int64, varc.Stages, t), update.Stages...)
+	}
+	if update.MaxVUs.Valid {
+		varc.MaxVUs = update.MaxVUs
+	}
+	if errs := varc.Validate(); len(errs) != 0 {
+		return nil, fmt.Errorf("invalid configuration supplied: %s", lib.ConcatErrors(errs, ", "))
+	}
+	return &varc, nil
+}
+
 // RampingArrivalRate tries to execute a specific number of iterations for a
-// specific period.
+// specific period. Its remaining stages and maxVUs can be changed while it's
+// running, it implements the LiveArrivalRate interface.
 // TODO: combine with the ConstantArrivalRate?
 type RampingArrivalRate struct {
 	*BaseExecutor
-	config RampingArrivalRateConfig
-	et     *lib.ExecutionTuple
+	config  RampingArrivalRateConfig
+	et      *lib.ExecutionTuple
+	control *arrivalRateControl
 }
 
-// Make sure we implement the lib.Executor interface.
-var _ lib.Executor = &RampingArrivalRate{}
+// Make sure we implement the lib.Executor and LiveArrivalRate interfaces.
+var (
+	_ lib.Executor    = &RampingArrivalRate{}
+	_ LiveArrivalRate = &RampingArrivalRate{}
+)
+
+// GetCurrentConfig returns the config of the executor with all of the updates
+// so far, the replaced stages are cut at the time of the update.
+func (varr *RampingArrivalRate) GetCurrentConfig() lib.ExecutorConfig {
+	return varr.control.getConfig()
+}
+
+// UpdateConfig changes the remaining stages or maxVUs of the executor, or
+// stops it, with the supplied ArrivalRateUpdate. The stages of an update that
+// is made before the executor has started replace all of them.
+func (varr *RampingArrivalRate) UpdateConfig(ctx context.Context, newConf interface{}) error {
+	update, ok := newConf.(ArrivalRateUpdate)
+	if !ok {
+		return errors.New("invalid config type")
+	}
+	return varr.control.update(ctx, update, func(config lib.ExecutorConfig) (lib.ExecutorConfig, error) {
+		current := *config.(*RampingArrivalRateConfig) //nolint:forcetypeassert
+		if update.Stages != nil {
+			current.Stages = nil
+		}
+		return current.withUpdate(update, 0)
+	})
+}
 
 // Init values needed for the execution
 func (varr *RampingArrivalRate) Init(_ context.Context) error {
+	err := validateArrivalsSeed(varr.config.ArrivalDistribution, varr.config.Name, varr.executionState.Test.Options)
+	if err != nil {
+		return err
+	}
 	// err should always be nil, because Init() won't be called for executors
 	// with no work, as determined by their config's HasWork() method.
 	et, err := varr.BaseExecutor.executionState.ExecutionTuple.GetNewExecutionTupleFromValue(varr.config.MaxVUs.Int64)
@@ -232,39 +330,103 @@ func (varr *RampingArrivalRate) Init(_ context.Context) error {
 // the striping algorithm from the lib.ExecutionTuple for additional speed up but this could
 // possibly be refactored if need for this arises.
 func (varc RampingArrivalRateConfig) cal(et *lib.ExecutionTuple, ch chan<- time.Duration) {
-	start, offsets, _ := et.GetStripedOffsets()
-	li := -1
-	// TODO: move this to a utility function, or directly what GetStripedOffsets uses once we see everywhere we will use it
-	next := func() int64 {
-		li++
-		return offsets[li%len(offsets)]
+	varc.getSchedule(null.Int{}).cal(et, ch, nil)
+}
+
+// rampingSchedule are the stages of a ramping arrival rate, which start at the
+// given time with the given (unscaled) rate and number of iterations already
+// done. It's the whole config before any updates and only the part after the
+// last update afterwards, so the updates only change the remaining stages.
+type rampingSchedule struct {
+	start  time.Duration
+	from   float64 // events per nanosecond
+	done   float64
+	stages []Stage
+	unit   float64 // the time unit of the stage targets
+	gaps   arrivalGaps
+}
+
+func (varc RampingArrivalRateConfig) getSchedule(randomSeed null.Int) rampingSchedule {
+	timeUnit := float64(varc.TimeUnit.Duration)
+	return rampingSchedule{
+		from:   float64(varc.StartRate.ValueOrZero()) / timeUnit,
+		stages: varc.Stages,
+		unit:   timeUnit,
+		gaps:   newArrivalGaps(varc.ArrivalDistribution, varc.Name, randomSeed),
+	}
+}
+
+// at returns the rate and the number of events done at the given time.
+func (s rampingSchedule) at(t time.Duration) (rate, done float64) {
+	stageStart, from, done := s.start, s.from, s.done
+	for _, stage := range s.stages {
+		to := float64(stage.Target.ValueOrZero()) / s.unit
+		dur := stage.Duration.TimeDuration()
+		if t < stageStart+dur {
+			x := float64(t - stageStart)
+			rate = from + (to-from)*x/float64(dur)
+			return rate, done + x*(from+rate)/2
+		}
+		done += float64(dur) * ((to-from)/2 + from)
+		from = to
+		stageStart += dur
 	}
+	return from, done
+}
+
+// update returns the schedule that starts at the given time, from the current
+// rate, and continues with the given stages.
+func (s rampingSchedule) update(t time.Duration, stages []Stage) rampingSchedule {
+	rate, done := s.at(t)
+	return rampingSchedule{start: t, from: rate, done: done, stages: stages, unit: s.unit, gaps: s.gaps}
+}
+
+// cal sends the times of the events of the execution segment on the channel,
+// until the schedule or the stop channel is done. The details are explained
+// in the comment of RampingArrivalRateConfig.cal().
+func (s rampingSchedule) cal(et *lib.ExecutionTuple, ch chan<- time.Duration, stop <-chan struct{}) {
+	// the algorithm works with area so the events start from 1 not 0, and
+	// the first one is the first of the segment after the ones already done
+	arrivals := s.gaps.newSequence(et, 1)
+	arrivals.skipPast(s.done)
 	defer close(ch) // TODO: maybe this is not a good design - closing a channel we get
 	var (
-		stageStart                   time.Duration
-		timeUnit                     = float64(varc.TimeUnit.Duration)
-		doneSoFar, endCount, to, dur float64
-		from                         = float64(varc.StartRate.ValueOrZero()) / timeUnit
-		// start .. starts at 0 but the algorithm works with area so we need to start from 1 not 0
-		i = float64(start + 1)
+		stageStart = s.start
+		doneSoFar  = s.done
+		endCount   = s.done
+		from       = s.from
+		to, dur    float64
 	)
+	send := func(t time.Duration) bool {
+		select {
+		case ch <- t:
+			return true
+		case <-stop:
+			return false
+		}
+	}
 
-	for _, stage := range varc.Stages {
-		to = float64(stage.Target.ValueOrZero()) / timeUnit
+	for _, stage := range s.stages {
+		to = float64(stage.Target.ValueOrZero()) / s.unit
 		dur = float64(stage.Duration.Duration)
 		if from != to { // ramp up/down
 			endCount += dur * ((to-from)/2 + from)
-			for ; i <= endCount; i += float64(next()) {
+			for ; arrivals.area <= endCount; arrivals.next() {
 				// TODO: try to twist this in a way to be able to get i (the only changing part)
 				// somewhere where it is less in the middle of the equation
+				i := arrivals.area
 				x := (from*dur - noNegativeSqrt(dur*(from*from*dur+2*(i-doneSoFar)*(to-from)))) / (from - to)
 
-				ch <- time.Duration(x) + stageStart
+				if !send(time.Duration(x) + stageStart) {
+					return
+				}
 			}
 		} else {
 			endCount += dur * to
-			for ; i <= endCount; i += float64(next()) {
-				ch <- time.Duration((i-doneSoFar)/to) + stageStart
+			for ; arrivals.area <= endCount; arrivals.next() {
+				if !send(time.Duration((arrivals.area-doneSoFar)/to) + stageStart) {
+					return
+				}
 			}
 		}
 		doneSoFar = endCount
@@ -273,6 +435,39 @@ func (varc RampingArrivalRateConfig) cal(et *lib.ExecutionTuple, ch chan<- time.
 	}
 }
 
+// cutStages returns the stages up to the given time, the last one is cut at
+// it with the target set to the rate at that time.
+func cutStages(startRate int64, stages []Stage, t time.Duration) []Stage {
+	result := make([]Stage, 0, len(stages)+1)
+	var stageStart time.Duration
+	from := float64(startRate)
+	for _, stage := range stages {
+		dur := stage.Duration.TimeDuration()
+		if t < stageStart+dur {
+			if x := t - stageStart; x > 0 {
+				to := float64(stage.Target.Int64)
+				rate := from + (to-from)*float64(x)/float64(dur)
+				result = append(result, Stage{
+					Duration: types.NullDurationFrom(x),
+					Target:   null.IntFrom(int64(math.Round(rate))),
+				})
+			}
+			return result
+		}
+		result = append(result, stage)
+		from = float64(stage.Target.Int64)
+		stageStart += dur
+	}
+	if t > stageStart && len(stages) > 0 {
+		// the rate stays at the last target until the end of the regular duration
+		result = append(result, Stage{
+			Duration: types.NullDurationFrom(t - stageStart),
+			Target:   stages[len(stages)-1].Target,
+		})
+	}
+	return result
+}
+
 // This is needed because, on some platforms (arm64), sometimes, even though we
 // in *reality* don't get negative results due to the nature of how float64 is
 // implemented, we get negative values (very close to the 0). This would get an
@@ -303,30 +498,33 @@ func noNegativeSqrt(f float64) float64 {
 //
 //nolint:funlen
 func (varr RampingArrivalRate) Run(parentCtx context.Context, out chan<- metrics.SampleContainer) (err error) {
+	config := *varr.control.start().(*RampingArrivalRateConfig) //nolint:forcetypeassert
 	segment := varr.executionState.ExecutionTuple.Segment
-	gracefulStop := varr.config.GetGracefulStop()
-	duration := sumStagesDuration(varr.config.Stages)
-	preAllocatedVUs := varr.config.GetPreAllocatedVUs(varr.executionState.ExecutionTuple)
-	maxVUs := varr.config.GetMaxVUs(varr.executionState.ExecutionTuple)
+	gracefulStop := config.GetGracefulStop()
+	duration := sumStagesDuration(config.Stages)
+	preAllocatedVUs := config.GetPreAllocatedVUs(varr.executionState.ExecutionTuple)
+	maxVUs := config.GetMaxVUs(varr.executionState.ExecutionTuple)
+	plannedMaxVUs := varr.config.GetMaxVUs(varr.executionState.ExecutionTuple)
 
 	// TODO: refactor and simplify
-	timeUnit := varr.config.TimeUnit.TimeDuration()
-	startArrivalRate := getScaledArrivalRate(segment, varr.config.StartRate.Int64, timeUnit)
-	maxUnscaledRate := getStagesUnscaledMaxTarget(varr.config.StartRate.Int64, varr.config.Stages)
+	timeUnit := config.TimeUnit.TimeDuration()
+	startArrivalRate := getScaledArrivalRate(segment, config.StartRate.Int64, timeUnit)
+	maxUnscaledRate := getStagesUnscaledMaxTarget(config.StartRate.Int64, config.Stages)
 	maxArrivalRatePerSec, _ := getArrivalRatePerSec(getScaledArrivalRate(segment, maxUnscaledRate, timeUnit)).Float64()
 	startTickerPeriod := getTickerPeriod(startArrivalRate)
 
 	// Make sure the log and the progress bar have accurate information
 	varr.logger.WithFields(logrus.Fields{
-		"maxVUs": maxVUs, "preAllocatedVUs": preAllocatedVUs, "duration": duration, "numStages": len(varr.config.Stages),
-		"startTickerPeriod": startTickerPeriod.Duration, "type": varr.config.GetType(),
+		"maxVUs": maxVUs, "preAllocatedVUs": preAllocatedVUs, "duration": duration, "numStages": len(config.Stages),
+		"startTickerPeriod": startTickerPeriod.Duration, "type": config.GetType(),
 	}).Debug("Starting executor run...")
 
 	activeVUsWg := &sync.WaitGroup{}
 
 	returnedVUs := make(chan struct{})
 	waitOnProgressChannel := make(chan struct{})
-	startTime, maxDurationCtx, regDurationCtx, cancel := getDurationContexts(parentCtx, duration, gracefulStop)
+	startTime, maxDurationCtx, regDurationCtx, cancel, setDuration := getLiveDurationContexts(
+		parentCtx, duration, gracefulStop)
 
 	vusPool := newActiveVUPool(varr.executionState)
 
@@ -340,15 +538,21 @@ func (varr RampingArrivalRate) Run(parentCtx context.Context, out chan<- metrics
 		activeVUsWg.Wait()
 		<-waitOnProgressChannel
 	}()
+	// The live updates are rejected once the executor has ended, which has
+	// to happen before the deferred wait for the graceful stop above, or the
+	// updates sent in the meantime would block until it's over.
+	defer varr.control.end()
 
 	activeVUsCount := uint64(0)
 	tickerPeriod := int64(startTickerPeriod.Duration)
+	currentDuration := int64(duration) // it's changed by the updates of the stages
 	vusFmt := pb.GetFixedLengthIntFormat(maxVUs)
 	itersFmt := pb.GetFixedLengthFloatFormat(maxArrivalRatePerSec, 2) + " iters/s"
 
 	progressFn := func() (float64, []string) {
 		currActiveVUs := atomic.LoadUint64(&activeVUsCount)
 		currentTickerPeriod := atomic.LoadInt64(&tickerPeriod)
+		duration := time.Duration(atomic.LoadInt64(&currentDuration))
 		progVUs := fmt.Sprintf(vusFmt+"/"+vusFmt+" VUs",
 			vusPool.Running(), currActiveVUs)
 
@@ -374,8 +578,8 @@ func (varr RampingArrivalRate) Run(parentCtx context.Context, out chan<- metrics
 
 	varr.progress.Modify(pb.WithProgress(progressFn))
 	maxDurationCtx = lib.WithScenarioState(maxDurationCtx, &lib.ScenarioState{
-		Name:       varr.config.Name,
-		Executor:   varr.config.Type,
+		Name:       config.Name,
+		Executor:   config.Type,
 		StartTime:  startTime,
 		ProgressFn: progressFn,
 	})
@@ -389,17 +593,17 @@ func (varr RampingArrivalRate) Run(parentCtx context.Context, out chan<- metrics
 		// is done in the goroutine started by activeVUPool.AddVU, whenever the
 		// VU finishes running an iteration. This results in a more accurate
 		// report of VUs that are _actually_ active.
-		varr.executionState.ReturnVU(u, false)
+		varr.control.returnVU(varr.executionState, u)
 		activeVUsWg.Done()
 	}
 
-	runIterationBasic := getIterationRunner(varr.executionState, varr.logger)
+	runIterationBasic := varr.getIterationRunner(out)
 
 	activateVU := func(initVU lib.InitializedVU) lib.ActiveVU {
 		activeVUsWg.Add(1)
 		activeVU := initVU.Activate(
 			getVUActivationParams(
-				maxDurationCtx, varr.config.BaseConfig, returnVU,
+				maxDurationCtx, regDurationCtx, config.BaseConfig, returnVU,
 				varr.nextIterationCounters))
 		atomic.AddUint64(&activeVUsCount, 1)
 
@@ -408,14 +612,14 @@ func (varr RampingArrivalRate) Run(parentCtx context.Context, out chan<- metrics
 	}
 
 	remainingUnplannedVUs := maxVUs - preAllocatedVUs
-	makeUnplannedVUCh := make(chan struct{})
+	makeUnplannedVUCh := make(chan bool) // true for the VUs beyond the execution plan
 	defer close(makeUnplannedVUCh)
 	go func() {
 		defer close(returnedVUs)
 
-		for range makeUnplannedVUCh {
+		for beyondPlan := range makeUnplannedVUCh {
 			varr.logger.Debug("Starting initialization of an unplanned VU...")
-			initVU, err := varr.executionState.GetUnplannedVU(maxDurationCtx, varr.logger)
+			initVU, err := varr.control.getUnplannedVU(maxDurationCtx, varr.executionState, varr.logger, beyondPlan)
 			if err != nil {
 				// TODO figure out how to return it to the Run goroutine
 				varr.logger.WithError(err).Error("Error while allocating unplanned VU")
@@ -438,27 +642,101 @@ func (varr RampingArrivalRate) Run(parentCtx context.Context, out chan<- metrics
 	regDurationDone := regDurationCtx.Done()
 	timer := time.NewTimer(time.Hour)
 	start := time.Now()
+	schedule := config.getSchedule(varr.executionState.Test.Options.RandomSeed)
 	ch := make(chan time.Duration, 10) // buffer 10 iteration times ahead
-	var prevTime time.Duration
+	stopCal := make(chan struct{})
+	go schedule.cal(varr.et, ch, stopCal)
+	defer func() { close(stopCal) }()
+
+	var (
+		prevTime time.Duration
+		waiting  <-chan time.Time // the timer channel while waiting for the next iteration
+	)
+	stopWaiting := func() {
+		if waiting != nil && !timer.Stop() {
+			<-timer.C
+		}
+		waiting = nil
+	}
 	shownWarning := false
 	metricTags := varr.getMetricTags(nil)
-	go varr.config.cal(varr.et, ch)
-	for nextTime := range ch {
+
+	applyUpdate := func(update ArrivalRateUpdate) error {
+		t := time.Since(start)
+		newConfig, err := config.withUpdate(update, t)
+		if err != nil {
+			return err
+		}
+		newMaxVUs := newConfig.GetMaxVUs(varr.executionState.ExecutionTuple)
+		initializedVUs := maxVUs - remainingUnplannedVUs
+		if newMaxVUs < initializedVUs {
+			return fmt.Errorf("maxVUs can't be lower than the %d VUs that were already initialized", initializedVUs)
+		}
+		newDuration := start.Sub(startTime) + sumStagesDuration(newConfig.Stages)
+		if update.Stop {
+			newDuration = time.Since(startTime)
+		}
+		if !setDuration(newDuration) {
+			return errScenarioEnded
+		}
+		atomic.StoreInt64(&currentDuration, int64(newDuration))
+
+		if update.Stages != nil {
+			// The iteration times that were already calculated are discarded
+			// and the ones of the new stages continue from the current rate
+			stopWaiting()
+			close(stopCal)
+			stopCal = make(chan struct{})
+			ch = make(chan time.Duration, 10)
+			schedule = schedule.update(t, update.Stages)
+			prevTime = t
+			go schedule.cal(varr.et, ch, stopCal)
+		}
+		remainingUnplannedVUs = newMaxVUs - initializedVUs
+		maxVUs = newMaxVUs
+		shownWarning = false
+
+		config = *newConfig
+		varr.control.setConfig(newConfig)
+		varr.logger.WithFields(logrus.Fields{
+			"maxVUs": maxVUs, "duration": newDuration, "numStages": len(config.Stages), "stop": update.Stop,
+		}).Debug("Updated the executor config")
+		return nil
+	}
+
+	for {
 		select {
 		case <-regDurationDone:
 			return nil
 		default:
 		}
-		atomic.StoreInt64(&tickerPeriod, int64(nextTime-prevTime))
-		prevTime = nextTime
-		b := time.Until(start.Add(nextTime))
-		if b > 0 { // TODO: have a minimal ?
-			timer.Reset(b)
-			select {
-			case <-timer.C:
-			case <-regDurationDone:
-				return nil
+
+		next := ch
+		if waiting != nil {
+			next = nil // the previous iteration hasn't started yet
+		}
+		select {
+		case <-regDurationDone:
+			return nil
+		case event := <-varr.control.updates:
+			event.err <- applyUpdate(event.update)
+			continue
+		case nextTime, ok := <-next:
+			if !ok {
+				// All of the iterations were started, but the stages can
+				// still be changed until the end of the regular duration
+				ch = nil
+				continue
+			}
+			atomic.StoreInt64(&tickerPeriod, int64(nextTime-prevTime))
+			prevTime = nextTime
+			if b := time.Until(start.Add(nextTime)); b > 0 { // TODO: have a minimal ?
+				timer.Reset(b)
+				waiting = timer.C
+				continue
 			}
+		case <-waiting:
+			waiting = nil
 		}
 
 		if vusPool.TryRunIteration() {
@@ -487,12 +765,11 @@ func (varr RampingArrivalRate) Run(parentCtx context.Context, out chan<- metrics
 		}
 
 		select {
-		case makeUnplannedVUCh <- struct{}{}: // great!
+		case makeUnplannedVUCh <- maxVUs-remainingUnplannedVUs >= plannedMaxVUs: // great!
 			remainingUnplannedVUs--
 		default: // we're already allocating a new VU
 		}
 	}
-	return nil
 }
 
 // activeVUPool controls the activeVUs
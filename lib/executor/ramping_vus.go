@@ -53,8 +53,12 @@ func NewRampingVUsConfig(name string) RampingVUsConfig {
 	}
 }
 
-// Make sure we implement the lib.ExecutorConfig interface
-var _ lib.ExecutorConfig = &RampingVUsConfig{}
+// Make sure we implement the lib.ExecutorConfig and lib.ResumableExecutorConfig
+// interfaces
+var (
+	_ lib.ExecutorConfig          = &RampingVUsConfig{}
+	_ lib.ResumableExecutorConfig = &RampingVUsConfig{}
+)
 
 // GetStartVUs is just a helper method that returns the scaled starting VUs.
 func (vlvc RampingVUsConfig) GetStartVUs(et *lib.ExecutionTuple) int64 {
@@ -448,6 +452,21 @@ func (vlvc RampingVUsConfig) GetExecutionRequirements(et *lib.ExecutionTuple) []
 	return steps
 }
 
+// GetResumedConfig returns the config of the rest of the stages after the
+// checkpoint, starting from the VUs at it, or nil if there are no stages left.
+func (vlvc RampingVUsConfig) GetResumedConfig(checkpoint lib.ExecutorCheckpoint) lib.ExecutorConfig {
+	vlvc.BaseConfig = vlvc.getResumedBaseConfig(checkpoint)
+	if !checkpoint.Started {
+		return vlvc
+	}
+	startVUs, stages := skipStages(vlvc.StartVUs.Int64, vlvc.Stages, time.Duration(checkpoint.Elapsed))
+	if len(stages) == 0 {
+		return nil
+	}
+	vlvc.StartVUs, vlvc.Stages = null.IntFrom(startVUs), stages
+	return vlvc
+}
+
 // NewExecutor creates a new RampingVUs executor
 func (vlvc RampingVUsConfig) NewExecutor(es *lib.ExecutionState, logger *logrus.Entry) (lib.Executor, error) {
 	return &RampingVUs{
@@ -488,7 +507,7 @@ func (vlv *RampingVUs) Init(_ context.Context) error {
 
 // Run constantly loops through as many iterations as possible on a variable
 // number of VUs for the specified stages.
-func (vlv *RampingVUs) Run(ctx context.Context, _ chan<- metrics.SampleContainer) error {
+func (vlv *RampingVUs) Run(ctx context.Context, out chan<- metrics.SampleContainer) error {
 	regularDuration, isFinal := lib.GetEndOffset(vlv.rawSteps)
 	if !isFinal {
 		return fmt.Errorf("%s expected raw end offset at %s to be final", vlv.config.GetName(), regularDuration)
@@ -522,7 +541,7 @@ func (vlv *RampingVUs) Run(ctx context.Context, _ chan<- metrics.SampleContainer
 		maxVUs:         maxVUs,
 		activeVUsCount: new(int64),
 		started:        startTime,
-		runIteration:   getIterationRunner(vlv.executionState, vlv.logger),
+		runIteration:   vlv.getIterationRunner(out),
 	}
 
 	progressFn := runState.makeProgressFn(regularDuration)
@@ -540,7 +559,7 @@ func (vlv *RampingVUs) Run(ctx context.Context, _ chan<- metrics.SampleContainer
 	defer runState.wg.Wait()
 	// this will populate stopped VUs and run runLoopsIfPossible on each VU
 	// handle in a new goroutine
-	runState.runLoopsIfPossible(maxDurationCtx, cancel)
+	runState.runLoopsIfPossible(maxDurationCtx, regularDurationCtx, cancel)
 
 	var (
 		handleNewMaxAllowedVUs = runState.maxAllowedVUsHandlerStrategy()
@@ -589,7 +608,7 @@ func (rs *rampingVUsRunState) makeProgressFn(regular time.Duration) (progressFn
 	}
 }
 
-func (rs *rampingVUsRunState) runLoopsIfPossible(ctx context.Context, cancel func()) {
+func (rs *rampingVUsRunState) runLoopsIfPossible(ctx, regularDurationCtx context.Context, cancel func()) {
 	getVU := func() (lib.InitializedVU, error) {
 		pvu, err := rs.executor.executionState.GetPlannedVU(rs.executor.logger, false)
 		if err != nil {
@@ -610,7 +629,7 @@ func (rs *rampingVUsRunState) runLoopsIfPossible(ctx context.Context, cancel fun
 	}
 	for i := uint64(0); i < rs.maxVUs; i++ {
 		rs.vuHandles[i] = newStoppedVUHandle(
-			ctx, getVU, returnVU, rs.executor.nextIterationCounters,
+			ctx, regularDurationCtx, getVU, returnVU, rs.executor.nextIterationCounters,
 			&rs.executor.config.BaseConfig, rs.executor.logger.WithField("vuNum", i))
 		go rs.vuHandles[i].runLoopsIfPossible(rs.runIteration) //nolint:contextcheck
 	}
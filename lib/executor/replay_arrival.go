@@ -0,0 +1,637 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/ui/pb"
+)
+
+const replayArrivalType = "replay-arrival"
+
+// The supported formats of the replayed request logs.
+const (
+	replayFormatCSV   = "csv"
+	replayFormatJSONL = "jsonl"
+)
+
+const defaultTimestampColumn = "timestamp"
+
+func init() {
+	lib.RegisterExecutorConfigType(
+		replayArrivalType,
+		func(name string, rawJSON []byte) (lib.ExecutorConfig, error) {
+			config := NewReplayArrivalConfig(name)
+			err := lib.StrictJSONUnmarshal(rawJSON, &config)
+			return config, err
+		},
+	)
+}
+
+// ReplayArrivalConfig stores the config for the replay-arrival executor
+type ReplayArrivalConfig struct {
+	BaseConfig
+	// File is the request log, relative to the script, with one record per
+	// line and the time of its arrival.
+	File null.String `json:"file"`
+	// Format is either csv, with a header row, or jsonl. By default, it's
+	// determined by the extension of the file.
+	Format null.String `json:"format"`
+	// TimestampColumn is the column or the JSON field with the arrival times,
+	// either as seconds since the Unix epoch or in the RFC 3339 format.
+	TimestampColumn null.String `json:"timestampColumn"`
+	// Speed is how much faster than the recorded arrivals they are replayed,
+	// e.g. they are twice as frequent with 2.
+	Speed       null.Float         `json:"speed"`
+	MaxDuration types.NullDuration `json:"maxDuration"`
+
+	// Initialize `PreAllocatedVUs` number of VUs, and if more than that are needed,
+	// they will be dynamically allocated, until `MaxVUs` is reached, which is an
+	// absolutely hard limit on the number of VUs the executor will use
+	PreAllocatedVUs null.Int `json:"preAllocatedVUs"`
+	MaxVUs          null.Int `json:"maxVUs"`
+}
+
+// NewReplayArrivalConfig returns a ReplayArrivalConfig with default values
+func NewReplayArrivalConfig(name string) *ReplayArrivalConfig {
+	return &ReplayArrivalConfig{
+		BaseConfig:      NewBaseConfig(name, replayArrivalType),
+		TimestampColumn: null.NewString(defaultTimestampColumn, false),
+		Speed:           null.NewFloat(1, false),
+		MaxDuration:     types.NewNullDuration(10*time.Minute, false),
+	}
+}
+
+// Make sure we implement the lib.ExecutorConfig and lib.ExecutorConfigWithFiles interfaces
+var (
+	_ lib.ExecutorConfig          = &ReplayArrivalConfig{}
+	_ lib.ExecutorConfigWithFiles = &ReplayArrivalConfig{}
+)
+
+// GetPreAllocatedVUs is just a helper method that returns the scaled pre-allocated VUs.
+func (rac ReplayArrivalConfig) GetPreAllocatedVUs(et *lib.ExecutionTuple) int64 {
+	return et.ScaleInt64(rac.PreAllocatedVUs.Int64)
+}
+
+// GetMaxVUs is just a helper method that returns the scaled max VUs.
+func (rac ReplayArrivalConfig) GetMaxVUs(et *lib.ExecutionTuple) int64 {
+	return et.ScaleInt64(rac.MaxVUs.Int64)
+}
+
+// GetFiles returns the request log, so it's loaded by the runner.
+func (rac ReplayArrivalConfig) GetFiles() []string {
+	return []string{rac.File.String}
+}
+
+// getFormat returns the format of the request log, either the configured one
+// or the one from the extension of the file.
+func (rac ReplayArrivalConfig) getFormat() string {
+	if rac.Format.Valid {
+		return rac.Format.String
+	}
+	switch strings.ToLower(path.Ext(rac.File.String)) {
+	case ".csv":
+		return replayFormatCSV
+	case ".jsonl", ".ndjson":
+		return replayFormatJSONL
+	default:
+		return ""
+	}
+}
+
+// GetDescription returns a human-readable description of the executor options
+func (rac ReplayArrivalConfig) GetDescription(et *lib.ExecutionTuple) string {
+	preAllocatedVUs, maxVUs := rac.GetPreAllocatedVUs(et), rac.GetMaxVUs(et)
+	maxVUsRange := fmt.Sprintf("maxVUs: %d", preAllocatedVUs)
+	if maxVUs > preAllocatedVUs {
+		maxVUsRange += fmt.Sprintf("-%d", maxVUs)
+	}
+
+	var speed string
+	if rac.Speed.Float64 != 1 {
+		speed = fmt.Sprintf(" at %gx speed", rac.Speed.Float64)
+	}
+	return fmt.Sprintf("Replay of the arrivals in %s%s%s", rac.File.String, speed,
+		rac.getBaseInfo(maxVUsRange, fmt.Sprintf("maxDuration: %s", rac.MaxDuration.Duration)))
+}
+
+// Validate makes sure all options are configured and valid
+func (rac *ReplayArrivalConfig) Validate() []error {
+	errors := rac.BaseConfig.Validate()
+	if !rac.File.Valid || rac.File.String == "" {
+		errors = append(errors, fmt.Errorf("the request log file isn't specified"))
+	} else if format := rac.getFormat(); format != replayFormatCSV && format != replayFormatJSONL {
+		if rac.Format.Valid {
+			errors = append(errors, fmt.Errorf(
+				"the format must be %s or %s, but is '%s'", replayFormatCSV, replayFormatJSONL, format))
+		} else {
+			errors = append(errors, fmt.Errorf(
+				"the format of '%s' can't be determined from its extension, it has to be specified", rac.File.String))
+		}
+	}
+
+	if rac.TimestampColumn.String == "" {
+		errors = append(errors, fmt.Errorf("the timestampColumn can't be empty"))
+	}
+
+	if rac.Speed.Float64 <= 0 || math.IsInf(rac.Speed.Float64, 0) {
+		errors = append(errors, fmt.Errorf("the speed must be more than 0"))
+	}
+
+	if rac.MaxDuration.TimeDuration() < minDuration {
+		errors = append(errors, fmt.Errorf(
+			"the maxDuration must be at least %s, but is %s", minDuration, rac.MaxDuration,
+		))
+	}
+
+	if !rac.PreAllocatedVUs.Valid {
+		errors = append(errors, fmt.Errorf("the number of preAllocatedVUs isn't specified"))
+	} else if rac.PreAllocatedVUs.Int64 < 0 {
+		errors = append(errors, fmt.Errorf("the number of preAllocatedVUs can't be negative"))
+	}
+
+	if !rac.MaxVUs.Valid {
+		// TODO: don't change the config while validating
+		rac.MaxVUs.Int64 = rac.PreAllocatedVUs.Int64
+	} else if rac.MaxVUs.Int64 < rac.PreAllocatedVUs.Int64 {
+		errors = append(errors, fmt.Errorf("maxVUs can't be less than preAllocatedVUs"))
+	}
+
+	return errors
+}
+
+// GetExecutionRequirements returns the number of required VUs to run the
+// executor for its whole duration (disregarding any startTime), including the
+// maximum waiting time for any iterations to gracefully stop. This is used by
+// the execution scheduler in its VU reservation calculations, so it knows how
+// many VUs to pre-initialize.
+//
+// The request log isn't loaded yet, so the executor is planned for its whole
+// maxDuration, even though it finishes as soon as all records are replayed.
+func (rac ReplayArrivalConfig) GetExecutionRequirements(et *lib.ExecutionTuple) []lib.ExecutionStep {
+	return []lib.ExecutionStep{
+		{
+			TimeOffset:      0,
+			PlannedVUs:      uint64(rac.GetPreAllocatedVUs(et)),
+			MaxUnplannedVUs: uint64(rac.GetMaxVUs(et) - rac.GetPreAllocatedVUs(et)),
+		}, {
+			TimeOffset:      rac.MaxDuration.TimeDuration() + rac.GracefulStop.TimeDuration(),
+			PlannedVUs:      0,
+			MaxUnplannedVUs: 0,
+		},
+	}
+}
+
+// NewExecutor creates a new ReplayArrival executor and loads its request log
+func (rac ReplayArrivalConfig) NewExecutor(
+	es *lib.ExecutionState, logger *logrus.Entry,
+) (lib.Executor, error) {
+	loader, ok := es.Test.Runner.(lib.FileLoader)
+	if !ok {
+		return nil, fmt.Errorf("the %s executor isn't supported by the current runner", replayArrivalType)
+	}
+	data, err := loader.LoadFile(rac.File.String)
+	if err != nil {
+		return nil, err
+	}
+	records, err := parseReplayRecords(data, rac.getFormat(), rac.TimestampColumn.String)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse the request log %s: %w", rac.File.String, err)
+	}
+	return &ReplayArrival{
+		BaseExecutor: NewBaseExecutor(&rac, es, logger),
+		config:       rac,
+		allRecords:   records,
+	}, nil
+}
+
+// HasWork reports whether there is any work to be done for the given execution segment.
+func (rac ReplayArrivalConfig) HasWork(et *lib.ExecutionTuple) bool {
+	return rac.GetMaxVUs(et) > 0
+}
+
+// replayRecord is a record of the request log, with its arrival time
+// relative to the first one.
+type replayRecord struct {
+	offset time.Duration
+	data   map[string]interface{}
+}
+
+// parseReplayRecords parses the request log and returns its records, sorted
+// by their arrival times.
+func parseReplayRecords(data []byte, format, timestampColumn string) ([]replayRecord, error) {
+	var (
+		records    []replayRecord
+		timestamps []time.Time
+	)
+	add := func(line int, record map[string]interface{}) error {
+		timestamp, err := parseReplayTimestamp(record[timestampColumn])
+		if err != nil {
+			return fmt.Errorf("line %d: invalid %s: %w", line, timestampColumn, err)
+		}
+		records = append(records, replayRecord{data: record})
+		timestamps = append(timestamps, timestamp)
+		return nil
+	}
+
+	switch format {
+	case replayFormatCSV:
+		r := csv.NewReader(bytes.NewReader(data))
+		r.ReuseRecord = true
+		header, err := r.Read()
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read the header: %w", err)
+		}
+		header = append([]string(nil), header...)
+		for line := 2; ; line++ {
+			row, err := r.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			record := make(map[string]interface{}, len(header))
+			for i, column := range header {
+				record[column] = row[i]
+			}
+			if err = add(line, record); err != nil {
+				return nil, err
+			}
+		}
+	case replayFormatJSONL:
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(nil, 1<<20)
+		for line := 1; scanner.Scan(); line++ {
+			if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+				continue
+			}
+			var record map[string]interface{}
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			if err := add(line, record); err != nil {
+				return nil, err
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported format '%s'", format)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("there aren't any records")
+	}
+
+	// The logs are usually sorted already, but not always strictly, e.g. when
+	// the requests are logged when they finish
+	indexes := make([]int, len(records))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return timestamps[indexes[i]].Before(timestamps[indexes[j]])
+	})
+	first := timestamps[indexes[0]]
+	sorted := make([]replayRecord, len(records))
+	for i, index := range indexes {
+		sorted[i] = records[index]
+		sorted[i].offset = timestamps[index].Sub(first)
+	}
+	return sorted, nil
+}
+
+// parseReplayTimestamp parses the arrival time of a record, which can be the
+// number of seconds since the Unix epoch or an RFC 3339 time.
+func parseReplayTimestamp(value interface{}) (time.Time, error) {
+	var seconds float64
+	switch v := value.(type) {
+	case float64:
+		seconds = v
+	case string:
+		var err error
+		if seconds, err = strconv.ParseFloat(strings.TrimSpace(v), 64); err != nil {
+			return time.Parse(time.RFC3339Nano, strings.TrimSpace(v))
+		}
+	case nil:
+		return time.Time{}, fmt.Errorf("it's missing")
+	default:
+		return time.Time{}, fmt.Errorf("unsupported value %v", v)
+	}
+	whole, fraction := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(fraction*float64(time.Second))), nil
+}
+
+// ReplayArrival replays the arrivals of the records of a request log, each
+// record is passed to the iteration started for it.
+type ReplayArrival struct {
+	*BaseExecutor
+	config     ReplayArrivalConfig
+	et         *lib.ExecutionTuple
+	allRecords []replayRecord
+	records    []replayRecord
+}
+
+// Make sure we implement the lib.Executor interface.
+var _ lib.Executor = &ReplayArrival{}
+
+// Init values needed for the execution
+func (ra *ReplayArrival) Init(_ context.Context) error {
+	// err should always be nil, because Init() won't be called for executors
+	// with no work, as determined by their config's HasWork() method.
+	et, err := ra.BaseExecutor.executionState.ExecutionTuple.GetNewExecutionTupleFromValue(ra.config.MaxVUs.Int64)
+	if err != nil {
+		return err
+	}
+	ra.et = et
+	ra.iterSegIndex = lib.NewSegmentedIndex(et)
+
+	// The records are striped across the execution segments the same way the
+	// iterations of the arrival-rate executors are, so all of the segments
+	// combined replay all of them.
+	ra.records = nil
+	start, offsets, _ := et.GetStripedOffsets()
+	for li, gi := 0, start; gi < int64(len(ra.allRecords)); li, gi = li+1, gi+offsets[li%len(offsets)] {
+		record := ra.allRecords[gi]
+		record.offset = time.Duration(float64(record.offset) / ra.config.Speed.Float64)
+		ra.records = append(ra.records, record)
+	}
+	ra.allRecords = nil
+
+	return nil
+}
+
+// Run replays the arrivals of the records of the execution segment.
+//
+//nolint:funlen
+func (ra ReplayArrival) Run(parentCtx context.Context, out chan<- metrics.SampleContainer) (err error) {
+	gracefulStop := ra.config.GetGracefulStop()
+	maxDuration := ra.config.MaxDuration.TimeDuration()
+	preAllocatedVUs := ra.config.GetPreAllocatedVUs(ra.executionState.ExecutionTuple)
+	maxVUs := ra.config.GetMaxVUs(ra.executionState.ExecutionTuple)
+	records := ra.records
+
+	// The replay finishes with its last record, or at the maxDuration
+	duration := maxDuration
+	if len(records) > 0 && records[len(records)-1].offset < maxDuration {
+		duration = records[len(records)-1].offset
+	} else if len(records) > 0 {
+		ra.logger.Warnf("The replay is longer than the maxDuration of %s, the records after it won't be replayed",
+			maxDuration)
+	}
+
+	// Make sure the log and the progress bar have accurate information
+	ra.logger.WithFields(logrus.Fields{
+		"maxVUs": maxVUs, "preAllocatedVUs": preAllocatedVUs, "records": len(records),
+		"duration": duration, "type": ra.config.GetType(),
+	}).Debug("Starting executor run...")
+
+	activeVUsWg := &sync.WaitGroup{}
+
+	returnedVUs := make(chan struct{})
+	waitOnProgressChannel := make(chan struct{})
+	startTime, maxDurationCtx, regDurationCtx, cancel := getDurationContexts(parentCtx, maxDuration, gracefulStop)
+	defer func() {
+		cancel()
+		<-waitOnProgressChannel
+	}()
+
+	vusPool := newReplayVUPool(ra.executionState)
+	defer func() {
+		// Make sure all VUs aren't executing iterations anymore, for the cancel()
+		// below to deactivate them.
+		<-returnedVUs
+		// first close the vusPool so we wait for the gracefulShutdown
+		vusPool.Close()
+		cancel()
+		activeVUsWg.Wait()
+	}()
+	activeVUsCount := uint64(0)
+	startedRecords := new(uint64)
+
+	vusFmt := pb.GetFixedLengthIntFormat(maxVUs)
+	recordsFmt := pb.GetFixedLengthIntFormat(int64(len(records)))
+	progressFn := func() (float64, []string) {
+		spent := time.Since(startTime)
+		currActiveVUs := atomic.LoadUint64(&activeVUsCount)
+		progVUs := fmt.Sprintf(vusFmt+"/"+vusFmt+" VUs", vusPool.Running(), currActiveVUs)
+		currStarted := atomic.LoadUint64(startedRecords)
+		progRecords := fmt.Sprintf(recordsFmt+"/"+recordsFmt+" records", currStarted, len(records))
+
+		right := []string{progVUs, duration.String(), progRecords}
+		if spent > duration {
+			return 1, right
+		}
+		right[1] = fmt.Sprintf("%s/%s", pb.GetFixedLengthDuration(spent, duration), duration)
+
+		if len(records) == 0 {
+			return 1, right
+		}
+		return float64(currStarted) / float64(len(records)), right
+	}
+	ra.progress.Modify(pb.WithProgress(progressFn))
+	maxDurationCtx = lib.WithScenarioState(maxDurationCtx, &lib.ScenarioState{
+		Name:       ra.config.Name,
+		Executor:   ra.config.Type,
+		StartTime:  startTime,
+		ProgressFn: progressFn,
+	})
+
+	go func() {
+		trackProgress(parentCtx, maxDurationCtx, regDurationCtx, &ra, progressFn)
+		close(waitOnProgressChannel)
+	}()
+
+	returnVU := func(u lib.InitializedVU) {
+		// Return the VU without decreasing the global active VU counter, which
+		// is done in the goroutine started by replayVUPool.AddVU, whenever the
+		// VU finishes running an iteration. This results in a more accurate
+		// report of VUs that are _actually_ active.
+		ra.executionState.ReturnVU(u, false)
+		activeVUsWg.Done()
+	}
+
+	runIterationBasic := ra.getIterationRunner(out)
+	activateVU := func(initVU lib.InitializedVU) lib.ActiveVU {
+		activeVUsWg.Add(1)
+		// The record is only changed and read by the goroutine of the VU
+		var record map[string]interface{}
+		params := getVUActivationParams(maxDurationCtx, regDurationCtx, ra.config.BaseConfig, returnVU, ra.nextIterationCounters)
+		params.GetIterationData = func() interface{} {
+			return record
+		}
+		activeVU := initVU.Activate(params)
+		atomic.AddUint64(&activeVUsCount, 1)
+		vusPool.AddVU(maxDurationCtx, activeVU, func(ctx context.Context, avu lib.ActiveVU, r replayRecord) bool {
+			record = r.data
+			return runIterationBasic(ctx, avu)
+		})
+		return activeVU
+	}
+
+	remainingUnplannedVUs := maxVUs - preAllocatedVUs
+	makeUnplannedVUCh := make(chan struct{})
+	defer close(makeUnplannedVUCh)
+	go func() {
+		defer close(returnedVUs)
+		for range makeUnplannedVUCh {
+			ra.logger.Debug("Starting initialization of an unplanned VU...")
+			initVU, err := ra.executionState.GetUnplannedVU(maxDurationCtx, ra.logger)
+			if err != nil {
+				// TODO figure out how to return it to the Run goroutine
+				ra.logger.WithError(err).Error("Error while allocating unplanned VU")
+			} else {
+				ra.logger.Debug("The unplanned VU finished initializing successfully!")
+				activateVU(initVU)
+			}
+		}
+	}()
+
+	// Get the pre-allocated VUs in the local buffer
+	for i := int64(0); i < preAllocatedVUs; i++ {
+		initVU, err := ra.executionState.GetPlannedVU(ra.logger, false)
+		if err != nil {
+			return err
+		}
+		activateVU(initVU)
+	}
+
+	droppedIterationMetric := ra.executionState.Test.BuiltinMetrics.DroppedIterations
+	metricTags := ra.getMetricTags(nil)
+	pushDropped := func(count int) {
+		metrics.PushIfNotDone(parentCtx, out, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: droppedIterationMetric,
+				Tags:   metricTags,
+			},
+			Time:  time.Now(),
+			Value: float64(count),
+		})
+	}
+
+	shownWarning := false
+	timer := time.NewTimer(time.Hour * 24)
+	defer timer.Stop()
+	for i, record := range records {
+		if wait := record.offset - time.Since(startTime); wait > 0 {
+			timer.Reset(wait)
+			select {
+			case <-timer.C:
+			case <-regDurationCtx.Done():
+				// The records after the maxDuration are never replayed
+				pushDropped(len(records) - i)
+				return nil
+			}
+		}
+		atomic.AddUint64(startedRecords, 1)
+		if vusPool.TryRunIteration(record) {
+			continue
+		}
+
+		// Since there aren't any free VUs available, consider this iteration
+		// dropped - we aren't going to try to recover it, but
+		pushDropped(1)
+
+		// We'll try to start allocating another VU in the background,
+		// non-blockingly, if we have remainingUnplannedVUs...
+		if remainingUnplannedVUs == 0 {
+			if !shownWarning {
+				ra.logger.Warningf("Insufficient VUs, reached %d active VUs and cannot initialize more", maxVUs)
+				shownWarning = true
+			}
+			continue
+		}
+
+		select {
+		case makeUnplannedVUCh <- struct{}{}: // great!
+			remainingUnplannedVUs--
+		default: // we're already allocating a new VU
+		}
+	}
+
+	return nil
+}
+
+// replayVUPool is like the activeVUPool, but every iteration it starts gets
+// the record it replays.
+type replayVUPool struct {
+	records   chan replayRecord
+	running   uint64
+	execState *lib.ExecutionState
+	wg        sync.WaitGroup
+}
+
+// newReplayVUPool returns a replayVUPool.
+func newReplayVUPool(es *lib.ExecutionState) *replayVUPool {
+	return &replayVUPool{
+		records:   make(chan replayRecord),
+		execState: es,
+	}
+}
+
+// TryRunIteration invokes a request to execute a new iteration for the record.
+// When there are no available VUs to process the request then false is
+// returned.
+func (p *replayVUPool) TryRunIteration(record replayRecord) bool {
+	select {
+	case p.records <- record:
+		return true
+	default:
+		return false
+	}
+}
+
+// Running returns the number of the currently running VUs.
+func (p *replayVUPool) Running() uint64 {
+	return atomic.LoadUint64(&p.running)
+}
+
+// AddVU adds the active VU to the pool of VUs for handling the incoming
+// records. When a new record is accepted the runfn function is executed.
+func (p *replayVUPool) AddVU(
+	ctx context.Context, avu lib.ActiveVU, runfn func(context.Context, lib.ActiveVU, replayRecord) bool,
+) {
+	p.wg.Add(1)
+	ch := make(chan struct{})
+	go func() {
+		defer p.wg.Done()
+
+		close(ch)
+		for record := range p.records {
+			atomic.AddUint64(&p.running, uint64(1))
+			p.execState.ModCurrentlyActiveVUsCount(+1)
+			runfn(ctx, avu, record)
+			p.execState.ModCurrentlyActiveVUsCount(-1)
+			atomic.AddUint64(&p.running, ^uint64(0))
+		}
+	}()
+	<-ch
+}
+
+// Close stops the pool from accepting records
+// then it will wait for all on-going iterations to complete.
+func (p *replayVUPool) Close() {
+	close(p.records)
+	p.wg.Wait()
+}
@@ -0,0 +1,210 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/testutils/minirunner"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+func getTestReplayArrivalConfig(file string) *ReplayArrivalConfig {
+	config := NewReplayArrivalConfig("replay")
+	config.File = null.StringFrom(file)
+	config.PreAllocatedVUs = null.IntFrom(10)
+	config.MaxVUs = null.IntFrom(20)
+	config.GracefulStop = types.NullDurationFrom(time.Second)
+	return config
+}
+
+func TestParseReplayRecords(t *testing.T) {
+	t.Parallel()
+
+	csvLog := "timestamp,path\n" +
+		"2024-05-01T10:00:01.5Z,/b\n" +
+		"2024-05-01T10:00:00Z,/a\n" +
+		"1714557603,/c\n"
+	records, err := parseReplayRecords([]byte(csvLog), replayFormatCSV, "timestamp")
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, []replayRecord{
+		{offset: 0, data: map[string]interface{}{"timestamp": "2024-05-01T10:00:00Z", "path": "/a"}},
+		{offset: 1500 * time.Millisecond, data: map[string]interface{}{"timestamp": "2024-05-01T10:00:01.5Z", "path": "/b"}},
+		{offset: 3 * time.Second, data: map[string]interface{}{"timestamp": "1714557603", "path": "/c"}},
+	}, records)
+
+	jsonlLog := `{"ts": 100.25, "method": "GET"}` + "\n\n" + `{"ts": 100, "method": "POST", "size": 12}` + "\n"
+	records, err = parseReplayRecords([]byte(jsonlLog), replayFormatJSONL, "ts")
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, time.Duration(0), records[0].offset)
+	assert.Equal(t, map[string]interface{}{"ts": 100.0, "method": "POST", "size": 12.0}, records[0].data)
+	assert.Equal(t, 250*time.Millisecond, records[1].offset)
+
+	testCases := []struct {
+		data, format, err string
+	}{
+		{data: "", format: replayFormatCSV, err: "couldn't read the header"},
+		{data: "timestamp\n", format: replayFormatCSV, err: "there aren't any records"},
+		{data: "path\n/a\n", format: replayFormatCSV, err: "line 2: invalid timestamp: it's missing"},
+		{data: "timestamp\nyesterday\n", format: replayFormatCSV, err: "line 2: invalid timestamp"},
+		{data: "timestamp,path\n1,/a\n2\n", format: replayFormatCSV, err: "wrong number of fields"},
+		{data: `{"timestamp": 1}` + "\n{", format: replayFormatJSONL, err: "line 2"},
+		{data: `{"timestamp": true}`, format: replayFormatJSONL, err: "unsupported value true"},
+		{data: `{"timestamp": 1}`, format: "xml", err: "unsupported format"},
+	}
+	for _, tc := range testCases {
+		_, err := parseReplayRecords([]byte(tc.data), tc.format, "timestamp")
+		assert.ErrorContains(t, err, tc.err, tc.data)
+	}
+}
+
+func TestReplayArrivalConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	config := getTestReplayArrivalConfig("logs/access.ndjson")
+	require.Empty(t, config.Validate())
+	assert.Equal(t, replayFormatJSONL, config.getFormat())
+	assert.Equal(t, []string{"logs/access.ndjson"}, config.GetFiles())
+
+	et, err := lib.NewExecutionTuple(nil, nil)
+	require.NoError(t, err)
+	config.Speed = null.FloatFrom(2.5)
+	assert.Equal(t,
+		"Replay of the arrivals in logs/access.ndjson at 2.5x speed (maxVUs: 10-20, maxDuration: 10m0s, gracefulStop: 1s)",
+		config.GetDescription(et))
+
+	config = getTestReplayArrivalConfig("access.log")
+	errs := config.Validate()
+	require.Len(t, errs, 1)
+	assert.ErrorContains(t, errs[0], "can't be determined from its extension")
+	config.Format = null.StringFrom(replayFormatCSV)
+	assert.Empty(t, config.Validate())
+
+	config.Speed = null.FloatFrom(0)
+	config.MaxVUs = null.IntFrom(5)
+	config.TimestampColumn = null.StringFrom("")
+	assert.Len(t, config.Validate(), 3)
+}
+
+func getTestReplayLog(records int, interval time.Duration) []byte {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	var log strings.Builder
+	log.WriteString("timestamp,id\n")
+	for i := 0; i < records; i++ {
+		fmt.Fprintf(&log, "%s,%d\n", start.Add(time.Duration(i)*interval).Format(time.RFC3339Nano), i)
+	}
+	return []byte(log.String())
+}
+
+func TestReplayArrivalSegments(t *testing.T) {
+	t.Parallel()
+
+	runner := &minirunner.MiniRunner{Files: map[string][]byte{"access.csv": getTestReplayLog(100, time.Second)}}
+	sequence := "0,1/4,1/3,1/2,1"
+	var ids []string
+	for _, segment := range []string{"0:1/4", "1/4:1/3", "1/3:1/2", "1/2:1"} {
+		test := setupExecutorTest(t, segment, sequence, lib.Options{}, runner, getTestReplayArrivalConfig("access.csv"))
+		ra, ok := test.executor.(*ReplayArrival)
+		require.True(t, ok)
+		assert.NotEmpty(t, ra.records, segment)
+		for _, record := range ra.records {
+			ids = append(ids, record.data["id"].(string)) //nolint:forcetypeassert
+		}
+		test.cancel()
+	}
+
+	// The records of all of the segments combined are all of the records
+	expected := make([]string, 100)
+	for i := range expected {
+		expected[i] = fmt.Sprint(i)
+	}
+	sort.Strings(expected)
+	sort.Strings(ids)
+	assert.Equal(t, expected, ids)
+}
+
+func TestReplayArrivalRun(t *testing.T) {
+	t.Parallel()
+
+	var (
+		lock     sync.Mutex
+		replayed []string
+	)
+	runner := &minirunner.MiniRunner{
+		Files: map[string][]byte{"access.csv": getTestReplayLog(20, 100*time.Millisecond)},
+		Fn: func(_ context.Context, state *lib.State, _ chan<- metrics.SampleContainer) error {
+			record, ok := state.GetScenarioIterationData().(map[string]interface{})
+			require.True(t, ok)
+			lock.Lock()
+			replayed = append(replayed, record["id"].(string)) //nolint:forcetypeassert
+			lock.Unlock()
+			return nil
+		},
+	}
+	config := getTestReplayArrivalConfig("access.csv")
+	config.Speed = null.FloatFrom(2)
+	test := setupExecutorTest(t, "", "", lib.Options{}, runner, config)
+	defer test.cancel()
+
+	startTime := time.Now()
+	require.NoError(t, test.executor.Run(test.ctx, make(chan metrics.SampleContainer, 100)))
+	// 20 records 100ms apart, replayed twice as fast
+	assert.InDelta(t, 950*time.Millisecond, time.Since(startTime), float64(300*time.Millisecond))
+
+	require.Len(t, replayed, 20)
+	for i, id := range replayed {
+		assert.Equal(t, fmt.Sprint(i), id)
+	}
+	assert.Equal(t, uint64(20), test.state.GetFullIterationCount())
+	progress, _ := test.executor.GetProgress().Progress()
+	assert.Equal(t, 1.0, progress)
+}
+
+func TestReplayArrivalMaxDuration(t *testing.T) {
+	t.Parallel()
+
+	runner := &minirunner.MiniRunner{
+		Files: map[string][]byte{"access.csv": getTestReplayLog(10, time.Second)},
+	}
+	config := getTestReplayArrivalConfig("access.csv")
+	config.MaxDuration = types.NullDurationFrom(1500 * time.Millisecond)
+	config.GracefulStop = types.NullDurationFrom(0)
+	test := setupExecutorTest(t, "", "", lib.Options{}, runner, config)
+	defer test.cancel()
+
+	engineOut := make(chan metrics.SampleContainer, 100)
+	require.NoError(t, test.executor.Run(test.ctx, engineOut))
+	assert.Equal(t, uint64(2), test.state.GetFullIterationCount())
+
+	var dropped float64
+	for _, sample := range metrics.GetBufferedSamples(engineOut) {
+		for _, s := range sample.GetSamples() {
+			if s.Metric.Name == metrics.DroppedIterationsName {
+				dropped += s.Value
+			}
+		}
+	}
+	assert.Equal(t, 8.0, dropped)
+}
+
+func TestReplayArrivalMissingFile(t *testing.T) {
+	t.Parallel()
+
+	et, err := lib.NewExecutionTuple(nil, nil)
+	require.NoError(t, err)
+	es := lib.NewExecutionState(getTestRunState(t, lib.Options{}, &minirunner.MiniRunner{}), et, 10, 20)
+	_, err = getTestReplayArrivalConfig("missing.csv").NewExecutor(es, nil)
+	assert.ErrorContains(t, err, `file "missing.csv" not found`)
+}
@@ -0,0 +1,96 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+)
+
+// ScenarioExec is the exported function that the iterations of a scenario run,
+// or its traffic mix, i.e. several functions with their weights, like
+// {"browse": 70, "search": 20, "checkout": 10}. Every iteration of a scenario
+// with a traffic mix runs one of its functions, picked at random by their
+// weights. The functions are validated externally.
+type ScenarioExec struct {
+	Fn      null.String
+	Weights map[string]float64
+}
+
+// ScenarioExecFrom returns the exec of a scenario that runs a single function.
+func ScenarioExecFrom(fn string) ScenarioExec {
+	return ScenarioExec{Fn: null.StringFrom(fn)}
+}
+
+// MarshalJSON returns the function, or the weights of the traffic mix.
+func (e ScenarioExec) MarshalJSON() ([]byte, error) {
+	if e.Weights != nil {
+		return json.Marshal(e.Weights)
+	}
+	return json.Marshal(e.Fn)
+}
+
+// UnmarshalJSON accepts both the name of a single function and the weights of
+// the functions of a traffic mix.
+func (e *ScenarioExec) UnmarshalJSON(data []byte) error {
+	*e = ScenarioExec{}
+	if len(data) > 0 && data[0] == '{' {
+		return json.Unmarshal(data, &e.Weights)
+	}
+	return json.Unmarshal(data, &e.Fn)
+}
+
+// Validate makes sure the function, or the functions and the weights of the
+// traffic mix, are valid.
+func (e ScenarioExec) Validate() []error {
+	var errors []error
+	if e.Fn.Valid && e.Fn.String == "" {
+		errors = append(errors, fmt.Errorf("exec value cannot be empty"))
+	}
+	if e.Weights != nil && len(e.Weights) == 0 {
+		errors = append(errors, fmt.Errorf("the exec traffic mix needs at least one function"))
+	}
+	for _, w := range e.getWeights() {
+		if w.Fn == "" {
+			errors = append(errors, fmt.Errorf("the functions of the exec traffic mix cannot be empty"))
+		}
+		if w.Weight <= 0 {
+			errors = append(errors, fmt.Errorf("the weight of the exec function '%s' should be more than 0", w.Fn))
+		}
+	}
+	return errors
+}
+
+// getWeights returns the functions of the traffic mix sorted by their names,
+// so the iterations pick the same functions in every test run with the same
+// random seed.
+func (e ScenarioExec) getWeights() []lib.ExecWeight {
+	if len(e.Weights) == 0 {
+		return nil
+	}
+	weights := make([]lib.ExecWeight, 0, len(e.Weights))
+	for fn, weight := range e.Weights {
+		weights = append(weights, lib.ExecWeight{Fn: fn, Weight: weight})
+	}
+	sort.Slice(weights, func(i, j int) bool { return weights[i].Fn < weights[j].Fn })
+	return weights
+}
+
+// getInfo returns a description of the function or of the traffic mix for the
+// executors' descriptions, it's empty if neither is specified.
+func (e ScenarioExec) getInfo() string {
+	if e.Weights == nil {
+		return e.Fn.String
+	}
+	weights := e.getWeights()
+	parts := make([]string, len(weights))
+	for i, w := range weights {
+		parts[i] = w.Fn + " " + strconv.FormatFloat(w.Weight, 'f', -1, 64)
+	}
+	return strings.Join(parts, ", ")
+}
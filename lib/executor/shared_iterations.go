@@ -47,8 +47,12 @@ func NewSharedIterationsConfig(name string) SharedIterationsConfig {
 	}
 }
 
-// Make sure we implement the lib.ExecutorConfig interface
-var _ lib.ExecutorConfig = &SharedIterationsConfig{}
+// Make sure we implement the lib.ExecutorConfig and lib.ResumableExecutorConfig
+// interfaces
+var (
+	_ lib.ExecutorConfig          = &SharedIterationsConfig{}
+	_ lib.ResumableExecutorConfig = &SharedIterationsConfig{}
+)
 
 // GetVUs returns the scaled VUs for the executor.
 func (sic SharedIterationsConfig) GetVUs(et *lib.ExecutionTuple) int64 {
@@ -123,6 +127,19 @@ func (sic SharedIterationsConfig) GetExecutionRequirements(et *lib.ExecutionTupl
 	}
 }
 
+// GetResumedConfig returns the config of the rest of the iterations after the
+// checkpoint, or nil if there are none left.
+func (sic SharedIterationsConfig) GetResumedConfig(checkpoint lib.ExecutorCheckpoint) lib.ExecutorConfig {
+	sic.BaseConfig = sic.getResumedBaseConfig(checkpoint)
+	sic.MaxDuration = getResumedDuration(sic.MaxDuration, checkpoint)
+	sic.Iterations.Int64 -= int64(checkpoint.Iterations) //nolint:gosec
+	if sic.Iterations.Int64 <= 0 || sic.MaxDuration.TimeDuration() <= 0 {
+		return nil
+	}
+	sic.VUs.Int64 = min(sic.VUs.Int64, sic.Iterations.Int64)
+	return sic
+}
+
 // NewExecutor creates a new SharedIterations executor
 func (sic SharedIterationsConfig) NewExecutor(
 	es *lib.ExecutionState, logger *logrus.Entry,
@@ -229,7 +246,7 @@ func (si SharedIterations) Run(parentCtx context.Context, out chan<- metrics.Sam
 	}()
 
 	regDurationDone := regDurationCtx.Done()
-	runIteration := getIterationRunner(si.executionState, si.logger)
+	runIteration := si.getIterationRunner(out)
 
 	returnVU := func(u lib.InitializedVU) {
 		si.executionState.ReturnVU(u, true)
@@ -241,7 +258,7 @@ func (si SharedIterations) Run(parentCtx context.Context, out chan<- metrics.Sam
 		defer cancel()
 
 		activeVU := initVU.Activate(getVUActivationParams(
-			ctx, si.config.BaseConfig, returnVU, si.nextIterationCounters))
+			ctx, regDurationCtx, si.config.BaseConfig, returnVU, si.nextIterationCounters))
 
 		for {
 			select {
@@ -2,6 +2,7 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"sync"
@@ -112,6 +113,92 @@ func TestSharedIterationsEmitDroppedIterations(t *testing.T) {
 	assert.Equal(t, float64(95), sumMetricValues(engineOut, metrics.DroppedIterationsName))
 }
 
+func TestSharedIterationsRetry(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name                  string
+		failures, attempts    int64
+		expCalls              int64
+		expRetried, expFailed float64
+	}{
+		{name: "succeeds on retry", failures: 2, attempts: 3, expCalls: 4, expRetried: 2},
+		{name: "fails every attempt", failures: 100, attempts: 2, expCalls: 4, expRetried: 2, expFailed: 2},
+		{name: "no retries", failures: 100, attempts: 1, expCalls: 2, expFailed: 2},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var calls int64
+			runner := simpleRunner(func(_ context.Context, _ *lib.State) error {
+				if atomic.AddInt64(&calls, 1) <= tc.failures {
+					return fmt.Errorf("failure %d", calls)
+				}
+				return nil
+			})
+
+			config := &SharedIterationsConfig{
+				BaseConfig: BaseConfig{Retry: &lib.RetryPolicy{
+					Attempts: null.IntFrom(tc.attempts),
+					Backoff:  types.NullDurationFrom(10 * time.Millisecond),
+				}},
+				VUs:         null.IntFrom(1),
+				Iterations:  null.IntFrom(2),
+				MaxDuration: types.NullDurationFrom(5 * time.Second),
+			}
+			test := setupExecutorTest(t, "", "", lib.Options{}, runner, config)
+			defer test.cancel()
+
+			engineOut := make(chan metrics.SampleContainer, 1000)
+			require.NoError(t, test.executor.Run(test.ctx, engineOut))
+			assert.Equal(t, tc.expCalls, atomic.LoadInt64(&calls))
+			sums := map[string]float64{}
+			for _, sc := range metrics.GetBufferedSamples(engineOut) {
+				for _, sample := range sc.GetSamples() {
+					sums[sample.Metric.Name] += sample.Value
+				}
+			}
+			assert.Equal(t, tc.expRetried, sums[metrics.IterationsRetriedName])
+			assert.Equal(t, tc.expFailed, sums[metrics.IterationsFailedName])
+			// The retries are the same iterations, so they aren't counted again
+			assert.Equal(t, uint64(2), test.state.GetFullIterationCount())
+			assert.Zero(t, test.state.GetPartialIterationCount())
+		})
+	}
+}
+
+func TestSharedIterationsRetryInterruptedBackoff(t *testing.T) {
+	t.Parallel()
+	var calls int64
+	runner := simpleRunner(func(_ context.Context, _ *lib.State) error {
+		atomic.AddInt64(&calls, 1)
+		return errors.New("failure")
+	})
+
+	config := &SharedIterationsConfig{
+		BaseConfig: BaseConfig{Retry: &lib.RetryPolicy{
+			Attempts: null.IntFrom(3),
+			Backoff:  types.NullDurationFrom(10 * time.Second),
+		}},
+		VUs:         null.IntFrom(1),
+		Iterations:  null.IntFrom(1),
+		MaxDuration: types.NullDurationFrom(100 * time.Millisecond),
+	}
+	test := setupExecutorTest(t, "", "", lib.Options{}, runner, config)
+	defer test.cancel()
+
+	engineOut := make(chan metrics.SampleContainer, 1000)
+	require.NoError(t, test.executor.Run(test.ctx, engineOut))
+	assert.Equal(t, int64(1), atomic.LoadInt64(&calls))
+	// The test ended while the iteration was waiting to be retried. The VU is
+	// returned when its context is done, so Run() doesn't wait for the count.
+	assert.Eventually(t, func() bool {
+		return test.state.GetPartialIterationCount() == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Zero(t, test.state.GetFullIterationCount())
+}
+
 func TestSharedIterationsGlobalIters(t *testing.T) {
 	t.Parallel()
 
@@ -70,6 +70,7 @@ short names for input:
 type vuHandle struct {
 	mutex                 *sync.Mutex
 	parentCtx             context.Context
+	regDurationCtx        context.Context
 	getVU                 func() (lib.InitializedVU, error)
 	returnVU              func(lib.InitializedVU)
 	nextIterationCounters func() (uint64, uint64)
@@ -88,7 +89,7 @@ type vuHandle struct {
 }
 
 func newStoppedVUHandle(
-	parentCtx context.Context, getVU func() (lib.InitializedVU, error),
+	parentCtx, regDurationCtx context.Context, getVU func() (lib.InitializedVU, error),
 	returnVU func(lib.InitializedVU),
 	nextIterationCounters func() (uint64, uint64),
 	config *BaseConfig, logger *logrus.Entry,
@@ -98,6 +99,7 @@ func newStoppedVUHandle(
 	return &vuHandle{
 		mutex:                 &sync.Mutex{},
 		parentCtx:             parentCtx,
+		regDurationCtx:        regDurationCtx,
 		getVU:                 getVU,
 		nextIterationCounters: nextIterationCounters,
 		config:                config,
@@ -131,7 +133,7 @@ func (vh *vuHandle) start() (err error) {
 		}
 
 		vh.activeVU = vh.initVU.Activate(getVUActivationParams(
-			vh.ctx, *vh.config, vh.returnVU, vh.nextIterationCounters))
+			vh.ctx, vh.regDurationCtx, *vh.config, vh.returnVU, vh.nextIterationCounters))
 		close(vh.canStartIter)
 		vh.changeState(starting)
 	}
@@ -3,14 +3,18 @@ package lib
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"gopkg.in/guregu/null.v3"
 
+	"go.k6.io/k6/lib/types"
 	"go.k6.io/k6/metrics"
 	"go.k6.io/k6/ui/pb"
 )
@@ -65,6 +69,14 @@ type ExecutorConfig interface {
 	GetStartTime() time.Duration
 	GetGracefulStop() time.Duration
 
+	// GetStartAfter returns the names of the scenarios that have to end before
+	// the executor starts, its startTime is counted from then.
+	GetStartAfter() []string
+	// GetStartWhen returns the conditions on the metrics of the test that have
+	// to hold before the executor starts, in the same format as the
+	// thresholds, i.e. a list of expressions for every metric.
+	GetStartWhen() map[string][]string
+
 	// This is used to validate whether a particular script can run in the cloud
 	// or, in the future, in the native k6 distributed execution. Currently only
 	// the externally-controlled executor should return false.
@@ -76,6 +88,21 @@ type ExecutorConfig interface {
 	//
 	// TODO: use interface{} so plain http requests can be specified?
 	GetExec() string
+	// GetExecWeights returns the functions of the traffic mix of the executor,
+	// sorted by their names, if it has one instead of a single exec function.
+	GetExecWeights() []ExecWeight
+	// GetSetup and GetTeardown return the functions, if any, which run once
+	// before and after the executor, and their timeouts, if they're specified.
+	GetSetup() (string, types.NullDuration)
+	GetTeardown() (string, types.NullDuration)
+	// GetThinkTime returns the think time after every iteration, if any, and
+	// GetPacing the cadence at which the VUs start their iterations, if it's
+	// specified.
+	GetThinkTime() *types.ThinkTime
+	GetPacing() time.Duration
+	// GetRetry returns the policy by which the failed iterations are
+	// retried, if any.
+	GetRetry() *RetryPolicy
 	GetTags() map[string]string
 
 	// Calculates the VU requirements in different stages of the executor's
@@ -99,6 +126,86 @@ type ScenarioOptions struct {
 	Browser map[string]any `json:"browser"`
 }
 
+// ExecWeight is one of the functions of the traffic mix of a scenario, every
+// iteration of which runs one of the functions, picked at random by their
+// weights.
+type ExecWeight struct {
+	Fn     string
+	Weight float64
+}
+
+// The failures of the iterations that a retry policy can retry them on.
+const (
+	RetryOnException   = "exception"
+	RetryOnFailedCheck = "failedCheck"
+)
+
+// RetryPolicy is the policy by which the executor of a scenario retries its
+// failed iterations, on the same VU. All of the attempts of an iteration are
+// counted as a single iteration.
+type RetryPolicy struct {
+	// Attempts is the maximum number of attempts of an iteration, including
+	// the first one.
+	Attempts null.Int `json:"attempts"`
+	// Backoff is the wait before the first retry, which doubles for every
+	// one after it.
+	Backoff types.NullDuration `json:"backoff"`
+	// On are the failures the iterations are retried on, which are only the
+	// exceptions by default.
+	On []string `json:"on"`
+}
+
+// Validate checks that the retry policy is valid.
+func (rp RetryPolicy) Validate() error {
+	if !rp.Attempts.Valid || rp.Attempts.Int64 < 1 {
+		return errors.New("the attempts should be at least 1")
+	}
+	if rp.Backoff.Valid && rp.Backoff.Duration < 0 {
+		return errors.New("the backoff can't be negative")
+	}
+	for _, on := range rp.On {
+		if on != RetryOnException && on != RetryOnFailedCheck {
+			return fmt.Errorf("unknown failure '%s' to retry on, it should be %s or %s",
+				on, RetryOnException, RetryOnFailedCheck)
+		}
+	}
+	return nil
+}
+
+// ShouldRetry returns whether the policy retries the attempt of an iteration,
+// which ended with the error, if any, and with failed checks, if failedCheck.
+func (rp RetryPolicy) ShouldRetry(attempt int64, err error, failedCheck bool) bool {
+	if attempt >= rp.Attempts.Int64 {
+		return false
+	}
+	if len(rp.On) == 0 {
+		return err != nil
+	}
+	for _, on := range rp.On {
+		if (on == RetryOnException && err != nil) || (on == RetryOnFailedCheck && failedCheck) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetBackoff returns the wait before the given attempt of an iteration.
+func (rp RetryPolicy) GetBackoff(attempt int64) time.Duration {
+	backoff := rp.Backoff.TimeDuration()
+	for i := int64(2); i < attempt && backoff < math.MaxInt64/2; i++ {
+		backoff *= 2
+	}
+	return backoff
+}
+
+func (rp RetryPolicy) String() string {
+	on := rp.On
+	if len(on) == 0 {
+		on = []string{RetryOnException}
+	}
+	return fmt.Sprintf("%d attempts on %s, backoff %s", rp.Attempts.Int64, strings.Join(on, ", "), rp.Backoff.Duration)
+}
+
 // ScenarioState holds runtime scenario information returned by the k6/execution
 // JS module.
 type ScenarioState struct {
@@ -135,6 +242,44 @@ type LiveUpdatableExecutor interface {
 	UpdateConfig(ctx context.Context, newConfig interface{}) error
 }
 
+// ExecutorConfigWithFiles should be implemented by the configs of the
+// executors that read data files, so the runner can load them when the
+// options are set and they become a part of the archive of the test.
+type ExecutorConfigWithFiles interface {
+	GetFiles() []string
+}
+
+// ExecutorCheckpoint is the progress of an executor at a checkpoint of the
+// test, from which a later run of the test can continue.
+type ExecutorCheckpoint struct {
+	// Started is whether the executor had started to run its iterations, after
+	// the scenarios it starts after, its startWhen conditions and its
+	// startTime, and Done whether it had finished.
+	Started bool `json:"started"`
+	Done    bool `json:"done"`
+	// Elapsed is the time the executor had run for, or the part of its
+	// startTime that had passed, if it hadn't started.
+	Elapsed types.Duration `json:"elapsed"`
+	// Iterations is the number of iterations the executor had started in all
+	// of the instances of the test, i.e. it isn't scaled.
+	Iterations uint64 `json:"iterations"`
+}
+
+// ResumableExecutorConfig should be implemented by the configs of the
+// executors that can continue from a checkpoint of an interrupted test.
+type ResumableExecutorConfig interface {
+	// GetResumedConfig returns the config of the rest of the work of the
+	// executor after the checkpoint, which starts right away if the executor
+	// had started.
+	GetResumedConfig(checkpoint ExecutorCheckpoint) ExecutorConfig
+}
+
+// ResumableExecutor should be implemented by the executors that report the
+// iterations they have started, for the checkpoints of the test.
+type ResumableExecutor interface {
+	GetStartedIterations() uint64
+}
+
 // ExecutorConfigConstructor is a simple function that returns a concrete
 // Config instance with the specified name and all default values correctly
 // initialized
@@ -203,7 +348,124 @@ func (scs ScenarioConfigs) Validate() (errors []error) {
 				fmt.Errorf("scenario %s has configuration errors: %s", name, ConcatErrors(execErr, ", ")))
 		}
 	}
-	return errors
+	return append(errors, scs.validateDependencies()...)
+}
+
+// validateDependencies makes sure that the scenarios start after existing
+// scenarios, and that there are no cycles in their dependencies.
+func (scs ScenarioConfigs) validateDependencies() (errors []error) {
+	for _, config := range scs.GetSortedConfigs() {
+		for _, dependency := range config.GetStartAfter() {
+			if _, ok := scs[dependency]; !ok {
+				errors = append(errors, fmt.Errorf(
+					"scenario %s starts after the scenario %s, which doesn't exist", config.GetName(), dependency))
+			}
+		}
+	}
+	if len(errors) != 0 {
+		return errors
+	}
+
+	// A depth-first search, where a scenario that is reached again while its
+	// own dependencies are being visited is a part of a cycle.
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int, len(scs))
+	var path []string
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			for i, pathName := range path {
+				if pathName == name {
+					return fmt.Errorf("the scenarios have a cycle in their startAfter dependencies: %s",
+						strings.Join(append(path[i:], name), " -> "))
+				}
+			}
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, dependency := range scs[name].GetStartAfter() {
+			if err := visit(dependency); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+	for _, config := range scs.GetSortedConfigs() {
+		if err := visit(config.GetName()); err != nil {
+			return []error{err}
+		}
+	}
+	return nil
+}
+
+// ScenarioSchedule is the place of a scenario in the execution plan, which
+// depends on its startTime and on the scenarios it starts after.
+type ScenarioSchedule struct {
+	StartAfter []string            `json:"startAfter,omitempty"`
+	StartWhen  map[string][]string `json:"startWhen,omitempty"`
+
+	// Start and End are the earliest times, from the start of the test, at
+	// which the scenario can start and end, including its gracefulStop.
+	Start types.NullDuration `json:"start"`
+	End   types.NullDuration `json:"end"`
+
+	// Dynamic is true when the scenario, or any scenario it starts after,
+	// waits for conditions on the metrics, so it can start at any time after
+	// its earliest start.
+	Dynamic bool `json:"dynamic,omitempty"`
+}
+
+// GetSchedules resolves the dependencies between the scenarios into the
+// earliest times at which each of them can start and end. The dependencies
+// should already be validated, the unknown scenarios and the cycles in them
+// are ignored.
+func (scs ScenarioConfigs) GetSchedules(et *ExecutionTuple) map[string]ScenarioSchedule {
+	schedules := make(map[string]ScenarioSchedule, len(scs))
+	resolving := make(map[string]bool, len(scs))
+	var resolve func(name string) ScenarioSchedule
+	resolve = func(name string) ScenarioSchedule {
+		if schedule, ok := schedules[name]; ok || resolving[name] {
+			return schedule
+		}
+		resolving[name] = true
+		config := scs[name]
+		var start time.Duration
+		dynamic := len(config.GetStartWhen()) != 0
+		for _, dependency := range config.GetStartAfter() {
+			if _, ok := scs[dependency]; !ok {
+				continue
+			}
+			dependencySchedule := resolve(dependency)
+			if end := dependencySchedule.End.TimeDuration(); end > start {
+				start = end
+			}
+			dynamic = dynamic || dependencySchedule.Dynamic
+		}
+		start += config.GetStartTime()
+		duration, _ := GetEndOffset(config.GetExecutionRequirements(et))
+
+		schedule := ScenarioSchedule{
+			StartAfter: config.GetStartAfter(),
+			StartWhen:  config.GetStartWhen(),
+			Start:      types.NewNullDuration(start, true),
+			End:        types.NewNullDuration(start+duration, true),
+			Dynamic:    dynamic,
+		}
+		schedules[name] = schedule
+		return schedule
+	}
+	for name := range scs {
+		resolve(name)
+	}
+	return schedules
 }
 
 // GetSortedConfigs returns a slice with the executor configurations,
@@ -241,7 +503,8 @@ func (scs ScenarioConfigs) GetSortedConfigs() []ExecutorConfig {
 // GetFullExecutionRequirements combines the execution requirements from all of
 // the configured executors. It takes into account their start times and their
 // individual VU requirements and calculates the total VU requirements for each
-// moment in the test execution.
+// moment in the test execution. The scenarios that start after other ones
+// start at the earliest time they can, when those end.
 func (scs ScenarioConfigs) GetFullExecutionRequirements(et *ExecutionTuple) []ExecutionStep {
 	sortedConfigs := scs.GetSortedConfigs()
 
@@ -252,10 +515,15 @@ func (scs ScenarioConfigs) GetFullExecutionRequirements(et *ExecutionTuple) []Ex
 		ExecutionStep
 		configID int
 	}
+	schedules := scs.GetSchedules(et)
 	trackedSteps := []trackedStep{}
 	for configID, config := range sortedConfigs { // orderly iteration over a slice
-		configStartTime := config.GetStartTime()
+		schedule := schedules[config.GetName()]
+		configStartTime := schedule.Start.TimeDuration()
 		configSteps := config.GetExecutionRequirements(et)
+		if schedule.Dynamic {
+			configSteps = getDynamicExecutionRequirements(configSteps)
+		}
 		for _, cs := range configSteps {
 			cs.TimeOffset += configStartTime // add the executor start time to the step time offset
 			trackedSteps = append(trackedSteps, trackedStep{cs, configID})
@@ -313,6 +581,23 @@ func (scs ScenarioConfigs) GetFullExecutionRequirements(et *ExecutionTuple) []Ex
 	return consolidatedSteps
 }
 
+// getDynamicExecutionRequirements returns the requirements of an executor that
+// can start at any time after its earliest start. Its VUs have to be available
+// from then until the end of the test, since it's not known when it will
+// need them.
+func getDynamicExecutionRequirements(steps []ExecutionStep) []ExecutionStep {
+	dynamicStep := ExecutionStep{}
+	for _, step := range steps {
+		if step.PlannedVUs > dynamicStep.PlannedVUs {
+			dynamicStep.PlannedVUs = step.PlannedVUs
+		}
+		if step.MaxUnplannedVUs > dynamicStep.MaxUnplannedVUs {
+			dynamicStep.MaxUnplannedVUs = step.MaxUnplannedVUs
+		}
+	}
+	return []ExecutionStep{dynamicStep}
+}
+
 // GetParsedExecutorConfig returns a struct instance corresponding to the supplied
 // config type. It will be fully initialized - with both the default values of
 // the type, as well as with whatever the user had specified in the JSON
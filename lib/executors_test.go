@@ -0,0 +1,45 @@
+package lib
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	err := errors.New("exception")
+
+	t.Run("exceptions by default", func(t *testing.T) {
+		t.Parallel()
+		rp := RetryPolicy{Attempts: null.IntFrom(3)}
+		assert.True(t, rp.ShouldRetry(1, err, false))
+		assert.True(t, rp.ShouldRetry(2, err, true))
+		assert.False(t, rp.ShouldRetry(3, err, false))
+		assert.False(t, rp.ShouldRetry(1, nil, true))
+	})
+
+	t.Run("failed checks", func(t *testing.T) {
+		t.Parallel()
+		rp := RetryPolicy{Attempts: null.IntFrom(3), On: []string{RetryOnFailedCheck}}
+		assert.True(t, rp.ShouldRetry(1, nil, true))
+		assert.False(t, rp.ShouldRetry(1, err, false))
+		assert.False(t, rp.ShouldRetry(3, nil, true))
+	})
+
+	t.Run("backoff", func(t *testing.T) {
+		t.Parallel()
+		rp := RetryPolicy{Attempts: null.IntFrom(100), Backoff: types.NullDurationFrom(time.Second)}
+		assert.Equal(t, time.Second, rp.GetBackoff(2))
+		assert.Equal(t, 2*time.Second, rp.GetBackoff(3))
+		assert.Equal(t, 8*time.Second, rp.GetBackoff(5))
+		assert.Positive(t, rp.GetBackoff(100))
+		assert.Equal(t, time.Duration(0), RetryPolicy{Attempts: null.IntFrom(2)}.GetBackoff(2))
+	})
+}
@@ -1,7 +1,13 @@
 package lib
 
 import (
+	"context"
+	"strings"
 	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
 )
 
 // SlotLimiter can restrict the concurrent execution of tasks to the given `slots` limit
@@ -28,6 +34,20 @@ func (sl SlotLimiter) Begin() {
 	}
 }
 
+// BeginContext is like Begin, but it stops waiting for a slot and returns the
+// error of the context if it's done first.
+func (sl SlotLimiter) BeginContext(ctx context.Context) error {
+	if sl == nil {
+		return nil
+	}
+	select {
+	case <-sl:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // End restores a slot and should be called at the end of a taks execution, preferably
 // from a defer statement right after Begin()
 func (sl SlotLimiter) End() {
@@ -68,3 +88,108 @@ func (l *MultiSlotLimiter) Slot(s string) SlotLimiter {
 	}
 	return ll
 }
+
+// hostLimiter limits the rate and the concurrency of the HTTP requests to a host.
+type hostLimiter struct {
+	rate  *rate.Limiter
+	slots SlotLimiter
+}
+
+// HTTPLimiter limits the rate and the concurrency of the HTTP requests of all
+// VUs of the instance, according to the httpLimits option.
+type HTTPLimiter struct {
+	all   *hostLimiter
+	hosts map[string]*hostLimiter
+}
+
+// NewHTTPLimiter returns the limiter for the limits, scaled by the execution
+// segment of the instance. It returns nil if there aren't any limits.
+//
+// The rate limits are split between the instances in proportion to their
+// segments. The concurrency limits are split like the VUs, so all of the
+// instances together have exactly the limit, but every instance can make at
+// least one request at a time, or the ones with a small segment wouldn't make
+// any. So when the limit is lower than the number of instances, they can make
+// more concurrent requests than it, which is logged as a warning.
+func NewHTTPLimiter(limits HTTPLimits, et *ExecutionTuple, logger logrus.FieldLogger) *HTTPLimiter {
+	if len(limits) == 0 {
+		return nil
+	}
+	var segment *ExecutionSegment
+	if et != nil {
+		segment = et.Segment
+	}
+	l := &HTTPLimiter{hosts: make(map[string]*hostLimiter, len(limits))}
+	for host, limit := range limits {
+		hl := &hostLimiter{}
+		if limit.RPS.Valid {
+			hl.rate = rate.NewLimiter(rate.Limit(limit.RPS.Float64*segment.FloatLength()), 1)
+		}
+		if limit.Concurrency.Valid {
+			concurrency := limit.Concurrency.Int64
+			if et != nil {
+				concurrency = et.ScaleInt64(concurrency)
+			}
+			if concurrency < 1 {
+				logger.Warnf("The concurrency of the httpLimits of '%s' is %d, which is less than the number "+
+					"of instances of the test, so this instance can still make one request at a time and all of "+
+					"them together can make more concurrent requests than that.", host, limit.Concurrency.Int64)
+				concurrency = 1
+			}
+			hl.slots = NewSlotLimiter(int(concurrency))
+		}
+		if host == HTTPLimitsAllHosts {
+			l.all = hl
+		} else {
+			l.hosts[strings.ToLower(host)] = hl
+		}
+	}
+	return l
+}
+
+// Wait waits until a request to the host is allowed by the limits. If it is
+// limited, it returns how long it waited and the function that should be
+// called when the request is done, otherwise release is nil.
+func (l *HTTPLimiter) Wait(ctx context.Context, host string) (queued time.Duration, release func(), err error) {
+	if l == nil {
+		return 0, nil, nil
+	}
+	limiters := make([]*hostLimiter, 0, 2)
+	if l.all != nil {
+		limiters = append(limiters, l.all)
+	}
+	if hl, ok := l.hosts[strings.ToLower(host)]; ok {
+		limiters = append(limiters, hl)
+	}
+	if len(limiters) == 0 {
+		return 0, nil, nil
+	}
+
+	start := time.Now()
+	release = func() {
+		for _, hl := range limiters {
+			hl.slots.End()
+		}
+	}
+	// The concurrency slots are taken before the rate tokens, always in the
+	// same order, so the requests can't deadlock each other and they don't use
+	// up the rate while they wait for a slot.
+	for i, hl := range limiters {
+		if err = hl.slots.BeginContext(ctx); err != nil {
+			for _, acquired := range limiters[:i] {
+				acquired.slots.End()
+			}
+			return 0, nil, err
+		}
+	}
+	for _, hl := range limiters {
+		if hl.rate == nil {
+			continue
+		}
+		if err = hl.rate.Wait(ctx); err != nil {
+			release()
+			return 0, nil, err
+		}
+	}
+	return time.Since(start), release, nil
+}
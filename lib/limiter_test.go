@@ -1,12 +1,19 @@
 package lib
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/testutils"
 )
 
 func TestSlotLimiterSingleSlot(t *testing.T) {
@@ -121,3 +128,117 @@ func TestMultiSlotLimiter(t *testing.T) {
 		assert.NotNil(t, l.Slot("dtest"))
 	})
 }
+
+func TestHTTPLimiter(t *testing.T) {
+	t.Parallel()
+	t.Run("no limits", func(t *testing.T) {
+		t.Parallel()
+		assert.Nil(t, NewHTTPLimiter(nil, nil, testutils.NewLogger(t)))
+		var l *HTTPLimiter
+		queued, release, err := l.Wait(context.Background(), "example.com")
+		require.NoError(t, err)
+		assert.Nil(t, release)
+		assert.Zero(t, queued)
+	})
+	t.Run("unlimited host", func(t *testing.T) {
+		t.Parallel()
+		l := NewHTTPLimiter(HTTPLimits{"example.com": {Concurrency: null.IntFrom(1)}}, nil, testutils.NewLogger(t))
+		_, release, err := l.Wait(context.Background(), "other.example.com")
+		require.NoError(t, err)
+		assert.Nil(t, release)
+	})
+	t.Run("concurrency", func(t *testing.T) {
+		t.Parallel()
+		l := NewHTTPLimiter(HTTPLimits{
+			HTTPLimitsAllHosts: {Concurrency: null.IntFrom(2)},
+			"example.com":      {Concurrency: null.IntFrom(1)},
+		}, nil, testutils.NewLogger(t))
+		_, release1, err := l.Wait(context.Background(), "Example.com")
+		require.NoError(t, err)
+		require.NotNil(t, release1)
+
+		// The host doesn't have a free slot
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, _, err = l.Wait(ctx, "example.com")
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		// The slot of all hosts taken by the failed request was given back
+		_, release2, err := l.Wait(context.Background(), "other.example.com")
+		require.NoError(t, err)
+		ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, _, err = l.Wait(ctx, "another.example.com")
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		release1()
+		queued, release3, err := l.Wait(context.Background(), "example.com")
+		require.NoError(t, err)
+		assert.Less(t, queued, 50*time.Millisecond)
+		release2()
+		release3()
+	})
+	t.Run("rps", func(t *testing.T) {
+		t.Parallel()
+		l := NewHTTPLimiter(HTTPLimits{"example.com": {RPS: null.FloatFrom(10)}}, nil, testutils.NewLogger(t))
+		start := time.Now()
+		var queued time.Duration
+		for i := 0; i < 3; i++ {
+			q, release, err := l.Wait(context.Background(), "example.com")
+			require.NoError(t, err)
+			release()
+			queued += q
+		}
+		assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+		assert.GreaterOrEqual(t, queued, 150*time.Millisecond)
+	})
+	t.Run("execution segment", func(t *testing.T) {
+		t.Parallel()
+		segment, err := NewExecutionSegmentFromString("0:1/4")
+		require.NoError(t, err)
+		et, err := NewExecutionTuple(segment, nil)
+		require.NoError(t, err)
+		logger, hook := testutils.NewLoggerWithHook(t, logrus.WarnLevel)
+		l := NewHTTPLimiter(HTTPLimits{
+			"example.com":     {RPS: null.FloatFrom(40), Concurrency: null.IntFrom(8)},
+			"two.example.com": {Concurrency: null.IntFrom(2)},
+		}, et, logger)
+		hl := l.hosts["example.com"]
+		assert.Equal(t, 10.0, float64(hl.rate.Limit()))
+		assert.Equal(t, 2, cap(hl.slots))
+		assert.Equal(t, 1, cap(l.hosts["two.example.com"].slots))
+		assert.Empty(t, hook.Drain())
+	})
+	t.Run("execution segment sequence", func(t *testing.T) {
+		t.Parallel()
+		sequence, err := NewExecutionSegmentSequenceFromString("0,1/3,2/3,1")
+		require.NoError(t, err)
+
+		// The instances together have exactly the limit, like the VUs
+		for _, limit := range []int64{4, 8, 9} {
+			total := 0
+			for _, segment := range sequence {
+				et, err := NewExecutionTuple(segment, &sequence)
+				require.NoError(t, err)
+				logger, hook := testutils.NewLoggerWithHook(t, logrus.WarnLevel)
+				l := NewHTTPLimiter(HTTPLimits{"example.com": {Concurrency: null.IntFrom(limit)}}, et, logger)
+				total += cap(l.hosts["example.com"].slots)
+				assert.Empty(t, hook.Drain())
+			}
+			assert.Equal(t, int(limit), total, limit)
+		}
+
+		// Every instance can make a request at a time, which exceeds a lower limit
+		total, warnings := 0, 0
+		for _, segment := range sequence {
+			et, err := NewExecutionTuple(segment, &sequence)
+			require.NoError(t, err)
+			logger, hook := testutils.NewLoggerWithHook(t, logrus.WarnLevel)
+			l := NewHTTPLimiter(HTTPLimits{"example.com": {Concurrency: null.IntFrom(2)}}, et, logger)
+			total += cap(l.hosts["example.com"].slots)
+			warnings += len(hook.Drain())
+		}
+		assert.Equal(t, 3, total)
+		assert.Equal(t, 1, warnings)
+	})
+}
@@ -98,19 +98,54 @@ func (d *Dialer) IOSamples(
 	})
 }
 
+// ResolveUDPAddr resolves the address like DialContext does, with the hosts
+// and the blocked hostnames, and checks it against the blacklist, without
+// opening a connection to it. It's used for the UDP (QUIC) connections, which
+// DialContext doesn't open.
+func (d *Dialer) ResolveUDPAddr(addr string) (*net.UDPAddr, error) {
+	remote, err := d.getRemote(addr)
+	if err != nil {
+		return nil, err
+	}
+	return &net.UDPAddr{IP: remote.IP, Port: remote.Port}, nil
+}
+
+// ListenUDP opens a UDP socket for the connections to the addresses returned
+// by ResolveUDPAddr, on the local IP of the dialer, if it has one. The data
+// sent and received on it is counted like the one of the dialed connections.
+func (d *Dialer) ListenUDP() (net.PacketConn, error) {
+	laddr := &net.UDPAddr{}
+	if tcpAddr, ok := d.Dialer.LocalAddr.(*net.TCPAddr); ok {
+		laddr.IP = tcpAddr.IP
+	}
+	conn, err := net.ListenUDP("udp", laddr)
+	if err != nil {
+		return nil, err
+	}
+	return &PacketConn{conn, &d.BytesRead, &d.BytesWritten}, nil
+}
+
 func (d *Dialer) getDialAddr(addr string) (string, error) {
-	remote, err := d.findRemote(addr)
+	remote, err := d.getRemote(addr)
 	if err != nil {
 		return "", err
 	}
+	return remote.String(), nil
+}
+
+func (d *Dialer) getRemote(addr string) (*types.Host, error) {
+	remote, err := d.findRemote(addr)
+	if err != nil {
+		return nil, err
+	}
 
 	for _, ipnet := range d.Blacklist {
 		if ipnet.Contains(remote.IP) {
-			return "", BlackListedIPError{ip: remote.IP, net: ipnet}
+			return nil, BlackListedIPError{ip: remote.IP, net: ipnet}
 		}
 	}
 
-	return remote.String(), nil
+	return remote, nil
 }
 
 func (d *Dialer) findRemote(addr string) (*types.Host, error) {
@@ -195,3 +230,48 @@ func (c *Conn) Write(b []byte) (int, error) {
 	}
 	return n, err
 }
+
+// PacketConn wraps net.PacketConn and keeps track of sent and received data size
+type PacketConn struct {
+	net.PacketConn
+
+	BytesRead, BytesWritten *int64
+}
+
+// ReadFrom implements net.PacketConn.
+func (c *PacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	n, addr, err := c.PacketConn.ReadFrom(b)
+	if n > 0 {
+		atomic.AddInt64(c.BytesRead, int64(n))
+	}
+	return n, addr, err
+}
+
+// WriteTo implements net.PacketConn.
+func (c *PacketConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	n, err := c.PacketConn.WriteTo(b, addr)
+	if n > 0 {
+		atomic.AddInt64(c.BytesWritten, int64(n))
+	}
+	return n, err
+}
+
+// SetReadBuffer sets the size of the receive buffer of the socket, which
+// quic-go increases for the QUIC connections.
+func (c *PacketConn) SetReadBuffer(bytes int) error {
+	conn, ok := c.PacketConn.(interface{ SetReadBuffer(int) error })
+	if !ok {
+		return fmt.Errorf("the size of the receive buffer of a %T can't be set", c.PacketConn)
+	}
+	return conn.SetReadBuffer(bytes)
+}
+
+// SetWriteBuffer sets the size of the send buffer of the socket, which
+// quic-go increases for the QUIC connections.
+func (c *PacketConn) SetWriteBuffer(bytes int) error {
+	conn, ok := c.PacketConn.(interface{ SetWriteBuffer(int) error })
+	if !ok {
+		return fmt.Errorf("the size of the send buffer of a %T can't be set", c.PacketConn)
+	}
+	return conn.SetWriteBuffer(bytes)
+}
@@ -0,0 +1,292 @@
+package httpext
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+
+	"go.k6.io/k6/lib"
+)
+
+// ConnectionParams are the request params that control the connections the
+// request is made over. A request with any of them set doesn't use the
+// transport of the VU, but the one of a connection pool with these settings.
+type ConnectionParams struct {
+	// The name of the pool, requests with the same name share its connections.
+	Pool string
+	// The maximum number of connections to a host, 0 means no limit.
+	MaxConnsPerHost int
+	// Use only HTTP/1.1.
+	ForceHTTP1 bool
+	// Use only HTTP/2, with prior knowledge (h2c) for cleartext requests.
+	ForceHTTP2 bool
+	// Use HTTP/3 over QUIC for the https:// requests.
+	HTTP3 bool
+	// How long idle connections are kept open, 0 means like the VU transport.
+	IdleTimeout time.Duration
+	// The TLS config of the connections, instead of the one of the VU transport.
+	TLSConfig *tls.Config
+}
+
+// IsZero returns true if the params don't change the connections of the VU.
+func (p ConnectionParams) IsZero() bool {
+	return p == ConnectionParams{}
+}
+
+// Validate checks the params for errors.
+func (p ConnectionParams) Validate() error {
+	if p.ForceHTTP1 && p.ForceHTTP2 {
+		return errors.New("forceHTTP1 and forceHTTP2 can't be used together")
+	}
+	if p.HTTP3 && (p.ForceHTTP1 || p.ForceHTTP2) {
+		return errors.New("http3 can't be used with forceHTTP1 or forceHTTP2")
+	}
+	if p.MaxConnsPerHost < 0 {
+		return fmt.Errorf("maxConnsPerHost should be positive, but is %d", p.MaxConnsPerHost)
+	}
+	if p.MaxConnsPerHost > 0 && p.ForceHTTP2 {
+		return errors.New("maxConnsPerHost can't be used with forceHTTP2, " +
+			"the HTTP/2 requests are multiplexed over a single connection per host")
+	}
+	if p.MaxConnsPerHost > 0 && p.HTTP3 {
+		return errors.New("maxConnsPerHost can't be used with http3, " +
+			"the HTTP/3 requests are multiplexed over a single connection per host")
+	}
+	if p.IdleTimeout < 0 {
+		return fmt.Errorf("idleTimeout should be positive, but is %s", p.IdleTimeout)
+	}
+	return nil
+}
+
+// closeIdler is implemented by the transports of the connection pools.
+type closeIdler interface {
+	http.RoundTripper
+	CloseIdleConnections()
+}
+
+type connectionPool struct {
+	params    ConnectionParams
+	transport closeIdler
+}
+
+// ConnectionPools are the connection pools of a single VU, besides the one of
+// its transport. They are created on the first request that uses them.
+type ConnectionPools struct {
+	mx        sync.Mutex
+	pools     map[string]*connectionPool
+	iteration int64
+}
+
+// NewConnectionPools returns an empty set of connection pools.
+func NewConnectionPools() *ConnectionPools {
+	return &ConnectionPools{pools: make(map[string]*connectionPool), iteration: -1}
+}
+
+// Get returns the transport of the pool for the params, and creates it if it
+// doesn't exist. Requests with the same params that don't name a pool share
+// the same unnamed pool.
+func (cp *ConnectionPools) Get(state *lib.State, params ConnectionParams) (http.RoundTripper, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	cp.mx.Lock()
+	defer cp.mx.Unlock()
+
+	// The VU closes its own idle connections at the end of every iteration,
+	// the ones of the pools are closed at their first use in the next one.
+	if state.Options.NoVUConnectionReuse.Bool && cp.iteration != state.Iteration {
+		for _, pool := range cp.pools {
+			pool.transport.CloseIdleConnections()
+		}
+	}
+	cp.iteration = state.Iteration
+
+	key := "name:" + params.Pool
+	if params.Pool == "" {
+		key = fmt.Sprintf("params:%+v", params)
+	}
+	if pool, ok := cp.pools[key]; ok {
+		if pool.params != params {
+			return nil, fmt.Errorf("the connection pool %q was already created with different params", params.Pool)
+		}
+		return pool.transport, nil
+	}
+
+	transport, err := newPoolTransport(state, params)
+	if err != nil {
+		return nil, err
+	}
+	cp.pools[key] = &connectionPool{params: params, transport: transport}
+	return transport, nil
+}
+
+// newPoolTransport returns a transport with the settings of the transport of
+// the VU, changed by the params.
+func newPoolTransport(state *lib.State, params ConnectionParams) (closeIdler, error) {
+	base, ok := state.Transport.(*http.Transport)
+	if !ok {
+		return nil, fmt.Errorf("connection params aren't supported with a %T transport", state.Transport)
+	}
+	if params.ForceHTTP2 {
+		return newHTTP2Transport(base, params), nil
+	}
+	if params.HTTP3 {
+		return newHTTP3Transport(state, base, params)
+	}
+
+	transport := base.Clone()
+	if params.TLSConfig != nil {
+		transport.TLSClientConfig = params.TLSConfig.Clone()
+	}
+	if params.IdleTimeout > 0 {
+		transport.IdleConnTimeout = params.IdleTimeout
+	}
+	if params.MaxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = params.MaxConnsPerHost
+		transport.MaxIdleConnsPerHost = params.MaxConnsPerHost
+	}
+
+	// The cloned HTTP/2 configuration still points to the connections of the
+	// VU transport, and the TLS config already offers h2 with ALPN.
+	transport.TLSNextProto = nil
+	if transport.TLSClientConfig != nil {
+		transport.TLSClientConfig.NextProtos = nil
+	}
+	// An empty TLSNextProto is how the VU transport is forced to use HTTP/1.1
+	forcedHTTP1 := base.TLSNextProto != nil && len(base.TLSNextProto) == 0
+	if params.ForceHTTP1 || forcedHTTP1 {
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+		return transport, nil
+	}
+	if _, err := http2.ConfigureTransports(transport); err != nil {
+		return nil, err
+	}
+	return transport, nil
+}
+
+// http2Transport makes only HTTP/2 requests, over TLS for https:// URLs and
+// with prior knowledge (h2c) for http:// ones.
+type http2Transport struct {
+	tls, cleartext    *http2.Transport
+	disableKeepAlives bool
+}
+
+var _ closeIdler = &http2Transport{}
+
+func newHTTP2Transport(base *http.Transport, params ConnectionParams) *http2Transport {
+	tlsConfig := base.TLSClientConfig.Clone()
+	if params.TLSConfig != nil {
+		tlsConfig = params.TLSConfig.Clone()
+	}
+	if tlsConfig != nil {
+		tlsConfig.NextProtos = nil // http2.Transport sets them
+	}
+	idleTimeout := base.IdleConnTimeout
+	if params.IdleTimeout > 0 {
+		idleTimeout = params.IdleTimeout
+	}
+	dial := base.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	return &http2Transport{
+		tls: &http2.Transport{
+			TLSClientConfig:    tlsConfig,
+			DialTLSContext:     dialHTTP2TLS(dial),
+			DisableCompression: base.DisableCompression,
+			IdleConnTimeout:    idleTimeout,
+		},
+		cleartext: &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				return dialTraced(ctx, dial, network, addr)
+			},
+			DisableCompression: base.DisableCompression,
+			IdleConnTimeout:    idleTimeout,
+		},
+		disableKeepAlives: base.DisableKeepAlives,
+	}
+}
+
+// dialTraced dials a connection with the dial function of the VU transport,
+// and reports it to the tracer of the request, which http2.Transport doesn't
+// do itself. The tracer only keeps the first report of the connection, so it
+// doesn't matter if the dialer reports it as well.
+func dialTraced(
+	ctx context.Context, dial func(ctx context.Context, network, addr string) (net.Conn, error), network, addr string,
+) (net.Conn, error) {
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace != nil && trace.ConnectStart != nil {
+		trace.ConnectStart(network, addr)
+	}
+	conn, err := dial(ctx, network, addr)
+	if trace != nil && trace.ConnectDone != nil {
+		trace.ConnectDone(network, addr, err)
+	}
+	return conn, err
+}
+
+// dialHTTP2TLS returns a function that dials a TLS connection with the dial
+// function of the VU transport. It reports the connection and the TLS
+// handshake to the tracer of the request, which http2.Transport doesn't do
+// itself.
+func dialHTTP2TLS(
+	dial func(ctx context.Context, network, addr string) (net.Conn, error),
+) func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+		conn, err := dialTraced(ctx, dial, network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		trace := httptrace.ContextClientTrace(ctx)
+		if trace != nil && trace.TLSHandshakeStart != nil {
+			trace.TLSHandshakeStart()
+		}
+		tlsConn := tls.Client(conn, cfg)
+		err = tlsConn.HandshakeContext(ctx)
+		if trace != nil && trace.TLSHandshakeDone != nil {
+			trace.TLSHandshakeDone(tlsConn.ConnectionState(), err)
+		}
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+
+		if p := tlsConn.ConnectionState().NegotiatedProtocol; p != http2.NextProtoTLS {
+			_ = conn.Close()
+			return nil, fmt.Errorf("the server doesn't support HTTP/2, it negotiated %q instead of %q",
+				p, http2.NextProtoTLS)
+		}
+		return tlsConn, nil
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *http2Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.disableKeepAlives {
+		// http2.Transport makes the requests that close the connection over
+		// a new connection, which isn't reused.
+		req = req.Clone(req.Context())
+		req.Close = true
+	}
+	if req.URL.Scheme == "http" {
+		return t.cleartext.RoundTrip(req)
+	}
+	return t.tls.RoundTrip(req)
+}
+
+// CloseIdleConnections closes the idle connections of both transports.
+func (t *http2Transport) CloseIdleConnections() {
+	t.tls.CloseIdleConnections()
+	t.cleartext.CloseIdleConnections()
+}
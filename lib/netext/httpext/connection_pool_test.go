@@ -0,0 +1,56 @@
+package httpext
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http/httptrace"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialTraced(t *testing.T) {
+	t.Parallel()
+
+	var events []string
+	ctx := httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+		ConnectStart: func(network, addr string) { events = append(events, "start "+network+" "+addr) },
+		ConnectDone: func(network, addr string, err error) {
+			events = append(events, "done "+network+" "+addr)
+			if err != nil {
+				events = append(events, err.Error())
+			}
+		},
+	})
+
+	// The dial function doesn't report anything itself, unlike a net.Dialer
+	server, client := net.Pipe()
+	t.Cleanup(func() { _ = server.Close() })
+	conn, err := dialTraced(ctx, func(context.Context, string, string) (net.Conn, error) {
+		events = append(events, "dial")
+		return client, nil
+	}, "tcp", "example.com:80")
+	require.NoError(t, err)
+	assert.Equal(t, client, conn)
+	assert.Equal(t, []string{"start tcp example.com:80", "dial", "done tcp example.com:80"}, events)
+
+	events = nil
+	_, err = dialTraced(ctx, func(context.Context, string, string) (net.Conn, error) {
+		return nil, errors.New("refused")
+	}, "tcp", "example.com:80")
+	require.ErrorContains(t, err, "refused")
+	assert.Equal(t, []string{"start tcp example.com:80", "done tcp example.com:80", "refused"}, events)
+
+	// The connecting time of the request is measured from them
+	tracer := &Tracer{}
+	ctx = httptrace.WithClientTrace(context.Background(), tracer.Trace())
+	_, err = dialTraced(ctx, func(context.Context, string, string) (net.Conn, error) {
+		time.Sleep(10 * time.Millisecond)
+		return client, nil
+	}, "tcp", "example.com:80")
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, tracer.Done().Connecting, 10*time.Millisecond)
+}
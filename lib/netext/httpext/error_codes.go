@@ -11,6 +11,8 @@ import (
 	"runtime"
 	"syscall"
 
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
 	"golang.org/x/net/http2"
 
 	"go.k6.io/k6/lib/netext"
@@ -65,6 +67,18 @@ const (
 	// Custom k6 content errors, i.e. when the magic fails
 	// defaultContentError errCode = 1700 // reserved for future use
 	responseDecompressionErrorCode errCode = 1701
+
+	// QUIC errors
+	// defaultQUICErrorCode errCode = 1800 // reserved for future use
+	quicHandshakeTimeoutErrorCode   errCode = 1801
+	quicIdleTimeoutErrorCode        errCode = 1802
+	quicStatelessResetErrorCode     errCode = 1803
+	quicVersionNegotiationErrorCode errCode = 1804
+	quicTransportErrorCode          errCode = 1810
+
+	// HTTP3 errors
+	unknownHTTP3ErrorCode errCode = 1850
+	// errors till 1851 + 16 are other HTTP3 errors with a specific errCode
 )
 
 const (
@@ -83,6 +97,13 @@ const (
 	x509UnknownAuthority        = "x509: unknown authority"
 	requestTimeoutErrorCodeMsg  = "request timeout"
 	invalidURLErrorCodeMsg      = "invalid URL"
+
+	quicHandshakeTimeoutErrorCodeMsg   = "quic: handshake timeout"
+	quicIdleTimeoutErrorCodeMsg        = "quic: idle timeout"
+	quicStatelessResetErrorCodeMsg     = "quic: received a stateless reset"
+	quicVersionNegotiationErrorCodeMsg = "quic: version negotiation failed"
+	quicTransportErrorCodeMsg          = "quic: connection error with quic error code %s"
+	http3ErrorCodeMsg                  = "http3: error with http3 ErrCode %s"
 )
 
 func http2ErrCodeOffset(code http2.ErrCode) errCode {
@@ -92,6 +113,24 @@ func http2ErrCodeOffset(code http2.ErrCode) errCode {
 	return 1 + errCode(code)
 }
 
+func http3ErrCodeOffset(code http3.ErrCode) errCode {
+	if code < http3.ErrCodeNoError || code > http3.ErrCodeVersionFallback {
+		return 0
+	}
+	return 1 + errCode(code-http3.ErrCodeNoError)
+}
+
+func errorCodeForQUICTransportError(err *quic.TransportError) (errCode, string) {
+	// The TLS errors of the handshake, e.g. the x509 ones, are wrapped
+	if wrappedErr := errors.Unwrap(err); wrappedErr != nil {
+		errCodeForWrapped, errForWrapped := errorCodeForError(wrappedErr)
+		if errCodeForWrapped != defaultErrorCode {
+			return errCodeForWrapped, errForWrapped
+		}
+	}
+	return quicTransportErrorCode, fmt.Sprintf(quicTransportErrorCodeMsg, err.ErrorCode)
+}
+
 //nolint:errorlint
 func errorCodeForNetOpError(err *net.OpError) (errCode, string) {
 	// TODO: refactor this further - a big switch would be more readable, maybe
@@ -182,6 +221,22 @@ func errorCodeForError(err error) (errCode, string) {
 	case http2.ConnectionError:
 		return unknownHTTP2ConnectionErrorCode + http2ErrCodeOffset(http2.ErrCode(e)),
 			fmt.Sprintf(http2ConnectionErrorCodeMsg, http2.ErrCode(e))
+	case *http3.Error:
+		return unknownHTTP3ErrorCode + http3ErrCodeOffset(e.ErrorCode),
+			fmt.Sprintf(http3ErrorCodeMsg, e.ErrorCode)
+	case *quic.ApplicationError:
+		return unknownHTTP3ErrorCode + http3ErrCodeOffset(http3.ErrCode(e.ErrorCode)),
+			fmt.Sprintf(http3ErrorCodeMsg, http3.ErrCode(e.ErrorCode))
+	case *quic.TransportError:
+		return errorCodeForQUICTransportError(e)
+	case *quic.HandshakeTimeoutError:
+		return quicHandshakeTimeoutErrorCode, quicHandshakeTimeoutErrorCodeMsg
+	case *quic.IdleTimeoutError:
+		return quicIdleTimeoutErrorCode, quicIdleTimeoutErrorCodeMsg
+	case *quic.StatelessResetError:
+		return quicStatelessResetErrorCode, quicStatelessResetErrorCodeMsg
+	case *quic.VersionNegotiationError:
+		return quicVersionNegotiationErrorCode, quicVersionNegotiationErrorCodeMsg
 	case *net.OpError:
 		return errorCodeForNetOpError(e)
 	case x509.UnknownAuthorityError:
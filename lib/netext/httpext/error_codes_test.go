@@ -17,6 +17,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/net/http2"
@@ -388,3 +390,37 @@ func getHTTP2ServerWithCustomConnContext(t *testing.T) *httpmultibin.HTTPMultiBi
 		HTTPTransport:   transport,
 	}
 }
+
+func TestQUICErrors(t *testing.T) {
+	t.Parallel()
+	testTable := map[errCode]error{
+		quicHandshakeTimeoutErrorCode:   &quic.HandshakeTimeoutError{},
+		quicIdleTimeoutErrorCode:        &quic.IdleTimeoutError{},
+		quicStatelessResetErrorCode:     &quic.StatelessResetError{},
+		quicVersionNegotiationErrorCode: &quic.VersionNegotiationError{},
+		quicTransportErrorCode:          &quic.TransportError{ErrorCode: quic.ProtocolViolation},
+	}
+	testMapOfErrorCodes(t, testTable)
+
+	code, msg := errorCodeForError(&quic.TransportError{ErrorCode: quic.FlowControlError})
+	assert.Equal(t, quicTransportErrorCode, code)
+	assert.Equal(t, fmt.Sprintf(quicTransportErrorCodeMsg, quic.FlowControlError), msg)
+}
+
+func TestHTTP3Errors(t *testing.T) {
+	t.Parallel()
+	testTable := map[errCode]error{
+		unknownHTTP3ErrorCode + 1:  &http3.Error{ErrorCode: http3.ErrCodeNoError},
+		unknownHTTP3ErrorCode + 12: &http3.Error{ErrorCode: http3.ErrCodeRequestRejected},
+		unknownHTTP3ErrorCode + 17: &http3.Error{ErrorCode: http3.ErrCodeVersionFallback},
+		unknownHTTP3ErrorCode:      &http3.Error{ErrorCode: http3.ErrCodeDatagramError},
+		unknownHTTP3ErrorCode + 2: &quic.ApplicationError{
+			ErrorCode: quic.ApplicationErrorCode(http3.ErrCodeGeneralProtocolError),
+		},
+	}
+	testMapOfErrorCodes(t, testTable)
+
+	code, msg := errorCodeForError(&http3.Error{ErrorCode: http3.ErrCodeRequestRejected})
+	assert.Equal(t, unknownHTTP3ErrorCode+12, code)
+	assert.Equal(t, fmt.Sprintf(http3ErrorCodeMsg, http3.ErrCodeRequestRejected), msg)
+}
@@ -0,0 +1,264 @@
+package httpext
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/netext"
+)
+
+// http3Transport makes HTTP/3 requests over QUIC for https:// URLs, and the
+// requests to other URLs, e.g. after a redirect, with the VU transport. There's
+// no fallback to TCP, a request fails if its QUIC connection can't be made.
+type http3Transport struct {
+	base               http.RoundTripper
+	dialer             *netext.Dialer
+	tlsConfig          *tls.Config
+	quicConfig         quic.Config
+	disableCompression bool
+	disableKeepAlives  bool
+
+	roundTripper *http3.RoundTripper
+
+	// The UDP socket of all the QUIC connections, opened with the first one.
+	mx            sync.Mutex
+	quicTransport *quic.Transport
+}
+
+var _ closeIdler = &http3Transport{}
+
+func newHTTP3Transport(state *lib.State, base *http.Transport, params ConnectionParams) (*http3Transport, error) {
+	dialer, ok := state.Dialer.(*netext.Dialer)
+	if !ok {
+		return nil, fmt.Errorf("http3 isn't supported with a %T dialer", state.Dialer)
+	}
+
+	tlsConfig := base.TLSClientConfig.Clone()
+	if params.TLSConfig != nil {
+		tlsConfig = params.TLSConfig.Clone()
+	}
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{} //nolint:gosec // the VU doesn't have a TLS config only in tests
+	}
+	// QUIC is always secured with TLS 1.3, which the options have to allow
+	if tlsConfig.MaxVersion != 0 && tlsConfig.MaxVersion < tls.VersionTLS13 {
+		return nil, fmt.Errorf("http3 needs TLS 1.3, but the tlsVersion option allows at most %s",
+			lib.SupportedTLSVersionsToString[lib.TLSVersion(tlsConfig.MaxVersion)])
+	}
+	tlsConfig.NextProtos = nil // http3.RoundTripper sets them
+
+	t := &http3Transport{
+		base:               base,
+		dialer:             dialer,
+		tlsConfig:          tlsConfig,
+		quicConfig:         quic.Config{MaxIdleTimeout: params.IdleTimeout},
+		disableCompression: base.DisableCompression,
+		disableKeepAlives:  base.DisableKeepAlives,
+	}
+	t.roundTripper = t.newRoundTripper()
+	return t, nil
+}
+
+func (t *http3Transport) newRoundTripper() *http3.RoundTripper {
+	quicConfig := t.quicConfig // http3.RoundTripper changes it
+	return &http3.RoundTripper{
+		TLSClientConfig:    t.tlsConfig,
+		QUICConfig:         &quicConfig,
+		Dial:               t.dial,
+		DisableCompression: t.disableCompression,
+	}
+}
+
+// dial makes a new QUIC connection to the address, resolved and checked by the
+// dialer of the VU, and reports it to the tracer of the request. QUIC combines
+// the connection and the TLS handshake, so the whole dial is reported as the
+// TLS handshake.
+func (t *http3Transport) dial(
+	ctx context.Context, addr string, tlsConfig *tls.Config, quicConfig *quic.Config,
+) (quic.EarlyConnection, error) {
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace != nil && trace.TLSHandshakeStart != nil {
+		trace.TLSHandshakeStart()
+	}
+	conn, err := t.dialQUIC(ctx, addr, tlsConfig, quicConfig)
+	if trace != nil && trace.TLSHandshakeDone != nil {
+		var state tls.ConnectionState
+		if conn != nil {
+			state = conn.ConnectionState().TLS
+		}
+		trace.TLSHandshakeDone(state, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &http3Conn{EarlyConnection: conn}, nil
+}
+
+func (t *http3Transport) dialQUIC(
+	ctx context.Context, addr string, tlsConfig *tls.Config, quicConfig *quic.Config,
+) (quic.EarlyConnection, error) {
+	udpAddr, err := t.dialer.ResolveUDPAddr(addr)
+	if err != nil {
+		return nil, err
+	}
+	quicTransport, err := t.getQUICTransport()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := quicTransport.DialEarly(ctx, udpAddr, tlsConfig, quicConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// The connection can be returned before its handshake is done
+	select {
+	case <-conn.HandshakeComplete():
+		return conn, nil
+	case <-conn.Context().Done():
+		return nil, context.Cause(conn.Context())
+	case <-ctx.Done():
+		_ = conn.CloseWithError(quic.ApplicationErrorCode(http3.ErrCodeRequestCanceled), "")
+		return nil, ctx.Err()
+	}
+}
+
+func (t *http3Transport) getQUICTransport() (*quic.Transport, error) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	if t.quicTransport == nil {
+		conn, err := t.dialer.ListenUDP()
+		if err != nil {
+			return nil, err
+		}
+		t.quicTransport = &quic.Transport{Conn: conn}
+	}
+	return t.quicTransport, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *http3Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != "https" {
+		return t.base.RoundTrip(req)
+	}
+
+	// http3.RoundTripper doesn't call any of the hooks of the tracer, the
+	// dial function and the connections report the request instead.
+	trace := httptrace.ContextClientTrace(req.Context())
+	if trace != nil && trace.GetConn != nil {
+		trace.GetConn(req.URL.Host)
+	}
+	if !t.disableKeepAlives {
+		return t.roundTripper.RoundTrip(req)
+	}
+
+	// Without keep-alive, every request is made over a new connection of its
+	// own, which is closed with the body of its response.
+	roundTripper := t.newRoundTripper()
+	resp, err := roundTripper.RoundTrip(req)
+	if err != nil {
+		_ = roundTripper.Close()
+		return nil, err
+	}
+	resp.Body = &http3Body{ReadCloser: resp.Body, roundTripper: roundTripper}
+	return resp, nil
+}
+
+// CloseIdleConnections closes the QUIC connections without active requests.
+func (t *http3Transport) CloseIdleConnections() {
+	t.roundTripper.CloseIdleConnections()
+}
+
+// http3Body is the body of a response without keep-alive, which closes the
+// connection of the request with it.
+type http3Body struct {
+	io.ReadCloser
+	roundTripper io.Closer
+}
+
+func (b *http3Body) Close() error {
+	err := b.ReadCloser.Close()
+	_ = b.roundTripper.Close()
+	return err
+}
+
+// http3Conn is a QUIC connection that reports the requests it opens streams
+// for to their tracers, since http3.RoundTripper doesn't.
+type http3Conn struct {
+	quic.EarlyConnection
+
+	used atomic.Bool
+}
+
+// OpenStreamSync opens the stream of a request.
+func (c *http3Conn) OpenStreamSync(ctx context.Context) (quic.Stream, error) {
+	str, err := c.EarlyConnection.OpenStreamSync(ctx)
+	if err != nil {
+		return nil, err
+	}
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace == nil {
+		return str, nil
+	}
+	if trace.GotConn != nil {
+		// The first request of the connection is the one that dialed it
+		trace.GotConn(httptrace.GotConnInfo{
+			Conn:   quicNetConn{conn: c.EarlyConnection},
+			Reused: c.used.Swap(true),
+		})
+	}
+	return &http3Stream{Stream: str, trace: trace}, nil
+}
+
+// quicNetConn is the net.Conn of a QUIC connection in the GotConn hook of the
+// tracers, which use only its addresses.
+type quicNetConn struct {
+	net.Conn
+
+	conn quic.Connection
+}
+
+func (c quicNetConn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+func (c quicNetConn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// http3Stream is the QUIC stream of a request, which reports when the request
+// was sent and the first byte of the response was received to its tracer.
+type http3Stream struct {
+	quic.Stream
+
+	trace                        *httptrace.ClientTrace
+	closed, gotFirstResponseByte atomic.Bool
+}
+
+// Close is called after the request and its body were written.
+func (s *http3Stream) Close() error {
+	err := s.Stream.Close()
+	if s.trace.WroteRequest != nil && !s.closed.Swap(true) {
+		s.trace.WroteRequest(httptrace.WroteRequestInfo{Err: err})
+	}
+	return err
+}
+
+func (s *http3Stream) Read(b []byte) (int, error) {
+	n, err := s.Stream.Read(b)
+	if n > 0 && s.trace.GotFirstResponseByte != nil && !s.gotFirstResponseByte.Swap(true) {
+		s.trace.GotFirstResponseByte()
+	}
+	return n, err
+}
@@ -0,0 +1,261 @@
+package httpext
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+)
+
+// The OAuth2 grants that can be used to get the tokens.
+const (
+	OAuth2GrantClientCredentials = "client_credentials"
+	OAuth2GrantPassword          = "password"
+	OAuth2GrantRefreshToken      = "refresh_token"
+)
+
+const defaultOAuth2RefreshBefore = 30 * time.Second
+
+// OAuth2Config is the config of the token endpoint for the requests with the
+// oauth2 auth.
+type OAuth2Config struct {
+	TokenURL     string   `json:"tokenURL"`
+	GrantType    string   `json:"grantType"`
+	ClientID     string   `json:"clientID"`
+	ClientSecret string   `json:"clientSecret"`
+	Username     string   `json:"username"`
+	Password     string   `json:"password"`
+	RefreshToken string   `json:"refreshToken"`
+	Scopes       []string `json:"scopes"`
+
+	// Whether the token is shared by all VUs of the instance, instead of each
+	// VU getting its own.
+	Shared bool `json:"shared"`
+	// How long before it expires the token is refreshed, 30s by default.
+	RefreshBefore types.NullDuration `json:"refreshBefore"`
+}
+
+// Validate checks the config for errors.
+func (c OAuth2Config) Validate() error {
+	u, err := url.Parse(c.TokenURL)
+	if err != nil {
+		return fmt.Errorf("invalid tokenURL: %w", err)
+	}
+	if !u.IsAbs() {
+		return fmt.Errorf("tokenURL should be an absolute URL, but is '%s'", c.TokenURL)
+	}
+	switch c.GrantType {
+	case "", OAuth2GrantClientCredentials:
+		if c.ClientID == "" {
+			return errors.New("the client_credentials grant needs a clientID")
+		}
+	case OAuth2GrantPassword:
+		if c.Username == "" {
+			return errors.New("the password grant needs a username")
+		}
+	case OAuth2GrantRefreshToken:
+		if c.RefreshToken == "" {
+			return errors.New("the refresh_token grant needs a refreshToken")
+		}
+	default:
+		return fmt.Errorf("unsupported grantType '%s', it should be one of %s, %s or %s", c.GrantType,
+			OAuth2GrantClientCredentials, OAuth2GrantPassword, OAuth2GrantRefreshToken)
+	}
+	if c.RefreshBefore.Valid && c.RefreshBefore.Duration < 0 {
+		return fmt.Errorf("refreshBefore should be positive, but is %s", c.RefreshBefore)
+	}
+	return nil
+}
+
+func (c OAuth2Config) getGrantType() string {
+	if c.GrantType == "" {
+		return OAuth2GrantClientCredentials
+	}
+	return c.GrantType
+}
+
+// key returns the key of the token of the config in the token cache.
+func (c OAuth2Config) key() string {
+	return strings.Join([]string{
+		c.TokenURL, c.getGrantType(), c.ClientID, c.ClientSecret,
+		c.Username, c.Password, c.RefreshToken, strings.Join(c.Scopes, " "),
+	}, "\x00")
+}
+
+type oauth2Token struct {
+	accessToken  string
+	refreshToken string
+	refreshAt    time.Time // zero if it doesn't expire
+}
+
+type oauth2CacheEntry struct {
+	mx    sync.Mutex
+	token *oauth2Token
+}
+
+// OAuth2Tokens is a cache of OAuth2 tokens, either of a single VU or shared by
+// all of them.
+type OAuth2Tokens struct {
+	mx      sync.Mutex
+	entries map[string]*oauth2CacheEntry
+}
+
+// NewOAuth2Tokens returns an empty token cache.
+func NewOAuth2Tokens() *OAuth2Tokens {
+	return &OAuth2Tokens{entries: make(map[string]*oauth2CacheEntry)}
+}
+
+// Token returns the cached access token for the config, or fetches a new one
+// with the VU state if there isn't one yet or it's about to expire. Only one
+// of the VUs that share the cache fetches the token, the rest wait for it.
+func (t *OAuth2Tokens) Token(ctx context.Context, state *lib.State, config OAuth2Config) (string, error) {
+	key := config.key()
+	t.mx.Lock()
+	entry, ok := t.entries[key]
+	if !ok {
+		entry = &oauth2CacheEntry{}
+		t.entries[key] = entry
+	}
+	t.mx.Unlock()
+
+	entry.mx.Lock()
+	defer entry.mx.Unlock()
+	now := time.Now()
+	if entry.token != nil && (entry.token.refreshAt.IsZero() || now.Before(entry.token.refreshAt)) {
+		return entry.token.accessToken, nil
+	}
+
+	var (
+		token *oauth2Token
+		err   error
+	)
+	if entry.token != nil && entry.token.refreshToken != "" {
+		token, err = fetchOAuth2Token(ctx, state, config, OAuth2GrantRefreshToken, entry.token.refreshToken)
+		if err != nil {
+			state.Logger.WithError(err).Debug("Couldn't refresh the OAuth2 token, getting a new one")
+		}
+	}
+	if token == nil {
+		token, err = fetchOAuth2Token(ctx, state, config, config.getGrantType(), config.RefreshToken)
+		if err != nil {
+			return "", err
+		}
+	}
+	entry.token = token
+	return token.accessToken, nil
+}
+
+type oauth2TokenResponse struct {
+	AccessToken  string      `json:"access_token"`
+	TokenType    string      `json:"token_type"`
+	ExpiresIn    json.Number `json:"expires_in"`
+	RefreshToken string      `json:"refresh_token"`
+}
+
+// fetchOAuth2Token makes the request to the token endpoint with the grant. It's
+// made like any other request of the VU, but its metrics are oauth2_token_reqs
+// and oauth2_token_duration, instead of the http_req_* ones, so the thresholds
+// on those only have the requests that use the tokens.
+func fetchOAuth2Token(
+	ctx context.Context, state *lib.State, config OAuth2Config, grantType, refreshToken string,
+) (*oauth2Token, error) {
+	form := url.Values{"grant_type": {grantType}}
+	switch grantType {
+	case OAuth2GrantPassword:
+		form.Set("username", config.Username)
+		form.Set("password", config.Password)
+	case OAuth2GrantRefreshToken:
+		form.Set("refresh_token", refreshToken)
+	}
+	if len(config.Scopes) > 0 {
+		form.Set("scope", strings.Join(config.Scopes, " "))
+	}
+
+	u, err := NewURL(config.TokenURL, config.TokenURL)
+	if err != nil {
+		return nil, err
+	}
+	req := &http.Request{
+		Method: http.MethodPost,
+		URL:    u.GetURL(),
+		Header: make(http.Header),
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", state.Options.UserAgent.String)
+	// https://www.rfc-editor.org/rfc/rfc6749#section-2.3.1
+	if config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(config.ClientID), url.QueryEscape(config.ClientSecret))
+	} else if config.ClientID != "" {
+		form.Set("client_id", config.ClientID)
+	}
+
+	preq := &ParsedHTTPRequest{
+		URL:          &u,
+		Req:          req,
+		Body:         bytes.NewBufferString(form.Encode()),
+		Timeout:      60 * time.Second,
+		Throw:        true,
+		ResponseType: ResponseTypeText,
+		ResponseCallback: func(status int) bool {
+			return status >= 200 && status < 300
+		},
+		Redirects:   null.IntFrom(0),
+		Cookies:     make(map[string]*HTTPRequestCookie),
+		TagsAndMeta: state.Tags.GetCurrentValues(),
+		oauth2Token: true,
+	}
+
+	resp, err := MakeRequest(ctx, state, preq)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get the OAuth2 token: %w", err)
+	}
+	body, _ := resp.Body.(string)
+	if resp.Status != http.StatusOK {
+		return nil, fmt.Errorf("couldn't get the OAuth2 token, the token endpoint responded with status %d: %s",
+			resp.Status, body)
+	}
+
+	var tokenResp oauth2TokenResponse
+	if err = json.Unmarshal([]byte(body), &tokenResp); err != nil {
+		return nil, fmt.Errorf("couldn't parse the OAuth2 token response: %w", err)
+	}
+	if tokenResp.AccessToken == "" {
+		return nil, errors.New("the OAuth2 token response doesn't have an access_token")
+	}
+	if tokenResp.TokenType != "" && !strings.EqualFold(tokenResp.TokenType, "bearer") {
+		return nil, fmt.Errorf("unsupported OAuth2 token_type '%s', only bearer tokens are supported",
+			tokenResp.TokenType)
+	}
+
+	token := &oauth2Token{accessToken: tokenResp.AccessToken, refreshToken: tokenResp.RefreshToken}
+	if token.refreshToken == "" && grantType == OAuth2GrantRefreshToken {
+		token.refreshToken = refreshToken // the token endpoint didn't rotate it
+	}
+	if tokenResp.ExpiresIn != "" {
+		expiresIn, err := tokenResp.ExpiresIn.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("invalid expires_in in the OAuth2 token response: %w", err)
+		}
+		lifetime := time.Duration(expiresIn * float64(time.Second))
+		// The token is refreshed before it expires, but not sooner than
+		// halfway through its lifetime.
+		refreshBefore := defaultOAuth2RefreshBefore
+		if config.RefreshBefore.Valid {
+			refreshBefore = config.RefreshBefore.TimeDuration()
+		}
+		token.refreshAt = time.Now().Add(lifetime - min(refreshBefore, lifetime/2))
+	}
+	return token, nil
+}
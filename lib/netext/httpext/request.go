@@ -51,6 +51,15 @@ type ParsedHTTPRequest struct {
 	ActiveJar        *cookiejar.Jar
 	Cookies          map[string]*HTTPRequestCookie
 	TagsAndMeta      metrics.TagsAndMeta
+	// The transport of the connection pool for the request, if it doesn't
+	// use the one of the VU.
+	Transport http.RoundTripper
+	// The token endpoint and the token cache for the oauth2 auth.
+	OAuth2       *OAuth2Config
+	OAuth2Tokens *OAuth2Tokens
+
+	// Whether the request fetches an OAuth2 token, so it has its own metrics.
+	oauth2Token bool
 }
 
 // Matches non-compliant io.Closer implementations (e.g. zstd.Decoder)
@@ -174,6 +183,14 @@ func MakeRequest(ctx context.Context, state *lib.State, preq *ParsedHTTPRequest)
 		preq.TagsAndMeta.SetSystemTagOrMeta(metrics.TagName, preq.URL.Name)
 	}
 
+	if preq.Auth == "oauth2" {
+		token, err := preq.OAuth2Tokens.Token(ctx, state, *preq.OAuth2)
+		if err != nil {
+			return nil, err
+		}
+		preq.Req.Header.Set("Authorization", "Bearer "+token)
+	}
+
 	// Check rate limit *after* we've prepared a request; no need to wait with that part.
 	if rpsLimit := state.RPSLimit; rpsLimit != nil {
 		if err := rpsLimit.Wait(ctx); err != nil {
@@ -182,6 +199,10 @@ func MakeRequest(ctx context.Context, state *lib.State, preq *ParsedHTTPRequest)
 	}
 
 	tracerTransport := newTransport(ctx, state, &preq.TagsAndMeta, preq.ResponseCallback)
+	if preq.Transport != nil {
+		tracerTransport.roundTripper = preq.Transport
+	}
+	tracerTransport.oauth2Token = preq.oauth2Token
 	var transport http.RoundTripper = tracerTransport
 
 	if state.Options.HTTPDebug.String != "" {
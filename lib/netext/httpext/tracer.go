@@ -29,6 +29,10 @@ type Trail struct {
 	Waiting        time.Duration // Waiting for first byte.
 	Receiving      time.Duration // Receiving response.
 
+	// Waiting for the httpLimits, only if the request was limited by them.
+	Queued  time.Duration
+	Limited bool
+
 	// Detailed connection information.
 	ConnReused     bool
 	ConnRemoteAddr net.Addr
@@ -44,7 +48,8 @@ type Trail struct {
 func (tr *Trail) SaveSamples(builtinMetrics *metrics.BuiltinMetrics, ctm *metrics.TagsAndMeta) {
 	tr.Tags = ctm.Tags
 	tr.Metadata = ctm.Metadata
-	tr.Samples = make([]metrics.Sample, 0, 9) // this is with 1 more for a possible HTTPReqFailed
+	// this is with 1 more for a possible HTTPReqFailed and 1 for a possible HTTPReqQueued
+	tr.Samples = make([]metrics.Sample, 0, 10)
 	tr.Samples = append(tr.Samples, []metrics.Sample{
 		{
 			TimeSeries: metrics.TimeSeries{
@@ -119,6 +124,45 @@ func (tr *Trail) SaveSamples(builtinMetrics *metrics.BuiltinMetrics, ctm *metric
 			Value:    metrics.D(tr.Receiving),
 		},
 	}...)
+	if tr.Limited {
+		tr.Samples = append(tr.Samples, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: builtinMetrics.HTTPReqQueued,
+				Tags:   ctm.Tags,
+			},
+			Time:     tr.EndTime,
+			Metadata: ctm.Metadata,
+			Value:    metrics.D(tr.Queued),
+		})
+	}
+}
+
+// saveOAuth2TokenSamples is SaveSamples() for the requests that fetch the
+// tokens of the oauth2 auth, which have their own metrics, so they don't skew
+// the http_req_* ones of the requests that the script makes.
+func (tr *Trail) saveOAuth2TokenSamples(builtinMetrics *metrics.BuiltinMetrics, ctm *metrics.TagsAndMeta) {
+	tr.Tags = ctm.Tags
+	tr.Metadata = ctm.Metadata
+	tr.Samples = []metrics.Sample{
+		{
+			TimeSeries: metrics.TimeSeries{
+				Metric: builtinMetrics.OAuth2TokenReqs,
+				Tags:   ctm.Tags,
+			},
+			Time:     tr.EndTime,
+			Metadata: ctm.Metadata,
+			Value:    1,
+		},
+		{
+			TimeSeries: metrics.TimeSeries{
+				Metric: builtinMetrics.OAuth2TokenDuration,
+				Tags:   ctm.Tags,
+			},
+			Time:     tr.EndTime,
+			Metadata: ctm.Metadata,
+			Value:    metrics.D(tr.Duration),
+		},
+	}
 }
 
 // GetSamples implements the metrics.SampleContainer interface.
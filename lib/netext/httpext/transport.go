@@ -9,6 +9,9 @@ import (
 	"net/http/httptrace"
 	"strconv"
 	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go"
 
 	"go.k6.io/k6/lib"
 	"go.k6.io/k6/lib/netext"
@@ -22,6 +25,10 @@ type transport struct {
 	state            *lib.State
 	tagsAndMeta      *metrics.TagsAndMeta
 	responseCallback func(int) bool
+	// The transport the requests are made with, the one of the state if it's nil.
+	roundTripper http.RoundTripper
+	// Whether the requests fetch the tokens of the oauth2 auth.
+	oauth2Token bool
 
 	lastRequest     *unfinishedRequest
 	lastRequestLock *sync.Mutex
@@ -35,6 +42,10 @@ type unfinishedRequest struct {
 	request  *http.Request
 	response *http.Response
 	err      error
+
+	// Set if the request was limited by the httpLimits.
+	queued  time.Duration
+	release func()
 }
 
 // finishedRequest is produced once the request has been finalized; it is
@@ -76,6 +87,11 @@ func newTransport(
 //nolint:funlen
 func (t *transport) measureAndEmitMetrics(unfReq *unfinishedRequest) *finishedRequest {
 	trail := unfReq.tracer.Done()
+	// The body has been read by now, so the request isn't in-flight anymore.
+	if unfReq.release != nil {
+		unfReq.release()
+		trail.Queued, trail.Limited = unfReq.queued, true
+	}
 
 	result := &finishedRequest{
 		unfinishedRequest: unfReq,
@@ -143,6 +159,11 @@ func (t *transport) measureAndEmitMetrics(unfReq *unfinishedRequest) *finishedRe
 		tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagExpectedResponse, strconv.FormatBool(expected))
 	}
 
+	if t.oauth2Token {
+		trail.saveOAuth2TokenSamples(t.state.BuiltinMetrics, &tagsAndMeta)
+		metrics.PushIfNotDone(t.ctx, t.state.Samples, trail)
+		return result
+	}
 	trail.SaveSamples(t.state.BuiltinMetrics, &tagsAndMeta)
 	if t.responseCallback != nil {
 		trail.Failed.Valid = true
@@ -202,16 +223,35 @@ func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
 	t.processLastSavedRequest(nil)
 
 	ctx := req.Context()
+	// Every redirect is limited separately, since it can be to another host.
+	queued, release, err := t.state.HTTPLimiter.Wait(ctx, req.URL.Hostname())
+	if err != nil {
+		return nil, err
+	}
+
 	tracer := &Tracer{}
 	reqWithTracer := req.WithContext(httptrace.WithClientTrace(ctx, tracer.Trace()))
-	resp, err := t.state.Transport.RoundTrip(reqWithTracer)
+	roundTripper := t.roundTripper
+	if roundTripper == nil {
+		roundTripper = t.state.Transport
+	}
+	resp, err := roundTripper.RoundTrip(reqWithTracer)
 
 	var netError net.Error
 	if errors.As(err, &netError) && netError.Timeout() {
-		var netOpError *net.OpError
-		if errors.As(err, &netOpError) && netOpError.Op == "dial" {
+		var (
+			netOpError           *net.OpError
+			quicHandshakeTimeout *quic.HandshakeTimeoutError
+			quicIdleTimeout      *quic.IdleTimeoutError
+		)
+		switch {
+		case errors.As(err, &netOpError) && netOpError.Op == "dial":
 			err = NewK6Error(tcpDialTimeoutErrorCode, tcpDialTimeoutErrorCodeMsg, netError)
-		} else {
+		case errors.As(err, &quicHandshakeTimeout):
+			err = NewK6Error(quicHandshakeTimeoutErrorCode, quicHandshakeTimeoutErrorCodeMsg, netError)
+		case errors.As(err, &quicIdleTimeout):
+			err = NewK6Error(quicIdleTimeoutErrorCode, quicIdleTimeoutErrorCodeMsg, netError)
+		default:
 			err = NewK6Error(requestTimeoutErrorCode, requestTimeoutErrorCodeMsg, netError)
 		}
 	}
@@ -222,6 +262,8 @@ func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
 		request:  req,
 		response: resp,
 		err:      err,
+		queued:   queued,
+		release:  release,
 	})
 
 	return resp, err
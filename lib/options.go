@@ -25,6 +25,20 @@ const DefaultScenarioName = "default"
 //nolint:gochecknoglobals
 var DefaultSummaryTrendStats = []string{"avg", "min", "med", "max", "p(90)", "p(95)"}
 
+// The possible values of the trendSink option
+const (
+	// TrendSinkExact keeps all of the trend values, to calculate the exact
+	// percentiles from them. This is the default.
+	TrendSinkExact = "exact"
+	// TrendSinkHistogram aggregates the trend values in a histogram with a
+	// constant memory usage, at the price of approximate percentiles.
+	TrendSinkHistogram = "histogram"
+)
+
+// DefaultTrendSinkMaxError is the default maximum relative error of the
+// percentiles calculated by the histogram trend sinks.
+const DefaultTrendSinkMaxError = 0.01
+
 // TLSVersion describes a TLS version. Serialised to/from JSON as a string, eg. "tls1.2".
 type TLSVersion int
 
@@ -224,6 +238,41 @@ func ParseCIDR(s string) (*IPNet, error) {
 	return &parsedIPNet, nil
 }
 
+// HTTPLimitsAllHosts is the key of the httpLimits option for the limits of all
+// requests, regardless of their host.
+const HTTPLimitsAllHosts = "*"
+
+// HTTPLimit is the limit of the rate and the concurrency of the HTTP requests
+// to a host, for the whole test. The limit of every instance is scaled by its
+// execution segment, but every instance can make at least one request at a
+// time, so a concurrency lower than the number of instances is exceeded.
+type HTTPLimit struct {
+	// The maximum number of requests per second.
+	RPS null.Float `json:"rps"`
+	// The maximum number of requests in-flight at the same time.
+	Concurrency null.Int `json:"concurrency"`
+}
+
+// HTTPLimits are the limits of the HTTP requests, by the hostname of the
+// request, or HTTPLimitsAllHosts for all of them.
+type HTTPLimits map[string]HTTPLimit
+
+// Validate checks the limits for errors.
+func (l HTTPLimits) Validate() []error {
+	var errs []error
+	for host, limit := range l {
+		if limit.RPS.Valid && limit.RPS.Float64 <= 0 {
+			errs = append(errs, fmt.Errorf("the rps of the httpLimits of '%s' must be positive, got %v",
+				host, limit.RPS.Float64))
+		}
+		if limit.Concurrency.Valid && limit.Concurrency.Int64 <= 0 {
+			errs = append(errs, fmt.Errorf("the concurrency of the httpLimits of '%s' must be positive, got %d",
+				host, limit.Concurrency.Int64))
+		}
+	}
+	return errs
+}
+
 // Options represent configure options for k6.
 type Options struct {
 	// Should the test start in a paused state?
@@ -246,6 +295,11 @@ type Options struct {
 	ExecutionSegment         *ExecutionSegment         `json:"executionSegment" ignored:"true"`
 	ExecutionSegmentSequence *ExecutionSegmentSequence `json:"executionSegmentSequence" ignored:"true"`
 
+	// The seed of the random parts of the test execution, like the arrivals
+	// of the scenarios with an arrivalDistribution and the picks of the
+	// traffic mixes, so they're the same in every test run.
+	RandomSeed null.Int `json:"randomSeed" envconfig:"K6_RANDOM_SEED"`
+
 	// Timeouts for the setup() and teardown() functions
 	NoSetup         null.Bool          `json:"noSetup" envconfig:"K6_NO_SETUP"`
 	SetupTimeout    types.NullDuration `json:"setupTimeout" envconfig:"K6_SETUP_TIMEOUT"`
@@ -255,6 +309,9 @@ type Options struct {
 	// Limit HTTP requests per second.
 	RPS null.Int `json:"rps" envconfig:"K6_RPS"`
 
+	// Limit the rate and the concurrency of the HTTP requests, by host.
+	HTTPLimits HTTPLimits `json:"httpLimits" ignored:"true"`
+
 	// DNS handling configuration.
 	DNS types.DNSConfig `json:"dns" envconfig:"K6_DNS"`
 
@@ -299,6 +356,9 @@ type Options struct {
 	// Disable keep-alive connections
 	NoConnectionReuse null.Bool `json:"noConnectionReuse" envconfig:"K6_NO_CONNECTION_REUSE"`
 
+	// Make the requests to https:// URLs with HTTP/3, over QUIC connections.
+	HTTP3 null.Bool `json:"http3" envconfig:"K6_HTTP3"`
+
 	// Do not reuse connections between VU iterations. This gives more realistic results (depending
 	// on what you're looking for), but you need to raise various kernel limits or you'll get
 	// errors about running out of file handles or sockets, or being unable to bind addresses.
@@ -322,6 +382,12 @@ type Options struct {
 	// Summary time unit for summary metrics (response times) in CLI output
 	SummaryTimeUnit null.String `json:"summaryTimeUnit" envconfig:"K6_SUMMARY_TIME_UNIT"`
 
+	// How trend metrics are aggregated, either "exact" or "histogram"
+	TrendSink null.String `json:"trendSink" envconfig:"K6_TREND_SINK"`
+
+	// The maximum relative error of the percentiles for the "histogram" trend sink
+	TrendSinkMaxError null.Float `json:"trendSinkMaxError" envconfig:"K6_TREND_SINK_MAX_ERROR"`
+
 	// Which system tags to include with metrics ("method", "vu" etc.)
 	// Use pointer for identifying whether user provide any tag or not.
 	SystemTags *metrics.SystemTagSet `json:"systemTags" envconfig:"K6_SYSTEM_TAGS"`
@@ -404,6 +470,9 @@ func (o Options) Apply(opts Options) Options {
 	if opts.ExecutionSegmentSequence != nil {
 		o.ExecutionSegmentSequence = opts.ExecutionSegmentSequence
 	}
+	if opts.RandomSeed.Valid {
+		o.RandomSeed = opts.RandomSeed
+	}
 	if opts.NoSetup.Valid {
 		o.NoSetup = opts.NoSetup
 	}
@@ -419,6 +488,9 @@ func (o Options) Apply(opts Options) Options {
 	if opts.RPS.Valid {
 		o.RPS = opts.RPS
 	}
+	if opts.HTTPLimits != nil {
+		o.HTTPLimits = opts.HTTPLimits
+	}
 	if opts.MaxRedirects.Valid {
 		o.MaxRedirects = opts.MaxRedirects
 	}
@@ -464,6 +536,9 @@ func (o Options) Apply(opts Options) Options {
 	if opts.NoConnectionReuse.Valid {
 		o.NoConnectionReuse = opts.NoConnectionReuse
 	}
+	if opts.HTTP3.Valid {
+		o.HTTP3 = opts.HTTP3
+	}
 	if opts.NoVUConnectionReuse.Valid {
 		o.NoVUConnectionReuse = opts.NoVUConnectionReuse
 	}
@@ -485,6 +560,12 @@ func (o Options) Apply(opts Options) Options {
 	if opts.SummaryTimeUnit.Valid {
 		o.SummaryTimeUnit = opts.SummaryTimeUnit
 	}
+	if opts.TrendSink.Valid {
+		o.TrendSink = opts.TrendSink
+	}
+	if opts.TrendSinkMaxError.Valid {
+		o.TrendSinkMaxError = opts.TrendSinkMaxError
+	}
 	if opts.SystemTags != nil {
 		o.SystemTags = opts.SystemTags
 	}
@@ -536,11 +617,21 @@ func (o Options) Validate() []error {
 		}
 	}
 	validationErrors = append(validationErrors, o.Scenarios.Validate()...)
+	validationErrors = append(validationErrors, o.HTTPLimits.Validate()...)
 
 	// Duration
 	if o.SetupTimeout.Valid && o.SetupTimeout.Duration <= 0 {
 		validationErrors = append(validationErrors, errors.New("setupTimeout must be positive"))
 	}
+
+	if o.TrendSink.Valid && o.TrendSink.String != TrendSinkExact && o.TrendSink.String != TrendSinkHistogram {
+		validationErrors = append(validationErrors, fmt.Errorf(
+			"trendSink must be either '%s' or '%s', got '%s'", TrendSinkExact, TrendSinkHistogram, o.TrendSink.String,
+		))
+	}
+	if o.TrendSinkMaxError.Valid && (o.TrendSinkMaxError.Float64 <= 0 || o.TrendSinkMaxError.Float64 >= 1) {
+		validationErrors = append(validationErrors, errors.New("trendSinkMaxError must be between 0 and 1"))
+	}
 	return validationErrors
 }
 
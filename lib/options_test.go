@@ -75,6 +75,21 @@ func TestOptions(t *testing.T) {
 		assert.True(t, opts.RPS.Valid)
 		assert.Equal(t, int64(12345), opts.RPS.Int64)
 	})
+	t.Run("RandomSeed", func(t *testing.T) {
+		t.Parallel()
+		opts := Options{}.Apply(Options{RandomSeed: null.IntFrom(42)})
+		assert.Equal(t, null.IntFrom(42), opts.RandomSeed)
+		opts = opts.Apply(Options{})
+		assert.Equal(t, null.IntFrom(42), opts.RandomSeed)
+	})
+	t.Run("HTTPLimits", func(t *testing.T) {
+		t.Parallel()
+		limits := HTTPLimits{"example.com": {RPS: null.FloatFrom(10), Concurrency: null.IntFrom(2)}}
+		opts := Options{}.Apply(Options{HTTPLimits: limits})
+		assert.Equal(t, limits, opts.HTTPLimits)
+		opts = opts.Apply(Options{RPS: null.IntFrom(5)})
+		assert.Equal(t, limits, opts.HTTPLimits)
+	})
 	t.Run("MaxRedirects", func(t *testing.T) {
 		t.Parallel()
 		opts := Options{}.Apply(Options{MaxRedirects: null.IntFrom(12345)})
@@ -405,6 +420,12 @@ func TestOptions(t *testing.T) {
 		assert.True(t, opts.NoConnectionReuse.Valid)
 		assert.True(t, opts.NoConnectionReuse.Bool)
 	})
+	t.Run("HTTP3", func(t *testing.T) {
+		t.Parallel()
+		opts := Options{}.Apply(Options{HTTP3: null.BoolFrom(true)})
+		assert.True(t, opts.HTTP3.Valid)
+		assert.True(t, opts.HTTP3.Bool)
+	})
 	t.Run("NoVUConnectionReuse", func(t *testing.T) {
 		t.Parallel()
 		opts := Options{}.Apply(Options{NoVUConnectionReuse: null.BoolFrom(true)})
@@ -634,6 +655,11 @@ func TestOptionsEnv(t *testing.T) {
 			"true":  null.BoolFrom(true),
 			"false": null.BoolFrom(false),
 		},
+		{"HTTP3", "K6_HTTP3"}: {
+			"":      null.Bool{},
+			"true":  null.BoolFrom(true),
+			"false": null.BoolFrom(false),
+		},
 		{"NoVUConnectionReuse", "K6_NO_VU_CONNECTION_REUSE"}: {
 			"":      null.Bool{},
 			"true":  null.BoolFrom(true),
@@ -829,4 +855,45 @@ func TestValidate(t *testing.T) {
 			})
 		}
 	})
+	t.Run("trendSink", func(t *testing.T) {
+		t.Parallel()
+		testData := []struct {
+			opts          Options
+			expectFailure bool
+		}{
+			{opts: Options{TrendSink: null.StringFrom(TrendSinkExact)}},
+			{opts: Options{TrendSink: null.StringFrom(TrendSinkHistogram), TrendSinkMaxError: null.FloatFrom(0.05)}},
+			{opts: Options{TrendSink: null.StringFrom("hdr")}, expectFailure: true},
+			{opts: Options{TrendSinkMaxError: null.FloatFrom(0)}, expectFailure: true},
+			{opts: Options{TrendSinkMaxError: null.FloatFrom(1)}, expectFailure: true},
+		}
+		for _, data := range testData {
+			errorsSlice := Options{}.Apply(data.opts).Validate()
+			if data.expectFailure {
+				assert.Len(t, errorsSlice, 1)
+			} else {
+				assert.Empty(t, errorsSlice)
+			}
+		}
+	})
+	t.Run("httpLimits", func(t *testing.T) {
+		t.Parallel()
+		testData := []struct {
+			limits        HTTPLimits
+			expectFailure bool
+		}{
+			{limits: HTTPLimits{HTTPLimitsAllHosts: {RPS: null.FloatFrom(0.5)}}},
+			{limits: HTTPLimits{"example.com": {RPS: null.FloatFrom(10), Concurrency: null.IntFrom(1)}}},
+			{limits: HTTPLimits{"example.com": {RPS: null.FloatFrom(0)}}, expectFailure: true},
+			{limits: HTTPLimits{"example.com": {Concurrency: null.IntFrom(-1)}}, expectFailure: true},
+		}
+		for _, data := range testData {
+			errorsSlice := Options{}.Apply(Options{HTTPLimits: data.limits}).Validate()
+			if data.expectFailure {
+				assert.Len(t, errorsSlice, 1)
+			} else {
+				assert.Empty(t, errorsSlice)
+			}
+		}
+	})
 }
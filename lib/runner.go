@@ -5,6 +5,7 @@ import (
 	"io"
 	"time"
 
+	"go.k6.io/k6/lib/types"
 	"go.k6.io/k6/metrics"
 )
 
@@ -16,6 +17,16 @@ type ActiveVU interface {
 	RunOnce() error
 }
 
+// RetryableVU is an ActiveVU that can run the attempts of the iterations that
+// the executor retries.
+type RetryableVU interface {
+	ActiveVU
+	// RunAttempt runs the exported function once, like RunOnce(), as the
+	// given attempt of the iteration, the metrics of the retries are tagged
+	// with their attempt number. It also returns whether any checks failed.
+	RunAttempt(attempt int64) (failedCheck bool, err error)
+}
+
 // InitializedVU represents a virtual user ready for work. It needs to be
 // activated (i.e. given a context) before it can actually be used. Activation
 // also requires a callback function, which will be called when the supplied
@@ -36,6 +47,23 @@ type VUActivationParams struct {
 	Env, Tags                map[string]string
 	Exec, Scenario           string
 	GetNextIterationCounters func() (uint64, uint64)
+	// ExecWeights is the traffic mix of the scenario, if it has one, in which
+	// case every iteration picks the function it runs, instead of Exec.
+	ExecWeights []ExecWeight
+	// ThinkTime is the time that the VU waits after each iteration, if any,
+	// and Pacing is the cadence at which the VU starts its iterations, if
+	// it's more than 0.
+	ThinkTime *types.ThinkTime
+	Pacing    time.Duration
+	// RegularDurationContext is done when the regular duration of the
+	// executor is over and it doesn't start new iterations anymore, which
+	// also stops the think time and the pacing waits. RunContext is used
+	// instead, if it's nil.
+	RegularDurationContext context.Context
+	// GetIterationData returns the data the executor supplies for the
+	// current iteration, like the record of the replay-arrival executor.
+	// It's nil for the executors that don't supply any.
+	GetIterationData func() interface{}
 }
 
 // A Runner is a factory for VUs. It should precompute as much as possible upon
@@ -71,6 +99,20 @@ type Runner interface {
 	// Runs post-test teardown, if applicable.
 	Teardown(ctx context.Context, out chan<- metrics.SampleContainer) error
 
+	// Runs the setup function of the given scenario, if it has one. Its result is passed to the
+	// exec and teardown functions of the scenario, instead of the one of the global setup().
+	ScenarioSetup(ctx context.Context, scenario string, out chan<- metrics.SampleContainer) error
+
+	// Returns json representation of the setup data of the given scenario if its setup function
+	// is specified and run, nil otherwise
+	GetScenarioSetupData(scenario string) []byte
+
+	// Saves the externally supplied setup data of the given scenario as json in the runner
+	SetScenarioSetupData(scenario string, data []byte)
+
+	// Runs the teardown function of the given scenario, if it has one.
+	ScenarioTeardown(ctx context.Context, scenario string, out chan<- metrics.SampleContainer) error
+
 	// Get and set options. The initial value will be whatever the script specifies (for JS,
 	// `export let options = {}`); cmd/run.go will mix this in with CLI-, config- and env-provided
 	// values and write it back to the runner.
@@ -84,6 +126,15 @@ type Runner interface {
 	HandleSummary(context.Context, *Summary) (map[string]io.Reader, error)
 }
 
+// FileLoader is implemented by the runners that can load the data files of
+// the test that aren't opened by the script, like the request log of the
+// replay-arrival executor.
+type FileLoader interface {
+	// LoadFile returns the contents of the file with the given name, relative
+	// to the script, and makes it a part of the archive of the test.
+	LoadFile(name string) ([]byte, error)
+}
+
 // UIState describes the state of the UI, which might influence what
 // handleSummary() returns.
 type UIState struct {
@@ -4,6 +4,7 @@ import (
 	"io"
 	"strings"
 	"sync/atomic"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"go.k6.io/k6/event"
@@ -37,10 +38,22 @@ type TestRunState struct {
 
 	GroupSummary *GroupSummary // TODO(@mstoykov): move and rename
 
+	// MetricsWatcher gives access to the metrics while the test is running,
+	// it's nil if the metrics aren't processed locally.
+	MetricsWatcher MetricsWatcher
+
 	// TODO: add other properties that are computed or derived after init, e.g.
 	// thresholds?
 }
 
+// MetricsWatcher is implemented by the metrics engine, it allows the executors
+// to adapt to the metrics of the test while it's running.
+type MetricsWatcher interface {
+	// WatchMetric returns a function that returns a sink with the samples of
+	// the metric or sub-metric since its previous call, and the time since then.
+	WatchMetric(name string) (func() (metrics.Sink, time.Duration), error)
+}
+
 // GroupSummaryDescription is the description of the GroupSummary used to identify and ignore it
 // for the purposes of the cli descriptions.
 const GroupSummaryDescription = "Internal Group Summary output"
@@ -13,6 +13,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"runtime"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -21,6 +22,7 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/klauspost/compress/zstd"
 	"github.com/mccutchen/go-httpbin/httpbin"
+	"github.com/quic-go/quic-go/http3"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/net/http2"
 	"google.golang.org/grpc"
@@ -74,6 +76,7 @@ type HTTPMultiBin struct {
 	ServerHTTP      *httptest.Server
 	ServerHTTPS     *httptest.Server
 	ServerHTTP2     *httptest.Server
+	ServerHTTP3     *http3.Server
 	ServerGRPC      *grpc.Server
 	GRPCStub        *GRPCStub
 	GRPCAnyStub     *GRPCAnyStub
@@ -341,6 +344,17 @@ func NewHTTPMultiBin(t testing.TB) *HTTPMultiBin {
 	http2IP := net.ParseIP(http2URL.Hostname())
 	require.NotNil(t, http2IP)
 
+	// Initialize the HTTP3 server, on a UDP port with the certificate of the
+	// https server
+	http3Conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: httpsIP})
+	require.NoError(t, err)
+	http3Srv := &http3.Server{
+		Handler:   mux,
+		TLSConfig: http3.ConfigureTLSConfig(httpsSrv.TLS.Clone()),
+	}
+	go func() { _ = http3Srv.Serve(http3Conn) }()
+	http3Port := strconv.Itoa(http3Conn.LocalAddr().(*net.UDPAddr).Port) //nolint:forcetypeassert
+
 	httpDomainValue, err := types.NewHost(httpIP, "")
 	require.NoError(t, err)
 	httpsDomainValue, err := types.NewHost(httpsIP, "")
@@ -371,6 +385,7 @@ func NewHTTPMultiBin(t testing.TB) *HTTPMultiBin {
 		ServerHTTP:  httpSrv,
 		ServerHTTPS: httpsSrv,
 		ServerHTTP2: http2Srv,
+		ServerHTTP3: http3Srv,
 		ServerGRPC:  grpcSrv,
 		GRPCStub:    stub,
 		GRPCAnyStub: anyStub,
@@ -394,6 +409,12 @@ func NewHTTPMultiBin(t testing.TB) *HTTPMultiBin {
 			"HTTP2BIN_IP", http2IP.String(),
 			"HTTP2BIN_PORT", http2URL.Port(),
 
+			"HTTP3BIN_IP_URL", fmt.Sprintf("https://%s", net.JoinHostPort(httpsIP.String(), http3Port)),
+			"HTTP3BIN_DOMAIN", httpsDomain,
+			"HTTP3BIN_URL", fmt.Sprintf("https://%s", net.JoinHostPort(httpsDomain, http3Port)),
+			"HTTP3BIN_IP", httpsIP.String(),
+			"HTTP3BIN_PORT", http3Port,
+
 			"GRPCBIN_ADDR", net.JoinHostPort(httpsDomain, http2URL.Port()),
 		),
 		TLSClientConfig: tlsConfig,
@@ -404,6 +425,8 @@ func NewHTTPMultiBin(t testing.TB) *HTTPMultiBin {
 
 	t.Cleanup(func() {
 		grpcSrv.Stop()
+		_ = http3Srv.Close()
+		_ = http3Conn.Close()
 		http2Srv.Close()
 		httpsSrv.Close()
 		httpSrv.Close()
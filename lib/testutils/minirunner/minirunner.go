@@ -4,7 +4,9 @@ package minirunner
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"sync"
 
 	"go.k6.io/k6/lib"
 	"go.k6.io/k6/metrics"
@@ -13,6 +15,7 @@ import (
 // Ensure mock implementations conform to the interfaces.
 var (
 	_ lib.Runner        = &MiniRunner{}
+	_ lib.FileLoader    = &MiniRunner{}
 	_ lib.InitializedVU = &VU{}
 	_ lib.ActiveVU      = &ActiveVU{}
 )
@@ -26,8 +29,17 @@ type MiniRunner struct {
 	TeardownFn      func(ctx context.Context, out chan<- metrics.SampleContainer) error
 	HandleSummaryFn func(context.Context, *lib.Summary) (map[string]io.Reader, error)
 
+	ScenarioSetupFn    func(ctx context.Context, scenario string, out chan<- metrics.SampleContainer) ([]byte, error)
+	ScenarioTeardownFn func(ctx context.Context, scenario string, out chan<- metrics.SampleContainer) error
+
 	SetupData []byte
 
+	scenarioSetupData   map[string][]byte
+	scenarioSetupDataMx sync.RWMutex
+
+	// Files are the data files that can be loaded with LoadFile().
+	Files map[string][]byte
+
 	Options      lib.Options
 	PreInitState *lib.TestPreInitState
 
@@ -36,10 +48,19 @@ type MiniRunner struct {
 
 // MakeArchive isn't implemented, it always returns nil and is just here to
 // satisfy the lib.Runner interface.
-func (r MiniRunner) MakeArchive() *lib.Archive {
+func (r *MiniRunner) MakeArchive() *lib.Archive {
 	return nil
 }
 
+// LoadFile returns one of the supplied Files.
+func (r *MiniRunner) LoadFile(name string) ([]byte, error) {
+	data, ok := r.Files[name]
+	if !ok {
+		return nil, fmt.Errorf("file %q not found", name)
+	}
+	return data, nil
+}
+
 // NewVU returns a new VU with an incremental ID.
 func (r *MiniRunner) NewVU(
 	_ context.Context, idLocal, idGlobal uint64, out chan<- metrics.SampleContainer,
@@ -68,7 +89,7 @@ func (r *MiniRunner) Setup(ctx context.Context, out chan<- metrics.SampleContain
 
 // GetSetupData returns json representation of the setup data if setup() is
 // specified and was ran, nil otherwise.
-func (r MiniRunner) GetSetupData() []byte {
+func (r *MiniRunner) GetSetupData() []byte {
 	return r.SetupData
 }
 
@@ -78,21 +99,61 @@ func (r *MiniRunner) SetSetupData(data []byte) {
 }
 
 // Teardown calls the supplied mock teardown() function, if present.
-func (r MiniRunner) Teardown(ctx context.Context, out chan<- metrics.SampleContainer) error {
+func (r *MiniRunner) Teardown(ctx context.Context, out chan<- metrics.SampleContainer) error {
 	if fn := r.TeardownFn; fn != nil {
 		return fn(ctx, out)
 	}
 	return nil
 }
 
+// ScenarioSetup calls the supplied mock setup function of the scenarios, if
+// present.
+func (r *MiniRunner) ScenarioSetup(ctx context.Context, scenario string, out chan<- metrics.SampleContainer) error {
+	if fn := r.ScenarioSetupFn; fn != nil {
+		data, err := fn(ctx, scenario, out)
+		if err != nil {
+			return err
+		}
+		r.SetScenarioSetupData(scenario, data)
+	}
+	return nil
+}
+
+// GetScenarioSetupData returns the setup data of the given scenario, if any.
+func (r *MiniRunner) GetScenarioSetupData(scenario string) []byte {
+	r.scenarioSetupDataMx.RLock()
+	defer r.scenarioSetupDataMx.RUnlock()
+	return r.scenarioSetupData[scenario]
+}
+
+// SetScenarioSetupData saves the externally supplied setup data of the given
+// scenario in the runner.
+func (r *MiniRunner) SetScenarioSetupData(scenario string, data []byte) {
+	r.scenarioSetupDataMx.Lock()
+	defer r.scenarioSetupDataMx.Unlock()
+	if r.scenarioSetupData == nil {
+		r.scenarioSetupData = make(map[string][]byte)
+	}
+	r.scenarioSetupData[scenario] = data
+}
+
+// ScenarioTeardown calls the supplied mock teardown function of the scenarios,
+// if present.
+func (r *MiniRunner) ScenarioTeardown(ctx context.Context, scenario string, out chan<- metrics.SampleContainer) error {
+	if fn := r.ScenarioTeardownFn; fn != nil {
+		return fn(ctx, scenario, out)
+	}
+	return nil
+}
+
 // IsExecutable satisfies lib.Runner, but is mocked for MiniRunner since
 // it doesn't deal with JS.
-func (r MiniRunner) IsExecutable(_ string) bool {
+func (r *MiniRunner) IsExecutable(_ string) bool {
 	return true
 }
 
 // GetOptions returns the supplied options struct.
-func (r MiniRunner) GetOptions() lib.Options {
+func (r *MiniRunner) GetOptions() lib.Options {
 	return r.Options
 }
 
@@ -171,6 +232,7 @@ func (vu *VU) Activate(params *lib.VUActivationParams) lib.ActiveVU {
 	vu.state.GetScenarioGlobalVUIter = func() uint64 {
 		return avu.scIterGlobal
 	}
+	vu.state.GetScenarioIterationData = params.GetIterationData
 
 	go func() {
 		<-ctx.Done()
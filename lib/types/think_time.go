@@ -0,0 +1,144 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"gopkg.in/guregu/null.v3"
+)
+
+// NormalThinkTime are the parameters of the normal distribution of think times.
+type NormalThinkTime struct {
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stddev"`
+}
+
+// ThinkTime is a possibly random time in seconds, like the ones of sleep() and
+// of the thinkTime option of the scenarios. It's either a fixed number of
+// seconds, like 3, or one of the distributions {"normal": {"mean": 3,
+// "stddev": 1}}, {"uniform": [1, 5]} or {"exponential": 3}, where 3 is the
+// mean.
+type ThinkTime struct {
+	Fixed       null.Float       `json:"-"`
+	Normal      *NormalThinkTime `json:"normal,omitempty"`
+	Uniform     []float64        `json:"uniform,omitempty"`
+	Exponential *float64         `json:"exponential,omitempty"`
+}
+
+// NewThinkTime returns a fixed think time of the given seconds.
+func NewThinkTime(secs float64) ThinkTime {
+	return ThinkTime{Fixed: null.FloatFrom(secs)}
+}
+
+// MarshalJSON returns the fixed seconds, or the distribution.
+func (tt ThinkTime) MarshalJSON() ([]byte, error) {
+	if tt.Fixed.Valid {
+		return json.Marshal(tt.Fixed)
+	}
+	type rawThinkTime ThinkTime
+	return json.Marshal(rawThinkTime(tt))
+}
+
+// UnmarshalJSON accepts both the fixed seconds and the distributions.
+func (tt *ThinkTime) UnmarshalJSON(data []byte) error {
+	*tt = ThinkTime{}
+	if len(data) > 0 && data[0] != '{' {
+		return json.Unmarshal(data, &tt.Fixed)
+	}
+	type rawThinkTime ThinkTime
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode((*rawThinkTime)(tt))
+}
+
+// Validate makes sure exactly one of the fixed seconds and the distributions is
+// specified, and that its parameters are valid.
+func (tt ThinkTime) Validate() error {
+	specified := 0
+	for _, isSpecified := range []bool{tt.Fixed.Valid, tt.Normal != nil, tt.Uniform != nil, tt.Exponential != nil} {
+		if isSpecified {
+			specified++
+		}
+	}
+	if specified != 1 {
+		return errors.New("the think time must be either a number of seconds or exactly one of " +
+			"the normal, uniform or exponential distributions")
+	}
+
+	switch {
+	case tt.Fixed.Valid:
+		if tt.Fixed.Float64 < 0 {
+			return errors.New("the think time can't be negative")
+		}
+	case tt.Normal != nil:
+		if tt.Normal.Mean < 0 || tt.Normal.StdDev < 0 {
+			return errors.New("the mean and the stddev of the normal think time can't be negative")
+		}
+	case tt.Uniform != nil:
+		if len(tt.Uniform) != 2 || tt.Uniform[0] < 0 || tt.Uniform[0] > tt.Uniform[1] {
+			return errors.New("the uniform think time must be a range [min, max] of non-negative seconds")
+		}
+	case tt.Exponential != nil:
+		if *tt.Exponential <= 0 {
+			return errors.New("the mean of the exponential think time must be more than 0")
+		}
+	}
+	return nil
+}
+
+// Draw returns a think time, drawn with the given random numbers in [0, 1). The
+// times of the normal distribution that would be negative are 0.
+func (tt ThinkTime) Draw(random func() (float64, error)) (time.Duration, error) {
+	var secs float64
+	switch {
+	case tt.Normal != nil:
+		u1, err := random()
+		if err != nil {
+			return 0, err
+		}
+		u2, err := random()
+		if err != nil {
+			return 0, err
+		}
+		// The Box-Muller transform
+		z := math.Sqrt(-2*math.Log(1-u1)) * math.Cos(2*math.Pi*u2)
+		secs = math.Max(0, tt.Normal.Mean+z*tt.Normal.StdDev)
+	case len(tt.Uniform) == 2:
+		u, err := random()
+		if err != nil {
+			return 0, err
+		}
+		secs = tt.Uniform[0] + u*(tt.Uniform[1]-tt.Uniform[0])
+	case tt.Exponential != nil:
+		u, err := random()
+		if err != nil {
+			return 0, err
+		}
+		secs = -*tt.Exponential * math.Log(1-u)
+	default:
+		secs = tt.Fixed.Float64
+	}
+	return time.Duration(secs * float64(time.Second)), nil
+}
+
+// String returns a human-readable description of the think time.
+func (tt ThinkTime) String() string {
+	formatSecs := func(secs float64) string {
+		return strconv.FormatFloat(secs, 'f', -1, 64) + "s"
+	}
+	switch {
+	case tt.Normal != nil:
+		return fmt.Sprintf("normal(mean: %s, stddev: %s)", formatSecs(tt.Normal.Mean), formatSecs(tt.Normal.StdDev))
+	case len(tt.Uniform) == 2:
+		return fmt.Sprintf("uniform(%s-%s)", formatSecs(tt.Uniform[0]), formatSecs(tt.Uniform[1]))
+	case tt.Exponential != nil:
+		return fmt.Sprintf("exponential(mean: %s)", formatSecs(*tt.Exponential))
+	default:
+		return formatSecs(tt.Fixed.Float64)
+	}
+}
@@ -0,0 +1,74 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThinkTime(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		data   string
+		desc   string
+		err    string
+		random []float64
+		exp    time.Duration
+	}{
+		{data: `2.5`, desc: "2.5s", exp: 2500 * time.Millisecond},
+		{data: `{"uniform": [1, 3]}`, desc: "uniform(1s-3s)", random: []float64{0.25}, exp: 1500 * time.Millisecond},
+		{data: `{"exponential": 2}`, desc: "exponential(mean: 2s)", random: []float64{0}, exp: 0},
+		{
+			data: `{"normal": {"mean": 3, "stddev": 1}}`, desc: "normal(mean: 3s, stddev: 1s)",
+			random: []float64{0.5, 0.25}, exp: 3 * time.Second,
+		},
+		{data: `{"normal": {"mean": 0.5, "stddev": 10}}`, random: []float64{0.9, 0.5}, exp: 0},
+		{data: `-1`, err: "the think time can't be negative"},
+		{data: `{}`, err: "the think time must be either a number of seconds or exactly one of"},
+		{data: `{"uniform": [1, 3], "exponential": 2}`, err: "exactly one of"},
+		{data: `{"uniform": [3, 1]}`, err: "the uniform think time must be a range"},
+		{data: `{"uniform": [1]}`, err: "the uniform think time must be a range"},
+		{data: `{"exponential": 0}`, err: "the mean of the exponential think time must be more than 0"},
+		{data: `{"normal": {"mean": -1, "stddev": 1}}`, err: "can't be negative"},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.data, func(t *testing.T) {
+			t.Parallel()
+			var thinkTime ThinkTime
+			require.NoError(t, json.Unmarshal([]byte(tc.data), &thinkTime))
+			if tc.err != "" {
+				assert.ErrorContains(t, thinkTime.Validate(), tc.err)
+				return
+			}
+			require.NoError(t, thinkTime.Validate())
+			if tc.desc != "" {
+				assert.Equal(t, tc.desc, thinkTime.String())
+			}
+
+			data, err := json.Marshal(thinkTime)
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.data, string(data))
+
+			random := tc.random
+			d, err := thinkTime.Draw(func() (float64, error) {
+				r := random[0]
+				random = random[1:]
+				return r, nil
+			})
+			require.NoError(t, err)
+			assert.InDelta(t, tc.exp, d, float64(time.Millisecond))
+			assert.Empty(t, random)
+		})
+	}
+
+	t.Run("unknown distribution", func(t *testing.T) {
+		t.Parallel()
+		var thinkTime ThinkTime
+		assert.Error(t, json.Unmarshal([]byte(`{"lognormal": 2}`), &thinkTime))
+	})
+}
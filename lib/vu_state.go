@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/cookiejar"
 	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"go.opentelemetry.io/otel/trace"
@@ -53,7 +54,8 @@ type State struct {
 	TLSConfig *tls.Config
 
 	// Rate limits.
-	RPSLimit *rate.Limiter
+	RPSLimit    *rate.Limiter
+	HTTPLimiter *HTTPLimiter
 
 	// Sample channel, possibly buffered
 	Samples chan<- metrics.SampleContainer
@@ -64,6 +66,12 @@ type State struct {
 	VUID, VUIDGlobal uint64
 	Iteration        int64
 
+	// The time the current iteration has spent in sleep(), which isn't part
+	// of its iteration_duration.
+	IterationSleep time.Duration
+	// Whether any of the checks of the current iteration failed.
+	IterationFailedCheck bool
+
 	// TODO: rename this field with one more representative
 	// because it includes now also the metadata.
 	Tags *VUStateTags
@@ -79,6 +87,9 @@ type State struct {
 	// unique globally across k6 instances (taking into account execution
 	// segments).
 	GetScenarioGlobalVUIter func() uint64
+	// Returns the data the executor supplied for the current iteration, if
+	// there's any.
+	GetScenarioIterationData func() interface{}
 
 	// Tracing instrumentation.
 	TracerProvider TracerProvider
@@ -8,6 +8,9 @@ const (
 	IterationsName        = "iterations"
 	IterationDurationName = "iteration_duration"
 	DroppedIterationsName = "dropped_iterations"
+	ThinkTimeName         = "think_time"
+	IterationsRetriedName = "iterations_retried"
+	IterationsFailedName  = "iterations_failed"
 
 	ChecksName        = "checks"
 	GroupDurationName = "group_duration"
@@ -21,6 +24,10 @@ const (
 	HTTPReqSendingName        = "http_req_sending"
 	HTTPReqWaitingName        = "http_req_waiting"
 	HTTPReqReceivingName      = "http_req_receiving"
+	HTTPReqQueuedName         = "http_req_queued"
+
+	OAuth2TokenReqsName     = "oauth2_token_reqs"
+	OAuth2TokenDurationName = "oauth2_token_duration"
 
 	WSSessionsName         = "ws_sessions"
 	WSMessagesSentName     = "ws_msgs_sent"
@@ -42,6 +49,13 @@ type BuiltinMetrics struct {
 	Iterations        *Metric
 	IterationDuration *Metric
 	DroppedIterations *Metric
+	// The time the iterations spent in sleep() and the think time of their
+	// scenarios, which isn't part of their iteration_duration.
+	ThinkTime *Metric
+	// The retries of the failed iterations, and the iterations which failed
+	// after all of their attempts.
+	IterationsRetried *Metric
+	IterationsFailed  *Metric
 
 	// Runner-emitted.
 	Checks        *Metric
@@ -57,6 +71,11 @@ type BuiltinMetrics struct {
 	HTTPReqSending        *Metric
 	HTTPReqWaiting        *Metric
 	HTTPReqReceiving      *Metric
+	HTTPReqQueued         *Metric
+	// The requests that fetch the tokens of the oauth2 auth, which aren't in
+	// the http_req_* metrics.
+	OAuth2TokenReqs     *Metric
+	OAuth2TokenDuration *Metric
 
 	// Websocket-related
 	WSSessions         *Metric
@@ -82,6 +101,9 @@ func RegisterBuiltinMetrics(registry *Registry) *BuiltinMetrics {
 		Iterations:        registry.MustNewMetric(IterationsName, Counter),
 		IterationDuration: registry.MustNewMetric(IterationDurationName, Trend, Time),
 		DroppedIterations: registry.MustNewMetric(DroppedIterationsName, Counter),
+		ThinkTime:         registry.MustNewMetric(ThinkTimeName, Trend, Time),
+		IterationsRetried: registry.MustNewMetric(IterationsRetriedName, Counter),
+		IterationsFailed:  registry.MustNewMetric(IterationsFailedName, Counter),
 
 		Checks:        registry.MustNewMetric(ChecksName, Rate),
 		GroupDuration: registry.MustNewMetric(GroupDurationName, Trend, Time),
@@ -95,6 +117,9 @@ func RegisterBuiltinMetrics(registry *Registry) *BuiltinMetrics {
 		HTTPReqSending:        registry.MustNewMetric(HTTPReqSendingName, Trend, Time),
 		HTTPReqWaiting:        registry.MustNewMetric(HTTPReqWaitingName, Trend, Time),
 		HTTPReqReceiving:      registry.MustNewMetric(HTTPReqReceivingName, Trend, Time),
+		HTTPReqQueued:         registry.MustNewMetric(HTTPReqQueuedName, Trend, Time),
+		OAuth2TokenReqs:       registry.MustNewMetric(OAuth2TokenReqsName, Counter),
+		OAuth2TokenDuration:   registry.MustNewMetric(OAuth2TokenDurationName, Trend, Time),
 
 		WSSessions:         registry.MustNewMetric(WSSessionsName, Counter),
 		WSMessagesSent:     registry.MustNewMetric(WSMessagesSentName, Counter),
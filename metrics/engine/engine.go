@@ -30,6 +30,18 @@ type MetricsEngine struct {
 	// These can be both top-level metrics or sub-metrics
 	metricsWithThresholds   []*metrics.Metric
 	breachedThresholdsCount uint32
+	evaluatingThresholds    uint32
+	// The evaluation of the thresholds while the test is running, it's nil
+	// before StartThresholdCalculations() is called
+	evaluation *thresholdsEvaluation
+
+	// The other metrics that thresholds reference, by the names they use
+	thresholdReferences map[*metrics.Metric]map[string]*metrics.Metric
+	// The recent data of the metrics with time-windowed thresholds, or that
+	// are referenced by time-windowed thresholds
+	thresholdWindows map[*metrics.Metric]*thresholdWindows
+	// The samples of the metrics that are watched while the test is running
+	metricWatches map[*metrics.Metric][]*metricWatch
 
 	// TODO: completely refactor:
 	//   - make these private, add a method to export the raw data
@@ -43,9 +55,12 @@ type MetricsEngine struct {
 // NewMetricsEngine creates a new metrics Engine with the given parameters.
 func NewMetricsEngine(registry *metrics.Registry, logger logrus.FieldLogger) (*MetricsEngine, error) {
 	me := &MetricsEngine{
-		registry:        registry,
-		logger:          logger.WithField("component", "metrics-engine"),
-		ObservedMetrics: make(map[string]*metrics.Metric),
+		registry:            registry,
+		logger:              logger.WithField("component", "metrics-engine"),
+		thresholdReferences: make(map[*metrics.Metric]map[string]*metrics.Metric),
+		thresholdWindows:    make(map[*metrics.Metric]*thresholdWindows),
+		metricWatches:       make(map[*metrics.Metric][]*metricWatch),
+		ObservedMetrics:     make(map[string]*metrics.Metric),
 	}
 
 	return me, nil
@@ -107,6 +122,52 @@ func (me *MetricsEngine) getThresholdMetricOrSubmetric(name string) (*metrics.Me
 	return sm.Metric, nil
 }
 
+// addSample adds the sample to the sink of the given metric or sub-metric,
+// and to its time windows, if it has any.
+func (me *MetricsEngine) addSample(metric *metrics.Metric, sample metrics.Sample) {
+	metric.Sink.Add(sample)
+	if tw, ok := me.thresholdWindows[metric]; ok {
+		tw.current.Add(sample)
+	}
+	for _, mw := range me.metricWatches[metric] {
+		mw.sink.Add(sample)
+	}
+}
+
+// metricWatch collects the samples of a metric since they were last taken.
+type metricWatch struct {
+	sink  metrics.Sink
+	since time.Time
+}
+
+// WatchMetric returns a function that returns a sink with the samples of the
+// given metric or sub-metric, like http_req_duration{scenario:api}, since its
+// previous call (or since the metric started being watched), together with
+// the time since then. It allows the executors to adapt to the metrics while
+// the test is running.
+func (me *MetricsEngine) WatchMetric(name string) (func() (metrics.Sink, time.Duration), error) {
+	me.MetricsLock.Lock()
+	defer me.MetricsLock.Unlock()
+
+	metric, err := me.getThresholdMetricOrSubmetric(name)
+	if err != nil {
+		return nil, err
+	}
+	mw := &metricWatch{sink: me.registry.NewSink(metric.Type), since: time.Now()}
+	me.metricWatches[metric] = append(me.metricWatches[metric], mw)
+
+	return func() (metrics.Sink, time.Duration) {
+		me.MetricsLock.Lock()
+		defer me.MetricsLock.Unlock()
+
+		sink, now := mw.sink, time.Now()
+		mw.sink = me.registry.NewSink(metric.Type)
+		elapsed := now.Sub(mw.since)
+		mw.since = now
+		return sink, elapsed
+	}, nil
+}
+
 func (me *MetricsEngine) markObserved(metric *metrics.Metric) {
 	if !metric.Observed {
 		metric.Observed = true
@@ -134,6 +195,22 @@ func (me *MetricsEngine) InitSubMetricsAndThresholds(options lib.Options, onlyLo
 
 		metric.Thresholds = thresholds
 		me.metricsWithThresholds = append(me.metricsWithThresholds, metric)
+		thresholdWindows := thresholds.Windows()
+		me.addThresholdWindows(metric, thresholdWindows)
+
+		// The referenced metrics get the same time windows, since they are
+		// evaluated together with the metric that references them
+		for _, reference := range thresholds.References() {
+			referencedMetric, err := me.getThresholdMetricOrSubmetric(reference)
+			if err != nil {
+				return fmt.Errorf("invalid metric '%s' referenced in the thresholds of '%s': %w", reference, metricName, err)
+			}
+			if me.thresholdReferences[metric] == nil {
+				me.thresholdReferences[metric] = make(map[string]*metrics.Metric)
+			}
+			me.thresholdReferences[metric][reference] = referencedMetric
+			me.addThresholdWindows(referencedMetric, thresholdWindows)
+		}
 
 		// Mark the metric (and the parent metric, if we're dealing with a
 		// submetric) as observed, so they are shown in the end-of-test summary,
@@ -153,32 +230,196 @@ func (me *MetricsEngine) InitSubMetricsAndThresholds(options lib.Options, onlyLo
 		}
 	}
 
+	// The iterations of every function of the traffic mixes are counted, so
+	// they are shown in the end-of-test summary
+	if options.SystemTags.Has(metrics.TagExec) {
+		for _, scenario := range options.Scenarios {
+			for _, w := range scenario.GetExecWeights() {
+				_, err := me.getThresholdMetricOrSubmetric(metrics.IterationsName + "{exec:" + w.Fn + "}")
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
 	return nil
 }
 
-// StartThresholdCalculations spins up a new goroutine to crunch thresholds and
-// returns a callback that will stop the goroutine and finalizes calculations.
-func (me *MetricsEngine) StartThresholdCalculations(
-	ingester *OutputIngester,
-	abortRun func(error),
-	getCurrentTestRunDuration func() time.Duration,
-) (finalize func() (breached []string)) {
-	if len(me.metricsWithThresholds) == 0 {
-		return nil // no thresholds were defined
+// addThresholdWindows makes sure the recent data of the metric is kept for
+// the given time windows, in addition to the windows it already had.
+func (me *MetricsEngine) addThresholdWindows(metric *metrics.Metric, windows []time.Duration) {
+	if len(windows) == 0 {
+		return
+	}
+	if tw, ok := me.thresholdWindows[metric]; ok {
+		tw.windows = mergeWindows(tw.windows, windows)
+		return
+	}
+	metricType := metric.Type
+	newSink := func() metrics.MergeableSink {
+		// the registry only makes the built-in sinks, which are all mergeable
+		return me.registry.NewSink(metricType).(metrics.MergeableSink) //nolint:forcetypeassert
+	}
+	me.thresholdWindows[metric] = newThresholdWindows(newSink, mergeWindows(nil, windows))
+}
+
+type thresholdsUpdate struct {
+	metric     *metrics.Metric
+	thresholds metrics.Thresholds
+	references map[string]*metrics.Metric
+}
+
+// UpdateThresholds replaces the thresholds of the given metrics and
+// sub-metrics while the test is running. All of the new thresholds are parsed
+// and validated first, so either all of them are changed or none of them are.
+// An empty list of thresholds removes the thresholds of the metric. The time
+// windows that weren't used before only have the data since the update.
+func (me *MetricsEngine) UpdateThresholds(thresholds map[string]metrics.Thresholds) error {
+	me.MetricsLock.Lock()
+	defer me.MetricsLock.Unlock()
+
+	metricNames := make([]string, 0, len(thresholds))
+	for metricName := range thresholds {
+		metricNames = append(metricNames, metricName)
+	}
+	sort.Strings(metricNames)
+
+	updates := make([]thresholdsUpdate, 0, len(metricNames))
+	for _, metricName := range metricNames {
+		ts := thresholds[metricName]
+		if err := ts.Parse(); err != nil {
+			return fmt.Errorf("invalid thresholds on metric '%s': %w", metricName, err)
+		}
+		if err := ts.Validate(metricName, me.registry); err != nil {
+			return err
+		}
+		metric, err := me.getThresholdMetricOrSubmetric(metricName)
+		if err != nil {
+			return fmt.Errorf("invalid metric '%s' in threshold definitions: %w", metricName, err)
+		}
+
+		update := thresholdsUpdate{metric: metric, thresholds: ts}
+		for _, reference := range ts.References() {
+			referencedMetric, err := me.getThresholdMetricOrSubmetric(reference)
+			if err != nil {
+				return fmt.Errorf("invalid metric '%s' referenced in the thresholds of '%s': %w", reference, metricName, err)
+			}
+			if update.references == nil {
+				update.references = make(map[string]*metrics.Metric)
+			}
+			update.references[reference] = referencedMetric
+		}
+		updates = append(updates, update)
+	}
+
+	for _, update := range updates {
+		me.setThresholds(update)
+	}
+	me.pruneThresholdWindows()
+	if me.evaluation != nil && len(me.metricsWithThresholds) > 0 {
+		me.evaluation.start(me)
+	}
+	return nil
+}
+
+// pruneThresholdWindows forgets the time windows that none of the thresholds
+// use any more, and all of the recent data of the metrics that don't have any
+// windows left. It must be called with the metrics lock held.
+func (me *MetricsEngine) pruneThresholdWindows() {
+	used := make(map[*metrics.Metric][]time.Duration, len(me.thresholdWindows))
+	for _, m := range me.metricsWithThresholds {
+		windows := m.Thresholds.Windows()
+		if len(windows) == 0 {
+			continue
+		}
+		used[m] = mergeWindows(used[m], windows)
+		for _, referencedMetric := range me.thresholdReferences[m] {
+			used[referencedMetric] = mergeWindows(used[referencedMetric], windows)
+		}
+	}
+	for m, tw := range me.thresholdWindows {
+		windows, ok := used[m]
+		if !ok {
+			delete(me.thresholdWindows, m)
+			continue
+		}
+		tw.windows = windows
+	}
+}
+
+// setThresholds replaces the thresholds of a metric. It must be called with
+// the metrics lock held.
+func (me *MetricsEngine) setThresholds(update thresholdsUpdate) {
+	metric := update.metric
+	metric.Thresholds = update.thresholds
+	metric.Tainted = null.Bool{} // until the new thresholds are evaluated
+
+	index := -1
+	for i, m := range me.metricsWithThresholds {
+		if m == metric {
+			index = i
+			break
+		}
 	}
 
-	stop := make(chan struct{})
-	done := make(chan struct{})
+	if len(update.thresholds.Thresholds) == 0 {
+		if index >= 0 {
+			me.metricsWithThresholds = append(me.metricsWithThresholds[:index], me.metricsWithThresholds[index+1:]...)
+		}
+		delete(me.thresholdReferences, metric)
+		return
+	}
+
+	if index < 0 {
+		me.metricsWithThresholds = append(me.metricsWithThresholds, metric)
+	}
+	if update.references != nil {
+		me.thresholdReferences[metric] = update.references
+	} else {
+		delete(me.thresholdReferences, metric)
+	}
+
+	windows := update.thresholds.Windows()
+	me.addThresholdWindows(metric, windows)
+	for _, referencedMetric := range update.references {
+		me.addThresholdWindows(referencedMetric, windows)
+	}
+
+	me.markObserved(metric)
+	if metric.Sub != nil {
+		me.markObserved(metric.Sub.Parent)
+	}
+}
+
+// thresholdsEvaluation is the goroutine that evaluates the thresholds while
+// the test is running. It's started only once there are any thresholds, which
+// can be after the test has started, if they are added with UpdateThresholds().
+type thresholdsEvaluation struct {
+	abortRun                  func(error)
+	getCurrentTestRunDuration func() time.Duration
+
+	started, finalized bool
+	stop, done         chan struct{}
+}
+
+// start starts the evaluation goroutine, if it isn't already running or done.
+// It must be called with the metrics lock held.
+func (te *thresholdsEvaluation) start(me *MetricsEngine) {
+	if te.started || te.finalized {
+		return
+	}
+	te.started = true
 
 	go func() {
-		defer close(done)
+		defer close(te.done)
 		ticker := time.NewTicker(thresholdsRate)
 		defer ticker.Stop()
 
 		for {
 			select {
 			case <-ticker.C:
-				breached, shouldAbort := me.evaluateThresholds(true, getCurrentTestRunDuration)
+				breached, shouldAbort := me.evaluateThresholds(true, te.getCurrentTestRunDuration)
 				if shouldAbort {
 					err := fmt.Errorf(
 						"thresholds on metrics '%s' were crossed; at least one has abortOnFail enabled, stopping test prematurely",
@@ -188,13 +429,38 @@ func (me *MetricsEngine) StartThresholdCalculations(
 					err = errext.WithAbortReasonIfNone(
 						errext.WithExitCodeIfNone(err, exitcodes.ThresholdsHaveFailed), errext.AbortedByThreshold,
 					)
-					abortRun(err)
+					te.abortRun(err)
 				}
-			case <-stop:
+			case <-te.stop:
 				return
 			}
 		}
 	}()
+}
+
+// StartThresholdCalculations spins up a new goroutine to crunch thresholds and
+// returns a callback that will stop the goroutine and finalizes calculations.
+// If the test doesn't define any thresholds, the goroutine is only started
+// when some are added with UpdateThresholds(), and without it the callback
+// only stops the ingester.
+func (me *MetricsEngine) StartThresholdCalculations(
+	ingester *OutputIngester,
+	abortRun func(error),
+	getCurrentTestRunDuration func() time.Duration,
+) (finalize func() (breached []string)) {
+	me.MetricsLock.Lock()
+	evaluation := &thresholdsEvaluation{
+		abortRun:                  abortRun,
+		getCurrentTestRunDuration: getCurrentTestRunDuration,
+		stop:                      make(chan struct{}),
+		done:                      make(chan struct{}),
+	}
+	me.evaluation = evaluation
+	if len(me.metricsWithThresholds) > 0 {
+		evaluation.start(me)
+	}
+	me.MetricsLock.Unlock()
+	atomic.StoreUint32(&me.evaluatingThresholds, 1)
 
 	return func() []string {
 		if ingester != nil {
@@ -204,9 +470,17 @@ func (me *MetricsEngine) StartThresholdCalculations(
 				me.logger.WithError(err).Warnf("There was a problem stopping the output ingester.")
 			}
 		}
-		close(stop)
-		<-done
+		me.MetricsLock.Lock()
+		evaluation.finalized = true
+		started := evaluation.started
+		me.MetricsLock.Unlock()
+		close(evaluation.stop)
+		if !started {
+			return nil // there were never any thresholds
+		}
+		<-evaluation.done
 
+		me.logger.Debug("Finalizing thresholds...")
 		breached, _ := me.evaluateThresholds(false, getCurrentTestRunDuration)
 		return breached
 	}
@@ -225,6 +499,19 @@ func (me *MetricsEngine) evaluateThresholds(
 	t := getCurrentTestRunDuration()
 
 	me.logger.Debugf("Running thresholds on %d metrics...", len(me.metricsWithThresholds))
+
+	// The sinks of the time windows are aggregated only once, since the
+	// same metric can be referenced by the thresholds of multiple metrics
+	windowSinks := make(map[*metrics.Metric]map[time.Duration]metrics.Sink, len(me.thresholdWindows))
+	for m, tw := range me.thresholdWindows {
+		sinks, err := tw.sinks(t)
+		if err != nil {
+			me.logger.WithField("metric_name", m.Name).WithError(err).Error("Threshold error")
+			continue
+		}
+		windowSinks[m] = sinks
+	}
+
 	for _, m := range me.metricsWithThresholds {
 		// If either the metric has no thresholds defined, or its sinks
 		// are empty, let's ignore its thresholds execution at this point.
@@ -233,7 +520,18 @@ func (me *MetricsEngine) evaluateThresholds(
 		}
 		m.Tainted = null.BoolFrom(false)
 
-		succ, err := m.Thresholds.Run(m.Sink, t)
+		references := me.thresholdReferences[m]
+		referencedSinks := make(map[string]metrics.ThresholdSinks, len(references))
+		for name, rm := range references {
+			if ignoreEmptySinks && rm.Sink.IsEmpty() {
+				continue
+			}
+			referencedSinks[name] = metrics.ThresholdSinks{Sink: rm.Sink, Windows: windowSinks[rm]}
+		}
+
+		succ, err := m.Thresholds.RunWithSinks(
+			metrics.ThresholdSinks{Sink: m.Sink, Windows: windowSinks[m]}, referencedSinks, t,
+		)
 		if err != nil {
 			me.logger.WithField("metric_name", m.Name).WithError(err).Error("Threshold error")
 			continue
@@ -255,6 +553,13 @@ func (me *MetricsEngine) evaluateThresholds(
 	return breachedThresholds, shouldAbort
 }
 
+// IsEvaluatingThresholds returns whether the thresholds are evaluated while
+// the test is running, which happens unless they are disabled, even if the test
+// doesn't define any thresholds yet. This API is safe to use concurrently.
+func (me *MetricsEngine) IsEvaluatingThresholds() bool {
+	return atomic.LoadUint32(&me.evaluatingThresholds) == 1
+}
+
 // GetMetricsWithBreachedThresholdsCount returns the number of metrics for which
 // the thresholds were breached (failed) during the last processing phase. This
 // API is safe to use concurrently.
@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/executor"
 	"go.k6.io/k6/lib/testutils"
 	"go.k6.io/k6/metrics"
 )
@@ -153,6 +154,273 @@ func TestMetricsEngineEvaluateIgnoreEmptySink(t *testing.T) {
 	assert.Empty(t, breached)
 }
 
+func TestMetricsEngineEvaluateWindowedThresholds(t *testing.T) {
+	t.Parallel()
+
+	me := newTestMetricsEngine(t)
+	m1, err := me.registry.NewMetric("m1", metrics.Trend)
+	require.NoError(t, err)
+
+	ths := metrics.NewThresholds([]string{"max<100 over 10s", "max<1000"})
+	require.NoError(t, ths.Parse())
+	require.NoError(t, me.InitSubMetricsAndThresholds(lib.Options{
+		Thresholds: map[string]metrics.Thresholds{"m1": ths},
+	}, false))
+	ingester := me.CreateIngester()
+
+	var now time.Duration
+	getTestRunDuration := func() time.Duration { return now }
+	addAndEvaluate := func(value float64) []string {
+		now += 2 * time.Second
+		ingester.AddMetricSamples([]metrics.SampleContainer{metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: m1, Tags: me.registry.RootTagSet()},
+			Time:       time.Now(),
+			Value:      value,
+		}})
+		ingester.flushMetrics()
+		breached, _ := me.evaluateThresholds(true, getTestRunDuration)
+		return breached
+	}
+
+	assert.Empty(t, addAndEvaluate(10))
+	assert.Equal(t, []string{"m1"}, addAndEvaluate(500)) // a spike
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, []string{"m1"}, addAndEvaluate(10), i) // the spike is still in the window
+	}
+	assert.Empty(t, addAndEvaluate(10)) // the spike is out of the window
+
+	// The whole test run is still used for the thresholds without a window
+	assert.Equal(t, 500.0, m1.Sink.(*metrics.TrendSink).Max()) //nolint:forcetypeassert
+	assert.Equal(t, []string{"m1"}, addAndEvaluate(5000))
+}
+
+func TestMetricsEngineEvaluateReferencingThresholds(t *testing.T) {
+	t.Parallel()
+
+	me := newTestMetricsEngine(t)
+	errs, err := me.registry.NewMetric("errs", metrics.Counter)
+	require.NoError(t, err)
+	total, err := me.registry.NewMetric("total", metrics.Counter)
+	require.NoError(t, err)
+
+	ths := metrics.NewThresholds([]string{"count / total.count < 0.15", "count < total{kind:a}.count over 10s"})
+	require.NoError(t, ths.Parse())
+	require.NoError(t, me.InitSubMetricsAndThresholds(lib.Options{
+		Thresholds: map[string]metrics.Thresholds{"errs": ths},
+	}, false))
+
+	// The referenced sub-metric was created, and it has the same windows
+	require.Len(t, total.Submetrics, 1)
+	assert.Contains(t, me.thresholdWindows, total.Submetrics[0].Metric)
+	assert.Contains(t, me.thresholdWindows, errs)
+
+	ingester := me.CreateIngester()
+	add := func(m *metrics.Metric, tags *metrics.TagSet, value float64) {
+		ingester.AddMetricSamples([]metrics.SampleContainer{metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: m, Tags: tags},
+			Time:       time.Now(),
+			Value:      value,
+		}})
+		ingester.flushMetrics()
+	}
+	getTestRunDuration := func() time.Duration { return time.Second }
+
+	add(errs, me.registry.RootTagSet(), 1)
+	breached, _ := me.evaluateThresholds(true, getTestRunDuration)
+	assert.Empty(t, breached, "the referenced metrics have no samples yet")
+
+	add(total, me.registry.RootTagSet().With("kind", "a"), 5)
+	add(total, me.registry.RootTagSet().With("kind", "b"), 5)
+	breached, _ = me.evaluateThresholds(true, getTestRunDuration)
+	assert.Empty(t, breached)
+
+	add(errs, me.registry.RootTagSet(), 1)
+	breached, _ = me.evaluateThresholds(true, getTestRunDuration)
+	assert.Equal(t, []string{"errs"}, breached)
+	assert.True(t, ths.Thresholds[0].LastFailed)
+	assert.False(t, ths.Thresholds[1].LastFailed)
+
+	invalid := metrics.NewThresholds([]string{"count < missing.count"})
+	require.NoError(t, invalid.Parse())
+	err = me.InitSubMetricsAndThresholds(lib.Options{
+		Thresholds: map[string]metrics.Thresholds{"errs": invalid},
+	}, false)
+	assert.ErrorContains(t, err, "invalid metric 'missing' referenced in the thresholds of 'errs'")
+}
+
+func TestMetricsEngineUpdateThresholds(t *testing.T) {
+	t.Parallel()
+
+	me := newTestMetricsEngine(t)
+	m1, err := me.registry.NewMetric("m1", metrics.Counter)
+	require.NoError(t, err)
+	m2, err := me.registry.NewMetric("m2", metrics.Trend)
+	require.NoError(t, err)
+
+	ths := metrics.NewThresholds([]string{"count<100"})
+	require.NoError(t, ths.Parse())
+	require.NoError(t, me.InitSubMetricsAndThresholds(lib.Options{
+		Thresholds: map[string]metrics.Thresholds{"m1": ths},
+	}, false))
+	m1.Sink.Add(metrics.Sample{Value: 10})
+	m2.Sink.Add(metrics.Sample{Value: 500})
+
+	breached, _ := me.evaluateThresholds(false, zeroTestRunDuration)
+	assert.Empty(t, breached)
+
+	// Nothing is changed if any of the thresholds is invalid
+	err = me.UpdateThresholds(map[string]metrics.Thresholds{
+		"m1": metrics.NewThresholds([]string{"count<5"}),
+		"m2": metrics.NewThresholds([]string{"count<5"}),
+	})
+	require.ErrorContains(t, err, "unsupported aggregation method count on metric of type trend")
+	assert.Equal(t, "count<100", m1.Thresholds.Thresholds[0].Source)
+	assert.Len(t, me.metricsWithThresholds, 1)
+
+	require.NoError(t, me.UpdateThresholds(map[string]metrics.Thresholds{
+		"m1": metrics.NewThresholds([]string{"count<5"}),
+		"m2": metrics.NewThresholds([]string{"max<100 over 10s"}),
+	}))
+	assert.Len(t, me.metricsWithThresholds, 2)
+	assert.Contains(t, me.thresholdWindows, m2)
+	breached, _ = me.evaluateThresholds(false, zeroTestRunDuration)
+	assert.Equal(t, []string{"m1"}, breached)
+
+	// An empty list removes the thresholds
+	require.NoError(t, me.UpdateThresholds(map[string]metrics.Thresholds{"m1": metrics.NewThresholds(nil)}))
+	assert.Equal(t, []*metrics.Metric{m2}, me.metricsWithThresholds)
+	assert.False(t, m1.Tainted.Valid)
+	breached, _ = me.evaluateThresholds(false, zeroTestRunDuration)
+	assert.Empty(t, breached)
+}
+
+func TestMetricsEngineUpdateThresholdsWindows(t *testing.T) {
+	t.Parallel()
+
+	me := newTestMetricsEngine(t)
+	errs, err := me.registry.NewMetric("errs", metrics.Counter)
+	require.NoError(t, err)
+	total, err := me.registry.NewMetric("total", metrics.Counter)
+	require.NoError(t, err)
+
+	require.NoError(t, me.UpdateThresholds(map[string]metrics.Thresholds{
+		"errs": metrics.NewThresholds([]string{"count < total.count over 10s", "count < 5 over 1m"}),
+	}))
+	require.Contains(t, me.thresholdWindows, errs)
+	require.Contains(t, me.thresholdWindows, total)
+	assert.Equal(t, []time.Duration{10 * time.Second, time.Minute}, me.thresholdWindows[errs].windows)
+	assert.Equal(t, []time.Duration{10 * time.Second, time.Minute}, me.thresholdWindows[total].windows)
+
+	// The windows which aren't used any more are dropped
+	require.NoError(t, me.UpdateThresholds(map[string]metrics.Thresholds{
+		"errs": metrics.NewThresholds([]string{"count < 5 over 10s"}),
+	}))
+	require.Contains(t, me.thresholdWindows, errs)
+	assert.Equal(t, []time.Duration{10 * time.Second}, me.thresholdWindows[errs].windows)
+	assert.NotContains(t, me.thresholdWindows, total)
+
+	require.NoError(t, me.UpdateThresholds(map[string]metrics.Thresholds{"errs": metrics.NewThresholds(nil)}))
+	assert.Empty(t, me.thresholdWindows)
+}
+
+func TestMetricsEngineStartThresholdCalculationsLazily(t *testing.T) {
+	t.Parallel()
+
+	me := newTestMetricsEngine(t)
+	m1, err := me.registry.NewMetric("m1", metrics.Counter)
+	require.NoError(t, err)
+	require.NoError(t, me.InitSubMetricsAndThresholds(lib.Options{}, false))
+
+	// The test has no thresholds, so they are only evaluated after some are added
+	finalize := me.StartThresholdCalculations(nil, func(error) {}, zeroTestRunDuration)
+	require.NotNil(t, finalize)
+	assert.True(t, me.IsEvaluatingThresholds())
+	assert.False(t, me.evaluation.started)
+
+	require.NoError(t, me.UpdateThresholds(map[string]metrics.Thresholds{
+		"m1": metrics.NewThresholds([]string{"count<5"}),
+	}))
+	assert.True(t, me.evaluation.started)
+	m1.Sink.Add(metrics.Sample{Value: 10})
+	assert.Equal(t, []string{"m1"}, finalize())
+
+	// The evaluation isn't started again after it was finalized
+	require.NoError(t, me.UpdateThresholds(map[string]metrics.Thresholds{
+		"m1": metrics.NewThresholds([]string{"count<50"}),
+	}))
+	assert.True(t, me.evaluation.finalized)
+}
+
+func TestMetricsEngineWatchMetric(t *testing.T) {
+	t.Parallel()
+
+	me := newTestMetricsEngine(t)
+	m1, err := me.registry.NewMetric("m1", metrics.Trend)
+	require.NoError(t, err)
+
+	_, err = me.WatchMetric("m2")
+	require.ErrorContains(t, err, "'m2' does not exist")
+	watchMetric, err := me.WatchMetric("m1")
+	require.NoError(t, err)
+	watchSubmetric, err := me.WatchMetric("m1{scenario:api}")
+	require.NoError(t, err)
+
+	ingester := me.CreateIngester()
+	addSample := func(value float64, tags map[string]string) {
+		ingester.AddMetricSamples([]metrics.SampleContainer{metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: m1, Tags: me.registry.RootTagSet().WithTagsFromMap(tags)},
+			Value:      value,
+		}})
+		ingester.flushMetrics()
+	}
+	addSample(10, nil)
+	addSample(20, map[string]string{"scenario": "api"})
+
+	sink, elapsed := watchMetric()
+	assert.Equal(t, uint64(2), sink.(*metrics.TrendSink).Count()) //nolint:forcetypeassert
+	assert.Positive(t, elapsed)
+	sink, _ = watchSubmetric()
+	assert.Equal(t, 20.0, sink.(*metrics.TrendSink).Max()) //nolint:forcetypeassert
+
+	// Only the samples since the previous call are returned
+	addSample(30, map[string]string{"scenario": "api"})
+	sink, _ = watchMetric()
+	assert.Equal(t, 30.0, sink.(*metrics.TrendSink).Min()) //nolint:forcetypeassert
+	sink, _ = watchMetric()
+	assert.True(t, sink.IsEmpty())
+	assert.Equal(t, uint64(3), m1.Sink.(*metrics.TrendSink).Count()) //nolint:forcetypeassert
+}
+
+func TestMetricsEngineTrafficMixSubmetrics(t *testing.T) {
+	t.Parallel()
+
+	me := newTestMetricsEngine(t)
+	iterations, err := me.registry.NewMetric(metrics.IterationsName, metrics.Counter)
+	require.NoError(t, err)
+
+	mix := executor.NewConstantVUsConfig("mix")
+	mix.Exec = executor.ScenarioExec{Weights: map[string]float64{"browse": 70, "search": 30}}
+	require.NoError(t, me.InitSubMetricsAndThresholds(lib.Options{
+		Scenarios:  lib.ScenarioConfigs{"mix": mix, "other": executor.NewConstantVUsConfig("other")},
+		SystemTags: metrics.ToSystemTagSet([]string{"exec"}),
+	}, false))
+	require.Len(t, iterations.Submetrics, 2)
+
+	ingester := me.CreateIngester()
+	ingester.AddMetricSamples([]metrics.SampleContainer{metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: iterations,
+			Tags:   me.registry.RootTagSet().WithTagsFromMap(map[string]string{"exec": "browse"}),
+		},
+		Value: 1,
+	}})
+	ingester.flushMetrics()
+
+	// Only the functions with iterations are shown in the end-of-test summary
+	assert.Contains(t, me.ObservedMetrics, "iterations{exec:browse}")
+	assert.NotContains(t, me.ObservedMetrics, "iterations{exec:search}")
+}
+
 func newTestMetricsEngine(t *testing.T) *MetricsEngine {
 	m, err := NewMetricsEngine(metrics.NewRegistry(), testutils.NewLogger(t))
 	require.NoError(t, err)
@@ -68,6 +68,26 @@ func (oi *OutputIngester) flushMetrics() {
 	oi.metricsEngine.MetricsLock.Lock()
 	defer oi.metricsEngine.MetricsLock.Unlock()
 
+	oi.ingest(sampleContainers)
+}
+
+// GetSnapshots adds all of the buffered samples to the metrics engine and
+// returns the snapshots of its metrics, like MetricsEngine.GetSnapshots().
+// The given function is called after the samples are added, while no more of
+// them can be, so whatever it takes from the test run, e.g. its progress, is
+// consistent with the snapshots.
+func (oi *OutputIngester) GetSnapshots(consistentWith func()) ([]MetricSnapshot, error) {
+	oi.metricsEngine.MetricsLock.Lock()
+	defer oi.metricsEngine.MetricsLock.Unlock()
+
+	oi.ingest(oi.GetBufferedSamples())
+	consistentWith()
+	return oi.metricsEngine.getSnapshotsLocked(false)
+}
+
+// ingest adds the samples to the sinks of their metrics and sub-metrics. It
+// has to be called with the MetricsLock of the engine.
+func (oi *OutputIngester) ingest(sampleContainers []metrics.SampleContainer) {
 	// TODO: split metric samples in buckets with a *metrics.Metric key; this will
 	// allow us to have a per-bucket lock, instead of one global one, and it
 	// will allow us to split apart the metric Name and Type from its Sink and
@@ -85,9 +105,9 @@ func (oi *OutputIngester) flushMetrics() {
 		}
 
 		for _, sample := range samples {
-			m := sample.Metric               // this should have come from the Registry, no need to look it up
-			oi.metricsEngine.markObserved(m) // mark it as observed so it shows in the end-of-test summary
-			m.Sink.Add(sample)               // finally, add its value to its own sink
+			m := sample.Metric                    // this should have come from the Registry, no need to look it up
+			oi.metricsEngine.markObserved(m)      // mark it as observed so it shows in the end-of-test summary
+			oi.metricsEngine.addSample(m, sample) // finally, add its value to its own sink
 
 			// and also to the same for any submetrics that match the metric sample
 			for _, sm := range m.Submetrics {
@@ -95,7 +115,7 @@ func (oi *OutputIngester) flushMetrics() {
 					continue
 				}
 				oi.metricsEngine.markObserved(sm.Metric)
-				sm.Metric.Sink.Add(sample)
+				oi.metricsEngine.addSample(sm.Metric, sample)
 			}
 
 			oi.cardinality.Add(sample.TimeSeries)
@@ -0,0 +1,148 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"go.k6.io/k6/metrics"
+)
+
+// MetricSnapshot is the serialized state of the sink of a single metric or
+// sub-metric. Snapshots allow the metrics from multiple k6 instances, e.g. the
+// agents of a distributed test, to be aggregated by a single MetricsEngine.
+type MetricSnapshot struct {
+	Name     string
+	Type     metrics.MetricType
+	Contains metrics.ValueType
+	Data     []byte
+}
+
+// DrainSnapshots returns snapshots of all observed metrics that have any new
+// data and resets their sinks, so the same data won't be returned again.
+func (me *MetricsEngine) DrainSnapshots() ([]MetricSnapshot, error) {
+	return me.getSnapshots(true)
+}
+
+// GetSnapshots returns snapshots of all observed metrics that have any data,
+// without resetting their sinks, e.g. for the checkpoints of a test.
+func (me *MetricsEngine) GetSnapshots() ([]MetricSnapshot, error) {
+	return me.getSnapshots(false)
+}
+
+func (me *MetricsEngine) getSnapshots(reset bool) ([]MetricSnapshot, error) {
+	me.MetricsLock.Lock()
+	defer me.MetricsLock.Unlock()
+
+	return me.getSnapshotsLocked(reset)
+}
+
+// getSnapshotsLocked is getSnapshots() for callers that already hold the
+// MetricsLock.
+func (me *MetricsEngine) getSnapshotsLocked(reset bool) ([]MetricSnapshot, error) {
+	snapshots := make([]MetricSnapshot, 0, len(me.ObservedMetrics))
+	for _, m := range me.ObservedMetrics {
+		if m.Sink.IsEmpty() {
+			continue
+		}
+		sink, err := asMergeableSink(m)
+		if err != nil {
+			return nil, err
+		}
+		snapshot := sink.Snapshot
+		if reset {
+			snapshot = sink.Drain
+		}
+		data, err := snapshot()
+		if err != nil {
+			return nil, fmt.Errorf("couldn't serialize the sink of metric '%s': %w", m.Name, err)
+		}
+		snapshots = append(snapshots, MetricSnapshot{
+			Name:     m.Name,
+			Type:     m.Type,
+			Contains: m.Contains,
+			Data:     data,
+		})
+	}
+	return snapshots, nil
+}
+
+// MergeSnapshots merges the given snapshots into the sinks of the respective
+// metrics and marks them as observed. Metrics that were not registered
+// beforehand, e.g. ones that only some of the k6 instances created, are
+// registered with the type from the snapshot. The snapshots are considered to
+// be recent data, so they are in the time windows of the thresholds too.
+func (me *MetricsEngine) MergeSnapshots(snapshots []MetricSnapshot) error {
+	return me.mergeSnapshots(snapshots, true)
+}
+
+// RestoreSnapshots is like MergeSnapshots, but for the metrics of a previous
+// run of the test, e.g. the one it was resumed from. They are only added to the
+// metrics for the whole test run, the time windows of the thresholds start
+// empty, since none of that data is recent.
+func (me *MetricsEngine) RestoreSnapshots(snapshots []MetricSnapshot) error {
+	return me.mergeSnapshots(snapshots, false)
+}
+
+func (me *MetricsEngine) mergeSnapshots(snapshots []MetricSnapshot, intoWindows bool) error {
+	me.MetricsLock.Lock()
+	defer me.MetricsLock.Unlock()
+
+	for _, snapshot := range snapshots {
+		m, err := me.getSnapshotMetric(snapshot)
+		if err != nil {
+			return err
+		}
+		if m.Type != snapshot.Type {
+			return fmt.Errorf("metric '%s' has type %s, but its snapshot is of type %s", m.Name, m.Type, snapshot.Type)
+		}
+		sink, err := asMergeableSink(m)
+		if err != nil {
+			return err
+		}
+		if err := sink.Merge(snapshot.Data); err != nil {
+			return fmt.Errorf("couldn't merge the snapshot of metric '%s': %w", m.Name, err)
+		}
+		if tw, ok := me.thresholdWindows[m]; ok && intoWindows {
+			if err := tw.current.Merge(snapshot.Data); err != nil {
+				return fmt.Errorf("couldn't merge the snapshot of metric '%s': %w", m.Name, err)
+			}
+		}
+		me.markObserved(m)
+		if m.Sub != nil {
+			me.markObserved(m.Sub.Parent)
+		}
+	}
+	return nil
+}
+
+// asMergeableSink returns the sink of the given metric if it can be
+// serialized and merged. All of the built-in sinks can, but a metric might have a custom
+// one that only implements metrics.Sink.
+func asMergeableSink(m *metrics.Metric) (metrics.MergeableSink, error) {
+	sink, ok := m.Sink.(metrics.MergeableSink)
+	if !ok {
+		return nil, fmt.Errorf("the sink of metric '%s' doesn't support snapshots, it's a %T", m.Name, m.Sink)
+	}
+	return sink, nil
+}
+
+func (me *MetricsEngine) getSnapshotMetric(snapshot MetricSnapshot) (*metrics.Metric, error) {
+	parentName, submetricDefinition, isSubmetric := strings.Cut(snapshot.Name, "{")
+	parent := me.registry.Get(parentName)
+	if parent == nil {
+		var err error
+		parent, err = me.registry.NewMetric(parentName, snapshot.Type, snapshot.Contains)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !isSubmetric {
+		return parent, nil
+	}
+
+	sm, err := parent.AddSubmetric(strings.TrimSuffix(submetricDefinition, "}"))
+	if err != nil {
+		return nil, err
+	}
+	return sm.Metric, nil
+}
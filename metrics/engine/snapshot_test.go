@@ -0,0 +1,195 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+)
+
+func TestMetricsEngineSnapshots(t *testing.T) {
+	t.Parallel()
+
+	options := lib.Options{
+		Thresholds: map[string]metrics.Thresholds{
+			"my_trend{tag:a}": {Thresholds: []*metrics.Threshold{}},
+		},
+	}
+
+	// Each of the instances has its own registry, like separate k6 processes
+	instances := make([]*MetricsEngine, 2)
+	for i := range instances {
+		instances[i] = newTestMetricsEngine(t)
+		_, err := instances[i].registry.NewMetric("my_trend", metrics.Trend, metrics.Time)
+		require.NoError(t, err)
+		require.NoError(t, instances[i].InitSubMetricsAndThresholds(options, false))
+	}
+	// This metric is only known to the second instance
+	onlySecond, err := instances[1].registry.NewMetric("only_second", metrics.Counter)
+	require.NoError(t, err)
+
+	for i, me := range instances {
+		ingester := me.CreateIngester()
+		ingester.AddMetricSamples([]metrics.SampleContainer{
+			metrics.Sample{
+				TimeSeries: metrics.TimeSeries{
+					Metric: me.registry.Get("my_trend"),
+					Tags:   me.registry.RootTagSet().With("tag", "a"),
+				},
+				Time:  time.Now(),
+				Value: float64(i + 1),
+			},
+			metrics.Sample{
+				TimeSeries: metrics.TimeSeries{
+					Metric: me.registry.Get("my_trend"),
+					Tags:   me.registry.RootTagSet().With("tag", "b"),
+				},
+				Time:  time.Now(),
+				Value: 10,
+			},
+		})
+		ingester.flushMetrics()
+	}
+	instances[1].ObservedMetrics[onlySecond.Name] = onlySecond
+	onlySecond.Sink.Add(metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: onlySecond}, Time: time.Now(), Value: 5})
+
+	aggregator := newTestMetricsEngine(t)
+	_, err = aggregator.registry.NewMetric("my_trend", metrics.Trend, metrics.Time)
+	require.NoError(t, err)
+	require.NoError(t, aggregator.InitSubMetricsAndThresholds(options, false))
+
+	for _, me := range instances {
+		snapshots, err := me.DrainSnapshots()
+		require.NoError(t, err)
+		require.NoError(t, aggregator.MergeSnapshots(snapshots))
+
+		// The sinks were drained, so there is nothing new to send
+		snapshots, err = me.DrainSnapshots()
+		require.NoError(t, err)
+		assert.Empty(t, snapshots)
+	}
+
+	require.Len(t, aggregator.ObservedMetrics, 3)
+	trend, ok := aggregator.ObservedMetrics["my_trend"].Sink.(*metrics.TrendSink)
+	require.True(t, ok)
+	assert.Equal(t, uint64(4), trend.Count())
+	assert.Equal(t, 23.0, trend.Total())
+
+	subTrend, ok := aggregator.ObservedMetrics["my_trend{tag:a}"].Sink.(*metrics.TrendSink)
+	require.True(t, ok)
+	assert.Equal(t, uint64(2), subTrend.Count())
+	assert.Equal(t, 3.0, subTrend.Total())
+
+	counter := aggregator.registry.Get("only_second")
+	require.NotNil(t, counter)
+	assert.Equal(t, metrics.Counter, counter.Type)
+	assert.Equal(t, 5.0, counter.Sink.(*metrics.CounterSink).Value) //nolint:forcetypeassert
+
+	err = aggregator.MergeSnapshots([]MetricSnapshot{{Name: "only_second", Type: metrics.Rate}})
+	assert.ErrorContains(t, err, "metric 'only_second' has type counter, but its snapshot is of type rate")
+}
+
+func TestMetricsEngineGetSnapshots(t *testing.T) {
+	t.Parallel()
+
+	me := newTestMetricsEngine(t)
+	counter, err := me.registry.NewMetric("my_counter", metrics.Counter)
+	require.NoError(t, err)
+	me.ObservedMetrics[counter.Name] = counter
+	counter.Sink.Add(metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: counter}, Time: time.Now(), Value: 5})
+
+	// The sinks are left as they were, so the same data is returned again
+	for i := 0; i < 2; i++ {
+		snapshots, err := me.GetSnapshots()
+		require.NoError(t, err)
+		require.Len(t, snapshots, 1)
+		assert.Equal(t, "my_counter", snapshots[0].Name)
+		assert.Equal(t, 5.0, counter.Sink.(*metrics.CounterSink).Value) //nolint:forcetypeassert
+	}
+
+	resumed := newTestMetricsEngine(t)
+	snapshots, err := me.GetSnapshots()
+	require.NoError(t, err)
+	require.NoError(t, resumed.MergeSnapshots(snapshots))
+	assert.Equal(t, 5.0, resumed.registry.Get("my_counter").Sink.(*metrics.CounterSink).Value) //nolint:forcetypeassert
+}
+
+// customSink only implements metrics.Sink, like a sink from an extension
+type customSink struct {
+	metrics.Sink
+}
+
+func TestMetricsEngineSnapshotsCustomSink(t *testing.T) {
+	t.Parallel()
+
+	me := newTestMetricsEngine(t)
+	m, err := me.registry.NewMetric("custom", metrics.Counter)
+	require.NoError(t, err)
+	m.Sink = customSink{Sink: &metrics.CounterSink{}}
+	m.Sink.Add(metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: m}, Time: time.Now(), Value: 1})
+	me.ObservedMetrics[m.Name] = m
+
+	_, err = me.GetSnapshots()
+	assert.ErrorContains(t, err, "doesn't support snapshots")
+	assert.ErrorContains(t, me.MergeSnapshots([]MetricSnapshot{{Name: "custom", Type: metrics.Counter}}),
+		"doesn't support snapshots")
+}
+
+func TestMetricsEngineRestoreSnapshotsWindowedThresholds(t *testing.T) {
+	t.Parallel()
+
+	newEngine := func() (*MetricsEngine, *metrics.Metric, *OutputIngester) {
+		me := newTestMetricsEngine(t)
+		m, err := me.registry.NewMetric("m1", metrics.Trend)
+		require.NoError(t, err)
+		ths := metrics.NewThresholds([]string{"max<100 over 10s", "max<1000"})
+		require.NoError(t, ths.Parse())
+		require.NoError(t, me.InitSubMetricsAndThresholds(lib.Options{
+			Thresholds: map[string]metrics.Thresholds{"m1": ths},
+		}, false))
+		return me, m, me.CreateIngester()
+	}
+	addSample := func(ingester *OutputIngester, me *MetricsEngine, m *metrics.Metric, value float64) {
+		ingester.AddMetricSamples([]metrics.SampleContainer{metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: m, Tags: me.registry.RootTagSet()},
+			Time:       time.Now(),
+			Value:      value,
+		}})
+	}
+
+	// The spike is in the run that was checkpointed
+	previous, m, ingester := newEngine()
+	addSample(ingester, previous, m, 500)
+	var taken bool
+	snapshots, err := ingester.GetSnapshots(func() { taken = true })
+	require.NoError(t, err)
+	assert.True(t, taken)
+	require.Len(t, snapshots, 1)
+
+	resumed, m, ingester := newEngine()
+	require.NoError(t, resumed.RestoreSnapshots(snapshots))
+	addSample(ingester, resumed, m, 10)
+	ingester.flushMetrics()
+
+	// The windowed threshold only has the data since the resume, the other
+	// one has the data of the whole test run
+	getTestRunDuration := func() time.Duration { return 2 * time.Second }
+	breached, _ := resumed.evaluateThresholds(true, getTestRunDuration)
+	assert.Empty(t, breached)
+	assert.Equal(t, 500.0, m.Sink.(*metrics.TrendSink).Max()) //nolint:forcetypeassert
+
+	addSample(ingester, resumed, m, 5000)
+	ingester.flushMetrics()
+	breached, _ = resumed.evaluateThresholds(true, getTestRunDuration)
+	assert.Equal(t, []string{"m1"}, breached)
+
+	// Merging the snapshots of other instances adds them to the windows
+	merged, m, _ := newEngine()
+	require.NoError(t, merged.MergeSnapshots(snapshots))
+	breached, _ = merged.evaluateThresholds(true, getTestRunDuration)
+	assert.Equal(t, []string{"m1"}, breached)
+	assert.Equal(t, 500.0, m.Sink.(*metrics.TrendSink).Max()) //nolint:forcetypeassert
+}
@@ -0,0 +1,94 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"go.k6.io/k6/metrics"
+)
+
+// thresholdWindows keeps the recent data of a metric with time-windowed
+// thresholds, so they can be evaluated over rolling windows instead of over
+// the whole test run.
+//
+// All of the samples since the last evaluation are added to the current sink,
+// which is drained into a snapshot at every evaluation. The sink for any
+// window is then aggregated from the snapshots that were taken within it, so
+// the windows have the granularity of the thresholds evaluation rate.
+type thresholdWindows struct {
+	newSink func() metrics.MergeableSink
+	windows []time.Duration // sorted in ascending order
+
+	current   metrics.MergeableSink
+	snapshots []windowSnapshot
+}
+
+type windowSnapshot struct {
+	// taken is the test run duration at the time the snapshot was taken
+	taken time.Duration
+	data  []byte
+}
+
+func newThresholdWindows(newSink func() metrics.MergeableSink, windows []time.Duration) *thresholdWindows {
+	return &thresholdWindows{
+		newSink: newSink,
+		windows: windows,
+		current: newSink(),
+	}
+}
+
+// sinks returns a sink with the data of each window that had any samples,
+// considering that the given test run duration is the end of all windows.
+func (tw *thresholdWindows) sinks(now time.Duration) (map[time.Duration]metrics.Sink, error) {
+	if !tw.current.IsEmpty() {
+		data, err := tw.current.Drain()
+		if err != nil {
+			return nil, err
+		}
+		tw.snapshots = append(tw.snapshots, windowSnapshot{taken: now, data: data})
+	}
+
+	// Forget the snapshots that are outside of even the longest window
+	longest := tw.windows[len(tw.windows)-1]
+	expired := 0
+	for expired < len(tw.snapshots) && tw.snapshots[expired].taken <= now-longest {
+		expired++
+	}
+	tw.snapshots = tw.snapshots[expired:]
+
+	sinks := make(map[time.Duration]metrics.Sink, len(tw.windows))
+	for _, window := range tw.windows {
+		sink := tw.newSink()
+		for _, snapshot := range tw.snapshots {
+			if snapshot.taken <= now-window {
+				continue
+			}
+			if err := sink.Merge(snapshot.data); err != nil {
+				return nil, fmt.Errorf("couldn't aggregate the %s window: %w", window, err)
+			}
+		}
+		if !sink.IsEmpty() {
+			sinks[window] = sink
+		}
+	}
+	return sinks, nil
+}
+
+// mergeWindows returns the distinct windows from both of the given sorted
+// slices, in ascending order.
+func mergeWindows(a, b []time.Duration) []time.Duration {
+	merged := make([]time.Duration, 0, len(a)+len(b))
+	for len(a) > 0 || len(b) > 0 {
+		var next time.Duration
+		switch {
+		case len(b) == 0 || (len(a) > 0 && a[0] < b[0]):
+			next, a = a[0], a[1:]
+		case len(a) == 0 || b[0] < a[0]:
+			next, b = b[0], b[1:]
+		default: // the same window in both
+			next, a, b = a[0], a[1:], b[1:]
+		}
+		merged = append(merged, next)
+	}
+	return merged
+}
@@ -13,6 +13,8 @@ type Registry struct {
 	metrics map[string]*Metric
 	l       sync.RWMutex
 
+	newTrendSink func() *TrendSink
+
 	rootTagSet *atlas.Node
 }
 
@@ -96,7 +98,7 @@ func (r *Registry) newMetric(name string, mt MetricType, vt ...ValueType) *Metri
 		valueType = vt[0]
 	}
 
-	sink := NewSink(mt)
+	sink := r.NewSink(mt)
 	return &Metric{
 		registry: r,
 		Name:     name,
@@ -106,6 +108,34 @@ func (r *Registry) newMetric(name string, mt MetricType, vt ...ValueType) *Metri
 	}
 }
 
+// NewSink creates a new sink for metrics of the given type, the same way as
+// the sinks of the registered metrics are created.
+func (r *Registry) NewSink(mt MetricType) Sink {
+	if mt == Trend && r.newTrendSink != nil {
+		return r.newTrendSink()
+	}
+	return NewSink(mt)
+}
+
+// SetTrendSinkFactory sets the function that creates the sinks of all trend
+// metrics and sub-metrics. The sinks of the already registered ones are
+// replaced, so it should be called before any samples are added to them.
+func (r *Registry) SetTrendSinkFactory(newTrendSink func() *TrendSink) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	r.newTrendSink = newTrendSink
+	for _, m := range r.metrics {
+		if m.Type != Trend {
+			continue
+		}
+		m.Sink = newTrendSink()
+		for _, sm := range m.Submetrics {
+			sm.Metric.Sink = newTrendSink()
+		}
+	}
+}
+
 // Get returns the Metric with the given name. If that metric doesn't exist,
 // Get() will return a nil value.
 func (r *Registry) Get(name string) *Metric {
@@ -101,3 +101,27 @@ func TestRegistryAll(t *testing.T) {
 		assert.ElementsMatch(t, exp, names(metrics))
 	})
 }
+
+func TestRegistrySetTrendSinkFactory(t *testing.T) {
+	t.Parallel()
+	r := NewRegistry()
+
+	before, err := r.NewMetric("before", Trend)
+	require.NoError(t, err)
+	sub, err := before.AddSubmetric("tag:value")
+	require.NoError(t, err)
+	counter, err := r.NewMetric("counter", Counter)
+	require.NoError(t, err)
+
+	r.SetTrendSinkFactory(func() *TrendSink { return NewHistogramTrendSink(0.05) })
+
+	after, err := r.NewMetric("after", Trend)
+	require.NoError(t, err)
+	for _, m := range []*Metric{before, sub.Metric, after} {
+		sink, ok := m.Sink.(*TrendSink)
+		require.True(t, ok, m.Name)
+		require.NotNil(t, sink.histogram, m.Name)
+		assert.Equal(t, 0.05, sink.histogram.maxError, m.Name)
+	}
+	assert.IsType(t, &CounterSink{}, counter.Sink)
+}
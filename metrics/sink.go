@@ -1,6 +1,8 @@
 package metrics
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
 	"math"
 	"sort"
@@ -8,10 +10,10 @@ import (
 )
 
 var (
-	_ Sink = &CounterSink{}
-	_ Sink = &GaugeSink{}
-	_ Sink = NewTrendSink()
-	_ Sink = &RateSink{}
+	_ MergeableSink = &CounterSink{}
+	_ MergeableSink = &GaugeSink{}
+	_ MergeableSink = NewTrendSink()
+	_ MergeableSink = &RateSink{}
 )
 
 // Sink is a sample sink which will accumulate data in specific way
@@ -21,6 +23,39 @@ type Sink interface {
 	IsEmpty() bool                             // Check if the Sink is empty.
 }
 
+// MergeableSink is a Sink whose state can be moved to another sink of the
+// same type, e.g. to aggregate the metrics of multiple k6 instances. All of
+// the built-in sinks are mergeable.
+type MergeableSink interface {
+	Sink
+
+	// Snapshot serializes the current state of the sink, without changing
+	// it, so that the state can be merged into another sink of the same type.
+	Snapshot() ([]byte, error)
+	// Drain is like Snapshot, but it also resets the sink.
+	Drain() ([]byte, error)
+	// Merge adds the state from a serialized Snapshot() or Drain() result to
+	// the sink.
+	Merge(data []byte) error
+}
+
+// encodeSinkState serializes the given fixed-size data for MergeableSink.Snapshot().
+func encodeSinkState(data interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, binary.LittleEndian, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeSinkState deserializes the fixed-size data for MergeableSink.Merge().
+func decodeSinkState(from []byte, data interface{}) error {
+	if binary.Size(data) != len(from) {
+		return fmt.Errorf("invalid sink state with length %d, expected %d", len(from), binary.Size(data))
+	}
+	return binary.Read(bytes.NewReader(from), binary.LittleEndian, data)
+}
+
 // NewSink creates the related Sink for
 // the provided MetricType.
 func NewSink(mt MetricType) Sink {
@@ -68,6 +103,42 @@ func (c *CounterSink) Format(t time.Duration) map[string]float64 {
 	}
 }
 
+type counterSinkState struct {
+	Value float64
+	First int64
+}
+
+// Drain serializes the counter value and resets it.
+func (c *CounterSink) Drain() ([]byte, error) {
+	data, err := c.Snapshot()
+	*c = CounterSink{}
+	return data, err
+}
+
+// Snapshot serializes the counter value.
+func (c *CounterSink) Snapshot() ([]byte, error) {
+	state := counterSinkState{Value: c.Value}
+	if !c.First.IsZero() {
+		state.First = c.First.UnixNano()
+	}
+	return encodeSinkState(state)
+}
+
+// Merge adds the drained counter value to the sink.
+func (c *CounterSink) Merge(data []byte) error {
+	var state counterSinkState
+	if err := decodeSinkState(data, &state); err != nil {
+		return err
+	}
+	c.Value += state.Value
+	if state.First != 0 {
+		if first := time.Unix(0, state.First); c.First.IsZero() || first.Before(c.First) {
+			c.First = first
+		}
+	}
+	return nil
+}
+
 // GaugeSink is a sink represents a Gauge
 type GaugeSink struct {
 	Value    float64
@@ -95,16 +166,68 @@ func (g *GaugeSink) Format(_ time.Duration) map[string]float64 {
 	return map[string]float64{"value": g.Value}
 }
 
-// NewTrendSink makes a Trend sink with the OpenHistogram circllhist histogram.
+type gaugeSinkState struct {
+	Value, Max, Min float64
+	Set             bool
+}
+
+// Drain serializes the gauge values and resets them.
+func (g *GaugeSink) Drain() ([]byte, error) {
+	data, err := g.Snapshot()
+	*g = GaugeSink{}
+	return data, err
+}
+
+// Snapshot serializes the gauge values.
+func (g *GaugeSink) Snapshot() ([]byte, error) {
+	return encodeSinkState(gaugeSinkState{Value: g.Value, Max: g.Max, Min: g.Min, Set: g.minSet})
+}
+
+// Merge combines the drained gauge values with the ones in the sink. The
+// merged value becomes the current one, since it's the most recent. The state
+// of an empty sink doesn't change anything.
+func (g *GaugeSink) Merge(data []byte) error {
+	var state gaugeSinkState
+	if err := decodeSinkState(data, &state); err != nil {
+		return err
+	}
+	if !state.Set {
+		return nil
+	}
+	g.Value = state.Value
+	if !g.minSet || state.Max > g.Max {
+		g.Max = state.Max
+	}
+	if !g.minSet || state.Min < g.Min {
+		g.Min = state.Min
+	}
+	g.minSet = true
+	return nil
+}
+
+// NewTrendSink makes a Trend sink that stores all of the values, so it can
+// calculate the exact percentiles from them.
 func NewTrendSink() *TrendSink {
 	return &TrendSink{}
 }
 
+// NewHistogramTrendSink makes a Trend sink that aggregates the values in a
+// streaming histogram, instead of storing all of them, so it uses a bounded
+// amount of memory. The percentiles it calculates are within maxError of the
+// actual values, e.g. 0.01 means that p(95) can be off by up to 1%. The min,
+// max, avg and total are always exact.
+func NewHistogramTrendSink(maxError float64) *TrendSink {
+	return &TrendSink{histogram: newTrendHistogram(maxError)}
+}
+
 // TrendSink is a sink for a Trend
 type TrendSink struct {
 	values []float64
 	sorted bool
 
+	// if set, the values are aggregated in it instead of the slice above
+	histogram *trendHistogram
+
 	count    uint64
 	min, max float64
 	sum      float64
@@ -115,21 +238,157 @@ func (t *TrendSink) IsEmpty() bool { return t.count == 0 }
 
 // Add a single sample into the trend
 func (t *TrendSink) Add(s Sample) {
+	t.addValue(s.Value)
+}
+
+func (t *TrendSink) addValue(v float64) {
+	if t.histogram != nil {
+		t.histogram.add(v, 1)
+	} else {
+		t.values = append(t.values, v)
+		t.sorted = false
+	}
+	t.addStats(v, v, v, 1)
+}
+
+func (t *TrendSink) addStats(minValue, maxValue, sum float64, count uint64) {
 	if t.count == 0 {
-		t.max, t.min = s.Value, s.Value
+		t.max, t.min = maxValue, minValue
 	} else {
-		if s.Value > t.max {
-			t.max = s.Value
+		if maxValue > t.max {
+			t.max = maxValue
 		}
-		if s.Value < t.min {
-			t.min = s.Value
+		if minValue < t.min {
+			t.min = minValue
 		}
 	}
 
-	t.values = append(t.values, s.Value)
-	t.sorted = false
-	t.count++
-	t.sum += s.Value
+	t.count += count
+	t.sum += sum
+}
+
+const (
+	trendSinkStateValues byte = iota
+	trendSinkStateHistogram
+)
+
+type trendHistogramState struct {
+	MaxError      float64
+	Min, Max, Sum float64
+	Count, Zeros  uint64
+
+	PositiveBuckets, NegativeBuckets uint32
+}
+
+type trendHistogramBucket struct {
+	Index int32
+	Count uint64
+}
+
+// Drain serializes all of the recorded values, or the histogram, and resets
+// the sink.
+func (t *TrendSink) Drain() ([]byte, error) {
+	data, err := t.Snapshot()
+	if t.histogram == nil {
+		*t = TrendSink{}
+	} else {
+		*t = *NewHistogramTrendSink(t.histogram.maxError)
+	}
+	return data, err
+}
+
+// Snapshot serializes all of the recorded values, or the histogram.
+func (t *TrendSink) Snapshot() ([]byte, error) {
+	if t.histogram == nil {
+		data, err := encodeSinkState(t.values)
+		return append([]byte{trendSinkStateValues}, data...), err
+	}
+
+	h := t.histogram
+	state := trendHistogramState{
+		MaxError: h.maxError,
+		Min:      t.min, Max: t.max, Sum: t.sum,
+		Count: t.count, Zeros: h.zeros,
+		PositiveBuckets: uint32(len(h.positive)), //nolint:gosec
+		NegativeBuckets: uint32(len(h.negative)), //nolint:gosec
+	}
+	buckets := make([]trendHistogramBucket, 0, len(h.positive)+len(h.negative))
+	for _, i := range sortedBucketIndexes(h.positive, false) {
+		buckets = append(buckets, trendHistogramBucket{Index: i, Count: h.positive[i]})
+	}
+	for _, i := range sortedBucketIndexes(h.negative, false) {
+		buckets = append(buckets, trendHistogramBucket{Index: i, Count: h.negative[i]})
+	}
+
+	stateData, err := encodeSinkState(state)
+	if err != nil {
+		return nil, err
+	}
+	bucketsData, err := encodeSinkState(buckets)
+	if err != nil {
+		return nil, err
+	}
+	data := append([]byte{trendSinkStateHistogram}, stateData...)
+	return append(data, bucketsData...), nil
+}
+
+// Merge adds all of the drained values, or the drained histogram, to the
+// sink. A drained histogram can only be merged into a histogram sink with the
+// same maximum error.
+func (t *TrendSink) Merge(data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("invalid empty trend sink state")
+	}
+	kind, data := data[0], data[1:]
+	switch kind {
+	case trendSinkStateValues:
+		if len(data)%8 != 0 {
+			return fmt.Errorf("invalid trend sink state with length %d", len(data))
+		}
+		values := make([]float64, len(data)/8)
+		if err := decodeSinkState(data, values); err != nil {
+			return err
+		}
+		for _, v := range values {
+			t.addValue(v)
+		}
+		return nil
+	case trendSinkStateHistogram:
+		return t.mergeHistogram(data)
+	default:
+		return fmt.Errorf("unknown trend sink state type %d", kind)
+	}
+}
+
+func (t *TrendSink) mergeHistogram(data []byte) error {
+	var state trendHistogramState
+	stateSize := binary.Size(state)
+	if len(data) < stateSize {
+		return fmt.Errorf("invalid trend histogram state with length %d", len(data))
+	}
+	if err := decodeSinkState(data[:stateSize], &state); err != nil {
+		return err
+	}
+	if t.histogram == nil || t.histogram.maxError != state.MaxError {
+		return fmt.Errorf("a trend histogram with a maximum error of %g can only be merged into a similar one", state.MaxError)
+	}
+	buckets := make([]trendHistogramBucket, int(state.PositiveBuckets)+int(state.NegativeBuckets))
+	if err := decodeSinkState(data[stateSize:], buckets); err != nil {
+		return err
+	}
+
+	for i, b := range buckets {
+		if i < int(state.PositiveBuckets) {
+			t.histogram.positive[b.Index] += b.Count
+		} else {
+			t.histogram.negative[b.Index] += b.Count
+		}
+	}
+	t.histogram.zeros += state.Zeros
+	if state.Count > 0 {
+		t.addStats(state.Min, state.Max, state.Sum, state.Count)
+	}
+	return nil
 }
 
 // P calculates the given percentile from sink values.
@@ -138,24 +397,40 @@ func (t *TrendSink) P(pct float64) float64 {
 	case 0:
 		return 0
 	case 1:
-		return t.values[0]
+		return t.min
 	default:
-		if !t.sorted {
-			sort.Float64s(t.values)
-			t.sorted = true
-		}
-
 		// If percentile falls on a value in Values slice, we return that value.
 		// If percentile does not fall on a value in Values slice, we calculate (linear interpolation)
 		// the value that would fall at percentile, given the values above and below that percentile.
 		i := pct * (float64(t.count) - 1.0)
-		j := t.values[int(math.Floor(i))]
-		k := t.values[int(math.Ceil(i))]
+		j := t.valueAt(uint64(math.Floor(i)))
+		k := t.valueAt(uint64(math.Ceil(i)))
 		f := i - math.Floor(i)
 		return j + (k-j)*f
 	}
 }
 
+// valueAt returns the value at the given position of the sorted values.
+func (t *TrendSink) valueAt(rank uint64) float64 {
+	if t.histogram == nil {
+		if !t.sorted {
+			sort.Float64s(t.values)
+			t.sorted = true
+		}
+		return t.values[rank]
+	}
+
+	// The smallest and largest values are always known exactly
+	switch rank {
+	case 0:
+		return t.min
+	case t.count - 1:
+		return t.max
+	default:
+		return math.Min(math.Max(t.histogram.valueAt(rank), t.min), t.max)
+	}
+}
+
 // Min returns the minimum value.
 func (t *TrendSink) Min() float64 {
 	return t.min
@@ -214,6 +489,33 @@ func (r *RateSink) Add(s Sample) {
 	}
 }
 
+type rateSinkState struct {
+	Trues, Total int64
+}
+
+// Drain serializes the rate counters and resets them.
+func (r *RateSink) Drain() ([]byte, error) {
+	data, err := r.Snapshot()
+	*r = RateSink{}
+	return data, err
+}
+
+// Snapshot serializes the rate counters.
+func (r *RateSink) Snapshot() ([]byte, error) {
+	return encodeSinkState(rateSinkState{Trues: r.Trues, Total: r.Total})
+}
+
+// Merge adds the drained rate counters to the sink.
+func (r *RateSink) Merge(data []byte) error {
+	var state rateSinkState
+	if err := decodeSinkState(data, &state); err != nil {
+		return err
+	}
+	r.Trues += state.Trues
+	r.Total += state.Total
+	return nil
+}
+
 // Format rate and return a map
 func (r RateSink) Format(_ time.Duration) map[string]float64 {
 	var rate float64
@@ -236,6 +236,87 @@ func TestTrendSink(t *testing.T) {
 	})
 }
 
+func TestHistogramTrendSink(t *testing.T) {
+	t.Parallel()
+
+	const maxError = 0.01
+
+	t.Run("percentiles", func(t *testing.T) {
+		t.Parallel()
+
+		exact, histogram := NewTrendSink(), NewHistogramTrendSink(maxError)
+		for i := 1; i <= 100000; i++ {
+			// A long-tailed distribution of values between 1 and ~22000
+			v := math.Exp(float64(i%1000)/100) * (1 + float64(i%7)/10)
+			exact.Add(Sample{TimeSeries: TimeSeries{Metric: &Metric{}}, Value: v})
+			histogram.Add(Sample{TimeSeries: TimeSeries{Metric: &Metric{}}, Value: v})
+		}
+
+		assert.Equal(t, exact.Count(), histogram.Count())
+		assert.Equal(t, exact.Min(), histogram.Min())
+		assert.Equal(t, exact.Max(), histogram.Max())
+		assert.InEpsilon(t, exact.Avg(), histogram.Avg(), 1e-9)
+		assert.Empty(t, histogram.values)
+		for _, pct := range []float64{0, 0.01, 0.1, 0.25, 0.5, 0.75, 0.9, 0.95, 0.99, 0.999, 1} {
+			assert.InEpsilon(t, exact.P(pct), histogram.P(pct), maxError, "p(%g)", pct*100)
+		}
+	})
+
+	t.Run("negative and zero", func(t *testing.T) {
+		t.Parallel()
+
+		sink := NewHistogramTrendSink(maxError)
+		for _, v := range []float64{-100, 0, 50, -10, 0, 1000} {
+			sink.Add(Sample{TimeSeries: TimeSeries{Metric: &Metric{}}, Value: v})
+		}
+		assert.Equal(t, -100.0, sink.P(0))
+		assert.InEpsilon(t, -10.0, sink.P(0.2), maxError)
+		assert.Equal(t, 0.0, sink.P(0.4))
+		assert.Equal(t, 0.0, sink.P(0.6))
+		assert.InEpsilon(t, 50.0, sink.P(0.8), maxError)
+		assert.Equal(t, 1000.0, sink.P(1))
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		t.Parallel()
+
+		expected, merged := NewHistogramTrendSink(maxError), NewHistogramTrendSink(maxError)
+		exact, first, second := NewTrendSink(), NewHistogramTrendSink(maxError), NewHistogramTrendSink(maxError)
+		for i := 0; i < 300; i++ {
+			sample := Sample{TimeSeries: TimeSeries{Metric: &Metric{}}, Value: float64(i*i%1013) - 100}
+			expected.Add(sample)
+			[]*TrendSink{exact, first, second}[i%3].Add(sample)
+		}
+
+		for _, sink := range []*TrendSink{exact, first, second} {
+			data, err := sink.Drain()
+			require.NoError(t, err)
+			assert.True(t, sink.IsEmpty())
+			require.NoError(t, merged.Merge(data))
+		}
+		assert.Equal(t, expected.Format(0), merged.Format(0))
+
+		// The drained sinks can still be used and keep their kind
+		first.Add(Sample{TimeSeries: TimeSeries{Metric: &Metric{}}, Value: 1})
+		assert.NotNil(t, first.histogram)
+		assert.Empty(t, first.values)
+	})
+
+	t.Run("merge incompatible", func(t *testing.T) {
+		t.Parallel()
+
+		sink := NewHistogramTrendSink(maxError)
+		sink.Add(Sample{TimeSeries: TimeSeries{Metric: &Metric{}}, Value: 1})
+		data, err := sink.Drain()
+		require.NoError(t, err)
+
+		assert.ErrorContains(t, NewTrendSink().Merge(data), "can only be merged into a similar one")
+		assert.ErrorContains(t, NewHistogramTrendSink(0.05).Merge(data), "can only be merged into a similar one")
+		assert.NoError(t, NewHistogramTrendSink(maxError).Merge(data))
+		assert.Error(t, NewHistogramTrendSink(maxError).Merge(nil))
+	})
+}
+
 func TestRateSink(t *testing.T) {
 	t.Parallel()
 	samples6 := []float64{1.0, 0.0, 1.0, 0.0, 0.0, 1.0}
@@ -275,3 +356,103 @@ func TestRateSink(t *testing.T) {
 		assert.Equal(t, map[string]float64{"rate": 0.5}, sink.Format(0))
 	})
 }
+
+func TestSinkDrainAndMerge(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	samples := []float64{5.0, 0.0, 30.0, 2.0, 10.0, 0.0, 7.0, 1.0}
+	for _, mt := range []MetricType{Counter, Gauge, Trend, Rate} {
+		mt := mt
+		t.Run(mt.String(), func(t *testing.T) {
+			t.Parallel()
+
+			// One sink gets all of the samples, while the rest of them are
+			// split between two sinks that are drained and merged into another.
+			expected := NewSink(mt)
+			first, second, merged := newMergeableSink(t, mt), newMergeableSink(t, mt), newMergeableSink(t, mt)
+			for i, v := range samples {
+				sample := Sample{TimeSeries: TimeSeries{Metric: &Metric{}}, Value: v, Time: now.Add(time.Duration(i))}
+				expected.Add(sample)
+				if i < len(samples)/2 {
+					first.Add(sample)
+				} else {
+					second.Add(sample)
+				}
+			}
+
+			for _, sink := range []MergeableSink{first, second} {
+				data, err := sink.Drain()
+				require.NoError(t, err)
+				assert.True(t, sink.IsEmpty())
+				require.NoError(t, merged.Merge(data))
+			}
+
+			assert.False(t, merged.IsEmpty())
+			assert.Equal(t, expected.Format(time.Second), merged.Format(time.Second))
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		for _, mt := range []MetricType{Counter, Gauge, Trend, Rate} {
+			assert.Error(t, newMergeableSink(t, mt).Merge([]byte{1, 2, 3}), mt.String())
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		for _, mt := range []MetricType{Counter, Gauge, Trend, Rate} {
+			sink, empty := newMergeableSink(t, mt), newMergeableSink(t, mt)
+			sink.Add(Sample{TimeSeries: TimeSeries{Metric: &Metric{}}, Value: 5, Time: now})
+			sink.Add(Sample{TimeSeries: TimeSeries{Metric: &Metric{}}, Value: 3, Time: now})
+			expected := sink.Format(time.Second)
+
+			data, err := empty.Drain()
+			require.NoError(t, err)
+			require.NoError(t, sink.Merge(data), mt.String())
+			assert.Equal(t, expected, sink.Format(time.Second), mt.String())
+		}
+
+		// The min and max of a gauge don't change either
+		gauge := &GaugeSink{}
+		gauge.Add(Sample{TimeSeries: TimeSeries{Metric: &Metric{}}, Value: 5})
+		gauge.Add(Sample{TimeSeries: TimeSeries{Metric: &Metric{}}, Value: 3})
+		data, err := (&GaugeSink{}).Drain()
+		require.NoError(t, err)
+		require.NoError(t, gauge.Merge(data))
+		assert.Equal(t, 3.0, gauge.Min)
+		assert.Equal(t, 5.0, gauge.Max)
+		assert.Equal(t, 3.0, gauge.Value)
+	})
+
+	t.Run("snapshot", func(t *testing.T) {
+		t.Parallel()
+		// A snapshot is the same as the drained state, but the sink keeps it
+		sinks := map[string]MergeableSink{"histogram": NewHistogramTrendSink(0.01)}
+		for _, mt := range []MetricType{Counter, Gauge, Trend, Rate} {
+			sinks[mt.String()] = newMergeableSink(t, mt)
+		}
+		for name, sink := range sinks {
+			for i, v := range samples {
+				sink.Add(Sample{TimeSeries: TimeSeries{Metric: &Metric{}}, Value: v, Time: now.Add(time.Duration(i))})
+			}
+			expected := sink.Format(time.Second)
+
+			snapshot, err := sink.Snapshot()
+			require.NoError(t, err, name)
+			assert.Equal(t, expected, sink.Format(time.Second), name)
+			drained, err := sink.Drain()
+			require.NoError(t, err, name)
+			assert.Equal(t, drained, snapshot, name)
+			assert.True(t, sink.IsEmpty(), name)
+		}
+	})
+}
+
+func newMergeableSink(t *testing.T, mt MetricType) MergeableSink {
+	t.Helper()
+	sink, ok := NewSink(mt).(MergeableSink)
+	require.True(t, ok, mt.String())
+	return sink
+}
@@ -38,6 +38,10 @@ const (
 	TagVU   // non-indexable
 	TagOCSPStatus
 	TagIP
+
+	// Enabled by default, but only set for the scenarios with a traffic mix,
+	// to the function that the iteration runs.
+	TagExec
 )
 
 // DefaultSystemTagSet includes all of the system tags emitted with metrics by default.
@@ -46,7 +50,8 @@ const (
 //nolint:gochecknoglobals
 var DefaultSystemTagSet = SystemTagSet(
 	TagProto | TagSubproto | TagStatus | TagMethod | TagURL | TagName | TagGroup |
-		TagCheck | TagError | TagErrorCode | TagTLSVersion | TagScenario | TagService | TagExpectedResponse)
+		TagCheck | TagError | TagErrorCode | TagTLSVersion | TagScenario | TagService | TagExpectedResponse |
+		TagExec)
 
 // NonIndexableSystemTags are high cardinality system tags (i.e. metadata).
 //
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -24,6 +25,9 @@ type Threshold struct {
 	// AbortGracePeriod is a the minimum amount of time a test should be running before a failing
 	// this threshold will abort the test
 	AbortGracePeriod types.NullDuration
+	// Window is the optional duration of the time window over which the threshold is
+	// evaluated, as an alternative to an "over" clause in the Source
+	Window types.NullDuration
 	// parsed is the threshold expression parsed from the Source
 	parsed *thresholdExpression
 }
@@ -37,12 +41,67 @@ func newThreshold(src string, abortOnFail bool, gracePeriod types.NullDuration)
 	}
 }
 
-func (t *Threshold) runNoTaint(sinks map[string]float64) (bool, error) {
-	// Extract the sink value for the aggregation method used in the threshold
+// window returns the duration of the rolling time window over which the
+// threshold is evaluated, or zero if it applies to the whole test run.
+func (t *Threshold) window() time.Duration {
+	if t.Window.Valid {
+		return t.Window.TimeDuration()
+	}
+	if t.parsed != nil {
+		return t.parsed.Window
+	}
+	return 0
+}
+
+// operands returns the values of both sides of the threshold expression, given
+// the sinked values of the metric and of the metrics it references, or false
+// if any of them is missing.
+func (t *Threshold) operands(sinks map[string]float64, referenced map[string]map[string]float64) (float64, float64, bool) {
+	if t.parsed.Left == nil {
+		lhs, ok := sinks[t.parsed.SinkKey()]
+		return lhs, t.parsed.Value, ok
+	}
+
+	values := thresholdValues{own: sinks, referenced: referenced}
+	lhs, ok := t.parsed.Left.evaluate(values)
+	if !ok {
+		return 0, 0, false
+	}
+	rhs, ok := t.parsed.Right.evaluate(values)
+	return lhs, rhs, ok
+}
+
+// Operator returns the comparison operator of the parsed threshold expression,
+// like "<" or ">=", or an empty string if it hasn't been parsed.
+func (t *Threshold) Operator() string {
+	if t.parsed == nil {
+		return ""
+	}
+	return t.parsed.Operator
+}
+
+// Operands returns the values of both sides of the parsed threshold
+// expression, for the given sink of its metric over the given duration, for
+// instance, the 95th percentile of the sink and 500 for "p(95)<500". It returns
+// false if the sink is empty, or if the expression references other metrics.
+func (t *Threshold) Operands(sink Sink, duration time.Duration) (float64, float64, bool) {
+	if t.parsed == nil || sink.IsEmpty() {
+		return 0, 0, false
+	}
+	ts := Thresholds{Thresholds: []*Threshold{t}}
+	sinked, err := ts.sinkValues(sink, duration)
+	if err != nil {
+		return 0, 0, false
+	}
+	return t.operands(sinked, nil)
+}
+
+func (t *Threshold) runNoTaint(sinks map[string]float64, referenced map[string]map[string]float64) (bool, error) {
+	// Extract the sink values for the aggregation methods used in the threshold
 	// expression. Considering we already validated thresholds before starting
 	// the execution, we assume that a missing sink entry means that no samples
 	// are available yet, and that it's safe to ignore this run.
-	lhs, ok := sinks[t.parsed.SinkKey()]
+	lhs, rhs, ok := t.operands(sinks, referenced)
 	if !ok {
 		return true, nil
 	}
@@ -52,19 +111,19 @@ func (t *Threshold) runNoTaint(sinks map[string]float64) (bool, error) {
 	var passes bool
 	switch t.parsed.Operator {
 	case ">":
-		passes = lhs > t.parsed.Value
+		passes = lhs > rhs
 	case ">=":
-		passes = lhs >= t.parsed.Value
+		passes = lhs >= rhs
 	case "<=":
-		passes = lhs <= t.parsed.Value
+		passes = lhs <= rhs
 	case "<":
-		passes = lhs < t.parsed.Value
+		passes = lhs < rhs
 	case "==", "===":
 		// Considering a sink always maps to float64 values,
 		// strictly equal is equivalent to loosely equal
-		passes = lhs == t.parsed.Value
+		passes = lhs == rhs
 	case "!=":
-		passes = lhs != t.parsed.Value
+		passes = lhs != rhs
 	default:
 		// The parseThresholdExpression function should ensure that no invalid
 		// operator gets through, but let's protect our future selves anyhow.
@@ -79,16 +138,17 @@ func (t *Threshold) runNoTaint(sinks map[string]float64) (bool, error) {
 	return passes, nil
 }
 
-func (t *Threshold) run(sinks map[string]float64) (bool, error) {
-	passes, err := t.runNoTaint(sinks)
+func (t *Threshold) run(sinks map[string]float64, referenced map[string]map[string]float64) (bool, error) {
+	passes, err := t.runNoTaint(sinks, referenced)
 	t.LastFailed = !passes
 	return passes, err
 }
 
 type thresholdConfig struct {
-	Threshold        string             `json:"threshold"`
-	AbortOnFail      bool               `json:"abortOnFail"`
-	AbortGracePeriod types.NullDuration `json:"delayAbortEval"`
+	Threshold        string              `json:"threshold"`
+	AbortOnFail      bool                `json:"abortOnFail"`
+	AbortGracePeriod types.NullDuration  `json:"delayAbortEval"`
+	Window           *types.NullDuration `json:"window,omitempty"`
 }
 
 // used internally for JSON marshalling
@@ -106,7 +166,7 @@ func (tc *thresholdConfig) UnmarshalJSON(data []byte) error {
 
 func (tc thresholdConfig) MarshalJSON() ([]byte, error) {
 	var data interface{} = tc.Threshold
-	if tc.AbortOnFail {
+	if tc.AbortOnFail || tc.Window != nil {
 		data = rawThresholdConfig(tc)
 	}
 
@@ -118,6 +178,22 @@ type Thresholds struct {
 	Thresholds []*Threshold
 	Abort      bool
 	sinked     map[string]float64
+	// windowSinked holds the sinked values of each time window
+	windowSinked map[time.Duration]map[string]float64
+	// referencedSinked and referencedWindowSinked hold the sinked
+	// values of the referenced metrics, indexed by their names
+	referencedSinked       map[string]map[string]float64
+	referencedWindowSinked map[time.Duration]map[string]map[string]float64
+}
+
+// ThresholdSinks holds the sinks of a metric that thresholds are evaluated with.
+type ThresholdSinks struct {
+	// Sink has the data of the whole test run
+	Sink Sink
+	// Windows has the data of the time windows of the thresholds, indexed by
+	// their durations. A window without a sink is considered to have no
+	// samples, so its thresholds pass.
+	Windows map[time.Duration]Sink
 }
 
 // NewThresholds returns Thresholds objects representing the provided source strings
@@ -136,16 +212,24 @@ func newThresholdsWithConfig(configs []thresholdConfig) Thresholds {
 
 	for i, config := range configs {
 		t := newThreshold(config.Threshold, config.AbortOnFail, config.AbortGracePeriod)
+		if config.Window != nil {
+			t.Window = *config.Window
+		}
 		thresholds[i] = t
 	}
 
-	return Thresholds{thresholds, false, sinked}
+	return Thresholds{Thresholds: thresholds, Abort: false, sinked: sinked}
 }
 
 func (ts *Thresholds) runAll(timeSpentInTest time.Duration) (bool, error) {
 	succeeded := true
 	for i, threshold := range ts.Thresholds {
-		b, err := threshold.run(ts.sinked)
+		sinked, referenced := ts.sinked, ts.referencedSinked
+		if window := threshold.window(); window > 0 {
+			// A missing window means that it has no samples yet
+			sinked, referenced = ts.windowSinked[window], ts.referencedWindowSinked[window]
+		}
+		b, err := threshold.run(sinked, referenced)
 		if err != nil {
 			return false, fmt.Errorf("threshold %d run error: %w", i, err)
 		}
@@ -166,10 +250,136 @@ func (ts *Thresholds) runAll(timeSpentInTest time.Duration) (bool, error) {
 }
 
 // Run processes all the thresholds with the provided Sink at the provided time and returns if any
-// of them fails
+// of them fails. The time-windowed thresholds and the ones that reference other metrics are
+// considered to have no data, so they pass, use RunWithSinks to evaluate them.
 func (ts *Thresholds) Run(sink Sink, duration time.Duration) (bool, error) {
+	return ts.RunWithSinks(ThresholdSinks{Sink: sink}, nil, duration)
+}
+
+// RunWithSinks processes all the thresholds like Run, but the time-windowed
+// thresholds are evaluated with the window sinks, and the referenced metrics
+// with the sinks from referenced, indexed by the names returned by References.
+// A referenced metric without sinks is considered to have no samples, so the
+// thresholds that reference it pass.
+func (ts *Thresholds) RunWithSinks(
+	sinks ThresholdSinks, referenced map[string]ThresholdSinks, duration time.Duration,
+) (bool, error) {
+	var err error
 	// Initialize the sinks store
-	ts.sinked = make(map[string]float64)
+	ts.sinked, ts.windowSinked, err = ts.sinkAll(sinks, duration)
+	if err != nil {
+		return false, err
+	}
+
+	ts.referencedSinked = make(map[string]map[string]float64, len(referenced))
+	ts.referencedWindowSinked = make(map[time.Duration]map[string]map[string]float64)
+	for name, referencedSinks := range referenced {
+		sinked, windowSinked, err := ts.sinkAll(referencedSinks, duration)
+		if err != nil {
+			return false, err
+		}
+		if sinked != nil {
+			ts.referencedSinked[name] = sinked
+		}
+		for window, values := range windowSinked {
+			if ts.referencedWindowSinked[window] == nil {
+				ts.referencedWindowSinked[window] = make(map[string]map[string]float64)
+			}
+			ts.referencedWindowSinked[window][name] = values
+		}
+	}
+
+	return ts.runAll(duration)
+}
+
+func (ts *Thresholds) sinkAll(
+	sinks ThresholdSinks, duration time.Duration,
+) (sinked map[string]float64, windowSinked map[time.Duration]map[string]float64, err error) {
+	if sinks.Sink != nil {
+		sinked, err = ts.sinkValues(sinks.Sink, duration)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	windowSinked = make(map[time.Duration]map[string]float64, len(sinks.Windows))
+	for window, windowSink := range sinks.Windows {
+		// The rate of a counter is calculated over the part of
+		// the window that has elapsed since the start of the test
+		elapsed := window
+		if duration < window {
+			elapsed = duration
+		}
+		windowSinked[window], err = ts.sinkValues(windowSink, elapsed)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return sinked, windowSinked, nil
+}
+
+// aggregations returns the aggregations of all metrics that the threshold
+// expression uses, including the ones in arithmetic expressions.
+func (te *thresholdExpression) aggregations() []*thresholdAggregation {
+	if te == nil {
+		return nil
+	}
+	if te.Left == nil {
+		return []*thresholdAggregation{{
+			AggregationMethod: te.AggregationMethod,
+			AggregationValue:  te.AggregationValue,
+		}}
+	}
+	return append(thresholdAggregations(te.Left), thresholdAggregations(te.Right)...)
+}
+
+// References returns the distinct names of the other metrics and sub-metrics
+// that the thresholds reference in their expressions, in ascending order. It
+// expects the thresholds to have been parsed already.
+func (ts *Thresholds) References() []string {
+	var references []string
+	for _, threshold := range ts.Thresholds {
+		for _, aggregation := range threshold.parsed.aggregations() {
+			if aggregation.Metric != "" {
+				references = append(references, aggregation.Metric)
+			}
+		}
+	}
+	sort.Strings(references)
+
+	distinct := references[:0]
+	for i, reference := range references {
+		if i == 0 || reference != references[i-1] {
+			distinct = append(distinct, reference)
+		}
+	}
+	return distinct
+}
+
+// Windows returns the distinct durations of the time windows that the
+// thresholds are evaluated over, in ascending order. It expects the
+// thresholds to have been parsed already.
+func (ts *Thresholds) Windows() []time.Duration {
+	var windows []time.Duration
+	for _, threshold := range ts.Thresholds {
+		window := threshold.window()
+		if window <= 0 {
+			continue
+		}
+		i := sort.Search(len(windows), func(i int) bool { return windows[i] >= window })
+		if i < len(windows) && windows[i] == window {
+			continue
+		}
+		windows = append(windows, 0)
+		copy(windows[i+1:], windows[i:])
+		windows[i] = window
+	}
+	return windows
+}
+
+func (ts *Thresholds) sinkValues(sink Sink, duration time.Duration) (map[string]float64, error) {
+	sinked := make(map[string]float64)
 
 	// FIXME: Remove this comment as soon as the metrics.Sink does not expose Format anymore.
 	//
@@ -180,37 +390,40 @@ func (ts *Thresholds) Run(sink Sink, duration time.Duration) (bool, error) {
 	// For more details, see https://github.com/grafana/k6/issues/2320
 	switch sinkImpl := sink.(type) {
 	case *CounterSink:
-		ts.sinked["count"] = sinkImpl.Value
-		ts.sinked["rate"] = sinkImpl.Value / (float64(duration) / float64(time.Second))
+		sinked["count"] = sinkImpl.Value
+		sinked["rate"] = sinkImpl.Value / (float64(duration) / float64(time.Second))
 	case *GaugeSink:
-		ts.sinked["value"] = sinkImpl.Value
+		sinked["value"] = sinkImpl.Value
 	case *TrendSink:
-		ts.sinked["min"] = sinkImpl.Min()
-		ts.sinked["max"] = sinkImpl.Max()
-		ts.sinked["avg"] = sinkImpl.Avg()
-		ts.sinked["med"] = sinkImpl.P(0.5)
+		sinked["min"] = sinkImpl.Min()
+		sinked["max"] = sinkImpl.Max()
+		sinked["avg"] = sinkImpl.Avg()
+		sinked["med"] = sinkImpl.P(0.5)
 
 		// Parse the percentile thresholds and insert them in
-		// the sinks mapping.
+		// the sinks mapping. The sink can be either the one of the
+		// metric itself or of a referenced one, so all percentiles
+		// in the threshold expressions are calculated.
 		for _, threshold := range ts.Thresholds {
-			if threshold.parsed.AggregationMethod != tokenPercentile {
-				continue
-			}
+			for _, aggregation := range threshold.parsed.aggregations() {
+				if aggregation.AggregationMethod != tokenPercentile {
+					continue
+				}
 
-			key := fmt.Sprintf("p(%g)", threshold.parsed.AggregationValue.Float64)
-			ts.sinked[key] = sinkImpl.P(threshold.parsed.AggregationValue.Float64 / 100)
+				sinked[aggregation.SinkKey()] = sinkImpl.P(aggregation.AggregationValue.Float64 / 100)
+			}
 		}
 	case *RateSink:
 		// We want to avoid division by zero, which
 		// would lead to [#2520](https://github.com/grafana/k6/issues/2520)
 		if sinkImpl.Total > 0 {
-			ts.sinked["rate"] = float64(sinkImpl.Trues) / float64(sinkImpl.Total)
+			sinked["rate"] = float64(sinkImpl.Trues) / float64(sinkImpl.Total)
 		}
 	default:
-		return false, fmt.Errorf("unable to run Thresholds; reason: unknown sink type")
+		return nil, fmt.Errorf("unable to run Thresholds; reason: unknown sink type")
 	}
 
-	return ts.runAll(duration)
+	return sinked, nil
 }
 
 // Parse parses the Thresholds and fills each Threshold.parsed field with the result.
@@ -221,6 +434,15 @@ func (ts *Thresholds) Parse() error {
 		if err != nil {
 			return err
 		}
+		if t.Window.Valid {
+			if t.Window.Duration <= 0 {
+				return fmt.Errorf("the window of threshold %q must be positive, got %s", t.Source, t.Window.Duration)
+			}
+			if parsed.Window > 0 && parsed.Window != t.Window.TimeDuration() {
+				return fmt.Errorf("the window of threshold %q is %s, which contradicts its expression",
+					t.Source, t.Window.Duration)
+			}
+		}
 
 		t.parsed = parsed
 	}
@@ -266,25 +488,51 @@ func (ts *Thresholds) Validate(metricName string, r *Registry) error {
 			threshold.parsed = thresholdExpression
 		}
 
-		// If the threshold's expression aggregation method is not
-		// supported for the metric we validate against, then we return
-		// an error indicating the InvalidConfig exitcode should be used.
-		if !metric.Type.supportsAggregationMethod(threshold.parsed.AggregationMethod) {
-			err := fmt.Errorf(
-				"%w %q applied on metric %s; reason: "+
-					"unsupported aggregation method %s on metric of type %s. "+
-					"supported aggregation methods for this metric are: %s",
-				ErrInvalidThreshold, threshold.Source, metricName,
-				threshold.parsed.AggregationMethod, metric.Type,
-				strings.Join(metric.Type.supportedAggregationMethods(), ", "),
-			)
-			return errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+		for _, aggregation := range threshold.parsed.aggregations() {
+			aggregatedName, aggregatedMetric := metricName, metric
+			if aggregation.Metric != "" {
+				aggregatedName = aggregation.Metric
+				if aggregatedMetric, err = getReferencedMetric(aggregatedName, r); err != nil {
+					err = fmt.Errorf("%w %q applied on metric %s; reason: %w",
+						ErrInvalidThreshold, threshold.Source, metricName, err)
+					return errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+				}
+			}
+
+			// If the threshold's expression aggregation method is not
+			// supported for the metric we validate against, then we return
+			// an error indicating the InvalidConfig exitcode should be used.
+			if !aggregatedMetric.Type.supportsAggregationMethod(aggregation.AggregationMethod) {
+				err := fmt.Errorf(
+					"%w %q applied on metric %s; reason: "+
+						"unsupported aggregation method %s on metric of type %s. "+
+						"supported aggregation methods for this metric are: %s",
+					ErrInvalidThreshold, threshold.Source, aggregatedName,
+					aggregation.AggregationMethod, aggregatedMetric.Type,
+					strings.Join(aggregatedMetric.Type.supportedAggregationMethods(), ", "),
+				)
+				return errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+			}
 		}
 	}
 
 	return nil
 }
 
+// getReferencedMetric returns the metric of a metric or sub-metric that is
+// referenced in a threshold expression, if it exists in the registry.
+func getReferencedMetric(name string, r *Registry) (*Metric, error) {
+	parsedName, _, err := ParseMetricName(name)
+	if err != nil {
+		return nil, err
+	}
+	metric := r.Get(parsedName)
+	if metric == nil {
+		return nil, fmt.Errorf("the referenced metric %q doesn't exist", parsedName)
+	}
+	return metric, nil
+}
+
 // UnmarshalJSON is implementation of json.Unmarshaler
 func (ts *Thresholds) UnmarshalJSON(data []byte) error {
 	var configs []thresholdConfig
@@ -304,6 +552,10 @@ func (ts Thresholds) MarshalJSON() ([]byte, error) {
 		configs[i].Threshold = t.Source
 		configs[i].AbortOnFail = t.AbortOnFail
 		configs[i].AbortGracePeriod = t.AbortGracePeriod
+		if t.Window.Valid {
+			window := t.Window
+			configs[i].Window = &window
+		}
 	}
 
 	return MarshalJSONWithoutHTMLEscape(configs)
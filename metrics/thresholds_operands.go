@@ -0,0 +1,322 @@
+package metrics
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/guregu/null.v3"
+)
+
+// thresholdOperand is a node of the arithmetic expression on one side of a
+// threshold expression, for instance: 1.5 * http_req_duration{scenario:browse}.p(95)
+type thresholdOperand interface {
+	// evaluate returns the value of the operand, or false if any of the
+	// values it needs is not available, e.g. a metric has no samples yet.
+	evaluate(values thresholdValues) (float64, bool)
+}
+
+// thresholdValues holds the aggregated values that threshold expressions are
+// evaluated with, indexed by the keys returned by thresholdAggregation.SinkKey.
+type thresholdValues struct {
+	// own holds the values of the metric that the thresholds are defined on
+	own map[string]float64
+	// referenced holds the values of the other metrics, indexed by their names
+	referenced map[string]map[string]float64
+}
+
+// thresholdLiteral is a literal number in a threshold expression.
+type thresholdLiteral float64
+
+func (tl thresholdLiteral) evaluate(thresholdValues) (float64, bool) {
+	return float64(tl), true
+}
+
+// thresholdAggregation is an aggregated value of a metric in a threshold
+// expression. It is either a bare aggregation method, like p(95), which is
+// applied on the metric the threshold is defined on, or a reference to
+// another metric or sub-metric, like http_reqs{status:500}.count
+type thresholdAggregation struct {
+	// Metric is the name of the referenced metric or sub-metric, or empty
+	// for the metric the threshold is defined on.
+	Metric string
+
+	// AggregationMethod and AggregationValue have the same meaning as the
+	// respective fields of thresholdExpression.
+	AggregationMethod string
+	AggregationValue  null.Float
+}
+
+// SinkKey computes the key of the aggregated value in the engine's sinks, the
+// same way as thresholdExpression.SinkKey.
+func (ta *thresholdAggregation) SinkKey() string {
+	if ta.AggregationMethod == tokenPercentile {
+		return fmt.Sprintf("%s(%g)", tokenPercentile, ta.AggregationValue.Float64)
+	}
+
+	return ta.AggregationMethod
+}
+
+func (ta *thresholdAggregation) evaluate(values thresholdValues) (float64, bool) {
+	sinks := values.own
+	if ta.Metric != "" {
+		sinks = values.referenced[ta.Metric]
+	}
+	value, ok := sinks[ta.SinkKey()]
+	return value, ok
+}
+
+// thresholdArithmetic is an arithmetic operation between two operands.
+type thresholdArithmetic struct {
+	Operator    byte
+	Left, Right thresholdOperand
+}
+
+func (ta *thresholdArithmetic) evaluate(values thresholdValues) (float64, bool) {
+	left, ok := ta.Left.evaluate(values)
+	if !ok {
+		return 0, false
+	}
+	right, ok := ta.Right.evaluate(values)
+	if !ok {
+		return 0, false
+	}
+
+	switch ta.Operator {
+	case '+':
+		return left + right, true
+	case '-':
+		return left - right, true
+	case '*':
+		return left * right, true
+	case '/':
+		// Dividing by zero usually means that there were no samples yet,
+		// e.g. in an errors/total ratio, so there is nothing to evaluate.
+		if right == 0 {
+			return 0, false
+		}
+		return left / right, true
+	default:
+		return 0, false // the parser doesn't produce any other operators
+	}
+}
+
+// thresholdAggregations returns all of the aggregations in the operand tree.
+func thresholdAggregations(operand thresholdOperand) []*thresholdAggregation {
+	switch o := operand.(type) {
+	case *thresholdAggregation:
+		return []*thresholdAggregation{o}
+	case *thresholdArithmetic:
+		return append(thresholdAggregations(o.Left), thresholdAggregations(o.Right)...)
+	default:
+		return nil
+	}
+}
+
+// parseThresholdOperand parses the arithmetic expression on one side of a
+// threshold expression, as defined by the following BNF:
+// ```
+// operand             -> term (whitespace* ("+" | "-") whitespace* term)*
+// term                -> factor (whitespace* ("*" | "/") whitespace* factor)*
+// factor              -> float | aggregation_method | reference | "(" operand ")" | "-" factor
+// reference           -> metric_name ("{" tags "}")? "." aggregation_method
+// metric_name         -> (letter | digit | "_")+
+// ```
+// The aggregation methods are the ones described in parseThresholdExpression.
+func parseThresholdOperand(input string) (thresholdOperand, error) {
+	p := &thresholdOperandParser{input: input}
+	operand, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if !p.done() {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.input[p.pos:], p.pos)
+	}
+	return operand, nil
+}
+
+type thresholdOperandParser struct {
+	input string
+	pos   int
+}
+
+func (p *thresholdOperandParser) done() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *thresholdOperandParser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *thresholdOperandParser) skipSpaces() {
+	for !p.done() && p.input[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *thresholdOperandParser) parseSum() (thresholdOperand, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpaces()
+		operator := p.peek()
+		if operator != '+' && operator != '-' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left = &thresholdArithmetic{Operator: operator, Left: left, Right: right}
+	}
+}
+
+func (p *thresholdOperandParser) parseProduct() (thresholdOperand, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpaces()
+		operator := p.peek()
+		if operator != '*' && operator != '/' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		left = &thresholdArithmetic{Operator: operator, Left: left, Right: right}
+	}
+}
+
+func (p *thresholdOperandParser) parseFactor() (thresholdOperand, error) {
+	p.skipSpaces()
+	c := p.peek()
+	switch {
+	case p.done():
+		return nil, fmt.Errorf("unexpected end of expression")
+	case c == '(':
+		p.pos++
+		operand, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpaces()
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("missing closing parenthesis at position %d", p.pos)
+		}
+		p.pos++
+		return operand, nil
+	case c == '-':
+		p.pos++
+		operand, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return &thresholdArithmetic{Operator: '-', Left: thresholdLiteral(0), Right: operand}, nil
+	case c == '.' || (c >= '0' && c <= '9'):
+		return p.parseNumber()
+	default:
+		return p.parseAggregation()
+	}
+}
+
+func (p *thresholdOperandParser) parseNumber() (thresholdOperand, error) {
+	start := p.pos
+	for !p.done() && (p.peek() == '.' || (p.peek() >= '0' && p.peek() <= '9')) {
+		p.pos++
+	}
+	value, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+	if err != nil {
+		return nil, fmt.Errorf("malformed number; reason: %w", err)
+	}
+	return thresholdLiteral(value), nil
+}
+
+// scanName scans a metric name or an aggregation method keyword.
+func (p *thresholdOperandParser) scanName() string {
+	start := p.pos
+	for _, r := range p.input[p.pos:] {
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			break
+		}
+		p.pos += len(string(r))
+	}
+	return p.input[start:p.pos]
+}
+
+// scanDelimited scans everything until the given closing character, which is
+// also included in the result. It expects the current character to be the
+// respective opening one.
+func (p *thresholdOperandParser) scanDelimited(closing byte) (string, error) {
+	end := strings.IndexByte(p.input[p.pos:], closing)
+	if end < 0 {
+		return "", fmt.Errorf("missing closing %q at position %d", closing, p.pos)
+	}
+	result := p.input[p.pos : p.pos+end+1]
+	p.pos += end + 1
+	return result, nil
+}
+
+// scanAggregationMethod scans an aggregation method, with the parameter of the
+// percentile method, if there is one.
+func (p *thresholdOperandParser) scanAggregationMethod(name string) (string, error) {
+	if name != tokenPercentile || p.peek() != '(' {
+		return name, nil
+	}
+	parameter, err := p.scanDelimited(')')
+	if err != nil {
+		return "", err
+	}
+	return name + parameter, nil
+}
+
+func (p *thresholdOperandParser) parseAggregation() (thresholdOperand, error) {
+	start := p.pos
+	name := p.scanName()
+	if name == "" {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.input[p.pos:], p.pos)
+	}
+
+	metric := ""
+	if p.peek() == '{' || p.peek() == '.' {
+		// A reference to another metric or sub-metric
+		if p.peek() == '{' {
+			tags, err := p.scanDelimited('}')
+			if err != nil {
+				return nil, err
+			}
+			name += tags
+		}
+		if p.peek() != '.' {
+			return nil, fmt.Errorf("missing the aggregation method of metric %q at position %d", name, p.pos)
+		}
+		p.pos++
+		metric, name = name, p.scanName()
+	}
+
+	method, err := p.scanAggregationMethod(name)
+	if err != nil {
+		return nil, err
+	}
+	parsedMethod, parsedMethodValue, err := parseThresholdAggregationMethod(method)
+	if err != nil {
+		return nil, fmt.Errorf("invalid aggregation %q; reason: %w", p.input[start:p.pos], err)
+	}
+
+	return &thresholdAggregation{
+		Metric:            metric,
+		AggregationMethod: parsedMethod,
+		AggregationValue:  parsedMethodValue,
+	}, nil
+}
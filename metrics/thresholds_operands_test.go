@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+)
+
+func TestParseThresholdOperand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input       string
+		wantOperand thresholdOperand
+		wantErr     bool
+	}{
+		{input: "42", wantOperand: thresholdLiteral(42)},
+		{input: "p(99.9)", wantOperand: &thresholdAggregation{
+			AggregationMethod: tokenPercentile, AggregationValue: null.FloatFrom(99.9),
+		}},
+		{input: "http_reqs.count", wantOperand: &thresholdAggregation{
+			Metric: "http_reqs", AggregationMethod: tokenCount,
+		}},
+		{input: "1.5 * http_req_duration{scenario:browse}.p(95)", wantOperand: &thresholdArithmetic{
+			Operator: '*',
+			Left:     thresholdLiteral(1.5),
+			Right: &thresholdAggregation{
+				Metric:            "http_req_duration{scenario:browse}",
+				AggregationMethod: tokenPercentile,
+				AggregationValue:  null.FloatFrom(95),
+			},
+		}},
+		{input: "count / (total.count + 1) - -2", wantOperand: &thresholdArithmetic{
+			Operator: '-',
+			Left: &thresholdArithmetic{
+				Operator: '/',
+				Left:     &thresholdAggregation{AggregationMethod: tokenCount},
+				Right: &thresholdArithmetic{
+					Operator: '+',
+					Left:     &thresholdAggregation{Metric: "total", AggregationMethod: tokenCount},
+					Right:    thresholdLiteral(1),
+				},
+			},
+			Right: &thresholdArithmetic{Operator: '-', Left: thresholdLiteral(0), Right: thresholdLiteral(2)},
+		}},
+		{input: "", wantErr: true},
+		{input: "foo", wantErr: true},
+		{input: "total.foo", wantErr: true},
+		{input: "total{status:200}", wantErr: true},
+		{input: "total{status:200.count", wantErr: true},
+		{input: "(count", wantErr: true},
+		{input: "count count", wantErr: true},
+		{input: "count +", wantErr: true},
+		{input: "1..2", wantErr: true},
+	}
+	for _, testCase := range tests {
+		testCase := testCase
+
+		t.Run(testCase.input, func(t *testing.T) {
+			t.Parallel()
+
+			gotOperand, gotErr := parseThresholdOperand(testCase.input)
+			if testCase.wantErr {
+				assert.Error(t, gotErr)
+				return
+			}
+			require.NoError(t, gotErr)
+			assert.Equal(t, testCase.wantOperand, gotOperand)
+		})
+	}
+}
+
+func TestThresholdOperandEvaluate(t *testing.T) {
+	t.Parallel()
+
+	values := thresholdValues{
+		own:        map[string]float64{"count": 3, "p(95)": 300},
+		referenced: map[string]map[string]float64{"total": {"count": 200}, "empty": {"count": 0}},
+	}
+	tests := []struct {
+		input     string
+		wantValue float64
+		wantOk    bool
+	}{
+		{input: "count / total.count", wantValue: 0.015, wantOk: true},
+		{input: "2 + 3 * 4 - 1", wantValue: 13, wantOk: true},
+		{input: "(2 + 3) * 4", wantValue: 20, wantOk: true},
+		{input: "-p(95) / 3", wantValue: -100, wantOk: true},
+		{input: "count / empty.count", wantOk: false},
+		{input: "p(99)", wantOk: false},
+		{input: "missing.count * 2", wantOk: false},
+	}
+	for _, testCase := range tests {
+		testCase := testCase
+
+		t.Run(testCase.input, func(t *testing.T) {
+			t.Parallel()
+
+			operand, err := parseThresholdOperand(testCase.input)
+			require.NoError(t, err)
+			gotValue, gotOk := operand.evaluate(values)
+			assert.Equal(t, testCase.wantOk, gotOk)
+			if testCase.wantOk {
+				assert.InDelta(t, testCase.wantValue, gotValue, 1e-9)
+			}
+		})
+	}
+}
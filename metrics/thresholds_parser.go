@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
+	"go.k6.io/k6/lib/types"
 	"gopkg.in/guregu/null.v3"
 )
 
@@ -27,6 +29,19 @@ type thresholdExpression struct {
 
 	// Value holds the value parsed from the threshold expression.
 	Value float64
+
+	// Window holds the duration of the optional time window, over which the
+	// expression is evaluated. For instance: an expression of the form
+	// p(95) < 200 over 1m would result in Window to be set to one minute.
+	// It is zero when the expression applies to the whole test run.
+	Window time.Duration
+
+	// Left and Right hold the arithmetic expressions on both sides of the
+	// operator, when the expression is not a plain comparison of an
+	// aggregation method with a value, for instance:
+	// p(95) < 1.5 * http_req_duration{scenario:browse}.p(95)
+	// In that case, AggregationMethod, AggregationValue and Value are unset.
+	Left, Right thresholdOperand
 }
 
 // SinkKey computes the key used to index a thresholdExpression in the engine's sinks.
@@ -53,10 +68,14 @@ func (te *thresholdExpression) SinkKey() string {
 // as defined in a JS script (for instance p(95)<1000), into a thresholdExpression
 // instance.
 //
-// It is expected to be of the form: `aggregation_method operator value`.
+// It is expected to be of the form: `aggregation_method operator value [over window]`,
+// where either side can also be an arithmetic expression, with references to
+// other metrics, as described in parseThresholdOperand.
 // As defined by the following BNF:
 // ```
-// assertion           -> aggregation_method whitespace* operator whitespace* float
+// assertion           -> aggregation_method whitespace* operator whitespace* float window?
+// assertion           -> operand whitespace* operator whitespace* operand window?
+// window              -> whitespace+ "over" whitespace+ duration
 // aggregation_method  -> trend | rate | gauge | counter
 // counter             -> "count" | "rate"
 // gauge               -> "value"
@@ -65,6 +84,7 @@ func (te *thresholdExpression) SinkKey() string {
 // percentile          -> "p(" float ")"
 // operator            -> ">" | ">=" | "<=" | "<" | "==" | "===" | "!="
 // float               -> digit+ ("." digit+)?
+// duration            -> a duration string, for instance "30s" or "1m30s"
 // digit               -> "0" | "1" | "2" | "3" | "4" | "5" | "6" | "7" | "8" | "9"
 // whitespace          -> " "
 // ```
@@ -76,27 +96,44 @@ func parseThresholdExpression(input string) (*thresholdExpression, error) {
 		return nil, fmt.Errorf("failed parsing threshold expression %q; reason: %w", input, err)
 	}
 
-	parsedMethod, parsedMethodValue, err := parseThresholdAggregationMethod(method)
+	value, window, err := parseThresholdWindow(value)
 	if err != nil {
-		err = fmt.Errorf("failed parsing threshold expression's %q left hand side; "+
+		err = fmt.Errorf("failed parsing threshold expression's %q time window; "+
 			"reason: %w", input, err,
 		)
 		return nil, err
 	}
 
-	parsedValue, err := strconv.ParseFloat(value, 64)
+	condition := &thresholdExpression{
+		Operator: operator,
+		Window:   window,
+	}
+
+	// Comparing an aggregation method with a literal value is by far the
+	// most common case, so it doesn't need the arithmetic expressions.
+	parsedMethod, parsedMethodValue, methodErr := parseThresholdAggregationMethod(method)
+	parsedValue, valueErr := strconv.ParseFloat(value, 64)
+	if methodErr == nil && valueErr == nil {
+		condition.AggregationMethod = parsedMethod
+		condition.AggregationValue = parsedMethodValue
+		condition.Value = parsedValue
+		return condition, nil
+	}
+
+	condition.Left, err = parseThresholdOperand(method)
 	if err != nil {
-		err = fmt.Errorf("failed parsing threshold expresion's %q right hand side; "+
+		err = fmt.Errorf("failed parsing threshold expression's %q left hand side; "+
 			"reason: %w", input, err,
 		)
 		return nil, err
 	}
 
-	condition := &thresholdExpression{
-		AggregationMethod: parsedMethod,
-		AggregationValue:  parsedMethodValue,
-		Operator:          operator,
-		Value:             parsedValue,
+	condition.Right, err = parseThresholdOperand(value)
+	if err != nil {
+		err = fmt.Errorf("failed parsing threshold expresion's %q right hand side; "+
+			"reason: %w", input, err,
+		)
+		return nil, err
 	}
 
 	return condition, nil
@@ -209,6 +246,39 @@ func parseThresholdAggregationMethod(input string) (string, null.Float, error) {
 	return "", null.Float{}, fmt.Errorf("failed parsing method from expression")
 }
 
+// tokenOver separates the value of a threshold expression from its optional time window
+const tokenOver = "over"
+
+// parseThresholdWindow splits the right hand side of a threshold expression
+// into its value and its optional time window, of the form `value over window`.
+// It assumes the provided input argument is already trimmed. If there is no
+// time window, the input is returned as is, with a zero window.
+func parseThresholdWindow(input string) (string, time.Duration, error) {
+	fields := strings.Fields(input)
+	overPos := -1
+	for i, field := range fields {
+		if field == tokenOver {
+			overPos = i
+		}
+	}
+	if overPos < 0 {
+		return input, 0, nil
+	}
+	if overPos == 0 || overPos != len(fields)-2 {
+		return "", 0, fmt.Errorf("malformed time window, it should be of the form 'value %s duration'", tokenOver)
+	}
+
+	window, err := types.ParseExtendedDuration(fields[overPos+1])
+	if err != nil {
+		return "", 0, err
+	}
+	if window <= 0 {
+		return "", 0, fmt.Errorf("the time window must be positive, got %s", fields[overPos+1])
+	}
+
+	return strings.TrimSpace(input[:strings.LastIndex(input, tokenOver)]), window, nil
+}
+
 func trimDelimited(prefix, input, suffix string) string {
 	return strings.TrimSuffix(strings.TrimPrefix(input, prefix), suffix)
 }
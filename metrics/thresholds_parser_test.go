@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/guregu/null.v3"
@@ -40,6 +41,57 @@ func TestParseThresholdExpression(t *testing.T) {
 			wantExpression: &thresholdExpression{AggregationMethod: "count", Operator: ">", Value: 20},
 			wantErr:        false,
 		},
+		{
+			name:  "valid threshold expression with a time window",
+			input: "p(95) < 500 over 1m30s",
+			wantExpression: &thresholdExpression{
+				AggregationMethod: "p",
+				AggregationValue:  null.FloatFrom(95),
+				Operator:          "<",
+				Value:             500,
+				Window:            90 * time.Second,
+			},
+			wantErr: false,
+		},
+		{
+			name:  "valid threshold expression referencing another metric",
+			input: "count / http_reqs{status:500}.count < 0.01 over 1m",
+			wantExpression: &thresholdExpression{
+				Operator: "<",
+				Window:   time.Minute,
+				Left: &thresholdArithmetic{
+					Operator: '/',
+					Left:     &thresholdAggregation{AggregationMethod: "count"},
+					Right:    &thresholdAggregation{Metric: "http_reqs{status:500}", AggregationMethod: "count"},
+				},
+				Right: thresholdLiteral(0.01),
+			},
+			wantErr: false,
+		},
+		{
+			name:           "invalid arithmetic expression fails",
+			input:          "count / <5",
+			wantExpression: nil,
+			wantErr:        true,
+		},
+		{
+			name:           "time window without a duration fails",
+			input:          "count>20 over",
+			wantExpression: nil,
+			wantErr:        true,
+		},
+		{
+			name:           "invalid time window duration fails",
+			input:          "count>20 over 1x",
+			wantExpression: nil,
+			wantErr:        true,
+		},
+		{
+			name:           "non positive time window duration fails",
+			input:          "count>20 over 0s",
+			wantExpression: nil,
+			wantErr:        true,
+		},
 	}
 	for _, testCase := range tests {
 		testCase := testCase
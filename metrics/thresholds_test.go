@@ -42,7 +42,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 	}{
 		{
 			name:             "valid expression using the > operator over passing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreater, 0.01},
+			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreater, 0.01, 0, nil, nil},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 1},
 			wantOk:           true,
@@ -50,7 +50,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression using the > operator over passing threshold and defined abort grace period",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreater, 0.01},
+			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreater, 0.01, 0, nil, nil},
 			abortGracePeriod: types.NullDurationFrom(2 * time.Second),
 			sinks:            map[string]float64{"rate": 1},
 			wantOk:           true,
@@ -58,7 +58,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression using the >= operator over passing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreaterEqual, 0.01},
+			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreaterEqual, 0.01, 0, nil, nil},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.01},
 			wantOk:           true,
@@ -66,7 +66,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression using the <= operator over passing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenLessEqual, 0.01},
+			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenLessEqual, 0.01, 0, nil, nil},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.01},
 			wantOk:           true,
@@ -74,7 +74,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression using the < operator over passing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenLess, 0.01},
+			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenLess, 0.01, 0, nil, nil},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.00001},
 			wantOk:           true,
@@ -82,7 +82,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression using the == operator over passing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenLooselyEqual, 0.01},
+			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenLooselyEqual, 0.01, 0, nil, nil},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.01},
 			wantOk:           true,
@@ -90,7 +90,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression using the === operator over passing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenStrictlyEqual, 0.01},
+			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenStrictlyEqual, 0.01, 0, nil, nil},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.01},
 			wantOk:           true,
@@ -98,7 +98,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression using != operator over passing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenBangEqual, 0.01},
+			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenBangEqual, 0.01, 0, nil, nil},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.02},
 			wantOk:           true,
@@ -106,7 +106,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression over failing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreater, 0.01},
+			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreater, 0.01, 0, nil, nil},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.00001},
 			wantOk:           false,
@@ -114,7 +114,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression over non-existing sink",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreater, 0.01},
+			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreater, 0.01, 0, nil, nil},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"med": 27.2},
 			wantOk:           true,
@@ -124,7 +124,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 			// The ParseThresholdCondition constructor should ensure that no invalid
 			// operator gets through, but let's protect our future selves anyhow.
 			name:             "invalid expression operator",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, "&", 0.01},
+			parsed:           &thresholdExpression{tokenRate, null.Float{}, "&", 0.01, 0, nil, nil},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.00001},
 			wantOk:           false,
@@ -145,7 +145,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 				parsed:           testCase.parsed,
 			}
 
-			gotOk, gotErr := threshold.runNoTaint(testCase.sinks)
+			gotOk, gotErr := threshold.runNoTaint(testCase.sinks, nil)
 
 			assert.Equal(t,
 				testCase.wantErr,
@@ -168,7 +168,7 @@ func BenchmarkRunNoTaint(b *testing.B) {
 		LastFailed:       false,
 		AbortOnFail:      false,
 		AbortGracePeriod: types.NullDurationFrom(2 * time.Second),
-		parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreater, 0.01},
+		parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreater, 0.01, 0, nil, nil},
 	}
 
 	sinks := map[string]float64{"rate": 1}
@@ -176,7 +176,7 @@ func BenchmarkRunNoTaint(b *testing.B) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		_, _ = threshold.runNoTaint(sinks)
+		_, _ = threshold.runNoTaint(sinks, nil)
 	}
 }
 
@@ -193,7 +193,7 @@ func TestThresholdRun(t *testing.T) {
 		threshold.parsed = parsed
 
 		t.Run("no taint", func(t *testing.T) {
-			b, err := threshold.runNoTaint(sinks)
+			b, err := threshold.runNoTaint(sinks, nil)
 			assert.NoError(t, err)
 			assert.True(t, b)
 			assert.False(t, threshold.LastFailed)
@@ -202,7 +202,7 @@ func TestThresholdRun(t *testing.T) {
 		t.Run("taint", func(t *testing.T) {
 			t.Parallel()
 
-			b, err := threshold.run(sinks)
+			b, err := threshold.run(sinks, nil)
 			assert.NoError(t, err)
 			assert.True(t, b)
 			assert.False(t, threshold.LastFailed)
@@ -219,14 +219,14 @@ func TestThresholdRun(t *testing.T) {
 		threshold.parsed = parsed
 
 		t.Run("no taint", func(t *testing.T) {
-			b, err := threshold.runNoTaint(sinks)
+			b, err := threshold.runNoTaint(sinks, nil)
 			assert.NoError(t, err)
 			assert.False(t, b)
 			assert.False(t, threshold.LastFailed)
 		})
 
 		t.Run("taint", func(t *testing.T) {
-			b, err := threshold.run(sinks)
+			b, err := threshold.run(sinks, nil)
 			assert.NoError(t, err)
 			assert.False(t, b)
 			assert.True(t, threshold.LastFailed)
@@ -566,8 +566,8 @@ func TestNewThresholdsWithConfig(t *testing.T) {
 		t.Parallel()
 
 		configs := []thresholdConfig{
-			{`rate<0.01`, false, types.NullDuration{}},
-			{`p(95)<200`, true, types.NullDuration{}},
+			{`rate<0.01`, false, types.NullDuration{}, nil},
+			{`p(95)<200`, true, types.NullDuration{}, nil},
 		}
 		ts := newThresholdsWithConfig(configs)
 		assert.Len(t, ts.Thresholds, 2)
@@ -749,6 +749,160 @@ func TestThresholdsRun(t *testing.T) {
 	}
 }
 
+func TestThresholdsRunWithSinksWindows(t *testing.T) {
+	t.Parallel()
+
+	thresholds := NewThresholds([]string{"count<100", "count<10 over 1m", "rate<1 over 10s"})
+	require.NoError(t, thresholds.Parse())
+	assert.Equal(t, []time.Duration{10 * time.Second, time.Minute}, thresholds.Windows())
+
+	// Without any samples in the windows, only the whole test run counts
+	passed, err := thresholds.RunWithSinks(ThresholdSinks{Sink: &CounterSink{Value: 50}}, nil, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, passed)
+
+	passed, err = thresholds.RunWithSinks(ThresholdSinks{Sink: &CounterSink{Value: 50}, Windows: map[time.Duration]Sink{
+		time.Minute:      &CounterSink{Value: 9},
+		10 * time.Second: &CounterSink{Value: 9},
+	}}, nil, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, passed)
+
+	passed, err = thresholds.RunWithSinks(ThresholdSinks{Sink: &CounterSink{Value: 50}, Windows: map[time.Duration]Sink{
+		time.Minute:      &CounterSink{Value: 10},
+		10 * time.Second: &CounterSink{Value: 9},
+	}}, nil, time.Hour)
+	require.NoError(t, err)
+	assert.False(t, passed)
+	assert.False(t, thresholds.Thresholds[0].LastFailed)
+	assert.True(t, thresholds.Thresholds[1].LastFailed)
+	assert.False(t, thresholds.Thresholds[2].LastFailed)
+
+	// The rate is calculated over the elapsed part of the window
+	passed, err = thresholds.RunWithSinks(ThresholdSinks{Sink: &CounterSink{Value: 9}, Windows: map[time.Duration]Sink{
+		10 * time.Second: &CounterSink{Value: 9},
+	}}, nil, 5*time.Second)
+	require.NoError(t, err)
+	assert.False(t, passed)
+	assert.True(t, thresholds.Thresholds[2].LastFailed)
+}
+
+func TestThresholdsRunWithSinksReferences(t *testing.T) {
+	t.Parallel()
+
+	thresholds := NewThresholds([]string{
+		"p(95) < 1.5 * other{scenario:browse}.p(95)",
+		"max < other{scenario:browse}.max over 1m",
+		"max / total.count < 40",
+	})
+	require.NoError(t, thresholds.Parse())
+	assert.Equal(t, []string{"other{scenario:browse}", "total"}, thresholds.References())
+
+	// Without the referenced sinks, there is nothing to compare to
+	passed, err := thresholds.RunWithSinks(ThresholdSinks{Sink: getTrendSink(100, 200)}, nil, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, passed)
+
+	referenced := map[string]ThresholdSinks{
+		"other{scenario:browse}": {
+			Sink:    getTrendSink(100, 150),
+			Windows: map[time.Duration]Sink{time.Minute: getTrendSink(150)},
+		},
+		"total": {Sink: &CounterSink{Value: 4}},
+	}
+	passed, err = thresholds.RunWithSinks(ThresholdSinks{
+		Sink:    getTrendSink(100, 200),
+		Windows: map[time.Duration]Sink{time.Minute: getTrendSink(200)},
+	}, referenced, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, passed)
+	assert.False(t, thresholds.Thresholds[0].LastFailed) // 195 < 1.5 * 147.5
+	assert.True(t, thresholds.Thresholds[1].LastFailed)  // 200 >= 150
+	assert.True(t, thresholds.Thresholds[2].LastFailed)  // 200/4 >= 40
+}
+
+func TestThresholdOperands(t *testing.T) {
+	t.Parallel()
+
+	thresholds := NewThresholds([]string{"p(95)<500", "rate>=0.5", "avg<2*other.avg"})
+	_, _, ok := thresholds.Thresholds[0].Operands(NewTrendSink(), time.Second)
+	assert.False(t, ok, "not parsed yet")
+	require.NoError(t, thresholds.Parse())
+	assert.Equal(t, "<", thresholds.Thresholds[0].Operator())
+	assert.Equal(t, ">=", thresholds.Thresholds[1].Operator())
+
+	trend := NewTrendSink()
+	_, _, ok = thresholds.Thresholds[0].Operands(trend, time.Second)
+	assert.False(t, ok, "no samples")
+	for i := 1; i <= 100; i++ {
+		trend.Add(Sample{Value: float64(i * 10)})
+	}
+	lhs, rhs, ok := thresholds.Thresholds[0].Operands(trend, time.Second)
+	require.True(t, ok)
+	assert.InDelta(t, 950, lhs, 10)
+	assert.Equal(t, 500.0, rhs)
+
+	counter := NewSink(Counter)
+	counter.Add(Sample{Value: 10, Time: time.Now()})
+	lhs, rhs, ok = thresholds.Thresholds[1].Operands(counter, 4*time.Second)
+	require.True(t, ok)
+	assert.Equal(t, 2.5, lhs)
+	assert.Equal(t, 0.5, rhs)
+
+	_, _, ok = thresholds.Thresholds[2].Operands(trend, time.Second)
+	assert.False(t, ok, "references another metric")
+}
+
+func TestThresholdsValidateReferences(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	_, err := registry.NewMetric("my_trend", Trend)
+	require.NoError(t, err)
+	_, err = registry.NewMetric("my_counter", Counter)
+	require.NoError(t, err)
+
+	valid := NewThresholds([]string{"p(95) < 2 * my_trend{scenario:a}.med", "max < my_counter.count"})
+	require.NoError(t, valid.Parse())
+	assert.NoError(t, valid.Validate("my_trend{scenario:b}", registry))
+
+	missing := NewThresholds([]string{"p(95) < other.p(95)"})
+	require.NoError(t, missing.Parse())
+	err = missing.Validate("my_trend", registry)
+	assert.ErrorIs(t, err, ErrInvalidThreshold)
+	assert.ErrorContains(t, err, `the referenced metric "other" doesn't exist`)
+
+	unsupported := NewThresholds([]string{"p(95) < my_counter.p(95)"})
+	require.NoError(t, unsupported.Parse())
+	err = unsupported.Validate("my_trend", registry)
+	assert.ErrorIs(t, err, ErrInvalidThreshold)
+	assert.ErrorContains(t, err, "unsupported aggregation method p on metric of type counter")
+
+	unsupportedOwn := NewThresholds([]string{"rate < my_trend.max"})
+	require.NoError(t, unsupportedOwn.Parse())
+	assert.ErrorIs(t, unsupportedOwn.Validate("my_trend", registry), ErrInvalidThreshold)
+}
+
+func TestThresholdsParseWindow(t *testing.T) {
+	t.Parallel()
+
+	ts := NewThresholds([]string{"count<10 over 1m"})
+	ts.Thresholds[0].Window = types.NullDurationFrom(time.Minute)
+	require.NoError(t, ts.Parse())
+
+	ts = NewThresholds([]string{"count<10"})
+	ts.Thresholds[0].Window = types.NullDurationFrom(30 * time.Second)
+	require.NoError(t, ts.Parse())
+	assert.Equal(t, []time.Duration{30 * time.Second}, ts.Windows())
+
+	ts.Thresholds[0].Window = types.NullDurationFrom(0)
+	assert.ErrorContains(t, ts.Parse(), "must be positive")
+
+	ts = NewThresholds([]string{"count<10 over 1m"})
+	ts.Thresholds[0].Window = types.NullDurationFrom(30 * time.Second)
+	assert.ErrorContains(t, ts.Parse(), "contradicts its expression")
+}
+
 func TestThresholdsJSON(t *testing.T) {
 	t.Parallel()
 
@@ -851,6 +1005,20 @@ func TestThresholdsJSON(t *testing.T) {
 		})
 	}
 
+	t.Run("window", func(t *testing.T) {
+		t.Parallel()
+
+		input := `[{"threshold":"p(95)<200","abortOnFail":false,"delayAbortEval":null,"window":"1m0s"}]`
+		var ts Thresholds
+		require.NoError(t, json.Unmarshal([]byte(input), &ts))
+		require.Len(t, ts.Thresholds, 1)
+		assert.Equal(t, types.NullDurationFrom(time.Minute), ts.Thresholds[0].Window)
+
+		output, err := MarshalJSONWithoutHTMLEscape(ts)
+		require.NoError(t, err)
+		assert.Equal(t, input, string(output))
+	})
+
 	t.Run("bad JSON", func(t *testing.T) {
 		t.Parallel()
 
@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+)
+
+// trendHistogram is a streaming histogram that a TrendSink can use instead of
+// storing every single value, so it needs a constant amount of memory.
+//
+// The sizes of its buckets grow exponentially, so that all of the values in a
+// bucket are within a fixed relative error from its representative value.
+// Unlike the HDR histogram in the cloud output, it also supports negative
+// values. Two histograms with the same maximum error can be merged together
+// without losing any accuracy.
+type trendHistogram struct {
+	maxError float64
+	gamma    float64
+	logGamma float64
+
+	positive map[int32]uint64
+	negative map[int32]uint64 // the buckets for the absolute values
+	zeros    uint64
+}
+
+func newTrendHistogram(maxError float64) *trendHistogram {
+	gamma := (1 + maxError) / (1 - maxError)
+	return &trendHistogram{
+		maxError: maxError,
+		gamma:    gamma,
+		logGamma: math.Log(gamma),
+		positive: make(map[int32]uint64),
+		negative: make(map[int32]uint64),
+	}
+}
+
+// index returns the index of the bucket for the given positive value, i.e.
+// the bucket for the (gamma^(i-1), gamma^i] range.
+func (h *trendHistogram) index(v float64) int32 {
+	return int32(math.Ceil(math.Log(v) / h.logGamma))
+}
+
+// value returns the representative value of the bucket with the given
+// index, which is within the maximum relative error from all of its values.
+func (h *trendHistogram) value(i int32) float64 {
+	return 2 * math.Pow(h.gamma, float64(i)) / (h.gamma + 1)
+}
+
+func (h *trendHistogram) add(v float64, count uint64) {
+	switch {
+	case v > 0:
+		h.positive[h.index(v)] += count
+	case v < 0:
+		h.negative[h.index(-v)] += count
+	default:
+		h.zeros += count
+	}
+}
+
+func sortedBucketIndexes(buckets map[int32]uint64, descending bool) []int32 {
+	indexes := make([]int32, 0, len(buckets))
+	for i := range buckets {
+		indexes = append(indexes, i)
+	}
+	sort.Slice(indexes, func(a, b int) bool {
+		if descending {
+			return indexes[a] > indexes[b]
+		}
+		return indexes[a] < indexes[b]
+	})
+	return indexes
+}
+
+// valueAt returns the approximate value at the given zero-based position, as
+// if all of the values were sorted.
+func (h *trendHistogram) valueAt(rank uint64) float64 {
+	// The negative values are in reverse order, i.e. the ones with the largest
+	// absolute values are first.
+	for _, i := range sortedBucketIndexes(h.negative, true) {
+		if rank < h.negative[i] {
+			return -h.value(i)
+		}
+		rank -= h.negative[i]
+	}
+	if rank < h.zeros {
+		return 0
+	}
+	rank -= h.zeros
+	indexes := sortedBucketIndexes(h.positive, false)
+	for _, i := range indexes {
+		if rank < h.positive[i] {
+			return h.value(i)
+		}
+		rank -= h.positive[i]
+	}
+	if len(indexes) == 0 {
+		return 0
+	}
+	return h.value(indexes[len(indexes)-1]) // shouldn't happen
+}
@@ -0,0 +1,92 @@
+package output
+
+import (
+	"time"
+
+	"go.k6.io/k6/metrics"
+)
+
+// Annotation is a note about a change during the test run, like an update of
+// the thresholds or of the run tags from the REST API.
+//
+// Annotations are sent through the samples channel, so the outputs receive
+// them in order with the metric samples. They don't contain any samples, so
+// the outputs that don't support them just ignore them, while the others can
+// find them with a type assertion on the received sample containers.
+type Annotation struct {
+	Time time.Time
+	Text string
+	// Data is the new state after the change, which can be encoded as JSON.
+	Data any
+}
+
+var _ metrics.SampleContainer = &Annotation{}
+
+// GetSamples implements the metrics.SampleContainer interface, an annotation
+// doesn't have any samples.
+func (a *Annotation) GetSamples() []metrics.Sample {
+	return nil
+}
+
+// RunTagsUpdate changes the run tags of the samples that are piped to the
+// outputs after it, so the run tags can be changed while the test is running.
+//
+// The samples are still emitted with the original run tags, so the Manager
+// replaces the values of those with the current ones. The tags that were set
+// by the script to a different value than the original run tag take
+// precedence, the same as with the original run tags. It's sent through the
+// samples channel as well, but it's not passed on to the outputs.
+type RunTagsUpdate struct {
+	// Original are the run tags of the test, as the samples are emitted with
+	Original map[string]string
+	// Current are all of the run tags after the update
+	Current map[string]string
+}
+
+var _ metrics.SampleContainer = &RunTagsUpdate{}
+
+// GetSamples implements the metrics.SampleContainer interface, the update
+// doesn't have any samples.
+func (u *RunTagsUpdate) GetSamples() []metrics.Sample {
+	return nil
+}
+
+// apply returns the container with the current run tags set on its samples.
+// The samples of the containers that are backed by a slice are changed in
+// place, since the Manager is their only reader at that point.
+func (u *RunTagsUpdate) apply(sc metrics.SampleContainer) metrics.SampleContainer {
+	switch c := sc.(type) {
+	case metrics.Sample:
+		c.Tags = u.tags(c.Tags)
+		return c
+	case metrics.ConnectedSamples:
+		for i := range c.Samples {
+			c.Samples[i].Tags = u.tags(c.Samples[i].Tags)
+		}
+		c.Tags = u.tags(c.Tags)
+		return c
+	default:
+		samples := sc.GetSamples()
+		for i := range samples {
+			samples[i].Tags = u.tags(samples[i].Tags)
+		}
+		return sc
+	}
+}
+
+func (u *RunTagsUpdate) tags(tags *metrics.TagSet) *metrics.TagSet {
+	if tags == nil {
+		return nil
+	}
+	for key, value := range u.Current {
+		current, ok := tags.Get(key)
+		if ok && current == value {
+			continue
+		}
+		if original, isRunTag := u.Original[key]; ok && (!isRunTag || current != original) {
+			continue // the tag was set by the script
+		}
+		tags = tags.With(key, value)
+	}
+	return tags
+}
@@ -0,0 +1,69 @@
+package output
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/metrics"
+)
+
+type bufferOutput struct {
+	SampleBuffer
+}
+
+func (o *bufferOutput) Description() string { return "buffer" }
+func (o *bufferOutput) Start() error        { return nil }
+func (o *bufferOutput) Stop() error         { return nil }
+
+func TestManagerRunTagsUpdate(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	metric, err := registry.NewMetric("my_metric", metrics.Counter)
+	require.NoError(t, err)
+	runTags := registry.RootTagSet().WithTagsFromMap(map[string]string{"phase": "warmup", "team": "a"})
+	newSample := func(tags *metrics.TagSet) metrics.Sample {
+		return metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: metric, Tags: tags}, Time: time.Now(), Value: 1}
+	}
+
+	out := &bufferOutput{}
+	manager := NewManager([]Output{out}, testutils.NewLogger(t), func(error) {})
+	samples := make(chan metrics.SampleContainer, 10)
+	wait, finish, err := manager.Start(samples)
+	require.NoError(t, err)
+
+	annotation := &Annotation{Time: time.Now(), Text: "Run tags changed"}
+	samples <- newSample(runTags)
+	samples <- &RunTagsUpdate{
+		Original: runTags.Map(),
+		Current:  map[string]string{"phase": "load", "team": "a", "env": "staging"},
+	}
+	samples <- annotation
+	samples <- newSample(runTags)
+	samples <- metrics.ConnectedSamples{
+		Samples: []metrics.Sample{newSample(runTags.With("phase", "custom"))},
+		Tags:    runTags,
+	}
+	close(samples)
+	wait()
+	finish(nil)
+
+	containers := out.GetBufferedSamples()
+	require.Len(t, containers, 4)
+	assert.Equal(t, map[string]string{"phase": "warmup", "team": "a"}, containers[0].GetSamples()[0].Tags.Map())
+	assert.Same(t, annotation, containers[1])
+	assert.Equal(t,
+		map[string]string{"phase": "load", "team": "a", "env": "staging"},
+		containers[2].GetSamples()[0].Tags.Map(),
+	)
+
+	// The tags set by the script take precedence
+	connected, ok := containers[3].(metrics.ConnectedSamples)
+	require.True(t, ok)
+	assert.Equal(t, map[string]string{"phase": "custom", "team": "a", "env": "staging"}, connected.Samples[0].Tags.Map())
+	assert.Equal(t, map[string]string{"phase": "load", "team": "a", "env": "staging"}, connected.Tags.Map())
+}
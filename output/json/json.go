@@ -127,6 +127,11 @@ func (o *Output) flushMetrics() {
 	var count int
 	jw := new(jwriter.Writer)
 	for _, sc := range samples {
+		if annotation, ok := sc.(*output.Annotation); ok {
+			jw.Raw(metrics.MarshalJSONWithoutHTMLEscape(wrapAnnotation(annotation)))
+			jw.RawByte('\n')
+			continue
+		}
 		samples := sc.GetSamples()
 		count += len(samples)
 		for _, sample := range samples {
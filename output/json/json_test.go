@@ -206,6 +206,32 @@ func TestJsonOutputFileGzipped(t *testing.T) {
 	assert.NoError(t, file.Close())
 }
 
+func TestJsonOutputAnnotation(t *testing.T) {
+	t.Parallel()
+
+	stdout := new(bytes.Buffer)
+	out, err := New(output.Params{
+		Logger: testutils.NewLogger(t),
+		StdOut: stdout,
+	})
+	require.NoError(t, err)
+	require.NoError(t, out.Start())
+
+	out.AddMetricSamples([]metrics.SampleContainer{&output.Annotation{
+		Time: time.Date(2021, time.February, 24, 13, 37, 10, 0, time.UTC),
+		Text: "Thresholds of http_req_duration changed from the REST API",
+		Data: map[string]metrics.Thresholds{"http_req_duration": metrics.NewThresholds([]string{"p(95)<500"})},
+	}})
+	require.NoError(t, out.Stop())
+
+	assert.Contains(t, stdout.String(), "p(95)<500")
+	getValidator(t, []string{
+		`{"type":"Annotation","data":{"time":"2021-02-24T13:37:10Z",` +
+			`"text":"Thresholds of http_req_duration changed from the REST API",` +
+			`"data":{"http_req_duration":["p(95)<500"]}}}`,
+	})(stdout)
+}
+
 func TestWrapSampleWithSamplePointer(t *testing.T) {
 	t.Parallel()
 	out := wrapSample(metrics.Sample{
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
 )
 
 //go:generate easyjson -pkg -no_std_marshalers -gen_build_flags -mod=mod .
@@ -46,3 +47,23 @@ type metricEnvelope struct {
 	} `json:"data"`
 	Metric string `json:"metric"`
 }
+
+// annotationEnvelope is the entry of an annotation about a change during the
+// test run. Since the annotations are rare and their data can be of any type,
+// it's encoded with encoding/json instead of easyjson.
+type annotationEnvelope struct {
+	Type string `json:"type"`
+	Data struct {
+		Time time.Time `json:"time"`
+		Text string    `json:"text"`
+		Data any       `json:"data,omitempty"`
+	} `json:"data"`
+}
+
+func wrapAnnotation(annotation *output.Annotation) annotationEnvelope {
+	a := annotationEnvelope{Type: "Annotation"}
+	a.Data.Time = annotation.Time
+	a.Data.Text = annotation.Text
+	a.Data.Data = annotation.Data
+	return a
+}
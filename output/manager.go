@@ -35,6 +35,9 @@ func NewManager(outputs []Output, logger logrus.FieldLogger, testStopCallback fu
 // take some time, since some outputs make initial network requests to set up
 // whatever remote services are going to listen to them.
 //
+// The run tags of the samples are changed by the RunTagsUpdate containers sent
+// on the samples channel, which aren't passed on to the outputs.
+//
 // If all outputs start successfully, this method will return 2 callbacks. The
 // first one, wait(), will block until the samples channel has been closed and
 // all of its buffered metrics have been sent to all outputs. The second
@@ -59,6 +62,7 @@ func (om *Manager) Start(samplesChan chan metrics.SampleContainer) (wait func(),
 		defer ticker.Stop()
 
 		buffer := make([]metrics.SampleContainer, 0, cap(samplesChan))
+		var runTags *RunTagsUpdate
 		for {
 			select {
 			case sampleContainer, ok := <-samplesChan:
@@ -66,6 +70,13 @@ func (om *Manager) Start(samplesChan chan metrics.SampleContainer) (wait func(),
 					sendToOutputs(buffer)
 					return
 				}
+				if update, isUpdate := sampleContainer.(*RunTagsUpdate); isUpdate {
+					runTags = update
+					continue
+				}
+				if runTags != nil {
+					sampleContainer = runTags.apply(sampleContainer)
+				}
 				buffer = append(buffer, sampleContainer)
 			case <-ticker.C:
 				sendToOutputs(buffer)
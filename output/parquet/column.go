@@ -0,0 +1,184 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// column buffers the values of a single leaf column for the current row group.
+//
+// The byte array columns are always dictionary encoded, since most of them
+// hold a few distinct values, like metric names or tags. Every other column is
+// plain encoded.
+type column struct {
+	path   []string
+	typ    physicalType
+	maxDef int
+	maxRep int
+
+	defLevels []int32
+	repLevels []int32
+	numLevels int
+
+	dictionary map[string]int32
+	dictValues []string
+	indices    []int32
+
+	plain []byte
+}
+
+func newColumn(typ physicalType, maxDef, maxRep int, path ...string) *column {
+	return &column{
+		path:       path,
+		typ:        typ,
+		maxDef:     maxDef,
+		maxRep:     maxRep,
+		dictionary: make(map[string]int32),
+	}
+}
+
+func (c *column) addLevels(def, rep int32) {
+	if c.maxDef > 0 {
+		c.defLevels = append(c.defLevels, def)
+	}
+	if c.maxRep > 0 {
+		c.repLevels = append(c.repLevels, rep)
+	}
+	c.numLevels++
+}
+
+func (c *column) addString(value string, def, rep int32) {
+	c.addLevels(def, rep)
+	index, ok := c.dictionary[value]
+	if !ok {
+		index = int32(len(c.dictValues)) //nolint:gosec
+		c.dictionary[value] = index
+		c.dictValues = append(c.dictValues, value)
+	}
+	c.indices = append(c.indices, index)
+}
+
+// addNull adds a level entry without a value, which is how both the missing
+// optional values and the empty repeated groups are represented.
+func (c *column) addNull(def, rep int32) {
+	c.addLevels(def, rep)
+}
+
+func (c *column) addInt64(value int64) {
+	c.addLevels(0, 0)
+	c.plain = binary.LittleEndian.AppendUint64(c.plain, uint64(value)) //nolint:gosec
+}
+
+func (c *column) addDouble(value float64) {
+	c.addLevels(0, 0)
+	c.plain = binary.LittleEndian.AppendUint64(c.plain, math.Float64bits(value))
+}
+
+// reset clears the buffered values, keeping the allocated memory for the next
+// row group.
+func (c *column) reset() {
+	c.defLevels = c.defLevels[:0]
+	c.repLevels = c.repLevels[:0]
+	c.numLevels = 0
+	c.dictionary = make(map[string]int32, len(c.dictValues))
+	c.dictValues = c.dictValues[:0]
+	c.indices = c.indices[:0]
+	c.plain = c.plain[:0]
+}
+
+// writeChunk writes the buffered values as a column chunk with a single data
+// page, preceded by the dictionary page for the dictionary encoded columns.
+func (c *column) writeChunk(w *offsetWriter, codec codec) (columnChunk, error) {
+	chunk := columnChunk{
+		FileOffset: w.offset,
+		MetaData: columnMetaData{
+			Type:         c.typ,
+			Encodings:    []encoding{encodingPlain, encodingRLE},
+			PathInSchema: c.path,
+			Codec:        codec.id,
+			NumValues:    int64(c.numLevels),
+		},
+	}
+	meta := &chunk.MetaData
+
+	dictionaryEncoded := len(c.dictValues) > 0
+	if dictionaryEncoded {
+		var dictionary []byte
+		for _, value := range c.dictValues {
+			dictionary = appendPlainByteArray(dictionary, value)
+		}
+		offset := w.offset
+		meta.DictionaryPageOffset = &offset
+		meta.Encodings = append(meta.Encodings, encodingRLEDictionary)
+		header := pageHeader{
+			Type:      pageDictionary,
+			NumValues: int32(len(c.dictValues)), //nolint:gosec
+			Encoding:  encodingPlain,
+		}
+		if err := writePage(w, codec, meta, header, dictionary); err != nil {
+			return chunk, err
+		}
+	}
+
+	var page []byte
+	if c.maxRep > 0 {
+		page = appendLevels(page, c.repLevels, c.maxRep)
+	}
+	if c.maxDef > 0 {
+		page = appendLevels(page, c.defLevels, c.maxDef)
+	}
+	header := pageHeader{Type: pageData, NumValues: int32(c.numLevels), Encoding: encodingPlain} //nolint:gosec
+	if dictionaryEncoded {
+		width := bitWidth(len(c.dictValues) - 1)
+		if width == 0 {
+			width = 1
+		}
+		page = append(page, byte(width))
+		page = appendHybrid(page, c.indices, width)
+		header.Encoding = encodingRLEDictionary
+	} else {
+		page = append(page, c.plain...)
+	}
+	meta.DataPageOffset = w.offset
+	if err := writePage(w, codec, meta, header, page); err != nil {
+		return chunk, err
+	}
+	return chunk, nil
+}
+
+func writePage(w *offsetWriter, codec codec, meta *columnMetaData, header pageHeader, page []byte) error {
+	compressed, err := codec.compress(page)
+	if err != nil {
+		return fmt.Errorf("couldn't compress a page of the %s column: %w", strings.Join(meta.PathInSchema, "."), err)
+	}
+	header.UncompressedPageSize = int32(len(page))     //nolint:gosec
+	header.CompressedPageSize = int32(len(compressed)) //nolint:gosec
+	var e thriftEncoder
+	encodedHeader := e.message(func() { header.encode(&e) })
+
+	if _, err := w.Write(encodedHeader); err != nil {
+		return err
+	}
+	if _, err := w.Write(compressed); err != nil {
+		return err
+	}
+	meta.TotalUncompressedSize += int64(len(encodedHeader) + len(page))
+	meta.TotalCompressedSize += int64(len(encodedHeader) + len(compressed))
+	return nil
+}
+
+// offsetWriter keeps track of the offset in the file, which is needed for the
+// metadata of the column chunks.
+type offsetWriter struct {
+	w      io.Writer
+	offset int64
+}
+
+func (ow *offsetWriter) Write(p []byte) (int, error) {
+	n, err := ow.w.Write(p)
+	ow.offset += int64(n)
+	return n, err
+}
@@ -0,0 +1,64 @@
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	compressionUncompressed = "uncompressed"
+	compressionSnappy       = "snappy"
+	compressionGzip         = "gzip"
+	compressionZstd         = "zstd"
+)
+
+// codec compresses the pages of the column chunks.
+type codec struct {
+	id       compressionCodec
+	compress func(src []byte) ([]byte, error)
+}
+
+func newCodec(name string) (codec, error) {
+	switch name {
+	case compressionUncompressed:
+		return codec{
+			id:       codecUncompressed,
+			compress: func(src []byte) ([]byte, error) { return src, nil },
+		}, nil
+	case compressionSnappy:
+		return codec{
+			id:       codecSnappy,
+			compress: func(src []byte) ([]byte, error) { return snappy.Encode(nil, src), nil },
+		}, nil
+	case compressionGzip:
+		return codec{id: codecGzip, compress: gzipCompress}, nil
+	case compressionZstd:
+		encoder, err := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			return codec{}, err
+		}
+		return codec{
+			id:       codecZstd,
+			compress: func(src []byte) ([]byte, error) { return encoder.EncodeAll(src, nil), nil },
+		}, nil
+	default:
+		return codec{}, fmt.Errorf("unsupported parquet compression %q, it should be one of %q, %q, %q or %q",
+			name, compressionUncompressed, compressionSnappy, compressionGzip, compressionZstd)
+	}
+}
+
+func gzipCompress(src []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(src); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,139 @@
+package parquet
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/mstoykov/envconfig"
+	"go.k6.io/k6/lib/types"
+)
+
+// Config is the config for the parquet output
+type Config struct {
+	FileName      null.String        `json:"fileName" envconfig:"K6_PARQUET_FILENAME"`
+	FlushInterval types.NullDuration `json:"flushInterval" envconfig:"K6_PARQUET_FLUSH_INTERVAL"`
+	RowGroupSize  null.Int           `json:"rowGroupSize" envconfig:"K6_PARQUET_ROW_GROUP_SIZE"`
+	Compression   null.String        `json:"compression" envconfig:"K6_PARQUET_COMPRESSION"`
+}
+
+// NewConfig creates a new Config instance with default values for some fields.
+func NewConfig() Config {
+	return Config{
+		FileName:      null.NewString("file.parquet", false),
+		FlushInterval: types.NewNullDuration(1*time.Second, false),
+		RowGroupSize:  null.NewInt(100000, false),
+		Compression:   null.NewString(compressionSnappy, false),
+	}
+}
+
+// Apply merges two configs by overwriting properties in the old config
+func (c Config) Apply(cfg Config) Config {
+	if cfg.FileName.Valid {
+		c.FileName = cfg.FileName
+	}
+	if cfg.FlushInterval.Valid {
+		c.FlushInterval = cfg.FlushInterval
+	}
+	if cfg.RowGroupSize.Valid {
+		c.RowGroupSize = cfg.RowGroupSize
+	}
+	if cfg.Compression.Valid {
+		c.Compression = cfg.Compression
+	}
+	return c
+}
+
+// Validate checks that the config can be used for writing a parquet file.
+func (c Config) Validate() error {
+	if c.FileName.String == "" || c.FileName.String == "-" {
+		return fmt.Errorf("the parquet output can only write to a file, got %q as the file name", c.FileName.String)
+	}
+	if c.FlushInterval.Duration <= 0 {
+		return fmt.Errorf("the parquet flush interval should be positive, got %s", c.FlushInterval)
+	}
+	if c.RowGroupSize.Int64 <= 0 {
+		return fmt.Errorf("the parquet row group size should be positive, got %d", c.RowGroupSize.Int64)
+	}
+	if _, err := newCodec(c.Compression.String); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ParseArg takes an arg string and converts it to a config
+func ParseArg(arg string) (Config, error) {
+	c := NewConfig()
+
+	if !strings.Contains(arg, "=") {
+		c.FileName = null.StringFrom(arg)
+		return c, nil
+	}
+
+	pairs := strings.Split(arg, ",")
+	for _, pair := range pairs {
+		r := strings.SplitN(pair, "=", 2)
+		if len(r) != 2 {
+			return c, fmt.Errorf("couldn't parse %q as argument for parquet output", arg)
+		}
+		switch r[0] {
+		case "fileName":
+			c.FileName = null.StringFrom(r[1])
+		case "flushInterval":
+			err := c.FlushInterval.UnmarshalText([]byte(r[1]))
+			if err != nil {
+				return c, err
+			}
+		case "rowGroupSize":
+			size, err := strconv.ParseInt(r[1], 10, 64)
+			if err != nil {
+				return c, fmt.Errorf("couldn't parse %q as the parquet row group size: %w", r[1], err)
+			}
+			c.RowGroupSize = null.IntFrom(size)
+		case "compression":
+			c.Compression = null.StringFrom(r[1])
+		default:
+			return c, fmt.Errorf("unknown key %q as argument for parquet output", r[0])
+		}
+	}
+
+	return c, nil
+}
+
+// GetConsolidatedConfig combines {default config values + JSON config +
+// environment vars + arg config values}, and returns the final result.
+func GetConsolidatedConfig(
+	jsonRawConf json.RawMessage, env map[string]string, arg string,
+) (Config, error) {
+	result := NewConfig()
+	if jsonRawConf != nil {
+		jsonConf := Config{}
+		if err := json.Unmarshal(jsonRawConf, &jsonConf); err != nil {
+			return result, err
+		}
+		result = result.Apply(jsonConf)
+	}
+
+	envConfig := Config{}
+	if err := envconfig.Process("", &envConfig, func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}); err != nil {
+		return result, err
+	}
+	result = result.Apply(envConfig)
+
+	if arg != "" {
+		argConf, err := ParseArg(arg)
+		if err != nil {
+			return result, err
+		}
+		result = result.Apply(argConf)
+	}
+
+	return result, result.Validate()
+}
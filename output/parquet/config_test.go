@@ -0,0 +1,101 @@
+package parquet
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+func TestNewConfig(t *testing.T) {
+	t.Parallel()
+
+	config := NewConfig()
+	assert.Equal(t, "file.parquet", config.FileName.String)
+	assert.Equal(t, "1s", config.FlushInterval.String())
+	assert.Equal(t, int64(100000), config.RowGroupSize.Int64)
+	assert.Equal(t, "snappy", config.Compression.String)
+	assert.NoError(t, config.Validate())
+}
+
+func TestParseArg(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		config      Config
+		expectedErr bool
+	}{
+		"results.parquet": {
+			config: Config{
+				FileName:      null.StringFrom("results.parquet"),
+				FlushInterval: types.NewNullDuration(1*time.Second, false),
+				RowGroupSize:  null.NewInt(100000, false),
+				Compression:   null.NewString("snappy", false),
+			},
+		},
+		"fileName=results.parquet,flushInterval=5s,rowGroupSize=1000,compression=zstd": {
+			config: Config{
+				FileName:      null.StringFrom("results.parquet"),
+				FlushInterval: types.NullDurationFrom(5 * time.Second),
+				RowGroupSize:  null.IntFrom(1000),
+				Compression:   null.StringFrom("zstd"),
+			},
+		},
+		"rowGroupSize=many": {
+			expectedErr: true,
+		},
+		"filename=results.parquet": {
+			expectedErr: true,
+		},
+	}
+
+	for arg, testCase := range cases {
+		arg := arg
+		testCase := testCase
+
+		t.Run(arg, func(t *testing.T) {
+			t.Parallel()
+
+			config, err := ParseArg(arg)
+			if testCase.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, testCase.config, config)
+		})
+	}
+}
+
+func TestGetConsolidatedConfig(t *testing.T) {
+	t.Parallel()
+
+	config, err := GetConsolidatedConfig(
+		json.RawMessage(`{"fileName":"json.parquet","rowGroupSize":10,"compression":"gzip"}`),
+		map[string]string{"K6_PARQUET_ROW_GROUP_SIZE": "20", "K6_PARQUET_FLUSH_INTERVAL": "3s"},
+		"arg.parquet",
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "arg.parquet", config.FileName.String)
+	assert.Equal(t, int64(20), config.RowGroupSize.Int64)
+	assert.Equal(t, 3*time.Second, config.FlushInterval.TimeDuration())
+	assert.Equal(t, "gzip", config.Compression.String)
+
+	invalid := map[string]string{
+		"-":                   "the parquet output can only write to a file",
+		"rowGroupSize=0":      "the parquet row group size should be positive",
+		"flushInterval=0s":    "the parquet flush interval should be positive",
+		"compression=brotli":  `unsupported parquet compression "brotli"`,
+		"fileName=a,unknown=": `unknown key "unknown"`,
+	}
+	for arg, expectedErr := range invalid {
+		_, err := GetConsolidatedConfig(nil, nil, arg)
+		assert.ErrorContains(t, err, expectedErr, arg)
+	}
+}
@@ -0,0 +1,6 @@
+/*
+Package parquet implements an output writing metrics in the Apache Parquet
+columnar format, so the results of a test run can be analyzed with the usual
+data tooling without any further conversion.
+*/
+package parquet
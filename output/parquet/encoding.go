@@ -0,0 +1,92 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// bitWidth returns the number of bits needed for encoding values up to maxValue.
+func bitWidth(maxValue int) int {
+	return bits.Len(uint(maxValue))
+}
+
+// appendHybrid appends the values with the RLE/bit-packing hybrid encoding,
+// which is used for the repetition and definition levels and for the
+// dictionary indices. Runs of at least 8 repeated values are RLE encoded and
+// everything else is bit-packed in groups of 8 values.
+func appendHybrid(dst []byte, values []int32, width int) []byte {
+	runLength := func(i int) int {
+		j := i + 1
+		for j < len(values) && values[j] == values[i] {
+			j++
+		}
+		return j - i
+	}
+
+	for i := 0; i < len(values); {
+		if run := runLength(i); run >= 8 {
+			dst = binary.AppendUvarint(dst, uint64(run)<<1)
+			for b := 0; b < (width+7)/8; b++ {
+				dst = append(dst, byte(values[i]>>(8*b)))
+			}
+			i += run
+			continue
+		}
+
+		// Bit-pack groups of 8 values, until a long enough run starts
+		start := i
+		for i < len(values) {
+			i += 8
+			if i >= len(values) || runLength(i) >= 8 {
+				break
+			}
+		}
+		if i > len(values) {
+			i = len(values)
+		}
+		groups := (i - start + 7) / 8
+		dst = binary.AppendUvarint(dst, uint64(groups)<<1|1)
+		dst = appendBitPacked(dst, values[start:i], groups*8, width)
+	}
+	return dst
+}
+
+// appendBitPacked packs count values from the least significant bit, padding
+// the missing values with zeros.
+func appendBitPacked(dst []byte, values []int32, count, width int) []byte {
+	var acc uint64
+	accBits := 0
+	for i := 0; i < count; i++ {
+		var v uint64
+		if i < len(values) {
+			v = uint64(values[i]) //nolint:gosec
+		}
+		acc |= v << accBits
+		accBits += width
+		for accBits >= 8 {
+			dst = append(dst, byte(acc))
+			acc >>= 8
+			accBits -= 8
+		}
+	}
+	if accBits > 0 {
+		dst = append(dst, byte(acc))
+	}
+	return dst
+}
+
+// appendLevels appends the levels in the format of the v1 data pages, where
+// the hybrid encoded data is prefixed with its length.
+func appendLevels(dst []byte, levels []int32, maxLevel int) []byte {
+	lengthAt := len(dst)
+	dst = append(dst, 0, 0, 0, 0)
+	dst = appendHybrid(dst, levels, bitWidth(maxLevel))
+	binary.LittleEndian.PutUint32(dst[lengthAt:], uint32(len(dst)-lengthAt-4)) //nolint:gosec
+	return dst
+}
+
+// appendPlainByteArray appends a byte array value with the plain encoding.
+func appendPlainByteArray(dst []byte, value string) []byte {
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(len(value))) //nolint:gosec
+	return append(dst, value...)
+}
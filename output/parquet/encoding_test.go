@@ -0,0 +1,43 @@
+package parquet
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendHybrid(t *testing.T) {
+	t.Parallel()
+
+	// The example from the parquet encodings specification
+	assert.Equal(t, []byte{0x03, 0x88, 0xc6, 0xfa}, appendHybrid(nil, []int32{0, 1, 2, 3, 4, 5, 6, 7}, 3))
+	assert.Equal(t, []byte{0x14, 0x01}, appendHybrid(nil, []int32{1, 1, 1, 1, 1, 1, 1, 1, 1, 1}, 1))
+
+	long := make([]int32, 1000)
+	for i := range long {
+		long[i] = int32(i / 30 % 2)
+	}
+	mixed := []int32{5, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 1, 2, 0, 7, 7, 7, 7, 7, 7, 7, 7, 4}
+
+	tests := []struct {
+		values []int32
+		width  int
+	}{
+		{values: []int32{}, width: 1},
+		{values: []int32{1}, width: 1},
+		{values: []int32{0, 1, 0, 1, 1}, width: 1},
+		{values: mixed, width: 3},
+		{values: long, width: 1},
+		{values: []int32{300, 300, 1, 2, 1000, 300, 300, 300, 300, 300, 300, 300, 300, 300}, width: 10},
+	}
+	for i, tc := range tests {
+		tc := tc
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			t.Parallel()
+
+			encoded := appendHybrid(nil, tc.values, tc.width)
+			assert.Equal(t, tc.values, decodeHybrid(t, encoded, tc.width, len(tc.values)))
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package parquet
+
+// The structures of the parquet file format that are used by the output, with
+// the same field IDs as in the parquet.thrift definition of the format.
+// See https://github.com/apache/parquet-format for the full specification.
+
+const magic = "PAR1"
+
+type physicalType int32
+
+const (
+	typeInt64     physicalType = 2
+	typeDouble    physicalType = 5
+	typeByteArray physicalType = 6
+)
+
+type repetitionType int32
+
+const (
+	repetitionRequired repetitionType = 0
+	repetitionOptional repetitionType = 1
+	repetitionRepeated repetitionType = 2
+)
+
+type convertedType int32
+
+const (
+	convertedUTF8            convertedType = 0
+	convertedMap             convertedType = 1
+	convertedTimestampMicros convertedType = 10
+)
+
+type encoding int32
+
+const (
+	encodingPlain         encoding = 0
+	encodingRLE           encoding = 3
+	encodingRLEDictionary encoding = 8
+)
+
+type compressionCodec int32
+
+const (
+	codecUncompressed compressionCodec = 0
+	codecSnappy       compressionCodec = 1
+	codecGzip         compressionCodec = 2
+	codecZstd         compressionCodec = 6
+)
+
+type pageType int32
+
+const (
+	pageData       pageType = 0
+	pageDictionary pageType = 2
+)
+
+// logicalType is the union of the logical types, only one of which is set.
+type logicalType struct {
+	String          bool
+	Map             bool
+	TimestampMicros bool
+}
+
+func (lt logicalType) encode(e *thriftEncoder) {
+	switch {
+	case lt.String:
+		e.structField(1, func() {})
+	case lt.Map:
+		e.structField(2, func() {})
+	case lt.TimestampMicros:
+		e.structField(8, func() {
+			e.boolField(1, true) // isAdjustedToUTC
+			e.structField(2, func() {
+				e.structField(2, func() {}) // MICROS
+			})
+		})
+	}
+}
+
+type schemaElement struct {
+	Name        string
+	Type        *physicalType
+	Repetition  *repetitionType
+	NumChildren int32
+	Converted   *convertedType
+	Logical     *logicalType
+}
+
+func (se schemaElement) encode(e *thriftEncoder) {
+	if se.Type != nil {
+		e.i32Field(1, int32(*se.Type))
+	}
+	if se.Repetition != nil {
+		e.i32Field(3, int32(*se.Repetition))
+	}
+	e.stringField(4, se.Name)
+	if se.NumChildren > 0 {
+		e.i32Field(5, se.NumChildren)
+	}
+	if se.Converted != nil {
+		e.i32Field(6, int32(*se.Converted))
+	}
+	if se.Logical != nil {
+		e.structField(10, func() { se.Logical.encode(e) })
+	}
+}
+
+type keyValue struct {
+	Key   string
+	Value string
+}
+
+type columnMetaData struct {
+	Type                  physicalType
+	Encodings             []encoding
+	PathInSchema          []string
+	Codec                 compressionCodec
+	NumValues             int64
+	TotalUncompressedSize int64
+	TotalCompressedSize   int64
+	DataPageOffset        int64
+	DictionaryPageOffset  *int64
+}
+
+func (cm columnMetaData) encode(e *thriftEncoder) {
+	e.i32Field(1, int32(cm.Type))
+	encodings := make([]int32, len(cm.Encodings))
+	for i, enc := range cm.Encodings {
+		encodings[i] = int32(enc)
+	}
+	e.i32ListField(2, encodings)
+	e.stringListField(3, cm.PathInSchema)
+	e.i32Field(4, int32(cm.Codec))
+	e.i64Field(5, cm.NumValues)
+	e.i64Field(6, cm.TotalUncompressedSize)
+	e.i64Field(7, cm.TotalCompressedSize)
+	e.i64Field(9, cm.DataPageOffset)
+	if cm.DictionaryPageOffset != nil {
+		e.i64Field(11, *cm.DictionaryPageOffset)
+	}
+}
+
+type columnChunk struct {
+	FileOffset int64
+	MetaData   columnMetaData
+}
+
+func (cc columnChunk) encode(e *thriftEncoder) {
+	e.i64Field(2, cc.FileOffset)
+	e.structField(3, func() { cc.MetaData.encode(e) })
+}
+
+type rowGroup struct {
+	Columns             []columnChunk
+	TotalByteSize       int64
+	NumRows             int64
+	TotalCompressedSize int64
+}
+
+func (rg rowGroup) encode(e *thriftEncoder) {
+	e.listField(1, thriftStruct, len(rg.Columns))
+	for _, column := range rg.Columns {
+		e.structValue(func() { column.encode(e) })
+	}
+	e.i64Field(2, rg.TotalByteSize)
+	e.i64Field(3, rg.NumRows)
+	e.i64Field(6, rg.TotalCompressedSize)
+}
+
+type fileMetaData struct {
+	Version          int32
+	Schema           []schemaElement
+	NumRows          int64
+	RowGroups        []rowGroup
+	KeyValueMetadata []keyValue
+	CreatedBy        string
+}
+
+func (fm fileMetaData) encode(e *thriftEncoder) {
+	e.i32Field(1, fm.Version)
+	e.listField(2, thriftStruct, len(fm.Schema))
+	for _, element := range fm.Schema {
+		e.structValue(func() { element.encode(e) })
+	}
+	e.i64Field(3, fm.NumRows)
+	e.listField(4, thriftStruct, len(fm.RowGroups))
+	for _, group := range fm.RowGroups {
+		e.structValue(func() { group.encode(e) })
+	}
+	if len(fm.KeyValueMetadata) > 0 {
+		e.listField(5, thriftStruct, len(fm.KeyValueMetadata))
+		for _, kv := range fm.KeyValueMetadata {
+			e.structValue(func() {
+				e.stringField(1, kv.Key)
+				e.stringField(2, kv.Value)
+			})
+		}
+	}
+	e.stringField(6, fm.CreatedBy)
+}
+
+type pageHeader struct {
+	Type                 pageType
+	UncompressedPageSize int32
+	CompressedPageSize   int32
+
+	// NumValues and Encoding are used for both the data and the dictionary
+	// page headers, the levels are always RLE encoded.
+	NumValues int32
+	Encoding  encoding
+}
+
+func (ph pageHeader) encode(e *thriftEncoder) {
+	e.i32Field(1, int32(ph.Type))
+	e.i32Field(2, ph.UncompressedPageSize)
+	e.i32Field(3, ph.CompressedPageSize)
+	switch ph.Type {
+	case pageData:
+		e.structField(5, func() {
+			e.i32Field(1, ph.NumValues)
+			e.i32Field(2, int32(ph.Encoding))
+			e.i32Field(3, int32(encodingRLE))
+			e.i32Field(4, int32(encodingRLE))
+		})
+	case pageDictionary:
+		e.structField(7, func() {
+			e.i32Field(1, ph.NumValues)
+			e.i32Field(2, int32(ph.Encoding))
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+// The keys of the file metadata entries written by the output.
+const (
+	metadataKeyThresholds = "k6.thresholds"
+	metadataKeyOptions    = "k6.options"
+)
+
+// Output implements the output.Output interface for saving to parquet files.
+type Output struct {
+	output.SampleBuffer
+
+	periodicFlusher *output.PeriodicFlusher
+
+	logger        logrus.FieldLogger
+	fname         string
+	flushInterval time.Duration
+	writer        *writer
+	writerLock    sync.Mutex
+
+	thresholds map[string]metrics.Thresholds
+	options    lib.Options
+	archive    *lib.Archive
+}
+
+var (
+	_ output.WithThresholds = &Output{}
+	_ output.WithArchive    = &Output{}
+)
+
+// New creates a new instance of the parquet output.
+func New(params output.Params) (output.Output, error) {
+	return newOutput(params)
+}
+
+func newOutput(params output.Params) (*Output, error) {
+	config, err := GetConsolidatedConfig(params.JSONConfig, params.Environment, params.ConfigArgument)
+	if err != nil {
+		return nil, err
+	}
+	codec, err := newCodec(config.Compression.String)
+	if err != nil {
+		return nil, err
+	}
+	indexedTags, err := buildIndexedTags(params)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := params.FS.Create(config.FileName.String)
+	if err != nil {
+		return nil, err
+	}
+	w, err := newWriter(file, codec, int(config.RowGroupSize.Int64), indexedTags)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return &Output{
+		logger: params.Logger.WithFields(logrus.Fields{
+			"output":   "parquet",
+			"filename": config.FileName.String,
+		}),
+		fname:         config.FileName.String,
+		flushInterval: config.FlushInterval.TimeDuration(),
+		writer:        w,
+		options:       params.ScriptOptions,
+	}, nil
+}
+
+// buildIndexedTags returns the enabled system tags, which have their own
+// columns instead of being written with the rest of the tags.
+func buildIndexedTags(params output.Params) ([]string, error) {
+	indexedTags := []string{}
+	for tag := range params.ScriptOptions.SystemTags.Map() {
+		systemTag, err := metrics.SystemTagString(tag)
+		if err != nil {
+			return nil, err
+		}
+
+		// The non-indexable system tags, like vu and iter, are part of the
+		// sample metadata, so they are written in the metadata column
+		if metrics.NonIndexableSystemTags.Has(systemTag) {
+			continue
+		}
+		indexedTags = append(indexedTags, tag)
+	}
+	sort.Strings(indexedTags)
+
+	return indexedTags, nil
+}
+
+// Description returns a human-readable description of the output.
+func (o *Output) Description() string {
+	return fmt.Sprintf("parquet (%s)", o.fname)
+}
+
+// SetThresholds receives the thresholds, which are recorded in the file
+// metadata.
+func (o *Output) SetThresholds(thresholds map[string]metrics.Thresholds) {
+	o.thresholds = thresholds
+}
+
+// SetArchive receives the test archive, whose options are recorded in the
+// file metadata. It's only called when the archive is going to be uploaded,
+// otherwise the consolidated script options are recorded instead.
+func (o *Output) SetArchive(archive *lib.Archive) {
+	o.archive = archive
+}
+
+// Start starts a new output.PeriodicFlusher, which writes the buffered
+// samples to the file.
+func (o *Output) Start() error {
+	o.logger.Debug("Starting...")
+	pf, err := output.NewPeriodicFlusher(o.flushInterval, o.flushMetrics)
+	if err != nil {
+		return err
+	}
+	o.logger.Debug("Started!")
+	o.periodicFlusher = pf
+	return nil
+}
+
+// Stop flushes any remaining metrics and writes the file footer.
+func (o *Output) Stop() error {
+	o.logger.Debug("Stopping...")
+	defer o.logger.Debug("Stopped!")
+	o.periodicFlusher.Stop()
+
+	// The file is completed even if the metadata couldn't be encoded, so the
+	// samples aren't lost
+	metadata, metadataErr := o.fileMetadata()
+
+	o.writerLock.Lock()
+	defer o.writerLock.Unlock()
+	if err := o.writer.close(metadata); err != nil {
+		return err
+	}
+	return metadataErr
+}
+
+func (o *Output) fileMetadata() ([]keyValue, error) {
+	var metadata []keyValue
+	if len(o.thresholds) > 0 {
+		thresholds, err := marshalMetadata(o.thresholds)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't encode the thresholds for the parquet metadata: %w", err)
+		}
+		metadata = append(metadata, keyValue{Key: metadataKeyThresholds, Value: thresholds})
+	}
+
+	scriptOptions := o.options
+	if o.archive != nil {
+		scriptOptions = o.archive.Options
+	}
+	options, err := marshalMetadata(scriptOptions)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't encode the options for the parquet metadata: %w", err)
+	}
+	metadata = append(metadata, keyValue{Key: metadataKeyOptions, Value: options})
+	return metadata, nil
+}
+
+// marshalMetadata encodes the value as JSON, without escaping the characters
+// that are common in the thresholds, like < and >.
+func marshalMetadata(v any) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+func (o *Output) flushMetrics() {
+	samples := o.GetBufferedSamples()
+	if len(samples) == 0 {
+		return
+	}
+
+	o.writerLock.Lock()
+	defer o.writerLock.Unlock()
+	for _, sc := range samples {
+		for _, sample := range sc.GetSamples() {
+			if err := o.writer.writeSample(sample); err != nil {
+				o.logger.WithError(err).Error("Parquet: Error writing to file")
+				return
+			}
+		}
+	}
+}
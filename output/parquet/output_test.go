@@ -0,0 +1,172 @@
+package parquet
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/fsext"
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+func TestOutput(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	reqs, err := registry.NewMetric("http_reqs", metrics.Counter)
+	require.NoError(t, err)
+	duration, err := registry.NewMetric("http_req_duration", metrics.Trend, metrics.Time)
+	require.NoError(t, err)
+
+	start := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	samples := []metrics.SampleContainer{
+		metrics.Samples{
+			{
+				TimeSeries: metrics.TimeSeries{
+					Metric: reqs,
+					Tags: registry.RootTagSet().WithTagsFromMap(map[string]string{
+						"name": "home", "status": "200", "custom": "a",
+					}),
+				},
+				Time:     start,
+				Metadata: map[string]string{"vu": "1", "iter": "0"},
+				Value:    1,
+			},
+			{
+				TimeSeries: metrics.TimeSeries{
+					Metric: duration,
+					Tags:   registry.RootTagSet().WithTagsFromMap(map[string]string{"status": "200"}),
+				},
+				Time:  start.Add(1500 * time.Microsecond),
+				Value: 123.5,
+			},
+		},
+		metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: reqs,
+				Tags: registry.RootTagSet().WithTagsFromMap(map[string]string{
+					"name": "home", "custom": "b",
+				}),
+			},
+			Time:  start.Add(time.Second),
+			Value: 1,
+		},
+	}
+
+	for _, compression := range []string{"uncompressed", "snappy", "gzip", "zstd"} {
+		compression := compression
+
+		t.Run(compression, func(t *testing.T) {
+			t.Parallel()
+
+			fs := fsext.NewMemMapFs()
+			out, err := newOutput(output.Params{
+				Logger:         testutils.NewLogger(t),
+				FS:             fs,
+				ConfigArgument: "fileName=results.parquet,rowGroupSize=2,compression=" + compression,
+				ScriptOptions: lib.Options{
+					SystemTags: metrics.NewSystemTagSet(
+						metrics.TagName | metrics.TagStatus | metrics.TagVU | metrics.TagIter,
+					),
+				},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, "parquet (results.parquet)", out.Description())
+
+			thresholds := metrics.NewThresholds([]string{"rate<0.01"})
+			out.SetThresholds(map[string]metrics.Thresholds{"http_reqs": thresholds})
+			out.SetArchive(&lib.Archive{Options: lib.Options{VUs: null.IntFrom(5)}})
+
+			require.NoError(t, out.Start())
+			out.AddMetricSamples(samples)
+			require.NoError(t, out.Stop())
+
+			data, err := fsext.ReadFile(fs, "results.parquet")
+			require.NoError(t, err)
+			file := readTestFile(t, data)
+
+			assert.Equal(t, int64(3), file.numRows())
+			assert.Len(t, file.rowGroups(), 2)
+			assert.Equal(t, []string{
+				"k6", "metric_name", "metric_type", "timestamp", "value", "name", "status",
+				"tags", "key_value", "key", "value", "metadata", "key_value", "key", "value",
+			}, file.schemaNames())
+			assert.Equal(t, map[string]string{
+				"k6.thresholds": `{"http_reqs":["rate<0.01"]}`,
+				"k6.options":    mustMarshalOptions(t, lib.Options{VUs: null.IntFrom(5)}),
+			}, file.keyValueMetadata())
+
+			assert.Equal(t, []testValue{
+				{value: "http_reqs"}, {value: "http_req_duration"}, {value: "http_reqs"},
+			}, file.column("metric_name", 0, 0))
+			assert.Equal(t, []testValue{
+				{value: "counter"}, {value: "trend"}, {value: "counter"},
+			}, file.column("metric_type", 0, 0))
+			assert.Equal(t, []testValue{
+				{value: start.UnixMicro()},
+				{value: start.UnixMicro() + 1500},
+				{value: start.Add(time.Second).UnixMicro()},
+			}, file.column("timestamp", 0, 0))
+			assert.Equal(t, []testValue{
+				{value: 1.0}, {value: 123.5}, {value: 1.0},
+			}, file.column("value", 0, 0))
+			assert.Equal(t, []testValue{
+				{def: 1, value: "home"}, {}, {def: 1, value: "home"},
+			}, file.column("name", 1, 0))
+			assert.Equal(t, []testValue{
+				{def: 1, value: "200"}, {def: 1, value: "200"}, {},
+			}, file.column("status", 1, 0))
+
+			assert.Equal(t, []testValue{
+				{def: 1, value: "custom"}, {}, {def: 1, value: "custom"},
+			}, file.column("tags.key_value.key", 1, 1))
+			assert.Equal(t, []testValue{
+				{def: 1, value: "a"}, {}, {def: 1, value: "b"},
+			}, file.column("tags.key_value.value", 1, 1))
+			assert.Equal(t, []testValue{
+				{def: 1, value: "iter"}, {rep: 1, def: 1, value: "vu"}, {}, {},
+			}, file.column("metadata.key_value.key", 1, 1))
+			assert.Equal(t, []testValue{
+				{def: 1, value: "0"}, {rep: 1, def: 1, value: "1"}, {}, {},
+			}, file.column("metadata.key_value.value", 1, 1))
+		})
+	}
+}
+
+func TestOutputWithoutSamples(t *testing.T) {
+	t.Parallel()
+
+	fs := fsext.NewMemMapFs()
+	out, err := newOutput(output.Params{
+		Logger:         testutils.NewLogger(t),
+		FS:             fs,
+		ConfigArgument: "empty.parquet",
+		ScriptOptions:  lib.Options{SystemTags: &metrics.DefaultSystemTagSet},
+	})
+	require.NoError(t, err)
+	require.NoError(t, out.Start())
+	require.NoError(t, out.Stop())
+
+	data, err := fsext.ReadFile(fs, "empty.parquet")
+	require.NoError(t, err)
+	file := readTestFile(t, data)
+	assert.Equal(t, int64(0), file.numRows())
+	assert.Empty(t, file.rowGroups())
+	assert.Equal(t, map[string]string{
+		"k6.options": mustMarshalOptions(t, lib.Options{SystemTags: &metrics.DefaultSystemTagSet}),
+	}, file.keyValueMetadata())
+}
+
+func mustMarshalOptions(t *testing.T, options lib.Options) string {
+	t.Helper()
+	data, err := json.Marshal(options)
+	require.NoError(t, err)
+	return string(data)
+}
@@ -0,0 +1,306 @@
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+)
+
+// A minimal parquet reader, which is only able to read the files written by
+// the output, so they can be checked by the tests.
+
+// thriftDecoder decodes the thrift compact protocol into generic values, with
+// the structs decoded as maps by field ID, the lists as slices, the integers
+// as int64 and the binaries as strings.
+type thriftDecoder struct {
+	t   *testing.T
+	buf []byte
+	pos int
+}
+
+func (d *thriftDecoder) uvarint() uint64 {
+	v, n := binary.Uvarint(d.buf[d.pos:])
+	require.Positive(d.t, n)
+	d.pos += n
+	return v
+}
+
+func (d *thriftDecoder) zigzag() int64 {
+	u := d.uvarint()
+	return int64(u>>1) ^ -int64(u&1) //nolint:gosec
+}
+
+func (d *thriftDecoder) value(typ byte) any {
+	switch typ {
+	case thriftI32, thriftI64:
+		return d.zigzag()
+	case thriftBinary:
+		n := int(d.uvarint()) //nolint:gosec
+		s := string(d.buf[d.pos : d.pos+n])
+		d.pos += n
+		return s
+	case thriftList:
+		header := d.buf[d.pos]
+		d.pos++
+		size := int(header >> 4)
+		if size == 15 {
+			size = int(d.uvarint()) //nolint:gosec
+		}
+		list := make([]any, size)
+		for i := range list {
+			list[i] = d.value(header & 0x0f)
+		}
+		return list
+	case thriftStruct:
+		return d.structValue()
+	default:
+		d.t.Fatalf("unexpected thrift type %d", typ)
+		return nil
+	}
+}
+
+func (d *thriftDecoder) structValue() map[int16]any {
+	fields := make(map[int16]any)
+	var last int16
+	for {
+		header := d.buf[d.pos]
+		d.pos++
+		if header == 0 {
+			return fields
+		}
+		typ := header & 0x0f
+		if delta := int16(header >> 4); delta != 0 {
+			last += delta
+		} else {
+			last = int16(d.zigzag())
+		}
+		switch typ {
+		case thriftBoolTrue, thriftBoolFalse:
+			fields[last] = typ == thriftBoolTrue
+		default:
+			fields[last] = d.value(typ)
+		}
+	}
+}
+
+type testFile struct {
+	t    *testing.T
+	data []byte
+	meta map[int16]any
+}
+
+func readTestFile(t *testing.T, data []byte) testFile {
+	t.Helper()
+	require.True(t, bytes.HasPrefix(data, []byte(magic)))
+	require.True(t, bytes.HasSuffix(data, []byte(magic)))
+	footerLength := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footerStart := len(data) - 8 - footerLength
+	d := &thriftDecoder{t: t, buf: data[footerStart : len(data)-8]}
+	meta := d.structValue()
+	require.Equal(t, footerLength, d.pos)
+	return testFile{t: t, data: data, meta: meta}
+}
+
+func (f testFile) numRows() int64 {
+	return f.meta[3].(int64) //nolint:forcetypeassert
+}
+
+func (f testFile) rowGroups() []any {
+	return f.meta[4].([]any) //nolint:forcetypeassert
+}
+
+func (f testFile) schemaNames() []string {
+	var names []string
+	for _, element := range f.meta[2].([]any) { //nolint:forcetypeassert
+		names = append(names, element.(map[int16]any)[4].(string)) //nolint:forcetypeassert
+	}
+	return names
+}
+
+func (f testFile) keyValueMetadata() map[string]string {
+	metadata := make(map[string]string)
+	list, _ := f.meta[5].([]any)
+	for _, kv := range list {
+		kv := kv.(map[int16]any)                  //nolint:forcetypeassert
+		metadata[kv[1].(string)] = kv[2].(string) //nolint:forcetypeassert
+	}
+	return metadata
+}
+
+type testValue struct {
+	rep, def int32
+	value    any
+}
+
+// column reads all of the values of the column with the given path from
+// every row group.
+func (f testFile) column(path string, maxDef, maxRep int) []testValue {
+	var values []testValue
+	for _, group := range f.rowGroups() {
+		found := false
+		for _, chunk := range group.(map[int16]any)[1].([]any) { //nolint:forcetypeassert
+			meta := chunk.(map[int16]any)[3].(map[int16]any) //nolint:forcetypeassert
+			var chunkPath []string
+			for _, p := range meta[3].([]any) { //nolint:forcetypeassert
+				chunkPath = append(chunkPath, p.(string)) //nolint:forcetypeassert
+			}
+			if strings.Join(chunkPath, ".") != path {
+				continue
+			}
+			found = true
+			values = append(values, f.readChunk(meta, maxDef, maxRep)...)
+		}
+		require.True(f.t, found, "column %s not found", path)
+	}
+	return values
+}
+
+func (f testFile) readPage(offset int64, codec int64) (map[int16]any, []byte) {
+	d := &thriftDecoder{t: f.t, buf: f.data[offset:]}
+	header := d.structValue()
+	start := int(offset) + d.pos
+	compressed := f.data[start : start+int(header[3].(int64))] //nolint:forcetypeassert
+
+	var page []byte
+	var err error
+	switch compressionCodec(codec) {
+	case codecUncompressed:
+		page = compressed
+	case codecSnappy:
+		page, err = snappy.Decode(nil, compressed)
+	case codecGzip:
+		var r *gzip.Reader
+		r, err = gzip.NewReader(bytes.NewReader(compressed))
+		require.NoError(f.t, err)
+		page, err = io.ReadAll(r)
+	case codecZstd:
+		var r *zstd.Decoder
+		r, err = zstd.NewReader(nil)
+		require.NoError(f.t, err)
+		page, err = r.DecodeAll(compressed, nil)
+	}
+	require.NoError(f.t, err)
+	require.Len(f.t, page, int(header[2].(int64))) //nolint:forcetypeassert
+	return header, page
+}
+
+func (f testFile) readChunk(meta map[int16]any, maxDef, maxRep int) []testValue {
+	typ := physicalType(meta[1].(int64)) //nolint:forcetypeassert
+	codec := meta[4].(int64)             //nolint:forcetypeassert
+
+	var dictionary []any
+	if dictionaryOffset, ok := meta[11].(int64); ok {
+		header, page := f.readPage(dictionaryOffset, codec)
+		dictionaryHeader := header[7].(map[int16]any) //nolint:forcetypeassert
+		require.Equal(f.t, int64(encodingPlain), dictionaryHeader[2])
+		dictionary = decodePlain(f.t, page, typ, int(dictionaryHeader[1].(int64))) //nolint:forcetypeassert
+	}
+
+	header, page := f.readPage(meta[9].(int64), codec) //nolint:forcetypeassert
+	dataHeader := header[5].(map[int16]any)            //nolint:forcetypeassert
+	numValues := int(dataHeader[1].(int64))            //nolint:forcetypeassert
+	require.Equal(f.t, meta[5], int64(numValues))
+
+	readLevels := func(maxLevel int) []int32 {
+		levels := make([]int32, numValues)
+		if maxLevel == 0 {
+			return levels
+		}
+		length := int(binary.LittleEndian.Uint32(page))
+		levels = decodeHybrid(f.t, page[4:4+length], bitWidth(maxLevel), numValues)
+		page = page[4+length:]
+		return levels
+	}
+	repLevels := readLevels(maxRep)
+	defLevels := readLevels(maxDef)
+
+	nonNull := 0
+	for _, def := range defLevels {
+		if int(def) == maxDef {
+			nonNull++
+		}
+	}
+	var decoded []any
+	switch encoding(dataHeader[2].(int64)) { //nolint:forcetypeassert
+	case encodingRLEDictionary:
+		indices := decodeHybrid(f.t, page[1:], int(page[0]), nonNull)
+		for _, index := range indices {
+			decoded = append(decoded, dictionary[index])
+		}
+	case encodingPlain:
+		decoded = decodePlain(f.t, page, typ, nonNull)
+	default:
+		f.t.Fatalf("unexpected encoding %v", dataHeader[2])
+	}
+
+	values := make([]testValue, numValues)
+	for i := range values {
+		values[i] = testValue{rep: repLevels[i], def: defLevels[i]}
+		if int(defLevels[i]) == maxDef {
+			values[i].value, decoded = decoded[0], decoded[1:]
+		}
+	}
+	return values
+}
+
+func decodePlain(t *testing.T, data []byte, typ physicalType, count int) []any {
+	values := make([]any, 0, count)
+	for i := 0; i < count; i++ {
+		switch typ {
+		case typeByteArray:
+			length := int(binary.LittleEndian.Uint32(data))
+			values = append(values, string(data[4:4+length]))
+			data = data[4+length:]
+		case typeInt64:
+			values = append(values, int64(binary.LittleEndian.Uint64(data))) //nolint:gosec
+			data = data[8:]
+		case typeDouble:
+			values = append(values, math.Float64frombits(binary.LittleEndian.Uint64(data)))
+			data = data[8:]
+		default:
+			t.Fatalf("unexpected type %d", typ)
+		}
+	}
+	return values
+}
+
+func decodeHybrid(t *testing.T, data []byte, width, count int) []int32 {
+	values := make([]int32, 0, count)
+	for len(values) < count {
+		header, n := binary.Uvarint(data)
+		require.Positive(t, n)
+		data = data[n:]
+		if header&1 == 0 {
+			var value int32
+			byteWidth := (width + 7) / 8
+			for b := 0; b < byteWidth; b++ {
+				value |= int32(data[b]) << (8 * b)
+			}
+			data = data[byteWidth:]
+			for i := uint64(0); i < header>>1; i++ {
+				values = append(values, value)
+			}
+			continue
+		}
+
+		groups := int(header >> 1) //nolint:gosec
+		for i := 0; i < groups*8; i++ {
+			var value int32
+			for b := 0; b < width; b++ {
+				bit := i*width + b
+				value |= int32(data[bit/8]>>(bit%8)&1) << b
+			}
+			values = append(values, value)
+		}
+		data = data[groups*width:]
+	}
+	return values[:count]
+}
@@ -0,0 +1,120 @@
+package parquet
+
+import "encoding/binary"
+
+// The types of the thrift compact protocol, which is used for serializing the
+// parquet metadata structures.
+const (
+	thriftBoolTrue  byte = 1
+	thriftBoolFalse byte = 2
+	thriftI32       byte = 5
+	thriftI64       byte = 6
+	thriftBinary    byte = 8
+	thriftList      byte = 9
+	thriftStruct    byte = 12
+)
+
+// thriftEncoder serializes structs with the thrift compact protocol. Only the
+// subset of the protocol used by the parquet metadata is supported.
+type thriftEncoder struct {
+	buf []byte
+
+	// lastField is the ID of the last written field of the current struct,
+	// the IDs of the outer structs are pushed to fieldStack
+	lastField  int16
+	fieldStack []int16
+}
+
+func (e *thriftEncoder) varint(v uint64) {
+	e.buf = binary.AppendUvarint(e.buf, v)
+}
+
+func (e *thriftEncoder) zigzag(v int64) {
+	e.varint(uint64((v << 1) ^ (v >> 63))) //nolint:gosec
+}
+
+func (e *thriftEncoder) fieldHeader(id int16, typ byte) {
+	if delta := id - e.lastField; delta > 0 && delta <= 15 {
+		e.buf = append(e.buf, byte(delta)<<4|typ)
+	} else {
+		e.buf = append(e.buf, typ)
+		e.zigzag(int64(id))
+	}
+	e.lastField = id
+}
+
+func (e *thriftEncoder) i32Field(id int16, v int32) {
+	e.fieldHeader(id, thriftI32)
+	e.zigzag(int64(v))
+}
+
+func (e *thriftEncoder) i64Field(id int16, v int64) {
+	e.fieldHeader(id, thriftI64)
+	e.zigzag(v)
+}
+
+func (e *thriftEncoder) boolField(id int16, v bool) {
+	if v {
+		e.fieldHeader(id, thriftBoolTrue)
+	} else {
+		e.fieldHeader(id, thriftBoolFalse)
+	}
+}
+
+func (e *thriftEncoder) stringField(id int16, v string) {
+	e.fieldHeader(id, thriftBinary)
+	e.string(v)
+}
+
+func (e *thriftEncoder) string(v string) {
+	e.varint(uint64(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+// structField writes a nested struct, whose fields are written by the given
+// function.
+func (e *thriftEncoder) structField(id int16, fields func()) {
+	e.fieldHeader(id, thriftStruct)
+	e.structValue(fields)
+}
+
+func (e *thriftEncoder) structValue(fields func()) {
+	e.fieldStack = append(e.fieldStack, e.lastField)
+	e.lastField = 0
+	fields()
+	e.buf = append(e.buf, 0) // the stop field
+	e.lastField = e.fieldStack[len(e.fieldStack)-1]
+	e.fieldStack = e.fieldStack[:len(e.fieldStack)-1]
+}
+
+// listField writes the header of a list with the given number of elements,
+// which should be written right after it.
+func (e *thriftEncoder) listField(id int16, elemType byte, size int) {
+	e.fieldHeader(id, thriftList)
+	if size < 15 {
+		e.buf = append(e.buf, byte(size)<<4|elemType)
+		return
+	}
+	e.buf = append(e.buf, 0xf0|elemType)
+	e.varint(uint64(size))
+}
+
+func (e *thriftEncoder) i32ListField(id int16, values []int32) {
+	e.listField(id, thriftI32, len(values))
+	for _, v := range values {
+		e.zigzag(int64(v))
+	}
+}
+
+func (e *thriftEncoder) stringListField(id int16, values []string) {
+	e.listField(id, thriftBinary, len(values))
+	for _, v := range values {
+		e.string(v)
+	}
+}
+
+// message serializes a whole top-level struct.
+func (e *thriftEncoder) message(fields func()) []byte {
+	e.structValue(fields)
+	return e.buf
+}
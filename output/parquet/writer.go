@@ -0,0 +1,234 @@
+package parquet
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+
+	"go.k6.io/k6/lib/consts"
+	"go.k6.io/k6/metrics"
+)
+
+// writer writes the samples as the rows of a parquet file. Up to a row group
+// of rows are buffered in memory, which are then written to the file as a
+// column chunk per column. The file metadata with the locations of all of the
+// row groups is written as the footer of the file when it's closed.
+type writer struct {
+	file         io.WriteCloser
+	buf          *bufio.Writer
+	out          *offsetWriter
+	codec        codec
+	rowGroupSize int
+
+	schema  []schemaElement
+	columns []*column
+
+	metricName     *column
+	metricType     *column
+	timestamp      *column
+	value          *column
+	indexedTags    []string
+	tagColumns     []*column
+	tagKeys        *column
+	tagValues      *column
+	metadataKeys   *column
+	metadataValues *column
+
+	bufferedRows int
+	numRows      int64
+	rowGroups    []rowGroup
+}
+
+func newWriter(file io.WriteCloser, codec codec, rowGroupSize int, indexedTags []string) (*writer, error) {
+	buf := bufio.NewWriter(file)
+	w := &writer{
+		file:         file,
+		buf:          buf,
+		out:          &offsetWriter{w: buf},
+		codec:        codec,
+		rowGroupSize: rowGroupSize,
+		indexedTags:  indexedTags,
+	}
+
+	w.schema = append(w.schema, schemaElement{Name: "k6", NumChildren: int32(6 + len(indexedTags))}) //nolint:gosec
+	w.metricName = w.addStringColumn(repetitionRequired, "metric_name")
+	w.metricType = w.addStringColumn(repetitionRequired, "metric_type")
+	w.timestamp = w.addColumn(schemaElement{
+		Name:       "timestamp",
+		Type:       ptr(typeInt64),
+		Repetition: ptr(repetitionRequired),
+		Converted:  ptr(convertedTimestampMicros),
+		Logical:    &logicalType{TimestampMicros: true},
+	}, 0, 0, "timestamp")
+	w.value = w.addColumn(schemaElement{
+		Name:       "value",
+		Type:       ptr(typeDouble),
+		Repetition: ptr(repetitionRequired),
+	}, 0, 0, "value")
+	for _, tag := range indexedTags {
+		w.tagColumns = append(w.tagColumns, w.addStringColumn(repetitionOptional, tag))
+	}
+	w.tagKeys, w.tagValues = w.addMapColumns("tags")
+	w.metadataKeys, w.metadataValues = w.addMapColumns("metadata")
+
+	if _, err := w.out.Write([]byte(magic)); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func (w *writer) addColumn(element schemaElement, maxDef, maxRep int, path ...string) *column {
+	w.schema = append(w.schema, element)
+	c := newColumn(*element.Type, maxDef, maxRep, path...)
+	w.columns = append(w.columns, c)
+	return c
+}
+
+func (w *writer) addStringColumn(repetition repetitionType, name string) *column {
+	maxDef := 0
+	if repetition == repetitionOptional {
+		maxDef = 1
+	}
+	return w.addColumn(stringSchemaElement(name, repetition), maxDef, 0, name)
+}
+
+// addMapColumns adds a MAP<STRING, STRING> column, which is stored as the
+// columns of the keys and of the values of a repeated group.
+func (w *writer) addMapColumns(name string) (keys, values *column) {
+	w.schema = append(w.schema,
+		schemaElement{
+			Name:        name,
+			Repetition:  ptr(repetitionRequired),
+			NumChildren: 1,
+			Converted:   ptr(convertedMap),
+			Logical:     &logicalType{Map: true},
+		},
+		schemaElement{Name: "key_value", Repetition: ptr(repetitionRepeated), NumChildren: 2},
+	)
+	keys = w.addColumn(stringSchemaElement("key", repetitionRequired), 1, 1, name, "key_value", "key")
+	values = w.addColumn(stringSchemaElement("value", repetitionRequired), 1, 1, name, "key_value", "value")
+	return keys, values
+}
+
+func stringSchemaElement(name string, repetition repetitionType) schemaElement {
+	return schemaElement{
+		Name:       name,
+		Type:       ptr(typeByteArray),
+		Repetition: ptr(repetition),
+		Converted:  ptr(convertedUTF8),
+		Logical:    &logicalType{String: true},
+	}
+}
+
+// writeSample adds the sample as a row of the current row group, which is
+// written to the file once it has enough rows.
+func (w *writer) writeSample(sample metrics.Sample) error {
+	w.metricName.addString(sample.Metric.Name, 0, 0)
+	w.metricType.addString(sample.Metric.Type.String(), 0, 0)
+	w.timestamp.addInt64(sample.Time.UnixMicro())
+	w.value.addDouble(sample.Value)
+
+	tags := sample.Tags.Map()
+	for i, tag := range w.indexedTags {
+		if value, ok := tags[tag]; ok {
+			w.tagColumns[i].addString(value, 1, 0)
+			delete(tags, tag)
+		} else {
+			w.tagColumns[i].addNull(0, 0)
+		}
+	}
+	addMap(w.tagKeys, w.tagValues, tags)
+	addMap(w.metadataKeys, w.metadataValues, sample.Metadata)
+
+	w.bufferedRows++
+	if w.bufferedRows >= w.rowGroupSize {
+		return w.flushRowGroup()
+	}
+	return nil
+}
+
+func addMap(keys, values *column, m map[string]string) {
+	if len(m) == 0 {
+		keys.addNull(0, 0)
+		values.addNull(0, 0)
+		return
+	}
+
+	sortedKeys := make([]string, 0, len(m))
+	for key := range m {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+	for i, key := range sortedKeys {
+		rep := int32(1)
+		if i == 0 {
+			rep = 0
+		}
+		keys.addString(key, 1, rep)
+		values.addString(m[key], 1, rep)
+	}
+}
+
+// flushRowGroup writes the buffered rows as a row group.
+func (w *writer) flushRowGroup() error {
+	if w.bufferedRows == 0 {
+		return nil
+	}
+
+	group := rowGroup{NumRows: int64(w.bufferedRows)}
+	for _, c := range w.columns {
+		chunk, err := c.writeChunk(w.out, w.codec)
+		if err != nil {
+			return err
+		}
+		group.Columns = append(group.Columns, chunk)
+		group.TotalByteSize += chunk.MetaData.TotalUncompressedSize
+		group.TotalCompressedSize += chunk.MetaData.TotalCompressedSize
+		c.reset()
+	}
+	w.rowGroups = append(w.rowGroups, group)
+	w.numRows += group.NumRows
+	w.bufferedRows = 0
+
+	// Flush the buffered writer as well, so only the metadata of the written
+	// row groups stays in memory
+	return w.buf.Flush()
+}
+
+// close writes the remaining rows and the footer with the given key-value
+// metadata, and closes the file.
+func (w *writer) close(metadata []keyValue) error {
+	if err := w.flushRowGroup(); err != nil {
+		_ = w.file.Close()
+		return err
+	}
+
+	var e thriftEncoder
+	footer := e.message(func() {
+		fileMetaData{
+			Version:          2,
+			Schema:           w.schema,
+			NumRows:          w.numRows,
+			RowGroups:        w.rowGroups,
+			KeyValueMetadata: metadata,
+			CreatedBy:        fmt.Sprintf("k6 version %s", consts.Version),
+		}.encode(&e)
+	})
+	footer = binary.LittleEndian.AppendUint32(footer, uint32(len(footer))) //nolint:gosec
+	footer = append(footer, magic...)
+	if _, err := w.out.Write(footer); err != nil {
+		_ = w.file.Close()
+		return err
+	}
+	if err := w.buf.Flush(); err != nil {
+		_ = w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
@@ -114,6 +114,27 @@ func (pb *ProgressBar) Left() string {
 	return pb.renderLeft(0)
 }
 
+// Status returns the status of the progressbar in a thread-safe way.
+func (pb *ProgressBar) Status() Status {
+	pb.mutex.RLock()
+	defer pb.mutex.RUnlock()
+
+	return pb.status
+}
+
+// Progress returns the current progress, clamped between 0 and 1, and the
+// right part of the progressbar in a thread-safe way.
+func (pb *ProgressBar) Progress() (float64, []string) {
+	pb.mutex.RLock()
+	defer pb.mutex.RUnlock()
+
+	if pb.progress == nil {
+		return 0, nil
+	}
+	progress, right := pb.progress()
+	return Clampf(progress, 0, 1), right
+}
+
 // renderLeft renders the left part of the progressbar, replacing text
 // exceeding maxLen with an ellipsis.
 func (pb *ProgressBar) renderLeft(maxLen int) string {